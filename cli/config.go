@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"done-hub/common/logger"
+	"done-hub/model"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 口令通过环境变量传入，避免出现在命令历史中
+const configPassphraseEnv = "CONFIG_PASSPHRASE"
+
+// RunConfigCommand 处理配置导入导出参数，需要在数据库和配置项初始化之后调用
+func RunConfigCommand() {
+	if *exportConfig == "" && *applyConfig == "" {
+		return
+	}
+
+	var err error
+	if *exportConfig != "" {
+		err = exportConfigFile(*exportConfig)
+	} else {
+		err = applyConfigFile(*applyConfig)
+	}
+
+	if err != nil {
+		logger.SysError(err.Error())
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func exportConfigFile(path string) error {
+	bundle, err := model.ExportConfigBundle(*secretMode, os.Getenv(configPassphraseEnv))
+	if err != nil {
+		return err
+	}
+
+	format := "yaml"
+	if filepath.Ext(path) == ".json" {
+		format = "json"
+	}
+
+	data, err := model.EncodeConfigBundle(bundle, format)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+
+	logger.SysLog("Config exported to " + path)
+	return nil
+}
+
+func applyConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := model.DecodeConfigBundle(data)
+	if err != nil {
+		return err
+	}
+
+	changes, err := model.ApplyConfigBundle(bundle, &model.ConfigApplyOptions{
+		DryRun:     *dryRun,
+		Prune:      *prune,
+		Passphrase: os.Getenv(configPassphraseEnv),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		diff, _ := json.Marshal(change.Diff)
+		fmt.Printf("%-6s %-14s %s %s\n", change.Action, change.Kind, change.Name, diff)
+	}
+
+	if *dryRun {
+		logger.SysLog(fmt.Sprintf("Dry run, %d changes not applied", len(changes)))
+	} else {
+		logger.SysLog(fmt.Sprintf("Config applied, %d changes", len(changes)))
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"done-hub/common/logger"
+	"done-hub/common/secret"
+	"done-hub/model"
+	"errors"
+	"os"
+)
+
+// RunEncryptionCommand 使用当前主密钥重新加密所有密钥数据
+func RunEncryptionCommand() {
+	if !*rotateKey {
+		return
+	}
+
+	if err := rotateEncryptionKey(); err != nil {
+		logger.SysError("Failed to rotate encryption key: " + err.Error())
+		os.Exit(1)
+	}
+
+	logger.SysLog("Encryption key rotated")
+	os.Exit(0)
+}
+
+func rotateEncryptionKey() error {
+	if !secret.Enabled() {
+		return errors.New("encryption.key is not configured")
+	}
+
+	return model.EncryptSecrets(true)
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"done-hub/common/logger"
+	"done-hub/common/secret"
+	"done-hub/model"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setEncryptionKey(t *testing.T, key string, oldKeys string) {
+	viper.Set("encryption.key", key)
+	viper.Set("encryption.old_keys", oldKeys)
+	assert.Nil(t, secret.Init())
+}
+
+func TestRotateEncryptionKey(t *testing.T) {
+	logger.SetupLogger()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	sqlDB, err := db.DB()
+	assert.Nil(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	assert.Nil(t, db.AutoMigrate(&model.Channel{}, &model.Payment{}, &model.Option{}))
+
+	oldDB := model.DB
+	model.DB = db
+	t.Cleanup(func() {
+		model.DB = oldDB
+		setEncryptionKey(t, "", "")
+	})
+
+	// 未配置主密钥时不能轮换
+	setEncryptionKey(t, "", "")
+	assert.NotNil(t, rotateEncryptionKey())
+
+	setEncryptionKey(t, "old-key", "")
+	channel := &model.Channel{Name: "test", Key: "sk-test"}
+	assert.Nil(t, db.Create(channel).Error)
+
+	var before string
+	assert.Nil(t, db.Table("channels").Select("key").Where("id = ?", channel.Id).Scan(&before).Error)
+
+	setEncryptionKey(t, "new-key", "old-key")
+	assert.Nil(t, rotateEncryptionKey())
+
+	var after string
+	assert.Nil(t, db.Table("channels").Select("key").Where("id = ?", channel.Id).Scan(&after).Error)
+	assert.False(t, secret.IsCurrent(before))
+	assert.True(t, secret.IsCurrent(after))
+
+	// 轮换后只使用新的主密钥也能读取
+	setEncryptionKey(t, "new-key", "")
+	loaded, err := model.GetChannelById(channel.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "sk-test", loaded.Key)
+}
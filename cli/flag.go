@@ -17,6 +17,12 @@ var (
 	logDir       = flag.String("log-dir", "", "specify the log directory")
 	Config       = flag.String("config", "config.yaml", "specify the config.yaml path")
 	export       = flag.Bool("export", false, "Exports prices to a JSON file.")
+	exportConfig = flag.String("export-config", "", "Exports channels, groups, prices and options to a YAML/JSON file.")
+	applyConfig  = flag.String("apply-config", "", "Applies a YAML/JSON config file to the database.")
+	dryRun       = flag.Bool("dry-run", false, "Only print the changes of --apply-config.")
+	prune        = flag.Bool("prune", false, "Delete items missing from the config file when using --apply-config.")
+	secretMode   = flag.String("secrets", "mask", "How to export secrets: mask, encrypt or plain.")
+	rotateKey    = flag.Bool("rotate-encryption-key", false, "Re-encrypt all secrets with the current encryption key.")
 )
 
 func InitCli() {
@@ -62,4 +68,9 @@ func help() {
 	fmt.Println("Original copyright holder: JustSong")
 	fmt.Println("GitHub: https://github.com/deanxv/done-hub")
 	fmt.Println("Usage: done-hub [--port <port>] [--log-dir <log directory>] [--config <config.yaml path>] [--version] [--help]")
+	fmt.Println("       done-hub --export-config <file> [--secrets mask|encrypt|plain]")
+	fmt.Println("       done-hub --apply-config <file> [--dry-run] [--prune]")
+	fmt.Println("Set CONFIG_PASSPHRASE to encrypt or decrypt secrets.")
+	fmt.Println("       done-hub --rotate-encryption-key")
+	fmt.Println("Set ENCRYPTION_KEY to the new key and ENCRYPTION_OLD_KEYS to the previous keys before rotating.")
 }
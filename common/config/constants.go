@@ -195,6 +195,14 @@ var UnifiedRequestResponseModelEnabled = false
 var DefaultChannelWeight = uint(1)
 var RetryCooldownSeconds = 5
 
+// 请求 json_schema 时校验输出，不符合时重新请求的次数
+var StructuredOutputEnabled = true
+var StructuredOutputMaxRetries = 2
+
+// 脚本每次执行的最大步数和超时时间（毫秒）
+var ScriptMaxSteps = 1000000
+var ScriptTimeout = 100
+
 var CFWorkerImageUrl = ""
 var CFWorkerImageKey = ""
 
@@ -219,6 +227,40 @@ var GeminiAPIEnabled = true
 // Claude
 var ClaudeAPIEnabled = true
 
+// Realtime 桥接（渠道不支持原生 Realtime 时使用的转录、语音合成模型）
+var RealtimeBridgeTranscriptionModel = "whisper-1"
+var RealtimeBridgeSpeechModel = "tts-1"
+
+// 自部署推理服务健康检查（间隔秒数，0 为关闭）以及排队请求数上限（0 为不限制）
+var LocalChannelHealthCheckInterval = 15
+var LocalChannelMaxQueueDepth = 16
+
+// 熔断器：统计窗口内请求数达到下限且错误率超过阈值时熔断，熔断时长按连续熔断次数翻倍
+var CircuitBreakerEnabled = false
+var CircuitBreakerWindowSeconds = 60
+var CircuitBreakerMinRequests = 10
+var CircuitBreakerErrorRate = 0.5
+var CircuitBreakerOpenSeconds = 60
+var CircuitBreakerHalfOpenRatio = 0.1
+var CircuitBreakerHalfOpenSuccesses = 3
+
+// 定时模型真实性检测（间隔分钟数），检测到可疑时是否自动禁用渠道
+var ChannelFingerprintCheckEnabled = false
+var ChannelFingerprintCheckInterval = 1440
+var ChannelFingerprintAutoDisable = false
+
+// 渠道指纹检测记录保留天数，0 为永久保留
+var ChannelCheckLogRetentionDays = 30
+
+// 渠道质量基准测试得分是否参与负载均衡权重计算
+var ChannelBenchmarkWeightEnabled = false
+
+// 管理员必须开启两步验证才能访问管理接口
+var AdminRequireTwoFactor = false
+
+// 管理员操作审计记录保留天数，0 为永久保留
+var AuditLogRetentionDays = 180
+
 const (
 	RoleGuestUser  = 0
 	RoleCommonUser = 1
@@ -310,6 +352,12 @@ const (
 	ChannelTypeAzureDatabricks = 54
 	ChannelTypeAzureV1         = 55
 	ChannelTypeXAI             = 56
+	ChannelTypeElevenLabs      = 57
+	ChannelTypeVLLM            = 58
+	ChannelTypeSGLang          = 59
+	ChannelTypeLlamaCpp        = 60
+	ChannelTypeLMStudio        = 61
+	ChannelTypeTGI             = 62
 )
 
 const (
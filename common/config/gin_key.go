@@ -1,5 +1,6 @@
 package config
 
 const (
-	GinRequestBodyKey = "cached_request_body"
+	GinRequestBodyKey   = "cached_request_body"
+	GinAuditRecordedKey = "audit_recorded"
 )
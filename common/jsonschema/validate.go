@@ -0,0 +1,408 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 只实现结构化输出常用的关键字，不支持远程 $ref
+
+type validator struct {
+	root map[string]any
+}
+
+// Validate 校验 JSON 值是否符合 schema，schema 和 value 均为 json.Unmarshal 得到的数据
+func Validate(schema any, value any) error {
+	root, _ := schema.(map[string]any)
+	v := &validator{root: root}
+	return v.validate(schema, value, "$", 0)
+}
+
+// ValidateJSON 解析 JSON 文本后校验
+func ValidateJSON(schema any, data string) error {
+	var value any
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return fmt.Errorf("invalid json: %s", err.Error())
+	}
+	return Validate(schema, value)
+}
+
+// ParseSchema 将任意类型的 schema 转换为 json.Unmarshal 得到的数据
+func ParseSchema(schema any) (any, error) {
+	if schema == nil {
+		return nil, nil
+	}
+
+	var data []byte
+	switch s := schema.(type) {
+	case string:
+		data = []byte(s)
+	case []byte:
+		data = s
+	default:
+		var err error
+		if data, err = json.Marshal(schema); err != nil {
+			return nil, err
+		}
+	}
+
+	var parsed any
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+
+	switch parsed.(type) {
+	case map[string]any, bool:
+		return parsed, nil
+	}
+	return nil, fmt.Errorf("schema must be an object")
+}
+
+func (v *validator) validate(schema any, value any, path string, depth int) error {
+	if depth > 64 {
+		return fmt.Errorf("%s: schema nesting too deep", path)
+	}
+
+	switch s := schema.(type) {
+	case nil:
+		return nil
+	case bool:
+		if !s {
+			return fmt.Errorf("%s: value is not allowed", path)
+		}
+		return nil
+	case map[string]any:
+		return v.validateObject(s, value, path, depth)
+	}
+
+	return fmt.Errorf("%s: invalid schema", path)
+}
+
+func (v *validator) validateObject(schema map[string]any, value any, path string, depth int) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := v.resolveRef(ref)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err.Error())
+		}
+		if err := v.validate(target, value, path, depth+1); err != nil {
+			return err
+		}
+	}
+
+	if types, ok := schema["type"]; ok {
+		if err := checkType(types, value, path); err != nil {
+			return err
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		matched := false
+		for _, item := range enum {
+			if equal(item, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: value is not one of the allowed values", path)
+		}
+	}
+
+	if constValue, ok := schema["const"]; ok && !equal(constValue, value) {
+		return fmt.Errorf("%s: value must be %v", path, constValue)
+	}
+
+	if err := v.validateCombinators(schema, value, path, depth); err != nil {
+		return err
+	}
+
+	switch val := value.(type) {
+	case string:
+		return validateString(schema, val, path)
+	case float64:
+		return validateNumber(schema, val, path)
+	case []any:
+		return v.validateArray(schema, val, path, depth)
+	case map[string]any:
+		return v.validateProperties(schema, val, path, depth)
+	}
+
+	return nil
+}
+
+func (v *validator) validateCombinators(schema map[string]any, value any, path string, depth int) error {
+	if allOf, ok := schema["allOf"].([]any); ok {
+		for _, sub := range allOf {
+			if err := v.validate(sub, value, path, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if v.validate(sub, value, path, depth+1) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: value does not match any schema in anyOf", path)
+		}
+	}
+
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		count := 0
+		for _, sub := range oneOf {
+			if v.validate(sub, value, path, depth+1) == nil {
+				count++
+			}
+		}
+		if count != 1 {
+			return fmt.Errorf("%s: value must match exactly one schema in oneOf", path)
+		}
+	}
+
+	if not, ok := schema["not"]; ok {
+		if v.validate(not, value, path, depth+1) == nil {
+			return fmt.Errorf("%s: value must not match the schema in not", path)
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) validateProperties(schema map[string]any, value map[string]any, path string, depth int) error {
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, exists := value[key]; !exists {
+				return fmt.Errorf("%s: missing required property %q", path, key)
+			}
+		}
+	}
+
+	if min, ok := toInt(schema["minProperties"]); ok && len(value) < min {
+		return fmt.Errorf("%s: must have at least %d properties", path, min)
+	}
+	if max, ok := toInt(schema["maxProperties"]); ok && len(value) > max {
+		return fmt.Errorf("%s: must have at most %d properties", path, max)
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	additional, hasAdditional := schema["additionalProperties"]
+
+	for key, item := range value {
+		itemPath := path + "." + key
+		if sub, ok := properties[key]; ok {
+			if err := v.validate(sub, item, itemPath, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				return fmt.Errorf("%s: additional property %q is not allowed", path, key)
+			}
+			if err := v.validate(additional, item, itemPath, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) validateArray(schema map[string]any, value []any, path string, depth int) error {
+	if min, ok := toInt(schema["minItems"]); ok && len(value) < min {
+		return fmt.Errorf("%s: must have at least %d items", path, min)
+	}
+	if max, ok := toInt(schema["maxItems"]); ok && len(value) > max {
+		return fmt.Errorf("%s: must have at most %d items", path, max)
+	}
+
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+		for i := range value {
+			for j := i + 1; j < len(value); j++ {
+				if equal(value[i], value[j]) {
+					return fmt.Errorf("%s: items must be unique", path)
+				}
+			}
+		}
+	}
+
+	prefix, _ := schema["prefixItems"].([]any)
+	for i, item := range value {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if i < len(prefix) {
+			if err := v.validate(prefix[i], item, itemPath, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if items, ok := schema["items"]; ok {
+			if err := v.validate(items, item, itemPath, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateString(schema map[string]any, value string, path string) error {
+	length := utf8.RuneCountInString(value)
+	if min, ok := toInt(schema["minLength"]); ok && length < min {
+		return fmt.Errorf("%s: length must be at least %d", path, min)
+	}
+	if max, ok := toInt(schema["maxLength"]); ok && length > max {
+		return fmt.Errorf("%s: length must be at most %d", path, max)
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern %q", path, pattern)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%s: value does not match pattern %q", path, pattern)
+		}
+	}
+
+	return nil
+}
+
+func validateNumber(schema map[string]any, value float64, path string) error {
+	if min, ok := schema["minimum"].(float64); ok && value < min {
+		return fmt.Errorf("%s: must be >= %v", path, min)
+	}
+	if max, ok := schema["maximum"].(float64); ok && value > max {
+		return fmt.Errorf("%s: must be <= %v", path, max)
+	}
+	if min, ok := schema["exclusiveMinimum"].(float64); ok && value <= min {
+		return fmt.Errorf("%s: must be > %v", path, min)
+	}
+	if max, ok := schema["exclusiveMaximum"].(float64); ok && value >= max {
+		return fmt.Errorf("%s: must be < %v", path, max)
+	}
+	if multiple, ok := schema["multipleOf"].(float64); ok && multiple > 0 {
+		quotient := value / multiple
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			return fmt.Errorf("%s: must be a multiple of %v", path, multiple)
+		}
+	}
+
+	return nil
+}
+
+func checkType(types any, value any, path string) error {
+	var names []string
+	switch t := types.(type) {
+	case string:
+		names = []string{t}
+	case []any:
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	default:
+		return nil
+	}
+
+	for _, name := range names {
+		if isType(name, value) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(names, " or "), typeName(value))
+}
+
+func isType(name string, value any) bool {
+	switch name {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// resolveRef 只支持当前文档内的引用，如 #/$defs/item
+func (v *validator) resolveRef(ref string) (any, error) {
+	if ref == "#" {
+		return v.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+
+	var current any = v.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("$ref %q not found", ref)
+		}
+		if current, ok = object[part]; !ok {
+			return nil, fmt.Errorf("$ref %q not found", ref)
+		}
+	}
+
+	return current, nil
+}
+
+func toInt(value any) (int, bool) {
+	n, ok := value.(float64)
+	if !ok {
+		return 0, false
+	}
+	return int(n), true
+}
+
+func equal(a, b any) bool {
+	return reflect.DeepEqual(a, b)
+}
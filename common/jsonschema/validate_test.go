@@ -0,0 +1,129 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		data   string
+		valid  bool
+	}{
+		{"type string", `{"type":"string"}`, `"a"`, true},
+		{"type mismatch", `{"type":"string"}`, `1`, false},
+		{"type union", `{"type":["string","null"]}`, `null`, true},
+		{"integer", `{"type":"integer"}`, `2`, true},
+		{"integer with fraction", `{"type":"integer"}`, `2.5`, false},
+		{"boolean schema true", `true`, `{"a":1}`, true},
+		{"boolean schema false", `{"properties":{"a":false}}`, `{"a":1}`, false},
+		{"invalid json", `{"type":"object"}`, `{"a":`, false},
+
+		{"enum", `{"enum":["a","b"]}`, `"b"`, true},
+		{"enum mismatch", `{"enum":["a","b"]}`, `"c"`, false},
+		{"const", `{"const":{"a":1}}`, `{"a":1}`, true},
+		{"const mismatch", `{"const":1}`, `2`, false},
+
+		{"required", `{"type":"object","required":["a"]}`, `{"a":1}`, true},
+		{"required missing", `{"type":"object","required":["a"]}`, `{"b":1}`, false},
+		{"nested property", `{"properties":{"a":{"properties":{"b":{"type":"number"}}}}}`, `{"a":{"b":"x"}}`, false},
+		{"additional properties false", `{"properties":{"a":{}},"additionalProperties":false}`, `{"a":1,"b":2}`, false},
+		{"additional properties schema", `{"additionalProperties":{"type":"number"}}`, `{"a":1,"b":2}`, true},
+		{"additional properties schema mismatch", `{"additionalProperties":{"type":"number"}}`, `{"a":"x"}`, false},
+		{"min properties", `{"minProperties":2}`, `{"a":1}`, false},
+		{"max properties", `{"maxProperties":1}`, `{"a":1,"b":2}`, false},
+
+		{"items", `{"type":"array","items":{"type":"string"}}`, `["a","b"]`, true},
+		{"items mismatch", `{"type":"array","items":{"type":"string"}}`, `["a",1]`, false},
+		{"prefix items", `{"prefixItems":[{"type":"string"}],"items":{"type":"number"}}`, `["a",1,2]`, true},
+		{"prefix items mismatch", `{"prefixItems":[{"type":"string"}],"items":{"type":"number"}}`, `["a","b"]`, false},
+		{"min items", `{"minItems":2}`, `[1]`, false},
+		{"max items", `{"maxItems":1}`, `[1,2]`, false},
+		{"unique items", `{"uniqueItems":true}`, `[1,{"a":1},{"a":1}]`, false},
+
+		{"min length", `{"minLength":2}`, `"中"`, false},
+		{"max length counts runes", `{"maxLength":2}`, `"中文"`, true},
+		{"pattern", `{"pattern":"^[a-z]+$"}`, `"abc"`, true},
+		{"pattern mismatch", `{"pattern":"^[a-z]+$"}`, `"abc1"`, false},
+		{"invalid pattern", `{"pattern":"("}`, `"abc"`, false},
+
+		{"minimum", `{"minimum":1}`, `1`, true},
+		{"minimum mismatch", `{"minimum":1}`, `0.5`, false},
+		{"maximum", `{"maximum":1}`, `2`, false},
+		{"exclusive minimum", `{"exclusiveMinimum":1}`, `1`, false},
+		{"exclusive maximum", `{"exclusiveMaximum":1}`, `1`, false},
+		{"multiple of", `{"multipleOf":0.1}`, `0.3`, true},
+		{"multiple of mismatch", `{"multipleOf":2}`, `3`, false},
+
+		{"all of", `{"allOf":[{"type":"number"},{"minimum":1}]}`, `0`, false},
+		{"any of", `{"anyOf":[{"type":"string"},{"type":"number"}]}`, `1`, true},
+		{"any of mismatch", `{"anyOf":[{"type":"string"},{"type":"number"}]}`, `true`, false},
+		{"one of", `{"oneOf":[{"type":"integer"},{"type":"string"}]}`, `1`, true},
+		{"one of matches both", `{"oneOf":[{"type":"integer"},{"type":"number"}]}`, `1`, false},
+		{"not", `{"not":{"type":"null"}}`, `null`, false},
+
+		{"ref", `{"$defs":{"item":{"type":"string"}},"type":"array","items":{"$ref":"#/$defs/item"}}`, `["a"]`, true},
+		{"ref mismatch", `{"$defs":{"item":{"type":"string"}},"type":"array","items":{"$ref":"#/$defs/item"}}`, `[1]`, false},
+		{"recursive ref", `{"type":"object","properties":{"child":{"$ref":"#"},"name":{"type":"string"}}}`, `{"name":"a","child":{"name":1}}`, false},
+		{"ref not found", `{"$ref":"#/$defs/missing"}`, `1`, false},
+		{"remote ref", `{"$ref":"https://example.com/schema.json"}`, `1`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := ParseSchema(tt.schema)
+			assert.Nil(t, err)
+
+			err = ValidateJSON(schema, tt.data)
+			if tt.valid {
+				assert.Nil(t, err)
+			} else {
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateErrorPath(t *testing.T) {
+	schema, err := ParseSchema(`{"properties":{"items":{"type":"array","items":{"required":["id"]}}}}`)
+	assert.Nil(t, err)
+
+	err = ValidateJSON(schema, `{"items":[{"id":1},{"name":"a"}]}`)
+	assert.EqualError(t, err, `$.items[1]: missing required property "id"`)
+}
+
+func TestValidateDepthLimit(t *testing.T) {
+	schema, err := ParseSchema(`{"$ref":"#"}`)
+	assert.Nil(t, err)
+	assert.NotNil(t, Validate(schema, 1))
+}
+
+func TestParseSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema any
+		valid  bool
+	}{
+		{"nil", nil, true},
+		{"string", `{"type":"object"}`, true},
+		{"bytes", []byte(`{"type":"object"}`), true},
+		{"map", map[string]any{"type": "object"}, true},
+		{"bool", true, true},
+		{"array", `[1]`, false},
+		{"invalid json", `{`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchema(tt.schema)
+			if tt.valid {
+				assert.Nil(t, err)
+			} else {
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
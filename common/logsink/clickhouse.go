@@ -0,0 +1,69 @@
+package logsink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ClickHouse 通过 HTTP 接口以 JSONEachRow 格式写入，表中不存在的字段会被忽略
+type ClickHouse struct {
+	url      string
+	user     string
+	password string
+	client   *http.Client
+}
+
+func NewClickHouse(baseUrl, table, user, password string) *ClickHouse {
+	params := url.Values{}
+	params.Set("query", fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", table))
+	params.Set("input_format_skip_unknown_fields", "1")
+
+	return &ClickHouse{
+		url:      strings.TrimSuffix(baseUrl, "/") + "/?" + params.Encode(),
+		user:     user,
+		password: password,
+		client:   &http.Client{Timeout: sinkTimeout},
+	}
+}
+
+func (ch *ClickHouse) Name() string {
+	return "clickhouse"
+}
+
+func (ch *ClickHouse) Write(records []json.RawMessage) error {
+	var body bytes.Buffer
+	for _, record := range records {
+		body.Write(record)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ch.url, &body)
+	if err != nil {
+		return err
+	}
+	if ch.user != "" {
+		req.Header.Set("X-ClickHouse-User", ch.user)
+		req.Header.Set("X-ClickHouse-Key", ch.password)
+	}
+
+	resp, err := ch.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status code %d: %s", resp.StatusCode, string(message))
+	}
+	return nil
+}
+
+func (ch *ClickHouse) Close() error {
+	return nil
+}
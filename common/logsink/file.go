@@ -0,0 +1,49 @@
+package logsink
+
+import (
+	"done-hub/common/utils"
+	"encoding/json"
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// File 按行写入 JSON，超过大小后切割
+type File struct {
+	mu     sync.Mutex
+	writer *lumberjack.Logger
+}
+
+func NewFile(path string) *File {
+	return &File{
+		writer: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    utils.GetOrDefault("log_writer.file.max_size", 100),  // 单个文件的最大大小(MB)
+			MaxAge:     utils.GetOrDefault("log_writer.file.max_age", 30),    // 保留旧文件的最大天数
+			MaxBackups: utils.GetOrDefault("log_writer.file.max_backup", 10), // 保留旧文件的最大个数
+			Compress:   utils.GetOrDefault("log_writer.file.compress", false),
+		},
+	}
+}
+
+func (f *File) Name() string {
+	return "file"
+}
+
+func (f *File) Write(records []json.RawMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data := make([]byte, 0, len(records)*256)
+	for _, record := range records {
+		data = append(data, record...)
+		data = append(data, '\n')
+	}
+
+	_, err := f.writer.Write(data)
+	return err
+}
+
+func (f *File) Close() error {
+	return f.writer.Close()
+}
@@ -0,0 +1,69 @@
+package logsink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Kafka 通过 REST Proxy 写入 Kafka 兼容的消息流，Confluent REST Proxy 和 Redpanda 的 HTTP Proxy 都支持该接口
+type Kafka struct {
+	url    string
+	client *http.Client
+}
+
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Value json.RawMessage `json:"value"`
+}
+
+func NewKafka(baseUrl, topic string) *Kafka {
+	return &Kafka{
+		url:    fmt.Sprintf("%s/topics/%s", strings.TrimSuffix(baseUrl, "/"), topic),
+		client: &http.Client{Timeout: sinkTimeout},
+	}
+}
+
+func (k *Kafka) Name() string {
+	return "kafka"
+}
+
+func (k *Kafka) Write(records []json.RawMessage) error {
+	body := kafkaRecords{Records: make([]kafkaRecord, 0, len(records))}
+	for _, record := range records {
+		body.Records = append(body.Records, kafkaRecord{Value: record})
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, k.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status code %d: %s", resp.StatusCode, string(message))
+	}
+	return nil
+}
+
+func (k *Kafka) Close() error {
+	return nil
+}
@@ -0,0 +1,53 @@
+package logsink
+
+import (
+	"done-hub/common/logger"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Sink 消费日志的额外输出，数据库之外的备份或分析
+type Sink interface {
+	Name() string
+	// Write 写入一批日志，每条为一个 JSON 对象
+	Write(records []json.RawMessage) error
+	Close() error
+}
+
+const sinkTimeout = 10 * time.Second
+
+// InitSinks 根据配置创建所有开启的输出
+func InitSinks() []Sink {
+	var sinks []Sink
+
+	if path := viper.GetString("log_writer.file.path"); path != "" {
+		sinks = append(sinks, NewFile(path))
+	}
+
+	if url := viper.GetString("log_writer.kafka.url"); url != "" {
+		topic := viper.GetString("log_writer.kafka.topic")
+		if topic == "" {
+			logger.SysError("log sink kafka: topic is empty")
+		} else {
+			sinks = append(sinks, NewKafka(url, topic))
+		}
+	}
+
+	if url := viper.GetString("log_writer.clickhouse.url"); url != "" {
+		table := viper.GetString("log_writer.clickhouse.table")
+		if table == "" {
+			logger.SysError("log sink clickhouse: table is empty")
+		} else {
+			sinks = append(sinks, NewClickHouse(url, table, viper.GetString("log_writer.clickhouse.user"), viper.GetString("log_writer.clickhouse.password")))
+		}
+	}
+
+	for _, sink := range sinks {
+		logger.SysLog(fmt.Sprintf("log sink %s enabled", sink.Name()))
+	}
+
+	return sinks
+}
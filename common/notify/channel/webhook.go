@@ -0,0 +1,92 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"done-hub/common/utils"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// Webhook 以 JSON 格式推送到用户填写的地址，只允许访问公网
+type Webhook struct {
+	url string
+}
+
+type webhookMessage struct {
+	Title     string `json:"title"`
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// webhookClient 不跟随跳转，连接时检查解析后的地址，防止 DNS 重绑定访问内网
+var webhookClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: webhookDialControl,
+		}).DialContext,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+	Timeout: 30 * time.Second,
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		url: url,
+	}
+}
+
+func (w *Webhook) Name() string {
+	return "Webhook"
+}
+
+func (w *Webhook) Send(ctx context.Context, title, message string) error {
+	msg := webhookMessage{
+		Title:     title,
+		Message:   message,
+		Timestamp: time.Now().Unix(),
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook response status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !utils.IsPublicIP(ip) {
+		return fmt.Errorf("webhook address %s is not allowed", host)
+	}
+	return nil
+}
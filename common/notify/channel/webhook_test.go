@@ -0,0 +1,60 @@
+package channel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookDialControl(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"8.8.8.8:443", true},
+		{"[2606:4700:4700::1111]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"10.0.0.1:80", false},
+		{"172.16.0.1:80", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false},
+		{"0.0.0.0:80", false},
+		{"[fd00::1]:80", false},
+		{"[fe80::1]:80", false},
+		{"224.0.0.1:80", false},
+		{"localhost:80", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := webhookDialControl("tcp", tt.address, nil)
+			if tt.allowed {
+				assert.Nil(t, err)
+			} else {
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func TestWebhookSendBlocksPrivateAddress(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	err := NewWebhook(server.URL).Send(context.Background(), "title", "message")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "is not allowed")
+	assert.False(t, called)
+}
+
+func TestWebhookClientNoRedirect(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	assert.ErrorIs(t, webhookClient.CheckRedirect(req, []*http.Request{req}), http.ErrUseLastResponse)
+}
@@ -0,0 +1,59 @@
+package requester
+
+import (
+	"errors"
+	"io"
+)
+
+// ChunkProcessor 在流式数据返回给客户端之前逐块处理
+type ChunkProcessor interface {
+	// Process 处理单个数据块，返回 false 时丢弃该块
+	Process(data string) (string, bool)
+	// Finish 上游正常结束时调用，返回需要补发的数据
+	Finish() []string
+}
+
+type chunkStreamReader struct {
+	StreamReaderInterface[string]
+	processor ChunkProcessor
+}
+
+func NewChunkStreamReader(stream StreamReaderInterface[string], processor ChunkProcessor) StreamReaderInterface[string] {
+	return &chunkStreamReader{
+		StreamReaderInterface: stream,
+		processor:             processor,
+	}
+}
+
+func (s *chunkStreamReader) Recv() (<-chan string, <-chan error) {
+	dataChan, errChan := s.StreamReaderInterface.Recv()
+	outDataChan := make(chan string)
+	outErrChan := make(chan error)
+
+	go func() {
+		for {
+			select {
+			case data, ok := <-dataChan:
+				if !ok {
+					close(outDataChan)
+					return
+				}
+
+				if data, ok = s.processor.Process(data); ok {
+					outDataChan <- data
+				}
+			case err := <-errChan:
+				if errors.Is(err, io.EOF) {
+					for _, data := range s.processor.Finish() {
+						outDataChan <- data
+					}
+				}
+
+				outErrChan <- err
+				return
+			}
+		}
+	}()
+
+	return outDataChan, outErrChan
+}
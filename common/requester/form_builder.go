@@ -1,6 +1,7 @@
 package requester
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -69,3 +70,31 @@ func (fb *DefaultFormBuilder) Close() error {
 func (fb *DefaultFormBuilder) FormDataContentType() string {
 	return fb.writer.FormDataContentType()
 }
+
+// NewFileHeader 将内存中的数据包装为 multipart.FileHeader，用于在网关内部构造文件上传请求
+func NewFileHeader(fieldname, filename string, data []byte) (*multipart.FileHeader, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	fieldWriter, err := writer.CreateFormFile(fieldname, filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = fieldWriter.Write(data); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(int64(len(data)) + 1024)
+	if err != nil {
+		return nil, err
+	}
+
+	files := form.File[fieldname]
+	if len(files) == 0 {
+		return nil, fmt.Errorf("file %s not found", fieldname)
+	}
+
+	return files[0], nil
+}
@@ -1,6 +1,7 @@
 package requester
 
 import (
+	"bytes"
 	"fmt"
 	"time"
 
@@ -26,8 +27,12 @@ type MessageSource int
 const (
 	UserMessage MessageSource = iota
 	SupplierMessage
+	// SessionClosed 连接关闭后调用一次，消息为空，处理器返回尚未计费的用量
+	SessionClosed
 )
 
+// MessageHandler 处理双向消息，返回是否继续、用量、替换后的消息和错误
+// 替换后的消息为空切片(非nil)时丢弃该消息；包含多条JSON事件时使用换行分隔，会被拆分为多帧以文本消息发送
 type MessageHandler func(source MessageSource, messageType int, message []byte) (bool, *types.UsageEvent, []byte, error)
 type UsageHandler func(usage *types.UsageEvent) error
 
@@ -81,6 +86,7 @@ func (p *WSProxy) transfer(src, dst *websocket.Conn, source MessageSource, close
 			return
 		}
 
+		translated := false
 		if p.handler != nil {
 			shouldContinue, usage, newMessage, err := p.handler(source, messageType, message)
 			if err != nil {
@@ -96,12 +102,14 @@ func (p *WSProxy) transfer(src, dst *websocket.Conn, source MessageSource, close
 
 			if newMessage != nil {
 				message = newMessage
+				messageType = websocket.TextMessage
+				translated = true
 			}
 
 			if usage != nil && p.usageHandler != nil {
 				err := p.usageHandler(usage)
 				if err != nil {
-					dst.WriteMessage(websocket.TextMessage, message)
+					writeMessage(dst, messageType, message, translated)
 					errMsg := []byte(err.Error())
 					dst.WriteMessage(websocket.TextMessage, errMsg)
 					logger.SysError(fmt.Sprintf("source: %d, usageHandler error: %s", source, err.Error()))
@@ -110,10 +118,32 @@ func (p *WSProxy) transfer(src, dst *websocket.Conn, source MessageSource, close
 			}
 		}
 
-		err = dst.WriteMessage(messageType, message)
+		err = writeMessage(dst, messageType, message, translated)
 		if err != nil {
 			logger.SysError(fmt.Sprintf("source: %d, WriteMessage error: %s", source, err.Error()))
 			return
 		}
 	}
 }
+
+// 写入消息，经过处理器转换的消息中以换行分隔的多条事件会拆分为多帧发送
+func writeMessage(conn *websocket.Conn, messageType int, message []byte, translated bool) error {
+	if translated && len(message) == 0 {
+		return nil
+	}
+
+	if !translated || !bytes.Contains(message, []byte("\n")) {
+		return conn.WriteMessage(messageType, message)
+	}
+
+	for _, line := range bytes.Split(message, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if err := conn.WriteMessage(messageType, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
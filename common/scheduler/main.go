@@ -78,3 +78,17 @@ func (tm *TaskManager) GetJob(name string) *JobInfo {
 
 	return tm.jobs[name]
 }
+
+// 移除任务
+func (tm *TaskManager) RemoveJob(name string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	jobInfo, exists := tm.jobs[name]
+	if !exists {
+		return nil
+	}
+
+	delete(tm.jobs, name)
+	return tm.scheduler.RemoveJob(jobInfo.Job.ID())
+}
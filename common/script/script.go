@@ -0,0 +1,236 @@
+package script
+
+import (
+	"done-hub/common/logger"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// Budget 单次执行的资源限制，MaxSteps 为 Starlark 虚拟机执行步数
+type Budget struct {
+	MaxSteps uint64
+	Timeout  time.Duration
+}
+
+var ErrBudgetExceeded = errors.New("script budget exceeded")
+
+var fileOptions = &syntax.FileOptions{
+	Set:       true,
+	While:     true,
+	Recursion: false,
+}
+
+// Program 编译后的脚本，全局变量在初始化后被冻结，可以并发调用
+type Program struct {
+	name    string
+	globals starlark.StringDict
+}
+
+// Compile 编译并执行脚本的顶层代码，脚本中不允许 load 其它模块
+func Compile(name, code string, budget Budget) (*Program, error) {
+	thread := newThread(name, budget)
+	stop := watch(thread, budget)
+	defer stop()
+
+	globals, err := starlark.ExecFileOptions(fileOptions, thread, name, code, nil)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	globals.Freeze()
+
+	return &Program{name: name, globals: globals}, nil
+}
+
+func (p *Program) Name() string {
+	return p.name
+}
+
+// Has 脚本是否定义了指定的函数
+func (p *Program) Has(fn string) bool {
+	_, ok := p.globals[fn].(starlark.Callable)
+	return ok
+}
+
+// Call 调用脚本函数，参数和返回值均为 JSON 兼容的数据
+func (p *Program) Call(fn string, arg any, budget Budget) (any, error) {
+	callable, ok := p.globals[fn].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("function %s not defined", fn)
+	}
+
+	value, err := ToValue(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	thread := newThread(p.name, budget)
+	stop := watch(thread, budget)
+	defer stop()
+
+	result, err := starlark.Call(thread, callable, starlark.Tuple{value}, nil)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	return FromValue(result)
+}
+
+func newThread(name string, budget Budget) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, msg string) {
+			logger.SysLog(fmt.Sprintf("script %s: %s", name, msg))
+		},
+		Load: func(_ *starlark.Thread, module string) (starlark.StringDict, error) {
+			return nil, fmt.Errorf("load is not allowed: %s", module)
+		},
+	}
+	if budget.MaxSteps > 0 {
+		thread.SetMaxExecutionSteps(budget.MaxSteps)
+	}
+	return thread
+}
+
+func watch(thread *starlark.Thread, budget Budget) func() {
+	if budget.Timeout <= 0 {
+		return func() {}
+	}
+	timer := time.AfterFunc(budget.Timeout, func() {
+		thread.Cancel("timeout")
+	})
+	return func() { timer.Stop() }
+}
+
+func wrapError(err error) error {
+	if strings.Contains(err.Error(), "Starlark computation cancelled") {
+		return fmt.Errorf("%w: %s", ErrBudgetExceeded, err.Error())
+	}
+
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return errors.New(evalErr.Backtrace())
+	}
+	return err
+}
+
+// ToValue 将 Go 数据转换为 Starlark 值，结构体等类型先经过 JSON 转换
+func ToValue(v any) (starlark.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(val), nil
+	case string:
+		return starlark.String(val), nil
+	case int:
+		return starlark.MakeInt(val), nil
+	case int64:
+		return starlark.MakeInt64(val), nil
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) < 1<<53 {
+			return starlark.MakeInt64(int64(val)), nil
+		}
+		return starlark.Float(val), nil
+	case []any:
+		items := make([]starlark.Value, 0, len(val))
+		for _, item := range val {
+			value, err := ToValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return starlark.NewList(items), nil
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		dict := starlark.NewDict(len(val))
+		for _, key := range keys {
+			value, err := ToValue(val[key])
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(key), value); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return ToValue(generic)
+}
+
+// FromValue 将 Starlark 值转换为 JSON 兼容的 Go 数据
+func FromValue(v starlark.Value) (any, error) {
+	switch val := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(val), nil
+	case starlark.String:
+		return string(val), nil
+	case starlark.Int:
+		if n, ok := val.Int64(); ok {
+			return n, nil
+		}
+		return nil, fmt.Errorf("integer out of range: %s", val.String())
+	case starlark.Float:
+		return float64(val), nil
+	case *starlark.List:
+		return fromIterable(val, val.Len())
+	case starlark.Tuple:
+		return fromIterable(val, val.Len())
+	case *starlark.Dict:
+		result := make(map[string]any, val.Len())
+		for _, item := range val.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("dict key must be string, got %s", item[0].Type())
+			}
+			value, err := FromValue(item[1])
+			if err != nil {
+				return nil, err
+			}
+			result[string(key)] = value
+		}
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("unsupported value type: %s", v.Type())
+}
+
+func fromIterable(iterable starlark.Iterable, size int) ([]any, error) {
+	result := make([]any, 0, size)
+	iter := iterable.Iterate()
+	defer iter.Done()
+
+	var item starlark.Value
+	for iter.Next(&item) {
+		value, err := FromValue(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
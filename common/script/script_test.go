@@ -0,0 +1,132 @@
+package script_test
+
+import (
+	"done-hub/common/logger"
+	"done-hub/common/script"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logger.SetupLogger()
+}
+
+func TestCallStepBudget(t *testing.T) {
+	code := `
+def on_request(ctx):
+    n = 0
+    while True:
+        n += 1
+    return n
+`
+	program, err := script.Compile("loop", code, script.Budget{})
+	assert.Nil(t, err)
+
+	_, err = program.Call("on_request", map[string]any{}, script.Budget{MaxSteps: 1000})
+	assert.True(t, errors.Is(err, script.ErrBudgetExceeded))
+}
+
+func TestCallTimeout(t *testing.T) {
+	code := `
+def on_request(ctx):
+    while True:
+        pass
+`
+	program, err := script.Compile("timeout", code, script.Budget{})
+	assert.Nil(t, err)
+
+	start := time.Now()
+	_, err = program.Call("on_request", nil, script.Budget{Timeout: 50 * time.Millisecond})
+	assert.True(t, errors.Is(err, script.ErrBudgetExceeded))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestCompileStepBudget(t *testing.T) {
+	code := `
+def spin():
+    while True:
+        pass
+
+spin()
+`
+	_, err := script.Compile("top", code, script.Budget{MaxSteps: 1000})
+	assert.True(t, errors.Is(err, script.ErrBudgetExceeded))
+}
+
+func TestCallWithinBudget(t *testing.T) {
+	code := `
+def on_billing(ctx):
+    return ctx["quota"] * 2
+`
+	program, err := script.Compile("billing", code, script.Budget{MaxSteps: 1000, Timeout: time.Second})
+	assert.Nil(t, err)
+	assert.True(t, program.Has("on_billing"))
+	assert.False(t, program.Has("on_request"))
+
+	result, err := program.Call("on_billing", map[string]any{"quota": 21}, script.Budget{MaxSteps: 1000, Timeout: time.Second})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), result)
+}
+
+func TestCallUndefined(t *testing.T) {
+	program, err := script.Compile("empty", "x = 1", script.Budget{})
+	assert.Nil(t, err)
+
+	_, err = program.Call("on_request", nil, script.Budget{})
+	assert.NotNil(t, err)
+}
+
+func TestCompileLoadNotAllowed(t *testing.T) {
+	_, err := script.Compile("load", `load("other.star", "x")`, script.Budget{})
+	assert.NotNil(t, err)
+}
+
+func TestValueRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input any
+		want  any
+	}{
+		{"nil", nil, nil},
+		{"bool", true, true},
+		{"string", "hello", "hello"},
+		{"int", 42, int64(42)},
+		{"int64", int64(-7), int64(-7)},
+		{"integral float", 3.0, int64(3)},
+		{"float", 1.5, 1.5},
+		{"list", []any{1, "a", false}, []any{int64(1), "a", false}},
+		{"map", map[string]any{
+			"model":  "gpt-4o",
+			"quota":  100,
+			"ratio":  0.5,
+			"stream": true,
+			"tags":   []any{"a", "b"},
+			"nested": map[string]any{"x": 1},
+		}, map[string]any{
+			"model":  "gpt-4o",
+			"quota":  int64(100),
+			"ratio":  0.5,
+			"stream": true,
+			"tags":   []any{"a", "b"},
+			"nested": map[string]any{"x": int64(1)},
+		}},
+		{"struct", struct {
+			Name  string `json:"name"`
+			Count int    `json:"count"`
+		}{"a", 2}, map[string]any{"name": "a", "count": int64(2)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := script.ToValue(tt.input)
+			assert.Nil(t, err)
+
+			got, err := script.FromValue(value)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
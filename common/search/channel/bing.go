@@ -0,0 +1,69 @@
+package channel
+
+import (
+	"done-hub/common/requester"
+	"done-hub/common/search/search_type"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const bingUri = "https://api.bing.microsoft.com/v7.0/search"
+
+type BingResponse struct {
+	WebPages struct {
+		Value []BingResult `json:"value"`
+	} `json:"webPages"`
+}
+
+type BingResult struct {
+	Name    string `json:"name"`
+	Url     string `json:"url"`
+	Snippet string `json:"snippet"`
+}
+
+type Bing struct {
+	apiKey string
+}
+
+func NewBing(apiKey string) *Bing {
+	return &Bing{
+		apiKey: apiKey,
+	}
+}
+
+func (b *Bing) Name() string {
+	return "bing"
+}
+
+func (b *Bing) Query(query string) (*search_type.SearchResponses, error) {
+	queryUrl := fmt.Sprintf("%s?q=%s&count=%d", bingUri, url.QueryEscape(query), search_type.MaxResults)
+
+	client := requester.NewHTTPRequester("", nil)
+	client.IsOpenAI = false
+
+	headers := requester.GetJsonHeaders()
+	headers["Ocp-Apim-Subscription-Key"] = b.apiKey
+
+	req, err := client.NewRequest(http.MethodGet, queryUrl, client.WithHeader(headers))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp BingResponse
+	_, opErr := client.SendRequest(req, &resp, true)
+	if opErr != nil {
+		return nil, opErr
+	}
+
+	responses := &search_type.SearchResponses{}
+	for _, result := range resp.WebPages.Value {
+		responses.Results = append(responses.Results, search_type.SearchResult{
+			Title:   result.Name,
+			Content: result.Snippet,
+			Url:     result.Url,
+		})
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,69 @@
+package channel
+
+import (
+	"done-hub/common/requester"
+	"done-hub/common/search/search_type"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const braveUri = "https://api.search.brave.com/res/v1/web/search"
+
+type BraveResponse struct {
+	Web struct {
+		Results []BraveResult `json:"results"`
+	} `json:"web"`
+}
+
+type BraveResult struct {
+	Title       string `json:"title"`
+	Url         string `json:"url"`
+	Description string `json:"description"`
+}
+
+type Brave struct {
+	apiKey string
+}
+
+func NewBrave(apiKey string) *Brave {
+	return &Brave{
+		apiKey: apiKey,
+	}
+}
+
+func (b *Brave) Name() string {
+	return "brave"
+}
+
+func (b *Brave) Query(query string) (*search_type.SearchResponses, error) {
+	queryUrl := fmt.Sprintf("%s?q=%s&count=%d", braveUri, url.QueryEscape(query), search_type.MaxResults)
+
+	client := requester.NewHTTPRequester("", nil)
+	client.IsOpenAI = false
+
+	headers := requester.GetJsonHeaders()
+	headers["X-Subscription-Token"] = b.apiKey
+
+	req, err := client.NewRequest(http.MethodGet, queryUrl, client.WithHeader(headers))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp BraveResponse
+	_, opErr := client.SendRequest(req, &resp, true)
+	if opErr != nil {
+		return nil, opErr
+	}
+
+	responses := &search_type.SearchResponses{}
+	for _, result := range resp.Web.Results {
+		responses.Results = append(responses.Results, search_type.SearchResult{
+			Title:   result.Title,
+			Content: result.Description,
+			Url:     result.Url,
+		})
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,85 @@
+package channel
+
+import (
+	"done-hub/common/requester"
+	"done-hub/common/search/search_type"
+	"net/http"
+)
+
+const exaUri = "https://api.exa.ai/search"
+
+type ExaRequest struct {
+	Query      string      `json:"query"`
+	NumResults int         `json:"numResults,omitempty"`
+	Contents   ExaContents `json:"contents"`
+}
+
+type ExaContents struct {
+	Text ExaTextOptions `json:"text"`
+}
+
+type ExaTextOptions struct {
+	MaxCharacters int `json:"maxCharacters,omitempty"`
+}
+
+type ExaResponse struct {
+	Results []ExaResult `json:"results"`
+}
+
+type ExaResult struct {
+	Title string `json:"title"`
+	Url   string `json:"url"`
+	Text  string `json:"text"`
+}
+
+type Exa struct {
+	apiKey string
+}
+
+func NewExa(apiKey string) *Exa {
+	return &Exa{
+		apiKey: apiKey,
+	}
+}
+
+func (e *Exa) Name() string {
+	return "exa"
+}
+
+func (e *Exa) Query(query string) (*search_type.SearchResponses, error) {
+	request := &ExaRequest{
+		Query:      query,
+		NumResults: search_type.MaxResults,
+		Contents: ExaContents{
+			Text: ExaTextOptions{MaxCharacters: 1000},
+		},
+	}
+
+	client := requester.NewHTTPRequester("", nil)
+	client.IsOpenAI = false
+
+	headers := requester.GetJsonHeaders()
+	headers["x-api-key"] = e.apiKey
+
+	req, err := client.NewRequest(http.MethodPost, exaUri, client.WithHeader(headers), client.WithBody(request))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp ExaResponse
+	_, opErr := client.SendRequest(req, &resp, true)
+	if opErr != nil {
+		return nil, opErr
+	}
+
+	responses := &search_type.SearchResponses{}
+	for _, result := range resp.Results {
+		responses.Results = append(responses.Results, search_type.SearchResult{
+			Title:   result.Title,
+			Content: result.Text,
+			Url:     result.Url,
+		})
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,72 @@
+package channel
+
+import (
+	"done-hub/common/requester"
+	"done-hub/common/search/search_type"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const googleUri = "https://www.googleapis.com/customsearch/v1"
+
+type GoogleResponse struct {
+	Items []GoogleResult `json:"items"`
+}
+
+type GoogleResult struct {
+	Title   string `json:"title"`
+	Link    string `json:"link"`
+	Snippet string `json:"snippet"`
+}
+
+// Google 可编程搜索引擎，cx 为搜索引擎 ID
+type Google struct {
+	apiKey string
+	cx     string
+}
+
+func NewGoogle(apiKey, cx string) *Google {
+	return &Google{
+		apiKey: apiKey,
+		cx:     cx,
+	}
+}
+
+func (g *Google) Name() string {
+	return "google"
+}
+
+func (g *Google) Query(query string) (*search_type.SearchResponses, error) {
+	params := url.Values{}
+	params.Set("key", g.apiKey)
+	params.Set("cx", g.cx)
+	params.Set("q", query)
+	params.Set("num", fmt.Sprintf("%d", search_type.MaxResults))
+	queryUrl := googleUri + "?" + params.Encode()
+
+	client := requester.NewHTTPRequester("", nil)
+	client.IsOpenAI = false
+
+	req, err := client.NewRequest(http.MethodGet, queryUrl, client.WithHeader(requester.GetJsonHeaders()))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp GoogleResponse
+	_, opErr := client.SendRequest(req, &resp, true)
+	if opErr != nil {
+		return nil, opErr
+	}
+
+	responses := &search_type.SearchResponses{}
+	for _, result := range resp.Items {
+		responses.Results = append(responses.Results, search_type.SearchResult{
+			Title:   result.Title,
+			Content: result.Snippet,
+			Url:     result.Link,
+		})
+	}
+
+	return responses, nil
+}
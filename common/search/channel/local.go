@@ -0,0 +1,31 @@
+package channel
+
+import (
+	"done-hub/common/search/search_type"
+	"fmt"
+	"net/url"
+)
+
+// Local 不请求外部服务，根据关键词生成固定的结果，用于测试
+type Local struct{}
+
+func NewLocal() *Local {
+	return &Local{}
+}
+
+func (l *Local) Name() string {
+	return "local"
+}
+
+func (l *Local) Query(query string) (*search_type.SearchResponses, error) {
+	responses := &search_type.SearchResponses{}
+	for i := 1; i <= 3; i++ {
+		responses.Results = append(responses.Results, search_type.SearchResult{
+			Title:   fmt.Sprintf("%s - result %d", query, i),
+			Content: fmt.Sprintf("Local search result %d for %q.", i, query),
+			Url:     fmt.Sprintf("https://example.com/search/%d?q=%s", i, url.QueryEscape(query)),
+		})
+	}
+
+	return responses, nil
+}
@@ -1,15 +1,18 @@
 package search
 
 import (
+	"done-hub/common/logger"
 	"done-hub/common/search/search_type"
 	"errors"
+	"fmt"
 )
 
 func (s *Search) query(query string) (*search_type.SearchResponses, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	for _, searcher := range s.searchers {
+	for _, name := range s.order {
+		searcher := s.searchers[name]
 		if searcher == nil {
 			continue
 		}
@@ -17,6 +20,7 @@ func (s *Search) query(query string) (*search_type.SearchResponses, error) {
 		if err == nil {
 			return responses, nil
 		}
+		logger.SysError(fmt.Sprintf("search %s query failed: %s", name, err.Error()))
 	}
 
 	return nil, errors.New("no searcher found")
@@ -6,6 +6,7 @@ var searchChannels = New()
 
 type Search struct {
 	searchers map[string]Searcher
+	order     []string // 按添加顺序依次尝试
 	enable    bool
 	mu        sync.RWMutex
 }
@@ -15,6 +16,9 @@ func (s *Search) addSearcher(searcher Searcher) {
 		s.mu.Lock()
 		defer s.mu.Unlock()
 		searcherName := searcher.Name()
+		if _, ok := s.searchers[searcherName]; !ok {
+			s.order = append(s.order, searcherName)
+		}
 		s.searchers[searcherName] = searcher
 		s.enable = true
 	}
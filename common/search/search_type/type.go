@@ -2,6 +2,9 @@ package search_type
 
 import "fmt"
 
+// MaxResults 每次搜索返回的结果数量
+const MaxResults = 5
+
 type SearchResponses struct {
 	Results []SearchResult
 }
@@ -16,6 +16,11 @@ type Searcher interface {
 func InitSearcher() {
 	InitSearxng()
 	InitTavily()
+	InitBing()
+	InitBrave()
+	InitGoogle()
+	InitExa()
+	InitLocal()
 }
 
 func InitSearxng() {
@@ -39,3 +44,49 @@ func InitTavily() {
 	tavily := channel.NewTavily(tavilyKey)
 	AddSearchers(tavily)
 }
+
+func InitBing() {
+	bingKey := viper.GetString("search.bing.key")
+	if bingKey == "" {
+		return
+	}
+
+	AddSearchers(channel.NewBing(bingKey))
+}
+
+func InitBrave() {
+	braveKey := viper.GetString("search.brave.key")
+	if braveKey == "" {
+		return
+	}
+
+	AddSearchers(channel.NewBrave(braveKey))
+}
+
+func InitGoogle() {
+	googleKey := viper.GetString("search.google.key")
+	googleCx := viper.GetString("search.google.cx")
+	if googleKey == "" || googleCx == "" {
+		return
+	}
+
+	AddSearchers(channel.NewGoogle(googleKey, googleCx))
+}
+
+func InitExa() {
+	exaKey := viper.GetString("search.exa.key")
+	if exaKey == "" {
+		return
+	}
+
+	AddSearchers(channel.NewExa(exaKey))
+}
+
+// InitLocal 本地搜索只用于测试，需要显式开启
+func InitLocal() {
+	if !viper.GetBool("search.local.enable") {
+		return
+	}
+
+	AddSearchers(channel.NewLocal())
+}
@@ -0,0 +1,75 @@
+package secret
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// LocalKeyProvider 使用配置文件中的主密钥
+type LocalKeyProvider struct {
+	id  string
+	key []byte
+}
+
+// NewLocalKeyProvider 支持 32 字节的 base64/hex 密钥，其他内容通过 sha256 派生
+func NewLocalKeyProvider(masterKey string) *LocalKeyProvider {
+	key := decodeMasterKey(masterKey)
+	sum := sha256.Sum256(key)
+	return &LocalKeyProvider{
+		id:  hex.EncodeToString(sum[:4]),
+		key: key,
+	}
+}
+
+func decodeMasterKey(masterKey string) []byte {
+	if key, err := base64.StdEncoding.DecodeString(masterKey); err == nil && len(key) == 32 {
+		return key
+	}
+	if key, err := hex.DecodeString(masterKey); err == nil && len(key) == 32 {
+		return key
+	}
+
+	sum := sha256.Sum256([]byte(masterKey))
+	return sum[:]
+}
+
+func (p *LocalKeyProvider) KeyId() string {
+	return p.id
+}
+
+func (p *LocalKeyProvider) WrapKey(dataKey []byte) ([]byte, error) {
+	gcm, err := newGCM(p.key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+func (p *LocalKeyProvider) UnwrapKey(wrapped []byte) ([]byte, error) {
+	gcm, err := newGCM(p.key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, errors.New("invalid data key")
+	}
+
+	return gcm.Open(nil, wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():], nil)
+}
+
+func (p *LocalKeyProvider) hashKey() []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte("done-hub key hash"))
+	return mac.Sum(nil)
+}
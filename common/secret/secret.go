@@ -0,0 +1,236 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// 密文格式: encv1:<主密钥ID>:<加密后的数据密钥>:<nonce|密文>
+const valuePrefix = "encv1:"
+
+// KeyProvider 主密钥提供者，只负责加解密数据密钥，可以对接 KMS
+type KeyProvider interface {
+	KeyId() string
+	WrapKey(dataKey []byte) ([]byte, error)
+	UnwrapKey(wrapped []byte) ([]byte, error)
+}
+
+type dataKey struct {
+	plain   []byte
+	wrapped string
+}
+
+var (
+	mutex     sync.RWMutex
+	primary   KeyProvider
+	providers = make(map[string]KeyProvider)
+	hashKey   []byte
+	current   *dataKey
+
+	// 解密后的数据密钥缓存，避免每次都调用主密钥
+	dataKeyCache sync.Map
+)
+
+// Init 从配置读取主密钥，encryption.old_keys 用于解密轮换前的数据
+func Init() error {
+	key := viper.GetString("encryption.key")
+	if key == "" {
+		return SetKeyProvider(nil, nil)
+	}
+
+	local := NewLocalKeyProvider(key)
+	fallbacks := make([]KeyProvider, 0)
+	for _, oldKey := range strings.Split(viper.GetString("encryption.old_keys"), ",") {
+		oldKey = strings.TrimSpace(oldKey)
+		if oldKey != "" {
+			fallbacks = append(fallbacks, NewLocalKeyProvider(oldKey))
+		}
+	}
+
+	return SetKeyProvider(local, local.hashKey(), fallbacks...)
+}
+
+// SetKeyProvider 设置当前主密钥，hashKey 用于计算可检索的密钥哈希，provider 为 nil 时关闭加密
+func SetKeyProvider(provider KeyProvider, key []byte, fallbacks ...KeyProvider) error {
+	// 移除的主密钥解密出的数据密钥不能继续使用
+	defer dataKeyCache.Range(func(key, _ any) bool {
+		dataKeyCache.Delete(key)
+		return true
+	})
+
+	if provider == nil {
+		mutex.Lock()
+		defer mutex.Unlock()
+		primary, hashKey, current = nil, nil, nil
+		providers = make(map[string]KeyProvider)
+		return nil
+	}
+
+	plain := make([]byte, 32)
+	if _, err := rand.Read(plain); err != nil {
+		return err
+	}
+
+	wrapped, err := provider.WrapKey(plain)
+	if err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	primary = provider
+	hashKey = key
+	current = &dataKey{plain: plain, wrapped: base64.RawStdEncoding.EncodeToString(wrapped)}
+	providers = map[string]KeyProvider{provider.KeyId(): provider}
+	for _, fallback := range fallbacks {
+		if _, ok := providers[fallback.KeyId()]; !ok {
+			providers[fallback.KeyId()] = fallback
+		}
+	}
+
+	return nil
+}
+
+func Enabled() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return primary != nil
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, valuePrefix)
+}
+
+// IsCurrent 是否已使用当前主密钥加密
+func IsCurrent(value string) bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if primary == nil {
+		return !IsEncrypted(value)
+	}
+	return strings.HasPrefix(value, valuePrefix+primary.KeyId()+":")
+}
+
+// Encrypt 未配置主密钥时原样返回
+func Encrypt(plaintext string) (string, error) {
+	mutex.RLock()
+	provider, key := primary, current
+	mutex.RUnlock()
+
+	if provider == nil || plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+
+	gcm, err := newGCM(key.plain)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	data := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return fmt.Sprintf("%s%s:%s:%s", valuePrefix, provider.KeyId(), key.wrapped, base64.RawStdEncoding.EncodeToString(data)), nil
+}
+
+// Decrypt 非密文时原样返回，兼容加密前的数据
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, valuePrefix), ":", 3)
+	if len(parts) != 3 {
+		return "", errors.New("invalid encrypted value")
+	}
+
+	plainKey, err := unwrapDataKey(parts[0], parts[1])
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(plainKey)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted value")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// Hash 使用 HMAC 计算哈希，用于在密文字段上做等值查询
+func Hash(value string) string {
+	mutex.RLock()
+	key := hashKey
+	mutex.RUnlock()
+
+	if key == nil || value == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func unwrapDataKey(keyId, wrapped string) ([]byte, error) {
+	cacheKey := keyId + ":" + wrapped
+	if plain, ok := dataKeyCache.Load(cacheKey); ok {
+		return plain.([]byte), nil
+	}
+
+	mutex.RLock()
+	provider, ok := providers[keyId]
+	mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("encryption key %s not found", keyId)
+	}
+
+	data, err := base64.RawStdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := provider.UnwrapKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKeyCache.Store(cacheKey, plain)
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,100 @@
+package common
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 附录 B 的测试密钥
+var testTotpSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTotpCode(t *testing.T) {
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, totpCode(key, tt.unix/totpPeriod))
+	}
+}
+
+func TestValidateTotpCode(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	counter := now.Unix() / totpPeriod
+	key := []byte("12345678901234567890")
+
+	tests := []struct {
+		name        string
+		code        string
+		lastCounter int64
+		counter     int64
+		ok          bool
+	}{
+		{"current", totpCode(key, counter), 0, counter, true},
+		{"previous period", totpCode(key, counter-1), 0, counter - 1, true},
+		{"next period", totpCode(key, counter+1), 0, counter + 1, true},
+		{"outside skew", totpCode(key, counter-2), 0, 0, false},
+		{"outside skew ahead", totpCode(key, counter+2), 0, 0, false},
+		{"already used", totpCode(key, counter), counter, 0, false},
+		{"older than last used", totpCode(key, counter-1), counter, 0, false},
+		{"newer than last used", totpCode(key, counter+1), counter, counter + 1, true},
+		{"with spaces", " " + totpCode(key, counter) + " ", 0, counter, true},
+		{"wrong length", "12345", 0, 0, false},
+		{"wrong code", "000000", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, ok := validateTotpCodeAt(testTotpSecret, tt.code, tt.lastCounter, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.counter, matched)
+		})
+	}
+
+	// 小写密钥同样有效，无效的密钥不通过
+	_, ok := validateTotpCodeAt(strings.ToLower(testTotpSecret), totpCode(key, counter), 0, now)
+	assert.True(t, ok)
+	_, ok = validateTotpCodeAt("not base32!", totpCode(key, counter), 0, now)
+	assert.False(t, ok)
+}
+
+func TestGenerateTotpSecret(t *testing.T) {
+	secret, err := GenerateTotpSecret()
+	assert.Nil(t, err)
+	assert.Len(t, secret, 32)
+
+	code, err := GenerateTotpCode(secret, time.Now())
+	assert.Nil(t, err)
+	_, ok := ValidateTotpCode(secret, code, 0)
+	assert.True(t, ok)
+
+	url := TotpURL("Done Hub", "alice", secret)
+	assert.True(t, strings.HasPrefix(url, "otpauth://totp/Done%20Hub:alice?"))
+	assert.Contains(t, url, "secret="+secret)
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes := GenerateRecoveryCodes(10)
+	assert.Len(t, codes, 10)
+	assert.Len(t, hashes, 10)
+
+	for i, code := range codes {
+		assert.Len(t, code, 11)
+		assert.Equal(t, "-", code[5:6])
+		assert.Equal(t, hashes[i], HashRecoveryCode(code))
+		// 忽略大小写、空格和连字符
+		assert.Equal(t, hashes[i], HashRecoveryCode(" "+strings.ToUpper(strings.ReplaceAll(code, "-", ""))+" "))
+	}
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// PCM16ToWav 为单声道 16bit PCM 数据添加 WAV 头
+func PCM16ToWav(pcm []byte, sampleRate int) []byte {
+	const (
+		channels      = 1
+		bitsPerSample = 16
+	)
+	byteRate := sampleRate * channels * bitsPerSample / 8
+	blockAlign := channels * bitsPerSample / 8
+
+	buffer := bytes.NewBuffer(make([]byte, 0, 44+len(pcm)))
+	buffer.WriteString("RIFF")
+	binary.Write(buffer, binary.LittleEndian, uint32(36+len(pcm)))
+	buffer.WriteString("WAVE")
+	buffer.WriteString("fmt ")
+	binary.Write(buffer, binary.LittleEndian, uint32(16))
+	binary.Write(buffer, binary.LittleEndian, uint16(1))
+	binary.Write(buffer, binary.LittleEndian, uint16(channels))
+	binary.Write(buffer, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(buffer, binary.LittleEndian, uint32(byteRate))
+	binary.Write(buffer, binary.LittleEndian, uint16(blockAlign))
+	binary.Write(buffer, binary.LittleEndian, uint16(bitsPerSample))
+	buffer.WriteString("data")
+	binary.Write(buffer, binary.LittleEndian, uint32(len(pcm)))
+	buffer.Write(pcm)
+
+	return buffer.Bytes()
+}
+
+var mp3Bitrates = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+
+// GetAudioDuration 估算音频时长(秒)，目前支持 WAV 与 MP3(按首帧码率估算)，无法识别时返回 0
+func GetAudioDuration(data []byte) float64 {
+	if len(data) < 44 {
+		return 0
+	}
+
+	if string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE" {
+		return getWavDuration(data)
+	}
+
+	return getMp3Duration(data)
+}
+
+func getWavDuration(data []byte) float64 {
+	var byteRate uint32
+	offset := 12
+	for offset+8 <= len(data) {
+		chunkId := string(data[offset : offset+4])
+		chunkSize := binary.LittleEndian.Uint32(data[offset+4 : offset+8])
+		switch chunkId {
+		case "fmt ":
+			if offset+20 <= len(data) {
+				byteRate = binary.LittleEndian.Uint32(data[offset+16 : offset+20])
+			}
+		case "data":
+			if byteRate == 0 {
+				return 0
+			}
+			size := int(chunkSize)
+			if size > len(data)-offset-8 || size <= 0 {
+				size = len(data) - offset - 8
+			}
+			return float64(size) / float64(byteRate)
+		}
+		offset += 8 + int(chunkSize) + int(chunkSize%2)
+	}
+
+	return 0
+}
+
+func getMp3Duration(data []byte) float64 {
+	offset := 0
+	// 跳过 ID3v2 标签
+	if string(data[0:3]) == "ID3" && len(data) > 10 {
+		size := int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f)
+		offset = 10 + size
+	}
+
+	for ; offset+4 <= len(data); offset++ {
+		// 只处理 MPEG-1 Layer III 帧
+		if data[offset] != 0xff || data[offset+1]&0xfe != 0xfa {
+			continue
+		}
+		bitrate := mp3Bitrates[data[offset+2]>>4]
+		if bitrate == 0 {
+			continue
+		}
+		return float64(len(data)-offset) * 8 / float64(bitrate*1000)
+	}
+
+	return 0
+}
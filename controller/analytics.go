@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"done-hub/common"
 	"done-hub/model"
 	"fmt"
 	"net/http"
@@ -71,6 +72,26 @@ func GetStatisticsByPeriod(c *gin.Context) {
 	})
 }
 
+// GetMarginStatisticsByPeriod 收入、上游成本和毛利，group_type 支持 channel、model、day
+func GetMarginStatisticsByPeriod(c *gin.Context) {
+	startTimestamp, _ := strconv.ParseInt(c.Query("start_timestamp"), 10, 64)
+	endTimestamp, _ := strconv.ParseInt(c.Query("end_timestamp"), 10, 64)
+
+	startDate := time.Unix(startTimestamp, 0).Format("2006-01-02")
+	endDate := time.Unix(endTimestamp, 0).Format("2006-01-02")
+	statistics, err := model.GetMarginStatisticsByPeriod(startDate, endDate, c.Query("group_type"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    statistics,
+	})
+}
+
 type StatisticsDetail struct {
 	UserStatistics      *model.StatisticsUser         `json:"user_statistics"`
 	ChannelStatistics   []*model.ChannelStatistics    `json:"channel_statistics"`
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/model"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordAudit 记录管理员操作，before/after 为变更前后的对象
+func recordAudit(c *gin.Context, entityType string, entityId any, action string, before, after any) {
+	c.Set(config.GinAuditRecordedKey, true)
+	model.RecordAuditLog(&model.AuditLog{
+		UserId:     c.GetInt("id"),
+		Username:   c.GetString("username"),
+		Ip:         c.ClientIP(),
+		Action:     action,
+		EntityType: entityType,
+		EntityId:   fmt.Sprintf("%v", entityId),
+		Method:     c.Request.Method,
+		Path:       c.FullPath(),
+	}, before, after)
+}
+
+func GetAuditLogsList(c *gin.Context) {
+	var params model.SearchAuditLogsParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	logs, err := model.GetAuditLogsList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    logs,
+	})
+}
@@ -0,0 +1,364 @@
+package controller
+
+import (
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/common/scheduler"
+	"done-hub/common/utils"
+	"done-hub/controller/check_channel"
+	"done-hub/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-co-op/gocron/v2"
+)
+
+// 同一时间只运行一个基准测试任务，避免对上游造成压力
+var channelBenchmarkLock sync.Mutex
+
+func GetChannelBenchmarks(c *gin.Context) {
+	var params model.SearchChannelBenchmarksParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	benchmarks, err := model.GetChannelBenchmarksList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    benchmarks,
+	})
+}
+
+type channelBenchmarkTrendRequest struct {
+	ChannelId int    `form:"channel_id" binding:"required"`
+	Model     string `form:"model"`
+	Days      int    `form:"days"`
+}
+
+func GetChannelBenchmarkTrend(c *gin.Context) {
+	var params channelBenchmarkTrendRequest
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if params.Days == 0 {
+		params.Days = 30
+	}
+
+	trends, err := model.GetChannelBenchmarkTrend(params.ChannelId, params.Model, params.Days)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    trends,
+	})
+}
+
+// GetChannelBenchmarkChanges 对比本周期和上一周期的成功率
+func GetChannelBenchmarkChanges(c *gin.Context) {
+	var params channelBenchmarkTrendRequest
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if params.Days == 0 {
+		params.Days = 7
+	}
+
+	changes, err := model.GetChannelBenchmarkChanges(params.ChannelId, params.Model, params.Days)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    changes,
+	})
+}
+
+type runChannelBenchmarkRequest struct {
+	ChannelId int    `json:"channel_id"`
+	Tag       string `json:"tag"`
+	Models    string `json:"models"`
+}
+
+func RunChannelBenchmark(c *gin.Context) {
+	var params runChannelBenchmarkRequest
+	if err := c.ShouldBindJSON(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if !channelBenchmarkLock.TryLock() {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("基准测试正在运行中"))
+		return
+	}
+
+	go func() {
+		defer channelBenchmarkLock.Unlock()
+		if params.ChannelId > 0 {
+			channel, err := model.GetChannelById(params.ChannelId)
+			if err != nil {
+				logger.SysError("channel benchmark error: " + err.Error())
+				return
+			}
+			benchmarkChannels([]*model.Channel{channel}, params.Tag, params.Models)
+			return
+		}
+
+		runChannelBenchmarkByTag(params.Tag, params.Models)
+	}()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+func GetChannelBenchmarkSchedules(c *gin.Context) {
+	schedules, err := model.GetChannelBenchmarkSchedules()
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    schedules,
+	})
+}
+
+func AddChannelBenchmarkSchedule(c *gin.Context) {
+	schedule := &model.ChannelBenchmarkSchedule{}
+	if err := c.ShouldBindJSON(schedule); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	schedule.Id = 0
+
+	if err := validateChannelBenchmarkCron(schedule.Cron); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := schedule.Insert(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	applyChannelBenchmarkSchedule(schedule)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    schedule,
+	})
+}
+
+func UpdateChannelBenchmarkSchedule(c *gin.Context) {
+	schedule := &model.ChannelBenchmarkSchedule{}
+	if err := c.ShouldBindJSON(schedule); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if _, err := model.GetChannelBenchmarkScheduleById(schedule.Id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := validateChannelBenchmarkCron(schedule.Cron); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := schedule.Update(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	applyChannelBenchmarkSchedule(schedule)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    schedule,
+	})
+}
+
+func DeleteChannelBenchmarkSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	schedule, err := model.GetChannelBenchmarkScheduleById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := schedule.Delete(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	scheduler.Manager.RemoveJob(channelBenchmarkJobName(schedule.Id))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+// InitChannelBenchmarkSchedules 启动时注册所有启用的基准测试计划，仅在主节点运行
+func InitChannelBenchmarkSchedules() {
+	if !config.IsMasterNode {
+		return
+	}
+
+	schedules, err := model.GetChannelBenchmarkSchedules()
+	if err != nil {
+		logger.SysError("load channel benchmark schedules error: " + err.Error())
+		return
+	}
+
+	for _, schedule := range schedules {
+		applyChannelBenchmarkSchedule(schedule)
+	}
+}
+
+func channelBenchmarkJobName(id int) string {
+	return fmt.Sprintf("channel_benchmark:%d", id)
+}
+
+func validateChannelBenchmarkCron(cron string) error {
+	if strings.TrimSpace(cron) == "" {
+		return errors.New("cron 表达式不能为空")
+	}
+
+	s, err := gocron.NewScheduler()
+	if err != nil {
+		return err
+	}
+	defer s.Shutdown()
+
+	if _, err := s.NewJob(gocron.CronJob(cron, false), gocron.NewTask(func() {})); err != nil {
+		return fmt.Errorf("cron 表达式错误: %v", err)
+	}
+
+	return nil
+}
+
+func applyChannelBenchmarkSchedule(schedule *model.ChannelBenchmarkSchedule) {
+	if !config.IsMasterNode {
+		return
+	}
+
+	name := channelBenchmarkJobName(schedule.Id)
+	if !schedule.Enabled {
+		scheduler.Manager.RemoveJob(name)
+		return
+	}
+
+	tag := schedule.Tag
+	models := schedule.Models
+	err := scheduler.Manager.AddJob(
+		name,
+		gocron.CronJob(schedule.Cron, false),
+		gocron.NewTask(func() {
+			if !channelBenchmarkLock.TryLock() {
+				logger.SysLog("channel benchmark is running, skip schedule " + name)
+				return
+			}
+			defer channelBenchmarkLock.Unlock()
+			runChannelBenchmarkByTag(tag, models)
+		}),
+	)
+	if err != nil {
+		logger.SysError("add channel benchmark schedule error: " + err.Error())
+	}
+}
+
+func runChannelBenchmarkByTag(tag, models string) {
+	var channels []*model.Channel
+	var err error
+	if tag == "" {
+		channels, err = model.GetAllChannels()
+	} else {
+		channels, err = model.GetChannelsByTag(tag)
+	}
+	if err != nil {
+		logger.SysError("get channels error: " + err.Error())
+		return
+	}
+
+	benchmarkChannels(channels, tag, models)
+}
+
+func benchmarkChannels(channels []*model.Channel, tag, models string) {
+	logger.SysLog("channel benchmark started")
+	for _, channel := range channels {
+		if channel.Status != config.ChannelStatusEnabled {
+			continue
+		}
+
+		benchmarkModels := getChannelBenchmarkModels(channel, models)
+		if benchmarkModels == "" {
+			continue
+		}
+		time.Sleep(config.RequestInterval)
+
+		ck, err := check_channel.CreateCheckChannel(channel.Id, benchmarkModels)
+		if err != nil {
+			logger.SysError(fmt.Sprintf("channel #%d benchmark error: %s", channel.Id, err.Error()))
+			continue
+		}
+
+		if err := model.BatchInsertChannelBenchmarks(ck.RunBenchmark(tag)); err != nil {
+			logger.SysError("save channel benchmark error: " + err.Error())
+		}
+	}
+
+	model.ChannelGroup.LoadBenchmarkScores()
+	logger.SysLog("channel benchmark finished")
+}
+
+// 计划中指定了模型时只测试渠道支持的模型，否则使用渠道的测速模型
+func getChannelBenchmarkModels(channel *model.Channel, models string) string {
+	if models == "" {
+		return channel.TestModel
+	}
+
+	channelModels := strings.Split(channel.Models, ",")
+	benchmarkModels := make([]string, 0)
+	for _, modelName := range strings.Split(models, ",") {
+		modelName = strings.TrimSpace(modelName)
+		if modelName != "" && utils.Contains(modelName, channelModels) {
+			benchmarkModels = append(benchmarkModels, modelName)
+		}
+	}
+
+	return strings.Join(benchmarkModels, ",")
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/model"
+	"done-hub/providers"
+	providers_base "done-hub/providers/base"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 自部署推理服务的渠道类型
+var selfHostedChannelTypes = []int{
+	config.ChannelTypeVLLM,
+	config.ChannelTypeSGLang,
+	config.ChannelTypeLlamaCpp,
+	config.ChannelTypeLMStudio,
+	config.ChannelTypeTGI,
+}
+
+// AutomaticallyCheckChannelHealth 定时检查自部署推理服务的健康状态和排队情况
+func AutomaticallyCheckChannelHealth() {
+	for {
+		interval := config.LocalChannelHealthCheckInterval
+		if interval <= 0 {
+			time.Sleep(time.Minute)
+			continue
+		}
+
+		time.Sleep(time.Duration(interval) * time.Second)
+		checkChannelsHealth()
+	}
+}
+
+func checkChannelsHealth() {
+	for _, channel := range model.ChannelGroup.GetChannelsByTypes(selfHostedChannelTypes) {
+		provider := providers.GetProvider(channel, nil)
+		checker, ok := provider.(providers_base.HealthCheckInterface)
+		if !ok {
+			continue
+		}
+
+		health := checker.CheckHealth()
+		if !health.Healthy {
+			logger.SysError(fmt.Sprintf("channel #%d(%s) health check failed: %s", channel.Id, channel.Name, health.Message))
+		}
+		model.ChannelGroup.SetHealth(channel.Id, health)
+	}
+}
+
+// AutomaticallyProbeCircuitBreakers 对半开状态的熔断器发起探测请求，避免没有流量时无法恢复
+func AutomaticallyProbeCircuitBreakers() {
+	for {
+		time.Sleep(15 * time.Second)
+		for _, target := range model.ChannelGroup.CircuitProbeTargets() {
+			probeCircuitBreaker(target)
+		}
+	}
+}
+
+func probeCircuitBreaker(target model.CircuitBreakerState) {
+	channel := model.ChannelGroup.GetChannel(target.ChannelId)
+	if channel == nil {
+		model.ChannelGroup.ResetCircuitBreakers(target.ChannelId)
+		return
+	}
+
+	// 复制一份，避免测试过程修改到缓存中的渠道
+	probeChannel := *channel
+	openaiErr, err := testChannel(&probeChannel, target.Model)
+	if openaiErr != nil {
+		model.ChannelGroup.RecordCircuitFailure(target.ChannelId, target.Model, openaiErr.Message, false)
+		return
+	}
+
+	// 不支持测试的模型只能依靠线上流量恢复
+	if err != nil {
+		logger.SysLog(fmt.Sprintf("channel #%d model %s circuit probe skipped: %s", target.ChannelId, target.Model, err.Error()))
+		return
+	}
+
+	model.ChannelGroup.RecordCircuitSuccess(target.ChannelId, target.Model)
+}
+
+func ResetChannelCircuitBreakers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	model.ChannelGroup.ResetCircuitBreakers(id)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+// 填充渠道的运行时状态
+func fillChannelsRuntimeState(channels []*model.Channel) {
+	for _, channel := range channels {
+		channel.Health = model.ChannelGroup.GetHealth(channel.Id)
+		if breakers := model.ChannelGroup.GetCircuitBreakers(channel.Id); len(breakers) > 0 {
+			channel.CircuitBreakers = breakers
+		}
+	}
+}
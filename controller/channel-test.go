@@ -599,6 +599,7 @@ func recordTestLog(channel *model.Channel, modelName string, startTime time.Time
 		modelName,
 		tokenName, // 流式请求使用"test"，非流式请求为空
 		0,         // quota为0，因为是测试
+		0,
 		content,
 		int(time.Since(startTime).Milliseconds()),
 		isStream,
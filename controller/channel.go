@@ -4,6 +4,7 @@ import (
 	"done-hub/common"
 	"done-hub/common/utils"
 	"done-hub/model"
+	"done-hub/relay/plugin"
 	"errors"
 	"net/http"
 	"strconv"
@@ -24,6 +25,9 @@ func GetChannelsList(c *gin.Context) {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
+	if channels.Data != nil {
+		fillChannelsRuntimeState(*channels.Data)
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -65,6 +69,14 @@ func AddChannel(c *gin.Context) {
 		})
 		return
 	}
+	if err = channel.ValidateCost(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err = plugin.Validate(channel.GetPluginPipeline()); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
 	channel.CreatedTime = utils.GetTimestamp()
 	keys := strings.Split(channel.Key, "\n")
 
@@ -99,6 +111,9 @@ func AddChannel(c *gin.Context) {
 		})
 		return
 	}
+	for i := range channels {
+		recordAudit(c, "channel", channels[i].Id, model.AuditActionCreate, nil, &channels[i])
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -107,6 +122,7 @@ func AddChannel(c *gin.Context) {
 
 func DeleteChannel(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
+	before, _ := model.GetChannelById(id)
 	channel := model.Channel{Id: id}
 	err := channel.Delete()
 	if err != nil {
@@ -116,6 +132,7 @@ func DeleteChannel(c *gin.Context) {
 		})
 		return
 	}
+	recordAudit(c, "channel", id, model.AuditActionDelete, before, nil)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -164,6 +181,15 @@ func UpdateChannel(c *gin.Context) {
 		})
 		return
 	}
+	if err = channel.ValidateCost(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err = plugin.Validate(channel.GetPluginPipeline()); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	before, _ := model.GetChannelById(channel.Id)
 	if channel.Models == "" {
 		err = channel.Update(false)
 	} else {
@@ -176,6 +202,9 @@ func UpdateChannel(c *gin.Context) {
 		})
 		return
 	}
+	if after, err := model.GetChannelById(channel.Id); err == nil {
+		recordAudit(c, "channel", channel.Id, model.AuditActionUpdate, before, after)
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
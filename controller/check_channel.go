@@ -2,10 +2,17 @@ package controller
 
 import (
 	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/common/notify"
 	"done-hub/common/requester"
+	"done-hub/common/utils"
 	"done-hub/controller/check_channel"
+	"done-hub/model"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -99,3 +106,80 @@ func CheckChannel(c *gin.Context) {
 		}
 	})
 }
+
+func GetChannelCheckLogs(c *gin.Context) {
+	var params model.SearchChannelCheckLogsParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	logs, err := model.GetChannelCheckLogsList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    logs,
+	})
+}
+
+// AutomaticallyCheckChannelFingerprint 定时对所有启用渠道的测速模型做真实性检测
+func AutomaticallyCheckChannelFingerprint() {
+	var lastRunTime time.Time
+	for {
+		time.Sleep(time.Minute)
+		if !config.ChannelFingerprintCheckEnabled || config.ChannelFingerprintCheckInterval <= 0 {
+			continue
+		}
+
+		if time.Since(lastRunTime) < time.Duration(config.ChannelFingerprintCheckInterval)*time.Minute {
+			continue
+		}
+		lastRunTime = time.Now()
+
+		logger.SysLog("checking channel fingerprint")
+		checkAllChannelsFingerprint()
+		logger.SysLog("channel fingerprint check finished")
+	}
+}
+
+func checkAllChannelsFingerprint() {
+	channels, err := model.GetAllChannels()
+	if err != nil {
+		logger.SysError("get channels error: " + err.Error())
+		return
+	}
+
+	var sendMessage strings.Builder
+	for _, channel := range channels {
+		if channel.Status != config.ChannelStatusEnabled || channel.TestModel == "" {
+			continue
+		}
+		time.Sleep(config.RequestInterval)
+
+		ck, err := check_channel.CreateCheckChannel(channel.Id, channel.TestModel)
+		if err != nil {
+			continue
+		}
+
+		for _, checkLog := range ck.RunFingerprint() {
+			if !checkLog.Suspicious {
+				continue
+			}
+
+			sendMessage.WriteString(fmt.Sprintf("**通道 %s - #%d** : 模型 %s 疑似被替换，返回模型 %s\n\n", utils.EscapeMarkdownText(channel.Name), channel.Id, checkLog.Model, checkLog.ResponseModel))
+			if config.ChannelFingerprintAutoDisable {
+				DisableChannel(channel.Id, channel.Name, fmt.Sprintf("模型 %s 真实性检测未通过", checkLog.Model), false)
+				sendMessage.WriteString("- 已被禁用\n\n")
+			}
+		}
+	}
+
+	if sendMessage.Len() > 0 {
+		notify.Send("模型真实性检测发现可疑渠道", sendMessage.String())
+	}
+}
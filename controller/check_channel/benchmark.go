@@ -0,0 +1,105 @@
+package check_channel
+
+import (
+	"done-hub/model"
+	"errors"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const benchmarkStreamTimeout = 60 * time.Second
+
+// RunBenchmark 执行全部检测项并测量延迟和首字时间，用于定时基准测试
+func (c *CheckChannel) RunBenchmark(tag string) []*model.ChannelBenchmark {
+	benchmarks := make([]*model.ChannelBenchmark, 0, len(c.Models))
+	for _, modelName := range c.Models {
+		benchmarks = append(benchmarks, c.runModelBenchmark(modelName, tag))
+	}
+
+	return benchmarks
+}
+
+func (c *CheckChannel) runModelBenchmark(modelName, tag string) *model.ChannelBenchmark {
+	benchmark := &model.ChannelBenchmark{
+		ChannelId: c.Channel.Id,
+		Tag:       tag,
+		Model:     modelName,
+	}
+
+	processes := make(map[string]float64)
+	for _, p := range getProcess(modelName, c.Channel) {
+		startTime := time.Now()
+		processResult := c.runProcess(p)
+		if _, ok := p.(*CheckBaseProcess); ok {
+			benchmark.Latency = int(time.Since(startTime).Milliseconds())
+		}
+
+		passed, total := 0, 0
+		for _, result := range processResult.Results {
+			// 无法判断的检测项不计入得分
+			if result.Status == CheckStatusUnknown {
+				continue
+			}
+			total++
+			if result.Status == CheckStatusSuccess {
+				passed++
+			}
+		}
+
+		if total == 0 {
+			continue
+		}
+		benchmark.Passed += passed
+		benchmark.Total += total
+		processes[processResult.Name] = float64(passed) / float64(total)
+	}
+
+	if benchmark.Total > 0 {
+		benchmark.Score = float64(benchmark.Passed) / float64(benchmark.Total)
+	}
+	benchmark.Processes = datatypes.NewJSONType(processes)
+
+	firstResponseTime, err := c.measureFirstResponse(modelName)
+	if err != nil {
+		benchmark.Error = err.Error()
+	}
+	benchmark.FirstResponseTime = firstResponseTime
+
+	return benchmark
+}
+
+// measureFirstResponse 发起流式请求，返回收到第一个数据块的耗时（毫秒）
+func (c *CheckChannel) measureFirstResponse(modelName string) (int, error) {
+	req := CreateCheckBaseProcess(modelName).GetRequest()
+	req.Stream = true
+
+	startTime := time.Now()
+	stream, errWithCode := c.ChatInterface.CreateChatCompletionStream(req)
+	if errWithCode != nil {
+		return 0, errors.New(errWithCode.Message)
+	}
+	defer stream.Close()
+
+	firstResponseTime := 0
+	timeout := time.After(benchmarkStreamTimeout)
+	dataChan, errChan := stream.Recv()
+	for {
+		select {
+		case <-dataChan:
+			if firstResponseTime == 0 {
+				firstResponseTime = int(time.Since(startTime).Milliseconds())
+			}
+		case err := <-errChan:
+			if firstResponseTime == 0 {
+				return 0, err
+			}
+			return firstResponseTime, nil
+		case <-timeout:
+			if firstResponseTime == 0 {
+				return 0, errors.New("stream timeout")
+			}
+			return firstResponseTime, nil
+		}
+	}
+}
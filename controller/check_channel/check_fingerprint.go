@@ -0,0 +1,410 @@
+package check_channel
+
+import (
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/model"
+	"done-hub/types"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 已知答案：12345 * 6789
+const fingerprintKnownAnswer = "83810205"
+
+// 中英文、emoji、代码混排，不同分词器的计数差异明显
+const fingerprintTokenizerText = "人工智能正在改变世界。The quick brown fox jumps over the lazy dog 🦊🐶. " +
+	"func main() { fmt.Println(\"你好，世界\") } // 分词器差异测试 トークナイザー 토크나이저 1234567890"
+
+// 各模型公开的知识截止时间，按前缀匹配
+var fingerprintCutoffs = map[string]string{
+	"gpt-3.5-turbo":     "2021-09",
+	"gpt-4":             "2021-09",
+	"gpt-4-turbo":       "2023-12",
+	"gpt-4o":            "2023-10",
+	"gpt-4o-mini":       "2023-10",
+	"gpt-4.1":           "2024-06",
+	"gpt-5":             "2024-09",
+	"o1":                "2023-10",
+	"o3":                "2024-06",
+	"o4-mini":           "2024-06",
+	"claude-3-haiku":    "2023-08",
+	"claude-3-opus":     "2023-08",
+	"claude-3-5-haiku":  "2024-07",
+	"claude-3-5-sonnet": "2024-04",
+	"claude-3-7-sonnet": "2024-10",
+	"claude-sonnet-4":   "2025-03",
+	"claude-opus-4":     "2025-03",
+	"gemini-1.5":        "2023-11",
+	"gemini-2.0":        "2024-08",
+	"gemini-2.5":        "2025-01",
+}
+
+var (
+	modelVersionSuffix = regexp.MustCompile(`(-\d{4}-\d{2}-\d{2}|-\d{8}|-\d{4}|-latest|@\d{8})$`)
+	cutoffPattern      = regexp.MustCompile(`(\d{4})-(\d{1,2})`)
+)
+
+type CheckFingerprintProcess struct {
+	ModelName string
+	ChannelId int
+	// 渠道模型映射后的名称，未映射时与 ModelName 相同
+	MappedModel string
+}
+
+type fingerprintAnswer struct {
+	Model  string `json:"model"`
+	Cutoff string `json:"cutoff"`
+	Answer any    `json:"answer"`
+}
+
+func CreateCheckFingerprintProcess(modelName string, channel *model.Channel) *CheckFingerprintProcess {
+	process := &CheckFingerprintProcess{
+		ModelName:   modelName,
+		MappedModel: modelName,
+	}
+	if channel != nil {
+		process.ChannelId = channel.Id
+		process.MappedModel = getMappedModel(channel, modelName)
+	}
+	return process
+}
+
+func (c *CheckFingerprintProcess) GetName() string {
+	return "模型真实性检测"
+}
+
+func (c *CheckFingerprintProcess) GetRequest() *types.ChatCompletionRequest {
+	prompt := "请只输出一个 JSON 对象，不要输出任何其他内容：" +
+		`{"model":"你的模型名称","cutoff":"你的知识截止时间，格式 YYYY-MM","answer":"12345 * 6789 的计算结果"}` +
+		"\n以下文本仅用于校验，无需处理：\n" + fingerprintTokenizerText
+
+	req := &types.ChatCompletionRequest{
+		Model: c.ModelName,
+		Messages: []types.ChatCompletionMessage{
+			{
+				Role:    types.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+	}
+
+	if strings.HasPrefix(c.ModelName, "o1") || strings.HasPrefix(c.ModelName, "o3") || strings.HasPrefix(c.ModelName, "o4") || strings.HasPrefix(c.ModelName, "gpt-5") {
+		req.MaxCompletionTokens = 2000
+	} else {
+		req.MaxTokens = 200
+	}
+
+	return req
+}
+
+func (c *CheckFingerprintProcess) Check(req *types.ChatCompletionRequest, resp *types.ChatCompletionResponse, openaiErr *types.OpenAIError) []*CheckResult {
+	checkResults := make([]*CheckResult, 0)
+	// 请求失败无法判断是否被替换
+	if openaiErr != nil {
+		checkResults = append(checkResults, &CheckResult{
+			Name:   "响应",
+			Status: CheckStatusUnknown,
+			Remark: openaiErr.Message,
+		})
+		return checkResults
+	}
+
+	channelType := getChannelTypeByModelName(c.ModelName)
+
+	checkResults = append(checkResults, c.checkUsage(req, resp, channelType))
+	checkResults = append(checkResults, c.checkResponseModel(req, resp))
+	checkResults = append(checkResults, c.checkSystemFingerprint(resp, channelType))
+
+	answer := parseFingerprintAnswer(resp)
+	checkResults = append(checkResults, c.checkKnownAnswer(answer))
+	checkResults = append(checkResults, c.checkCutoff(answer))
+
+	return checkResults
+}
+
+// 对比上游返回的输入 token 数与本地分词器的计数，OpenAI 系列应基本一致
+func (c *CheckFingerprintProcess) checkUsage(req *types.ChatCompletionRequest, resp *types.ChatCompletionResponse, channelType int) *CheckResult {
+	result := &CheckResult{
+		Name:   "用量对比",
+		Status: CheckStatusUnknown,
+	}
+
+	if resp.Usage == nil || resp.Usage.PromptTokens <= 0 {
+		result.Remark = "用量数据为空"
+		return result
+	}
+
+	if config.DisableTokenEncoders || config.ApproximateTokenEnabled {
+		result.Remark = "本地分词器未启用，无法对比"
+		return result
+	}
+
+	localTokens := common.CountTokenMessages(req.Messages, c.ModelName, config.PreCostDefault)
+	diff := resp.Usage.PromptTokens - localTokens
+	ratio := float64(resp.Usage.PromptTokens) / float64(localTokens)
+	result.Remark = fmt.Sprintf("上游 %d / 本地 %d (差值 %d，比例 %.2f)", resp.Usage.PromptTokens, localTokens, diff, ratio)
+
+	if channelType != config.ChannelTypeOpenAI {
+		result.Remark += "，非 OpenAI 分词器仅供参考"
+		return result
+	}
+
+	if c.MappedModel != c.ModelName {
+		result.Remark += "，渠道配置了模型映射，仅供参考"
+		return result
+	}
+
+	tolerance := math.Max(5, float64(localTokens)*0.05)
+	if math.Abs(float64(diff)) > tolerance {
+		result.Status = CheckStatusFailed
+		result.Remark += "，与该模型分词器不一致，疑似模型替换"
+		return result
+	}
+
+	result.Status = CheckStatusSuccess
+	return result
+}
+
+// 返回的模型名去掉版本后缀后应与请求模型一致，比如请求 gpt-4o 返回 gpt-4o-mini-2024-07-18 即为替换
+func (c *CheckFingerprintProcess) checkResponseModel(req *types.ChatCompletionRequest, resp *types.ChatCompletionResponse) *CheckResult {
+	result := &CheckResult{
+		Name:   "返回模型",
+		Status: CheckStatusUnknown,
+	}
+
+	if resp.Model == "" {
+		result.Remark = "返回模型为空"
+		return result
+	}
+
+	if isSameModel(req.Model, resp.Model) || isSameModel(c.MappedModel, resp.Model) {
+		result.Status = CheckStatusSuccess
+		result.Remark = resp.Model
+		return result
+	}
+
+	// 映射后的模型名称可能与上游返回的名称不同，不能作为替换的依据
+	if c.MappedModel != c.ModelName {
+		result.Remark = fmt.Sprintf("请求 %s（映射为 %s），返回 %s", req.Model, c.MappedModel, resp.Model)
+		return result
+	}
+
+	result.Status = CheckStatusFailed
+	result.Remark = fmt.Sprintf("请求 %s，返回 %s，疑似模型替换", req.Model, resp.Model)
+	return result
+}
+
+// OpenAI 官方接口会返回 fp_ 开头的 system_fingerprint，与上次检测不同说明后端发生了变化
+func (c *CheckFingerprintProcess) checkSystemFingerprint(resp *types.ChatCompletionResponse, channelType int) *CheckResult {
+	result := &CheckResult{
+		Name:   "system_fingerprint",
+		Status: CheckStatusUnknown,
+	}
+
+	if resp.SystemFingerprint == "" {
+		result.Remark = "未返回 system_fingerprint"
+		if channelType != config.ChannelTypeOpenAI {
+			result.Remark += "（非 OpenAI 模型可忽略）"
+		}
+		return result
+	}
+
+	if channelType == config.ChannelTypeOpenAI && !strings.HasPrefix(resp.SystemFingerprint, "fp_") {
+		result.Status = CheckStatusFailed
+		result.Remark = fmt.Sprintf("%s 不是 OpenAI 的格式", resp.SystemFingerprint)
+		return result
+	}
+
+	result.Status = CheckStatusSuccess
+	result.Remark = resp.SystemFingerprint
+
+	if c.ChannelId == 0 {
+		return result
+	}
+
+	lastLog, err := model.GetLastChannelCheckLog(c.ChannelId, c.ModelName)
+	if err == nil && lastLog.SystemFingerprint != "" && lastLog.SystemFingerprint != resp.SystemFingerprint {
+		result.Status = CheckStatusUnknown
+		result.Remark = fmt.Sprintf("%s，与上次检测的 %s 不同", resp.SystemFingerprint, lastLog.SystemFingerprint)
+	}
+
+	return result
+}
+
+func (c *CheckFingerprintProcess) checkKnownAnswer(answer *fingerprintAnswer) *CheckResult {
+	result := &CheckResult{
+		Name:   "已知答案",
+		Status: CheckStatusUnknown,
+	}
+
+	if answer == nil {
+		result.Remark = "返回内容不是 JSON，无法判断"
+		return result
+	}
+
+	value := strings.NewReplacer(",", "", " ", "").Replace(fmt.Sprint(answer.Answer))
+	if value == fingerprintKnownAnswer {
+		result.Status = CheckStatusSuccess
+		result.Remark = value
+		return result
+	}
+
+	result.Remark = fmt.Sprintf("回答 %s，正确答案 %s（能力较弱的模型容易出错，仅供参考）", value, fingerprintKnownAnswer)
+	return result
+}
+
+// 模型自述的知识截止时间与公开的差距超过半年时提示
+func (c *CheckFingerprintProcess) checkCutoff(answer *fingerprintAnswer) *CheckResult {
+	result := &CheckResult{
+		Name:   "知识截止",
+		Status: CheckStatusUnknown,
+	}
+
+	if answer == nil {
+		result.Remark = "返回内容不是 JSON，无法判断"
+		return result
+	}
+
+	expected := getExpectedCutoff(c.ModelName)
+	result.Remark = fmt.Sprintf("自述模型 %s，知识截止 %s", answer.Model, answer.Cutoff)
+	if expected == "" {
+		return result
+	}
+
+	actualMonths, ok := parseCutoffMonths(answer.Cutoff)
+	if !ok {
+		return result
+	}
+	expectedMonths, _ := parseCutoffMonths(expected)
+
+	if math.Abs(float64(actualMonths-expectedMonths)) > 6 {
+		result.Remark += fmt.Sprintf("，公开的截止时间为 %s（模型自述不一定准确，仅供参考）", expected)
+		return result
+	}
+
+	result.Status = CheckStatusSuccess
+	return result
+}
+
+// Record 保存检测结果，只有发现替换证据的失败项才视为可疑，无法判断的项不影响结果
+func (c *CheckFingerprintProcess) Record(req *types.ChatCompletionRequest, resp *types.ChatCompletionResponse, results []*CheckResult) *model.ChannelCheckLog {
+	if c.ChannelId == 0 {
+		return nil
+	}
+
+	checkLog := &model.ChannelCheckLog{
+		ChannelId: c.ChannelId,
+		Model:     c.ModelName,
+	}
+
+	if resp != nil {
+		checkLog.ResponseModel = resp.Model
+		checkLog.SystemFingerprint = resp.SystemFingerprint
+		if resp.Usage != nil {
+			checkLog.PromptTokens = resp.Usage.PromptTokens
+		}
+		checkLog.LocalPromptTokens = common.CountTokenMessages(req.Messages, c.ModelName, config.PreCostDefault)
+	}
+
+	for _, result := range results {
+		if result.Status == CheckStatusFailed {
+			checkLog.Suspicious = true
+			break
+		}
+	}
+
+	checkLog.Results, _ = json.Marshal(results)
+	if err := checkLog.Insert(); err != nil {
+		logger.SysError(fmt.Sprintf("save channel check log error: %s", err.Error()))
+	}
+
+	return checkLog
+}
+
+func isSameModel(requestModel, responseModel string) bool {
+	requestModel = normalizeModelName(requestModel)
+	responseModel = normalizeModelName(responseModel)
+	return requestModel == responseModel || strings.HasSuffix(responseModel, "/"+requestModel)
+}
+
+// getMappedModel 按渠道的模型映射返回实际请求上游的模型
+func getMappedModel(channel *model.Channel, modelName string) string {
+	mapping := channel.GetModelMapping()
+	if mapping == "" || mapping == "{}" {
+		return modelName
+	}
+
+	modelMap := make(map[string]string)
+	if err := json.Unmarshal([]byte(mapping), &modelMap); err != nil {
+		return modelName
+	}
+	if mapped := modelMap[modelName]; mapped != "" {
+		return mapped
+	}
+	return modelName
+}
+
+func normalizeModelName(modelName string) string {
+	modelName = strings.ToLower(strings.TrimSpace(modelName))
+	return modelVersionSuffix.ReplaceAllString(modelName, "")
+}
+
+func parseFingerprintAnswer(resp *types.ChatCompletionResponse) *fingerprintAnswer {
+	if resp == nil || len(resp.Choices) == 0 {
+		return nil
+	}
+
+	content := strings.TrimSpace(resp.Choices[0].Message.StringContent())
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start == -1 || end <= start {
+		return nil
+	}
+
+	answer := &fingerprintAnswer{}
+	if err := json.Unmarshal([]byte(content[start:end+1]), answer); err != nil {
+		return nil
+	}
+
+	return answer
+}
+
+func getExpectedCutoff(modelName string) string {
+	prefixes := make([]string, 0, len(fingerprintCutoffs))
+	for prefix := range fingerprintCutoffs {
+		prefixes = append(prefixes, prefix)
+	}
+	// 优先匹配更长的前缀
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(modelName, prefix) {
+			return fingerprintCutoffs[prefix]
+		}
+	}
+
+	return ""
+}
+
+func parseCutoffMonths(cutoff string) (int, bool) {
+	matches := cutoffPattern.FindStringSubmatch(cutoff)
+	if len(matches) != 3 {
+		return 0, false
+	}
+
+	date, err := time.Parse("2006-1", matches[1]+"-"+matches[2])
+	if err != nil {
+		return 0, false
+	}
+
+	return date.Year()*12 + int(date.Month()), true
+}
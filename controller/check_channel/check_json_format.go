@@ -1,6 +1,7 @@
 package check_channel
 
 import (
+	"done-hub/common/jsonschema"
 	"done-hub/types"
 	"encoding/json"
 )
@@ -82,8 +83,10 @@ func (c *CheckJsonFormatProcess) Check(req *types.ChatCompletionRequest, resp *t
 	err := json.Unmarshal([]byte(content), &jsonSchema)
 	if err != nil {
 		result.Remark = "返回结果不是json"
+	} else if err = jsonschema.ValidateJSON(req.ResponseFormat.JsonSchema.Schema, content); err != nil {
+		result.Remark = "返回结果不符合schema: " + err.Error()
 	} else {
-		result.Remark = "返回结果是json"
+		result.Remark = "返回结果符合schema"
 		result.Status = CheckStatusSuccess
 	}
 
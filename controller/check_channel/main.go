@@ -5,6 +5,7 @@ import (
 	"done-hub/model"
 	"done-hub/providers"
 	providers_base "done-hub/providers/base"
+	"done-hub/relay/toolcall"
 	"done-hub/types"
 	"errors"
 	"net/http"
@@ -49,6 +50,11 @@ type CheckProcess interface {
 	Check(req *types.ChatCompletionRequest, resp *types.ChatCompletionResponse, openaiErr *types.OpenAIError) []*CheckResult
 }
 
+// 需要保存每次检测结果的检测项
+type CheckRecorder interface {
+	Record(req *types.ChatCompletionRequest, resp *types.ChatCompletionResponse, results []*CheckResult) *model.ChannelCheckLog
+}
+
 func CreateCheckChannel(channelId int, models string) (*CheckChannel, error) {
 	modelsList := strings.Split(models, ",")
 	if len(modelsList) == 0 {
@@ -89,7 +95,7 @@ func CreateCheckChannel(channelId int, models string) (*CheckChannel, error) {
 	return &CheckChannel{
 		Models:        modelsList,
 		Channel:       channel,
-		ChatInterface: chatInterface,
+		ChatInterface: toolcall.Wrap(chatInterface),
 	}, nil
 }
 
@@ -97,40 +103,74 @@ func (c *CheckChannel) Run() ([]*ModelResult, error) {
 	results := make([]*ModelResult, 0)
 
 	for _, model := range c.Models {
-		process := getProcess(model)
-		modelResult := &ModelResult{
-			Model:   model,
-			Process: make([]*CheckProcessResult, 0),
+		results = append(results, c.runModel(model))
+	}
+	return results, nil
+}
+
+func (c *CheckChannel) runModel(modelName string) *ModelResult {
+	process := getProcess(modelName, c.Channel)
+	modelResult := &ModelResult{
+		Model:   modelName,
+		Process: make([]*CheckProcessResult, 0),
+	}
+	for _, p := range process {
+		modelResult.Process = append(modelResult.Process, c.runProcess(p))
+	}
+
+	return modelResult
+}
+
+func (c *CheckChannel) runProcess(p CheckProcess) *CheckProcessResult {
+	processResult := &CheckProcessResult{
+		Name:     p.GetName(),
+		Results:  make([]*CheckResult, 0),
+		Response: nil,
+	}
+	req := p.GetRequest()
+	resp, err := c.ChatInterface.CreateChatCompletion(req)
+	var openaiErr *types.OpenAIError
+	if err != nil {
+		openaiErr = &err.OpenAIError
+	}
+	processResult.Results = p.Check(req, resp, openaiErr)
+	processResult.Response = resp
+
+	if recorder, ok := p.(CheckRecorder); ok {
+		recorder.Record(req, resp, processResult.Results)
+	}
+
+	return processResult
+}
+
+// RunFingerprint 只执行模型真实性检测，用于定时任务
+func (c *CheckChannel) RunFingerprint() []*model.ChannelCheckLog {
+	logs := make([]*model.ChannelCheckLog, 0, len(c.Models))
+	for _, modelName := range c.Models {
+		p := CreateCheckFingerprintProcess(modelName, c.Channel)
+		req := p.GetRequest()
+		resp, err := c.ChatInterface.CreateChatCompletion(req)
+		var openaiErr *types.OpenAIError
+		if err != nil {
+			openaiErr = &err.OpenAIError
 		}
-		for _, p := range process {
-			processResult := &CheckProcessResult{
-				Name:     p.GetName(),
-				Results:  make([]*CheckResult, 0),
-				Response: nil,
-			}
-			req := p.GetRequest()
-			resp, err := c.ChatInterface.CreateChatCompletion(req)
-			var openaiErr *types.OpenAIError
-			if err != nil {
-				openaiErr = &err.OpenAIError
-			}
-			processResult.Results = p.Check(req, resp, openaiErr)
-			processResult.Response = resp
-
-			modelResult.Process = append(modelResult.Process, processResult)
+
+		if checkLog := p.Record(req, resp, p.Check(req, resp, openaiErr)); checkLog != nil {
+			logs = append(logs, checkLog)
 		}
-		results = append(results, modelResult)
 	}
-	return results, nil
+
+	return logs
 }
 
-func getProcess(modelName string) []CheckProcess {
+func getProcess(modelName string, channel *model.Channel) []CheckProcess {
 	return []CheckProcess{
 		CreateCheckBaseProcess(modelName),
 		CreateCheckErrorProcess(modelName),
 		CreateCheckImgProcess(modelName),
 		CreateCheckJsonFormatProcess(modelName),
 		CreateCheckToolProcess(modelName),
+		CreateCheckFingerprintProcess(modelName, channel),
 	}
 }
 
@@ -158,31 +198,7 @@ func (c *CheckChannel) RunStream(resultChan chan<- *ModelResult, doneChan chan<-
 	defer close(doneChan)
 
 	for _, model := range c.Models {
-		process := getProcess(model)
-		modelResult := &ModelResult{
-			Model:   model,
-			Process: make([]*CheckProcessResult, 0),
-		}
-
-		for _, p := range process {
-			processResult := &CheckProcessResult{
-				Name:     p.GetName(),
-				Results:  make([]*CheckResult, 0),
-				Response: nil,
-			}
-			req := p.GetRequest()
-			resp, err := c.ChatInterface.CreateChatCompletion(req)
-			var openaiErr *types.OpenAIError
-			if err != nil {
-				openaiErr = &err.OpenAIError
-			}
-			processResult.Results = p.Check(req, resp, openaiErr)
-			processResult.Response = resp
-
-			modelResult.Process = append(modelResult.Process, processResult)
-		}
-
 		// 每完成一个模型的检查就发送结果
-		resultChan <- modelResult
+		resultChan <- c.runModel(model)
 	}
 }
@@ -26,7 +26,16 @@ func shouldEnableChannel(err error, openAIErr *types.OpenAIErrorWithStatusCode)
 }
 
 func ShouldDisableChannel(channelType int, err *types.OpenAIErrorWithStatusCode) bool {
-	if !config.AutomaticDisableChannelEnabled || err == nil || err.LocalError {
+	if !config.AutomaticDisableChannelEnabled {
+		return false
+	}
+
+	return isChannelFatalError(channelType, err)
+}
+
+// 密钥失效、账户停用等无法通过重试恢复的错误
+func isChannelFatalError(channelType int, err *types.OpenAIErrorWithStatusCode) bool {
+	if err == nil || err.LocalError {
 		return false
 	}
 
@@ -70,9 +79,25 @@ func DisableChannel(channelId int, channelName string, reason string, sendNotify
 	notify.Send(subject, content)
 }
 
+// RecordChannelFailure 开启熔断器后，上游错误计入熔断器而不是直接禁用通道
+func RecordChannelFailure(channelId int, channelType int, modelName string, err *types.OpenAIErrorWithStatusCode) {
+	if err == nil || err.LocalError {
+		return
+	}
+
+	fatal := isChannelFatalError(channelType, err)
+	// 普通的请求参数错误不计入
+	if !fatal && err.StatusCode >= http.StatusBadRequest && err.StatusCode < http.StatusInternalServerError && err.StatusCode != http.StatusTooManyRequests && err.StatusCode != http.StatusRequestTimeout {
+		return
+	}
+
+	model.ChannelGroup.RecordCircuitFailure(channelId, modelName, err.Message, fatal)
+}
+
 // enable & notify
 func EnableChannel(channelId int, channelName string, sendNotify bool) {
 	model.UpdateChannelStatusById(channelId, config.ChannelStatusEnabled)
+	model.ChannelGroup.ResetCircuitBreakers(channelId)
 	if !sendNotify {
 		return
 	}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type exportConfigRequest struct {
+	Format  string `form:"format"`
+	Secrets string `form:"secrets"`
+}
+
+// ExportConfig 导出渠道、分组、价格等配置为 YAML/JSON 文件
+func ExportConfig(c *gin.Context) {
+	var params exportConfigRequest
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if params.Format != "json" {
+		params.Format = "yaml"
+	}
+
+	// 口令放在请求头中，避免出现在访问日志里
+	bundle, err := model.ExportConfigBundle(params.Secrets, c.GetHeader("X-Config-Passphrase"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	data, err := model.EncodeConfigBundle(bundle, params.Format)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	filename := fmt.Sprintf("done-hub-config-%s.%s", time.Now().Format("20060102150405"), params.Format)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
+
+type applyConfigRequest struct {
+	Content    string `json:"content"`
+	DryRun     bool   `json:"dry_run"`
+	Prune      bool   `json:"prune"`
+	Passphrase string `json:"passphrase"`
+}
+
+// ApplyConfig 将配置文件同步到数据库，dry_run 时只返回差异
+func ApplyConfig(c *gin.Context) {
+	var params applyConfigRequest
+	if err := c.ShouldBindJSON(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if params.Content == "" {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("配置内容不能为空"))
+		return
+	}
+
+	bundle, err := model.DecodeConfigBundle([]byte(params.Content))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	changes, err := model.ApplyConfigBundle(bundle, &model.ConfigApplyOptions{
+		DryRun:     params.DryRun,
+		Prune:      params.Prune,
+		Passphrase: params.Passphrase,
+	})
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if params.DryRun {
+		c.Set(config.GinAuditRecordedKey, true)
+	} else if len(changes) > 0 {
+		recordAudit(c, "config", "", model.AuditActionUpdate, nil, gin.H{"changes": changes, "prune": params.Prune})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    changes,
+	})
+}
@@ -123,6 +123,7 @@ func UpdateOption(c *gin.Context) {
 			}
 		}
 	}
+	before := config.GlobalOption.Get(option.Key)
 	err = model.UpdateOption(option.Key, option.Value)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
@@ -131,6 +132,7 @@ func UpdateOption(c *gin.Context) {
 		})
 		return
 	}
+	recordAudit(c, "option", option.Key, model.AuditActionUpdate, map[string]string{option.Key: before}, map[string]string{option.Key: option.Value})
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -136,13 +136,19 @@ func PaymentCallback(c *gin.Context) {
 	}
 
 	payNotify, err := paymentService.HandleCallback(c, paymentService.Payment.Config)
-	if err != nil {
+	// 网关不关心的事件不会返回通知内容
+	if err != nil || payNotify == nil {
 		return
 	}
 
 	LockOrder(payNotify.GatewayNo)
 	defer UnlockOrder(payNotify.GatewayNo)
 
+	if payNotify.SubscriptionEvent != "" {
+		handleSubscriptionNotify(paymentService.Payment.ID, payNotify)
+		return
+	}
+
 	order, err := model.GetOrderByTradeNo(payNotify.TradeNo)
 	if err != nil {
 		logger.SysError(fmt.Sprintf("gateway callback failed to find order, trade_no: %s,", payNotify.TradeNo))
@@ -162,6 +168,17 @@ func PaymentCallback(c *gin.Context) {
 		return
 	}
 
+	// 套餐订单开通订阅，额度由订阅发放
+	if order.PlanId > 0 {
+		_, err = model.ActivateSubscription(order, payNotify.SubscriptionId)
+		if err != nil {
+			logger.SysError(fmt.Sprintf("gateway callback failed to activate subscription, trade_no: %s, error: %s", payNotify.TradeNo, err.Error()))
+			return
+		}
+		model.PublishWebhookEvent(model.WebhookEventOrderPaid, order)
+		return
+	}
+
 	err = model.IncreaseUserQuota(order.UserId, order.Quota)
 	if err != nil {
 		logger.SysError(fmt.Sprintf("gateway callback failed to increase user quota, trade_no: %s,", payNotify.TradeNo))
@@ -175,6 +192,7 @@ func PaymentCallback(c *gin.Context) {
 	}
 
 	model.RecordQuotaLog(order.UserId, model.LogTypeTopup, order.Quota, c.ClientIP(), fmt.Sprintf("在线充值成功，充值积分: %d，支付金额：%.2f %s", order.Quota, order.OrderAmount, order.OrderCurrency))
+	model.PublishWebhookEvent(model.WebhookEventOrderPaid, order)
 
 	// 处理邀请人充值返利
 	err = model.ProcessInviterReward(order.UserId, order.Quota, c.ClientIP())
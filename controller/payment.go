@@ -85,6 +85,7 @@ func AddPayment(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, "payment", payment.ID, model.AuditActionCreate, nil, &payment)
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"message": "Payment added successfully",
@@ -106,11 +107,15 @@ func UpdatePayment(c *gin.Context) {
 		overwrite = false
 	}
 
+	before, _ := model.GetPaymentByID(payment.ID)
 	err = payment.Update(overwrite)
 	if err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
+	if after, err := model.GetPaymentByID(payment.ID); err == nil {
+		recordAudit(c, "payment", payment.ID, model.AuditActionUpdate, before, after)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -129,6 +134,7 @@ func DeletePayment(c *gin.Context) {
 		return
 	}
 
+	before, _ := model.GetPaymentByID(id)
 	payment := model.Payment{ID: id}
 	err = payment.Delete()
 	if err != nil {
@@ -138,6 +144,7 @@ func DeletePayment(c *gin.Context) {
 		})
 		return
 	}
+	recordAudit(c, "payment", id, model.AuditActionDelete, before, nil)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
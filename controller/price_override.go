@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"done-hub/common"
+	"done-hub/model"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetPriceOverrides(c *gin.Context) {
+	var params model.SearchPriceOverridesParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	overrides, err := model.GetPriceOverridesList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    overrides,
+	})
+}
+
+func AddPriceOverride(c *gin.Context) {
+	override := &model.PriceOverride{}
+	if err := c.ShouldBindJSON(override); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	override.Id = 0
+
+	if err := override.Validate(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := override.Insert(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	recordAudit(c, "price_override", override.Id, model.AuditActionCreate, nil, override)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    override,
+	})
+}
+
+func UpdatePriceOverride(c *gin.Context) {
+	override := &model.PriceOverride{}
+	if err := c.ShouldBindJSON(override); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	before, err := model.GetPriceOverrideById(override.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := override.Validate(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := override.Update(before); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	recordAudit(c, "price_override", override.Id, model.AuditActionUpdate, before, override)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    override,
+	})
+}
+
+func DeletePriceOverride(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	override, err := model.GetPriceOverrideById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := override.Delete(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	recordAudit(c, "price_override", override.Id, model.AuditActionDelete, override, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+// GetSelfPriceOverrides 用户查看自己的专属价格
+func GetSelfPriceOverrides(c *gin.Context) {
+	overrides, err := model.GetUserPriceOverrides(c.GetInt("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    overrides,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"done-hub/common"
 	"done-hub/model"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 
@@ -69,10 +70,16 @@ func AddPrice(c *gin.Context) {
 		return
 	}
 
+	if err := price.ValidateRules(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
 	if err := model.PricingInstance.AddPrice(&price); err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
+	recordAudit(c, "price", price.Model, model.AuditActionCreate, nil, price)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -95,10 +102,17 @@ func UpdatePrice(c *gin.Context) {
 		return
 	}
 
+	if err := price.ValidateRules(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	before := getPriceSnapshot(modelName)
 	if err := model.PricingInstance.UpdatePrice(modelName, &price); err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
+	recordAudit(c, "price", modelName, model.AuditActionUpdate, before, price)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -115,10 +129,12 @@ func DeletePrice(c *gin.Context) {
 	modelName = modelName[1:]
 	modelName, _ = url.PathUnescape(modelName)
 
+	before := getPriceSnapshot(modelName)
 	if err := model.PricingInstance.DeletePrice(modelName); err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
+	recordAudit(c, "price", modelName, model.AuditActionDelete, before, nil)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -138,10 +154,17 @@ func BatchSetPrices(c *gin.Context) {
 		return
 	}
 
+	if err := pricesBatch.Price.ValidateRules(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	before := getPricesSnapshot(append(pricesBatch.OriginalModels, pricesBatch.Models...))
 	if err := model.PricingInstance.BatchSetPrices(&pricesBatch.BatchPrices, pricesBatch.OriginalModels); err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
+	recordAudit(c, "price", "batch", model.AuditActionUpdate, before, getPricesSnapshot(append(pricesBatch.OriginalModels, pricesBatch.Models...)))
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -160,10 +183,12 @@ func BatchDeletePrices(c *gin.Context) {
 		return
 	}
 
+	before := getPricesSnapshot(pricesBatch.Models)
 	if err := model.PricingInstance.BatchDeletePrices(pricesBatch.Models); err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
+	recordAudit(c, "price", "batch", model.AuditActionDelete, before, nil)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -185,6 +210,13 @@ func SyncPricing(c *gin.Context) {
 		return
 	}
 
+	for _, price := range prices {
+		if err := price.ValidateRules(); err != nil {
+			common.APIRespondWithError(c, http.StatusOK, fmt.Errorf("%s: %s", price.Model, err.Error()))
+			return
+		}
+	}
+
 	err := model.PricingInstance.SyncPricing(prices, updateMode)
 	if err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
@@ -205,3 +237,24 @@ func GetUpdatePriceService(c *gin.Context) {
 		"message": "",
 	})
 }
+
+// 复制价格，避免记录审计时读到修改后的数据
+func getPriceSnapshot(modelName string) *model.Price {
+	price, ok := model.PricingInstance.GetAllPrices()[modelName]
+	if !ok {
+		return nil
+	}
+
+	snapshot := *price
+	return &snapshot
+}
+
+func getPricesSnapshot(modelNames []string) map[string]*model.Price {
+	prices := make(map[string]*model.Price, len(modelNames))
+	for _, modelName := range modelNames {
+		if price := getPriceSnapshot(modelName); price != nil {
+			prices[modelName] = price
+		}
+	}
+	return prices
+}
@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"done-hub/common"
+	"done-hub/common/script"
+	"done-hub/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetScriptHooks(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    model.ScriptHooks,
+	})
+}
+
+func GetScripts(c *gin.Context) {
+	var params model.SearchScriptsParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	scripts, err := model.GetScriptsList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    scripts,
+	})
+}
+
+func GetScript(c *gin.Context) {
+	s, ok := getScriptParam(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    s,
+	})
+}
+
+func AddScript(c *gin.Context) {
+	s := &model.Script{}
+	if err := c.ShouldBindJSON(s); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	s.Id = 0
+
+	if err := s.Validate(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := s.Insert(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	recordAudit(c, "script", s.Id, model.AuditActionCreate, nil, s)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    s,
+	})
+}
+
+func UpdateScript(c *gin.Context) {
+	s := &model.Script{}
+	if err := c.ShouldBindJSON(s); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	before, err := model.GetScriptById(s.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := s.Validate(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := s.Update(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	recordAudit(c, "script", s.Id, model.AuditActionUpdate, before, s)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    s,
+	})
+}
+
+func DeleteScript(c *gin.Context) {
+	s, ok := getScriptParam(c)
+	if !ok {
+		return
+	}
+
+	if err := s.Delete(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	recordAudit(c, "script", s.Id, model.AuditActionDelete, s, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+type scriptTestRequest struct {
+	Code string         `json:"code" binding:"required"`
+	Hook string         `json:"hook" binding:"required"`
+	Ctx  map[string]any `json:"ctx"`
+}
+
+// TestScript 使用给定的 ctx 执行脚本的钩子函数，不影响线上的脚本
+func TestScript(c *gin.Context) {
+	var req scriptTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	budget := model.GetScriptBudget()
+	program, err := script.Compile("test", req.Code, budget)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, fmt.Errorf("脚本编译失败: %s", err.Error()))
+		return
+	}
+
+	if !program.Has(req.Hook) {
+		common.APIRespondWithError(c, http.StatusOK, fmt.Errorf("脚本没有定义 %s", req.Hook))
+		return
+	}
+
+	if req.Ctx == nil {
+		req.Ctx = map[string]any{}
+	}
+
+	result, err := program.Call(req.Hook, req.Ctx, budget)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    result,
+	})
+}
+
+func getScriptParam(c *gin.Context) (*model.Script, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("无效的脚本 ID"))
+		return nil, false
+	}
+
+	s, err := model.GetScriptById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return nil, false
+	}
+	return s, true
+}
@@ -0,0 +1,393 @@
+package controller
+
+import (
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/common/utils"
+	"done-hub/model"
+	"done-hub/payment"
+	"done-hub/payment/types"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SubscribeRequest struct {
+	PlanId    int    `json:"plan_id" binding:"required"`
+	UUID      string `json:"uuid" binding:"required"`
+	AutoRenew bool   `json:"auto_renew"`
+}
+
+func GetSubscriptionPlans(c *gin.Context) {
+	var params model.PaginationParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	plans, err := model.GetSubscriptionPlansList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    plans,
+	})
+}
+
+func GetSubscriptionPlan(c *gin.Context) {
+	plan, ok := getSubscriptionPlanParam(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    plan,
+	})
+}
+
+func AddSubscriptionPlan(c *gin.Context) {
+	plan := &model.SubscriptionPlan{}
+	if err := c.ShouldBindJSON(plan); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	plan.Id = 0
+
+	if err := validateSubscriptionPlan(plan); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := plan.Insert(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	recordAudit(c, "subscription_plan", plan.Id, model.AuditActionCreate, nil, plan)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    plan,
+	})
+}
+
+func UpdateSubscriptionPlan(c *gin.Context) {
+	plan := &model.SubscriptionPlan{}
+	if err := c.ShouldBindJSON(plan); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	before, err := model.GetSubscriptionPlanById(plan.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := validateSubscriptionPlan(plan); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := plan.Update(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	recordAudit(c, "subscription_plan", plan.Id, model.AuditActionUpdate, before, plan)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    plan,
+	})
+}
+
+func DeleteSubscriptionPlan(c *gin.Context) {
+	plan, ok := getSubscriptionPlanParam(c)
+	if !ok {
+		return
+	}
+
+	if err := plan.Delete(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	recordAudit(c, "subscription_plan", plan.Id, model.AuditActionDelete, plan, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+func GetUserSubscriptions(c *gin.Context) {
+	var params model.SearchUserSubscriptionsParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	subscriptions, err := model.GetUserSubscriptionsList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    subscriptions,
+	})
+}
+
+// GetEnabledSubscriptionPlans 用户可购买的套餐
+func GetEnabledSubscriptionPlans(c *gin.Context) {
+	plans, err := model.GetEnabledSubscriptionPlans()
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    plans,
+	})
+}
+
+func GetSelfSubscription(c *gin.Context) {
+	subscription, err := model.GetUserSubscription(c.GetInt("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	var data any
+	if subscription.Id != 0 {
+		data = subscription
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    data,
+	})
+}
+
+// CreateSubscriptionOrder 购买套餐，开启自动续费时需要网关支持
+func CreateSubscriptionOrder(c *gin.Context) {
+	var req SubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("invalid request"))
+		return
+	}
+
+	plan, err := model.GetSubscriptionPlanById(req.PlanId)
+	if err != nil || !plan.Enabled {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("套餐不存在"))
+		return
+	}
+
+	userId := c.GetInt("id")
+	user, err := model.GetUserById(userId, false)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("用户不存在"))
+		return
+	}
+
+	current, err := model.GetUserSubscription(userId)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if current.Id != 0 && current.AutoRenew {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("当前套餐已开启自动续费，请先取消后再购买"))
+		return
+	}
+
+	go model.CloseUnfinishedOrder()
+
+	paymentService, err := payment.NewPaymentService(req.UUID)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	fee, payMoney := calculatePlanAmount(paymentService.Payment, plan.Price)
+	tradeNo := utils.GenerateTradeNo()
+
+	var payRequest *types.PayRequest
+	if req.AutoRenew {
+		if !paymentService.SupportSubscription() {
+			common.APIRespondWithError(c, http.StatusOK, errors.New("该支付方式不支持自动续费"))
+			return
+		}
+		payRequest, err = paymentService.Subscribe(tradeNo, payMoney, user, plan)
+	} else {
+		payRequest, err = paymentService.Pay(tradeNo, payMoney, user)
+	}
+	if err != nil {
+		logger.SysError(fmt.Sprintf("create subscription payment error: %s", err.Error()))
+		common.APIRespondWithError(c, http.StatusOK, errors.New("创建支付失败，请稍后再试"))
+		return
+	}
+
+	order := &model.Order{
+		UserId:        userId,
+		GatewayId:     paymentService.Payment.ID,
+		TradeNo:       tradeNo,
+		PlanId:        plan.Id,
+		OrderAmount:   payMoney,
+		OrderCurrency: paymentService.Payment.Currency,
+		Fee:           fee,
+		Status:        model.OrderStatusPending,
+		Quota:         plan.Quota,
+	}
+
+	if err := order.Insert(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("创建订单失败，请稍后再试"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data": &OrderResponse{
+			TradeNo:    tradeNo,
+			PayRequest: payRequest,
+		},
+	})
+}
+
+// CancelSelfSubscription 取消自动续费，当前周期结束后失效
+func CancelSelfSubscription(c *gin.Context) {
+	subscription, err := model.GetUserSubscription(c.GetInt("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if subscription.Id == 0 || !subscription.AutoRenew {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("没有开启自动续费的套餐"))
+		return
+	}
+
+	if subscription.GatewaySubscriptionId != "" {
+		if err := cancelGatewaySubscription(subscription); err != nil {
+			logger.SysError(fmt.Sprintf("cancel subscription #%d error: %s", subscription.Id, err.Error()))
+			common.APIRespondWithError(c, http.StatusOK, errors.New("取消自动续费失败，请稍后再试"))
+			return
+		}
+	}
+
+	if err := subscription.CancelAutoRenew(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+func cancelGatewaySubscription(subscription *model.UserSubscription) error {
+	gateway, err := model.GetPaymentByID(subscription.GatewayId)
+	if err != nil {
+		return err
+	}
+
+	paymentService, err := payment.NewPaymentService(gateway.UUID)
+	if err != nil {
+		return err
+	}
+
+	return paymentService.CancelSubscription(subscription.GatewaySubscriptionId)
+}
+
+// handleSubscriptionNotify 处理网关的续费和取消通知
+func handleSubscriptionNotify(gatewayId int, payNotify *types.PayNotify) {
+	var err error
+	switch payNotify.SubscriptionEvent {
+	case types.SubscriptionEventRenewed:
+		err = model.RenewSubscriptionByGateway(gatewayId, payNotify.SubscriptionId, payNotify.GatewayNo)
+	case types.SubscriptionEventCanceled:
+		err = model.CancelSubscriptionByGateway(gatewayId, payNotify.SubscriptionId)
+	}
+
+	if err != nil {
+		logger.SysError(fmt.Sprintf("gateway subscription %s callback error, subscription_id: %s, error: %s", payNotify.SubscriptionEvent, payNotify.SubscriptionId, err.Error()))
+	}
+}
+
+// fee手续费，payMoney实付金额，套餐不参与充值折扣
+func calculatePlanAmount(payment *model.Payment, price float64) (fee, payMoney float64) {
+	if payment.PercentFee > 0 {
+		fee = utils.Decimal(price*payment.PercentFee, 2)
+	} else if payment.FixedFee > 0 {
+		fee = payment.FixedFee
+	}
+
+	payMoney = utils.Decimal(price+fee, 2)
+	if payment.Currency != model.CurrencyTypeUSD {
+		payMoney = utils.Decimal(payMoney*config.PaymentUSDRate, 2)
+	}
+	return
+}
+
+func getSubscriptionPlanParam(c *gin.Context) (*model.SubscriptionPlan, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return nil, false
+	}
+
+	plan, err := model.GetSubscriptionPlanById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return nil, false
+	}
+
+	return plan, true
+}
+
+func validateSubscriptionPlan(plan *model.SubscriptionPlan) error {
+	plan.Name = strings.TrimSpace(plan.Name)
+	if plan.Name == "" {
+		return errors.New("套餐名称不能为空")
+	}
+
+	if plan.Price <= 0 {
+		return errors.New("套餐价格必须大于 0")
+	}
+
+	// Stripe 按天计费的周期最长为一年
+	if plan.PeriodDays <= 0 || plan.PeriodDays > 365 {
+		return errors.New("套餐周期必须在 1 到 365 天之间")
+	}
+
+	if plan.Quota < 0 || plan.RateLimit < 0 {
+		return errors.New("额度和速率限制不能为负数")
+	}
+
+	if plan.Quota == 0 && !plan.Unlimited {
+		return errors.New("套餐需要设置每周期额度或不限量模型")
+	}
+
+	if plan.Group != "" && model.GlobalUserGroupRatio.GetBySymbol(plan.Group) == nil {
+		return errors.New("用户分组不存在")
+	}
+
+	return nil
+}
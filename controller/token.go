@@ -5,6 +5,7 @@ import (
 	"done-hub/common/config"
 	"done-hub/common/utils"
 	"done-hub/model"
+	"done-hub/relay/plugin"
 	"errors"
 	"net/http"
 	"strconv"
@@ -233,6 +234,7 @@ func UpdateToken(c *gin.Context) {
 		}
 	}
 
+	before := *cleanToken
 	if statusOnly != "" {
 		cleanToken.Status = token.Status
 	} else {
@@ -252,6 +254,9 @@ func UpdateToken(c *gin.Context) {
 		})
 		return
 	}
+	if c.GetInt("role") >= config.RoleAdminUser {
+		recordAudit(c, "token", cleanToken.Id, model.AuditActionUpdate, &before, cleanToken)
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -288,5 +293,9 @@ func validateTokenSetting(setting *model.TokenSetting) error {
 		}
 	}
 
-	return nil
+	if err := model.ValidateFallbackChains(setting.Fallback); err != nil {
+		return err
+	}
+
+	return plugin.Validate(setting.Plugins)
 }
@@ -0,0 +1,619 @@
+package controller
+
+import (
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/model"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const (
+	pendingTwoFactorIdKey   = "pending_2fa_id"
+	pendingTwoFactorTimeKey = "pending_2fa_time"
+	pendingTotpSecretKey    = "pending_totp_secret"
+	webAuthnSessionKey      = "webauthn_session"
+	twoFactorVerifyTimeKey  = "2fa_verify_time"
+
+	// 密码验证通过后需要在此时间内完成两步验证
+	pendingTwoFactorTimeout = 5 * 60
+	recoveryCodeCount       = 10
+)
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func setupPendingTwoFactor(user *model.User, c *gin.Context) {
+	session := sessions.Default(c)
+	session.Clear()
+	session.Set(pendingTwoFactorIdKey, user.Id)
+	session.Set(pendingTwoFactorTimeKey, time.Now().Unix())
+	if err := session.Save(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "无法保存会话信息，请重试",
+			"success": false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "",
+		"success": true,
+		"data": gin.H{
+			"require_2fa": true,
+			"totp":        user.TotpEnabled,
+			"webauthn":    model.CountUserPasskeys(user.Id) > 0,
+		},
+	})
+}
+
+// 获取密码验证通过、等待两步验证的用户
+func getPendingTwoFactorUser(c *gin.Context) (*model.User, error) {
+	session := sessions.Default(c)
+	id, ok := session.Get(pendingTwoFactorIdKey).(int)
+	if !ok || id == 0 {
+		return nil, errors.New("请先登录")
+	}
+
+	loginTime, _ := session.Get(pendingTwoFactorTimeKey).(int64)
+	if time.Now().Unix()-loginTime > pendingTwoFactorTimeout {
+		return nil, errors.New("验证已超时，请重新登录")
+	}
+
+	user, err := model.GetUserById(id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Status != config.UserStatusEnabled {
+		return nil, errors.New("用户已被封禁")
+	}
+
+	return user, nil
+}
+
+// LoginTwoFactor 使用 TOTP 验证码或恢复码完成登录
+func LoginTwoFactor(c *gin.Context) {
+	user, err := getPendingTwoFactorUser(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("无效的参数"))
+		return
+	}
+
+	if !user.ValidateTwoFactorCode(req.Code) {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("验证码错误"))
+		return
+	}
+
+	completeLogin(user, c, true)
+}
+
+func LoginWebAuthnBegin(c *gin.Context) {
+	user, err := getPendingTwoFactorUser(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	web, webUser, err := getWebAuthnContext(user.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if len(webUser.Passkeys) == 0 {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("未绑定通行密钥"))
+		return
+	}
+
+	assertion, sessionData, err := web.BeginLogin(webUser)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := saveWebAuthnSession(c, sessionData); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    assertion,
+	})
+}
+
+func LoginWebAuthnFinish(c *gin.Context) {
+	user, err := getPendingTwoFactorUser(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	web, webUser, err := getWebAuthnContext(user.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	sessionData, err := loadWebAuthnSession(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	credential, err := web.FinishLogin(webUser, *sessionData, c.Request)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("通行密钥验证失败"))
+		return
+	}
+	model.UpdateUserPasskeyCredential(user.Id, credential)
+
+	completeLogin(user, c, true)
+}
+
+func GetSelfTwoFactor(c *gin.Context) {
+	user, err := model.GetUserById(c.GetInt("id"), false)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	passkeys, err := model.GetUserPasskeys(user.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data": gin.H{
+			"status":   user.GetTwoFactorStatus(),
+			"passkeys": passkeys,
+		},
+	})
+}
+
+// SetupTotp 生成新的 TOTP 密钥，验证通过后才会保存
+func SetupTotp(c *gin.Context) {
+	user, err := model.GetUserById(c.GetInt("id"), false)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if user.TotpEnabled {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("已开启 TOTP 验证"))
+		return
+	}
+
+	// 已绑定通行密钥时需要先验证通行密钥
+	if err := verifySelfTwoFactor(c, user, ""); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	secret, err := common.GenerateTotpSecret()
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	session := sessions.Default(c)
+	session.Set(pendingTotpSecretKey, secret)
+	if err := session.Save(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data": gin.H{
+			"secret": secret,
+			"url":    common.TotpURL(config.SystemName, user.Username, secret),
+		},
+	})
+}
+
+func EnableTotp(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("无效的参数"))
+		return
+	}
+
+	session := sessions.Default(c)
+	secret, _ := session.Get(pendingTotpSecretKey).(string)
+	if secret == "" {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("请先生成密钥"))
+		return
+	}
+
+	counter, ok := common.ValidateTotpCode(secret, req.Code, 0)
+	if !ok {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("验证码错误"))
+		return
+	}
+
+	codes, hashes := common.GenerateRecoveryCodes(recoveryCodeCount)
+	if err := model.EnableUserTotp(c.GetInt("id"), secret, counter, hashes); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	session.Delete(pendingTotpSecretKey)
+	markSessionTwoFactor(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    codes,
+	})
+}
+
+func DisableTotp(c *gin.Context) {
+	user, err := validateSelfTwoFactorCode(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := model.DisableUserTotp(user.Id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部作废
+func RegenerateRecoveryCodes(c *gin.Context) {
+	user, err := validateSelfTwoFactorCode(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	codes, hashes := common.GenerateRecoveryCodes(recoveryCodeCount)
+	if err := model.UpdateUserRecoveryCodes(user.Id, hashes); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    codes,
+	})
+}
+
+// PasskeyRegisterBegin 已开启两步验证的用户需要先完成验证才能添加通行密钥
+func PasskeyRegisterBegin(c *gin.Context) {
+	var req twoFactorCodeRequest
+	c.ShouldBindJSON(&req)
+
+	web, webUser, err := getWebAuthnContext(c.GetInt("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := verifySelfTwoFactor(c, webUser.User, req.Code); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(webUser.Passkeys))
+	for _, credential := range webUser.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, sessionData, err := web.BeginRegistration(webUser, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := saveWebAuthnSession(c, sessionData); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    creation,
+	})
+}
+
+func PasskeyRegisterFinish(c *gin.Context) {
+	userId := c.GetInt("id")
+	web, webUser, err := getWebAuthnContext(userId)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	// 通行密钥验证也会创建挑战，这里再次确认已完成两步验证
+	if err := verifySelfTwoFactor(c, webUser.User, ""); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	sessionData, err := loadWebAuthnSession(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	credential, err := web.FinishRegistration(webUser, *sessionData, c.Request)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("通行密钥注册失败: "+err.Error()))
+		return
+	}
+
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		name = "Passkey " + strconv.Itoa(len(webUser.Passkeys)+1)
+	}
+
+	passkey, err := model.InsertUserPasskey(userId, name, credential)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	markSessionTwoFactor(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    passkey,
+	})
+}
+
+func DeletePasskey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	var req twoFactorCodeRequest
+	c.ShouldBindJSON(&req)
+
+	user, err := model.GetUserById(c.GetInt("id"), false)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := verifySelfTwoFactor(c, user, req.Code); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := model.DeleteUserPasskey(id, user.Id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+// PasskeyVerifyBegin 只绑定了通行密钥的用户修改两步验证前使用通行密钥验证身份
+func PasskeyVerifyBegin(c *gin.Context) {
+	web, webUser, err := getWebAuthnContext(c.GetInt("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if len(webUser.Passkeys) == 0 {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("未绑定通行密钥"))
+		return
+	}
+
+	assertion, sessionData, err := web.BeginLogin(webUser)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := saveWebAuthnSession(c, sessionData); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    assertion,
+	})
+}
+
+func PasskeyVerifyFinish(c *gin.Context) {
+	userId := c.GetInt("id")
+	web, webUser, err := getWebAuthnContext(userId)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	sessionData, err := loadWebAuthnSession(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	credential, err := web.FinishLogin(webUser, *sessionData, c.Request)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("通行密钥验证失败"))
+		return
+	}
+	model.UpdateUserPasskeyCredential(userId, credential)
+
+	if err := markSelfTwoFactorVerified(c); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+// ResetUserTwoFactor 用户丢失验证器时由超级管理员重置
+func ResetUserTwoFactor(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := model.ResetUserTwoFactor(id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+func validateSelfTwoFactorCode(c *gin.Context) (*model.User, error) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		return nil, errors.New("无效的参数")
+	}
+
+	user, err := model.GetUserById(c.GetInt("id"), false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.ValidateTwoFactorCode(req.Code) {
+		return nil, errors.New("验证码错误")
+	}
+
+	return user, nil
+}
+
+// verifySelfTwoFactor 修改通行密钥等操作前确认两步验证
+// 未开启两步验证时直接通过，否则需要提供正确的验证码，或者在有效期内通过了通行密钥验证
+func verifySelfTwoFactor(c *gin.Context, user *model.User, code string) error {
+	if !user.HasTwoFactor() {
+		return nil
+	}
+
+	if code != "" {
+		if !user.ValidateTwoFactorCode(code) {
+			return errors.New("验证码错误")
+		}
+		return markSelfTwoFactorVerified(c)
+	}
+
+	session := sessions.Default(c)
+	verifyTime, _ := session.Get(twoFactorVerifyTimeKey).(int64)
+	if time.Now().Unix()-verifyTime > pendingTwoFactorTimeout {
+		return errors.New("请先完成两步验证")
+	}
+
+	return nil
+}
+
+func markSelfTwoFactorVerified(c *gin.Context) error {
+	session := sessions.Default(c)
+	session.Set(twoFactorVerifyTimeKey, time.Now().Unix())
+	return session.Save()
+}
+
+// 绑定验证器后当前会话视为已通过两步验证
+func markSessionTwoFactor(c *gin.Context) {
+	session := sessions.Default(c)
+	if session.Get("id") == nil {
+		return
+	}
+	session.Set("two_factor", true)
+	session.Save()
+}
+
+func getWebAuthnContext(userId int) (*webauthn.WebAuthn, *model.WebAuthnUser, error) {
+	serverURL, err := url.Parse(config.ServerAddress)
+	if err != nil || serverURL.Hostname() == "" {
+		return nil, nil, errors.New("服务器地址配置错误")
+	}
+
+	web, err := webauthn.New(&webauthn.Config{
+		RPID:          serverURL.Hostname(),
+		RPDisplayName: config.SystemName,
+		RPOrigins:     []string{serverURL.Scheme + "://" + serverURL.Host},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	webUser, err := model.GetWebAuthnUser(userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return web, webUser, nil
+}
+
+func saveWebAuthnSession(c *gin.Context, sessionData *webauthn.SessionData) error {
+	data, err := json.Marshal(sessionData)
+	if err != nil {
+		return err
+	}
+
+	session := sessions.Default(c)
+	session.Set(webAuthnSessionKey, string(data))
+	return session.Save()
+}
+
+func loadWebAuthnSession(c *gin.Context) (*webauthn.SessionData, error) {
+	session := sessions.Default(c)
+	data, _ := session.Get(webAuthnSessionKey).(string)
+	if data == "" {
+		return nil, errors.New("验证会话已失效，请重试")
+	}
+
+	// 每个挑战只能使用一次
+	session.Delete(webAuthnSessionKey)
+	session.Save()
+
+	sessionData := &webauthn.SessionData{}
+	if err := json.Unmarshal([]byte(data), sessionData); err != nil {
+		return nil, err
+	}
+
+	return sessionData, nil
+}
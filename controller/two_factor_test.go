@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"bytes"
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/middleware"
+	"done-hub/model"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type twoFactorTestClient struct {
+	t       *testing.T
+	server  *gin.Engine
+	cookies []*http.Cookie
+}
+
+type twoFactorTestResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func (client *twoFactorTestClient) do(method, path string, body any) twoFactorTestResponse {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		assert.Nil(client.t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for _, cookie := range client.cookies {
+		req.AddCookie(cookie)
+	}
+
+	w := httptest.NewRecorder()
+	client.server.ServeHTTP(w, req)
+	if cookies := w.Result().Cookies(); len(cookies) > 0 {
+		client.cookies = cookies
+	}
+
+	var response twoFactorTestResponse
+	assert.Nil(client.t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func setupTwoFactorTest(t *testing.T) (*twoFactorTestClient, *model.User, string) {
+	logger.SetupLogger()
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	sqlDB, err := db.DB()
+	assert.Nil(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	assert.Nil(t, db.AutoMigrate(&model.User{}, &model.UserPasskey{}))
+
+	oldDB := model.DB
+	model.DB = db
+	passwordLoginEnabled := config.PasswordLoginEnabled
+	adminRequireTwoFactor := config.AdminRequireTwoFactor
+	serverAddress := config.ServerAddress
+	config.PasswordLoginEnabled = true
+	config.AdminRequireTwoFactor = true
+	config.ServerAddress = "http://localhost:3000"
+	t.Cleanup(func() {
+		model.DB = oldDB
+		config.PasswordLoginEnabled = passwordLoginEnabled
+		config.AdminRequireTwoFactor = adminRequireTwoFactor
+		config.ServerAddress = serverAddress
+	})
+
+	password, err := common.Password2Hash("password")
+	assert.Nil(t, err)
+	user := &model.User{Username: "admin", Password: password, Role: config.RoleAdminUser, Status: config.UserStatusEnabled}
+	assert.Nil(t, db.Create(user).Error)
+
+	secret, err := common.GenerateTotpSecret()
+	assert.Nil(t, err)
+	_, hashes := common.GenerateRecoveryCodes(recoveryCodeCount)
+	assert.Nil(t, model.EnableUserTotp(user.Id, secret, 0, hashes))
+
+	server := gin.New()
+	server.Use(sessions.Sessions("session", cookie.NewStore([]byte("secret"))))
+	server.POST("/login", Login)
+	server.POST("/login/2fa", LoginTwoFactor)
+	server.GET("/admin", middleware.AdminAuth(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": ""})
+	})
+	server.POST("/passkey/begin", middleware.UserAuth(), PasskeyRegisterBegin)
+	server.POST("/passkey/finish", middleware.UserAuth(), PasskeyRegisterFinish)
+	server.DELETE("/passkey/:id", middleware.UserAuth(), DeletePasskey)
+
+	return &twoFactorTestClient{t: t, server: server}, user, secret
+}
+
+func getTestTotpCode(t *testing.T, secret string, offset time.Duration) string {
+	code, err := common.GenerateTotpCode(secret, time.Now().Add(offset))
+	assert.Nil(t, err)
+	return code
+}
+
+// loginWithTwoFactor 完成登录并返回使用的验证码
+func loginWithTwoFactor(t *testing.T, client *twoFactorTestClient, secret string) string {
+	code := getTestTotpCode(t, secret, 0)
+	response := client.do(http.MethodPost, "/login", gin.H{"username": "admin", "password": "password"})
+	assert.True(t, response.Success)
+	assert.True(t, client.do(http.MethodPost, "/login/2fa", gin.H{"code": code}).Success)
+	return code
+}
+
+func TestLoginTwoFactorGate(t *testing.T) {
+	client, _, secret := setupTwoFactorTest(t)
+
+	// 密码验证通过后只进入待验证状态，还不能访问需要登录的接口
+	response := client.do(http.MethodPost, "/login", gin.H{"username": "admin", "password": "password"})
+	assert.True(t, response.Success)
+	var pending struct {
+		Require2fa bool `json:"require_2fa"`
+		Totp       bool `json:"totp"`
+		Webauthn   bool `json:"webauthn"`
+	}
+	assert.Nil(t, json.Unmarshal(response.Data, &pending))
+	assert.True(t, pending.Require2fa)
+	assert.True(t, pending.Totp)
+	assert.False(t, pending.Webauthn)
+	assert.False(t, client.do(http.MethodGet, "/admin", nil).Success)
+
+	assert.False(t, client.do(http.MethodPost, "/login/2fa", gin.H{"code": "000000"}).Success)
+	code := getTestTotpCode(t, secret, 0)
+	assert.True(t, client.do(http.MethodPost, "/login/2fa", gin.H{"code": code}).Success)
+	assert.True(t, client.do(http.MethodGet, "/admin", nil).Success)
+
+	// 同一个验证码不能再次用于登录
+	other := &twoFactorTestClient{t: t, server: client.server}
+	assert.True(t, other.do(http.MethodPost, "/login", gin.H{"username": "admin", "password": "password"}).Success)
+	response = other.do(http.MethodPost, "/login/2fa", gin.H{"code": code})
+	assert.False(t, response.Success)
+	assert.Equal(t, "验证码错误", response.Message)
+	assert.False(t, other.do(http.MethodGet, "/admin", nil).Success)
+
+	// 没有待验证的登录时不能直接验证
+	other = &twoFactorTestClient{t: t, server: client.server}
+	assert.False(t, other.do(http.MethodPost, "/login/2fa", gin.H{"code": getTestTotpCode(t, secret, 30*time.Second)}).Success)
+}
+
+func TestAdminRequireTwoFactor(t *testing.T) {
+	client, user, _ := setupTwoFactorTest(t)
+
+	// 管理员没有开启两步验证时不能访问管理接口
+	assert.Nil(t, model.DisableUserTotp(user.Id))
+	assert.True(t, client.do(http.MethodPost, "/login", gin.H{"username": "admin", "password": "password"}).Success)
+	response := client.do(http.MethodGet, "/admin", nil)
+	assert.False(t, response.Success)
+	assert.Contains(t, response.Message, "两步验证")
+
+	config.AdminRequireTwoFactor = false
+	assert.True(t, client.do(http.MethodGet, "/admin", nil).Success)
+}
+
+func TestPasskeyRequireTwoFactor(t *testing.T) {
+	client, user, secret := setupTwoFactorTest(t)
+	code := loginWithTwoFactor(t, client, secret)
+
+	passkey, err := model.InsertUserPasskey(user.Id, "key", &webauthn.Credential{ID: []byte("credential")})
+	assert.Nil(t, err)
+	path := "/passkey/" + strconv.Itoa(passkey.Id)
+
+	// 只有登录会话时不能添加或删除通行密钥
+	response := client.do(http.MethodDelete, path, nil)
+	assert.False(t, response.Success)
+	assert.Equal(t, "请先完成两步验证", response.Message)
+	assert.False(t, client.do(http.MethodDelete, path, gin.H{"code": "000000"}).Success)
+	assert.False(t, client.do(http.MethodPost, "/passkey/begin", nil).Success)
+	assert.False(t, client.do(http.MethodPost, "/passkey/finish", nil).Success)
+	assert.Equal(t, int64(1), model.CountUserPasskeys(user.Id))
+
+	// 登录使用过的验证码不能再次使用
+	assert.False(t, client.do(http.MethodDelete, path, gin.H{"code": code}).Success)
+
+	assert.True(t, client.do(http.MethodDelete, path, gin.H{"code": getTestTotpCode(t, secret, 30*time.Second)}).Success)
+	assert.Equal(t, int64(0), model.CountUserPasskeys(user.Id))
+
+	// 验证通过后有效期内可以继续添加通行密钥
+	assert.True(t, client.do(http.MethodPost, "/passkey/begin", nil).Success)
+}
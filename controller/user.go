@@ -67,11 +67,24 @@ func Login(c *gin.Context) {
 
 // setup session & cookies and then return user info
 func setupLogin(user *model.User, c *gin.Context) {
+	// 开启了两步验证的用户需要先完成验证
+	if user.HasTwoFactor() {
+		setupPendingTwoFactor(user, c)
+		return
+	}
+
+	completeLogin(user, c, false)
+}
+
+func completeLogin(user *model.User, c *gin.Context, twoFactor bool) {
 	session := sessions.Default(c)
+	session.Delete(pendingTwoFactorIdKey)
+	session.Delete(pendingTwoFactorTimeKey)
 	session.Set("id", user.Id)
 	session.Set("username", user.Username)
 	session.Set("role", user.Role)
 	session.Set("status", user.Status)
+	session.Set("two_factor", twoFactor)
 	err := session.Save()
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
@@ -470,6 +483,13 @@ func UpdateUser(c *gin.Context) {
 	if originUser.Quota != updatedUser.Quota {
 		model.RecordLog(originUser.Id, model.LogTypeManage, fmt.Sprintf("管理员将用户额度从 %s修改为 %s", common.LogQuota(originUser.Quota), common.LogQuota(updatedUser.Quota)))
 	}
+	if afterUser, err := model.GetUserById(updatedUser.Id, false); err == nil {
+		if updatePassword {
+			// 密码不会记录明文，仅标记发生了修改
+			afterUser.Password = "changed"
+		}
+		recordAudit(c, "user", updatedUser.Id, model.AuditActionUpdate, originUser, afterUser)
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -555,6 +575,7 @@ func DeleteUser(c *gin.Context) {
 		})
 		return
 	}
+	recordAudit(c, "user", id, model.AuditActionDelete, originUser, nil)
 }
 
 func CreateUser(c *gin.Context) {
@@ -610,6 +631,7 @@ func CreateUser(c *gin.Context) {
 		})
 		return
 	}
+	recordAudit(c, "user", cleanUser.Id, model.AuditActionCreate, nil, cleanUser)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -659,6 +681,7 @@ func ManageUser(c *gin.Context) {
 		})
 		return
 	}
+	before := *user
 	switch req.Action {
 	case "disable":
 		user.Status = config.UserStatusDisabled
@@ -727,6 +750,11 @@ func ManageUser(c *gin.Context) {
 		})
 		return
 	}
+	if req.Action == "delete" {
+		recordAudit(c, "user", user.Id, model.AuditActionDelete, &before, nil)
+	} else {
+		recordAudit(c, "user", user.Id, model.AuditActionUpdate, &before, user)
+	}
 	clearUser := model.User{
 		Role:   user.Role,
 		Status: user.Status,
@@ -842,6 +870,7 @@ func ChangeUserQuota(c *gin.Context) {
 		return
 	}
 
+	beforeQuota, _ := model.GetUserQuota(userId)
 	err = model.ChangeUserQuota(userId, req.Quota, false)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
@@ -858,6 +887,8 @@ func ChangeUserQuota(c *gin.Context) {
 	}
 
 	model.RecordQuotaLog(userId, model.LogTypeManage, req.Quota, c.ClientIP(), remark)
+	afterQuota, _ := model.GetUserQuota(userId)
+	recordAudit(c, "user_quota", userId, model.AuditActionUpdate, gin.H{"quota": beforeQuota}, gin.H{"quota": afterQuota, "remark": req.Remark})
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"done-hub/common"
+	"done-hub/common/utils"
+	"done-hub/model"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetSelfAlertSetting(c *gin.Context) {
+	setting, err := model.GetUserAlertSetting(c.GetInt("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    setting,
+	})
+}
+
+func UpdateSelfAlertSetting(c *gin.Context) {
+	setting := &model.UserAlertSetting{}
+	if err := c.ShouldBindJSON(setting); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	setting.UserId = c.GetInt("id")
+
+	if setting.BalanceThreshold < 0 || setting.TokenQuotaThreshold < 0 || setting.TokenExpireDays < 0 || setting.DailySpendThreshold < 0 {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("提醒阈值不能为负数"))
+		return
+	}
+
+	if setting.WebhookUrl != "" {
+		if err := validateAlertWebhook(setting.WebhookUrl); err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
+	}
+
+	if err := setting.Save(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    setting,
+	})
+}
+
+// 用户可以自行填写地址，禁止指向内网，发送时还会检查实际连接的地址
+func validateAlertWebhook(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("Webhook 地址无效")
+	}
+
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil {
+		return errors.New("Webhook 地址无法解析")
+	}
+
+	for _, ip := range ips {
+		if !utils.IsPublicIP(ip) {
+			return errors.New("Webhook 地址不能指向内网")
+		}
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"done-hub/common"
 	"done-hub/model"
+	"done-hub/relay/plugin"
 	"errors"
 	"net/http"
 	"strconv"
@@ -53,6 +54,11 @@ func AddUserGroup(c *gin.Context) {
 		return
 	}
 
+	if err := plugin.Validate(userGroup.GetPluginPipeline()); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
 	if err := userGroup.Create(); err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
@@ -72,6 +78,11 @@ func UpdateUserGroup(c *gin.Context) {
 		return
 	}
 
+	if err := plugin.Validate(userGroup.GetPluginPipeline()); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
 	if err := userGroup.Update(); err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
@@ -0,0 +1,236 @@
+package controller
+
+import (
+	"done-hub/common"
+	"done-hub/model"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetWebhookEvents(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    model.WebhookEvents,
+	})
+}
+
+func GetWebhookSubscriptions(c *gin.Context) {
+	var params model.PaginationParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	subscriptions, err := model.GetWebhookSubscriptionsList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    subscriptions,
+	})
+}
+
+func AddWebhookSubscription(c *gin.Context) {
+	subscription := &model.WebhookSubscription{}
+	if err := c.ShouldBindJSON(subscription); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	subscription.Id = 0
+
+	if err := validateWebhookSubscription(subscription); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := subscription.Insert(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	recordAudit(c, "webhook", subscription.Id, model.AuditActionCreate, nil, subscription)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    subscription,
+	})
+}
+
+func UpdateWebhookSubscription(c *gin.Context) {
+	subscription := &model.WebhookSubscription{}
+	if err := c.ShouldBindJSON(subscription); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	before, err := model.GetWebhookSubscriptionById(subscription.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	// 未填写密钥时保留原来的
+	if subscription.Secret == "" {
+		subscription.Secret = before.Secret
+	}
+
+	if err := validateWebhookSubscription(subscription); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := subscription.Update(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	recordAudit(c, "webhook", subscription.Id, model.AuditActionUpdate, before, subscription)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    subscription,
+	})
+}
+
+func DeleteWebhookSubscription(c *gin.Context) {
+	subscription, ok := getWebhookSubscriptionParam(c)
+	if !ok {
+		return
+	}
+
+	if err := subscription.Delete(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	recordAudit(c, "webhook", subscription.Id, model.AuditActionDelete, subscription, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+// TestWebhookSubscription 发送 ping 事件并返回投递结果
+func TestWebhookSubscription(c *gin.Context) {
+	subscription, ok := getWebhookSubscriptionParam(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := model.SendWebhookPing(subscription)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    delivery,
+	})
+}
+
+func GetWebhookDeliveries(c *gin.Context) {
+	var params model.SearchWebhookDeliveriesParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	deliveries, err := model.GetWebhookDeliveriesList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    deliveries,
+	})
+}
+
+func GetWebhookDelivery(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	delivery, err := model.GetWebhookDeliveryById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    delivery,
+	})
+}
+
+// ReplayWebhookDelivery 使用原始事件内容重新投递
+func ReplayWebhookDelivery(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	delivery, err := model.GetWebhookDeliveryById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	replay, err := model.ReplayWebhookDelivery(delivery)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    replay,
+	})
+}
+
+func getWebhookSubscriptionParam(c *gin.Context) (*model.WebhookSubscription, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return nil, false
+	}
+
+	subscription, err := model.GetWebhookSubscriptionById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return nil, false
+	}
+
+	return subscription, true
+}
+
+func validateWebhookSubscription(subscription *model.WebhookSubscription) error {
+	u, err := url.Parse(subscription.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("Webhook 地址无效")
+	}
+
+	if subscription.Events == "" {
+		subscription.Events = "*"
+	}
+
+	return nil
+}
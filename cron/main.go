@@ -5,6 +5,7 @@ import (
 	"done-hub/common/logger"
 	"done-hub/common/scheduler"
 	"done-hub/model"
+	"fmt"
 	"github.com/spf13/viper"
 	"time"
 
@@ -59,6 +60,71 @@ func InitCron() {
 		}),
 	)
 
+	// 检查用户设置的令牌过期和当日消费提醒
+	err = scheduler.Manager.AddJob(
+		"check_user_alerts",
+		gocron.DurationJob(10*time.Minute),
+		gocron.NewTask(func() {
+			model.CheckUserScheduledAlerts()
+		}),
+	)
+
+	// 处理到期的订阅套餐
+	err = scheduler.Manager.AddJob(
+		"expire_subscriptions",
+		gocron.DurationJob(10*time.Minute),
+		gocron.NewTask(func() {
+			model.ExpireSubscriptions()
+		}),
+	)
+
+	// 重试失败的 Webhook 投递
+	err = scheduler.Manager.AddJob(
+		"retry_webhook_deliveries",
+		gocron.DurationJob(time.Minute),
+		gocron.NewTask(func() {
+			model.RetryWebhookDeliveries()
+		}),
+		// 上一次重试还没结束时跳过本次执行
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+
+	// 每天清理过期的审计记录
+	err = scheduler.Manager.AddJob(
+		"cleanup_audit_logs",
+		gocron.DailyJob(1, gocron.NewAtTimes(gocron.NewAtTime(3, 30, 0))),
+		gocron.NewTask(func() {
+			if config.AuditLogRetentionDays <= 0 {
+				return
+			}
+			targetTimestamp := time.Now().AddDate(0, 0, -config.AuditLogRetentionDays).Unix()
+			count, err := model.DeleteOldAuditLogs(targetTimestamp)
+			if err != nil {
+				logger.SysError("Cleanup audit logs error: " + err.Error())
+				return
+			}
+			logger.SysLog(fmt.Sprintf("清理过期审计记录 %d 条", count))
+		}),
+	)
+
+	// 每天清理过期的渠道指纹检测记录
+	err = scheduler.Manager.AddJob(
+		"cleanup_channel_check_logs",
+		gocron.DailyJob(1, gocron.NewAtTimes(gocron.NewAtTime(3, 40, 0))),
+		gocron.NewTask(func() {
+			if config.ChannelCheckLogRetentionDays <= 0 {
+				return
+			}
+			targetTimestamp := time.Now().AddDate(0, 0, -config.ChannelCheckLogRetentionDays).Unix()
+			count, err := model.DeleteOldChannelCheckLogs(targetTimestamp)
+			if err != nil {
+				logger.SysError("Cleanup channel check logs error: " + err.Error())
+				return
+			}
+			logger.SysLog(fmt.Sprintf("清理过期渠道检测记录 %d 条", count))
+		}),
+	)
+
 	// 开启自动更新 并且设置了有效自动更新时间 同时自动更新模式不是system 则会从服务器拉取最新价格表
 	autoPriceUpdatesInterval := viper.GetInt("auto_price_updates_interval")
 	autoPriceUpdates := viper.GetBool("auto_price_updates")
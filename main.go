@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"done-hub/cli"
 	"done-hub/common"
 	"done-hub/common/cache"
@@ -13,6 +14,7 @@ import (
 	"done-hub/common/search"
 	"done-hub/common/storage"
 	"done-hub/common/telegram"
+	"done-hub/common/utils"
 	"done-hub/controller"
 	"done-hub/cron"
 	"done-hub/middleware"
@@ -21,8 +23,12 @@ import (
 	"done-hub/router"
 	"done-hub/safty"
 	"embed"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-contrib/sessions"
@@ -58,7 +64,11 @@ func main() {
 
 	// Initialize SQL Database
 	model.SetupDB()
-	defer model.CloseDB()
+	// 先写入剩余的消费日志再关闭数据库
+	defer func() {
+		model.StopLogWriter()
+		model.CloseDB()
+	}()
 	// Initialize Redis
 	redis.InitRedisClient()
 	cache.InitCacheManager()
@@ -67,6 +77,8 @@ func main() {
 	// Initialize oidc
 	oidc.InitOIDCConfig()
 	model.NewPricing()
+	cli.RunConfigCommand()
+	cli.RunEncryptionCommand()
 	model.HandleOldTokenMaxId()
 
 	initMemoryCache()
@@ -81,6 +93,7 @@ func main() {
 	task.InitTask()
 	notify.InitNotifier()
 	cron.InitCron()
+	controller.InitChannelBenchmarkSchedules()
 	storage.InitStorage()
 	search.InitSearcher()
 	// 初始化安全检查器
@@ -114,6 +127,9 @@ func initMemoryCache() {
 func initSync() {
 	// go controller.AutomaticallyUpdateChannels(viper.GetInt("channel.update_frequency"))
 	go controller.AutomaticallyTestChannels(viper.GetInt("channel.test_frequency"))
+	go controller.AutomaticallyCheckChannelHealth()
+	go controller.AutomaticallyProbeCircuitBreakers()
+	go controller.AutomaticallyCheckChannelFingerprint()
 }
 
 func initHttpServer() {
@@ -149,9 +165,27 @@ func initHttpServer() {
 	router.SetRouter(server, buildFS, indexPage)
 	port := viper.GetString("port")
 
-	err := server.Run(":" + port)
-	if err != nil {
-		logger.FatalLog("failed to start HTTP server: " + err.Error())
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: server,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.FatalLog("failed to start HTTP server: " + err.Error())
+		}
+	}()
+
+	// 收到退出信号后等待正在处理的请求完成，返回后执行 main 中的清理
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	logger.SysLog("shutting down HTTP server")
+
+	timeout := time.Duration(utils.GetOrDefault("shutdown_timeout", 30)) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.SysError("HTTP server shutdown error: " + err.Error())
 	}
 }
 
@@ -165,7 +199,9 @@ func SyncChannelCache(frequency int) {
 		time.Sleep(time.Duration(frequency) * time.Second)
 		logger.SysLog("syncing channels from database")
 		model.ChannelGroup.Load()
+		model.ChannelGroup.LoadBenchmarkScores()
 		model.PricingInstance.Init()
 		model.ModelOwnedBysInstance.Load()
+		model.ScriptInstance.Load()
 	}
 }
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"bytes"
+	"done-hub/common/config"
+	"done-hub/model"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 审计时最多记录的请求体大小
+const auditMaxBodySize = 16 * 1024
+
+// 不需要审计的接口，避免记录验证码、通行密钥等数据
+var auditSkipPaths = []string{
+	"/api/user/login",
+	"/api/user/2fa",
+	"/api/user/logout",
+}
+
+// AuditLog 为管理员的写操作兜底记录审计日志，已在接口内记录详细变更的请求会跳过
+func AuditLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodOptions || isAuditSkipPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil && c.Request.ContentLength > 0 && c.Request.ContentLength <= auditMaxBodySize {
+			body, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body.Close()
+			c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+		}
+
+		c.Next()
+
+		if c.GetInt("role") < config.RoleAdminUser || c.GetBool(config.GinAuditRecordedKey) {
+			return
+		}
+
+		// 只记录 JSON 请求体，其它格式无法识别敏感字段
+		var after any
+		if len(body) > 0 && json.Unmarshal(body, &after) != nil {
+			after = nil
+		}
+
+		model.RecordAuditLog(&model.AuditLog{
+			UserId:     c.GetInt("id"),
+			Username:   c.GetString("username"),
+			Ip:         c.ClientIP(),
+			Action:     auditActionByMethod(c.Request.Method),
+			EntityType: auditEntityByPath(c.FullPath()),
+			EntityId:   c.Param("id"),
+			Method:     c.Request.Method,
+			Path:       c.FullPath(),
+		}, nil, after)
+	}
+}
+
+func isAuditSkipPath(path string) bool {
+	for _, skipPath := range auditSkipPaths {
+		if strings.HasPrefix(path, skipPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func auditActionByMethod(method string) string {
+	switch method {
+	case http.MethodPost:
+		return model.AuditActionCreate
+	case http.MethodDelete:
+		return model.AuditActionDelete
+	default:
+		return model.AuditActionUpdate
+	}
+}
+
+// 以 /api/ 后的第一段路径作为实体类型
+func auditEntityByPath(path string) string {
+	path = strings.TrimPrefix(path, "/api/")
+	if index := strings.Index(path, "/"); index > 0 {
+		path = path[:index]
+	}
+	return path
+}
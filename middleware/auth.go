@@ -18,6 +18,7 @@ func authHelper(c *gin.Context, minRole int) {
 	role := session.Get("role")
 	id := session.Get("id")
 	status := session.Get("status")
+	twoFactor, _ := session.Get("two_factor").(bool)
 	if username == nil {
 		// Check access token
 		accessToken := c.Request.Header.Get("Authorization")
@@ -40,6 +41,7 @@ func authHelper(c *gin.Context, minRole int) {
 			role = user.Role
 			id = user.Id
 			status = user.Status
+			twoFactor = minRole >= config.RoleAdminUser && config.AdminRequireTwoFactor && user.HasTwoFactor()
 		} else {
 			c.JSON(http.StatusOK, gin.H{
 				"success": false,
@@ -65,6 +67,14 @@ func authHelper(c *gin.Context, minRole int) {
 		c.Abort()
 		return
 	}
+	if minRole >= config.RoleAdminUser && config.AdminRequireTwoFactor && !twoFactor {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "管理员需要开启两步验证并使用两步验证登录后才能进行此操作",
+		})
+		c.Abort()
+		return
+	}
 	c.Set("username", username)
 	c.Set("role", role)
 	c.Set("id", id)
@@ -0,0 +1,233 @@
+package model
+
+import (
+	"done-hub/common/logger"
+	"done-hub/common/utils"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// 管理员操作审计记录
+type AuditLog struct {
+	Id         int            `json:"id"`
+	UserId     int            `json:"user_id" gorm:"index"`
+	Username   string         `json:"username" gorm:"type:varchar(64);default:''"`
+	Ip         string         `json:"ip" gorm:"type:varchar(64);default:''"`
+	Action     string         `json:"action" gorm:"type:varchar(32);index"`
+	EntityType string         `json:"entity_type" gorm:"type:varchar(32);index"`
+	EntityId   string         `json:"entity_id" gorm:"type:varchar(255);default:''"`
+	Method     string         `json:"method" gorm:"type:varchar(16);default:''"`
+	Path       string         `json:"path" gorm:"type:varchar(255);default:''"`
+	Diff       datatypes.JSON `json:"diff" gorm:"type:json"`
+	CreatedAt  int64          `json:"created_at" gorm:"bigint;index"`
+}
+
+type SearchAuditLogsParams struct {
+	UserId     int    `form:"user_id"`
+	Username   string `form:"username"`
+	Action     string `form:"action"`
+	EntityType string `form:"entity_type"`
+	EntityId   string `form:"entity_id"`
+	StartTime  int64  `form:"start_timestamp"`
+	EndTime    int64  `form:"end_timestamp"`
+	PaginationParams
+}
+
+// 字段值的变化
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+var allowedAuditLogsOrderFields = map[string]bool{
+	"id":          true,
+	"user_id":     true,
+	"entity_type": true,
+	"created_at":  true,
+}
+
+// 敏感字段只记录是否发生变化
+var auditSensitiveFields = []string{"key", "password", "secret", "token", "recovery_codes"}
+
+const auditRedacted = "******"
+
+func GetAuditLogsList(params *SearchAuditLogsParams) (*DataResult[AuditLog], error) {
+	var logs []*AuditLog
+	db := DB
+
+	if params.UserId != 0 {
+		db = db.Where("user_id = ?", params.UserId)
+	}
+
+	if params.Username != "" {
+		db = db.Where("username = ?", params.Username)
+	}
+
+	if params.Action != "" {
+		db = db.Where("action = ?", params.Action)
+	}
+
+	if params.EntityType != "" {
+		db = db.Where("entity_type = ?", params.EntityType)
+	}
+
+	if params.EntityId != "" {
+		db = db.Where("entity_id = ?", params.EntityId)
+	}
+
+	if params.StartTime != 0 {
+		db = db.Where("created_at >= ?", params.StartTime)
+	}
+
+	if params.EndTime != 0 {
+		db = db.Where("created_at <= ?", params.EndTime)
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &logs, allowedAuditLogsOrderFields)
+}
+
+func (log *AuditLog) Insert() error {
+	log.CreatedAt = utils.GetTimestamp()
+	return DB.Create(log).Error
+}
+
+// RecordAuditLog 计算变更前后的差异并异步保存，不影响业务请求
+func RecordAuditLog(log *AuditLog, before, after any) {
+	diff := DiffAuditValues(before, after)
+	if log.Action == AuditActionUpdate && len(diff) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		logger.SysError("marshal audit diff error: " + err.Error())
+		return
+	}
+	log.Diff = data
+
+	go func() {
+		if err := log.Insert(); err != nil {
+			logger.SysError("insert audit log error: " + err.Error())
+		}
+	}()
+}
+
+func DeleteOldAuditLogs(targetTimestamp int64) (int64, error) {
+	result := DB.Where("created_at < ?", targetTimestamp).Delete(&AuditLog{})
+	return result.RowsAffected, result.Error
+}
+
+// DiffAuditValues 按 JSON 字段比较两个对象，before 或 after 为 nil 时记录另一方的全部字段
+func DiffAuditValues(before, after any) map[string]*AuditChange {
+	beforeMap := toAuditMap(before)
+	afterMap := toAuditMap(after)
+
+	diff := make(map[string]*AuditChange)
+	for key, afterValue := range afterMap {
+		beforeValue, ok := beforeMap[key]
+		if ok && reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		diff[key] = &AuditChange{Before: beforeValue, After: afterValue}
+	}
+
+	for key, beforeValue := range beforeMap {
+		if _, ok := afterMap[key]; !ok {
+			diff[key] = &AuditChange{Before: beforeValue}
+		}
+	}
+
+	for key, change := range diff {
+		if !isAuditSensitiveField(key) {
+			change.Before = redactAuditValue(change.Before)
+			change.After = redactAuditValue(change.After)
+			continue
+		}
+		if change.Before != nil {
+			change.Before = auditRedacted
+		}
+		if change.After != nil {
+			change.After = auditRedacted
+		}
+	}
+
+	return diff
+}
+
+func toAuditMap(value any) map[string]any {
+	result := make(map[string]any)
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return result
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return result
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		// 非对象类型统一放到 value 字段
+		var raw any
+		json.Unmarshal(data, &raw)
+		return map[string]any{"value": raw}
+	}
+
+	return result
+}
+
+func isAuditSensitiveField(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range auditSensitiveFields {
+		if strings.HasSuffix(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAuditValue 隐藏嵌套对象中的敏感字段，JSON 字符串（如支付配置）解析后同样处理
+func redactAuditValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			if item != nil && isAuditSensitiveField(key) {
+				result[key] = auditRedacted
+				continue
+			}
+			result[key] = redactAuditValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = redactAuditValue(item)
+		}
+		return result
+	case string:
+		trimmed := strings.TrimSpace(v)
+		if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+			return v
+		}
+
+		var parsed any
+		if err := json.Unmarshal([]byte(trimmed), &parsed); err != nil {
+			return v
+		}
+		data, err := json.Marshal(redactAuditValue(parsed))
+		if err != nil {
+			return v
+		}
+		return string(data)
+	}
+	return value
+}
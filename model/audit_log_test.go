@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffAuditValuesRedactTopLevel(t *testing.T) {
+	diff := DiffAuditValues(
+		map[string]any{"name": "a", "api_key": "old", "password": "p1"},
+		map[string]any{"name": "b", "api_key": "new", "password": "p1"},
+	)
+
+	assert.Equal(t, &AuditChange{Before: "a", After: "b"}, diff["name"])
+	assert.Equal(t, &AuditChange{Before: auditRedacted, After: auditRedacted}, diff["api_key"])
+	assert.NotContains(t, diff, "password")
+}
+
+func TestDiffAuditValuesRedactNested(t *testing.T) {
+	diff := DiffAuditValues(nil, map[string]any{
+		"settings": map[string]any{
+			"webhook": map[string]any{"url": "https://example.com", "secret": "s"},
+			"items":   []any{map[string]any{"token": "t", "name": "n"}},
+		},
+	})
+
+	assert.Equal(t, map[string]any{
+		"webhook": map[string]any{"url": "https://example.com", "secret": auditRedacted},
+		"items":   []any{map[string]any{"token": auditRedacted, "name": "n"}},
+	}, diff["settings"].After)
+}
+
+func TestDiffAuditValuesRedactJSONString(t *testing.T) {
+	before := &Payment{ID: 1, Name: "stripe", Config: `{"secret_key":"sk_old","webhook_secret":"whsec","currency":"usd"}`}
+	after := &Payment{ID: 1, Name: "stripe", Config: `{"secret_key":"sk_new","webhook_secret":"whsec","currency":"usd"}`}
+
+	diff := DiffAuditValues(before, after)
+	change := diff["config"]
+	assert.NotNil(t, change)
+
+	for _, value := range []any{change.Before, change.After} {
+		config, ok := value.(string)
+		assert.True(t, ok)
+		assert.NotContains(t, config, "sk_")
+		assert.NotContains(t, config, "whsec")
+
+		var parsed map[string]any
+		assert.Nil(t, json.Unmarshal([]byte(config), &parsed))
+		assert.Equal(t, auditRedacted, parsed["secret_key"])
+		assert.Equal(t, "usd", parsed["currency"])
+	}
+}
+
+func TestRedactAuditValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{"plain string", "hello", "hello"},
+		{"invalid json string", "{not json", "{not json"},
+		{"number", 1.5, 1.5},
+		{"nil", nil, nil},
+		{"json array string", `[{"key":"k","id":1}]`, `[{"id":1,"key":"******"}]`},
+		{"nested json string", map[string]any{"config": `{"private_key":"pk"}`}, map[string]any{"config": `{"private_key":"******"}`}},
+		{"nil sensitive", map[string]any{"token": nil}, map[string]any{"token": nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, redactAuditValue(tt.value))
+		})
+	}
+}
@@ -25,6 +25,9 @@ type ChannelsChooser struct {
 	Rule      map[string]map[string][][]int // group -> model -> priority -> channelIds
 	Match     []string
 	Cooldowns sync.Map
+	Health    sync.Map // channelId -> *ChannelHealth
+	Breakers  sync.Map // channelId:model -> *CircuitBreaker
+	Scores    sync.Map // channelId -> 最近一次基准测试得分
 
 	ModelGroup map[string]map[string]bool
 }
@@ -61,6 +64,7 @@ func init() {
 		ticker := time.NewTicker(1 * time.Hour)
 		for range ticker.C {
 			ChannelGroup.CleanupExpiredCooldowns()
+			ChannelGroup.CleanupIdleCircuitBreakers()
 		}
 	}()
 }
@@ -131,59 +135,68 @@ func (cc *ChannelsChooser) ChangeStatus(channelId int, status bool) {
 	}
 }
 
+// availableChoice 渠道未禁用、未冷却、健康且熔断器放行时返回，否则返回 nil
+func (cc *ChannelsChooser) availableChoice(channelId int, filters []ChannelsFilterFunc, modelName string) *ChannelChoice {
+	choice, ok := cc.Channels[channelId]
+	if !ok || choice.Disable {
+		return nil
+	}
+
+	if cc.IsInCooldown(channelId, modelName) {
+		return nil
+	}
+
+	if cc.IsSaturated(channelId) || !cc.CircuitAllow(channelId, modelName) {
+		return nil
+	}
+
+	for _, filter := range filters {
+		if filter(channelId, choice) {
+			return nil
+		}
+	}
+
+	return choice
+}
+
 func (cc *ChannelsChooser) balancer(channelIds []int, filters []ChannelsFilterFunc, modelName string) *Channel {
 	totalWeight := 0
 
+	// 权重只计算一次，评分在两次遍历之间可能被更新
 	validChannels := make([]*ChannelChoice, 0, len(channelIds))
+	weights := make([]int, 0, len(channelIds))
 	for _, channelId := range channelIds {
-		choice, ok := cc.Channels[channelId]
-		if !ok || choice.Disable {
-			continue
-		}
-
-		if cc.IsInCooldown(channelId, modelName) {
+		choice := cc.availableChoice(channelId, filters, modelName)
+		if choice == nil {
 			continue
 		}
 
-		isSkip := false
-		for _, filter := range filters {
-			if filter(channelId, choice) {
-				isSkip = true
-				break
-			}
-		}
-		if isSkip {
-			continue
-		}
-
-		weight := int(*choice.Channel.Weight)
+		weight := cc.effectiveWeight(choice.Channel)
 		totalWeight += weight
 		validChannels = append(validChannels, choice)
+		weights = append(weights, weight)
 	}
 
 	if len(validChannels) == 0 {
 		return nil
 	}
 
-	if len(validChannels) == 1 {
-		return validChannels[0].Channel
+	if len(validChannels) == 1 || totalWeight <= 0 {
+		return validChannels[rand.Intn(len(validChannels))].Channel
 	}
 
 	choiceWeight := rand.Intn(totalWeight)
-	for _, choice := range validChannels {
-		weight := int(*choice.Channel.Weight)
-		choiceWeight -= weight
+	for i, choice := range validChannels {
+		choiceWeight -= weights[i]
 		if choiceWeight < 0 {
 			return choice.Channel
 		}
 	}
 
-	return nil
+	return validChannels[len(validChannels)-1].Channel
 }
 
-func (cc *ChannelsChooser) Next(group, modelName string, filters ...ChannelsFilterFunc) (*Channel, error) {
-	cc.RLock()
-	defer cc.RUnlock()
+func (cc *ChannelsChooser) getChannelsPriority(group, modelName string) ([][]int, error) {
 	if _, ok := cc.Rule[group]; !ok {
 		return nil, errors.New("group not found")
 	}
@@ -197,6 +210,63 @@ func (cc *ChannelsChooser) Next(group, modelName string, filters ...ChannelsFilt
 		}
 	}
 
+	return channelsPriority, nil
+}
+
+// HasAvailableChannel 分组下该模型当前是否有可用的渠道
+func (cc *ChannelsChooser) HasAvailableChannel(group, modelName string, filters ...ChannelsFilterFunc) bool {
+	cc.RLock()
+	defer cc.RUnlock()
+
+	channelsPriority, err := cc.getChannelsPriority(group, modelName)
+	if err != nil {
+		return false
+	}
+
+	for _, priority := range channelsPriority {
+		for _, channelId := range priority {
+			if cc.availableChoice(channelId, filters, modelName) != nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AvailableChannels 分组下该模型当前可用的渠道，返回的优先级为分组内的层级，0 为最高
+func (cc *ChannelsChooser) AvailableChannels(group, modelName string, filters ...ChannelsFilterFunc) ([]*Channel, []int) {
+	cc.RLock()
+	defer cc.RUnlock()
+
+	channelsPriority, err := cc.getChannelsPriority(group, modelName)
+	if err != nil {
+		return nil, nil
+	}
+
+	var channels []*Channel
+	var levels []int
+	for level, priority := range channelsPriority {
+		for _, channelId := range priority {
+			if choice := cc.availableChoice(channelId, filters, modelName); choice != nil {
+				channels = append(channels, choice.Channel)
+				levels = append(levels, level)
+			}
+		}
+	}
+
+	return channels, levels
+}
+
+func (cc *ChannelsChooser) Next(group, modelName string, filters ...ChannelsFilterFunc) (*Channel, error) {
+	cc.RLock()
+	defer cc.RUnlock()
+
+	channelsPriority, err := cc.getChannelsPriority(group, modelName)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(channelsPriority) == 0 {
 		return nil, errors.New("channel not found")
 	}
@@ -245,6 +315,20 @@ func (cc *ChannelsChooser) GetChannel(channelId int) *Channel {
 	return nil
 }
 
+func (cc *ChannelsChooser) GetChannelsByTypes(channelTypes []int) []*Channel {
+	cc.RLock()
+	defer cc.RUnlock()
+
+	channels := make([]*Channel, 0)
+	for _, choice := range cc.Channels {
+		if utils.Contains(choice.Channel.Type, channelTypes) {
+			channels = append(channels, choice.Channel)
+		}
+	}
+
+	return channels
+}
+
 var ChannelGroup = ChannelsChooser{}
 
 func (cc *ChannelsChooser) Load() {
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"done-hub/common/config"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestChooser(weights ...uint) (*ChannelsChooser, []int) {
+	cc := &ChannelsChooser{Channels: map[int]*ChannelChoice{}}
+	ids := make([]int, 0, len(weights))
+	for i, weight := range weights {
+		weight := weight
+		id := i + 1
+		cc.Channels[id] = &ChannelChoice{Channel: &Channel{Id: id, Weight: &weight}}
+		ids = append(ids, id)
+	}
+	return cc, ids
+}
+
+func TestBalancerWithChangingScores(t *testing.T) {
+	enabled := config.ChannelBenchmarkWeightEnabled
+	config.ChannelBenchmarkWeightEnabled = true
+	t.Cleanup(func() {
+		config.ChannelBenchmarkWeightEnabled = enabled
+	})
+
+	cc, ids := newTestChooser(1, 1, 1)
+
+	// 选择渠道时评分被并发更新，不能选不出渠道
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			for _, id := range ids {
+				cc.Scores.Store(id, float64((i+id)%10)/10)
+			}
+		}
+	}()
+
+	for i := 0; i < 10000; i++ {
+		assert.NotNil(t, cc.balancer(ids, nil, "gpt-4o"))
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestBalancerZeroWeight(t *testing.T) {
+	cc, ids := newTestChooser(0, 0)
+	assert.NotNil(t, cc.balancer(ids, nil, "gpt-4o"))
+
+	cc, ids = newTestChooser(0, 1)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, 2, cc.balancer(ids, nil, "gpt-4o").Id)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/md5"
 	"done-hub/common/config"
 	"done-hub/common/logger"
+	"done-hub/common/secret"
 	"done-hub/common/utils"
 	"encoding/hex"
 	"slices"
@@ -14,37 +15,50 @@ import (
 )
 
 type Channel struct {
-	Id                 int     `json:"id"`
-	Type               int     `json:"type" form:"type" gorm:"default:0"`
-	Key                string  `json:"key" form:"key" gorm:"type:text"`
-	Status             int     `json:"status" form:"status" gorm:"default:1"`
-	Name               string  `json:"name" form:"name" gorm:"index"`
-	Weight             *uint   `json:"weight" gorm:"default:1"`
-	CreatedTime        int64   `json:"created_time" gorm:"bigint"`
-	TestTime           int64   `json:"test_time" gorm:"bigint"`
-	ResponseTime       int     `json:"response_time"` // in milliseconds
-	BaseURL            *string `json:"base_url" gorm:"column:base_url;default:''"`
-	Other              string  `json:"other" form:"other"`
-	Balance            float64 `json:"balance"` // in USD
-	BalanceUpdatedTime int64   `json:"balance_updated_time" gorm:"bigint"`
-	Models             string  `json:"models" form:"models"`
-	Group              string  `json:"group" form:"group" gorm:"type:varchar(32);default:'default'"`
-	Tag                string  `json:"tag" form:"tag" gorm:"type:varchar(32);default:''"`
-	UsedQuota          int64   `json:"used_quota" gorm:"bigint;default:0"`
-	ModelMapping       *string `json:"model_mapping" gorm:"type:text"`
-	ModelHeaders       *string `json:"model_headers" gorm:"type:varchar(1024);default:''"`
-	CustomParameter    *string `json:"custom_parameter" gorm:"type:varchar(1024);default:''"`
-	Priority           *int64  `json:"priority" gorm:"bigint;default:0"`
-	Proxy              *string `json:"proxy" gorm:"type:varchar(255);default:''"`
-	TestModel          string  `json:"test_model" form:"test_model" gorm:"type:varchar(50);default:''"`
-	OnlyChat           bool    `json:"only_chat" form:"only_chat" gorm:"default:false"`
-	PreCost            int     `json:"pre_cost" form:"pre_cost" gorm:"default:1"`
-	CompatibleResponse bool    `json:"compatible_response" gorm:"default:false"`
+	Id                 int      `json:"id"`
+	Type               int      `json:"type" form:"type" gorm:"default:0"`
+	Key                string   `json:"key" form:"key" gorm:"type:text;serializer:secret"`
+	KeyHash            string   `json:"-" gorm:"type:varchar(64);index;default:''"`
+	Status             int      `json:"status" form:"status" gorm:"default:1"`
+	Name               string   `json:"name" form:"name" gorm:"index"`
+	Weight             *uint    `json:"weight" gorm:"default:1"`
+	CreatedTime        int64    `json:"created_time" gorm:"bigint"`
+	TestTime           int64    `json:"test_time" gorm:"bigint"`
+	ResponseTime       int      `json:"response_time"` // in milliseconds
+	BaseURL            *string  `json:"base_url" gorm:"column:base_url;default:''"`
+	Other              string   `json:"other" form:"other"`
+	Balance            float64  `json:"balance"` // in USD
+	BalanceUpdatedTime int64    `json:"balance_updated_time" gorm:"bigint"`
+	Models             string   `json:"models" form:"models"`
+	Group              string   `json:"group" form:"group" gorm:"type:varchar(32);default:'default'"`
+	Tag                string   `json:"tag" form:"tag" gorm:"type:varchar(32);default:''"`
+	UsedQuota          int64    `json:"used_quota" gorm:"bigint;default:0"`
+	ModelMapping       *string  `json:"model_mapping" gorm:"type:text"`
+	ModelHeaders       *string  `json:"model_headers" gorm:"type:varchar(1024);default:''"`
+	CustomParameter    *string  `json:"custom_parameter" gorm:"type:varchar(1024);default:''"`
+	Priority           *int64   `json:"priority" gorm:"bigint;default:0"`
+	Proxy              *string  `json:"proxy" gorm:"type:varchar(255);default:''"`
+	TestModel          string   `json:"test_model" form:"test_model" gorm:"type:varchar(50);default:''"`
+	OnlyChat           bool     `json:"only_chat" form:"only_chat" gorm:"default:false"`
+	PreCost            int      `json:"pre_cost" form:"pre_cost" gorm:"default:1"`
+	CompatibleResponse bool     `json:"compatible_response" gorm:"default:false"`
+	ToolEmulation      bool     `json:"tool_emulation" gorm:"default:false"` // 模型不支持函数调用时通过提示词模拟
+	CostRatio          *float64 `json:"cost_ratio" gorm:"default:1"`
+
+	// 按模型单独设置的上游成本，优先于 CostRatio
+	ModelCosts *datatypes.JSONType[map[string]ChannelModelCost] `json:"model_costs,omitempty" gorm:"type:json"`
 
 	DisabledStream *datatypes.JSONSlice[string] `json:"disabled_stream,omitempty" gorm:"type:json"`
 
 	Plugin    *datatypes.JSONType[PluginType] `json:"plugin" form:"plugin" gorm:"type:json"`
 	DeletedAt gorm.DeletedAt                  `json:"-" gorm:"index"`
+
+	// 请求处理插件，在分组和令牌的插件之后执行
+	PluginPipeline *datatypes.JSONType[PluginPipeline] `json:"plugin_pipeline,omitempty" gorm:"type:json"`
+
+	// 运行时状态，仅用于管理后台展示
+	Health          *ChannelHealth        `json:"health,omitempty" gorm:"-"`
+	CircuitBreakers []CircuitBreakerState `json:"circuit_breakers,omitempty" gorm:"-"`
 }
 
 func (c *Channel) AllowStream(modelName string) bool {
@@ -115,8 +129,13 @@ func GetChannelsList(params *SearchChannelsParams) (*DataResult[Channel], error)
 	}
 
 	if params.Key != "" {
-		db = db.Where(quotePostgresField("key")+" = ?", params.Key)
-		tagDB = tagDB.Where(quotePostgresField("key")+" = ?", params.Key)
+		// 开启加密后只能通过哈希匹配
+		keyField, keyValue := quotePostgresField("key"), params.Key
+		if secret.Enabled() {
+			keyField, keyValue = "key_hash", secret.Hash(params.Key)
+		}
+		db = db.Where(keyField+" = ?", keyValue)
+		tagDB = tagDB.Where(keyField+" = ?", keyValue)
 	}
 
 	if params.TestModel != "" {
@@ -409,6 +428,19 @@ func UpdateChannelStatusById(id int, status int) {
 	tx.Commit()
 
 	go ChannelGroup.ChangeStatus(id, status == config.ChannelStatusEnabled)
+
+	event := WebhookEventChannelDisabled
+	if status == config.ChannelStatusEnabled {
+		event = WebhookEventChannelEnabled
+	}
+	channel := &Channel{}
+	DB.Select("id", "name", "type").Where("id = ?", id).First(channel)
+	PublishWebhookEvent(event, map[string]any{
+		"id":     id,
+		"name":   channel.Name,
+		"type":   channel.Type,
+		"status": status,
+	})
 }
 
 func UpdateChannelUsedQuota(id int, quota int) {
@@ -0,0 +1,316 @@
+package model
+
+import (
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/common/utils"
+	"errors"
+	"sort"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// 渠道质量基准测试记录，每个渠道的每个模型每次运行保存一条
+type ChannelBenchmark struct {
+	Id                int                                    `json:"id"`
+	ChannelId         int                                    `json:"channel_id" gorm:"index"`
+	Tag               string                                 `json:"tag" gorm:"type:varchar(32);default:'';index"`
+	Model             string                                 `json:"model" gorm:"type:varchar(100);index"`
+	Latency           int                                    `json:"latency"`             // 非流式请求耗时，毫秒
+	FirstResponseTime int                                    `json:"first_response_time"` // 流式首字耗时，毫秒
+	Passed            int                                    `json:"passed"`
+	Total             int                                    `json:"total"`
+	Score             float64                                `json:"score"`
+	Processes         datatypes.JSONType[map[string]float64] `json:"processes" gorm:"type:json"`
+	Error             string                                 `json:"error" gorm:"type:text"`
+	CreatedAt         int64                                  `json:"created_at" gorm:"bigint;index"`
+}
+
+// 基准测试计划，按渠道标签定时运行，标签为空时对所有启用的渠道运行
+type ChannelBenchmarkSchedule struct {
+	Id      int    `json:"id"`
+	Tag     string `json:"tag" gorm:"type:varchar(32);default:''"`
+	Cron    string `json:"cron" gorm:"type:varchar(100)"`
+	Models  string `json:"models" gorm:"type:varchar(1024);default:''"` // 为空时使用渠道的测速模型
+	Enabled bool   `json:"enabled" gorm:"default:true"`
+}
+
+type SearchChannelBenchmarksParams struct {
+	ChannelId int    `form:"channel_id"`
+	Tag       string `form:"tag"`
+	Model     string `form:"model"`
+	PaginationParams
+}
+
+var allowedChannelBenchmarksOrderFields = map[string]bool{
+	"id":                  true,
+	"channel_id":          true,
+	"score":               true,
+	"latency":             true,
+	"first_response_time": true,
+	"created_at":          true,
+}
+
+func GetChannelBenchmarksList(params *SearchChannelBenchmarksParams) (*DataResult[ChannelBenchmark], error) {
+	var benchmarks []*ChannelBenchmark
+	db := DB
+
+	if params.ChannelId != 0 {
+		db = db.Where("channel_id = ?", params.ChannelId)
+	}
+
+	if params.Tag != "" {
+		db = db.Where("tag = ?", params.Tag)
+	}
+
+	if params.Model != "" {
+		db = db.Where("model = ?", params.Model)
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &benchmarks, allowedChannelBenchmarksOrderFields)
+}
+
+func GetChannelBenchmarks(channelId int, modelName string, startTime, endTime int64) ([]*ChannelBenchmark, error) {
+	var benchmarks []*ChannelBenchmark
+	db := DB.Where("channel_id = ? AND created_at >= ? AND created_at < ?", channelId, startTime, endTime)
+	if modelName != "" {
+		db = db.Where("model = ?", modelName)
+	}
+
+	err := db.Order("created_at ASC").Find(&benchmarks).Error
+	return benchmarks, err
+}
+
+func BatchInsertChannelBenchmarks(benchmarks []*ChannelBenchmark) error {
+	if len(benchmarks) == 0 {
+		return nil
+	}
+
+	now := utils.GetTimestamp()
+	for _, benchmark := range benchmarks {
+		benchmark.CreatedAt = now
+	}
+
+	return DB.Create(&benchmarks).Error
+}
+
+type ChannelBenchmarkTrend struct {
+	Date              string             `json:"date"`
+	Runs              int                `json:"runs"`
+	Score             float64            `json:"score"`
+	Latency           int                `json:"latency"`
+	FirstResponseTime int                `json:"first_response_time"`
+	Processes         map[string]float64 `json:"processes"`
+}
+
+type ChannelBenchmarkChange struct {
+	Name     string  `json:"name"`
+	Previous float64 `json:"previous"`
+	Current  float64 `json:"current"`
+	Change   float64 `json:"change"`
+}
+
+// GetChannelBenchmarkTrend 按天汇总最近 days 天的基准测试结果
+func GetChannelBenchmarkTrend(channelId int, modelName string, days int) ([]*ChannelBenchmarkTrend, error) {
+	if days <= 0 {
+		return nil, errors.New("days 参数错误")
+	}
+
+	endTime := time.Now()
+	startTime := endTime.AddDate(0, 0, -days)
+	benchmarks, err := GetChannelBenchmarks(channelId, modelName, startTime.Unix(), endTime.Unix()+1)
+	if err != nil {
+		return nil, err
+	}
+
+	trends := make([]*ChannelBenchmarkTrend, 0)
+	var current *ChannelBenchmarkTrend
+	var group []*ChannelBenchmark
+	for _, benchmark := range benchmarks {
+		date := time.Unix(benchmark.CreatedAt, 0).Format("2006-01-02")
+		if current == nil || current.Date != date {
+			if current != nil {
+				fillChannelBenchmarkTrend(current, group)
+			}
+			current = &ChannelBenchmarkTrend{Date: date}
+			trends = append(trends, current)
+			group = nil
+		}
+		group = append(group, benchmark)
+	}
+	if current != nil {
+		fillChannelBenchmarkTrend(current, group)
+	}
+
+	return trends, nil
+}
+
+// GetChannelBenchmarkChanges 对比最近 days 天与之前 days 天的各项成功率
+func GetChannelBenchmarkChanges(channelId int, modelName string, days int) ([]*ChannelBenchmarkChange, error) {
+	if days <= 0 {
+		return nil, errors.New("days 参数错误")
+	}
+
+	now := time.Now()
+	middle := now.AddDate(0, 0, -days)
+	start := now.AddDate(0, 0, -days*2)
+
+	previous, err := GetChannelBenchmarks(channelId, modelName, start.Unix(), middle.Unix())
+	if err != nil {
+		return nil, err
+	}
+	current, err := GetChannelBenchmarks(channelId, modelName, middle.Unix(), now.Unix()+1)
+	if err != nil {
+		return nil, err
+	}
+
+	previousTrend := &ChannelBenchmarkTrend{}
+	fillChannelBenchmarkTrend(previousTrend, previous)
+	currentTrend := &ChannelBenchmarkTrend{}
+	fillChannelBenchmarkTrend(currentTrend, current)
+
+	changes := []*ChannelBenchmarkChange{
+		newChannelBenchmarkChange("score", previousTrend.Score, currentTrend.Score),
+	}
+
+	names := make([]string, 0, len(currentTrend.Processes))
+	for name := range currentTrend.Processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		previousRate, ok := previousTrend.Processes[name]
+		if !ok {
+			continue
+		}
+		changes = append(changes, newChannelBenchmarkChange(name, previousRate, currentTrend.Processes[name]))
+	}
+
+	return changes, nil
+}
+
+// GetLatestChannelBenchmarkScores 获取每个渠道最近一次运行的平均得分
+func GetLatestChannelBenchmarkScores(since int64) (map[int]float64, error) {
+	var benchmarks []*ChannelBenchmark
+	err := DB.Select("channel_id, score, created_at").Where("created_at >= ?", since).Order("created_at DESC").Find(&benchmarks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[int]int64)
+	sums := make(map[int]float64)
+	counts := make(map[int]int)
+	for _, benchmark := range benchmarks {
+		if createdAt, ok := latest[benchmark.ChannelId]; ok && createdAt != benchmark.CreatedAt {
+			continue
+		}
+		latest[benchmark.ChannelId] = benchmark.CreatedAt
+		sums[benchmark.ChannelId] += benchmark.Score
+		counts[benchmark.ChannelId]++
+	}
+
+	scores := make(map[int]float64, len(sums))
+	for channelId, sum := range sums {
+		scores[channelId] = sum / float64(counts[channelId])
+	}
+
+	return scores, nil
+}
+
+// LoadBenchmarkScores 加载最近七天内每个渠道的基准测试得分
+func (cc *ChannelsChooser) LoadBenchmarkScores() {
+	scores, err := GetLatestChannelBenchmarkScores(time.Now().AddDate(0, 0, -7).Unix())
+	if err != nil {
+		logger.SysError("load channel benchmark scores error: " + err.Error())
+		return
+	}
+
+	cc.Scores.Range(func(key, _ interface{}) bool {
+		if _, ok := scores[key.(int)]; !ok {
+			cc.Scores.Delete(key)
+		}
+		return true
+	})
+
+	for channelId, score := range scores {
+		cc.Scores.Store(channelId, score)
+	}
+}
+
+// 开启后按得分缩放渠道权重，放大 100 倍避免小权重取整为 0，没有测试记录的渠道按满分计算
+func (cc *ChannelsChooser) effectiveWeight(channel *Channel) int {
+	weight := int(*channel.Weight)
+	if !config.ChannelBenchmarkWeightEnabled {
+		return weight
+	}
+
+	score := 1.0
+	if value, ok := cc.Scores.Load(channel.Id); ok {
+		score = value.(float64)
+	}
+
+	return max(1, int(float64(weight*100)*score))
+}
+
+func newChannelBenchmarkChange(name string, previous, current float64) *ChannelBenchmarkChange {
+	return &ChannelBenchmarkChange{
+		Name:     name,
+		Previous: previous,
+		Current:  current,
+		Change:   current - previous,
+	}
+}
+
+func fillChannelBenchmarkTrend(trend *ChannelBenchmarkTrend, benchmarks []*ChannelBenchmark) {
+	trend.Processes = make(map[string]float64)
+	trend.Runs = len(benchmarks)
+	if trend.Runs == 0 {
+		return
+	}
+
+	var score float64
+	var latency, firstResponseTime int
+	processCounts := make(map[string]int)
+	for _, benchmark := range benchmarks {
+		score += benchmark.Score
+		latency += benchmark.Latency
+		firstResponseTime += benchmark.FirstResponseTime
+		for name, rate := range benchmark.Processes.Data() {
+			trend.Processes[name] += rate
+			processCounts[name]++
+		}
+	}
+
+	trend.Score = score / float64(trend.Runs)
+	trend.Latency = latency / trend.Runs
+	trend.FirstResponseTime = firstResponseTime / trend.Runs
+	for name, count := range processCounts {
+		trend.Processes[name] /= float64(count)
+	}
+}
+
+func GetChannelBenchmarkSchedules() ([]*ChannelBenchmarkSchedule, error) {
+	var schedules []*ChannelBenchmarkSchedule
+	err := DB.Order("id ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+func GetChannelBenchmarkScheduleById(id int) (*ChannelBenchmarkSchedule, error) {
+	schedule := &ChannelBenchmarkSchedule{}
+	err := DB.First(schedule, "id = ?", id).Error
+	return schedule, err
+}
+
+func (schedule *ChannelBenchmarkSchedule) Insert() error {
+	return DB.Create(schedule).Error
+}
+
+func (schedule *ChannelBenchmarkSchedule) Update() error {
+	return DB.Model(schedule).Select("tag", "cron", "models", "enabled").Updates(schedule).Error
+}
+
+func (schedule *ChannelBenchmarkSchedule) Delete() error {
+	return DB.Delete(schedule).Error
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"done-hub/common/utils"
+
+	"gorm.io/datatypes"
+)
+
+// 渠道模型指纹检测记录，每次检测保存一条
+type ChannelCheckLog struct {
+	Id                int            `json:"id"`
+	ChannelId         int            `json:"channel_id" gorm:"index"`
+	Model             string         `json:"model" gorm:"type:varchar(100);index"`
+	ResponseModel     string         `json:"response_model" gorm:"type:varchar(100);default:''"`
+	SystemFingerprint string         `json:"system_fingerprint" gorm:"type:varchar(100);default:''"`
+	PromptTokens      int            `json:"prompt_tokens"`
+	LocalPromptTokens int            `json:"local_prompt_tokens"`
+	Suspicious        bool           `json:"suspicious" gorm:"default:false"`
+	Results           datatypes.JSON `json:"results" gorm:"type:json"`
+	CreatedAt         int64          `json:"created_at" gorm:"bigint;index"`
+}
+
+type SearchChannelCheckLogsParams struct {
+	ChannelId  int    `form:"channel_id"`
+	Model      string `form:"model"`
+	Suspicious bool   `form:"suspicious"`
+	PaginationParams
+}
+
+var allowedChannelCheckLogsOrderFields = map[string]bool{
+	"id":         true,
+	"channel_id": true,
+	"created_at": true,
+}
+
+func GetChannelCheckLogsList(params *SearchChannelCheckLogsParams) (*DataResult[ChannelCheckLog], error) {
+	var logs []*ChannelCheckLog
+	db := DB
+
+	if params.ChannelId != 0 {
+		db = db.Where("channel_id = ?", params.ChannelId)
+	}
+
+	if params.Model != "" {
+		db = db.Where("model = ?", params.Model)
+	}
+
+	if params.Suspicious {
+		db = db.Where("suspicious = ?", true)
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &logs, allowedChannelCheckLogsOrderFields)
+}
+
+// GetLastChannelCheckLog 获取渠道模型最近一次的检测记录
+func GetLastChannelCheckLog(channelId int, modelName string) (*ChannelCheckLog, error) {
+	log := &ChannelCheckLog{}
+	err := DB.Where("channel_id = ? AND model = ?", channelId, modelName).Order("id DESC").First(log).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return log, nil
+}
+
+func (log *ChannelCheckLog) Insert() error {
+	log.CreatedAt = utils.GetTimestamp()
+	return DB.Create(log).Error
+}
+
+func DeleteOldChannelCheckLogs(targetTimestamp int64) (int64, error) {
+	result := DB.Where("created_at < ?", targetTimestamp).Delete(&ChannelCheckLog{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ChannelModelCost 渠道某个模型的上游成本，设置了 Input/Output 时按成本价计算，否则使用 Ratio
+type ChannelModelCost struct {
+	Ratio  *float64 `json:"ratio,omitempty"`
+	Input  float64  `json:"input,omitempty"`
+	Output float64  `json:"output,omitempty"`
+}
+
+func (c *Channel) GetCostRatio() float64 {
+	if c.CostRatio == nil {
+		return 1
+	}
+	return *c.CostRatio
+}
+
+func (c *Channel) GetModelCost(modelName string) *ChannelModelCost {
+	if c.ModelCosts == nil {
+		return nil
+	}
+
+	if cost, ok := c.ModelCosts.Data()[modelName]; ok {
+		return &cost
+	}
+	return nil
+}
+
+// GetModelCostRatio 返回模型的成本倍率，未单独设置时使用渠道倍率
+func (c *Channel) GetModelCostRatio(modelName string) float64 {
+	if cost := c.GetModelCost(modelName); cost != nil && cost.Ratio != nil {
+		return *cost.Ratio
+	}
+	return c.GetCostRatio()
+}
+
+// ResolveCost 根据售价（不含分组倍率）计算上游成本价格
+func (c *Channel) ResolveCost(modelName string, input, output float64) (float64, float64) {
+	if cost := c.GetModelCost(modelName); cost != nil && (cost.Input > 0 || cost.Output > 0) {
+		return cost.Input, cost.Output
+	}
+
+	ratio := c.GetModelCostRatio(modelName)
+	return input * ratio, output * ratio
+}
+
+func (c *Channel) ValidateCost() error {
+	if c.CostRatio != nil && *c.CostRatio < 0 {
+		return errors.New("成本倍率不能为负数")
+	}
+
+	if c.ModelCosts == nil {
+		return nil
+	}
+
+	for modelName, cost := range c.ModelCosts.Data() {
+		if (cost.Ratio != nil && *cost.Ratio < 0) || cost.Input < 0 || cost.Output < 0 {
+			return fmt.Errorf("模型 %s 的成本不能为负数", modelName)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"done-hub/common/config"
+	"time"
+)
+
+// 自部署推理服务的健康状态，由定时健康检查写入
+type ChannelHealth struct {
+	Healthy    bool   `json:"healthy"`
+	QueueDepth int    `json:"queue_depth"`
+	Running    int    `json:"running"`
+	Message    string `json:"message,omitempty"`
+	CheckedAt  int64  `json:"checked_at"`
+}
+
+func (cc *ChannelsChooser) SetHealth(channelId int, health *ChannelHealth) {
+	health.CheckedAt = time.Now().Unix()
+	cc.Health.Store(channelId, health)
+}
+
+// GetHealth 获取渠道健康状态，超过三个检查周期未更新的视为无数据
+func (cc *ChannelsChooser) GetHealth(channelId int) *ChannelHealth {
+	value, ok := cc.Health.Load(channelId)
+	if !ok {
+		return nil
+	}
+
+	health := value.(*ChannelHealth)
+	expire := int64(config.LocalChannelHealthCheckInterval * 3)
+	if expire < 60 {
+		expire = 60
+	}
+	if time.Now().Unix()-health.CheckedAt > expire {
+		return nil
+	}
+
+	return health
+}
+
+func (cc *ChannelsChooser) DeleteHealth(channelId int) {
+	cc.Health.Delete(channelId)
+}
+
+// IsSaturated 渠道健康检查失败或排队请求数达到上限时跳过
+func (cc *ChannelsChooser) IsSaturated(channelId int) bool {
+	health := cc.GetHealth(channelId)
+	if health == nil {
+		return false
+	}
+
+	if !health.Healthy {
+		return true
+	}
+
+	return config.LocalChannelMaxQueueDepth > 0 && health.QueueDepth >= config.LocalChannelMaxQueueDepth
+}
@@ -159,6 +159,10 @@ func UpdateChannelsTag(tag string, channel *Channel) error {
 			PreCost:            channel.PreCost,
 			DisabledStream:     channel.DisabledStream,
 			CompatibleResponse: channel.CompatibleResponse,
+			ToolEmulation:      channel.ToolEmulation,
+			CostRatio:          channel.CostRatio,
+			ModelCosts:         channel.ModelCosts,
+			PluginPipeline:     channel.PluginPipeline,
 		}).Error
 
 	if err != nil {
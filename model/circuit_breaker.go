@@ -0,0 +1,282 @@
+package model
+
+import (
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/common/notify"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	CircuitStateClosed   = "closed"
+	CircuitStateOpen     = "open"
+	CircuitStateHalfOpen = "half_open"
+)
+
+// 熔断时长最多翻倍的次数
+const circuitMaxBackoff = 5
+
+type CircuitBreakerState struct {
+	ChannelId         int    `json:"channel_id"`
+	Model             string `json:"model"`
+	State             string `json:"state"`
+	Requests          int    `json:"requests"`
+	Failures          int    `json:"failures"`
+	WindowStart       int64  `json:"window_start"`
+	Trips             int    `json:"trips"`
+	OpenUntil         int64  `json:"open_until"`
+	HalfOpenSuccesses int    `json:"half_open_successes"`
+	LastProbeTime     int64  `json:"last_probe_time"`
+	LastError         string `json:"last_error"`
+	UpdatedAt         int64  `json:"updated_at"`
+}
+
+type CircuitBreaker struct {
+	sync.Mutex
+	CircuitBreakerState
+}
+
+func circuitKey(channelId int, modelName string) string {
+	return fmt.Sprintf("%d:%s", channelId, modelName)
+}
+
+func (cc *ChannelsChooser) getCircuitBreaker(channelId int, modelName string, create bool) *CircuitBreaker {
+	key := circuitKey(channelId, modelName)
+	if value, ok := cc.Breakers.Load(key); ok {
+		return value.(*CircuitBreaker)
+	}
+
+	if !create {
+		return nil
+	}
+
+	breaker := &CircuitBreaker{
+		CircuitBreakerState: CircuitBreakerState{
+			ChannelId:   channelId,
+			Model:       modelName,
+			State:       CircuitStateClosed,
+			WindowStart: time.Now().Unix(),
+		},
+	}
+	value, _ := cc.Breakers.LoadOrStore(key, breaker)
+
+	return value.(*CircuitBreaker)
+}
+
+// CircuitAllow 判断请求是否可以通过熔断器，半开状态下只放行一部分流量
+func (cc *ChannelsChooser) CircuitAllow(channelId int, modelName string) bool {
+	if !config.CircuitBreakerEnabled {
+		return true
+	}
+
+	breaker := cc.getCircuitBreaker(channelId, modelName, false)
+	if breaker == nil {
+		return true
+	}
+
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	now := time.Now().Unix()
+	switch breaker.State {
+	case CircuitStateOpen:
+		if now < breaker.OpenUntil {
+			return false
+		}
+		breaker.halfOpen(now)
+	case CircuitStateClosed:
+		return true
+	}
+
+	return rand.Float64() < config.CircuitBreakerHalfOpenRatio
+}
+
+func (cc *ChannelsChooser) RecordCircuitSuccess(channelId int, modelName string) {
+	if !config.CircuitBreakerEnabled || channelId == 0 || modelName == "" {
+		return
+	}
+
+	breaker := cc.getCircuitBreaker(channelId, modelName, true)
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	now := time.Now().Unix()
+	breaker.UpdatedAt = now
+
+	switch breaker.State {
+	case CircuitStateClosed:
+		breaker.rollWindow(now)
+		breaker.Requests++
+	case CircuitStateHalfOpen:
+		breaker.HalfOpenSuccesses++
+		if breaker.HalfOpenSuccesses >= config.CircuitBreakerHalfOpenSuccesses {
+			breaker.close(now)
+			go cc.notifyCircuit(breaker.CircuitBreakerState)
+		}
+	}
+}
+
+// RecordCircuitFailure 记录失败，fatal 为 true 时（如密钥失效）直接熔断
+func (cc *ChannelsChooser) RecordCircuitFailure(channelId int, modelName string, reason string, fatal bool) {
+	if !config.CircuitBreakerEnabled || channelId == 0 || modelName == "" {
+		return
+	}
+
+	breaker := cc.getCircuitBreaker(channelId, modelName, true)
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	now := time.Now().Unix()
+	breaker.UpdatedAt = now
+	breaker.LastError = reason
+
+	switch breaker.State {
+	case CircuitStateClosed:
+		breaker.rollWindow(now)
+		breaker.Requests++
+		breaker.Failures++
+
+		errorRate := float64(breaker.Failures) / float64(breaker.Requests)
+		if fatal || (breaker.Requests >= config.CircuitBreakerMinRequests && errorRate >= config.CircuitBreakerErrorRate) {
+			breaker.trip(now)
+			go cc.notifyCircuit(breaker.CircuitBreakerState)
+		}
+	case CircuitStateHalfOpen:
+		breaker.trip(now)
+		go cc.notifyCircuit(breaker.CircuitBreakerState)
+	}
+}
+
+// CircuitProbeTargets 返回需要主动探测的熔断器，探测间隔为基础熔断时长
+func (cc *ChannelsChooser) CircuitProbeTargets() []CircuitBreakerState {
+	targets := make([]CircuitBreakerState, 0)
+	if !config.CircuitBreakerEnabled {
+		return targets
+	}
+
+	now := time.Now().Unix()
+	cc.Breakers.Range(func(_, value interface{}) bool {
+		breaker := value.(*CircuitBreaker)
+		breaker.Lock()
+		defer breaker.Unlock()
+
+		if breaker.State == CircuitStateOpen && now >= breaker.OpenUntil {
+			breaker.halfOpen(now)
+		}
+
+		if breaker.State == CircuitStateHalfOpen && now-breaker.LastProbeTime >= int64(config.CircuitBreakerOpenSeconds) {
+			breaker.LastProbeTime = now
+			targets = append(targets, breaker.CircuitBreakerState)
+		}
+		return true
+	})
+
+	return targets
+}
+
+// GetCircuitBreakers 获取渠道下所有未闭合的熔断器
+func (cc *ChannelsChooser) GetCircuitBreakers(channelId int) []CircuitBreakerState {
+	states := make([]CircuitBreakerState, 0)
+	cc.Breakers.Range(func(_, value interface{}) bool {
+		breaker := value.(*CircuitBreaker)
+		breaker.Lock()
+		defer breaker.Unlock()
+
+		if breaker.ChannelId == channelId && breaker.State != CircuitStateClosed {
+			states = append(states, breaker.CircuitBreakerState)
+		}
+		return true
+	})
+
+	return states
+}
+
+func (cc *ChannelsChooser) ResetCircuitBreakers(channelId int) {
+	cc.Breakers.Range(func(key, value interface{}) bool {
+		if value.(*CircuitBreaker).ChannelId == channelId {
+			cc.Breakers.Delete(key)
+		}
+		return true
+	})
+}
+
+// CleanupIdleCircuitBreakers 清理长时间没有请求的闭合熔断器
+func (cc *ChannelsChooser) CleanupIdleCircuitBreakers() {
+	now := time.Now().Unix()
+	cc.Breakers.Range(func(key, value interface{}) bool {
+		breaker := value.(*CircuitBreaker)
+		breaker.Lock()
+		idle := breaker.State == CircuitStateClosed && now-breaker.UpdatedAt > int64(config.CircuitBreakerWindowSeconds)
+		breaker.Unlock()
+
+		if idle {
+			cc.Breakers.Delete(key)
+		}
+		return true
+	})
+}
+
+func (cc *ChannelsChooser) notifyCircuit(state CircuitBreakerState) {
+	channelName := ""
+	if channel := cc.GetChannel(state.ChannelId); channel != nil {
+		channelName = channel.Name
+	}
+
+	var subject, content string
+	switch state.State {
+	case CircuitStateOpen:
+		subject = fmt.Sprintf("通道「%s」（#%d）模型 %s 已熔断", channelName, state.ChannelId, state.Model)
+		content = fmt.Sprintf("%s，将于 %s 后尝试恢复，原因：%s", subject, time.Unix(state.OpenUntil, 0).Format("2006-01-02 15:04:05"), state.LastError)
+	case CircuitStateClosed:
+		subject = fmt.Sprintf("通道「%s」（#%d）模型 %s 已恢复", channelName, state.ChannelId, state.Model)
+		content = subject
+	default:
+		return
+	}
+
+	logger.SysLog(content)
+	notify.Send(subject, content)
+}
+
+func (b *CircuitBreaker) rollWindow(now int64) {
+	if now-b.WindowStart < int64(config.CircuitBreakerWindowSeconds) {
+		return
+	}
+
+	b.WindowStart = now
+	b.Requests = 0
+	b.Failures = 0
+}
+
+func (b *CircuitBreaker) trip(now int64) {
+	backoff := b.Trips
+	if backoff > circuitMaxBackoff {
+		backoff = circuitMaxBackoff
+	}
+
+	b.State = CircuitStateOpen
+	b.Trips++
+	b.OpenUntil = now + int64(config.CircuitBreakerOpenSeconds)<<backoff
+	b.HalfOpenSuccesses = 0
+	b.Requests = 0
+	b.Failures = 0
+}
+
+func (b *CircuitBreaker) halfOpen(now int64) {
+	b.State = CircuitStateHalfOpen
+	b.HalfOpenSuccesses = 0
+	b.UpdatedAt = now
+}
+
+func (b *CircuitBreaker) close(now int64) {
+	b.State = CircuitStateClosed
+	b.Trips = 0
+	b.OpenUntil = 0
+	b.HalfOpenSuccesses = 0
+	b.WindowStart = now
+	b.Requests = 0
+	b.Failures = 0
+}
@@ -0,0 +1,683 @@
+package model
+
+import (
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/common/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+const ConfigBundleVersion = 1
+
+// 导出时密钥的处理方式
+const (
+	ConfigSecretPlain   = "plain"
+	ConfigSecretMask    = "mask"
+	ConfigSecretEncrypt = "encrypt"
+)
+
+const (
+	ConfigKindChannel      = "channel"
+	ConfigKindUserGroup    = "user_group"
+	ConfigKindPrice        = "price"
+	ConfigKindModelOwnedBy = "model_owned_by"
+	ConfigKindOption       = "option"
+)
+
+// 被隐藏的密钥，导入时保留数据库中的原值
+const ConfigSecretMasked = "******"
+
+// 运行时产生的数据，不属于配置
+var configChannelRuntimeFields = []string{"id", "created_time", "test_time", "response_time", "balance", "balance_updated_time", "used_quota", "health", "circuit_breakers"}
+
+// 内部状态，不参与导入导出
+var configIgnoredOptions = map[string]bool{
+	"OldTokenMaxId": true,
+}
+
+// ConfigBundle 声明式配置文件，未出现的部分在导入时保持不变
+type ConfigBundle struct {
+	Version      int               `json:"version"`
+	ExportedAt   int64             `json:"exported_at,omitempty"`
+	Channels     []*Channel        `json:"channels,omitempty"`
+	UserGroups   []*UserGroup      `json:"user_groups,omitempty"`
+	Prices       []*Price          `json:"prices,omitempty"`
+	ModelOwnedBy []*ModelOwnedBy   `json:"model_owned_by,omitempty"`
+	Options      map[string]string `json:"options,omitempty"`
+}
+
+type ConfigApplyOptions struct {
+	DryRun     bool `json:"dry_run"`
+	Prune      bool `json:"prune"` // 删除数据库中存在但配置文件中没有的条目
+	Passphrase string
+}
+
+type ConfigChange struct {
+	Kind   string                  `json:"kind"`
+	Name   string                  `json:"name"`
+	Action string                  `json:"action"`
+	Diff   map[string]*AuditChange `json:"diff,omitempty"`
+
+	apply func(tx *gorm.DB) error
+}
+
+func IsSecretOption(key string) bool {
+	return strings.HasSuffix(key, "Token") ||
+		strings.HasSuffix(key, "Secret") ||
+		strings.HasSuffix(key, "SecretKey") ||
+		key == "CFWorkerImageKey"
+}
+
+// ExportConfigBundle 导出当前配置
+func ExportConfigBundle(secretMode, passphrase string) (*ConfigBundle, error) {
+	if secretMode == "" {
+		secretMode = ConfigSecretMask
+	}
+	if secretMode == ConfigSecretEncrypt && passphrase == "" {
+		return nil, errors.New("加密导出需要提供口令")
+	}
+
+	protect := func(value string) (string, error) {
+		if value == "" {
+			return value, nil
+		}
+		switch secretMode {
+		case ConfigSecretPlain:
+			return value, nil
+		case ConfigSecretEncrypt:
+			return common.EncryptWithPassphrase(value, passphrase)
+		default:
+			return ConfigSecretMasked, nil
+		}
+	}
+
+	bundle := &ConfigBundle{
+		Version:    ConfigBundleVersion,
+		ExportedAt: utils.GetTimestamp(),
+		Options:    make(map[string]string),
+	}
+
+	if err := DB.Order("id asc").Find(&bundle.Channels).Error; err != nil {
+		return nil, err
+	}
+	for _, channel := range bundle.Channels {
+		key, err := protect(channel.Key)
+		if err != nil {
+			return nil, err
+		}
+		channel.Key = key
+	}
+
+	if err := DB.Order("id asc").Find(&bundle.UserGroups).Error; err != nil {
+		return nil, err
+	}
+
+	if err := DB.Order("model asc").Find(&bundle.Prices).Error; err != nil {
+		return nil, err
+	}
+
+	if err := DB.Order("id asc").Find(&bundle.ModelOwnedBy).Error; err != nil {
+		return nil, err
+	}
+
+	for key, value := range config.GlobalOption.GetAll() {
+		if configIgnoredOptions[key] {
+			continue
+		}
+		if IsSecretOption(key) {
+			protected, err := protect(value)
+			if err != nil {
+				return nil, err
+			}
+			value = protected
+		}
+		bundle.Options[key] = value
+	}
+
+	return bundle, nil
+}
+
+// EncodeConfigBundle 按格式输出配置，去掉渠道中的运行时字段
+func EncodeConfigBundle(bundle *ConfigBundle, format string) ([]byte, error) {
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	if channels, ok := raw["channels"].([]any); ok {
+		for _, item := range channels {
+			if channel, ok := item.(map[string]any); ok {
+				for _, field := range configChannelRuntimeFields {
+					delete(channel, field)
+				}
+			}
+		}
+	}
+
+	if format == "json" {
+		return json.MarshalIndent(raw, "", "  ")
+	}
+
+	return yaml.Marshal(raw)
+}
+
+// DecodeConfigBundle 解析 YAML 或 JSON 格式的配置
+func DecodeConfigBundle(data []byte) (*ConfigBundle, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("配置文件格式错误: %v", err)
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &ConfigBundle{}
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return nil, fmt.Errorf("配置文件格式错误: %v", err)
+	}
+
+	if bundle.Version > ConfigBundleVersion {
+		return nil, fmt.Errorf("不支持的配置版本: %d", bundle.Version)
+	}
+
+	return bundle, nil
+}
+
+// ApplyConfigBundle 将配置文件同步到数据库，DryRun 时只返回变更计划
+func ApplyConfigBundle(bundle *ConfigBundle, opts *ConfigApplyOptions) ([]*ConfigChange, error) {
+	planners := []func(*ConfigBundle, *ConfigApplyOptions) ([]*ConfigChange, error){
+		planModelOwnedByChanges,
+		planUserGroupChanges,
+		planPriceChanges,
+		planChannelChanges,
+		planOptionChanges,
+	}
+
+	changes := make([]*ConfigChange, 0)
+	for _, planner := range planners {
+		sectionChanges, err := planner(bundle, opts)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, sectionChanges...)
+	}
+
+	if opts.DryRun || len(changes) == 0 {
+		return changes, nil
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, change := range changes {
+			if err := change.apply(tx); err != nil {
+				return fmt.Errorf("%s %s: %v", change.Kind, change.Name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reloadConfigCaches(bundle)
+
+	return changes, nil
+}
+
+func reloadConfigCaches(bundle *ConfigBundle) {
+	for key, value := range bundle.Options {
+		config.GlobalOption.Set(key, value)
+	}
+
+	ModelOwnedBysInstance.Load()
+	GlobalUserGroupRatio.Load()
+	PricingInstance.Init()
+	ChannelGroup.Load()
+}
+
+func resolveConfigSecret(value, passphrase string) (string, error) {
+	if !common.IsEncryptedValue(value) {
+		return value, nil
+	}
+	if passphrase == "" {
+		return "", errors.New("配置中包含加密内容，需要提供口令")
+	}
+	return common.DecryptWithPassphrase(value, passphrase)
+}
+
+func planModelOwnedByChanges(bundle *ConfigBundle, opts *ConfigApplyOptions) ([]*ConfigChange, error) {
+	if bundle.ModelOwnedBy == nil {
+		return nil, nil
+	}
+
+	existing, err := GetAllModelOwnedBy()
+	if err != nil {
+		return nil, err
+	}
+	existingMap := make(map[int]*ModelOwnedBy, len(existing))
+	for _, item := range existing {
+		existingMap[item.Id] = item
+	}
+
+	changes := make([]*ConfigChange, 0)
+	seen := make(map[int]bool)
+	for _, item := range bundle.ModelOwnedBy {
+		if item.Id <= 0 {
+			return nil, fmt.Errorf("模型归属 %s 缺少 id", item.Name)
+		}
+		if seen[item.Id] {
+			return nil, fmt.Errorf("模型归属 id 重复: %d", item.Id)
+		}
+		seen[item.Id] = true
+
+		item := item
+		name := fmt.Sprintf("%d", item.Id)
+		old, ok := existingMap[item.Id]
+		if !ok {
+			changes = append(changes, &ConfigChange{
+				Kind:   ConfigKindModelOwnedBy,
+				Name:   name,
+				Action: AuditActionCreate,
+				Diff:   DiffAuditValues(nil, item),
+				apply:  func(tx *gorm.DB) error { return tx.Create(item).Error },
+			})
+			continue
+		}
+
+		diff := DiffAuditValues(old, item)
+		if len(diff) == 0 {
+			continue
+		}
+		changes = append(changes, &ConfigChange{
+			Kind:   ConfigKindModelOwnedBy,
+			Name:   name,
+			Action: AuditActionUpdate,
+			Diff:   diff,
+			apply:  func(tx *gorm.DB) error { return tx.Save(item).Error },
+		})
+	}
+
+	if opts.Prune {
+		for _, old := range existing {
+			if seen[old.Id] {
+				continue
+			}
+			old := old
+			changes = append(changes, &ConfigChange{
+				Kind:   ConfigKindModelOwnedBy,
+				Name:   fmt.Sprintf("%d", old.Id),
+				Action: AuditActionDelete,
+				Diff:   DiffAuditValues(old, nil),
+				apply:  func(tx *gorm.DB) error { return tx.Delete(&ModelOwnedBy{}, old.Id).Error },
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+func planUserGroupChanges(bundle *ConfigBundle, opts *ConfigApplyOptions) ([]*ConfigChange, error) {
+	if bundle.UserGroups == nil {
+		return nil, nil
+	}
+
+	var existing []*UserGroup
+	if err := DB.Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	existingMap := make(map[string]*UserGroup, len(existing))
+	for _, item := range existing {
+		existingMap[item.Symbol] = item
+	}
+
+	changes := make([]*ConfigChange, 0)
+	seen := make(map[string]bool)
+	for _, item := range bundle.UserGroups {
+		if item.Symbol == "" {
+			return nil, errors.New("用户分组缺少 symbol")
+		}
+		if seen[item.Symbol] {
+			return nil, fmt.Errorf("用户分组重复: %s", item.Symbol)
+		}
+		seen[item.Symbol] = true
+
+		item := item
+		if item.Enable == nil {
+			item.Enable = utils.GetPointer(true)
+		}
+		if err := item.GetPluginPipeline().Validate(); err != nil {
+			return nil, fmt.Errorf("用户分组 %s: %s", item.Symbol, err.Error())
+		}
+
+		old, ok := existingMap[item.Symbol]
+		if !ok {
+			item.Id = 0
+			changes = append(changes, &ConfigChange{
+				Kind:   ConfigKindUserGroup,
+				Name:   item.Symbol,
+				Action: AuditActionCreate,
+				Diff:   DiffAuditValues(nil, item),
+				apply:  func(tx *gorm.DB) error { return tx.Create(item).Error },
+			})
+			continue
+		}
+
+		item.Id = old.Id
+		diff := DiffAuditValues(old, item)
+		if len(diff) == 0 {
+			continue
+		}
+		changes = append(changes, &ConfigChange{
+			Kind:   ConfigKindUserGroup,
+			Name:   item.Symbol,
+			Action: AuditActionUpdate,
+			Diff:   diff,
+			apply:  func(tx *gorm.DB) error { return tx.Save(item).Error },
+		})
+	}
+
+	if opts.Prune {
+		for _, old := range existing {
+			if seen[old.Symbol] {
+				continue
+			}
+			old := old
+			changes = append(changes, &ConfigChange{
+				Kind:   ConfigKindUserGroup,
+				Name:   old.Symbol,
+				Action: AuditActionDelete,
+				Diff:   DiffAuditValues(old, nil),
+				apply:  func(tx *gorm.DB) error { return tx.Delete(old).Error },
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+func planPriceChanges(bundle *ConfigBundle, opts *ConfigApplyOptions) ([]*ConfigChange, error) {
+	if bundle.Prices == nil {
+		return nil, nil
+	}
+
+	existing, err := GetAllPrices()
+	if err != nil {
+		return nil, err
+	}
+	existingMap := make(map[string]*Price, len(existing))
+	for _, item := range existing {
+		existingMap[item.Model] = item
+	}
+
+	changes := make([]*ConfigChange, 0)
+	seen := make(map[string]bool)
+	for _, item := range bundle.Prices {
+		if item.Model == "" {
+			return nil, errors.New("价格缺少 model")
+		}
+		if seen[item.Model] {
+			return nil, fmt.Errorf("价格重复: %s", item.Model)
+		}
+		seen[item.Model] = true
+
+		item := item
+		if item.Type == "" {
+			item.Type = TokensPriceType
+		}
+		if err := item.ValidateRules(); err != nil {
+			return nil, fmt.Errorf("价格 %s: %s", item.Model, err.Error())
+		}
+
+		old, ok := existingMap[item.Model]
+		if !ok {
+			changes = append(changes, &ConfigChange{
+				Kind:   ConfigKindPrice,
+				Name:   item.Model,
+				Action: AuditActionCreate,
+				Diff:   DiffAuditValues(nil, item),
+				apply:  func(tx *gorm.DB) error { return tx.Create(item).Error },
+			})
+			continue
+		}
+
+		diff := DiffAuditValues(old, item)
+		if len(diff) == 0 {
+			continue
+		}
+		changes = append(changes, &ConfigChange{
+			Kind:   ConfigKindPrice,
+			Name:   item.Model,
+			Action: AuditActionUpdate,
+			Diff:   diff,
+			apply: func(tx *gorm.DB) error {
+				return tx.Model(item).Select("*").Where("model = ?", item.Model).Updates(item).Error
+			},
+		})
+	}
+
+	if opts.Prune {
+		for _, old := range existing {
+			if seen[old.Model] {
+				continue
+			}
+			old := old
+			changes = append(changes, &ConfigChange{
+				Kind:   ConfigKindPrice,
+				Name:   old.Model,
+				Action: AuditActionDelete,
+				Diff:   DiffAuditValues(old, nil),
+				apply:  func(tx *gorm.DB) error { return tx.Where("model = ?", old.Model).Delete(&Price{}).Error },
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+// 渠道按名称匹配，不同实例之间的 id 并不一致
+func planChannelChanges(bundle *ConfigBundle, opts *ConfigApplyOptions) ([]*ConfigChange, error) {
+	if bundle.Channels == nil {
+		return nil, nil
+	}
+
+	existing, err := GetAllChannels()
+	if err != nil {
+		return nil, err
+	}
+	existingMap := make(map[string]*Channel, len(existing))
+	duplicated := make(map[string]bool)
+	for _, item := range existing {
+		if _, ok := existingMap[item.Name]; ok {
+			duplicated[item.Name] = true
+		}
+		existingMap[item.Name] = item
+	}
+
+	changes := make([]*ConfigChange, 0)
+	seen := make(map[string]bool)
+	for _, item := range bundle.Channels {
+		if item.Name == "" {
+			return nil, errors.New("渠道缺少 name")
+		}
+		if seen[item.Name] {
+			return nil, fmt.Errorf("渠道名称重复: %s", item.Name)
+		}
+		if duplicated[item.Name] {
+			return nil, fmt.Errorf("数据库中存在多个名为 %s 的渠道，无法匹配", item.Name)
+		}
+		seen[item.Name] = true
+
+		item := item
+		normalizeConfigChannel(item)
+		if err := item.ValidateCost(); err != nil {
+			return nil, fmt.Errorf("渠道 %s: %s", item.Name, err.Error())
+		}
+		if err := item.GetPluginPipeline().Validate(); err != nil {
+			return nil, fmt.Errorf("渠道 %s: %s", item.Name, err.Error())
+		}
+
+		old, ok := existingMap[item.Name]
+		if item.Key == ConfigSecretMasked {
+			if !ok {
+				return nil, fmt.Errorf("渠道 %s 的密钥已被隐藏，无法创建", item.Name)
+			}
+			item.Key = old.Key
+		} else {
+			key, err := resolveConfigSecret(item.Key, opts.Passphrase)
+			if err != nil {
+				return nil, fmt.Errorf("渠道 %s: %v", item.Name, err)
+			}
+			item.Key = key
+		}
+
+		if !ok {
+			changes = append(changes, &ConfigChange{
+				Kind:   ConfigKindChannel,
+				Name:   item.Name,
+				Action: AuditActionCreate,
+				Diff:   DiffAuditValues(nil, item),
+				apply: func(tx *gorm.DB) error {
+					item.CreatedTime = utils.GetTimestamp()
+					return tx.Omit("UsedQuota").Create(item).Error
+				},
+			})
+			continue
+		}
+
+		current := *old
+		normalizeConfigChannel(&current)
+		diff := DiffAuditValues(&current, item)
+		if len(diff) == 0 {
+			continue
+		}
+
+		// 保留运行时数据
+		item.Id = old.Id
+		item.CreatedTime = old.CreatedTime
+		item.TestTime = old.TestTime
+		item.ResponseTime = old.ResponseTime
+		item.Balance = old.Balance
+		item.BalanceUpdatedTime = old.BalanceUpdatedTime
+		changes = append(changes, &ConfigChange{
+			Kind:   ConfigKindChannel,
+			Name:   item.Name,
+			Action: AuditActionUpdate,
+			Diff:   diff,
+			apply: func(tx *gorm.DB) error {
+				return tx.Model(item).Select("*").Omit("UsedQuota", "DeletedAt").Updates(item).Error
+			},
+		})
+	}
+
+	if opts.Prune {
+		for _, old := range existing {
+			if seen[old.Name] {
+				continue
+			}
+			old := old
+			changes = append(changes, &ConfigChange{
+				Kind:   ConfigKindChannel,
+				Name:   old.Name,
+				Action: AuditActionDelete,
+				Diff:   DiffAuditValues(old, nil),
+				apply:  func(tx *gorm.DB) error { return tx.Delete(old).Error },
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+// 清除运行时字段并补齐默认值，使配置文件与数据库中的记录可以直接比较
+func normalizeConfigChannel(channel *Channel) {
+	channel.Id = 0
+	channel.CreatedTime = 0
+	channel.TestTime = 0
+	channel.ResponseTime = 0
+	channel.Balance = 0
+	channel.BalanceUpdatedTime = 0
+	channel.UsedQuota = 0
+	channel.Health = nil
+	channel.CircuitBreakers = nil
+
+	if channel.Status == 0 {
+		channel.Status = config.ChannelStatusEnabled
+	}
+	if channel.Group == "" {
+		channel.Group = "default"
+	}
+	if channel.Weight == nil {
+		weight := uint(1)
+		channel.Weight = &weight
+	}
+	if channel.Priority == nil {
+		priority := int64(0)
+		channel.Priority = &priority
+	}
+
+	for _, field := range []**string{&channel.BaseURL, &channel.ModelHeaders, &channel.CustomParameter, &channel.Proxy} {
+		if *field == nil {
+			*field = utils.GetPointer("")
+		}
+	}
+}
+
+func planOptionChanges(bundle *ConfigBundle, opts *ConfigApplyOptions) ([]*ConfigChange, error) {
+	if bundle.Options == nil {
+		return nil, nil
+	}
+
+	current := config.GlobalOption.GetAll()
+	keys := make([]string, 0, len(bundle.Options))
+	for key := range bundle.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	changes := make([]*ConfigChange, 0)
+	for _, key := range keys {
+		oldValue, ok := current[key]
+		if !ok || configIgnoredOptions[key] {
+			return nil, fmt.Errorf("未知的配置项: %s", key)
+		}
+
+		value := bundle.Options[key]
+		if value == ConfigSecretMasked {
+			bundle.Options[key] = oldValue
+			continue
+		}
+		value, err := resolveConfigSecret(value, opts.Passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("配置项 %s: %v", key, err)
+		}
+		bundle.Options[key] = value
+
+		if value == oldValue {
+			continue
+		}
+
+		option := &Option{Key: key, Value: value}
+		changes = append(changes, &ConfigChange{
+			Kind:   ConfigKindOption,
+			Name:   key,
+			Action: AuditActionUpdate,
+			Diff:   DiffAuditValues(map[string]string{key: oldValue}, map[string]string{key: value}),
+			apply:  func(tx *gorm.DB) error { return tx.Save(option).Error },
+		})
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,245 @@
+package model
+
+import (
+	"done-hub/common"
+	"done-hub/common/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupConfigSyncTest 准备一个渠道、价格、用户分组、模型归属和两个配置项，返回配置项的值
+func setupConfigSyncTest(t *testing.T) (systemName, smtpToken *string) {
+	db := setupTestDB(t, &Channel{}, &UserGroup{}, &Price{}, &ModelOwnedBy{}, &Option{})
+
+	// 导入后会重新加载缓存，替换为测试用的实例
+	oldOption, oldPricing, oldOwnedBy := config.GlobalOption, PricingInstance, ModelOwnedBysInstance
+	ChannelGroup.Lock()
+	oldChannels, oldRule, oldMatch, oldModelGroup := ChannelGroup.Channels, ChannelGroup.Rule, ChannelGroup.Match, ChannelGroup.ModelGroup
+	ChannelGroup.Unlock()
+	GlobalUserGroupRatio.RLock()
+	oldUserGroup, oldAPILimiter, oldPublicGroup := GlobalUserGroupRatio.UserGroup, GlobalUserGroupRatio.APILimiter, GlobalUserGroupRatio.PublicGroup
+	GlobalUserGroupRatio.RUnlock()
+	t.Cleanup(func() {
+		config.GlobalOption, PricingInstance, ModelOwnedBysInstance = oldOption, oldPricing, oldOwnedBy
+		ChannelGroup.Lock()
+		ChannelGroup.Channels, ChannelGroup.Rule, ChannelGroup.Match, ChannelGroup.ModelGroup = oldChannels, oldRule, oldMatch, oldModelGroup
+		ChannelGroup.Unlock()
+		GlobalUserGroupRatio.Lock()
+		GlobalUserGroupRatio.UserGroup, GlobalUserGroupRatio.APILimiter, GlobalUserGroupRatio.PublicGroup = oldUserGroup, oldAPILimiter, oldPublicGroup
+		GlobalUserGroupRatio.Unlock()
+	})
+
+	systemName, smtpToken = new(string), new(string)
+	config.GlobalOption = config.NewOptionManager()
+	config.GlobalOption.RegisterString("SystemName", systemName)
+	config.GlobalOption.RegisterString("SMTPToken", smtpToken)
+	config.GlobalOption.RegisterString("OldTokenMaxId", new(string))
+	config.GlobalOption.Set("SystemName", "Done Hub")
+	config.GlobalOption.Set("SMTPToken", "smtp-secret")
+	PricingInstance = &Pricing{Prices: map[string]*Price{}}
+	ModelOwnedBysInstance = &ModelOwnedBys{}
+
+	weight := uint(1)
+	assert.Nil(t, db.Create(&Channel{Name: "openai", Type: config.ChannelTypeOpenAI, Key: "sk-old", Models: "gpt-4o", Group: "default", Status: config.ChannelStatusEnabled, Weight: &weight, UsedQuota: 100}).Error)
+	assert.Nil(t, db.Create(&Price{Model: "gpt-4o", Type: TokensPriceType, ChannelType: config.ChannelTypeOpenAI, Input: 2.5, Output: 10}).Error)
+	assert.Nil(t, db.Create(&UserGroup{Symbol: "default", Name: "默认分组", Ratio: 1}).Error)
+	assert.Nil(t, db.Create(&ModelOwnedBy{Id: 1, Name: "OpenAI"}).Error)
+
+	return systemName, smtpToken
+}
+
+// newConfigSyncChannel 与导出的渠道一样带上数据库的默认值
+func newConfigSyncChannel(name string) *Channel {
+	weight := uint(1)
+	costRatio := 1.0
+	return &Channel{Name: name, Type: config.ChannelTypeAnthropic, Key: "sk-ant", Models: "claude-3-haiku", Weight: &weight, PreCost: 1, CostRatio: &costRatio}
+}
+
+func getConfigSyncChannel(t *testing.T, name string) *Channel {
+	channel := &Channel{}
+	assert.Nil(t, DB.Where("name = ?", name).First(channel).Error)
+	return channel
+}
+
+func TestConfigBundleRoundTrip(t *testing.T) {
+	systemName, _ := setupConfigSyncTest(t)
+
+	bundle, err := ExportConfigBundle(ConfigSecretPlain, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "sk-old", bundle.Channels[0].Key)
+	// 内部状态不导出
+	assert.NotContains(t, bundle.Options, "OldTokenMaxId")
+
+	for _, format := range []string{"yaml", "json"} {
+		data, err := EncodeConfigBundle(bundle, format)
+		assert.Nil(t, err)
+		// 不导出运行时字段
+		assert.NotContains(t, string(data), "used_quota")
+
+		decoded, err := DecodeConfigBundle(data)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, decoded.Channels[0].Id)
+
+		// 导出的配置导入到同一实例不产生变更
+		changes, err := ApplyConfigBundle(decoded, &ConfigApplyOptions{})
+		assert.Nil(t, err)
+		assert.Empty(t, changes)
+	}
+
+	data, err := EncodeConfigBundle(bundle, "yaml")
+	assert.Nil(t, err)
+	decoded, err := DecodeConfigBundle(data)
+	assert.Nil(t, err)
+	decoded.Channels[0].Models = "gpt-4o,gpt-4o-mini"
+	decoded.Prices[0].Input = 5
+	decoded.Options["SystemName"] = "New Hub"
+
+	// 预览时只返回变更计划
+	changes, err := ApplyConfigBundle(decoded, &ConfigApplyOptions{DryRun: true})
+	assert.Nil(t, err)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, "gpt-4o", getConfigSyncChannel(t, "openai").Models)
+	assert.Equal(t, "Done Hub", *systemName)
+
+	changes, err = ApplyConfigBundle(decoded, &ConfigApplyOptions{})
+	assert.Nil(t, err)
+	actions := map[string]string{}
+	for _, change := range changes {
+		actions[change.Kind+":"+change.Name] = change.Action
+	}
+	assert.Equal(t, map[string]string{
+		ConfigKindPrice + ":gpt-4o":      AuditActionUpdate,
+		ConfigKindChannel + ":openai":    AuditActionUpdate,
+		ConfigKindOption + ":SystemName": AuditActionUpdate,
+	}, actions)
+
+	channel := getConfigSyncChannel(t, "openai")
+	assert.Equal(t, "gpt-4o,gpt-4o-mini", channel.Models)
+	// 更新渠道时保留运行时数据
+	assert.Equal(t, bundle.Channels[0].Id, channel.Id)
+	assert.Equal(t, 100, int(channel.UsedQuota))
+
+	prices, err := GetAllPrices()
+	assert.Nil(t, err)
+	assert.Equal(t, 5.0, prices[0].Input)
+
+	option, err := GetOption("SystemName")
+	assert.Nil(t, err)
+	assert.Equal(t, "New Hub", option.Value)
+	assert.Equal(t, "New Hub", *systemName)
+	assert.Equal(t, 5.0, PricingInstance.GetPrice("gpt-4o").Input)
+}
+
+func TestConfigBundleMaskedSecrets(t *testing.T) {
+	_, smtpToken := setupConfigSyncTest(t)
+
+	bundle, err := ExportConfigBundle("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, ConfigSecretMasked, bundle.Channels[0].Key)
+	assert.Equal(t, ConfigSecretMasked, bundle.Options["SMTPToken"])
+	assert.Equal(t, "Done Hub", bundle.Options["SystemName"])
+
+	// 隐藏的密钥导入时保留原值
+	bundle.Channels[0].Models = "gpt-4o,gpt-4o-mini"
+	changes, err := ApplyConfigBundle(bundle, &ConfigApplyOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "sk-old", getConfigSyncChannel(t, "openai").Key)
+	assert.Equal(t, "smtp-secret", *smtpToken)
+
+	// 新渠道没有原值可用
+	bundle, err = ExportConfigBundle(ConfigSecretMask, "")
+	assert.Nil(t, err)
+	bundle.Channels[0].Name = "openai-2"
+	_, err = ApplyConfigBundle(bundle, &ConfigApplyOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestConfigBundleEncryptedSecrets(t *testing.T) {
+	_, smtpToken := setupConfigSyncTest(t)
+
+	_, err := ExportConfigBundle(ConfigSecretEncrypt, "")
+	assert.NotNil(t, err)
+
+	bundle, err := ExportConfigBundle(ConfigSecretEncrypt, "passphrase")
+	assert.Nil(t, err)
+	assert.True(t, common.IsEncryptedValue(bundle.Channels[0].Key))
+	assert.True(t, common.IsEncryptedValue(bundle.Options["SMTPToken"]))
+	data, err := EncodeConfigBundle(bundle, "yaml")
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "sk-old")
+
+	// 导入到密钥不同的实例
+	assert.Nil(t, DB.Model(&Channel{}).Where("name = ?", "openai").Update("key", "sk-other").Error)
+	config.GlobalOption.Set("SMTPToken", "smtp-other")
+
+	for _, passphrase := range []string{"", "wrong"} {
+		decoded, err := DecodeConfigBundle(data)
+		assert.Nil(t, err)
+		_, err = ApplyConfigBundle(decoded, &ConfigApplyOptions{Passphrase: passphrase})
+		assert.NotNil(t, err)
+		assert.Equal(t, "sk-other", getConfigSyncChannel(t, "openai").Key)
+		assert.Equal(t, "smtp-other", *smtpToken)
+	}
+
+	decoded, err := DecodeConfigBundle(data)
+	assert.Nil(t, err)
+	changes, err := ApplyConfigBundle(decoded, &ConfigApplyOptions{Passphrase: "passphrase"})
+	assert.Nil(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, "sk-old", getConfigSyncChannel(t, "openai").Key)
+	assert.Equal(t, "smtp-secret", *smtpToken)
+}
+
+func TestConfigBundlePrune(t *testing.T) {
+	setupConfigSyncTest(t)
+
+	bundle := &ConfigBundle{
+		Version:  ConfigBundleVersion,
+		Channels: []*Channel{newConfigSyncChannel("claude")},
+	}
+
+	changes, err := ApplyConfigBundle(bundle, &ConfigApplyOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, AuditActionCreate, changes[0].Action)
+	channels, err := GetAllChannels()
+	assert.Nil(t, err)
+	assert.Len(t, channels, 2)
+
+	// 只删除配置文件中出现的部分里多余的条目
+	bundle.Channels = []*Channel{newConfigSyncChannel("claude")}
+	changes, err = ApplyConfigBundle(bundle, &ConfigApplyOptions{Prune: true})
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, AuditActionDelete, changes[0].Action)
+	assert.Equal(t, "openai", changes[0].Name)
+
+	channels, err = GetAllChannels()
+	assert.Nil(t, err)
+	assert.Len(t, channels, 1)
+	assert.Equal(t, "claude", channels[0].Name)
+	prices, err := GetAllPrices()
+	assert.Nil(t, err)
+	assert.Len(t, prices, 1)
+
+	// 空列表表示删除该部分的所有条目
+	changes, err = ApplyConfigBundle(&ConfigBundle{Prices: []*Price{}}, &ConfigApplyOptions{Prune: true, DryRun: true})
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, ConfigKindPrice, changes[0].Kind)
+}
+
+func TestDecodeConfigBundle(t *testing.T) {
+	_, err := DecodeConfigBundle([]byte("version: [1"))
+	assert.NotNil(t, err)
+
+	_, err = DecodeConfigBundle([]byte("version: 2"))
+	assert.NotNil(t, err)
+
+	bundle, err := DecodeConfigBundle([]byte(`{"version": 1, "options": {"SystemName": "Done Hub"}}`))
+	assert.Nil(t, err)
+	assert.Nil(t, bundle.Channels)
+	assert.Equal(t, "Done Hub", bundle.Options["SystemName"])
+}
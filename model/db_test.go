@@ -0,0 +1,34 @@
+package model
+
+import (
+	"done-hub/common/logger"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 使用内存数据库替换 DB，测试结束后恢复
+func setupTestDB(t *testing.T, models ...any) *gorm.DB {
+	logger.SetupLogger()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	sqlDB, err := db.DB()
+	assert.Nil(t, err)
+	// 内存数据库每个连接是独立的
+	sqlDB.SetMaxOpenConns(1)
+	assert.Nil(t, db.AutoMigrate(models...))
+
+	// 异步发送的事件在测试结束后可能仍在读取 DB，不恢复为 nil
+	oldDB := DB
+	DB = db
+	t.Cleanup(func() {
+		if oldDB != nil {
+			DB = oldDB
+		}
+	})
+
+	return db
+}
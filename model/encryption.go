@@ -0,0 +1,177 @@
+package model
+
+import (
+	"context"
+	"done-hub/common/logger"
+	"done-hub/common/secret"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("secret", SecretSerializer{})
+}
+
+// SecretSerializer 字段写入数据库前加密，读取时解密，未配置主密钥时保持明文
+type SecretSerializer struct{}
+
+func (SecretSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("unsupported secret value type: %T", dbValue)
+	}
+
+	plaintext, err := secret.Decrypt(value)
+	if err != nil {
+		return fmt.Errorf("decrypt %s error: %v", field.Name, err)
+	}
+
+	return field.Set(ctx, dst, plaintext)
+}
+
+func (SecretSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, _ := fieldValue.(string)
+	return secret.Encrypt(value)
+}
+
+// BeforeSave 同步密钥哈希，密文无法直接用于等值查询
+// 只要写入了 key 字段就重新计算，Select("*") 写入空密钥时同时清空哈希
+func (channel *Channel) BeforeSave(tx *gorm.DB) error {
+	if !secret.Enabled() {
+		return nil
+	}
+
+	// Model(channel).Updates(...) 时写入的是 Dest 中的值
+	key, written := channel.Key, channel.Key != ""
+	switch dest := tx.Statement.Dest.(type) {
+	case *Channel:
+		key, written = dest.Key, dest.Key != ""
+	case map[string]interface{}:
+		value, ok := dest["key"]
+		if !ok {
+			value, ok = dest["Key"]
+		}
+		key, _ = value.(string)
+		written = ok
+	}
+
+	selected, restricted := tx.Statement.SelectAndOmitColumns(false, true)
+	if v, ok := selected["key"]; ok {
+		written = v
+	} else if restricted {
+		written = false
+	}
+	if !written {
+		return nil
+	}
+
+	if restricted && !selected["key_hash"] {
+		tx.Statement.Selects = append(tx.Statement.Selects, "key_hash")
+	}
+	tx.Statement.SetColumn("KeyHash", secret.Hash(key))
+	return nil
+}
+
+func (option *Option) BeforeSave(tx *gorm.DB) error {
+	if !IsSecretOption(option.Key) {
+		return nil
+	}
+
+	value, err := secret.Encrypt(option.Value)
+	if err != nil {
+		return err
+	}
+	tx.Statement.SetColumn("Value", value)
+	return nil
+}
+
+func (option *Option) AfterFind(tx *gorm.DB) (err error) {
+	option.Value, err = secret.Decrypt(option.Value)
+	return
+}
+
+type secretRow struct {
+	Id    string
+	Value string
+	Hash  string
+}
+
+// EncryptSecrets 加密数据库中的明文密钥，rotate 为 true 时使用当前主密钥重新加密全部数据
+func EncryptSecrets(rotate bool) error {
+	if !secret.Enabled() {
+		return nil
+	}
+
+	var total int64
+	targets := []struct {
+		table  string
+		id     string
+		column string
+		hash   string
+		filter func(*secretRow) bool
+	}{
+		{table: "channels", id: "id", column: "key", hash: "key_hash"},
+		{table: "payments", id: "id", column: "config"},
+		{table: "options", id: "key", column: "value", filter: func(row *secretRow) bool {
+			return IsSecretOption(row.Id)
+		}},
+	}
+
+	for _, target := range targets {
+		selects := quotePostgresField(target.id) + " as id, " + quotePostgresField(target.column) + " as value"
+		if target.hash != "" {
+			selects += ", " + target.hash + " as hash"
+		}
+
+		var rows []*secretRow
+		if err := DB.Table(target.table).Select(selects).Find(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if target.filter != nil && !target.filter(row) {
+				continue
+			}
+
+			plaintext, err := secret.Decrypt(row.Value)
+			if err != nil {
+				return fmt.Errorf("decrypt %s %s error: %v", target.table, row.Id, err)
+			}
+
+			updates := make(map[string]interface{})
+			if rotate || !secret.IsCurrent(row.Value) {
+				encrypted, err := secret.Encrypt(plaintext)
+				if err != nil {
+					return err
+				}
+				updates[target.column] = encrypted
+			}
+			if target.hash != "" && (rotate || row.Hash != secret.Hash(plaintext)) {
+				updates[target.hash] = secret.Hash(plaintext)
+			}
+			if len(updates) == 0 {
+				continue
+			}
+
+			if err := DB.Table(target.table).Where(quotePostgresField(target.id)+" = ?", row.Id).Updates(updates).Error; err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	if total > 0 {
+		logger.SysLog(fmt.Sprintf("encrypted %d secret rows", total))
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package model
+
+import (
+	"done-hub/common/secret"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// setEncryptionKey 使用配置的主密钥初始化加密，key 为空时关闭加密
+func setEncryptionKey(t *testing.T, key string, oldKeys ...string) {
+	viper.Set("encryption.key", key)
+	viper.Set("encryption.old_keys", strings.Join(oldKeys, ","))
+	assert.Nil(t, secret.Init())
+	t.Cleanup(func() {
+		viper.Set("encryption.key", "")
+		viper.Set("encryption.old_keys", "")
+		secret.Init()
+	})
+}
+
+type rawSecretRow struct {
+	Value string
+	Hash  string
+}
+
+func getRawChannelKey(t *testing.T, id int) rawSecretRow {
+	var row rawSecretRow
+	assert.Nil(t, DB.Table("channels").Select("key as value, key_hash as hash").Where("id = ?", id).Scan(&row).Error)
+	return row
+}
+
+func getRawOption(t *testing.T, key string) string {
+	var value string
+	assert.Nil(t, DB.Table("options").Select("value").Where(quotePostgresField("key")+" = ?", key).Scan(&value).Error)
+	return value
+}
+
+func TestSecretSerializer(t *testing.T) {
+	setupTestDB(t, &Channel{})
+	setEncryptionKey(t, "master-key")
+
+	channel := &Channel{Name: "test", Key: "sk-test"}
+	assert.Nil(t, DB.Create(channel).Error)
+
+	// 数据库中保存密文和哈希，读取时解密
+	raw := getRawChannelKey(t, channel.Id)
+	assert.True(t, secret.IsEncrypted(raw.Value))
+	assert.NotContains(t, raw.Value, "sk-test")
+	assert.Equal(t, secret.Hash("sk-test"), raw.Hash)
+
+	loaded, err := GetChannelById(channel.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "sk-test", loaded.Key)
+}
+
+func TestChannelKeyHash(t *testing.T) {
+	setupTestDB(t, &Channel{})
+	setEncryptionKey(t, "master-key")
+
+	channel := &Channel{Name: "test", Key: "sk-old"}
+	assert.Nil(t, DB.Create(channel).Error)
+
+	// 部分更新不包含密钥时保持原来的哈希
+	channel.Key = ""
+	channel.Name = "renamed"
+	assert.Nil(t, channel.UpdateRaw(false))
+	assert.Equal(t, "sk-old", channel.Key)
+	assert.Equal(t, secret.Hash("sk-old"), getRawChannelKey(t, channel.Id).Hash)
+
+	channel.Key = "sk-new"
+	assert.Nil(t, channel.UpdateRaw(false))
+	assert.Equal(t, secret.Hash("sk-new"), getRawChannelKey(t, channel.Id).Hash)
+
+	// 指定字段更新密钥时同时更新哈希
+	assert.Nil(t, DB.Model(channel).Select("key").Updates(&Channel{Key: "sk-select"}).Error)
+	assert.Equal(t, secret.Hash("sk-select"), getRawChannelKey(t, channel.Id).Hash)
+
+	assert.Nil(t, DB.Model(channel).Updates(map[string]interface{}{"key": "sk-map"}).Error)
+	assert.Equal(t, secret.Hash("sk-map"), getRawChannelKey(t, channel.Id).Hash)
+	assert.Nil(t, DB.Model(channel).Updates(map[string]interface{}{"name": "map"}).Error)
+	assert.Equal(t, secret.Hash("sk-map"), getRawChannelKey(t, channel.Id).Hash)
+
+	// 覆盖更新写入空密钥时清空哈希
+	channel.Key = ""
+	assert.Nil(t, channel.UpdateRaw(true))
+	raw := getRawChannelKey(t, channel.Id)
+	assert.Equal(t, "", raw.Value)
+	assert.Equal(t, "", raw.Hash)
+
+	channel.Key = "sk-overwrite"
+	assert.Nil(t, channel.UpdateRaw(true))
+	assert.Equal(t, secret.Hash("sk-overwrite"), getRawChannelKey(t, channel.Id).Hash)
+
+	// 不写入密钥的更新不修改哈希
+	assert.Nil(t, DB.Model(channel).Omit("key").Updates(&Channel{Name: "omit", Key: "sk-ignored"}).Error)
+	assert.Equal(t, secret.Hash("sk-overwrite"), getRawChannelKey(t, channel.Id).Hash)
+}
+
+func TestOptionSecretHooks(t *testing.T) {
+	setupTestDB(t, &Option{})
+	setEncryptionKey(t, "master-key")
+
+	assert.Nil(t, UpdateOption("GitHubClientSecret", "gh-secret"))
+	assert.Nil(t, UpdateOption("SystemName", "Done Hub"))
+
+	// 只加密密钥类的配置
+	assert.True(t, secret.IsEncrypted(getRawOption(t, "GitHubClientSecret")))
+	assert.Equal(t, "Done Hub", getRawOption(t, "SystemName"))
+
+	options, err := AllOption()
+	assert.Nil(t, err)
+	values := make(map[string]string)
+	for _, option := range options {
+		values[option.Key] = option.Value
+	}
+	assert.Equal(t, map[string]string{"GitHubClientSecret": "gh-secret", "SystemName": "Done Hub"}, values)
+
+	// 更新后仍然是密文
+	assert.Nil(t, UpdateOption("GitHubClientSecret", "gh-secret-2"))
+	assert.True(t, secret.IsEncrypted(getRawOption(t, "GitHubClientSecret")))
+	option, err := GetOption("GitHubClientSecret")
+	assert.Nil(t, err)
+	assert.Equal(t, "gh-secret-2", option.Value)
+}
+
+func TestSearchChannelByKeyHash(t *testing.T) {
+	setupTestDB(t, &Channel{})
+	setEncryptionKey(t, "master-key")
+
+	for _, key := range []string{"sk-a", "sk-b"} {
+		assert.Nil(t, DB.Create(&Channel{Name: key, Key: key}).Error)
+	}
+
+	// 开启加密后通过哈希查找渠道
+	params := &SearchChannelsParams{Channel: Channel{Key: "sk-b"}}
+	result, err := GetChannelsList(params)
+	assert.Nil(t, err)
+	assert.Len(t, *result.Data, 1)
+	assert.Equal(t, "sk-b", (*result.Data)[0].Name)
+
+	params.Key = "sk-c"
+	result, err = GetChannelsList(params)
+	assert.Nil(t, err)
+	assert.Empty(t, *result.Data)
+}
+
+func TestEncryptSecretsLegacyPlaintext(t *testing.T) {
+	setupTestDB(t, &Channel{}, &Payment{}, &Option{})
+
+	// 开启加密前保存的明文数据
+	channel := &Channel{Name: "legacy", Key: "sk-legacy"}
+	assert.Nil(t, DB.Create(channel).Error)
+	payment := &Payment{Name: "stripe", UUID: "uuid", Config: `{"secret_key":"sk_live"}`}
+	assert.Nil(t, DB.Create(payment).Error)
+	assert.Nil(t, UpdateOption("GitHubClientSecret", "gh-secret"))
+	assert.Nil(t, UpdateOption("SystemName", "Done Hub"))
+	assert.Equal(t, "sk-legacy", getRawChannelKey(t, channel.Id).Value)
+	assert.Equal(t, "", getRawChannelKey(t, channel.Id).Hash)
+
+	setEncryptionKey(t, "master-key")
+	assert.Nil(t, EncryptSecrets(false))
+
+	raw := getRawChannelKey(t, channel.Id)
+	assert.True(t, secret.IsCurrent(raw.Value) && secret.IsEncrypted(raw.Value))
+	assert.Equal(t, secret.Hash("sk-legacy"), raw.Hash)
+	assert.True(t, secret.IsEncrypted(getRawOption(t, "GitHubClientSecret")))
+	assert.Equal(t, "Done Hub", getRawOption(t, "SystemName"))
+
+	var paymentConfig string
+	assert.Nil(t, DB.Table("payments").Select("config").Where("id = ?", payment.ID).Scan(&paymentConfig).Error)
+	assert.True(t, secret.IsEncrypted(paymentConfig))
+
+	// 读取时解密
+	loaded, err := GetChannelById(channel.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "sk-legacy", loaded.Key)
+	loadedPayment, err := GetPaymentByID(payment.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"secret_key":"sk_live"}`, loadedPayment.Config)
+	option, err := GetOption("GitHubClientSecret")
+	assert.Nil(t, err)
+	assert.Equal(t, "gh-secret", option.Value)
+
+	// 已经加密的数据不会重复加密
+	assert.Nil(t, EncryptSecrets(false))
+	assert.Equal(t, raw, getRawChannelKey(t, channel.Id))
+}
+
+func TestEncryptSecretsRotate(t *testing.T) {
+	setupTestDB(t, &Channel{}, &Payment{}, &Option{})
+	setEncryptionKey(t, "old-key")
+
+	channel := &Channel{Name: "test", Key: "sk-test"}
+	assert.Nil(t, DB.Create(channel).Error)
+	assert.Nil(t, UpdateOption("GitHubClientSecret", "gh-secret"))
+	before := getRawChannelKey(t, channel.Id)
+	beforeOption := getRawOption(t, "GitHubClientSecret")
+
+	// 新的主密钥通过旧密钥解密，轮换后全部使用新密钥加密
+	setEncryptionKey(t, "new-key", "old-key")
+	assert.False(t, secret.IsCurrent(before.Value))
+	assert.Nil(t, EncryptSecrets(true))
+
+	after := getRawChannelKey(t, channel.Id)
+	assert.True(t, secret.IsCurrent(after.Value))
+	assert.NotEqual(t, before.Hash, after.Hash)
+	assert.Equal(t, secret.Hash("sk-test"), after.Hash)
+	assert.True(t, secret.IsCurrent(getRawOption(t, "GitHubClientSecret")))
+	assert.NotEqual(t, beforeOption, getRawOption(t, "GitHubClientSecret"))
+
+	// 轮换完成后不再需要旧密钥
+	setEncryptionKey(t, "new-key")
+	loaded, err := GetChannelById(channel.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "sk-test", loaded.Key)
+	option, err := GetOption("GitHubClientSecret")
+	assert.Nil(t, err)
+	assert.Equal(t, "gh-secret", option.Value)
+
+	// 缺少旧密钥时无法解密
+	DB.Table("channels").Where("id = ?", channel.Id).Update("key", before.Value)
+	assert.NotNil(t, EncryptSecrets(false))
+}
@@ -22,6 +22,7 @@ type Log struct {
 	TokenName        string                             `json:"token_name" gorm:"index;default:''"`
 	ModelName        string                             `json:"model_name" gorm:"index;index:index_username_model_name,priority:1;default:''"`
 	Quota            int                                `json:"quota" gorm:"default:0"`
+	Cost             int                                `json:"cost" gorm:"default:0"` // 上游成本
 	PromptTokens     int                                `json:"prompt_tokens" gorm:"default:0"`
 	CompletionTokens int                                `json:"completion_tokens" gorm:"default:0"`
 	ChannelId        int                                `json:"channel_id" gorm:"index"`
@@ -89,12 +90,13 @@ func RecordConsumeLog(
 	modelName string,
 	tokenName string,
 	quota int,
+	cost int,
 	content string,
 	requestTime int,
 	isStream bool,
 	metadata map[string]any,
 	sourceIp string) {
-	logger.LogInfo(ctx, fmt.Sprintf("record consume log: userId=%d, channelId=%d, promptTokens=%d, completionTokens=%d, modelName=%s, tokenName=%s, quota=%d, content=%s ,sourceIp=%s", userId, channelId, promptTokens, completionTokens, modelName, tokenName, quota, content, sourceIp))
+	logger.LogInfo(ctx, fmt.Sprintf("record consume log: userId=%d, channelId=%d, promptTokens=%d, completionTokens=%d, modelName=%s, tokenName=%s, quota=%d, cost=%d, content=%s ,sourceIp=%s", userId, channelId, promptTokens, completionTokens, modelName, tokenName, quota, cost, content, sourceIp))
 	if !config.LogConsumeEnabled {
 		return
 	}
@@ -112,6 +114,7 @@ func RecordConsumeLog(
 		TokenName:        tokenName,
 		ModelName:        modelName,
 		Quota:            quota,
+		Cost:             cost,
 		ChannelId:        channelId,
 		RequestTime:      requestTime,
 		IsStream:         isStream,
@@ -122,6 +125,11 @@ func RecordConsumeLog(
 		log.Metadata = datatypes.NewJSONType(metadata)
 	}
 
+	if w := consumeLogWriter.Load(); w != nil {
+		w.add(log)
+		return
+	}
+
 	err := DB.Create(log).Error
 	if err != nil {
 		logger.LogError(ctx, "failed to record log: "+err.Error())
@@ -253,6 +261,7 @@ type LogStatistic struct {
 	Date             string `gorm:"column:date"`
 	RequestCount     int64  `gorm:"column:request_count"`
 	Quota            int64  `gorm:"column:quota"`
+	Cost             int64  `gorm:"column:cost"`
 	PromptTokens     int64  `gorm:"column:prompt_tokens"`
 	CompletionTokens int64  `gorm:"column:completion_tokens"`
 	RequestTime      int64  `gorm:"column:request_time"`
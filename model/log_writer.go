@@ -0,0 +1,367 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"done-hub/common/logger"
+	"done-hub/common/logsink"
+	"done-hub/common/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 消费日志异步批量写入数据库，数据库不可用时先写入本地缓存文件，恢复后重新写入
+// 每批日志同时发送到配置的额外输出
+
+const (
+	// 每次最多重新写入的缓存文件数量
+	logSpoolReplayFiles = 10
+	logWriterStopWait   = 10 * time.Second
+)
+
+type logWriter struct {
+	queue     chan *Log
+	batchSize int
+	interval  time.Duration
+	spool     *logSpool
+	sinks     []logsink.Sink
+	stop      chan struct{}
+	done      chan struct{}
+	// closed 后不再写入队列，add 直接写入数据库
+	mu     sync.RWMutex
+	closed bool
+}
+
+var consumeLogWriter atomic.Pointer[logWriter]
+
+func InitLogWriter() {
+	if !viper.GetBool("log_writer.enabled") {
+		return
+	}
+
+	w := &logWriter{
+		queue:     make(chan *Log, utils.GetOrDefault("log_writer.buffer_size", 10000)),
+		batchSize: utils.GetOrDefault("log_writer.batch_size", 100),
+		interval:  time.Duration(utils.GetOrDefault("log_writer.flush_interval", 2)) * time.Second,
+		spool: &logSpool{
+			dir:     utils.GetOrDefault("log_writer.spool_dir", "./logs/spool"),
+			maxSize: int64(utils.GetOrDefault("log_writer.spool_max_size", 100)) * 1024 * 1024,
+		},
+		sinks: logsink.InitSinks(),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if w.batchSize <= 0 {
+		w.batchSize = 100
+	}
+	if w.interval <= 0 {
+		w.interval = 2 * time.Second
+	}
+
+	consumeLogWriter.Store(w)
+	go w.run()
+
+	logger.SysLog(fmt.Sprintf("consume log writer enabled with batch size %d, flush interval %s", w.batchSize, w.interval))
+}
+
+// StopLogWriter 写入队列中剩余的日志
+func StopLogWriter() {
+	w := consumeLogWriter.Swap(nil)
+	if w == nil {
+		return
+	}
+
+	// 等待正在写入队列的 add 完成，之后队列中的日志都会被 run 处理
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stop)
+	select {
+	case <-w.done:
+	case <-time.After(logWriterStopWait):
+		logger.SysError("consume log writer stop timeout")
+	}
+
+	for _, sink := range w.sinks {
+		if err := sink.Close(); err != nil {
+			logger.SysError(fmt.Sprintf("failed to close log sink %s: %s", sink.Name(), err.Error()))
+		}
+	}
+}
+
+// add 队列已满时直接写入，不丢弃日志；已停止时只写入数据库，额外输出已关闭
+func (w *logWriter) add(log *Log) {
+	w.mu.RLock()
+	closed := w.closed
+	if !closed {
+		select {
+		case w.queue <- log:
+			w.mu.RUnlock()
+			return
+		default:
+		}
+	}
+	w.mu.RUnlock()
+
+	if closed {
+		w.write([]*Log{log})
+		return
+	}
+	w.flush([]*Log{log})
+}
+
+func (w *logWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	batch := make([]*Log, 0, w.batchSize)
+	for {
+		select {
+		case log := <-w.queue:
+			batch = append(batch, log)
+			if len(batch) >= w.batchSize {
+				w.flush(batch)
+				batch = make([]*Log, 0, w.batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = make([]*Log, 0, w.batchSize)
+			}
+			w.replay()
+		case <-w.stop:
+			for len(w.queue) > 0 {
+				batch = append(batch, <-w.queue)
+			}
+			if len(batch) > 0 {
+				w.flush(batch)
+			}
+			return
+		}
+	}
+}
+
+func (w *logWriter) flush(batch []*Log) {
+	w.write(batch)
+	w.fanOut(batch)
+}
+
+// write 写入数据库，失败时写入本地缓存文件
+func (w *logWriter) write(batch []*Log) {
+	if err := DB.CreateInBatches(batch, len(batch)).Error; err != nil {
+		logger.SysError(fmt.Sprintf("failed to write %d consume logs, spool them: %s", len(batch), err.Error()))
+		if err := w.spool.write(batch); err != nil {
+			logger.SysError("failed to spool consume logs: " + err.Error())
+		}
+	}
+}
+
+// fanOut 发送到额外输出，失败时只记录错误
+func (w *logWriter) fanOut(batch []*Log) {
+	if len(w.sinks) == 0 {
+		return
+	}
+
+	records := make([]json.RawMessage, 0, len(batch))
+	for _, log := range batch {
+		data, err := json.Marshal(log)
+		if err != nil {
+			continue
+		}
+		records = append(records, data)
+	}
+
+	var wg sync.WaitGroup
+	for _, sink := range w.sinks {
+		wg.Add(1)
+		go func(sink logsink.Sink) {
+			defer wg.Done()
+			if err := sink.Write(records); err != nil {
+				logger.SysError(fmt.Sprintf("failed to write consume logs to %s: %s", sink.Name(), err.Error()))
+			}
+		}(sink)
+	}
+	wg.Wait()
+}
+
+// replay 数据库恢复后重新写入缓存的日志
+func (w *logWriter) replay() {
+	files := w.spool.files()
+	if len(files) > logSpoolReplayFiles {
+		files = files[:logSpoolReplayFiles]
+	}
+
+	for _, name := range files {
+		logs, err := w.spool.read(name)
+		if err != nil {
+			logger.SysError(fmt.Sprintf("failed to read spool file %s, drop it: %s", name, err.Error()))
+			w.spool.remove(name)
+			continue
+		}
+
+		if len(logs) > 0 {
+			if err := DB.CreateInBatches(logs, w.batchSize).Error; err != nil {
+				if !isDBAvailable() {
+					return
+				}
+				// 数据库正常但写入失败，逐条写入并丢弃无法写入的日志
+				insertLogsOneByOne(logs)
+			}
+		}
+
+		w.spool.remove(name)
+		logger.SysLog(fmt.Sprintf("replayed %d consume logs from spool", len(logs)))
+	}
+}
+
+func isDBAvailable() bool {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return false
+	}
+	return sqlDB.Ping() == nil
+}
+
+func insertLogsOneByOne(logs []*Log) {
+	for _, log := range logs {
+		if err := DB.Create(log).Error; err != nil {
+			logger.SysError(fmt.Sprintf("drop spooled consume log of user %d: %s", log.UserId, err.Error()))
+		}
+	}
+}
+
+// logSpool 本地缓存文件，每批日志一个 JSONL 文件，超过大小限制时删除最早的文件
+type logSpool struct {
+	mu      sync.Mutex
+	dir     string
+	maxSize int64
+}
+
+func (s *logSpool) write(batch []*Log) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for _, log := range batch {
+		if err := encoder.Encode(log); err != nil {
+			return err
+		}
+	}
+
+	if int64(buffer.Len()) > s.maxSize {
+		return fmt.Errorf("batch size %d exceeds spool limit", buffer.Len())
+	}
+
+	// 先写入临时文件，避免读取到写了一半的文件
+	name := fmt.Sprintf("%020d.jsonl", time.Now().UnixNano())
+	tmp := filepath.Join(s.dir, name+".tmp")
+	if err := os.WriteFile(tmp, buffer.Bytes(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		return err
+	}
+
+	s.trim()
+	return nil
+}
+
+// trim 删除最早的文件直到总大小不超过限制
+func (s *logSpool) trim() {
+	files := s.list()
+
+	var total int64
+	sizes := make([]int64, len(files))
+	for i, name := range files {
+		if info, err := os.Stat(filepath.Join(s.dir, name)); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+
+	for i := 0; i < len(files) && total > s.maxSize; i++ {
+		if err := os.Remove(filepath.Join(s.dir, files[i])); err != nil {
+			continue
+		}
+		total -= sizes[i]
+		logger.SysError("consume log spool is full, drop " + files[i])
+	}
+}
+
+// files 按写入顺序返回缓存文件
+func (s *logSpool) files() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.list()
+}
+
+func (s *logSpool) list() []string {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+			continue
+		}
+		files = append(files, entry.Name())
+	}
+	sort.Strings(files)
+	return files
+}
+
+func (s *logSpool) read(name string) ([]*Log, error) {
+	file, err := os.Open(filepath.Join(s.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var logs []*Log
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		log := &Log{}
+		if err := json.Unmarshal(line, log); err != nil {
+			return nil, err
+		}
+		log.Channel = nil
+		logs = append(logs, log)
+	}
+
+	return logs, scanner.Err()
+}
+
+func (s *logSpool) remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+		logger.SysError("failed to remove spool file: " + err.Error())
+	}
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"done-hub/common/logger"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupLogWriterTest(t *testing.T) *logWriter {
+	logger.SetupLogger()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	sqlDB, err := db.DB()
+	assert.Nil(t, err)
+	// 内存数据库每个连接是独立的
+	sqlDB.SetMaxOpenConns(1)
+	assert.Nil(t, db.AutoMigrate(&Log{}))
+
+	oldDB := DB
+	DB = db
+	t.Cleanup(func() {
+		DB = oldDB
+	})
+
+	w := &logWriter{
+		queue:     make(chan *Log, 16),
+		batchSize: 8,
+		interval:  time.Hour,
+		spool:     &logSpool{dir: t.TempDir(), maxSize: 1024 * 1024},
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	consumeLogWriter.Store(w)
+	go w.run()
+	t.Cleanup(func() {
+		consumeLogWriter.Store(nil)
+	})
+
+	return w
+}
+
+func countLogs(t *testing.T) int64 {
+	var count int64
+	assert.Nil(t, DB.Model(&Log{}).Count(&count).Error)
+	return count
+}
+
+func TestLogWriterStopFlushesQueue(t *testing.T) {
+	w := setupLogWriterTest(t)
+	for i := 0; i < 5; i++ {
+		w.add(&Log{UserId: 1, Type: LogTypeConsume})
+	}
+
+	StopLogWriter()
+	assert.Nil(t, consumeLogWriter.Load())
+	assert.Equal(t, int64(5), countLogs(t))
+}
+
+func TestLogWriterAddAfterStop(t *testing.T) {
+	w := setupLogWriterTest(t)
+	StopLogWriter()
+
+	// 停止前取到 writer 的调用方仍然可以写入
+	w.add(&Log{UserId: 1, Type: LogTypeConsume})
+	assert.Equal(t, int64(1), countLogs(t))
+}
+
+func TestLogWriterConcurrentStop(t *testing.T) {
+	w := setupLogWriterTest(t)
+
+	const total = 200
+	var wg sync.WaitGroup
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.add(&Log{UserId: 1, Type: LogTypeConsume})
+		}()
+	}
+
+	StopLogWriter()
+	wg.Wait()
+
+	assert.Equal(t, int64(total), countLogs(t))
+}
@@ -4,6 +4,7 @@ import (
 	"done-hub/common"
 	"done-hub/common/config"
 	"done-hub/common/logger"
+	"done-hub/common/secret"
 	"done-hub/common/utils"
 	"fmt"
 	"net/url"
@@ -21,12 +22,25 @@ import (
 var DB *gorm.DB
 
 func SetupDB() {
+	if err := secret.Init(); err != nil {
+		logger.FatalLog("failed to initialize encryption key: " + err.Error())
+	}
+
 	err := InitDB()
 	if err != nil {
 		logger.FatalLog("failed to initialize database: " + err.Error())
 	}
+
+	// 加密历史明文数据，以及使用旧主密钥加密的数据
+	if config.IsMasterNode {
+		if err := EncryptSecrets(false); err != nil {
+			logger.FatalLog("failed to encrypt secrets: " + err.Error())
+		}
+	}
 	ChannelGroup.Load()
+	ChannelGroup.LoadBenchmarkScores()
 	GlobalUserGroupRatio.Load()
+	ScriptInstance.Load()
 	config.RootUserEmail = GetRootUserEmail()
 	NewModelOwnedBys()
 
@@ -36,6 +50,8 @@ func SetupDB() {
 		logger.SysLog("batch update enabled with interval " + strconv.Itoa(config.BatchUpdateInterval) + "s")
 		InitBatchUpdater()
 	}
+
+	InitLogWriter()
 }
 
 func createRootAccountIfNeed() error {
@@ -184,6 +200,51 @@ func InitDB() (err error) {
 			return err
 		}
 
+		err = db.AutoMigrate(&ChannelCheckLog{})
+		if err != nil {
+			return err
+		}
+
+		err = db.AutoMigrate(&ChannelBenchmark{}, &ChannelBenchmarkSchedule{})
+		if err != nil {
+			return err
+		}
+
+		err = db.AutoMigrate(&UserPasskey{})
+		if err != nil {
+			return err
+		}
+
+		err = db.AutoMigrate(&AuditLog{})
+		if err != nil {
+			return err
+		}
+
+		err = db.AutoMigrate(&UserAlertSetting{})
+		if err != nil {
+			return err
+		}
+
+		err = db.AutoMigrate(&WebhookSubscription{}, &WebhookDelivery{})
+		if err != nil {
+			return err
+		}
+
+		err = db.AutoMigrate(&SubscriptionPlan{}, &UserSubscription{})
+		if err != nil {
+			return err
+		}
+
+		err = db.AutoMigrate(&PriceOverride{})
+		if err != nil {
+			return err
+		}
+
+		err = db.AutoMigrate(&Script{})
+		if err != nil {
+			return err
+		}
+
 		if config.UserInvoiceMonth {
 			err = db.AutoMigrate(&StatisticsMonthGeneratedHistory{})
 			if err != nil {
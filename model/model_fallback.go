@@ -0,0 +1,99 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 每条降级链最多包含的模型数
+const ModelFallbackMaxLength = 5
+
+// ModelFallbacks 模型降级链，主模型所有渠道都失败后按顺序改用后续模型
+type ModelFallbacks struct {
+	sync.RWMutex
+	chains map[string][]string
+}
+
+var ModelFallbackInstance = &ModelFallbacks{chains: map[string][]string{}}
+
+func (m *ModelFallbacks) Load(value string) error {
+	chains := map[string][]string{}
+	if strings.TrimSpace(value) != "" {
+		if err := json.Unmarshal([]byte(value), &chains); err != nil {
+			return err
+		}
+	}
+
+	if err := ValidateFallbackChains(chains); err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	m.chains = chains
+
+	return nil
+}
+
+func (m *ModelFallbacks) String() string {
+	m.RLock()
+	defer m.RUnlock()
+
+	if len(m.chains) == 0 {
+		return ""
+	}
+
+	value, err := json.Marshal(m.chains)
+	if err != nil {
+		return ""
+	}
+	return string(value)
+}
+
+func (m *ModelFallbacks) Get(modelName string) []string {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.chains[modelName]
+}
+
+// ValidateFallbackChains 检查降级链，模型名会去除首尾空格
+func ValidateFallbackChains(chains map[string][]string) error {
+	for modelName, chain := range chains {
+		if strings.TrimSpace(modelName) == "" {
+			return errors.New("降级链的模型名称不能为空")
+		}
+		if len(chain) > ModelFallbackMaxLength {
+			return fmt.Errorf("模型 %s 的降级链最多包含 %d 个模型", modelName, ModelFallbackMaxLength)
+		}
+
+		seen := map[string]bool{modelName: true}
+		for i, fallback := range chain {
+			fallback = strings.TrimSpace(fallback)
+			if fallback == "" {
+				return fmt.Errorf("模型 %s 的降级链包含空的模型名称", modelName)
+			}
+			if seen[fallback] {
+				return fmt.Errorf("模型 %s 的降级链包含重复的模型: %s", modelName, fallback)
+			}
+			seen[fallback] = true
+			chain[i] = fallback
+		}
+	}
+
+	return nil
+}
+
+// GetModelFallbackChain 令牌设置的降级链优先于系统设置
+func GetModelFallbackChain(setting *TokenSetting, modelName string) []string {
+	if setting != nil {
+		if chain, ok := setting.Fallback[modelName]; ok {
+			return chain
+		}
+	}
+
+	return ModelFallbackInstance.Get(modelName)
+}
@@ -0,0 +1,249 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	ModelRouterStrategyCheapest = "cheapest" // 满足条件的模型中预估费用最低的
+	ModelRouterStrategyPriority = "priority" // 按 Models 的顺序选择第一个可用的
+)
+
+// 未指定 max_tokens 时用于估算费用的输出 token 数
+const modelRouterDefaultCompletionTokens = 500
+
+// ModelCapability 模型能力，ContextLength 为 0 时不检查上下文长度
+type ModelCapability struct {
+	Vision        bool `json:"vision,omitempty"`
+	Tools         bool `json:"tools,omitempty"`
+	JSONMode      bool `json:"json_mode,omitempty"`
+	ContextLength int  `json:"context_length,omitempty"`
+}
+
+// ModelRouter 虚拟模型，请求时按能力、价格和渠道状态解析为实际模型
+type ModelRouter struct {
+	Name     string          `json:"name"`
+	Strategy string          `json:"strategy,omitempty"`
+	Models   []string        `json:"models,omitempty"` // 候选模型，为空时使用分组下所有声明了能力的模型
+	Require  ModelCapability `json:"require,omitempty"`
+}
+
+type ModelRouterSetting struct {
+	Routers      []*ModelRouter             `json:"routers"`
+	Capabilities map[string]ModelCapability `json:"capabilities"` // 模型名支持以 * 结尾的通配符
+}
+
+// RouteRequirement 从请求中分析出的需求
+type RouteRequirement struct {
+	Vision           bool
+	Tools            bool
+	JSONMode         bool
+	PromptTokens     int
+	CompletionTokens int
+}
+
+type ModelRouters struct {
+	sync.RWMutex
+	setting ModelRouterSetting
+	routers map[string]*ModelRouter
+	match   []string
+}
+
+var ModelRouterInstance = &ModelRouters{routers: map[string]*ModelRouter{}}
+
+func (m *ModelRouters) Load(value string) error {
+	setting := ModelRouterSetting{}
+	if strings.TrimSpace(value) != "" {
+		if err := json.Unmarshal([]byte(value), &setting); err != nil {
+			return err
+		}
+	}
+
+	routers := make(map[string]*ModelRouter, len(setting.Routers))
+	for _, router := range setting.Routers {
+		router.Name = strings.TrimSpace(router.Name)
+		if router.Name == "" {
+			return errors.New("路由模型名称不能为空")
+		}
+		if _, ok := routers[router.Name]; ok {
+			return fmt.Errorf("路由模型名称重复: %s", router.Name)
+		}
+
+		switch router.Strategy {
+		case "":
+			router.Strategy = ModelRouterStrategyCheapest
+		case ModelRouterStrategyCheapest, ModelRouterStrategyPriority:
+		default:
+			return fmt.Errorf("路由模型 %s 的策略无效: %s", router.Name, router.Strategy)
+		}
+
+		routers[router.Name] = router
+	}
+
+	match := make([]string, 0)
+	for name := range setting.Capabilities {
+		if strings.HasSuffix(name, "*") {
+			match = append(match, name)
+		}
+	}
+	// 前缀越长越优先
+	sort.Slice(match, func(i, j int) bool {
+		return len(match[i]) > len(match[j])
+	})
+
+	m.Lock()
+	defer m.Unlock()
+	m.setting = setting
+	m.routers = routers
+	m.match = match
+
+	return nil
+}
+
+func (m *ModelRouters) String() string {
+	m.RLock()
+	defer m.RUnlock()
+
+	if len(m.setting.Routers) == 0 && len(m.setting.Capabilities) == 0 {
+		return ""
+	}
+
+	value, err := json.Marshal(m.setting)
+	if err != nil {
+		return ""
+	}
+	return string(value)
+}
+
+func (m *ModelRouters) Get(name string) *ModelRouter {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.routers[name]
+}
+
+func (m *ModelRouters) GetNames() []string {
+	m.RLock()
+	defer m.RUnlock()
+
+	names := make([]string, 0, len(m.routers))
+	for name := range m.routers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *ModelRouters) GetCapability(modelName string) (ModelCapability, bool) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if capability, ok := m.setting.Capabilities[modelName]; ok {
+		return capability, true
+	}
+
+	for _, name := range m.match {
+		if strings.HasPrefix(modelName, strings.TrimRight(name, "*")) {
+			return m.setting.Capabilities[name], true
+		}
+	}
+
+	return ModelCapability{}, false
+}
+
+func (m *ModelRouters) satisfy(modelName string, require ModelCapability, req *RouteRequirement) bool {
+	capability, ok := m.GetCapability(modelName)
+
+	if (require.Vision || req.Vision) && !capability.Vision {
+		return false
+	}
+	if (require.Tools || req.Tools) && !capability.Tools {
+		return false
+	}
+	if (require.JSONMode || req.JSONMode) && !capability.JSONMode {
+		return false
+	}
+
+	// 没有声明能力的模型只能用于没有能力要求的请求
+	if !ok {
+		return true
+	}
+
+	return capability.ContextLength == 0 || req.PromptTokens+req.CompletionTokens <= capability.ContextLength
+}
+
+// estimateModelCost 按模型价格估算请求费用，只用于候选模型之间的比较
+func estimateModelCost(modelName string, req *RouteRequirement) float64 {
+	price := PricingInstance.GetPrice(modelName)
+	if price.Type == TimesPriceType {
+		return 1000 * price.GetInput()
+	}
+
+	completionTokens := req.CompletionTokens
+	if completionTokens <= 0 {
+		completionTokens = modelRouterDefaultCompletionTokens
+	}
+
+	return float64(req.PromptTokens)*price.GetInput() + float64(completionTokens)*price.GetOutput()
+}
+
+// Resolve 解析路由模型，返回分组下满足需求且当前有可用渠道的实际模型
+// getFilters 返回检查某个模型渠道时使用的过滤条件
+func (m *ModelRouters) Resolve(router *ModelRouter, group string, req *RouteRequirement, getFilters func(modelName string) []ChannelsFilterFunc) (string, error) {
+	candidates := router.Models
+	if len(candidates) == 0 {
+		groupModels, err := ChannelGroup.GetGroupModels(group)
+		if err != nil {
+			return "", err
+		}
+		sort.Strings(groupModels)
+		for _, modelName := range groupModels {
+			if strings.HasSuffix(modelName, "*") {
+				continue
+			}
+			if _, ok := m.GetCapability(modelName); ok {
+				candidates = append(candidates, modelName)
+			}
+		}
+	}
+
+	type candidate struct {
+		name string
+		cost float64
+	}
+
+	available := make([]candidate, 0, len(candidates))
+	for _, modelName := range candidates {
+		// 不允许路由到另一个路由模型
+		if m.Get(modelName) != nil {
+			continue
+		}
+
+		if !m.satisfy(modelName, router.Require, req) {
+			continue
+		}
+
+		if !ChannelGroup.HasAvailableChannel(group, modelName, getFilters(modelName)...) {
+			continue
+		}
+
+		available = append(available, candidate{name: modelName, cost: estimateModelCost(modelName, req)})
+	}
+
+	if len(available) == 0 {
+		return "", fmt.Errorf("路由模型 %s 在分组 %s 下没有满足条件的可用模型", router.Name, group)
+	}
+
+	if router.Strategy == ModelRouterStrategyCheapest {
+		sort.SliceStable(available, func(i, j int) bool {
+			return available[i].cost < available[j].cost
+		})
+	}
+
+	return available[0].name, nil
+}
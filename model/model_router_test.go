@@ -0,0 +1,183 @@
+package model
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testModelRouterSetting = `{
+	"routers": [
+		{"name": "auto"},
+		{"name": "auto-priority", "strategy": "priority", "models": ["auto", "gpt-4o", "gpt-4o-mini", "text-embedding-3-small"]},
+		{"name": "auto-vision", "require": {"vision": true}}
+	],
+	"capabilities": {
+		"gpt-4o": {"vision": true, "tools": true, "json_mode": true, "context_length": 128000},
+		"gpt-4o-mini": {"vision": true, "tools": true, "json_mode": true, "context_length": 128000},
+		"claude-*": {"vision": true, "context_length": 200000},
+		"deepseek-chat": {"tools": true, "json_mode": true, "context_length": 64000},
+		"qwen-turbo": {"context_length": 8000}
+	}
+}`
+
+// setupModelRouterTest 替换分组渠道和价格，测试结束后恢复
+func setupModelRouterTest(t *testing.T) *ModelRouters {
+	weight := uint(1)
+	channels := map[int]*ChannelChoice{}
+	models := []string{"gpt-4o", "gpt-4o-mini", "claude-3-haiku", "deepseek-chat", "qwen-turbo", "text-embedding-3-small"}
+	rule := map[string][][]int{}
+	for i, modelName := range models {
+		id := i + 1
+		channels[id] = &ChannelChoice{Channel: &Channel{Id: id, Weight: &weight}}
+		rule[modelName] = [][]int{{id}}
+	}
+	// deepseek-chat 的渠道已禁用
+	channels[4].Disable = true
+
+	ChannelGroup.Lock()
+	oldChannels, oldRule, oldMatch := ChannelGroup.Channels, ChannelGroup.Rule, ChannelGroup.Match
+	ChannelGroup.Channels = channels
+	ChannelGroup.Rule = map[string]map[string][][]int{"default": rule, "empty": {}}
+	ChannelGroup.Match = nil
+	ChannelGroup.Unlock()
+
+	oldPricing := PricingInstance
+	PricingInstance = &Pricing{Prices: map[string]*Price{
+		"gpt-4o":                 {Type: TokensPriceType, Input: 2.5, Output: 10},
+		"gpt-4o-mini":            {Type: TokensPriceType, Input: 0.15, Output: 0.6},
+		"claude-3-haiku":         {Type: TokensPriceType, Input: 0.25, Output: 1.25},
+		"deepseek-chat":          {Type: TokensPriceType, Input: 0.1, Output: 0.2},
+		"qwen-turbo":             {Type: TimesPriceType, Input: 0.5},
+		"text-embedding-3-small": {Type: TokensPriceType, Input: 0.01},
+	}}
+
+	t.Cleanup(func() {
+		ChannelGroup.Lock()
+		ChannelGroup.Channels, ChannelGroup.Rule, ChannelGroup.Match = oldChannels, oldRule, oldMatch
+		ChannelGroup.Unlock()
+		ChannelGroup.Cooldowns = sync.Map{}
+		PricingInstance = oldPricing
+	})
+
+	routers := &ModelRouters{routers: map[string]*ModelRouter{}}
+	assert.Nil(t, routers.Load(testModelRouterSetting))
+	return routers
+}
+
+func noRouteFilters(string) []ChannelsFilterFunc {
+	return nil
+}
+
+func TestModelRoutersResolve(t *testing.T) {
+	routers := setupModelRouterTest(t)
+
+	tests := []struct {
+		name    string
+		router  string
+		group   string
+		req     RouteRequirement
+		skip    []int
+		want    string
+		wantErr bool
+	}{
+		// 候选为分组下声明了能力的模型，没有声明能力的 text-embedding-3-small 不参与
+		{"cheapest", "auto", "default", RouteRequirement{PromptTokens: 1000}, nil, "gpt-4o-mini", false},
+		// 按次计费的模型按 1000 倍单价估算
+		{"cheapest with times price", "auto", "default", RouteRequirement{PromptTokens: 1000}, []int{2}, "qwen-turbo", false},
+		{"cheapest vision", "auto-vision", "default", RouteRequirement{PromptTokens: 1000}, []int{2}, "claude-3-haiku", false},
+		// 请求需要的能力与路由要求的能力同时检查
+		{"request needs tools", "auto-vision", "default", RouteRequirement{Tools: true}, []int{2}, "gpt-4o", false},
+		// 禁用渠道的模型不可用
+		{"request needs json mode", "auto", "default", RouteRequirement{JSONMode: true}, []int{2}, "gpt-4o", false},
+		// 超过上下文长度的模型被过滤
+		{"long prompt", "auto", "default", RouteRequirement{PromptTokens: 150000}, nil, "claude-3-haiku", false},
+		{"context includes completion", "auto", "default", RouteRequirement{PromptTokens: 7000, CompletionTokens: 2000}, []int{2, 1, 3}, "", true},
+		{"long prompt with tools", "auto", "default", RouteRequirement{PromptTokens: 150000, Tools: true}, nil, "", true},
+		// 按顺序选择第一个可用的模型，跳过路由模型
+		{"priority", "auto-priority", "default", RouteRequirement{PromptTokens: 1000}, nil, "gpt-4o", false},
+		{"priority skips unavailable", "auto-priority", "default", RouteRequirement{PromptTokens: 1000}, []int{1}, "gpt-4o-mini", false},
+		// 没有声明能力的模型只能用于没有能力要求的请求
+		{"priority undeclared model", "auto-priority", "default", RouteRequirement{}, []int{1, 2}, "text-embedding-3-small", false},
+		{"priority undeclared model with tools", "auto-priority", "default", RouteRequirement{Tools: true}, []int{1, 2}, "", true},
+		{"group without models", "auto", "empty", RouteRequirement{}, nil, "", true},
+		{"unknown group", "auto", "vip", RouteRequirement{}, nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := routers.Get(tt.router)
+			req := tt.req
+			modelName, err := routers.Resolve(router, tt.group, &req, func(string) []ChannelsFilterFunc {
+				return []ChannelsFilterFunc{FilterChannelId(tt.skip)}
+			})
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				assert.Empty(t, modelName)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, modelName)
+		})
+	}
+}
+
+func TestModelRoutersResolveCooldown(t *testing.T) {
+	routers := setupModelRouterTest(t)
+	router := routers.Get("auto")
+	req := &RouteRequirement{PromptTokens: 1000, Tools: true}
+
+	modelName, err := routers.Resolve(router, "default", req, noRouteFilters)
+	assert.Nil(t, err)
+	assert.Equal(t, "gpt-4o-mini", modelName)
+
+	// 渠道冷却中时选择下一个便宜的模型
+	ChannelGroup.Cooldowns.Store(fmt.Sprintf("%d:%s", 2, "gpt-4o-mini"), time.Now().Add(time.Minute).Unix())
+	modelName, err = routers.Resolve(router, "default", req, noRouteFilters)
+	assert.Nil(t, err)
+	assert.Equal(t, "gpt-4o", modelName)
+
+	ChannelGroup.Cooldowns.Store(fmt.Sprintf("%d:%s", 1, "gpt-4o"), time.Now().Add(time.Minute).Unix())
+	_, err = routers.Resolve(router, "default", req, noRouteFilters)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "auto")
+}
+
+func TestModelRoutersLoad(t *testing.T) {
+	routers := &ModelRouters{routers: map[string]*ModelRouter{}}
+
+	assert.Nil(t, routers.Load(testModelRouterSetting))
+	assert.Equal(t, []string{"auto", "auto-priority", "auto-vision"}, routers.GetNames())
+	assert.Equal(t, ModelRouterStrategyCheapest, routers.Get("auto").Strategy)
+
+	// 通配符能力按前缀匹配
+	capability, ok := routers.GetCapability("claude-3-5-sonnet")
+	assert.True(t, ok)
+	assert.Equal(t, 200000, capability.ContextLength)
+	_, ok = routers.GetCapability("gemini-2.0-flash")
+	assert.False(t, ok)
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"invalid json", `{"routers":`},
+		{"empty name", `{"routers":[{"name":" "}]}`},
+		{"duplicate name", `{"routers":[{"name":"auto"},{"name":"auto"}]}`},
+		{"invalid strategy", `{"routers":[{"name":"auto","strategy":"random"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NotNil(t, routers.Load(tt.value))
+			// 加载失败时保留原来的配置
+			assert.NotNil(t, routers.Get("auto-priority"))
+		})
+	}
+
+	assert.Nil(t, routers.Load(""))
+	assert.Empty(t, routers.GetNames())
+	assert.Equal(t, "", routers.String())
+}
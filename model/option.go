@@ -91,6 +91,10 @@ func InitOptionMap() {
 	config.GlobalOption.RegisterFloat("QuotaPerUnit", &config.QuotaPerUnit)
 	config.GlobalOption.RegisterInt("RetryTimes", &config.RetryTimes)
 	config.GlobalOption.RegisterInt("RetryCooldownSeconds", &config.RetryCooldownSeconds)
+	config.GlobalOption.RegisterBool("StructuredOutputEnabled", &config.StructuredOutputEnabled)
+	config.GlobalOption.RegisterInt("StructuredOutputMaxRetries", &config.StructuredOutputMaxRetries)
+	config.GlobalOption.RegisterInt("ScriptMaxSteps", &config.ScriptMaxSteps)
+	config.GlobalOption.RegisterInt("ScriptTimeout", &config.ScriptTimeout)
 
 	config.GlobalOption.RegisterBool("MjNotifyEnabled", &config.MjNotifyEnabled)
 	config.GlobalOption.RegisterString("ChatImageRequestProxy", &config.ChatImageRequestProxy)
@@ -113,6 +117,29 @@ func InitOptionMap() {
 	config.GlobalOption.RegisterBool("GeminiAPIEnabled", &config.GeminiAPIEnabled)
 	config.GlobalOption.RegisterBool("ClaudeAPIEnabled", &config.ClaudeAPIEnabled)
 
+	config.GlobalOption.RegisterString("RealtimeBridgeTranscriptionModel", &config.RealtimeBridgeTranscriptionModel)
+	config.GlobalOption.RegisterString("RealtimeBridgeSpeechModel", &config.RealtimeBridgeSpeechModel)
+
+	config.GlobalOption.RegisterInt("LocalChannelHealthCheckInterval", &config.LocalChannelHealthCheckInterval)
+	config.GlobalOption.RegisterInt("LocalChannelMaxQueueDepth", &config.LocalChannelMaxQueueDepth)
+
+	config.GlobalOption.RegisterBool("CircuitBreakerEnabled", &config.CircuitBreakerEnabled)
+	config.GlobalOption.RegisterInt("CircuitBreakerWindowSeconds", &config.CircuitBreakerWindowSeconds)
+	config.GlobalOption.RegisterInt("CircuitBreakerMinRequests", &config.CircuitBreakerMinRequests)
+	config.GlobalOption.RegisterFloat("CircuitBreakerErrorRate", &config.CircuitBreakerErrorRate)
+	config.GlobalOption.RegisterInt("CircuitBreakerOpenSeconds", &config.CircuitBreakerOpenSeconds)
+	config.GlobalOption.RegisterFloat("CircuitBreakerHalfOpenRatio", &config.CircuitBreakerHalfOpenRatio)
+	config.GlobalOption.RegisterInt("CircuitBreakerHalfOpenSuccesses", &config.CircuitBreakerHalfOpenSuccesses)
+
+	config.GlobalOption.RegisterBool("ChannelFingerprintCheckEnabled", &config.ChannelFingerprintCheckEnabled)
+	config.GlobalOption.RegisterInt("ChannelFingerprintCheckInterval", &config.ChannelFingerprintCheckInterval)
+	config.GlobalOption.RegisterBool("ChannelFingerprintAutoDisable", &config.ChannelFingerprintAutoDisable)
+	config.GlobalOption.RegisterInt("ChannelCheckLogRetentionDays", &config.ChannelCheckLogRetentionDays)
+	config.GlobalOption.RegisterBool("ChannelBenchmarkWeightEnabled", &config.ChannelBenchmarkWeightEnabled)
+
+	config.GlobalOption.RegisterBool("AdminRequireTwoFactor", &config.AdminRequireTwoFactor)
+	config.GlobalOption.RegisterInt("AuditLogRetentionDays", &config.AuditLogRetentionDays)
+
 	config.GlobalOption.RegisterCustom("DisableChannelKeywords", func() string {
 		return common.DisableChannelKeywordsInstance.GetKeywords()
 	}, func(value string) error {
@@ -122,6 +149,18 @@ func InitOptionMap() {
 
 	config.GlobalOption.RegisterInt("RetryTimeOut", &config.RetryTimeOut)
 
+	config.GlobalOption.RegisterCustom("ModelRouterSetting", func() string {
+		return ModelRouterInstance.String()
+	}, func(value string) error {
+		return ModelRouterInstance.Load(value)
+	}, "")
+
+	config.GlobalOption.RegisterCustom("ModelFallbackChains", func() string {
+		return ModelFallbackInstance.String()
+	}, func(value string) error {
+		return ModelFallbackInstance.Load(value)
+	}, "")
+
 	config.GlobalOption.RegisterBool("EnableSafe", &config.EnableSafe)
 	config.GlobalOption.RegisterString("SafeToolName", &config.SafeToolName)
 	config.GlobalOption.RegisterCustom("SafeKeyWords", func() string {
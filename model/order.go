@@ -21,6 +21,7 @@ type Order struct {
 	GatewayId     int            `json:"gateway_id"`
 	TradeNo       string         `json:"trade_no" gorm:"type:varchar(50);uniqueIndex"`
 	GatewayNo     string         `json:"gateway_no" gorm:"type:varchar(100)"`
+	PlanId        int            `json:"plan_id" gorm:"default:0"` // 订阅套餐订单
 	Amount        int            `json:"amount" gorm:"default:0"`
 	OrderAmount   float64        `json:"order_amount" gorm:"type:decimal(10,2);default:0"`
 	OrderCurrency CurrencyType   `json:"order_currency" gorm:"type:varchar(16)"`
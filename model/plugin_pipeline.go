@@ -0,0 +1,40 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// PluginConfig 请求处理插件配置，Params 由插件自行解析
+type PluginConfig struct {
+	Name   string         `json:"name"`
+	Params map[string]any `json:"params,omitempty"`
+}
+
+// PluginPipeline 按顺序执行的插件列表
+type PluginPipeline []PluginConfig
+
+// Validate 只检查结构，插件参数由 relay/plugin 校验
+func (pipeline PluginPipeline) Validate() error {
+	for i := range pipeline {
+		pipeline[i].Name = strings.TrimSpace(pipeline[i].Name)
+		if pipeline[i].Name == "" {
+			return errors.New("插件名称不能为空")
+		}
+	}
+	return nil
+}
+
+func (channel *Channel) GetPluginPipeline() PluginPipeline {
+	if channel.PluginPipeline == nil {
+		return nil
+	}
+	return channel.PluginPipeline.Data()
+}
+
+func (c *UserGroup) GetPluginPipeline() PluginPipeline {
+	if c.PluginPipeline == nil {
+		return nil
+	}
+	return c.PluginPipeline.Data()
+}
@@ -11,6 +11,8 @@ import (
 const (
 	TokensPriceType    = "tokens"
 	TimesPriceType     = "times"
+	SecondsPriceType   = "seconds"    // 按音频时长(秒)计费
+	CharsPriceType     = "characters" // 按字符数计费
 	DefaultPrice       = 30.0
 	DollarRate         = 0.002
 	RMBRate            = 0.014
@@ -61,6 +63,8 @@ type Price struct {
 	Locked      bool    `json:"locked" gorm:"default:false"` // 如果模型为locked 则覆盖模式不会更新locked的模型价格
 
 	ExtraRatios *datatypes.JSONType[map[string]float64] `json:"extra_ratios,omitempty" gorm:"type:json"`
+	Tiers       *datatypes.JSONType[[]PriceTier]        `json:"tiers,omitempty" gorm:"type:json"`
+	TimeWindows *datatypes.JSONType[[]PriceTimeWindow]  `json:"time_windows,omitempty" gorm:"type:json"`
 }
 
 func GetAllPrices() ([]*Price, error) {
@@ -111,13 +115,18 @@ func (price *Price) GetInput() float64 {
 }
 
 func (price *Price) GetOutput() float64 {
-	if price.Output <= 0 || price.Type == TimesPriceType {
+	if price.Output <= 0 || price.Type == TimesPriceType || price.IsUnitPrice() {
 		return 0
 	}
 
 	return price.Output
 }
 
+// 是否按音频秒数或字符数计费
+func (price *Price) IsUnitPrice() bool {
+	return price.Type == SecondsPriceType || price.Type == CharsPriceType
+}
+
 func (price *Price) GetExtraRatio(key string) float64 {
 	if price.ExtraRatios != nil {
 		extraRatios := price.ExtraRatios.Data()
@@ -153,6 +162,8 @@ func UpdatePrices(tx *gorm.DB, models []string, prices *Price) error {
 			Output:      prices.Output,
 			Locked:      prices.Locked,
 			ExtraRatios: prices.ExtraRatios,
+			Tiers:       prices.Tiers,
+			TimeWindows: prices.TimeWindows,
 		}).Error
 
 	return err
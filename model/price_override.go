@@ -0,0 +1,231 @@
+package model
+
+import (
+	"done-hub/common/cache"
+	"done-hub/common/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+var userPriceOverridesCacheKey = "user_price_overrides:%d"
+
+// PriceOverride 用户或令牌的专属价格，Model 支持以 * 结尾的通配符
+// 设置了 Input/Output 时使用固定价格（不再使用阶梯和分时段），否则按 Ratio 折算，结果仍会乘以分组倍率
+type PriceOverride struct {
+	Id        int      `json:"id"`
+	UserId    int      `json:"user_id" gorm:"index"`
+	TokenId   int      `json:"token_id" gorm:"index;default:0"` // 0 表示对用户的所有令牌生效
+	Model     string   `json:"model" gorm:"type:varchar(100)"`
+	Ratio     float64  `json:"ratio" gorm:"default:1"`
+	Input     *float64 `json:"input"`
+	Output    *float64 `json:"output"`
+	Remark    string   `json:"remark" gorm:"type:varchar(255);default:''"`
+	CreatedAt int64    `json:"created_at" gorm:"bigint"`
+	UpdatedAt int64    `json:"updated_at" gorm:"bigint"`
+}
+
+type SearchPriceOverridesParams struct {
+	UserId  int    `form:"user_id"`
+	TokenId int    `form:"token_id"`
+	Model   string `form:"model"`
+	PaginationParams
+}
+
+var allowedPriceOverrideOrderFields = map[string]bool{
+	"id":         true,
+	"user_id":    true,
+	"token_id":   true,
+	"model":      true,
+	"created_at": true,
+}
+
+func GetPriceOverridesList(params *SearchPriceOverridesParams) (*DataResult[PriceOverride], error) {
+	var overrides []*PriceOverride
+	db := DB
+
+	if params.UserId != 0 {
+		db = db.Where("user_id = ?", params.UserId)
+	}
+
+	if params.TokenId != 0 {
+		db = db.Where("token_id = ?", params.TokenId)
+	}
+
+	if params.Model != "" {
+		db = db.Where("model LIKE ?", params.Model+"%")
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &overrides, allowedPriceOverrideOrderFields)
+}
+
+func GetPriceOverrideById(id int) (*PriceOverride, error) {
+	override := &PriceOverride{}
+	err := DB.Where("id = ?", id).First(override).Error
+	return override, err
+}
+
+func GetUserPriceOverrides(userId int) ([]*PriceOverride, error) {
+	var overrides []*PriceOverride
+	err := DB.Where("user_id = ?", userId).Order("id asc").Find(&overrides).Error
+	return overrides, err
+}
+
+func CacheGetUserPriceOverrides(userId int) ([]*PriceOverride, error) {
+	return cache.GetOrSetCache(
+		fmt.Sprintf(userPriceOverridesCacheKey, userId),
+		5*time.Minute,
+		func() ([]*PriceOverride, error) {
+			return GetUserPriceOverrides(userId)
+		},
+		cache.CacheTimeout)
+}
+
+func (override *PriceOverride) Validate() error {
+	override.Model = strings.TrimSpace(override.Model)
+	if override.UserId <= 0 {
+		return errors.New("用户不能为空")
+	}
+
+	if override.Model == "" {
+		return errors.New("模型不能为空")
+	}
+
+	if override.TokenId != 0 {
+		if _, err := GetTokenByIds(override.TokenId, override.UserId); err != nil {
+			return errors.New("令牌不属于该用户")
+		}
+	}
+
+	if override.Ratio < 0 || (override.Input != nil && *override.Input < 0) || (override.Output != nil && *override.Output < 0) {
+		return errors.New("价格和倍率不能为负数")
+	}
+
+	if override.Input == nil && override.Output == nil && override.Ratio == 0 {
+		override.Ratio = 1
+	}
+
+	return nil
+}
+
+func (override *PriceOverride) Insert() error {
+	override.CreatedAt = utils.GetTimestamp()
+	override.UpdatedAt = override.CreatedAt
+	err := DB.Create(override).Error
+	if err == nil {
+		clearUserPriceOverridesCache(override.UserId)
+	}
+	return err
+}
+
+func (override *PriceOverride) Update(before *PriceOverride) error {
+	override.UpdatedAt = utils.GetTimestamp()
+	err := DB.Select("user_id", "token_id", "model", "ratio", "input", "output", "remark", "updated_at").Updates(override).Error
+	if err == nil {
+		clearUserPriceOverridesCache(before.UserId)
+		clearUserPriceOverridesCache(override.UserId)
+	}
+	return err
+}
+
+func (override *PriceOverride) Delete() error {
+	err := DB.Delete(override).Error
+	if err == nil {
+		clearUserPriceOverridesCache(override.UserId)
+	}
+	return err
+}
+
+func clearUserPriceOverridesCache(userId int) {
+	cache.DeleteCache(fmt.Sprintf(userPriceOverridesCacheKey, userId))
+}
+
+// matchScore 精确匹配优先，通配符按前缀长度，未命中返回 -1
+func (override *PriceOverride) matchScore(modelName string) int {
+	if override.Model == modelName {
+		return len(modelName) + 1
+	}
+
+	if strings.HasSuffix(override.Model, "*") {
+		prefix := strings.TrimRight(override.Model, "*")
+		if strings.HasPrefix(modelName, prefix) {
+			return len(prefix)
+		}
+	}
+
+	return -1
+}
+
+// GetPriceOverride 获取命中的专属价格，令牌级优先于用户级
+func GetPriceOverride(userId, tokenId int, modelName string) *PriceOverride {
+	overrides, err := CacheGetUserPriceOverrides(userId)
+	if err != nil || len(overrides) == 0 {
+		return nil
+	}
+
+	var matched *PriceOverride
+	matchedScore := -1
+	for _, override := range overrides {
+		if override.TokenId != 0 && override.TokenId != tokenId {
+			continue
+		}
+
+		score := override.matchScore(modelName)
+		if score < 0 {
+			continue
+		}
+
+		if matched == nil || (override.TokenId != 0 && matched.TokenId == 0) ||
+			(override.TokenId == matched.TokenId && score > matchedScore) {
+			matched = override
+			matchedScore = score
+		}
+	}
+
+	return matched
+}
+
+func (override *PriceOverride) IsFixed() bool {
+	return override.Input != nil || override.Output != nil
+}
+
+// Apply 返回覆盖后的输入输出价格（不含分组倍率），固定价格模式下未设置的一侧保持传入的价格
+func (override *PriceOverride) Apply(input, output float64) (float64, float64) {
+	if !override.IsFixed() {
+		return input * override.Ratio, output * override.Ratio
+	}
+
+	if override.Input != nil {
+		input = *override.Input
+	}
+	if override.Output != nil {
+		output = *override.Output
+	}
+	return input, output
+}
+
+// ApplyToPrice 返回应用专属价格后的模型价格副本，用于展示
+func (override *PriceOverride) ApplyToPrice(price *Price) *Price {
+	effective := *price
+	effective.Input, effective.Output = override.Apply(price.GetInput(), price.GetOutput())
+
+	if override.IsFixed() {
+		effective.Tiers = nil
+		effective.TimeWindows = nil
+		return &effective
+	}
+
+	if tiers := price.GetTiers(); len(tiers) > 0 {
+		scaled := make([]PriceTier, 0, len(tiers))
+		for _, tier := range tiers {
+			tier.Input, tier.Output = override.Apply(tier.Input, tier.Output)
+			scaled = append(scaled, tier)
+		}
+		scaledTiers := datatypes.NewJSONType(scaled)
+		effective.Tiers = &scaledTiers
+	}
+	return &effective
+}
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"done-hub/common/cache"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func setupPriceOverrideTest(t *testing.T) {
+	cache.InitCacheManager()
+	db := setupTestDB(t, &Token{}, &PriceOverride{})
+	assert.Nil(t, db.Session(&gorm.Session{SkipHooks: true}).Create(&Token{Id: 1, UserId: 1, Key: "key-1", Name: "a"}).Error)
+	assert.Nil(t, db.Session(&gorm.Session{SkipHooks: true}).Create(&Token{Id: 2, UserId: 1, Key: "key-2", Name: "b"}).Error)
+	assert.Nil(t, db.Session(&gorm.Session{SkipHooks: true}).Create(&Token{Id: 3, UserId: 2, Key: "key-3", Name: "c"}).Error)
+}
+
+func TestGetPriceOverride(t *testing.T) {
+	setupPriceOverrideTest(t)
+
+	overrides := []*PriceOverride{
+		{UserId: 1, Model: "gpt-*", Ratio: 0.9},
+		{UserId: 1, Model: "gpt-4o*", Ratio: 0.8},
+		{UserId: 1, Model: "gpt-4o", Ratio: 0.7},
+		{UserId: 1, TokenId: 1, Model: "gpt-*", Ratio: 0.6},
+		{UserId: 1, TokenId: 1, Model: "claude-3-5-sonnet", Ratio: 0.5},
+		{UserId: 2, Model: "*", Ratio: 0.4},
+	}
+	for _, override := range overrides {
+		assert.Nil(t, override.Insert())
+	}
+
+	tests := []struct {
+		name    string
+		userId  int
+		tokenId int
+		model   string
+		ratio   float64
+	}{
+		{"exact over wildcard", 1, 2, "gpt-4o", 0.7},
+		{"longest prefix", 1, 2, "gpt-4o-mini", 0.8},
+		{"shorter prefix", 1, 2, "gpt-3.5-turbo", 0.9},
+		// 令牌级优先于用户级，即使用户级是精确匹配
+		{"token over user", 1, 1, "gpt-4o", 0.6},
+		{"token exact", 1, 1, "claude-3-5-sonnet", 0.5},
+		{"other token", 1, 2, "claude-3-5-sonnet", 0},
+		{"wildcard all", 2, 3, "gemini-2.0-flash", 0.4},
+		{"no override", 1, 2, "gemini-2.0-flash", 0},
+		{"no user", 3, 0, "gpt-4o", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			override := GetPriceOverride(tt.userId, tt.tokenId, tt.model)
+			if tt.ratio == 0 {
+				assert.Nil(t, override)
+				return
+			}
+			assert.Equal(t, tt.ratio, override.Ratio)
+		})
+	}
+
+	// 修改后清除缓存
+	override := overrides[2]
+	before := *override
+	override.Ratio = 0.3
+	assert.Nil(t, override.Update(&before))
+	assert.Equal(t, 0.3, GetPriceOverride(1, 2, "gpt-4o").Ratio)
+
+	assert.Nil(t, override.Delete())
+	assert.Equal(t, 0.8, GetPriceOverride(1, 2, "gpt-4o").Ratio)
+}
+
+func TestPriceOverrideValidate(t *testing.T) {
+	setupPriceOverrideTest(t)
+
+	price := func(value float64) *float64 {
+		return &value
+	}
+
+	tests := []struct {
+		name     string
+		override PriceOverride
+		wantErr  bool
+		ratio    float64
+	}{
+		{"ratio", PriceOverride{UserId: 1, Model: " gpt-4o ", Ratio: 0.5}, false, 0.5},
+		{"default ratio", PriceOverride{UserId: 1, Model: "gpt-4o"}, false, 1},
+		// 固定价格模式不修改倍率
+		{"fixed", PriceOverride{UserId: 1, Model: "gpt-4o", Input: price(0)}, false, 0},
+		{"own token", PriceOverride{UserId: 1, TokenId: 2, Model: "gpt-4o", Ratio: 1}, false, 1},
+		{"other user's token", PriceOverride{UserId: 1, TokenId: 3, Model: "gpt-4o", Ratio: 1}, true, 0},
+		{"no user", PriceOverride{Model: "gpt-4o", Ratio: 1}, true, 0},
+		{"no model", PriceOverride{UserId: 1, Model: " ", Ratio: 1}, true, 0},
+		{"negative ratio", PriceOverride{UserId: 1, Model: "gpt-4o", Ratio: -1}, true, 0},
+		{"negative input", PriceOverride{UserId: 1, Model: "gpt-4o", Input: price(-1)}, true, 0},
+		{"negative output", PriceOverride{UserId: 1, Model: "gpt-4o", Output: price(-1)}, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.override.Validate()
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "gpt-4o", tt.override.Model)
+			assert.Equal(t, tt.ratio, tt.override.Ratio)
+		})
+	}
+}
+
+func TestPriceOverrideApply(t *testing.T) {
+	price := func(value float64) *float64 {
+		return &value
+	}
+
+	tests := []struct {
+		name     string
+		override PriceOverride
+		input    float64
+		output   float64
+	}{
+		{"ratio", PriceOverride{Ratio: 0.5}, 1, 2},
+		{"free", PriceOverride{Ratio: 0}, 0, 0},
+		{"fixed", PriceOverride{Ratio: 0.5, Input: price(3), Output: price(4)}, 3, 4},
+		// 固定价格模式下未设置的一侧保持原价，不乘以倍率
+		{"fixed input only", PriceOverride{Ratio: 0.5, Input: price(3)}, 3, 4},
+		{"fixed output only", PriceOverride{Ratio: 0.5, Output: price(0)}, 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, output := tt.override.Apply(2, 4)
+			assert.Equal(t, tt.input, input)
+			assert.Equal(t, tt.output, output)
+		})
+	}
+}
+
+func TestPriceOverrideApplyToPrice(t *testing.T) {
+	tiers := datatypes.NewJSONType([]PriceTier{{Threshold: 1000, Input: 4, Output: 8}})
+	windows := datatypes.NewJSONType([]PriceTimeWindow{{Start: "22:00", End: "06:00", Ratio: 0.5}})
+	price := &Price{Model: "gpt-4o", Type: TokensPriceType, Input: 2, Output: 4, Tiers: &tiers, TimeWindows: &windows}
+
+	// 倍率模式同时折算阶梯价格，保留分时段
+	effective := (&PriceOverride{Ratio: 0.5}).ApplyToPrice(price)
+	assert.Equal(t, 1.0, effective.Input)
+	assert.Equal(t, 2.0, effective.Output)
+	assert.Equal(t, []PriceTier{{Threshold: 1000, Input: 2, Output: 4}}, effective.GetTiers())
+	assert.Len(t, effective.GetTimeWindows(), 1)
+
+	// 固定价格模式不再使用阶梯和分时段
+	input := 1.5
+	effective = (&PriceOverride{Input: &input}).ApplyToPrice(price)
+	assert.Equal(t, 1.5, effective.Input)
+	assert.Equal(t, 4.0, effective.Output)
+	assert.Nil(t, effective.GetTiers())
+	assert.Nil(t, effective.GetTimeWindows())
+
+	// 原价格不受影响
+	assert.Equal(t, 2.0, price.Input)
+	assert.Equal(t, 4.0, price.GetTiers()[0].Input)
+}
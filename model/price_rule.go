@@ -0,0 +1,181 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// PriceTier 阶梯价格，提示词 token 数超过 Threshold 时整次请求使用该档价格
+type PriceTier struct {
+	Threshold int     `json:"threshold"`
+	Input     float64 `json:"input"`
+	Output    float64 `json:"output"`
+}
+
+// PriceTimeWindow 分时段价格，Start/End 为 HH:MM，End 早于 Start 时表示跨天
+type PriceTimeWindow struct {
+	Start    string  `json:"start"`
+	End      string  `json:"end"`
+	Timezone string  `json:"timezone,omitempty"` // 为空时使用服务器时区
+	Ratio    float64 `json:"ratio"`              // 价格倍率，0.5 表示半价
+}
+
+var timeLocationCache sync.Map
+
+func loadTimeLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+
+	if location, ok := timeLocationCache.Load(name); ok {
+		return location.(*time.Location), nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	timeLocationCache.Store(name, location)
+	return location, nil
+}
+
+func parseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("时间格式错误: %s", value)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+// Contains 判断时间是否在时段内，包含开始不包含结束
+func (window *PriceTimeWindow) Contains(at time.Time) bool {
+	location, err := loadTimeLocation(window.Timezone)
+	if err != nil {
+		return false
+	}
+	start, err := parseClock(window.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(window.End)
+	if err != nil {
+		return false
+	}
+
+	local := at.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+func (price *Price) GetTiers() []PriceTier {
+	if price.Tiers == nil {
+		return nil
+	}
+	return price.Tiers.Data()
+}
+
+func (price *Price) GetTimeWindows() []PriceTimeWindow {
+	if price.TimeWindows == nil {
+		return nil
+	}
+	return price.TimeWindows.Data()
+}
+
+// GetTier 返回命中的最高一档，未命中时返回 nil
+func (price *Price) GetTier(promptTokens int) *PriceTier {
+	if price.Type != TokensPriceType {
+		return nil
+	}
+
+	var matched *PriceTier
+	for _, tier := range price.GetTiers() {
+		if promptTokens > tier.Threshold && (matched == nil || tier.Threshold > matched.Threshold) {
+			tier := tier
+			matched = &tier
+		}
+	}
+	return matched
+}
+
+// GetTimeWindow 返回第一个包含该时间的时段
+func (price *Price) GetTimeWindow(at time.Time) *PriceTimeWindow {
+	for _, window := range price.GetTimeWindows() {
+		if window.Contains(at) {
+			window := window
+			return &window
+		}
+	}
+	return nil
+}
+
+// Resolve 根据提示词长度和请求时间计算实际的输入输出价格
+func (price *Price) Resolve(promptTokens int, at time.Time) (input, output float64) {
+	input = price.GetInput()
+	output = price.GetOutput()
+
+	if tier := price.GetTier(promptTokens); tier != nil {
+		input = max(tier.Input, 0)
+		output = max(tier.Output, 0)
+	}
+
+	if window := price.GetTimeWindow(at); window != nil {
+		input *= window.Ratio
+		output *= window.Ratio
+	}
+
+	return
+}
+
+// ValidateRules 检查阶梯和分时段配置，阶梯会按阈值排序
+func (price *Price) ValidateRules() error {
+	if tiers := price.GetTiers(); len(tiers) > 0 {
+		if price.Type != TokensPriceType {
+			return errors.New("只有按 token 计费的模型支持阶梯价格")
+		}
+
+		seen := make(map[int]bool, len(tiers))
+		for _, tier := range tiers {
+			if tier.Threshold <= 0 || tier.Input < 0 || tier.Output < 0 {
+				return errors.New("阶梯价格的阈值必须大于 0，价格不能为负数")
+			}
+			if seen[tier.Threshold] {
+				return fmt.Errorf("阶梯价格阈值重复: %d", tier.Threshold)
+			}
+			seen[tier.Threshold] = true
+		}
+
+		sort.Slice(tiers, func(i, j int) bool {
+			return tiers[i].Threshold < tiers[j].Threshold
+		})
+		sorted := datatypes.NewJSONType(tiers)
+		price.Tiers = &sorted
+	}
+
+	for _, window := range price.GetTimeWindows() {
+		if _, err := parseClock(window.Start); err != nil {
+			return err
+		}
+		if _, err := parseClock(window.End); err != nil {
+			return err
+		}
+		if window.Start == window.End {
+			return errors.New("时段的开始和结束时间不能相同")
+		}
+		if _, err := loadTimeLocation(window.Timezone); err != nil {
+			return fmt.Errorf("时区无效: %s", window.Timezone)
+		}
+		if window.Ratio < 0 {
+			return errors.New("时段价格倍率不能为负数")
+		}
+	}
+
+	return nil
+}
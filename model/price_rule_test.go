@@ -0,0 +1,148 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func newRulePrice(priceType string, tiers []PriceTier, windows []PriceTimeWindow) *Price {
+	price := &Price{Model: "gpt-4o", Type: priceType, Input: 1, Output: 2}
+	if tiers != nil {
+		data := datatypes.NewJSONType(tiers)
+		price.Tiers = &data
+	}
+	if windows != nil {
+		data := datatypes.NewJSONType(windows)
+		price.TimeWindows = &data
+	}
+	return price
+}
+
+func TestGetTier(t *testing.T) {
+	price := newRulePrice(TokensPriceType, []PriceTier{
+		{Threshold: 200000, Input: 4, Output: 8},
+		{Threshold: 32000, Input: 2, Output: 4},
+	}, nil)
+
+	tests := []struct {
+		name         string
+		promptTokens int
+		threshold    int
+	}{
+		{"below all", 1000, 0},
+		{"equal to threshold", 32000, 0},
+		{"above threshold", 32001, 32000},
+		{"equal to highest threshold", 200000, 32000},
+		{"above all", 200001, 200000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tier := price.GetTier(tt.promptTokens)
+			if tt.threshold == 0 {
+				assert.Nil(t, tier)
+				return
+			}
+			assert.Equal(t, tt.threshold, tier.Threshold)
+		})
+	}
+
+	// 只有按 token 计费的模型使用阶梯价格
+	price.Type = TimesPriceType
+	assert.Nil(t, price.GetTier(200001))
+	assert.Nil(t, newRulePrice(TokensPriceType, nil, nil).GetTier(200001))
+}
+
+func TestPriceTimeWindowContains(t *testing.T) {
+	tests := []struct {
+		name   string
+		window PriceTimeWindow
+		at     string
+		want   bool
+	}{
+		{"inside", PriceTimeWindow{Start: "09:00", End: "18:00", Timezone: "UTC"}, "12:00", true},
+		{"at start", PriceTimeWindow{Start: "09:00", End: "18:00", Timezone: "UTC"}, "09:00", true},
+		{"at end", PriceTimeWindow{Start: "09:00", End: "18:00", Timezone: "UTC"}, "18:00", false},
+		{"before start", PriceTimeWindow{Start: "09:00", End: "18:00", Timezone: "UTC"}, "08:59", false},
+		{"crossing midnight before", PriceTimeWindow{Start: "22:00", End: "06:00", Timezone: "UTC"}, "23:30", true},
+		{"crossing midnight after", PriceTimeWindow{Start: "22:00", End: "06:00", Timezone: "UTC"}, "05:59", true},
+		{"crossing midnight at end", PriceTimeWindow{Start: "22:00", End: "06:00", Timezone: "UTC"}, "06:00", false},
+		{"crossing midnight outside", PriceTimeWindow{Start: "22:00", End: "06:00", Timezone: "UTC"}, "12:00", false},
+		// 开始和结束相同的时段不包含任何时间，保存时会被拒绝
+		{"start equals end", PriceTimeWindow{Start: "09:00", End: "09:00", Timezone: "UTC"}, "09:00", false},
+		// 按时段的时区判断，UTC 16:00 为上海时间 00:00
+		{"timezone", PriceTimeWindow{Start: "00:00", End: "08:00", Timezone: "Asia/Shanghai"}, "16:00", true},
+		{"invalid timezone", PriceTimeWindow{Start: "00:00", End: "23:59", Timezone: "Mars/Base"}, "12:00", false},
+		{"invalid clock", PriceTimeWindow{Start: "9am", End: "23:59", Timezone: "UTC"}, "12:00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, err := time.ParseInLocation("15:04", tt.at, time.UTC)
+			assert.Nil(t, err)
+			at = time.Date(2026, 1, 1, at.Hour(), at.Minute(), 0, 0, time.UTC)
+			assert.Equal(t, tt.want, tt.window.Contains(at))
+		})
+	}
+}
+
+func TestPriceResolve(t *testing.T) {
+	price := newRulePrice(TokensPriceType, []PriceTier{{Threshold: 32000, Input: 2, Output: 4}}, []PriceTimeWindow{
+		{Start: "22:00", End: "06:00", Timezone: "UTC", Ratio: 0.5},
+	})
+	day := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	night := time.Date(2026, 1, 1, 23, 0, 0, 0, time.UTC)
+
+	input, output := price.Resolve(1000, day)
+	assert.Equal(t, []float64{1, 2}, []float64{input, output})
+
+	input, output = price.Resolve(32001, day)
+	assert.Equal(t, []float64{2, 4}, []float64{input, output})
+
+	// 阶梯价格再乘以时段倍率
+	input, output = price.Resolve(32001, night)
+	assert.Equal(t, []float64{1, 2}, []float64{input, output})
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		price   *Price
+		wantErr bool
+	}{
+		{"empty", newRulePrice(TokensPriceType, nil, nil), false},
+		{"valid", newRulePrice(TokensPriceType, []PriceTier{{Threshold: 1000, Input: 2}}, []PriceTimeWindow{{Start: "22:00", End: "06:00", Timezone: "Asia/Shanghai", Ratio: 0.5}}), false},
+		{"tiers on times price", newRulePrice(TimesPriceType, []PriceTier{{Threshold: 1000}}, nil), true},
+		{"zero threshold", newRulePrice(TokensPriceType, []PriceTier{{Threshold: 0}}, nil), true},
+		{"negative tier price", newRulePrice(TokensPriceType, []PriceTier{{Threshold: 1000, Output: -1}}, nil), true},
+		{"duplicate threshold", newRulePrice(TokensPriceType, []PriceTier{{Threshold: 1000}, {Threshold: 1000}}, nil), true},
+		{"invalid start", newRulePrice(TokensPriceType, nil, []PriceTimeWindow{{Start: "24:00", End: "06:00"}}), true},
+		{"invalid end", newRulePrice(TokensPriceType, nil, []PriceTimeWindow{{Start: "22:00", End: "6"}}), true},
+		{"start equals end", newRulePrice(TokensPriceType, nil, []PriceTimeWindow{{Start: "06:00", End: "06:00"}}), true},
+		{"invalid timezone", newRulePrice(TokensPriceType, nil, []PriceTimeWindow{{Start: "22:00", End: "06:00", Timezone: "Mars/Base"}}), true},
+		{"negative ratio", newRulePrice(TokensPriceType, nil, []PriceTimeWindow{{Start: "22:00", End: "06:00", Ratio: -1}}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.price.ValidateRules()
+			if tt.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+
+	// 阶梯按阈值排序
+	price := newRulePrice(TokensPriceType, []PriceTier{{Threshold: 200000}, {Threshold: 1000}, {Threshold: 32000}}, nil)
+	assert.Nil(t, price.ValidateRules())
+	thresholds := make([]int, 0)
+	for _, tier := range price.GetTiers() {
+		thresholds = append(thresholds, tier.Threshold)
+	}
+	assert.Equal(t, []int{1000, 32000, 200000}, thresholds)
+}
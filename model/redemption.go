@@ -94,6 +94,13 @@ func Redeem(key string, userId int, ip string) (quota int, err error) {
 	}
 
 	RecordQuotaLog(userId, LogTypeTopup, redemption.Quota, ip, fmt.Sprintf("通过兑换码充值 %s", common.LogQuota(redemption.Quota)))
+	PublishWebhookEvent(WebhookEventRedemptionUsed, map[string]any{
+		"id":            redemption.Id,
+		"name":          redemption.Name,
+		"quota":         redemption.Quota,
+		"user_id":       userId,
+		"redeemed_time": redemption.RedeemedTime,
+	})
 
 	// 处理邀请人充值返利
 	err = ProcessInviterReward(userId, redemption.Quota, ip)
@@ -0,0 +1,198 @@
+package model
+
+import (
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/common/script"
+	"done-hub/common/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 脚本中可以定义的钩子函数，每个函数接收一个 ctx 字典
+const (
+	ScriptHookRequest  = "on_request"  // 修改或拒绝请求，返回 {"request": ..., "reject": "原因"}
+	ScriptHookChannels = "on_channels" // 返回保留的渠道 ID 列表
+	ScriptHookBilling  = "on_billing"  // 返回新的扣费额度
+)
+
+var ScriptHooks = []string{ScriptHookRequest, ScriptHookChannels, ScriptHookBilling}
+
+// Script 管理员维护的 Starlark 脚本，多个脚本按 Sort 从小到大依次执行
+type Script struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name" gorm:"type:varchar(64);uniqueIndex"`
+	Code        string `json:"code" gorm:"type:text"`
+	Enabled     bool   `json:"enabled" gorm:"default:true"`
+	Sort        int    `json:"sort" gorm:"default:0"`
+	Remark      string `json:"remark" gorm:"type:varchar(255);default:''"`
+	CreatedTime int64  `json:"created_time" gorm:"bigint"`
+	UpdatedTime int64  `json:"updated_time" gorm:"bigint"`
+}
+
+type SearchScriptsParams struct {
+	Name string `form:"name"`
+	PaginationParams
+}
+
+var allowedScriptOrderFields = map[string]bool{
+	"id":           true,
+	"name":         true,
+	"sort":         true,
+	"created_time": true,
+}
+
+func GetScriptsList(params *SearchScriptsParams) (*DataResult[Script], error) {
+	var scripts []*Script
+	db := DB
+
+	if params.Name != "" {
+		db = db.Where("name LIKE ?", params.Name+"%")
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &scripts, allowedScriptOrderFields)
+}
+
+func GetScriptById(id int) (*Script, error) {
+	s := &Script{}
+	err := DB.Where("id = ?", id).First(s).Error
+	return s, err
+}
+
+func (s *Script) Insert() error {
+	s.CreatedTime = utils.GetTimestamp()
+	s.UpdatedTime = s.CreatedTime
+	if err := DB.Create(s).Error; err != nil {
+		return err
+	}
+	return ScriptInstance.Load()
+}
+
+func (s *Script) Update() error {
+	s.UpdatedTime = utils.GetTimestamp()
+	if err := DB.Select("name", "code", "enabled", "sort", "remark", "updated_time").Updates(s).Error; err != nil {
+		return err
+	}
+	return ScriptInstance.Load()
+}
+
+func (s *Script) Delete() error {
+	if err := DB.Delete(s).Error; err != nil {
+		return err
+	}
+	return ScriptInstance.Load()
+}
+
+// Validate 检查名称并编译脚本
+func (s *Script) Validate() error {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return errors.New("脚本名称不能为空")
+	}
+	if strings.TrimSpace(s.Code) == "" {
+		return errors.New("脚本内容不能为空")
+	}
+
+	program, err := script.Compile(s.Name, s.Code, GetScriptBudget())
+	if err != nil {
+		return fmt.Errorf("脚本编译失败: %s", err.Error())
+	}
+
+	for _, hook := range ScriptHooks {
+		if program.Has(hook) {
+			return nil
+		}
+	}
+	return fmt.Errorf("脚本至少需要定义一个钩子函数: %s", strings.Join(ScriptHooks, ", "))
+}
+
+// GetScriptBudget 单个脚本每次执行的步数和时间限制
+func GetScriptBudget() script.Budget {
+	return script.Budget{
+		MaxSteps: uint64(max(config.ScriptMaxSteps, 0)),
+		Timeout:  time.Duration(config.ScriptTimeout) * time.Millisecond,
+	}
+}
+
+type Scripts struct {
+	sync.RWMutex
+	programs []*script.Program
+}
+
+var ScriptInstance = &Scripts{}
+
+// Load 从数据库加载启用的脚本，编译失败的脚本会被跳过
+func (s *Scripts) Load() error {
+	var scripts []*Script
+	if err := DB.Where("enabled = ?", true).Order("sort asc, id asc").Find(&scripts).Error; err != nil {
+		logger.SysError("failed to load scripts: " + err.Error())
+		return err
+	}
+
+	budget := GetScriptBudget()
+	programs := make([]*script.Program, 0, len(scripts))
+	for _, item := range scripts {
+		program, err := script.Compile(item.Name, item.Code, budget)
+		if err != nil {
+			logger.SysError(fmt.Sprintf("failed to compile script %s: %s", item.Name, err.Error()))
+			continue
+		}
+		programs = append(programs, program)
+	}
+
+	s.SetPrograms(programs)
+	return nil
+}
+
+// SetPrograms 替换当前生效的脚本
+func (s *Scripts) SetPrograms(programs []*script.Program) {
+	s.Lock()
+	s.programs = programs
+	s.Unlock()
+}
+
+// Has 是否有脚本定义了指定的钩子
+func (s *Scripts) Has(hook string) bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	for _, program := range s.programs {
+		if program.Has(hook) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run 依次执行定义了该钩子的脚本，handle 处理每个脚本的返回值
+// 脚本出错或超出限制时记录日志并跳过，不影响请求；handle 返回错误时停止执行
+func (s *Scripts) Run(hook string, ctx map[string]any, handle func(result any) error) error {
+	s.RLock()
+	programs := s.programs
+	s.RUnlock()
+
+	budget := GetScriptBudget()
+	for _, program := range programs {
+		if !program.Has(hook) {
+			continue
+		}
+
+		result, err := program.Call(hook, ctx, budget)
+		if err != nil {
+			logger.SysError(fmt.Sprintf("script %s %s failed: %s", program.Name(), hook, err.Error()))
+			continue
+		}
+		if result == nil {
+			continue
+		}
+
+		if err := handle(result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
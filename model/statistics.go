@@ -15,6 +15,7 @@ type Statistics struct {
 	ModelName        string    `json:"model_name" gorm:"primary_key;type:varchar(255)"`
 	RequestCount     int       `json:"request_count"`
 	Quota            int       `json:"quota"`
+	Cost             int       `json:"cost" gorm:"default:0"`
 	PromptTokens     int       `json:"prompt_tokens"`
 	CompletionTokens int       `json:"completion_tokens"`
 	RequestTime      int       `json:"request_time"`
@@ -67,6 +68,7 @@ func GetChannelExpensesStatisticsByPeriod(startTime, endTime, groupType string,
         SELECT ` + dateStr + `,
         sum(request_count) as request_count,
         sum(quota) as quota,
+        sum(cost) as cost,
         sum(prompt_tokens) as prompt_tokens,
         sum(completion_tokens) as completion_tokens,
         sum(request_time) as request_time,`
@@ -108,6 +110,60 @@ func GetChannelExpensesStatisticsByPeriod(startTime, endTime, groupType string,
 	return LogStatistics, nil
 }
 
+type MarginStatistic struct {
+	Date         string `json:"date" gorm:"column:date"`
+	Name         string `json:"name" gorm:"column:name"`
+	RequestCount int64  `json:"request_count" gorm:"column:request_count"`
+	Revenue      int64  `json:"revenue" gorm:"column:revenue"`
+	Cost         int64  `json:"cost" gorm:"column:cost"`
+	Margin       int64  `json:"margin" gorm:"column:margin"`
+}
+
+// GetMarginStatisticsByPeriod 按渠道、模型或日期统计收入、上游成本和毛利
+func GetMarginStatisticsByPeriod(startTime, endTime, groupType string) (statistics []*MarginStatistic, err error) {
+	dateStr := "date"
+	if common.UsingPostgreSQL {
+		dateStr = "TO_CHAR(date, 'YYYY-MM-DD') as date"
+	} else if common.UsingSQLite {
+		dateStr = "strftime('%Y-%m-%d', date) as date"
+	}
+
+	baseSelect := `
+        SELECT ` + dateStr + `,
+        sum(request_count) as request_count,
+        sum(quota) as revenue,
+        sum(cost) as cost,
+        sum(quota) - sum(cost) as margin`
+
+	var sql string
+	switch groupType {
+	case "model":
+		sql = baseSelect + `,
+            model_name as name
+            FROM statistics
+            WHERE date BETWEEN ? AND ?
+            GROUP BY date, model_name
+            ORDER BY date, model_name`
+	case "day":
+		sql = baseSelect + `
+            FROM statistics
+            WHERE date BETWEEN ? AND ?
+            GROUP BY date
+            ORDER BY date`
+	default:
+		sql = baseSelect + `,
+            MAX(channels.name) as name
+            FROM statistics
+            JOIN channels ON statistics.channel_id = channels.id
+            WHERE date BETWEEN ? AND ?
+            GROUP BY date, channel_id
+            ORDER BY date, channel_id`
+	}
+
+	err = DB.Raw(sql, startTime, endTime).Scan(&statistics).Error
+	return
+}
+
 type StatisticsUpdateType int
 
 const (
@@ -118,7 +174,7 @@ const (
 
 func UpdateStatistics(updateType StatisticsUpdateType) error {
 	sql := `
-	%s statistics (date, user_id, channel_id, model_name, request_count, quota, prompt_tokens, completion_tokens, request_time)
+	%s statistics (date, user_id, channel_id, model_name, request_count, quota, cost, prompt_tokens, completion_tokens, request_time)
 	SELECT 
 		%s as date,
 		user_id,
@@ -126,6 +182,7 @@ func UpdateStatistics(updateType StatisticsUpdateType) error {
 		model_name, 
 		count(1) as request_count,
 		sum(quota) as quota,
+		sum(cost) as cost,
 		sum(prompt_tokens) as prompt_tokens,
 		sum(completion_tokens) as completion_tokens,
 		sum(request_time) as request_time
@@ -198,6 +255,7 @@ func UpdateStatistics(updateType StatisticsUpdateType) error {
 		sqlSuffix = `ON CONFLICT (date, user_id, channel_id, model_name) DO UPDATE SET
 		request_count = EXCLUDED.request_count,
 		quota = EXCLUDED.quota,
+		cost = EXCLUDED.cost,
 		prompt_tokens = EXCLUDED.prompt_tokens,
 		completion_tokens = EXCLUDED.completion_tokens,
 		request_time = EXCLUDED.request_time`
@@ -209,6 +267,7 @@ func UpdateStatistics(updateType StatisticsUpdateType) error {
 		sqlSuffix = `ON DUPLICATE KEY UPDATE
 		request_count = VALUES(request_count),
 		quota = VALUES(quota),
+		cost = VALUES(cost),
 		prompt_tokens = VALUES(prompt_tokens),
 		completion_tokens = VALUES(completion_tokens),
 		request_time = VALUES(request_time)`
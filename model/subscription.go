@@ -0,0 +1,502 @@
+package model
+
+import (
+	"crypto/sha1"
+	"done-hub/common"
+	"done-hub/common/cache"
+	"done-hub/common/config"
+	"done-hub/common/limit"
+	"done-hub/common/logger"
+	"done-hub/common/redis"
+	"done-hub/common/utils"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SubscriptionStatusActive   = "active"
+	SubscriptionStatusCanceled = "canceled" // 已取消自动续费，到期后失效
+	SubscriptionStatusExpired  = "expired"
+)
+
+var (
+	userSubscriptionCacheKey = "user_subscription:%d"
+	subscriptionLimitKey     = "subscription-limiter:%d"
+)
+
+// 自动续费的订阅在到期后保留一段时间等待网关扣款通知
+const subscriptionRenewGrace = 24 * time.Hour
+
+// 订阅套餐，每个周期发放额度或在周期内不限量使用指定模型
+type SubscriptionPlan struct {
+	Id          int     `json:"id"`
+	Name        string  `json:"name" gorm:"type:varchar(64)"`
+	Description string  `json:"description" gorm:"type:text"`
+	Price       float64 `json:"price" gorm:"type:decimal(10,2);default:0"` // 每周期价格，单位为 USD
+	PeriodDays  int     `json:"period_days" gorm:"default:30"`
+	Quota       int     `json:"quota" gorm:"default:0"`         // 每周期发放的额度
+	Unlimited   bool    `json:"unlimited" gorm:"default:false"` // 周期内不限量使用 Models 中的模型
+	Models      string  `json:"models" gorm:"type:text"`        // 逗号分隔，为空表示全部模型
+	RateLimit   int     `json:"rate_limit" gorm:"default:0"`    // 不限量模型的 RPM 上限，0 为不限制
+	Group       string  `json:"group" gorm:"type:varchar(32);default:''"`
+	Enabled     bool    `json:"enabled" gorm:"default:true"`
+	Sort        int     `json:"sort" gorm:"default:0"`
+	CreatedAt   int64   `json:"created_at" gorm:"bigint"`
+	UpdatedAt   int64   `json:"updated_at" gorm:"bigint"`
+}
+
+// 用户的订阅，同一时间只有一个生效中的订阅
+type UserSubscription struct {
+	Id                    int               `json:"id"`
+	UserId                int               `json:"user_id" gorm:"index"`
+	PlanId                int               `json:"plan_id" gorm:"index"`
+	Status                string            `json:"status" gorm:"type:varchar(16);index"`
+	AutoRenew             bool              `json:"auto_renew" gorm:"default:false"`
+	GatewayId             int               `json:"gateway_id" gorm:"default:0"`
+	GatewaySubscriptionId string            `json:"-" gorm:"type:varchar(100);index"`
+	TradeNo               string            `json:"trade_no" gorm:"type:varchar(50)"` // 最近一次支付的订单
+	PreviousGroup         string            `json:"-" gorm:"type:varchar(32);default:''"`
+	StartedAt             int64             `json:"started_at" gorm:"bigint"`
+	PeriodStart           int64             `json:"period_start" gorm:"bigint"`
+	PeriodEnd             int64             `json:"period_end" gorm:"bigint;index"`
+	CreatedAt             int64             `json:"created_at" gorm:"bigint"`
+	UpdatedAt             int64             `json:"updated_at" gorm:"bigint"`
+	Plan                  *SubscriptionPlan `json:"plan,omitempty" gorm:"-"`
+}
+
+type SearchUserSubscriptionsParams struct {
+	UserId int    `form:"user_id"`
+	PlanId int    `form:"plan_id"`
+	Status string `form:"status"`
+	PaginationParams
+}
+
+var allowedSubscriptionPlanOrderFields = map[string]bool{
+	"id":         true,
+	"name":       true,
+	"price":      true,
+	"sort":       true,
+	"created_at": true,
+}
+
+var allowedUserSubscriptionOrderFields = map[string]bool{
+	"id":         true,
+	"user_id":    true,
+	"plan_id":    true,
+	"status":     true,
+	"period_end": true,
+	"created_at": true,
+}
+
+func GetSubscriptionPlansList(params *PaginationParams) (*DataResult[SubscriptionPlan], error) {
+	var plans []*SubscriptionPlan
+	return PaginateAndOrder(DB, params, &plans, allowedSubscriptionPlanOrderFields)
+}
+
+func GetEnabledSubscriptionPlans() ([]*SubscriptionPlan, error) {
+	var plans []*SubscriptionPlan
+	err := DB.Where("enabled = ?", true).Order("sort desc, id asc").Find(&plans).Error
+	return plans, err
+}
+
+func GetSubscriptionPlanById(id int) (*SubscriptionPlan, error) {
+	plan := &SubscriptionPlan{}
+	err := DB.Where("id = ?", id).First(plan).Error
+	return plan, err
+}
+
+func (plan *SubscriptionPlan) Insert() error {
+	plan.CreatedAt = utils.GetTimestamp()
+	plan.UpdatedAt = plan.CreatedAt
+	return DB.Create(plan).Error
+}
+
+func (plan *SubscriptionPlan) Update() error {
+	plan.UpdatedAt = utils.GetTimestamp()
+	err := DB.Select("name", "description", "price", "period_days", "quota", "unlimited", "models", "rate_limit", "group", "enabled", "sort", "updated_at").Updates(plan).Error
+	if err != nil {
+		return err
+	}
+
+	// 套餐内容会缓存在用户订阅中
+	var userIds []int
+	DB.Model(&UserSubscription{}).Where("plan_id = ? AND status <> ?", plan.Id, SubscriptionStatusExpired).Pluck("user_id", &userIds)
+	for _, userId := range userIds {
+		cache.DeleteCache(fmt.Sprintf(userSubscriptionCacheKey, userId))
+	}
+
+	return nil
+}
+
+func (plan *SubscriptionPlan) Delete() error {
+	var count int64
+	DB.Model(&UserSubscription{}).Where("plan_id = ? AND status <> ?", plan.Id, SubscriptionStatusExpired).Count(&count)
+	if count > 0 {
+		return errors.New("该套餐还有生效中的订阅，请先禁用")
+	}
+	return DB.Delete(plan).Error
+}
+
+// CoversModel 不限量套餐是否包含该模型
+func (plan *SubscriptionPlan) CoversModel(modelName string) bool {
+	if strings.TrimSpace(plan.Models) == "" {
+		return true
+	}
+
+	for _, item := range strings.Split(plan.Models, ",") {
+		if strings.TrimSpace(item) == modelName {
+			return true
+		}
+	}
+	return false
+}
+
+func (plan *SubscriptionPlan) periodSeconds() int64 {
+	return int64(plan.PeriodDays) * 86400
+}
+
+func GetUserSubscriptionsList(params *SearchUserSubscriptionsParams) (*DataResult[UserSubscription], error) {
+	var subscriptions []*UserSubscription
+	db := DB
+
+	if params.UserId != 0 {
+		db = db.Where("user_id = ?", params.UserId)
+	}
+
+	if params.PlanId != 0 {
+		db = db.Where("plan_id = ?", params.PlanId)
+	}
+
+	if params.Status != "" {
+		db = db.Where("status = ?", params.Status)
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &subscriptions, allowedUserSubscriptionOrderFields)
+}
+
+func getUserActiveSubscription(db *gorm.DB, userId int) (*UserSubscription, error) {
+	subscription := &UserSubscription{}
+	err := db.Where("user_id = ? AND status <> ?", userId, SubscriptionStatusExpired).Order("id desc").First(subscription).Error
+	return subscription, err
+}
+
+// GetUserSubscription 获取用户生效中的订阅，没有时返回 Id 为 0 的空订阅
+func GetUserSubscription(userId int) (*UserSubscription, error) {
+	subscription, err := getUserActiveSubscription(DB, userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &UserSubscription{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.Plan, err = GetSubscriptionPlanById(subscription.PlanId)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func CacheGetUserSubscription(userId int) (*UserSubscription, error) {
+	return cache.GetOrSetCache(
+		fmt.Sprintf(userSubscriptionCacheKey, userId),
+		5*time.Minute,
+		func() (*UserSubscription, error) {
+			return GetUserSubscription(userId)
+		},
+		cache.CacheTimeout)
+}
+
+// GetUnlimitedSubscriptionPlan 用户当前订阅可以不限量使用该模型时返回对应套餐
+func GetUnlimitedSubscriptionPlan(userId int, modelName string) *SubscriptionPlan {
+	subscription, err := CacheGetUserSubscription(userId)
+	if err != nil || subscription.Id == 0 || subscription.Plan == nil {
+		return nil
+	}
+
+	if !subscription.Plan.Unlimited || subscription.PeriodEnd <= utils.GetTimestamp() || !subscription.Plan.CoversModel(modelName) {
+		return nil
+	}
+
+	return subscription.Plan
+}
+
+var subscriptionLimiters sync.Map
+
+// AllowSubscriptionRequest 不限量套餐的速率限制
+func AllowSubscriptionRequest(userId int, plan *SubscriptionPlan) bool {
+	if plan.RateLimit <= 0 {
+		return true
+	}
+
+	limiterKey := fmt.Sprintf("%d:%d", plan.Id, plan.RateLimit)
+	limiter, ok := subscriptionLimiters.Load(limiterKey)
+	if !ok {
+		limiter, _ = subscriptionLimiters.LoadOrStore(limiterKey, limit.NewAPILimiter(plan.RateLimit))
+	}
+
+	return limiter.(limit.RateLimiter).Allow(fmt.Sprintf(subscriptionLimitKey, userId))
+}
+
+// ActivateSubscription 订阅订单支付成功后开通或续期，并发放本周期额度
+func ActivateSubscription(order *Order, gatewaySubscriptionId string) (*UserSubscription, error) {
+	plan, err := GetSubscriptionPlanById(order.PlanId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := utils.GetTimestamp()
+	subscription := &UserSubscription{}
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		user := &User{}
+		if err := tx.Select("id", "group").Where("id = ?", order.UserId).First(user).Error; err != nil {
+			return err
+		}
+
+		current, err := getUserActiveSubscription(tx, order.UserId)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err == nil && current.PlanId == plan.Id {
+			// 同一套餐续期，从当前周期结束时开始
+			subscription = current
+			subscription.PeriodStart = max(subscription.PeriodEnd, now)
+		} else {
+			previousGroup := user.Group
+			if err == nil {
+				// 更换套餐，旧订阅直接结束，保留最初的分组
+				previousGroup = current.PreviousGroup
+				if err := tx.Model(current).Updates(map[string]interface{}{
+					"status":     SubscriptionStatusExpired,
+					"period_end": now,
+					"updated_at": now,
+				}).Error; err != nil {
+					return err
+				}
+			}
+
+			subscription = &UserSubscription{
+				UserId:        order.UserId,
+				PlanId:        plan.Id,
+				PreviousGroup: previousGroup,
+				StartedAt:     now,
+				PeriodStart:   now,
+				CreatedAt:     now,
+			}
+		}
+
+		subscription.Status = SubscriptionStatusActive
+		subscription.AutoRenew = gatewaySubscriptionId != ""
+		subscription.GatewayId = order.GatewayId
+		subscription.GatewaySubscriptionId = gatewaySubscriptionId
+		subscription.TradeNo = order.TradeNo
+		subscription.PeriodEnd = subscription.PeriodStart + plan.periodSeconds()
+		subscription.UpdatedAt = now
+		if err := tx.Save(subscription).Error; err != nil {
+			return err
+		}
+
+		if plan.Group != "" && plan.Group != user.Group {
+			return tx.Model(&User{}).Where("id = ?", order.UserId).Update("group", plan.Group).Error
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.Plan = plan
+	clearUserSubscriptionCache(order.UserId)
+	grantSubscriptionQuota(subscription, "订阅套餐「%s」生效")
+	PublishWebhookEvent(WebhookEventSubscriptionActivated, subscription)
+
+	return subscription, nil
+}
+
+// RenewSubscriptionByGateway 网关自动扣款成功后续期，gatewayNo 用于防止重复通知
+func RenewSubscriptionByGateway(gatewayId int, gatewaySubscriptionId, gatewayNo string) error {
+	subscription := &UserSubscription{}
+	err := DB.Where("gateway_id = ? AND gateway_subscription_id = ?", gatewayId, gatewaySubscriptionId).Order("id desc").First(subscription).Error
+	if err != nil {
+		return err
+	}
+
+	// 同一笔扣款的订单号固定，并发的重复通知由 trade_no 唯一索引拦截
+	tradeNo := renewalTradeNo(gatewayId, gatewayNo)
+	if _, err := GetOrderByTradeNo(tradeNo); err == nil {
+		return nil
+	}
+
+	plan, err := GetSubscriptionPlanById(subscription.PlanId)
+	if err != nil {
+		return err
+	}
+
+	// 续费也记录订单，金额沿用首次订阅
+	order := &Order{
+		UserId:    subscription.UserId,
+		GatewayId: gatewayId,
+		TradeNo:   tradeNo,
+		GatewayNo: gatewayNo,
+		PlanId:    plan.Id,
+		Quota:     plan.Quota,
+		Status:    OrderStatusSuccess,
+	}
+	if lastOrder, err := GetOrderByTradeNo(subscription.TradeNo); err == nil {
+		order.OrderAmount = lastOrder.OrderAmount
+		order.OrderCurrency = lastOrder.OrderCurrency
+		order.Fee = lastOrder.Fee
+	}
+	if err := order.Insert(); err != nil {
+		if _, findErr := GetOrderByTradeNo(tradeNo); findErr == nil {
+			return nil
+		}
+		return err
+	}
+
+	now := utils.GetTimestamp()
+	if subscription.Status == SubscriptionStatusExpired {
+		// 宽限期后才收到扣款，重新开通
+		_, err := ActivateSubscription(order, gatewaySubscriptionId)
+		return err
+	}
+
+	subscription.Status = SubscriptionStatusActive
+	subscription.AutoRenew = true
+	subscription.TradeNo = order.TradeNo
+	subscription.PeriodStart = max(subscription.PeriodEnd, now)
+	subscription.PeriodEnd = subscription.PeriodStart + plan.periodSeconds()
+	subscription.UpdatedAt = now
+	err = DB.Model(subscription).Select("status", "auto_renew", "trade_no", "period_start", "period_end", "updated_at").Updates(subscription).Error
+	if err != nil {
+		return err
+	}
+
+	subscription.Plan = plan
+	clearUserSubscriptionCache(subscription.UserId)
+	grantSubscriptionQuota(subscription, "订阅套餐「%s」自动续费成功")
+	PublishWebhookEvent(WebhookEventSubscriptionRenewed, subscription)
+
+	return nil
+}
+
+// renewalTradeNo 根据网关扣款单号生成续费订单号
+func renewalTradeNo(gatewayId int, gatewayNo string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d:%s", gatewayId, gatewayNo)))
+	return "R" + hex.EncodeToString(sum[:])
+}
+
+// CancelSubscriptionByGateway 网关通知订阅已取消，当前周期结束后失效
+func CancelSubscriptionByGateway(gatewayId int, gatewaySubscriptionId string) error {
+	subscription := &UserSubscription{}
+	err := DB.Where("gateway_id = ? AND gateway_subscription_id = ? AND status <> ?", gatewayId, gatewaySubscriptionId, SubscriptionStatusExpired).First(subscription).Error
+	if err != nil {
+		return err
+	}
+
+	return subscription.CancelAutoRenew()
+}
+
+// CancelAutoRenew 关闭自动续费，网关侧的订阅需要调用方先取消
+func (subscription *UserSubscription) CancelAutoRenew() error {
+	err := DB.Model(subscription).Updates(map[string]interface{}{
+		"status":     SubscriptionStatusCanceled,
+		"auto_renew": false,
+		"updated_at": utils.GetTimestamp(),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	clearUserSubscriptionCache(subscription.UserId)
+	return nil
+}
+
+// ExpireSubscriptions 处理到期的订阅并恢复用户原来的分组，由主节点定时执行
+func ExpireSubscriptions() {
+	now := time.Now()
+	var subscriptions []*UserSubscription
+	err := DB.Where("status <> ? AND ((auto_renew = ? AND period_end <= ?) OR (auto_renew = ? AND period_end <= ?))",
+		SubscriptionStatusExpired, false, now.Unix(), true, now.Add(-subscriptionRenewGrace).Unix()).
+		Find(&subscriptions).Error
+	if err != nil {
+		logger.SysError("load expired subscriptions error: " + err.Error())
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		if err := expireSubscription(subscription); err != nil {
+			logger.SysError(fmt.Sprintf("expire subscription #%d error: %s", subscription.Id, err.Error()))
+		}
+	}
+}
+
+func expireSubscription(subscription *UserSubscription) error {
+	plan, err := GetSubscriptionPlanById(subscription.PlanId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(subscription).Updates(map[string]interface{}{
+			"status":     SubscriptionStatusExpired,
+			"auto_renew": false,
+			"updated_at": utils.GetTimestamp(),
+		}).Error; err != nil {
+			return err
+		}
+
+		// 只有分组仍是套餐分组时才降级，避免覆盖管理员的调整
+		if plan != nil && plan.Group != "" && subscription.PreviousGroup != "" && subscription.PreviousGroup != plan.Group {
+			return tx.Model(&User{}).Where("id = ? AND "+quotePostgresField("group")+" = ?", subscription.UserId, plan.Group).
+				Update("group", subscription.PreviousGroup).Error
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	clearUserSubscriptionCache(subscription.UserId)
+	RecordLog(subscription.UserId, LogTypeSystem, fmt.Sprintf("订阅套餐「%s」已到期", subscriptionPlanName(plan)))
+	PublishWebhookEvent(WebhookEventSubscriptionExpired, subscription)
+
+	return nil
+}
+
+func grantSubscriptionQuota(subscription *UserSubscription, content string) {
+	if subscription.Plan.Quota <= 0 {
+		return
+	}
+
+	if err := IncreaseUserQuota(subscription.UserId, subscription.Plan.Quota); err != nil {
+		logger.SysError(fmt.Sprintf("grant subscription #%d quota error: %s", subscription.Id, err.Error()))
+		return
+	}
+
+	RecordQuotaLog(subscription.UserId, LogTypeTopup, subscription.Plan.Quota, "", fmt.Sprintf(content+"，发放额度 %s", subscription.Plan.Name, common.LogQuota(subscription.Plan.Quota)))
+}
+
+func clearUserSubscriptionCache(userId int) {
+	cache.DeleteCache(fmt.Sprintf(userSubscriptionCacheKey, userId))
+	if config.RedisEnabled {
+		redis.RedisDel(fmt.Sprintf(UserGroupCacheKey, userId))
+	}
+}
+
+func subscriptionPlanName(plan *SubscriptionPlan) string {
+	if plan == nil {
+		return "已删除"
+	}
+	return plan.Name
+}
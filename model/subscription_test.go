@@ -0,0 +1,279 @@
+package model
+
+import (
+	"done-hub/common/cache"
+	"done-hub/common/config"
+	"done-hub/common/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDaySeconds = int64(86400)
+
+func setupSubscriptionTest(t *testing.T) func() []webhookPayload {
+	batchUpdateEnabled := config.BatchUpdateEnabled
+	config.BatchUpdateEnabled = false
+	t.Cleanup(func() {
+		config.BatchUpdateEnabled = batchUpdateEnabled
+	})
+
+	cache.InitCacheManager()
+	setupTestDB(t, &User{}, &Order{}, &Log{}, &SubscriptionPlan{}, &UserSubscription{}, &WebhookSubscription{}, &WebhookDelivery{})
+	_, received := setupWebhookReceiver(t, http.StatusOK)
+	return received
+}
+
+func createSubscriptionUser(t *testing.T, username string) *User {
+	user := &User{Username: username, AffCode: username, AccessToken: username, Group: "default", Status: config.UserStatusEnabled}
+	assert.Nil(t, DB.Create(user).Error)
+	return user
+}
+
+func createSubscriptionPlan(t *testing.T, name, group string) *SubscriptionPlan {
+	plan := &SubscriptionPlan{Name: name, Price: 10, PeriodDays: 30, Quota: 1000, Group: group, Enabled: true}
+	assert.Nil(t, plan.Insert())
+	return plan
+}
+
+func createPlanOrder(t *testing.T, user *User, plan *SubscriptionPlan, tradeNo string) *Order {
+	order := &Order{UserId: user.Id, GatewayId: 1, TradeNo: tradeNo, PlanId: plan.Id, OrderAmount: plan.Price, Status: OrderStatusSuccess}
+	assert.Nil(t, order.Insert())
+	return order
+}
+
+func getTestUser(t *testing.T, id int) *User {
+	user := &User{}
+	assert.Nil(t, DB.First(user, id).Error)
+	return user
+}
+
+func getTestSubscription(t *testing.T, id int) *UserSubscription {
+	subscription := &UserSubscription{}
+	assert.Nil(t, DB.First(subscription, id).Error)
+	return subscription
+}
+
+// 把订阅的周期结束时间移到 offset 秒之前
+func endTestSubscription(t *testing.T, subscription *UserSubscription, offset int64) {
+	periodEnd := utils.GetTimestamp() - offset
+	assert.Nil(t, DB.Model(subscription).Updates(map[string]any{"period_start": periodEnd - 30*testDaySeconds, "period_end": periodEnd}).Error)
+	subscription.PeriodEnd = periodEnd
+}
+
+// assertWebhookEvents 等待所有事件送达，避免异步投递读取到下一个测试的数据库
+func assertWebhookEvents(t *testing.T, received func() []webhookPayload, want map[string]int) {
+	total := 0
+	for _, count := range want {
+		total += count
+	}
+	assert.Eventually(t, func() bool { return len(received()) >= total }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	counts := make(map[string]int)
+	for _, payload := range received() {
+		counts[payload.Event]++
+	}
+	assert.Equal(t, want, counts)
+}
+
+func TestActivateSubscription(t *testing.T) {
+	received := setupSubscriptionTest(t)
+	user := createSubscriptionUser(t, "alice")
+	basic := createSubscriptionPlan(t, "basic", "vip")
+	pro := createSubscriptionPlan(t, "pro", "svip")
+
+	subscription, err := ActivateSubscription(createPlanOrder(t, user, basic, "T1"), "")
+	assert.Nil(t, err)
+	assert.Equal(t, SubscriptionStatusActive, subscription.Status)
+	assert.False(t, subscription.AutoRenew)
+	assert.Equal(t, "default", subscription.PreviousGroup)
+	assert.Equal(t, subscription.PeriodStart+30*testDaySeconds, subscription.PeriodEnd)
+	assert.Equal(t, "vip", getTestUser(t, user.Id).Group)
+	assert.Equal(t, 1000, getTestUser(t, user.Id).Quota)
+
+	// 同一套餐续期从当前周期结束时开始，再次发放额度
+	periodEnd := subscription.PeriodEnd
+	renewed, err := ActivateSubscription(createPlanOrder(t, user, basic, "T2"), "")
+	assert.Nil(t, err)
+	assert.Equal(t, subscription.Id, renewed.Id)
+	assert.Equal(t, periodEnd, renewed.PeriodStart)
+	assert.Equal(t, periodEnd+30*testDaySeconds, renewed.PeriodEnd)
+	assert.Equal(t, "T2", renewed.TradeNo)
+	assert.Equal(t, 2000, getTestUser(t, user.Id).Quota)
+
+	// 更换套餐时旧订阅立即结束，保留最初的分组
+	changed, err := ActivateSubscription(createPlanOrder(t, user, pro, "T3"), "sub_1")
+	assert.Nil(t, err)
+	assert.NotEqual(t, subscription.Id, changed.Id)
+	assert.True(t, changed.AutoRenew)
+	assert.Equal(t, "sub_1", changed.GatewaySubscriptionId)
+	assert.Equal(t, "default", changed.PreviousGroup)
+	assert.Equal(t, SubscriptionStatusExpired, getTestSubscription(t, subscription.Id).Status)
+	assert.Equal(t, "svip", getTestUser(t, user.Id).Group)
+
+	current, err := GetUserSubscription(user.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, changed.Id, current.Id)
+	assert.Equal(t, "pro", current.Plan.Name)
+
+	assertWebhookEvents(t, received, map[string]int{WebhookEventSubscriptionActivated: 3})
+}
+
+func TestRenewSubscriptionByGateway(t *testing.T) {
+	received := setupSubscriptionTest(t)
+	user := createSubscriptionUser(t, "alice")
+	plan := createSubscriptionPlan(t, "basic", "vip")
+
+	subscription, err := ActivateSubscription(createPlanOrder(t, user, plan, "T1"), "sub_1")
+	assert.Nil(t, err)
+	periodEnd := subscription.PeriodEnd
+
+	assert.Nil(t, RenewSubscriptionByGateway(1, "sub_1", "in_1"))
+	renewed := getTestSubscription(t, subscription.Id)
+	assert.Equal(t, periodEnd, renewed.PeriodStart)
+	assert.Equal(t, periodEnd+30*testDaySeconds, renewed.PeriodEnd)
+	assert.Equal(t, renewalTradeNo(1, "in_1"), renewed.TradeNo)
+	assert.Equal(t, 2000, getTestUser(t, user.Id).Quota)
+
+	// 续费订单沿用首次订阅的金额
+	order, err := GetOrderByTradeNo(renewed.TradeNo)
+	assert.Nil(t, err)
+	assert.Equal(t, "in_1", order.GatewayNo)
+	assert.Equal(t, 10.0, order.OrderAmount)
+
+	// 重复的扣款通知不会再次续期和发放额度
+	assert.Nil(t, RenewSubscriptionByGateway(1, "sub_1", "in_1"))
+	assert.Equal(t, renewed.PeriodEnd, getTestSubscription(t, subscription.Id).PeriodEnd)
+	assert.Equal(t, 2000, getTestUser(t, user.Id).Quota)
+
+	// 其他网关的同名订阅不会被续期
+	assert.NotNil(t, RenewSubscriptionByGateway(2, "sub_1", "in_2"))
+
+	assertWebhookEvents(t, received, map[string]int{
+		WebhookEventSubscriptionActivated: 1,
+		WebhookEventSubscriptionRenewed:   1,
+	})
+}
+
+func TestRenewSubscriptionAfterExpiry(t *testing.T) {
+	received := setupSubscriptionTest(t)
+	user := createSubscriptionUser(t, "alice")
+	plan := createSubscriptionPlan(t, "basic", "vip")
+
+	subscription, err := ActivateSubscription(createPlanOrder(t, user, plan, "T1"), "sub_1")
+	assert.Nil(t, err)
+
+	// 超过宽限期才收到扣款，订阅已到期并降级
+	endTestSubscription(t, subscription, int64(subscriptionRenewGrace.Seconds())+60)
+	ExpireSubscriptions()
+	assert.Equal(t, SubscriptionStatusExpired, getTestSubscription(t, subscription.Id).Status)
+	assert.Equal(t, "default", getTestUser(t, user.Id).Group)
+
+	// 重新开通新的订阅，周期从现在开始
+	assert.Nil(t, RenewSubscriptionByGateway(1, "sub_1", "in_1"))
+	current, err := GetUserSubscription(user.Id)
+	assert.Nil(t, err)
+	assert.NotEqual(t, subscription.Id, current.Id)
+	assert.Equal(t, SubscriptionStatusActive, current.Status)
+	assert.True(t, current.AutoRenew)
+	assert.Equal(t, "sub_1", current.GatewaySubscriptionId)
+	assert.Equal(t, "default", current.PreviousGroup)
+	assert.InDelta(t, utils.GetTimestamp()+30*testDaySeconds, current.PeriodEnd, 5)
+	assert.Equal(t, "vip", getTestUser(t, user.Id).Group)
+	assert.Equal(t, 2000, getTestUser(t, user.Id).Quota)
+
+	assertWebhookEvents(t, received, map[string]int{
+		WebhookEventSubscriptionActivated: 2,
+		WebhookEventSubscriptionExpired:   1,
+	})
+}
+
+func TestExpireSubscriptions(t *testing.T) {
+	received := setupSubscriptionTest(t)
+	plan := createSubscriptionPlan(t, "basic", "vip")
+	grace := int64(subscriptionRenewGrace.Seconds())
+
+	activate := func(username, gatewaySubscriptionId string) (*User, *UserSubscription) {
+		user := createSubscriptionUser(t, username)
+		subscription, err := ActivateSubscription(createPlanOrder(t, user, plan, "T-"+username), gatewaySubscriptionId)
+		assert.Nil(t, err)
+		return user, subscription
+	}
+
+	// 未开启自动续费的订阅到期后立即失效
+	manualUser, manual := activate("manual", "")
+	endTestSubscription(t, manual, 60)
+	// 自动续费的订阅在宽限期内等待扣款通知
+	graceUser, inGrace := activate("grace", "sub_grace")
+	endTestSubscription(t, inGrace, 60)
+	lateUser, late := activate("late", "sub_late")
+	endTestSubscription(t, late, grace+60)
+	// 管理员已调整的分组不会被覆盖
+	adminUser, adjusted := activate("admin", "")
+	endTestSubscription(t, adjusted, 60)
+	assert.Nil(t, DB.Model(adminUser).Update("group", "partner").Error)
+	activeUser, active := activate("active", "")
+
+	ExpireSubscriptions()
+
+	tests := []struct {
+		name         string
+		user         *User
+		subscription *UserSubscription
+		status       string
+		group        string
+	}{
+		{"manual", manualUser, manual, SubscriptionStatusExpired, "default"},
+		{"in grace", graceUser, inGrace, SubscriptionStatusActive, "vip"},
+		{"after grace", lateUser, late, SubscriptionStatusExpired, "default"},
+		{"admin group", adminUser, adjusted, SubscriptionStatusExpired, "partner"},
+		{"active", activeUser, active, SubscriptionStatusActive, "vip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscription := getTestSubscription(t, tt.subscription.Id)
+			assert.Equal(t, tt.status, subscription.Status)
+			if tt.status == SubscriptionStatusExpired {
+				assert.False(t, subscription.AutoRenew)
+			}
+			assert.Equal(t, tt.group, getTestUser(t, tt.user.Id).Group)
+		})
+	}
+
+	// 已到期的订阅不会重复处理
+	ExpireSubscriptions()
+	assertWebhookEvents(t, received, map[string]int{
+		WebhookEventSubscriptionActivated: 5,
+		WebhookEventSubscriptionExpired:   3,
+	})
+}
+
+func TestCancelSubscriptionByGateway(t *testing.T) {
+	received := setupSubscriptionTest(t)
+	user := createSubscriptionUser(t, "alice")
+	plan := createSubscriptionPlan(t, "basic", "vip")
+
+	subscription, err := ActivateSubscription(createPlanOrder(t, user, plan, "T1"), "sub_1")
+	assert.Nil(t, err)
+
+	// 取消后当前周期仍然有效，到期后直接失效
+	assert.Nil(t, CancelSubscriptionByGateway(1, "sub_1"))
+	canceled := getTestSubscription(t, subscription.Id)
+	assert.Equal(t, SubscriptionStatusCanceled, canceled.Status)
+	assert.False(t, canceled.AutoRenew)
+
+	endTestSubscription(t, canceled, 60)
+	ExpireSubscriptions()
+	assert.Equal(t, SubscriptionStatusExpired, getTestSubscription(t, subscription.Id).Status)
+	assert.Equal(t, "default", getTestUser(t, user.Id).Group)
+	assert.NotNil(t, CancelSubscriptionByGateway(1, "sub_1"))
+
+	assertWebhookEvents(t, received, map[string]int{
+		WebhookEventSubscriptionActivated: 1,
+		WebhookEventSubscriptionExpired:   1,
+	})
+}
@@ -63,6 +63,10 @@ func (token *Token) AfterCreate(tx *gorm.DB) (err error) {
 
 type TokenSetting struct {
 	Heartbeat HeartbeatSetting `json:"heartbeat,omitempty"`
+	// 模型降级链，键为请求的模型，空列表表示不使用系统设置的降级链
+	Fallback map[string][]string `json:"fallback,omitempty"`
+	// 请求处理插件，在分组的插件之后执行
+	Plugins PluginPipeline `json:"plugins,omitempty"`
 }
 
 type HeartbeatSetting struct {
@@ -209,6 +213,16 @@ func GetTokenByKey(key string) (*Token, error) {
 
 func (token *Token) Insert() error {
 	err := DB.Create(token).Error
+	if err == nil {
+		PublishWebhookEvent(WebhookEventTokenCreated, map[string]any{
+			"id":              token.Id,
+			"user_id":         token.UserId,
+			"name":            token.Name,
+			"expired_time":    token.ExpiredTime,
+			"remain_quota":    token.RemainQuota,
+			"unlimited_quota": token.UnlimitedQuota,
+		})
+	}
 	return err
 }
 
@@ -320,6 +334,7 @@ func PreConsumeTokenQuota(tokenId int, quota int) (err error) {
 	if quotaTooLow || noMoreQuota {
 		go sendQuotaWarningEmail(token.UserId, userQuota, noMoreQuota)
 	}
+	publishQuotaExhausted(token.UserId, tokenId, userQuota, userQuota-quota)
 	if !token.UnlimitedQuota {
 		err = DecreaseTokenQuota(tokenId, quota)
 		if err != nil {
@@ -330,6 +345,19 @@ func PreConsumeTokenQuota(tokenId int, quota int) (err error) {
 	return err
 }
 
+// publishQuotaExhausted 用户额度由正数变为不大于 0 时发送事件，额度用尽后的扣费不再重复发送
+func publishQuotaExhausted(userId, tokenId, before, after int) {
+	if before <= 0 || after > 0 {
+		return
+	}
+
+	PublishWebhookEvent(WebhookEventQuotaExhausted, map[string]any{
+		"user_id":  userId,
+		"token_id": tokenId,
+		"quota":    after,
+	})
+}
+
 func sendQuotaWarningEmail(userId int, userQuota int, noMoreQuota bool) {
 	user := User{Id: userId}
 
@@ -364,7 +392,16 @@ func PostConsumeTokenQuota(tokenId int, quota int) (err error) {
 		return err
 	}
 	if quota > 0 {
+		var userQuota int
+		userQuota, err = GetUserQuota(token.UserId)
+		if err != nil {
+			return err
+		}
 		err = DecreaseUserQuota(token.UserId, quota)
+		if err == nil {
+			// 最终扣费也可能用尽额度
+			publishQuotaExhausted(token.UserId, tokenId, userQuota, userQuota-quota)
+		}
 	} else {
 		err = IncreaseUserQuota(token.UserId, -quota)
 	}
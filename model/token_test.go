@@ -0,0 +1,58 @@
+package model
+
+import (
+	"done-hub/common/config"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestQuotaExhaustedEvent(t *testing.T) {
+	logConsumeEnabled := config.LogConsumeEnabled
+	batchUpdateEnabled := config.BatchUpdateEnabled
+	config.LogConsumeEnabled = false
+	config.BatchUpdateEnabled = false
+	t.Cleanup(func() {
+		config.LogConsumeEnabled = logConsumeEnabled
+		config.BatchUpdateEnabled = batchUpdateEnabled
+	})
+
+	setupTestDB(t, &User{}, &Token{}, &WebhookSubscription{}, &WebhookDelivery{})
+	_, received := setupWebhookReceiver(t, http.StatusOK)
+
+	user := &User{Username: "alice", Quota: 100, Status: config.UserStatusEnabled}
+	assert.Nil(t, DB.Create(user).Error)
+	token := &Token{UserId: user.Id, Key: "test", Name: "test", UnlimitedQuota: true}
+	assert.Nil(t, DB.Session(&gorm.Session{SkipHooks: true}).Create(token).Error)
+
+	// 预扣费没有用尽额度，最终扣费用尽时发送事件
+	assert.Nil(t, PreConsumeTokenQuota(token.Id, 20))
+	assert.Nil(t, PostConsumeTokenQuota(token.Id, 40))
+	assert.Nil(t, PostConsumeTokenQuota(token.Id, 50))
+	// 已经用尽后继续扣费不再发送
+	assert.Nil(t, PostConsumeTokenQuota(token.Id, 10))
+	assert.Eventually(t, func() bool { return len(received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// 充值后再次用尽时重新发送
+	assert.Nil(t, PostConsumeTokenQuota(token.Id, -30))
+	assert.Nil(t, PreConsumeTokenQuota(token.Id, 10))
+	assert.Eventually(t, func() bool { return len(received()) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	quota, err := GetUserQuota(user.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, quota)
+
+	time.Sleep(100 * time.Millisecond)
+	events := received()
+	assert.Len(t, events, 2)
+	for i, want := range []float64{-10, 0} {
+		assert.Equal(t, WebhookEventQuotaExhausted, events[i].Event)
+		data := events[i].Data.(map[string]any)
+		assert.Equal(t, float64(user.Id), data["user_id"])
+		assert.Equal(t, float64(token.Id), data["token_id"])
+		assert.Equal(t, want, data["quota"])
+	}
+}
@@ -42,6 +42,10 @@ type User struct {
 	AffHistoryQuota  int            `json:"aff_history_quota" gorm:"type:int;default:0;column:aff_history"`
 	InviterId        int            `json:"inviter_id" gorm:"type:int;column:inviter_id;index"`
 	LastLoginTime    int64          `json:"last_login_time" gorm:"bigint;default:0"`
+	TotpSecret       string         `json:"-" gorm:"type:varchar(64);column:totp_secret;default:''"`
+	TotpEnabled      bool           `json:"-" gorm:"column:totp_enabled;default:false"`
+	RecoveryCodes    string         `json:"-" gorm:"type:text;column:recovery_codes"`           // 恢复码哈希，逗号分隔
+	TotpLastCounter  int64          `json:"-" gorm:"bigint;column:totp_last_counter;default:0"` // 最后一次通过验证的 TOTP 周期
 	CreatedTime      int64          `json:"created_time" gorm:"bigint"`
 	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
 }
@@ -147,6 +151,14 @@ func (user *User) Insert(inviterId int) error {
 	if result.Error != nil {
 		return result.Error
 	}
+	PublishWebhookEvent(WebhookEventUserRegistered, map[string]any{
+		"id":           user.Id,
+		"username":     user.Username,
+		"display_name": user.DisplayName,
+		"email":        user.Email,
+		"inviter_id":   inviterId,
+		"created_time": user.CreatedTime,
+	})
 	if config.QuotaForNewUser > 0 {
 		RecordLog(user.Id, LogTypeSystem, fmt.Sprintf("新用户注册赠送 %s", common.LogQuota(config.QuotaForNewUser)))
 	}
@@ -166,7 +178,8 @@ func (user *User) Insert(inviterId int) error {
 
 func (user *User) Update(updatePassword bool) error {
 	var err error
-	omitFields := []string{"quota", "used_quota", "request_count", "aff_count", "aff_quota", "aff_history"}
+	// totp_last_counter 只通过条件更新修改，避免旧的用户数据覆盖
+	omitFields := []string{"quota", "used_quota", "request_count", "aff_count", "aff_quota", "aff_history", "totp_last_counter"}
 
 	if updatePassword {
 		user.Password, err = common.Password2Hash(user.Password)
@@ -0,0 +1,235 @@
+package model
+
+import (
+	"context"
+	"done-hub/common"
+	"done-hub/common/cache"
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/common/notify"
+	"done-hub/common/notify/channel"
+	"done-hub/common/redis"
+	"done-hub/common/utils"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+const (
+	UserAlertBalance     = "balance"
+	UserAlertTokenQuota  = "token_quota"
+	UserAlertTokenExpire = "token_expire"
+	UserAlertDailySpend  = "daily_spend"
+)
+
+var (
+	userAlertSettingCacheKey = "user_alert_setting:%d"
+	userAlertSentKey         = "user_alert_sent:%s:%d:%s"
+)
+
+// 用户自定义的提醒，阈值为 0 表示不提醒
+type UserAlertSetting struct {
+	UserId              int    `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	BalanceThreshold    int    `json:"balance_threshold" gorm:"default:0"`     // 余额低于该额度
+	TokenQuotaThreshold int    `json:"token_quota_threshold" gorm:"default:0"` // 令牌剩余额度低于该额度
+	TokenExpireDays     int    `json:"token_expire_days" gorm:"default:0"`     // 令牌在 N 天内过期
+	DailySpendThreshold int    `json:"daily_spend_threshold" gorm:"default:0"` // 当日消费超过该额度
+	NotifyEmail         bool   `json:"notify_email" gorm:"default:false"`
+	NotifyTelegram      bool   `json:"notify_telegram" gorm:"default:false"`
+	WebhookUrl          string `json:"webhook_url" gorm:"type:varchar(512);default:''"`
+	UpdatedAt           int64  `json:"updated_at" gorm:"bigint"`
+}
+
+func GetUserAlertSetting(userId int) (*UserAlertSetting, error) {
+	setting := &UserAlertSetting{}
+	err := DB.Where("user_id = ?", userId).First(setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &UserAlertSetting{UserId: userId}, nil
+	}
+	return setting, err
+}
+
+func CacheGetUserAlertSetting(userId int) (*UserAlertSetting, error) {
+	return cache.GetOrSetCache(
+		fmt.Sprintf(userAlertSettingCacheKey, userId),
+		10*time.Minute,
+		func() (*UserAlertSetting, error) {
+			return GetUserAlertSetting(userId)
+		},
+		cache.CacheTimeout)
+}
+
+func (setting *UserAlertSetting) Save() error {
+	setting.UpdatedAt = utils.GetTimestamp()
+	if err := DB.Save(setting).Error; err != nil {
+		return err
+	}
+
+	cache.DeleteCache(fmt.Sprintf(userAlertSettingCacheKey, setting.UserId))
+	return nil
+}
+
+func (setting *UserAlertSetting) hasReceiver() bool {
+	return setting.NotifyEmail || setting.NotifyTelegram || setting.WebhookUrl != ""
+}
+
+// CheckUserConsumeAlerts 消费后检查余额和令牌额度提醒
+func CheckUserConsumeAlerts(userId, tokenId int) {
+	setting, err := CacheGetUserAlertSetting(userId)
+	if err != nil || !setting.hasReceiver() {
+		return
+	}
+
+	if setting.BalanceThreshold > 0 {
+		quota, err := CacheGetUserQuota(userId)
+		if err == nil && quota < setting.BalanceThreshold && markUserAlertSent(UserAlertBalance, userId, "", 24*time.Hour) {
+			sendUserAlert(setting, "余额不足提醒", fmt.Sprintf("您的账户余额为 %s，已低于设置的提醒额度 %s，请及时充值。", common.LogQuota(quota), common.LogQuota(setting.BalanceThreshold)))
+		}
+	}
+
+	if setting.TokenQuotaThreshold > 0 && tokenId > 0 {
+		token, err := GetTokenById(tokenId)
+		if err == nil && !token.UnlimitedQuota && token.RemainQuota < setting.TokenQuotaThreshold &&
+			markUserAlertSent(UserAlertTokenQuota, userId, strconv.Itoa(tokenId), 24*time.Hour) {
+			sendUserAlert(setting, "令牌额度提醒", fmt.Sprintf("您的令牌「%s」剩余额度为 %s，已低于设置的提醒额度 %s。", token.Name, common.LogQuota(token.RemainQuota), common.LogQuota(setting.TokenQuotaThreshold)))
+		}
+	}
+}
+
+// CheckUserScheduledAlerts 定时检查令牌过期和当日消费提醒
+func CheckUserScheduledAlerts() {
+	var settings []*UserAlertSetting
+	err := DB.Where("token_expire_days > 0 OR daily_spend_threshold > 0").Find(&settings).Error
+	if err != nil {
+		logger.SysError("load user alert settings error: " + err.Error())
+		return
+	}
+
+	now := time.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Unix()
+	for _, setting := range settings {
+		if !setting.hasReceiver() {
+			continue
+		}
+
+		if setting.TokenExpireDays > 0 {
+			checkTokenExpireAlert(setting, now.Unix())
+		}
+
+		if setting.DailySpendThreshold > 0 {
+			checkDailySpendAlert(setting, todayStart, now.Format("2006-01-02"))
+		}
+	}
+}
+
+func checkTokenExpireAlert(setting *UserAlertSetting, now int64) {
+	var tokens []*Token
+	err := DB.Select("id", "name", "expired_time").
+		Where("user_id = ? AND status = ? AND expired_time > ? AND expired_time <= ?", setting.UserId, config.TokenStatusEnabled, now, now+int64(setting.TokenExpireDays)*86400).
+		Find(&tokens).Error
+	if err != nil {
+		logger.SysError("load expiring tokens error: " + err.Error())
+		return
+	}
+
+	ttl := time.Duration(setting.TokenExpireDays+1) * 24 * time.Hour
+	for _, token := range tokens {
+		// 过期时间修改后重新提醒
+		entity := fmt.Sprintf("%d:%d", token.Id, token.ExpiredTime)
+		if !markUserAlertSent(UserAlertTokenExpire, setting.UserId, entity, ttl) {
+			continue
+		}
+		expiredAt := time.Unix(token.ExpiredTime, 0).Format("2006-01-02 15:04:05")
+		sendUserAlert(setting, "令牌即将过期", fmt.Sprintf("您的令牌「%s」将于 %s 过期。", token.Name, expiredAt))
+	}
+}
+
+func checkDailySpendAlert(setting *UserAlertSetting, todayStart int64, today string) {
+	var quota int64
+	err := DB.Table("logs").
+		Select("COALESCE(SUM(quota), 0)").
+		Where("user_id = ? AND type = ? AND created_at >= ?", setting.UserId, LogTypeConsume, todayStart).
+		Scan(&quota).Error
+	if err != nil {
+		logger.SysError("sum daily spend error: " + err.Error())
+		return
+	}
+
+	if quota <= int64(setting.DailySpendThreshold) || !markUserAlertSent(UserAlertDailySpend, setting.UserId, today, 48*time.Hour) {
+		return
+	}
+
+	sendUserAlert(setting, "当日消费提醒", fmt.Sprintf("您今天已消费 %s，超过了设置的提醒额度 %s。", common.LogQuota(int(quota)), common.LogQuota(setting.DailySpendThreshold)))
+}
+
+var (
+	userAlertSentMutex sync.Mutex
+	userAlertSent      = make(map[string]time.Time)
+)
+
+// markUserAlertSent 在有效期内同一提醒只发送一次，返回 false 表示已经发送过
+func markUserAlertSent(alertType string, userId int, entity string, ttl time.Duration) bool {
+	key := fmt.Sprintf(userAlertSentKey, alertType, userId, entity)
+	if config.RedisEnabled {
+		ok, err := redis.GetRedisClient().SetNX(context.Background(), key, 1, ttl).Result()
+		if err != nil {
+			logger.SysError("set user alert key error: " + err.Error())
+			return false
+		}
+		return ok
+	}
+
+	userAlertSentMutex.Lock()
+	defer userAlertSentMutex.Unlock()
+
+	now := time.Now()
+	if expiredAt, ok := userAlertSent[key]; ok && expiredAt.After(now) {
+		return false
+	}
+
+	for k, expiredAt := range userAlertSent {
+		if expiredAt.Before(now) {
+			delete(userAlertSent, k)
+		}
+	}
+	userAlertSent[key] = now.Add(ttl)
+
+	return true
+}
+
+func sendUserAlert(setting *UserAlertSetting, title, message string) {
+	user, err := GetUserById(setting.UserId, false)
+	if err != nil {
+		logger.SysError("get user error: " + err.Error())
+		return
+	}
+
+	notifiers := make([]notify.Notifier, 0)
+
+	if setting.NotifyEmail && user.Email != "" {
+		notifiers = append(notifiers, channel.NewEmail(user.Email))
+	}
+
+	botToken := viper.GetString("tg.bot_api_key")
+	if setting.NotifyTelegram && user.TelegramId != 0 && botToken != "" {
+		notifiers = append(notifiers, channel.NewTelegram(botToken, strconv.FormatInt(user.TelegramId, 10), viper.GetString("tg.http_proxy")))
+	}
+
+	if setting.WebhookUrl != "" {
+		notifiers = append(notifiers, channel.NewWebhook(setting.WebhookUrl))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, notifier := range notifiers {
+		if err := notifier.Send(ctx, title, message); err != nil {
+			logger.SysError(fmt.Sprintf("send user #%d alert by %s error: %s", user.Id, notifier.Name(), err.Error()))
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"done-hub/common/redis"
 	"fmt"
 	"sync"
+
+	"gorm.io/datatypes"
 )
 
 type UserGroup struct {
@@ -20,6 +22,8 @@ type UserGroup struct {
 	Min       int     `json:"min" form:"min" gorm:"default:0"`                 // 晋级条件最小值
 	Max       int     `json:"max" form:"max" gorm:"default:0"`                 // 晋级条件最大值
 	Enable    *bool   `json:"enable" form:"enable" gorm:"default:true"`        // 是否启用
+
+	PluginPipeline *datatypes.JSONType[PluginPipeline] `json:"plugin_pipeline,omitempty" gorm:"type:json"` // 请求处理插件
 }
 
 type SearchUserGroupParams struct {
@@ -75,7 +79,7 @@ func (c *UserGroup) Create() error {
 }
 
 func (c *UserGroup) Update() error {
-	err := DB.Select("name", "ratio", "public", "api_rate", "promotion", "min", "max").Updates(c).Error
+	err := DB.Select("name", "ratio", "public", "api_rate", "promotion", "min", "max", "plugin_pipeline").Updates(c).Error
 	if err == nil {
 		GlobalUserGroupRatio.Load()
 	}
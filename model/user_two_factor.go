@@ -0,0 +1,206 @@
+package model
+
+import (
+	"done-hub/common"
+	"done-hub/common/utils"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"gorm.io/datatypes"
+)
+
+// 用户的 WebAuthn 通行密钥
+type UserPasskey struct {
+	Id           int                                     `json:"id"`
+	UserId       int                                     `json:"user_id" gorm:"index"`
+	Name         string                                  `json:"name" gorm:"type:varchar(64);default:''"`
+	CredentialId string                                  `json:"-" gorm:"type:varchar(255);uniqueIndex"`
+	Credential   datatypes.JSONType[webauthn.Credential] `json:"-" gorm:"type:json"`
+	LastUsedAt   int64                                   `json:"last_used_at" gorm:"bigint;default:0"`
+	CreatedAt    int64                                   `json:"created_at" gorm:"bigint"`
+}
+
+type UserTwoFactorStatus struct {
+	TotpEnabled   bool `json:"totp_enabled"`
+	RecoveryCodes int  `json:"recovery_codes"`
+	Passkeys      int  `json:"passkeys"`
+}
+
+// 实现 webauthn.User 接口
+type WebAuthnUser struct {
+	User     *User
+	Passkeys []*UserPasskey
+}
+
+func (u *WebAuthnUser) WebAuthnID() []byte {
+	return []byte(strconv.Itoa(u.User.Id))
+}
+
+func (u *WebAuthnUser) WebAuthnName() string {
+	return u.User.Username
+}
+
+func (u *WebAuthnUser) WebAuthnDisplayName() string {
+	if u.User.DisplayName != "" {
+		return u.User.DisplayName
+	}
+	return u.User.Username
+}
+
+func (u *WebAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.Passkeys))
+	for _, passkey := range u.Passkeys {
+		credentials = append(credentials, passkey.Credential.Data())
+	}
+	return credentials
+}
+
+func GetWebAuthnUser(userId int) (*WebAuthnUser, error) {
+	user, err := GetUserById(userId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys, err := GetUserPasskeys(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebAuthnUser{User: user, Passkeys: passkeys}, nil
+}
+
+// HasTwoFactor 用户是否开启了任意一种两步验证
+func (user *User) HasTwoFactor() bool {
+	if user.TotpEnabled {
+		return true
+	}
+
+	return CountUserPasskeys(user.Id) > 0
+}
+
+func (user *User) GetTwoFactorStatus() *UserTwoFactorStatus {
+	return &UserTwoFactorStatus{
+		TotpEnabled:   user.TotpEnabled,
+		RecoveryCodes: len(user.recoveryCodes()),
+		Passkeys:      int(CountUserPasskeys(user.Id)),
+	}
+}
+
+func (user *User) recoveryCodes() []string {
+	if user.RecoveryCodes == "" {
+		return nil
+	}
+	return strings.Split(user.RecoveryCodes, ",")
+}
+
+// ValidateTwoFactorCode 校验 TOTP 验证码或恢复码，恢复码使用后立即作废
+func (user *User) ValidateTwoFactorCode(code string) bool {
+	if user.TotpEnabled {
+		if counter, ok := common.ValidateTotpCode(user.TotpSecret, code, user.TotpLastCounter); ok {
+			// 条件更新，同一个验证码并发使用时只有一次成功
+			result := DB.Model(&User{}).Where("id = ? AND totp_last_counter < ?", user.Id, counter).Update("totp_last_counter", counter)
+			if result.Error != nil || result.RowsAffected != 1 {
+				return false
+			}
+			user.TotpLastCounter = counter
+			return true
+		}
+	}
+
+	hash := common.HashRecoveryCode(code)
+	codes := user.recoveryCodes()
+	for i, recoveryCode := range codes {
+		if recoveryCode != hash {
+			continue
+		}
+
+		original := user.RecoveryCodes
+		user.RecoveryCodes = strings.Join(append(codes[:i], codes[i+1:]...), ",")
+		// 条件更新，避免同一个恢复码被并发使用两次
+		result := DB.Model(&User{}).Where("id = ? AND recovery_codes = ?", user.Id, original).Update("recovery_codes", user.RecoveryCodes)
+		return result.Error == nil && result.RowsAffected == 1
+	}
+
+	return false
+}
+
+// EnableUserTotp 开启 TOTP，counter 为开启时使用的验证码周期，之后不能再次使用
+func EnableUserTotp(userId int, secret string, counter int64, recoveryCodes []string) error {
+	return UpdateUser(userId, map[string]interface{}{
+		"totp_secret":       secret,
+		"totp_enabled":      true,
+		"totp_last_counter": counter,
+		"recovery_codes":    strings.Join(recoveryCodes, ","),
+	})
+}
+
+func DisableUserTotp(userId int) error {
+	return UpdateUser(userId, map[string]interface{}{
+		"totp_secret":       "",
+		"totp_enabled":      false,
+		"totp_last_counter": 0,
+		"recovery_codes":    "",
+	})
+}
+
+func UpdateUserRecoveryCodes(userId int, recoveryCodes []string) error {
+	return UpdateUser(userId, map[string]interface{}{
+		"recovery_codes": strings.Join(recoveryCodes, ","),
+	})
+}
+
+// ResetUserTwoFactor 管理员重置用户的全部两步验证
+func ResetUserTwoFactor(userId int) error {
+	err := UpdateUser(userId, map[string]interface{}{
+		"totp_secret":       "",
+		"totp_enabled":      false,
+		"totp_last_counter": 0,
+		"recovery_codes":    "",
+	})
+	if err != nil {
+		return err
+	}
+
+	return DB.Where("user_id = ?", userId).Delete(&UserPasskey{}).Error
+}
+
+func GetUserPasskeys(userId int) ([]*UserPasskey, error) {
+	var passkeys []*UserPasskey
+	err := DB.Where("user_id = ?", userId).Order("id ASC").Find(&passkeys).Error
+	return passkeys, err
+}
+
+func CountUserPasskeys(userId int) int64 {
+	var count int64
+	DB.Model(&UserPasskey{}).Where("user_id = ?", userId).Count(&count)
+	return count
+}
+
+func InsertUserPasskey(userId int, name string, credential *webauthn.Credential) (*UserPasskey, error) {
+	passkey := &UserPasskey{
+		UserId:       userId,
+		Name:         name,
+		CredentialId: base64.RawURLEncoding.EncodeToString(credential.ID),
+		Credential:   datatypes.NewJSONType(*credential),
+		CreatedAt:    utils.GetTimestamp(),
+	}
+
+	err := DB.Create(passkey).Error
+	return passkey, err
+}
+
+// UpdateUserPasskeyCredential 登录成功后更新签名计数等信息
+func UpdateUserPasskeyCredential(userId int, credential *webauthn.Credential) error {
+	return DB.Model(&UserPasskey{}).
+		Where("user_id = ? AND credential_id = ?", userId, base64.RawURLEncoding.EncodeToString(credential.ID)).
+		Updates(map[string]interface{}{
+			"credential":   datatypes.NewJSONType(*credential),
+			"last_used_at": utils.GetTimestamp(),
+		}).Error
+}
+
+func DeleteUserPasskey(id, userId int) error {
+	return DB.Where("id = ? AND user_id = ?", id, userId).Delete(&UserPasskey{}).Error
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"done-hub/common"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTwoFactorUser(t *testing.T) (*User, string, []string) {
+	setupTestDB(t, &User{}, &UserPasskey{})
+
+	user := &User{Username: "alice", Password: "password", Status: 1}
+	assert.Nil(t, DB.Create(user).Error)
+
+	secret, err := common.GenerateTotpSecret()
+	assert.Nil(t, err)
+	codes, hashes := common.GenerateRecoveryCodes(2)
+	assert.Nil(t, EnableUserTotp(user.Id, secret, 0, hashes))
+
+	user, err = GetUserById(user.Id, false)
+	assert.Nil(t, err)
+	return user, secret, codes
+}
+
+func TestValidateTwoFactorCodeTotp(t *testing.T) {
+	user, secret, _ := setupTwoFactorUser(t)
+	assert.True(t, user.HasTwoFactor())
+
+	code, err := common.GenerateTotpCode(secret, time.Now())
+	assert.Nil(t, err)
+	assert.True(t, user.ValidateTwoFactorCode(code))
+
+	// 同一个验证码不能再次使用，重新读取的用户也一样
+	assert.False(t, user.ValidateTwoFactorCode(code))
+	reloaded, err := GetUserById(user.Id, false)
+	assert.Nil(t, err)
+	assert.Equal(t, user.TotpLastCounter, reloaded.TotpLastCounter)
+	assert.False(t, reloaded.ValidateTwoFactorCode(code))
+
+	// 之前周期的验证码也不再接受，之后周期的验证码可以使用
+	previous, _ := common.GenerateTotpCode(secret, time.Now().Add(-30*time.Second))
+	assert.False(t, reloaded.ValidateTwoFactorCode(previous))
+	next, _ := common.GenerateTotpCode(secret, time.Now().Add(30*time.Second))
+	if next != code {
+		assert.True(t, reloaded.ValidateTwoFactorCode(next))
+	}
+
+	// 更新用户资料不会覆盖最后使用的周期
+	stale := *user
+	stale.TotpLastCounter = 0
+	stale.DisplayName = "Alice"
+	assert.Nil(t, stale.Update(false))
+	reloaded, _ = GetUserById(user.Id, false)
+	assert.NotZero(t, reloaded.TotpLastCounter)
+}
+
+func TestValidateTwoFactorCodeConcurrent(t *testing.T) {
+	user, secret, _ := setupTwoFactorUser(t)
+	code, _ := common.GenerateTotpCode(secret, time.Now())
+
+	// 多个请求同时使用同一个验证码，只有一个成功
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	passed := 0
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			copied := *user
+			if copied.ValidateTwoFactorCode(code) {
+				mu.Lock()
+				passed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, passed)
+}
+
+func TestValidateTwoFactorCodeRecovery(t *testing.T) {
+	user, _, codes := setupTwoFactorUser(t)
+	assert.Equal(t, 2, user.GetTwoFactorStatus().RecoveryCodes)
+
+	// 恢复码忽略大小写和连字符，使用后立即作废
+	assert.True(t, user.ValidateTwoFactorCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))))
+	assert.False(t, user.ValidateTwoFactorCode(codes[0]))
+	assert.Equal(t, 1, user.GetTwoFactorStatus().RecoveryCodes)
+
+	// 使用旧的用户数据时同一个恢复码也不能再次使用
+	stale, err := GetUserById(user.Id, false)
+	assert.Nil(t, err)
+	stale.RecoveryCodes = user.RecoveryCodes + "," + common.HashRecoveryCode(codes[0])
+	assert.False(t, stale.ValidateTwoFactorCode(codes[0]))
+
+	reloaded, _ := GetUserById(user.Id, false)
+	assert.True(t, reloaded.ValidateTwoFactorCode(codes[1]))
+	assert.Equal(t, 0, reloaded.GetTwoFactorStatus().RecoveryCodes)
+	assert.False(t, reloaded.ValidateTwoFactorCode("wrong-code"))
+}
+
+func TestDisableUserTotp(t *testing.T) {
+	user, secret, codes := setupTwoFactorUser(t)
+	code, _ := common.GenerateTotpCode(secret, time.Now())
+	assert.True(t, user.ValidateTwoFactorCode(code))
+
+	assert.Nil(t, DisableUserTotp(user.Id))
+	user, _ = GetUserById(user.Id, false)
+	assert.False(t, user.HasTwoFactor())
+	assert.Zero(t, user.TotpLastCounter)
+	assert.False(t, user.ValidateTwoFactorCode(code))
+	assert.False(t, user.ValidateTwoFactorCode(codes[1]))
+}
@@ -0,0 +1,372 @@
+package model
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"done-hub/common/logger"
+	"done-hub/common/utils"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 对外推送的事件类型
+const (
+	WebhookEventPing            = "ping"
+	WebhookEventUserRegistered  = "user.registered"
+	WebhookEventOrderPaid       = "order.paid"
+	WebhookEventRedemptionUsed  = "redemption.used"
+	WebhookEventChannelDisabled = "channel.disabled"
+	WebhookEventChannelEnabled  = "channel.enabled"
+	WebhookEventTokenCreated    = "token.created"
+	WebhookEventQuotaExhausted  = "quota.exhausted"
+
+	WebhookEventSubscriptionActivated = "subscription.activated"
+	WebhookEventSubscriptionRenewed   = "subscription.renewed"
+	WebhookEventSubscriptionExpired   = "subscription.expired"
+)
+
+var WebhookEvents = []string{
+	WebhookEventUserRegistered,
+	WebhookEventOrderPaid,
+	WebhookEventRedemptionUsed,
+	WebhookEventChannelDisabled,
+	WebhookEventChannelEnabled,
+	WebhookEventTokenCreated,
+	WebhookEventQuotaExhausted,
+	WebhookEventSubscriptionActivated,
+	WebhookEventSubscriptionRenewed,
+	WebhookEventSubscriptionExpired,
+}
+
+const (
+	WebhookDeliveryPending = "pending"
+	WebhookDeliverySuccess = "success"
+	WebhookDeliveryFailed  = "failed"
+)
+
+const (
+	webhookMaxAttempts   = 8
+	webhookRetryInterval = 30 * time.Second
+	webhookTimeout       = 10 * time.Second
+)
+
+var webhookHTTPClient = &http.Client{Timeout: webhookTimeout}
+
+// 事件订阅，Events 为逗号分隔的事件类型，* 表示全部
+type WebhookSubscription struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name" gorm:"type:varchar(64);default:''"`
+	Url       string `json:"url" gorm:"type:varchar(512)"`
+	Secret    string `json:"secret" gorm:"type:text;serializer:secret"`
+	Events    string `json:"events" gorm:"type:varchar(512);default:'*'"`
+	Enabled   bool   `json:"enabled" gorm:"default:true"`
+	CreatedAt int64  `json:"created_at" gorm:"bigint"`
+	UpdatedAt int64  `json:"updated_at" gorm:"bigint"`
+}
+
+// 每次投递的记录，用于重试和重放
+type WebhookDelivery struct {
+	Id             int    `json:"id"`
+	SubscriptionId int    `json:"subscription_id" gorm:"index"`
+	EventId        string `json:"event_id" gorm:"type:varchar(64);index"`
+	Event          string `json:"event" gorm:"type:varchar(64);index"`
+	Payload        string `json:"payload" gorm:"type:text"`
+	Status         string `json:"status" gorm:"type:varchar(16);index"`
+	Attempts       int    `json:"attempts" gorm:"default:0"`
+	ResponseCode   int    `json:"response_code" gorm:"default:0"`
+	ResponseBody   string `json:"response_body" gorm:"type:text"`
+	Error          string `json:"error" gorm:"type:text"`
+	NextRetryAt    int64  `json:"next_retry_at" gorm:"bigint;index"`
+	CreatedAt      int64  `json:"created_at" gorm:"bigint;index"`
+	UpdatedAt      int64  `json:"updated_at" gorm:"bigint"`
+}
+
+type webhookPayload struct {
+	Id        string `json:"id"`
+	Event     string `json:"event"`
+	CreatedAt int64  `json:"created_at"`
+	Data      any    `json:"data"`
+}
+
+type SearchWebhookDeliveriesParams struct {
+	SubscriptionId int    `form:"subscription_id"`
+	Event          string `form:"event"`
+	Status         string `form:"status"`
+	PaginationParams
+}
+
+var allowedWebhookSubscriptionOrderFields = map[string]bool{
+	"id":         true,
+	"name":       true,
+	"created_at": true,
+}
+
+var allowedWebhookDeliveryOrderFields = map[string]bool{
+	"id":         true,
+	"event":      true,
+	"status":     true,
+	"created_at": true,
+}
+
+func GetWebhookSubscriptionsList(params *PaginationParams) (*DataResult[WebhookSubscription], error) {
+	var subscriptions []*WebhookSubscription
+	return PaginateAndOrder(DB, params, &subscriptions, allowedWebhookSubscriptionOrderFields)
+}
+
+func GetWebhookSubscriptionById(id int) (*WebhookSubscription, error) {
+	subscription := &WebhookSubscription{}
+	err := DB.Where("id = ?", id).First(subscription).Error
+	return subscription, err
+}
+
+func (subscription *WebhookSubscription) Insert() error {
+	if subscription.Secret == "" {
+		subscription.Secret = "whsec_" + utils.GetRandomString(32)
+	}
+	subscription.CreatedAt = utils.GetTimestamp()
+	subscription.UpdatedAt = subscription.CreatedAt
+	return DB.Create(subscription).Error
+}
+
+func (subscription *WebhookSubscription) Update() error {
+	subscription.UpdatedAt = utils.GetTimestamp()
+	return DB.Select("name", "url", "secret", "events", "enabled", "updated_at").Updates(subscription).Error
+}
+
+func (subscription *WebhookSubscription) Delete() error {
+	return DB.Delete(subscription).Error
+}
+
+func (subscription *WebhookSubscription) Subscribed(event string) bool {
+	if event == WebhookEventPing {
+		return true
+	}
+
+	for _, item := range strings.Split(subscription.Events, ",") {
+		item = strings.TrimSpace(item)
+		if item == "*" || item == event {
+			return true
+		}
+	}
+	return false
+}
+
+func GetWebhookDeliveriesList(params *SearchWebhookDeliveriesParams) (*DataResult[WebhookDelivery], error) {
+	var deliveries []*WebhookDelivery
+	db := DB.Omit("payload", "response_body")
+
+	if params.SubscriptionId != 0 {
+		db = db.Where("subscription_id = ?", params.SubscriptionId)
+	}
+
+	if params.Event != "" {
+		db = db.Where("event = ?", params.Event)
+	}
+
+	if params.Status != "" {
+		db = db.Where("status = ?", params.Status)
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &deliveries, allowedWebhookDeliveryOrderFields)
+}
+
+func GetWebhookDeliveryById(id int) (*WebhookDelivery, error) {
+	delivery := &WebhookDelivery{}
+	err := DB.Where("id = ?", id).First(delivery).Error
+	return delivery, err
+}
+
+// PublishWebhookEvent 向订阅了该事件的地址异步投递，不影响业务流程
+func PublishWebhookEvent(event string, data any) {
+	go func() {
+		var subscriptions []*WebhookSubscription
+		if err := DB.Where("enabled = ?", true).Find(&subscriptions).Error; err != nil {
+			logger.SysError("load webhook subscriptions error: " + err.Error())
+			return
+		}
+
+		var payload []byte
+		for _, subscription := range subscriptions {
+			if !subscription.Subscribed(event) {
+				continue
+			}
+
+			if payload == nil {
+				var err error
+				payload, err = json.Marshal(&webhookPayload{
+					Id:        "evt_" + utils.GetUUID(),
+					Event:     event,
+					CreatedAt: utils.GetTimestamp(),
+					Data:      data,
+				})
+				if err != nil {
+					logger.SysError("marshal webhook payload error: " + err.Error())
+					return
+				}
+			}
+
+			deliverWebhook(subscription, event, payload)
+		}
+	}()
+}
+
+// SendWebhookPing 发送测试事件
+func SendWebhookPing(subscription *WebhookSubscription) (*WebhookDelivery, error) {
+	payload, err := json.Marshal(&webhookPayload{
+		Id:        "evt_" + utils.GetUUID(),
+		Event:     WebhookEventPing,
+		CreatedAt: utils.GetTimestamp(),
+		Data:      map[string]any{"subscription_id": subscription.Id},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliverWebhook(subscription, WebhookEventPing, payload), nil
+}
+
+// ReplayWebhookDelivery 使用原始内容重新投递，生成新的投递记录
+func ReplayWebhookDelivery(delivery *WebhookDelivery) (*WebhookDelivery, error) {
+	subscription, err := GetWebhookSubscriptionById(delivery.SubscriptionId)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliverWebhook(subscription, delivery.Event, []byte(delivery.Payload)), nil
+}
+
+func deliverWebhook(subscription *WebhookSubscription, event string, payload []byte) *WebhookDelivery {
+	var eventId struct {
+		Id string `json:"id"`
+	}
+	json.Unmarshal(payload, &eventId)
+
+	now := utils.GetTimestamp()
+	delivery := &WebhookDelivery{
+		SubscriptionId: subscription.Id,
+		EventId:        eventId.Id,
+		Event:          event,
+		Payload:        string(payload),
+		Status:         WebhookDeliveryPending,
+		// 首次投递由当前节点完成，避免被重试任务重复发送
+		NextRetryAt: now + int64(webhookRetryInterval.Seconds()),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := DB.Create(delivery).Error; err != nil {
+		logger.SysError("create webhook delivery error: " + err.Error())
+		return delivery
+	}
+
+	delivery.attempt(subscription)
+	return delivery
+}
+
+// RetryWebhookDeliveries 重试到期的失败投递，由主节点定时执行
+func RetryWebhookDeliveries() {
+	var deliveries []*WebhookDelivery
+	err := DB.Where("status = ? AND next_retry_at <= ?", WebhookDeliveryPending, utils.GetTimestamp()).
+		Order("id asc").Limit(100).Find(&deliveries).Error
+	if err != nil {
+		logger.SysError("load webhook deliveries error: " + err.Error())
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if !delivery.claim() {
+			continue
+		}
+
+		subscription, err := GetWebhookSubscriptionById(delivery.SubscriptionId)
+		if err != nil || !subscription.Enabled {
+			DB.Model(delivery).Updates(map[string]interface{}{
+				"status":     WebhookDeliveryFailed,
+				"error":      "subscription not found or disabled",
+				"updated_at": utils.GetTimestamp(),
+			})
+			continue
+		}
+
+		delivery.attempt(subscription)
+	}
+}
+
+// claim 条件更新重试时间来领取投递，重试任务重叠或多个节点同时执行时只有一个能领取成功
+// 投递过程中进程退出时，到了新的重试时间会再次重试
+func (delivery *WebhookDelivery) claim() bool {
+	nextRetryAt := utils.GetTimestamp() + int64(webhookRetryInterval.Seconds())
+	result := DB.Model(&WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_retry_at = ?", delivery.Id, WebhookDeliveryPending, delivery.NextRetryAt).
+		Update("next_retry_at", nextRetryAt)
+	if result.Error != nil || result.RowsAffected != 1 {
+		return false
+	}
+
+	delivery.NextRetryAt = nextRetryAt
+	return true
+}
+
+func (delivery *WebhookDelivery) attempt(subscription *WebhookSubscription) {
+	delivery.Attempts++
+	delivery.ResponseCode, delivery.ResponseBody, delivery.Error = sendWebhook(subscription, delivery.Event, []byte(delivery.Payload))
+
+	now := utils.GetTimestamp()
+	switch {
+	case delivery.Error == "":
+		delivery.Status = WebhookDeliverySuccess
+	case delivery.Attempts >= webhookMaxAttempts:
+		delivery.Status = WebhookDeliveryFailed
+	default:
+		// 指数退避：30s、1m、2m、4m ...
+		delivery.NextRetryAt = now + int64(webhookRetryInterval.Seconds())<<(delivery.Attempts-1)
+	}
+	delivery.UpdatedAt = now
+
+	err := DB.Model(delivery).Select("attempts", "status", "response_code", "response_body", "error", "next_retry_at", "updated_at").Updates(delivery).Error
+	if err != nil {
+		logger.SysError("update webhook delivery error: " + err.Error())
+	}
+}
+
+// 签名为 HMAC-SHA256(secret, timestamp + "." + body)
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func sendWebhook(subscription *WebhookSubscription, event string, payload []byte) (int, string, string) {
+	req, err := http.NewRequest(http.MethodPost, subscription.Url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", err.Error()
+	}
+
+	timestamp := utils.GetTimestamp()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Done-Hub-Webhook")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", SignWebhookPayload(subscription.Secret, timestamp, payload))
+
+	resp, err := webhookHTTPClient.Do(req)
+	if err != nil {
+		return 0, "", err.Error()
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, string(body), fmt.Sprintf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, string(body), ""
+}
@@ -0,0 +1,208 @@
+package model
+
+import (
+	"done-hub/common/utils"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupWebhookReceiver 订阅全部事件，接收地址返回指定的状态码，并校验签名
+func setupWebhookReceiver(t *testing.T, status int) (*WebhookSubscription, func() []webhookPayload) {
+	var mu sync.Mutex
+	var received []webhookPayload
+	subscription := &WebhookSubscription{Name: "test", Events: "*", Enabled: true}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-Webhook-Timestamp"), 10, 64)
+		assert.Equal(t, SignWebhookPayload(subscription.Secret, timestamp, body), r.Header.Get("X-Webhook-Signature"))
+
+		var payload webhookPayload
+		json.Unmarshal(body, &payload)
+		assert.Equal(t, payload.Event, r.Header.Get("X-Webhook-Event"))
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	subscription.Url = server.URL
+	assert.Nil(t, subscription.Insert())
+
+	return subscription, func() []webhookPayload {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]webhookPayload{}, received...)
+	}
+}
+
+func getTestWebhookDeliveries(t *testing.T) []*WebhookDelivery {
+	var deliveries []*WebhookDelivery
+	assert.Nil(t, DB.Order("id asc").Find(&deliveries).Error)
+	return deliveries
+}
+
+// 将投递的重试时间改为已到期
+func dueWebhookDelivery(t *testing.T, delivery *WebhookDelivery) {
+	assert.Nil(t, DB.Model(delivery).Update("next_retry_at", utils.GetTimestamp()-1).Error)
+}
+
+func TestWebhookSubscribed(t *testing.T) {
+	tests := []struct {
+		events string
+		event  string
+		want   bool
+	}{
+		{"*", WebhookEventOrderPaid, true},
+		{"order.paid", WebhookEventOrderPaid, true},
+		{"user.registered, order.paid", WebhookEventOrderPaid, true},
+		{"user.registered,token.created", WebhookEventOrderPaid, false},
+		{"order", WebhookEventOrderPaid, false},
+		{"", WebhookEventOrderPaid, false},
+		{"", WebhookEventPing, true},
+	}
+
+	for _, tt := range tests {
+		subscription := &WebhookSubscription{Events: tt.events}
+		assert.Equal(t, tt.want, subscription.Subscribed(tt.event), tt.events+" "+tt.event)
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	payload := []byte(`{"id":"evt_1"}`)
+	signature := SignWebhookPayload("whsec_test", 1700000000, payload)
+	assert.Equal(t, "sha256=c89214b5b5da833daed6f0b8c5bb6bd58cea9022bd80ccc78230f3942d632925", signature)
+
+	assert.NotEqual(t, signature, SignWebhookPayload("whsec_other", 1700000000, payload))
+	assert.NotEqual(t, signature, SignWebhookPayload("whsec_test", 1700000001, payload))
+}
+
+func TestWebhookRetryBackoff(t *testing.T) {
+	setupTestDB(t, &WebhookSubscription{}, &WebhookDelivery{})
+	subscription, received := setupWebhookReceiver(t, http.StatusInternalServerError)
+
+	delivery, err := SendWebhookPing(subscription)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, http.StatusInternalServerError, delivery.ResponseCode)
+
+	// 指数退避：30s、1m、2m、4m ...
+	interval := int64(webhookRetryInterval.Seconds())
+	for attempts := 1; attempts < webhookMaxAttempts; attempts++ {
+		delivery, err = GetWebhookDeliveryById(delivery.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, attempts, delivery.Attempts)
+		assert.Equal(t, WebhookDeliveryPending, delivery.Status)
+		assert.InDelta(t, utils.GetTimestamp()+interval<<(attempts-1), delivery.NextRetryAt, 2)
+
+		// 未到期的投递不会重试
+		RetryWebhookDeliveries()
+		assert.Len(t, received(), attempts)
+
+		dueWebhookDelivery(t, delivery)
+		RetryWebhookDeliveries()
+		assert.Len(t, received(), attempts+1)
+	}
+
+	// 达到最大次数后不再重试
+	delivery, err = GetWebhookDeliveryById(delivery.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, webhookMaxAttempts, delivery.Attempts)
+	assert.Equal(t, WebhookDeliveryFailed, delivery.Status)
+
+	dueWebhookDelivery(t, delivery)
+	RetryWebhookDeliveries()
+	assert.Len(t, received(), webhookMaxAttempts)
+}
+
+func TestWebhookRetryDisabledSubscription(t *testing.T) {
+	setupTestDB(t, &WebhookSubscription{}, &WebhookDelivery{})
+	subscription, received := setupWebhookReceiver(t, http.StatusInternalServerError)
+
+	delivery, err := SendWebhookPing(subscription)
+	assert.Nil(t, err)
+
+	subscription.Enabled = false
+	assert.Nil(t, subscription.Update())
+	dueWebhookDelivery(t, delivery)
+	RetryWebhookDeliveries()
+
+	delivery, err = GetWebhookDeliveryById(delivery.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, WebhookDeliveryFailed, delivery.Status)
+	assert.Len(t, received(), 1)
+}
+
+func TestWebhookRetryOverlapping(t *testing.T) {
+	setupTestDB(t, &WebhookSubscription{}, &WebhookDelivery{})
+	subscription, received := setupWebhookReceiver(t, http.StatusInternalServerError)
+
+	for i := 0; i < 5; i++ {
+		_, err := SendWebhookPing(subscription)
+		assert.Nil(t, err)
+	}
+	for _, delivery := range getTestWebhookDeliveries(t) {
+		dueWebhookDelivery(t, delivery)
+	}
+
+	// 重叠执行的重试任务，每个投递只发送一次
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			RetryWebhookDeliveries()
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, received(), 10)
+	for _, delivery := range getTestWebhookDeliveries(t) {
+		assert.Equal(t, 2, delivery.Attempts)
+	}
+
+	// 读取后被其他任务领取的投递不能再次领取
+	delivery := getTestWebhookDeliveries(t)[0]
+	dueWebhookDelivery(t, delivery)
+	stale := *delivery
+	assert.True(t, delivery.claim())
+	assert.False(t, stale.claim())
+}
+
+func TestReplayWebhookDelivery(t *testing.T) {
+	setupTestDB(t, &WebhookSubscription{}, &WebhookDelivery{})
+	_, received := setupWebhookReceiver(t, http.StatusOK)
+
+	PublishWebhookEvent(WebhookEventOrderPaid, map[string]any{"trade_no": "T1"})
+	assert.Eventually(t, func() bool { return len(received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		deliveries := getTestWebhookDeliveries(t)
+		return len(deliveries) == 1 && deliveries[0].Status == WebhookDeliverySuccess
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 重放使用原始内容，生成新的投递记录
+	original := getTestWebhookDeliveries(t)[0]
+	replayed, err := ReplayWebhookDelivery(original)
+	assert.Nil(t, err)
+	assert.NotEqual(t, original.Id, replayed.Id)
+	assert.Equal(t, original.EventId, replayed.EventId)
+	assert.Equal(t, original.Payload, replayed.Payload)
+	assert.Equal(t, WebhookDeliverySuccess, replayed.Status)
+
+	events := received()
+	assert.Len(t, events, 2)
+	assert.Equal(t, events[0].Id, events[1].Id)
+	assert.Equal(t, WebhookEventOrderPaid, events[1].Event)
+	assert.Equal(t, "T1", events[1].Data.(map[string]any)["trade_no"])
+	assert.Len(t, getTestWebhookDeliveries(t), 2)
+}
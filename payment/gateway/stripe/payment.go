@@ -87,8 +87,89 @@ func (e *Stripe) Pay(config *types.PayConfig, gatewayConfig string) (*types.PayR
 	return payRequest, nil
 }
 
+// Subscribe 创建订阅模式的支付，按周期自动扣款
+func (e *Stripe) Subscribe(config *types.SubscribeConfig, gatewayConfig string) (*types.PayRequest, error) {
+	var stripeConfig StripeConfig
+	err := json.Unmarshal([]byte(gatewayConfig), &stripeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &client.API{}
+	sc.Init(stripeConfig.SecretKey, nil)
+	currency := stripe.String("USD")
+	if config.Currency == "CNY" {
+		currency = stripe.String("CNY")
+	}
+
+	metadata := map[string]string{
+		"user_id":  fmt.Sprintf("%d", config.User.Id),
+		"trade_no": config.TradeNo,
+	}
+
+	params := &stripe.CheckoutSessionParams{
+		Mode:              stripe.String(string(stripe.CheckoutSessionModeSubscription)),
+		SuccessURL:        stripe.String(config.ReturnURL),
+		ClientReferenceID: stripe.String(config.TradeNo),
+
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
+			{
+				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+					Currency: currency,
+					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+						Name: stripe.String(sysconfig.SystemName + "-订阅套餐:" + config.PlanName),
+					},
+					UnitAmount: stripe.Int64(int64(math.Round(config.Money * 100))),
+					Recurring: &stripe.CheckoutSessionLineItemPriceDataRecurringParams{
+						Interval:      stripe.String(string(stripe.PriceRecurringIntervalDay)),
+						IntervalCount: stripe.Int64(int64(config.PeriodDays)),
+					},
+				},
+				Quantity: stripe.Int64(1),
+			},
+		},
+		SubscriptionData: &stripe.CheckoutSessionSubscriptionDataParams{
+			Metadata: metadata,
+		},
+		Metadata: metadata,
+	}
+
+	if config.User.Email != "" {
+		params.CustomerEmail = stripe.String(config.User.Email)
+	}
+
+	result, err := sc.CheckoutSessions.New(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.PayRequest{
+		Type: 1,
+		Data: types.PayRequestData{
+			URL: result.URL,
+			Params: map[string]interface{}{
+				"tradeNo": config.TradeNo,
+				"linkId":  result.ID,
+			},
+		},
+	}, nil
+}
+
+// CancelSubscription 立即取消订阅，已支付的周期仍然有效
+func (e *Stripe) CancelSubscription(subscriptionId string, gatewayConfig string) error {
+	var stripeConfig StripeConfig
+	if err := json.Unmarshal([]byte(gatewayConfig), &stripeConfig); err != nil {
+		return err
+	}
+
+	sc := &client.API{}
+	sc.Init(stripeConfig.SecretKey, nil)
+	_, err := sc.Subscriptions.Cancel(subscriptionId, &stripe.SubscriptionCancelParams{})
+	return err
+}
+
 func (e *Stripe) CreatedPay(notifyURL string, gatewayConfig *model.Payment) error {
-	eventName := "checkout.session.completed"
+	eventNames := []string{"checkout.session.completed", "invoice.paid", "customer.subscription.deleted"}
 	var stripeConfig StripeConfig
 	err := json.Unmarshal([]byte(gatewayConfig.Config), &stripeConfig)
 	if err != nil {
@@ -103,7 +184,7 @@ func (e *Stripe) CreatedPay(notifyURL string, gatewayConfig *model.Payment) erro
 	var existingWebhook *stripe.WebhookEndpoint
 	for i.Next() {
 		webhook := i.WebhookEndpoint()
-		if webhook.URL == notifyURL && contains(webhook.EnabledEvents, eventName) {
+		if webhook.URL == notifyURL {
 			existingWebhook = webhook
 			break
 		}
@@ -117,11 +198,9 @@ func (e *Stripe) CreatedPay(notifyURL string, gatewayConfig *model.Payment) erro
 
 	if existingWebhook == nil {
 		createParams := &stripe.WebhookEndpointParams{
-			URL: stripe.String(notifyURL),
-			EnabledEvents: []*string{
-				stripe.String(eventName),
-			},
-			APIVersion: stripe.String("2024-09-30.acacia"),
+			URL:           stripe.String(notifyURL),
+			EnabledEvents: stripe.StringSlice(eventNames),
+			APIVersion:    stripe.String("2024-09-30.acacia"),
 		}
 		newWebhook, err := webhookendpoint.New(createParams)
 		if err != nil {
@@ -129,12 +208,25 @@ func (e *Stripe) CreatedPay(notifyURL string, gatewayConfig *model.Payment) erro
 		}
 		wh = newWebhook
 		fmt.Printf("Created new webhook: %s\n", newWebhook.ID)
+	} else if !containsAll(existingWebhook.EnabledEvents, eventNames) {
+		// 旧版本只订阅了支付完成事件，补充订阅续费相关事件
+		updatedWebhook, err := webhookendpoint.Update(existingWebhook.ID, &stripe.WebhookEndpointParams{
+			EnabledEvents: stripe.StringSlice(eventNames),
+		})
+		if err != nil {
+			return fmt.Errorf("error updating webhook: %v", err)
+		}
+		wh = updatedWebhook
+		fmt.Printf("Updated webhook events: %s\n", updatedWebhook.ID)
 	} else {
 		fmt.Printf("Webhook already exists: %s\n", existingWebhook.ID)
 		wh = existingWebhook
 	}
 
-	stripeConfig.WebhookSecret = wh.Secret
+	// 只有创建时才会返回签名密钥
+	if wh.Secret != "" {
+		stripeConfig.WebhookSecret = wh.Secret
+	}
 	config, err := json.Marshal(stripeConfig)
 	if err != nil {
 		return fmt.Errorf("error creating webhook: %v", err)
@@ -158,6 +250,15 @@ func contains(slice []string, str string) bool {
 	return false
 }
 
+func containsAll(slice []string, items []string) bool {
+	for _, item := range items {
+		if !contains(slice, item) {
+			return false
+		}
+	}
+	return true
+}
+
 // HandleCallback 处理支付回调
 func (e *Stripe) HandleCallback(c *gin.Context, gatewayConfig string) (*types.PayNotify, error) {
 	body, err := c.GetRawData()
@@ -192,6 +293,22 @@ func (e *Stripe) HandleCallback(c *gin.Context, gatewayConfig string) (*types.Pa
 		// 获取订单号
 		orderID := session.ClientReferenceID
 
+		// 订阅模式没有 PaymentIntent，使用首期账单号
+		if session.Mode == stripe.CheckoutSessionModeSubscription {
+			if session.Subscription == nil {
+				return nil, fmt.Errorf("subscription not found in session: %s", session.ID)
+			}
+			gatewayNo := session.ID
+			if session.Invoice != nil {
+				gatewayNo = session.Invoice.ID
+			}
+			return &types.PayNotify{
+				TradeNo:        orderID,
+				GatewayNo:      gatewayNo,
+				SubscriptionId: session.Subscription.ID,
+			}, nil
+		}
+
 		// 构造 PayNotify
 		payNotify := &types.PayNotify{
 			TradeNo:   orderID,
@@ -199,6 +316,33 @@ func (e *Stripe) HandleCallback(c *gin.Context, gatewayConfig string) (*types.Pa
 		}
 
 		return payNotify, nil
+	case "invoice.paid":
+		var invoice stripe.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
+			return nil, fmt.Errorf("failed to parse invoice data: %v", err)
+		}
+
+		// 首期账单由 checkout.session.completed 处理
+		if invoice.BillingReason != stripe.InvoiceBillingReasonSubscriptionCycle || invoice.Subscription == nil {
+			return nil, nil
+		}
+
+		return &types.PayNotify{
+			GatewayNo:         invoice.ID,
+			SubscriptionId:    invoice.Subscription.ID,
+			SubscriptionEvent: types.SubscriptionEventRenewed,
+		}, nil
+	case "customer.subscription.deleted":
+		var sub stripe.Subscription
+		if err := json.Unmarshal(event.Data.Raw, &sub); err != nil {
+			return nil, fmt.Errorf("failed to parse subscription data: %v", err)
+		}
+
+		return &types.PayNotify{
+			GatewayNo:         event.ID,
+			SubscriptionId:    sub.ID,
+			SubscriptionEvent: types.SubscriptionEventCanceled,
+		}, nil
 	default:
 		return nil, nil
 	}
@@ -0,0 +1,130 @@
+package stripe
+
+import (
+	"bytes"
+	"done-hub/payment/types"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v80"
+	"github.com/stripe/stripe-go/v80/webhook"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// newCallbackContext 构造带签名的 Stripe 回调请求
+func newCallbackContext(t *testing.T, eventType string, object map[string]any, secret string) *gin.Context {
+	data, err := json.Marshal(object)
+	assert.Nil(t, err)
+	payload, err := json.Marshal(map[string]any{
+		"id":          "evt_1",
+		"object":      "event",
+		"type":        eventType,
+		"api_version": stripe.APIVersion,
+		"data":        map[string]any{"object": json.RawMessage(data)},
+	})
+	assert.Nil(t, err)
+
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: secret})
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/payment/notify/stripe", bytes.NewReader(payload))
+	c.Request.Header.Set("Stripe-Signature", signed.Header)
+	return c
+}
+
+func handleTestCallback(t *testing.T, eventType string, object map[string]any) (*types.PayNotify, error) {
+	gatewayConfig, err := json.Marshal(&StripeConfig{SecretKey: "sk_test", WebhookSecret: testWebhookSecret})
+	assert.Nil(t, err)
+	return (&Stripe{}).HandleCallback(newCallbackContext(t, eventType, object, testWebhookSecret), string(gatewayConfig))
+}
+
+func TestHandleCallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		object    map[string]any
+		want      *types.PayNotify
+	}{
+		{
+			"payment completed",
+			"checkout.session.completed",
+			map[string]any{"id": "cs_1", "object": "checkout.session", "mode": "payment", "client_reference_id": "T1", "payment_intent": "pi_1"},
+			&types.PayNotify{TradeNo: "T1", GatewayNo: "pi_1"},
+		},
+		{
+			// 订阅模式使用首期账单号，同时返回订阅ID
+			"subscription completed",
+			"checkout.session.completed",
+			map[string]any{"id": "cs_1", "object": "checkout.session", "mode": "subscription", "client_reference_id": "T1", "subscription": "sub_1", "invoice": "in_1"},
+			&types.PayNotify{TradeNo: "T1", GatewayNo: "in_1", SubscriptionId: "sub_1"},
+		},
+		{
+			"subscription completed without invoice",
+			"checkout.session.completed",
+			map[string]any{"id": "cs_1", "object": "checkout.session", "mode": "subscription", "client_reference_id": "T1", "subscription": "sub_1"},
+			&types.PayNotify{TradeNo: "T1", GatewayNo: "cs_1", SubscriptionId: "sub_1"},
+		},
+		{
+			// 账单号作为扣款单号，重复通知生成相同的续费订单
+			"renewal invoice",
+			"invoice.paid",
+			map[string]any{"id": "in_2", "object": "invoice", "billing_reason": "subscription_cycle", "subscription": "sub_1"},
+			&types.PayNotify{GatewayNo: "in_2", SubscriptionId: "sub_1", SubscriptionEvent: types.SubscriptionEventRenewed},
+		},
+		{
+			// 首期账单由 checkout.session.completed 处理
+			"first invoice",
+			"invoice.paid",
+			map[string]any{"id": "in_1", "object": "invoice", "billing_reason": "subscription_create", "subscription": "sub_1"},
+			nil,
+		},
+		{
+			"one-off invoice",
+			"invoice.paid",
+			map[string]any{"id": "in_3", "object": "invoice", "billing_reason": "manual"},
+			nil,
+		},
+		{
+			"subscription deleted",
+			"customer.subscription.deleted",
+			map[string]any{"id": "sub_1", "object": "subscription"},
+			&types.PayNotify{GatewayNo: "evt_1", SubscriptionId: "sub_1", SubscriptionEvent: types.SubscriptionEventCanceled},
+		},
+		{
+			"unknown event",
+			"customer.created",
+			map[string]any{"id": "cus_1", "object": "customer"},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payNotify, err := handleTestCallback(t, tt.eventType, tt.object)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, payNotify)
+		})
+	}
+}
+
+func TestHandleCallbackErrors(t *testing.T) {
+	gatewayConfig, err := json.Marshal(&StripeConfig{SecretKey: "sk_test", WebhookSecret: testWebhookSecret})
+	assert.Nil(t, err)
+
+	object := map[string]any{"id": "in_2", "object": "invoice", "billing_reason": "subscription_cycle", "subscription": "sub_1"}
+	c := newCallbackContext(t, "invoice.paid", object, "whsec_other")
+	payNotify, err := (&Stripe{}).HandleCallback(c, string(gatewayConfig))
+	assert.NotNil(t, err)
+	assert.Nil(t, payNotify)
+
+	// 订阅模式缺少订阅ID时不能开通
+	payNotify, err = handleTestCallback(t, "checkout.session.completed", map[string]any{"id": "cs_1", "object": "checkout.session", "mode": "subscription", "client_reference_id": "T1"})
+	assert.NotNil(t, err)
+	assert.Nil(t, payNotify)
+}
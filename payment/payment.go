@@ -18,6 +18,12 @@ type PaymentProcessor interface {
 	HandleCallback(c *gin.Context, gatewayConfig string) (*types.PayNotify, error)
 }
 
+// SubscriptionProcessor 支持自动续费的网关
+type SubscriptionProcessor interface {
+	Subscribe(config *types.SubscribeConfig, gatewayConfig string) (*types.PayRequest, error)
+	CancelSubscription(subscriptionId string, gatewayConfig string) error
+}
+
 var Gateways = make(map[string]PaymentProcessor)
 
 func init() {
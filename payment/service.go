@@ -61,6 +61,43 @@ func (s *PaymentService) Pay(tradeNo string, amount float64, user *model.User) (
 	return payRequest, nil
 }
 
+// SupportSubscription 网关是否支持自动续费
+func (s *PaymentService) SupportSubscription() bool {
+	_, ok := s.gateway.(SubscriptionProcessor)
+	return ok
+}
+
+func (s *PaymentService) Subscribe(tradeNo string, amount float64, user *model.User, plan *model.SubscriptionPlan) (*types.PayRequest, error) {
+	processor, ok := s.gateway.(SubscriptionProcessor)
+	if !ok {
+		return nil, errors.New("payment gateway does not support subscription")
+	}
+
+	config := &types.SubscribeConfig{
+		PayConfig: types.PayConfig{
+			Money:     amount,
+			TradeNo:   tradeNo,
+			NotifyURL: s.getNotifyURL(),
+			ReturnURL: s.getReturnURL(),
+			Currency:  s.Payment.Currency,
+			User:      user,
+		},
+		PlanName:   plan.Name,
+		PeriodDays: plan.PeriodDays,
+	}
+
+	return processor.Subscribe(config, s.Payment.Config)
+}
+
+func (s *PaymentService) CancelSubscription(subscriptionId string) error {
+	processor, ok := s.gateway.(SubscriptionProcessor)
+	if !ok {
+		return errors.New("payment gateway does not support subscription")
+	}
+
+	return processor.CancelSubscription(subscriptionId, s.Payment.Config)
+}
+
 func (s *PaymentService) HandleCallback(c *gin.Context, gatewayConfig string) (*types.PayNotify, error) {
 	payNotify, err := s.gateway.HandleCallback(c, gatewayConfig)
 	if err != nil {
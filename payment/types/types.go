@@ -12,6 +12,13 @@ type PayConfig struct {
 	User      *model.User        `json:"user"`
 }
 
+// 自动续费订阅的配置，Money 为每周期的金额
+type SubscribeConfig struct {
+	PayConfig
+	PlanName   string `json:"plan_name"`
+	PeriodDays int    `json:"period_days"`
+}
+
 // 请求支付时的数据结构
 type PayRequest struct {
 	Type int            `json:"type"` // 支付类型 1 url 2 qrcode
@@ -28,4 +35,12 @@ type PayRequestData struct {
 type PayNotify struct {
 	TradeNo   string `json:"trade_no"`
 	GatewayNo string `json:"gateway_no"`
+	// 订阅相关，首次支付时返回订阅ID，之后的续费和取消通过 SubscriptionEvent 区分
+	SubscriptionId    string `json:"subscription_id,omitempty"`
+	SubscriptionEvent string `json:"subscription_event,omitempty"`
 }
+
+const (
+	SubscriptionEventRenewed  = "renewed"
+	SubscriptionEventCanceled = "canceled"
+)
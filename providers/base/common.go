@@ -160,6 +160,11 @@ func GetResponseModelNameFromContext(ctx *gin.Context, fallbackModel string) str
 		return fallbackModel
 	}
 
+	// 降级到其他模型时返回用户请求的模型
+	if fallbackFrom := ctx.GetString("fallback_from"); fallbackFrom != "" {
+		return fallbackFrom
+	}
+
 	// 优先使用存储的原始模型名称
 	if originalModel, exists := ctx.Get("original_model"); exists {
 		if originalModelStr, ok := originalModel.(string); ok && originalModelStr != "" {
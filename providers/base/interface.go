@@ -118,6 +118,12 @@ type ModelListInterface interface {
 	GetModelList() ([]string, error)
 }
 
+// 健康检查接口，用于自部署的推理服务
+type HealthCheckInterface interface {
+	ProviderInterface
+	CheckHealth() *model.ChannelHealth
+}
+
 // 余额接口
 type BalanceInterface interface {
 	Balance() (float64, error)
@@ -184,6 +184,16 @@ func ConvertFromChatOpenai(request *types.ChatCompletionRequest) (*ClaudeRequest
 		claudeRequest.ToolChoice = ConvertToolChoice(toolType, toolFunc)
 	}
 
+	// Claude 没有 json_schema，通过强制调用工具实现结构化输出
+	if UseStructuredOutputTool(request) {
+		claudeRequest.Tools = append(claudeRequest.Tools, Tools{
+			Name:        StructuredOutputToolName,
+			Description: getStructuredOutputDescription(request.ResponseFormat.JsonSchema),
+			InputSchema: request.ResponseFormat.JsonSchema.Schema,
+		})
+		claudeRequest.ToolChoice = &ToolChoice{Type: "tool", Name: StructuredOutputToolName}
+	}
+
 	if claudeRequest.MaxTokens == 0 {
 		claudeRequest.MaxTokens = config.ClaudeSettingsInstance.GetDefaultMaxTokens(request.Model)
 	}
@@ -203,6 +213,32 @@ func ConvertFromChatOpenai(request *types.ChatCompletionRequest) (*ClaudeRequest
 	return &claudeRequest, nil
 }
 
+// StructuredOutputToolName 用于实现 json_schema 的工具名称，调用结果会转换为文本内容
+const StructuredOutputToolName = "structured_output"
+
+// UseStructuredOutputTool 请求了 json_schema 且没有使用其它工具、未开启思考时使用工具实现
+// 强制调用工具与思考不兼容，工具的参数也必须是 object
+func UseStructuredOutputTool(request *types.ChatCompletionRequest) bool {
+	format := request.ResponseFormat
+	if format == nil || format.Type != "json_schema" || format.JsonSchema == nil || format.JsonSchema.Schema == nil {
+		return false
+	}
+
+	if len(request.Tools) > 0 || request.OneOtherArg == "thinking" || request.Reasoning != nil {
+		return false
+	}
+
+	schema, ok := format.JsonSchema.Schema.(map[string]any)
+	return ok && schema["type"] == "object"
+}
+
+func getStructuredOutputDescription(schema *types.FormatJsonSchema) string {
+	if schema.Description != "" {
+		return schema.Description
+	}
+	return "Respond with the final answer by calling this tool."
+}
+
 func getThinking(maxTokens int, reasoning *types.ChatReasoning) (newMaxtokens int, thinking *Thinking, err *types.OpenAIErrorWithStatusCode) {
 	newMaxtokens = maxTokens
 	thinking = &Thinking{
@@ -344,7 +380,20 @@ func ConvertToChatOpenai(provider base.ProviderInterface, response *ClaudeRespon
 	isThinking := false
 	thinkingContent := ""
 
+	structured := UseStructuredOutputTool(request)
+	finishReason := stopReasonClaude2OpenAI(response.StopReason)
+	if structured && finishReason == types.FinishReasonToolCalls {
+		finishReason = types.FinishReasonStop
+	}
+
 	for _, content := range response.Content {
+		// 结构化输出的工具调用参数作为文本内容返回
+		if structured && content.Type == ContentTypeToolUse {
+			args, _ := json.Marshal(content.Input)
+			content.Type = ContentTypeText
+			content.Text = string(args)
+		}
+
 		switch content.Type {
 		case ContentTypeToolUse:
 			if len(choices) == 0 {
@@ -380,7 +429,7 @@ func ConvertToChatOpenai(provider base.ProviderInterface, response *ClaudeRespon
 					Role:    response.Role,
 					Content: content.Text,
 				},
-				FinishReason: stopReasonClaude2OpenAI(response.StopReason),
+				FinishReason: finishReason,
 			}
 
 			if isThinking {
@@ -400,7 +449,7 @@ func ConvertToChatOpenai(provider base.ProviderInterface, response *ClaudeRespon
 				Role:    response.Role,
 				Content: "",
 			},
-			FinishReason: stopReasonClaude2OpenAI(response.StopReason),
+			FinishReason: finishReason,
 		})
 	}
 
@@ -498,6 +547,17 @@ func (h *ClaudeStreamHandler) convertToOpenaiStream(claudeResponse *ClaudeStream
 		choice.Delta.Content = claudeResponse.ContentBlock.Text
 	}
 
+	// 结构化输出的工具参数作为文本内容返回
+	structured := h.Request != nil && UseStructuredOutputTool(h.Request)
+	if structured && (claudeResponse.ContentBlock.Type == ContentTypeToolUse || claudeResponse.Delta.Type == ContentStreamTypeInputJsonDelta) {
+		if claudeResponse.Delta.PartialJson == "" {
+			return
+		}
+		choice.Delta.Content = claudeResponse.Delta.PartialJson
+		claudeResponse.ContentBlock.Type = ""
+		claudeResponse.Delta.Type = ""
+	}
+
 	var toolCalls []*types.ChatCompletionToolCalls
 
 	if claudeResponse.ContentBlock.Type == ContentTypeToolUse {
@@ -549,6 +609,9 @@ func (h *ClaudeStreamHandler) convertToOpenaiStream(claudeResponse *ClaudeStream
 	}
 
 	finishReason := stopReasonClaude2OpenAI(claudeResponse.Delta.StopReason)
+	if structured && finishReason == types.FinishReasonToolCalls {
+		finishReason = types.FinishReasonStop
+	}
 	if finishReason != "" {
 		choice.FinishReason = &finishReason
 	}
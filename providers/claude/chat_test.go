@@ -0,0 +1,217 @@
+package claude
+
+import (
+	"done-hub/common/logger"
+	"done-hub/providers/base"
+	"done-hub/types"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logger.SetupLogger()
+}
+
+func getStructuredRequest() *types.ChatCompletionRequest {
+	return &types.ChatCompletionRequest{
+		Model:     "claude-sonnet-4",
+		MaxTokens: 1024,
+		Messages: []types.ChatCompletionMessage{
+			{Role: types.ChatMessageRoleUser, Content: "hello"},
+		},
+		ResponseFormat: &types.ChatCompletionResponseFormat{
+			Type: "json_schema",
+			JsonSchema: &types.FormatJsonSchema{
+				Name: "answer",
+				Schema: map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"answer": map[string]any{"type": "string"}},
+					"required":   []any{"answer"},
+				},
+			},
+		},
+	}
+}
+
+func TestUseStructuredOutputTool(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(request *types.ChatCompletionRequest)
+		want   bool
+	}{
+		{"json_schema", func(request *types.ChatCompletionRequest) {}, true},
+		{"json_object", func(request *types.ChatCompletionRequest) {
+			request.ResponseFormat = &types.ChatCompletionResponseFormat{Type: "json_object"}
+		}, false},
+		{"no format", func(request *types.ChatCompletionRequest) {
+			request.ResponseFormat = nil
+		}, false},
+		{"with tools", func(request *types.ChatCompletionRequest) {
+			request.Tools = []*types.ChatCompletionTool{{Type: "function", Function: types.ChatCompletionFunction{Name: "get_weather"}}}
+		}, false},
+		{"thinking", func(request *types.ChatCompletionRequest) {
+			request.OneOtherArg = "thinking"
+		}, false},
+		{"reasoning", func(request *types.ChatCompletionRequest) {
+			request.Reasoning = &types.ChatReasoning{MaxTokens: 2048}
+		}, false},
+		{"array schema", func(request *types.ChatCompletionRequest) {
+			request.ResponseFormat.JsonSchema.Schema = map[string]any{"type": "array"}
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := getStructuredRequest()
+			tt.modify(request)
+			assert.Equal(t, tt.want, UseStructuredOutputTool(request))
+		})
+	}
+}
+
+func TestConvertFromChatOpenaiStructuredOutput(t *testing.T) {
+	request := getStructuredRequest()
+
+	claudeRequest, errWithCode := ConvertFromChatOpenai(request)
+	assert.Nil(t, errWithCode)
+	assert.Len(t, claudeRequest.Tools, 1)
+	assert.Equal(t, StructuredOutputToolName, claudeRequest.Tools[0].Name)
+	assert.Equal(t, request.ResponseFormat.JsonSchema.Schema, claudeRequest.Tools[0].InputSchema)
+	assert.Equal(t, &ToolChoice{Type: "tool", Name: StructuredOutputToolName}, claudeRequest.ToolChoice)
+}
+
+func TestConvertToChatOpenaiStructuredOutput(t *testing.T) {
+	provider := &ClaudeProvider{BaseProvider: base.BaseProvider{Usage: &types.Usage{}}}
+	response := &ClaudeResponse{
+		Id:         "msg_1",
+		Role:       types.ChatMessageRoleAssistant,
+		StopReason: "tool_use",
+		Content: []ResContent{{
+			Type:  ContentTypeToolUse,
+			Id:    "toolu_1",
+			Name:  StructuredOutputToolName,
+			Input: map[string]any{"answer": "42"},
+		}},
+		Usage: Usage{InputTokens: 10, OutputTokens: 5},
+	}
+
+	openaiResponse, errWithCode := ConvertToChatOpenai(provider, response, getStructuredRequest())
+	assert.Nil(t, errWithCode)
+	assert.Len(t, openaiResponse.Choices, 1)
+
+	choice := openaiResponse.Choices[0]
+	assert.Empty(t, choice.Message.ToolCalls)
+	assert.Equal(t, types.FinishReasonStop, choice.FinishReason)
+	assert.JSONEq(t, `{"answer":"42"}`, choice.Message.StringContent())
+}
+
+func TestConvertToChatOpenaiToolUseWithoutStructuredOutput(t *testing.T) {
+	provider := &ClaudeProvider{BaseProvider: base.BaseProvider{Usage: &types.Usage{}}}
+	response := &ClaudeResponse{
+		Id:         "msg_1",
+		Role:       types.ChatMessageRoleAssistant,
+		StopReason: "tool_use",
+		Content: []ResContent{{
+			Type:  ContentTypeToolUse,
+			Id:    "toolu_1",
+			Name:  "get_weather",
+			Input: map[string]any{"city": "Paris"},
+		}},
+		Usage: Usage{InputTokens: 10, OutputTokens: 5},
+	}
+
+	request := getStructuredRequest()
+	request.ResponseFormat = nil
+	openaiResponse, errWithCode := ConvertToChatOpenai(provider, response, request)
+	assert.Nil(t, errWithCode)
+
+	choice := openaiResponse.Choices[0]
+	assert.Len(t, choice.Message.ToolCalls, 1)
+	assert.Equal(t, "get_weather", choice.Message.ToolCalls[0].Function.Name)
+	assert.Equal(t, types.FinishReasonToolCalls, choice.FinishReason)
+}
+
+// runStream 把 Claude 的流式事件转换为 OpenAI 的流式响应块
+func runStream(t *testing.T, request *types.ChatCompletionRequest, events []string) []types.ChatCompletionStreamResponse {
+	handler := &ClaudeStreamHandler{
+		Usage:   &types.Usage{},
+		Request: request,
+		Prefix:  "data: {\"type\"",
+	}
+
+	dataChan := make(chan string, 100)
+	errChan := make(chan error, 100)
+	for _, event := range events {
+		line := []byte("data: " + event)
+		handler.HandlerStream(&line, dataChan, errChan)
+	}
+	close(dataChan)
+
+	var chunks []types.ChatCompletionStreamResponse
+	for data := range dataChan {
+		var chunk types.ChatCompletionStreamResponse
+		assert.Nil(t, json.Unmarshal([]byte(data), &chunk))
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+var structuredStreamEvents = []string{
+	`{"type":"message_start","message":{"id":"msg_1","role":"assistant","usage":{"input_tokens":10}}}`,
+	`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"structured_output","input":{}}}`,
+	`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":""}}`,
+	`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"answer\":"}}`,
+	`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"42\"}"}}`,
+	`{"type":"content_block_stop","index":0}`,
+	`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":5}}`,
+}
+
+func TestStreamStructuredOutput(t *testing.T) {
+	chunks := runStream(t, getStructuredRequest(), structuredStreamEvents)
+
+	var content strings.Builder
+	var finishReason any
+	for _, chunk := range chunks {
+		for _, choice := range chunk.Choices {
+			assert.Empty(t, choice.Delta.ToolCalls)
+			content.WriteString(choice.Delta.Content)
+			if choice.FinishReason != nil {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+
+	assert.JSONEq(t, `{"answer":"42"}`, content.String())
+	assert.Equal(t, types.FinishReasonStop, finishReason)
+}
+
+func TestStreamToolUseWithoutStructuredOutput(t *testing.T) {
+	request := getStructuredRequest()
+	request.ResponseFormat = nil
+	chunks := runStream(t, request, structuredStreamEvents)
+
+	var arguments strings.Builder
+	var finishReason any
+	var name string
+	for _, chunk := range chunks {
+		for _, choice := range chunk.Choices {
+			assert.Empty(t, choice.Delta.Content)
+			for _, call := range choice.Delta.ToolCalls {
+				if call.Function.Name != "" {
+					name = call.Function.Name
+				}
+				arguments.WriteString(call.Function.Arguments)
+			}
+			if choice.FinishReason != nil {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+
+	assert.Equal(t, StructuredOutputToolName, name)
+	assert.JSONEq(t, `{"answer":"42"}`, arguments.String())
+	assert.Equal(t, types.FinishReasonToolCalls, finishReason)
+}
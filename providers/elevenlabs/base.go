@@ -0,0 +1,86 @@
+package elevenlabs
+
+import (
+	"done-hub/common/requester"
+	"done-hub/model"
+	"done-hub/providers/base"
+	"done-hub/types"
+	"encoding/json"
+	"net/http"
+)
+
+// 定义供应商工厂
+type ElevenLabsProviderFactory struct{}
+
+// 创建 ElevenLabsProvider
+func (f ElevenLabsProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	return &ElevenLabsProvider{
+		BaseProvider: base.BaseProvider{
+			Config:    getConfig(),
+			Channel:   channel,
+			Requester: requester.NewHTTPRequester(*channel.Proxy, requestErrorHandle),
+		},
+	}
+}
+
+type ElevenLabsProvider struct {
+	base.BaseProvider
+}
+
+func getConfig() base.ProviderConfig {
+	return base.ProviderConfig{
+		BaseURL:             "https://api.elevenlabs.io",
+		AudioSpeech:         "/v1/text-to-speech/%s",
+		AudioTranscriptions: "/v1/speech-to-text",
+	}
+}
+
+// 请求错误处理
+func requestErrorHandle(resp *http.Response) *types.OpenAIError {
+	elevenLabsError := &ElevenLabsError{}
+	err := json.NewDecoder(resp.Body).Decode(elevenLabsError)
+	if err != nil {
+		return nil
+	}
+
+	return errorHandle(elevenLabsError)
+}
+
+// 错误处理
+func errorHandle(elevenLabsError *ElevenLabsError) *types.OpenAIError {
+	if elevenLabsError.Detail == nil {
+		return nil
+	}
+
+	message := ""
+	code := ""
+	switch detail := elevenLabsError.Detail.(type) {
+	case string:
+		message = detail
+	case map[string]any:
+		message, _ = detail["message"].(string)
+		code, _ = detail["status"].(string)
+	default:
+		detailBytes, _ := json.Marshal(detail)
+		message = string(detailBytes)
+	}
+
+	if message == "" {
+		return nil
+	}
+
+	return &types.OpenAIError{
+		Message: message,
+		Type:    "elevenlabs_error",
+		Code:    code,
+	}
+}
+
+// 获取请求头
+func (p *ElevenLabsProvider) GetRequestHeaders() (headers map[string]string) {
+	headers = make(map[string]string)
+	p.CommonRequestHeaders(headers)
+	headers["xi-api-key"] = p.Channel.Key
+
+	return headers
+}
@@ -0,0 +1,128 @@
+package elevenlabs
+
+import (
+	"bytes"
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/common/utils"
+	"done-hub/types"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// OpenAI 音色到 ElevenLabs 预置音色ID的默认映射
+var defaultVoiceMap = map[string]string{
+	"alloy":   "21m00Tcm4TlvDq8ikWAM", // Rachel
+	"echo":    "pNInz6obpgDQGcFmaJgB", // Adam
+	"fable":   "ErXwobaYiN019PkySvjV", // Antoni
+	"onyx":    "VR6AewLTigWG4xSOukaG", // Arnold
+	"nova":    "EXAVITQu4vr4xnSDxMaL", // Bella
+	"shimmer": "MF3mGyEYCl7XYWbV88XP", // Elli
+}
+
+var outputFormatMap = map[string]string{
+	"mp3":  "mp3_44100_128",
+	"opus": "opus_48000_128",
+	"pcm":  "pcm_24000",
+	"wav":  "pcm_24000",
+}
+
+const pcmSampleRate = 24000
+
+func (p *ElevenLabsProvider) GetVoiceMap() map[string]string {
+	voiceMap := make(map[string]string, len(defaultVoiceMap))
+	for key, value := range defaultVoiceMap {
+		voiceMap[key] = value
+	}
+
+	if p.Channel.Plugin == nil {
+		return voiceMap
+	}
+
+	customVoiceMapping, ok := p.Channel.Plugin.Data()["voice"]
+	if !ok {
+		return voiceMap
+	}
+
+	for key, value := range customVoiceMapping {
+		customVoiceValue, isString := value.(string)
+		if !isString || customVoiceValue == "" {
+			continue
+		}
+		voiceMap[key] = customVoiceValue
+	}
+
+	return voiceMap
+}
+
+func (p *ElevenLabsProvider) CreateSpeech(request *types.SpeechAudioRequest) (*http.Response, *types.OpenAIErrorWithStatusCode) {
+	uri, errWithCode := p.GetSupportedAPIUri(config.RelayModeAudioSpeech)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	voice := request.Voice
+	if voiceID, ok := p.GetVoiceMap()[voice]; ok {
+		voice = voiceID
+	}
+
+	responseFormat := request.ResponseFormat
+	if responseFormat == "" {
+		responseFormat = "mp3"
+	}
+	outputFormat, ok := outputFormatMap[responseFormat]
+	if !ok {
+		return nil, common.StringErrorWrapperLocal("response_format "+responseFormat+" is not supported", "invalid_request_error", http.StatusBadRequest)
+	}
+
+	fullRequestURL := p.GetFullRequestURL(fmt.Sprintf(uri, url.PathEscape(voice)), request.Model)
+	fullRequestURL += "?output_format=" + outputFormat
+	headers := p.GetRequestHeaders()
+
+	speechRequest := &SpeechRequest{
+		Text:    request.Input,
+		ModelID: request.Model,
+	}
+	if request.Speed != 0 {
+		speechRequest.VoiceSettings = &VoiceSettings{Speed: request.Speed}
+	}
+
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(speechRequest), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	resp, errWithCode := p.Requester.SendRequestRaw(req)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	p.Usage.TotalTokens = p.Usage.PromptTokens
+
+	if responseFormat != "wav" {
+		return resp, nil
+	}
+
+	// ElevenLabs 不直接支持 wav，需要将 PCM 数据封装
+	defer resp.Body.Close()
+	pcm, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "read_response_body_failed", http.StatusInternalServerError)
+	}
+	audio := utils.PCM16ToWav(pcm, pcmSampleRate)
+
+	response := &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(audio)),
+		Header:     make(http.Header),
+	}
+	response.Header.Set("Content-Type", "audio/wav")
+	response.Header.Set("Content-Length", strconv.Itoa(len(audio)))
+
+	return response, nil
+}
@@ -0,0 +1,119 @@
+package elevenlabs
+
+import (
+	"bytes"
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/common/requester"
+	"done-hub/types"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+func (p *ElevenLabsProvider) CreateTranscriptions(request *types.AudioRequest) (*types.AudioResponseWrapper, *types.OpenAIErrorWithStatusCode) {
+	switch request.ResponseFormat {
+	case "", "json", "text", "verbose_json":
+	default:
+		return nil, common.StringErrorWrapperLocal("response_format "+request.ResponseFormat+" is not supported", "invalid_request_error", http.StatusBadRequest)
+	}
+
+	uri, errWithCode := p.GetSupportedAPIUri(config.RelayModeAudioTranscription)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	fullRequestURL := p.GetFullRequestURL(uri, request.Model)
+	headers := p.GetRequestHeaders()
+
+	var formBody bytes.Buffer
+	builder := p.Requester.CreateFormBuilder(&formBody)
+	if err := transcriptionMultipartForm(request, builder); err != nil {
+		return nil, common.ErrorWrapper(err, "create_form_builder_failed", http.StatusInternalServerError)
+	}
+
+	req, err := p.Requester.NewRequest(
+		http.MethodPost,
+		fullRequestURL,
+		p.Requester.WithBody(&formBody),
+		p.Requester.WithHeader(headers),
+		p.Requester.WithContentType(builder.FormDataContentType()))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	req.ContentLength = int64(formBody.Len())
+	defer req.Body.Close()
+
+	transcriptionResponse := &TranscriptionResponse{}
+	_, errWithCode = p.Requester.SendRequest(req, transcriptionResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	if openaiErr := errorHandle(&transcriptionResponse.ElevenLabsError); openaiErr != nil {
+		return nil, &types.OpenAIErrorWithStatusCode{
+			OpenAIError: *openaiErr,
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+
+	var duration float64
+	if len(transcriptionResponse.Words) > 0 {
+		duration = transcriptionResponse.Words[len(transcriptionResponse.Words)-1].End
+	}
+
+	audioResponseWrapper := &types.AudioResponseWrapper{
+		Text:     transcriptionResponse.Text,
+		Duration: duration,
+	}
+
+	switch request.ResponseFormat {
+	case "text":
+		audioResponseWrapper.Headers = map[string]string{"Content-Type": "text/plain; charset=utf-8"}
+		audioResponseWrapper.Body = []byte(transcriptionResponse.Text)
+	default:
+		audioResponse := &types.AudioResponse{Text: transcriptionResponse.Text}
+		if request.ResponseFormat == "verbose_json" {
+			audioResponse.Task = "transcribe"
+			audioResponse.Language = transcriptionResponse.LanguageCode
+			audioResponse.Duration = duration
+			for _, word := range transcriptionResponse.Words {
+				if word.Type != "word" {
+					continue
+				}
+				audioResponse.Words = append(audioResponse.Words, types.AudioWordsList{
+					Word:  word.Text,
+					Start: word.Start,
+					End:   word.End,
+				})
+			}
+		}
+		audioResponseWrapper.Headers = map[string]string{"Content-Type": "application/json"}
+		audioResponseWrapper.Body, _ = json.Marshal(audioResponse)
+	}
+
+	p.Usage.CompletionTokens = common.CountTokenText(transcriptionResponse.Text, request.Model)
+	p.Usage.TotalTokens = p.Usage.PromptTokens + p.Usage.CompletionTokens
+
+	return audioResponseWrapper, nil
+}
+
+func transcriptionMultipartForm(request *types.AudioRequest, b requester.FormBuilder) error {
+	err := b.CreateFormFile("file", request.File)
+	if err != nil {
+		return fmt.Errorf("creating form file: %w", err)
+	}
+
+	err = b.WriteField("model_id", request.Model)
+	if err != nil {
+		return fmt.Errorf("writing model name: %w", err)
+	}
+
+	if request.Language != "" {
+		err = b.WriteField("language_code", request.Language)
+		if err != nil {
+			return fmt.Errorf("writing language: %w", err)
+		}
+	}
+
+	return b.Close()
+}
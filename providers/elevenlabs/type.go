@@ -0,0 +1,29 @@
+package elevenlabs
+
+type ElevenLabsError struct {
+	Detail any `json:"detail,omitempty"`
+}
+
+type SpeechRequest struct {
+	Text          string         `json:"text"`
+	ModelID       string         `json:"model_id"`
+	VoiceSettings *VoiceSettings `json:"voice_settings,omitempty"`
+}
+
+type VoiceSettings struct {
+	Speed float64 `json:"speed,omitempty"`
+}
+
+type TranscriptionResponse struct {
+	LanguageCode string              `json:"language_code"`
+	Text         string              `json:"text"`
+	Words        []TranscriptionWord `json:"words,omitempty"`
+	ElevenLabsError
+}
+
+type TranscriptionWord struct {
+	Text  string  `json:"text"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Type  string  `json:"type"`
+}
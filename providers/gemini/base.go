@@ -67,6 +67,7 @@ func getConfig(version string) base.ProviderConfig {
 		ChatCompletions:   fmt.Sprintf("/%s/chat/completions", version),
 		ModelList:         "/models",
 		ImagesGenerations: "1",
+		ChatRealtime:      "/ws/google.ai.generativelanguage.%s.GenerativeService.BidiGenerateContent",
 	}
 }
 
@@ -0,0 +1,68 @@
+package gemini
+
+import (
+	"done-hub/common"
+	"done-hub/types"
+	"net/http"
+)
+
+func (p *GeminiProvider) CreateEmbeddings(request *types.EmbeddingRequest) (*types.EmbeddingResponse, *types.OpenAIErrorWithStatusCode) {
+	inputs := request.ParseInput()
+	if len(inputs) == 0 {
+		return nil, common.StringErrorWrapperLocal("input is required", "invalid_request_error", http.StatusBadRequest)
+	}
+
+	batchRequest := &GeminiBatchEmbeddingRequest{
+		Requests: make([]GeminiEmbeddingRequest, 0, len(inputs)),
+	}
+	for _, input := range inputs {
+		batchRequest.Requests = append(batchRequest.Requests, GeminiEmbeddingRequest{
+			Model: "models/" + request.Model,
+			Content: GeminiChatContent{
+				Parts: []GeminiPart{{Text: input}},
+			},
+			OutputDimensionality: request.Dimensions,
+		})
+	}
+
+	fullRequestURL := p.GetFullRequestURL("batchEmbedContents", request.Model)
+	headers := p.GetRequestHeaders()
+
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(batchRequest), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	geminiResponse := &GeminiBatchEmbeddingResponse{}
+	_, errWithCode := p.Requester.SendRequest(req, geminiResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	if openaiErr := errorHandle(&geminiResponse.GeminiErrorResponse, p.Channel.Key); openaiErr != nil {
+		return nil, &types.OpenAIErrorWithStatusCode{
+			OpenAIError: *openaiErr,
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+
+	response := &types.EmbeddingResponse{
+		Object: "list",
+		Model:  request.Model,
+		Data:   make([]types.Embedding, 0, len(geminiResponse.Embeddings)),
+	}
+	for index, embedding := range geminiResponse.Embeddings {
+		response.Data = append(response.Data, types.Embedding{
+			Object:    "embedding",
+			Embedding: embedding.Values,
+			Index:     index,
+		})
+	}
+
+	// Gemini 不返回用量，使用本地计算的 token 数
+	p.Usage.TotalTokens = p.Usage.PromptTokens
+	response.Usage = p.Usage
+
+	return response, nil
+}
@@ -0,0 +1,189 @@
+package gemini
+
+import (
+	"done-hub/common"
+	"done-hub/common/storage"
+	"done-hub/common/utils"
+	"done-hub/types"
+	"encoding/base64"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+type ImageContentSender func(*GeminiChatRequest) (*GeminiChatResponse, *types.OpenAIErrorWithStatusCode)
+
+// Gemini 原生模型通过 generateContent 输出图片，imagen 系列模型使用 predict
+func IsGeminiImageModel(modelName string) bool {
+	return strings.HasPrefix(modelName, "gemini")
+}
+
+// GetInlineImageURL 将 inline 图片上传到存储，未配置存储或上传失败时返回 data URL
+func GetInlineImageURL(inlineData *GeminiInlineData) string {
+	imageData, err := base64.StdEncoding.DecodeString(inlineData.Data)
+	if err == nil {
+		url := storage.Upload(imageData, utils.GetUUID()+getImageExtension(inlineData.MimeType))
+		if url != "" {
+			return url
+		}
+	}
+
+	return "data:" + inlineData.MimeType + ";base64," + inlineData.Data
+}
+
+func getImageExtension(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/webp":
+		return ".webp"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ".png"
+	}
+}
+
+// BuildImageChatRequest 构建图片生成/编辑请求，images 为需要编辑的参考图
+func BuildImageChatRequest(modelName, prompt string, images []*GeminiInlineData, aspectRatio string) *GeminiChatRequest {
+	parts := make([]GeminiPart, 0, len(images)+1)
+	for _, image := range images {
+		parts = append(parts, GeminiPart{InlineData: image})
+	}
+	parts = append(parts, GeminiPart{Text: prompt})
+
+	geminiRequest := &GeminiChatRequest{
+		Model: modelName,
+		Contents: []GeminiChatContent{
+			{
+				Role:  "user",
+				Parts: parts,
+			},
+		},
+		GenerationConfig: GeminiChatGenerationConfig{
+			ResponseModalities: []string{ModalityTEXT, ModalityIMAGE},
+		},
+	}
+
+	if aspectRatio != "" {
+		geminiRequest.GenerationConfig.ImageConfig = &GeminiImageConfig{AspectRatio: aspectRatio}
+	}
+
+	return geminiRequest
+}
+
+// BuildImageEditChatRequest 将 OpenAI 的图片编辑请求转换为 Gemini 请求，支持多张输入图片
+func BuildImageEditChatRequest(request *types.ImageEditRequest) (*GeminiChatRequest, *types.OpenAIErrorWithStatusCode) {
+	files := make([]*multipart.FileHeader, 0, len(request.Images)+1)
+	if request.Image != nil {
+		files = append(files, request.Image)
+	}
+	files = append(files, request.Images...)
+	if len(files) == 0 {
+		return nil, common.StringErrorWrapperLocal("field image is required", "invalid_request_error", http.StatusBadRequest)
+	}
+
+	prompt := request.Prompt
+	if request.Mask != nil {
+		// Gemini 不支持蒙版，作为最后一张参考图并通过提示词说明
+		files = append(files, request.Mask)
+		prompt += "\n\nThe last image is a mask: only edit the transparent area of the mask on the first image, keep everything else unchanged."
+	}
+
+	images := make([]*GeminiInlineData, 0, len(files))
+	for _, file := range files {
+		inlineData, err := fileToInlineData(file)
+		if err != nil {
+			return nil, common.ErrorWrapperLocal(err, "read_image_failed", http.StatusBadRequest)
+		}
+		images = append(images, inlineData)
+	}
+
+	return BuildImageChatRequest(request.Model, prompt, images, ""), nil
+}
+
+func fileToInlineData(fileHeader *multipart.FileHeader) (*GeminiInlineData, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType := fileHeader.Header.Get("Content-Type")
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+	}
+
+	return &GeminiInlineData{
+		MimeType: mimeType,
+		Data:     base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+// CreateImagesByContent 按 n 多次调用 generateContent，并转换为 OpenAI 图片响应
+func CreateImagesByContent(geminiRequest *GeminiChatRequest, n int, responseFormat string, usage *types.Usage, send ImageContentSender) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	n = max(n, 1)
+
+	openaiResponse := &types.ImageResponse{
+		Created: utils.GetTimestamp(),
+		Data:    make([]types.ImageResponseDataInner, 0, n),
+	}
+
+	promptTokens := 0
+	completionTokens := 0
+	for i := 0; i < n; i++ {
+		geminiResponse, errWithCode := send(geminiRequest)
+		if errWithCode != nil {
+			return nil, errWithCode
+		}
+
+		var revisedPrompt []string
+		for _, candidate := range geminiResponse.Candidates {
+			for _, part := range candidate.Content.Parts {
+				if part.InlineData != nil && strings.HasPrefix(part.InlineData.MimeType, "image/") {
+					openaiResponse.Data = append(openaiResponse.Data, convertInlineImage(part.InlineData, responseFormat))
+				} else if part.Text != "" && !part.Thought {
+					revisedPrompt = append(revisedPrompt, part.Text)
+				}
+			}
+		}
+		// 模型附带的文字说明放到最后一张图片的 revised_prompt 中
+		if len(revisedPrompt) > 0 && len(openaiResponse.Data) > 0 {
+			openaiResponse.Data[len(openaiResponse.Data)-1].RevisedPrompt = strings.Join(revisedPrompt, "\n")
+		}
+
+		if geminiResponse.UsageMetadata != nil {
+			promptTokens += geminiResponse.UsageMetadata.PromptTokenCount
+			completionTokens += geminiResponse.UsageMetadata.CandidatesTokenCount + geminiResponse.UsageMetadata.ThoughtsTokenCount
+		}
+	}
+
+	imageCount := len(openaiResponse.Data)
+	if imageCount == 0 {
+		return nil, common.StringErrorWrapper("no image generated", "no_image_generated", http.StatusInternalServerError)
+	}
+
+	if completionTokens > 0 {
+		usage.PromptTokens = promptTokens
+		usage.CompletionTokens = completionTokens
+	} else {
+		usage.CompletionTokens = imageCount * 258
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	return openaiResponse, nil
+}
+
+func convertInlineImage(inlineData *GeminiInlineData, responseFormat string) types.ImageResponseDataInner {
+	if responseFormat == "url" {
+		return types.ImageResponseDataInner{URL: GetInlineImageURL(inlineData)}
+	}
+
+	return types.ImageResponseDataInner{B64JSON: inlineData.Data}
+}
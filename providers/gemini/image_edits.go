@@ -0,0 +1,20 @@
+package gemini
+
+import (
+	"done-hub/common"
+	"done-hub/types"
+	"net/http"
+)
+
+func (p *GeminiProvider) CreateImageEdits(request *types.ImageEditRequest) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	if !IsGeminiImageModel(request.Model) {
+		return nil, common.StringErrorWrapperLocal("model "+request.Model+" does not support image edits", "invalid_request_error", http.StatusBadRequest)
+	}
+
+	geminiRequest, errWithCode := BuildImageEditChatRequest(request)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	return CreateImagesByContent(geminiRequest, request.N, request.ResponseFormat, p.GetUsage(), p.sendGenerateContent)
+}
@@ -8,6 +8,18 @@ import (
 )
 
 func (p *GeminiProvider) CreateImageGenerations(request *types.ImageRequest) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	if IsGeminiImageModel(request.Model) {
+		aspectRatio := ""
+		if request.AspectRatio != nil {
+			aspectRatio = *request.AspectRatio
+		} else if request.Size != "" {
+			aspectRatio = getImageAspectRatio(request.Size)
+		}
+		geminiRequest := BuildImageChatRequest(request.Model, request.Prompt, nil, aspectRatio)
+
+		return CreateImagesByContent(geminiRequest, request.N, request.ResponseFormat, p.GetUsage(), p.sendGenerateContent)
+	}
+
 	// 创建动态参数map
 	parameters := make(GeminiImageParametersDynamic)
 	parameters["sampleCount"] = request.N
@@ -35,14 +47,7 @@ func (p *GeminiProvider) CreateImageGenerations(request *types.ImageRequest) (*t
 	if request.AspectRatio != nil {
 		parameters["aspectRatio"] = *request.AspectRatio
 	} else {
-		switch request.Size {
-		case "1024x1792":
-			parameters["aspectRatio"] = "9:16"
-		case "1792x1024":
-			parameters["aspectRatio"] = "16:9"
-		default:
-			parameters["aspectRatio"] = "1:1"
-		}
+		parameters["aspectRatio"] = getImageAspectRatio(request.Size)
 	}
 
 	fullRequestURL := p.GetFullRequestURL("predict", request.Model)
@@ -91,3 +96,19 @@ func (p *GeminiProvider) CreateImageGenerations(request *types.ImageRequest) (*t
 
 	return openaiResponse, nil
 }
+
+// 将 OpenAI 的尺寸转换为比例
+func getImageAspectRatio(size string) string {
+	switch size {
+	case "1024x1792":
+		return "9:16"
+	case "1792x1024":
+		return "16:9"
+	case "1024x1536":
+		return "2:3"
+	case "1536x1024":
+		return "3:2"
+	default:
+		return "1:1"
+	}
+}
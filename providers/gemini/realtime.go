@@ -0,0 +1,427 @@
+package gemini
+
+import (
+	"bytes"
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/common/requester"
+	"done-hub/common/utils"
+	"done-hub/types"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+const geminiLiveInputAudioMimeType = "audio/pcm;rate=24000"
+
+// CreateChatRealtime 通过 Gemini Live API 提供 OpenAI Realtime 协议
+// Gemini 的 setup 只能在建立连接时发送，语音名称通过 model#voice 的方式传入
+func (p *GeminiProvider) CreateChatRealtime(modelName string) (*websocket.Conn, requester.MessageHandler, *types.OpenAIErrorWithStatusCode) {
+	url, errWithCode := p.GetSupportedAPIUri(config.RelayModeChatRealtime)
+	if errWithCode != nil {
+		return nil, nil, errWithCode
+	}
+
+	version := "v1beta"
+	if p.Channel.Other != "" {
+		version = p.Channel.Other
+	}
+
+	baseURL := strings.TrimSuffix(p.GetBaseURL(), "/")
+	baseURL = strings.Replace(baseURL, "https://", "wss://", 1)
+	baseURL = strings.Replace(baseURL, "http://", "ws://", 1)
+	fullRequestURL := fmt.Sprintf("%s%s", baseURL, fmt.Sprintf(url, version))
+
+	httpHeaders := make(http.Header)
+	httpHeaders.Set("x-goog-api-key", p.Channel.Key)
+
+	wsRequester := requester.NewWSRequester(*p.Channel.Proxy)
+	wsConn, err := wsRequester.NewRequest(fullRequestURL, httpHeaders)
+	if err != nil {
+		return nil, nil, common.ErrorWrapper(err, "ws_request_failed", http.StatusInternalServerError)
+	}
+
+	setup := &GeminiLiveSetup{
+		Model: "models/" + modelName,
+		GenerationConfig: &GeminiChatGenerationConfig{
+			ResponseModalities: []string{ModalityAUDIO},
+		},
+		InputAudioTranscription:  &struct{}{},
+		OutputAudioTranscription: &struct{}{},
+	}
+	if p.OtherArg != "" {
+		setup.GenerationConfig.SpeechConfig = &GeminiSpeechConfig{
+			VoiceConfig: &GeminiVoiceConfig{
+				PrebuiltVoiceConfig: &GeminiPrebuiltVoiceConfig{VoiceName: p.OtherArg},
+			},
+		}
+	}
+
+	if err := wsConn.WriteJSON(&GeminiLiveClientMessage{Setup: setup}); err != nil {
+		wsConn.Close()
+		return nil, nil, common.ErrorWrapper(err, "ws_request_failed", http.StatusInternalServerError)
+	}
+
+	session := &geminiLiveSession{
+		modelName: modelName,
+		voice:     p.OtherArg,
+	}
+
+	return wsConn, session.HandleMessage, nil
+}
+
+// geminiLiveSession 保存单个连接的转换状态
+// Gemini 在一个回合内可能多次返回 usageMetadata，只保留最新的一份，在回合结束或连接关闭时计费一次
+type geminiLiveSession struct {
+	mu         sync.Mutex
+	modelName  string
+	voice      string
+	responseId string
+	itemId     string
+	transcript strings.Builder
+	text       strings.Builder
+	usage      *types.UsageEvent
+	// 回合已结束但还没有收到用量
+	awaitUsage bool
+}
+
+func (s *geminiLiveSession) HandleMessage(source requester.MessageSource, messageType int, message []byte) (bool, *types.UsageEvent, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch source {
+	case requester.UserMessage:
+		return s.handleUserMessage(message)
+	case requester.SessionClosed:
+		usage := s.usage
+		s.usage = nil
+		return false, usage, nil, nil
+	}
+
+	return s.handleSupplierMessage(message)
+}
+
+func (s *geminiLiveSession) handleUserMessage(message []byte) (bool, *types.UsageEvent, []byte, error) {
+	var event types.RealtimeClientEvent
+	if err := json.Unmarshal(message, &event); err != nil {
+		return true, nil, nil, types.NewErrorEvent("", "invalid_request_error", "invalid_event", err.Error())
+	}
+
+	var clientMessage *GeminiLiveClientMessage
+
+	switch event.Type {
+	case types.EventTypeInputAudioBufferAppend:
+		clientMessage = &GeminiLiveClientMessage{
+			RealtimeInput: &GeminiLiveRealtimeInput{
+				Audio: &GeminiInlineData{MimeType: geminiLiveInputAudioMimeType, Data: event.Audio},
+			},
+		}
+	case types.EventTypeInputAudioBufferCommit:
+		clientMessage = &GeminiLiveClientMessage{
+			RealtimeInput: &GeminiLiveRealtimeInput{AudioStreamEnd: true},
+		}
+	case types.EventTypeSessionUpdate:
+		// setup 无法在会话中修改，将 instructions 作为上下文发送
+		if event.Session == nil || event.Session.Instructions == "" {
+			return true, nil, []byte{}, nil
+		}
+		clientMessage = &GeminiLiveClientMessage{
+			ClientContent: &GeminiLiveClientContent{
+				Turns: []GeminiChatContent{{
+					Role:  "user",
+					Parts: []GeminiPart{{Text: event.Session.Instructions}},
+				}},
+			},
+		}
+	case types.EventTypeItemCreate:
+		clientMessage = s.convertItem(event.Item)
+	case types.EventTypeResponseCreate:
+		clientMessage = &GeminiLiveClientMessage{
+			ClientContent: &GeminiLiveClientContent{TurnComplete: true},
+		}
+	}
+
+	if clientMessage == nil {
+		return true, nil, []byte{}, nil
+	}
+
+	newMessage, err := json.Marshal(clientMessage)
+	if err != nil {
+		return true, nil, nil, types.NewErrorEvent("", "system_error", "json_marshal_failed", err.Error())
+	}
+
+	return true, nil, newMessage, nil
+}
+
+func (s *geminiLiveSession) convertItem(item *types.RealtimeItem) *GeminiLiveClientMessage {
+	if item == nil {
+		return nil
+	}
+
+	if item.Type == "function_call_output" {
+		response := map[string]any{}
+		if err := json.Unmarshal([]byte(item.Output), &response); err != nil {
+			response = map[string]any{"output": item.Output}
+		}
+
+		return &GeminiLiveClientMessage{
+			ToolResponse: &GeminiLiveToolResponse{
+				FunctionResponses: []GeminiLiveFunctionResponse{{
+					Id:       item.CallId,
+					Name:     item.Name,
+					Response: response,
+				}},
+			},
+		}
+	}
+
+	parts := make([]GeminiPart, 0, len(item.Content))
+	for _, content := range item.Content {
+		switch content.Type {
+		case "input_text", "text":
+			parts = append(parts, GeminiPart{Text: content.Text})
+		case "input_audio":
+			parts = append(parts, GeminiPart{InlineData: &GeminiInlineData{MimeType: geminiLiveInputAudioMimeType, Data: content.Audio}})
+		}
+	}
+
+	if len(parts) == 0 {
+		return nil
+	}
+
+	return &GeminiLiveClientMessage{
+		ClientContent: &GeminiLiveClientContent{
+			Turns: []GeminiChatContent{{Role: ConvertRole(item.Role), Parts: parts}},
+		},
+	}
+}
+
+func (s *geminiLiveSession) handleSupplierMessage(message []byte) (bool, *types.UsageEvent, []byte, error) {
+	var serverMessage GeminiLiveServerMessage
+	if err := json.Unmarshal(message, &serverMessage); err != nil {
+		return true, nil, nil, types.NewErrorEvent("", "system_error", "json_unmarshal_failed", err.Error())
+	}
+
+	if serverMessage.ErrorInfo != nil {
+		return false, nil, nil, types.NewErrorEvent("", "gemini_error", serverMessage.ErrorInfo.Status, serverMessage.ErrorInfo.Message)
+	}
+
+	events := make([]*types.RealtimeServerEvent, 0)
+
+	if serverMessage.SetupComplete != nil {
+		event := types.NewRealtimeServerEvent(types.EventTypeSessionCreated)
+		event.Session = &types.RealtimeSession{
+			Model:             s.modelName,
+			Modalities:        []string{"text", "audio"},
+			Voice:             s.voice,
+			InputAudioFormat:  "pcm16",
+			OutputAudioFormat: "pcm16",
+		}
+		events = append(events, event)
+	}
+
+	var usage *types.UsageEvent
+	if serverMessage.UsageMetadata != nil {
+		if s.awaitUsage && s.responseId == "" {
+			// 回合结束后才送达的用量属于上一个回合，直接计费
+			usage = serverMessage.UsageMetadata.ToUsageEvent()
+			s.awaitUsage = false
+		} else {
+			s.usage = serverMessage.UsageMetadata.ToUsageEvent()
+		}
+	}
+
+	if content := serverMessage.ServerContent; content != nil {
+		if content.Interrupted {
+			events = append(events, types.NewRealtimeServerEvent(types.EventTypeSpeechStarted))
+		}
+
+		if content.InputTranscription != nil && content.InputTranscription.Text != "" {
+			event := types.NewRealtimeServerEvent(types.EventTypeTranscriptionDelta)
+			event.Delta = content.InputTranscription.Text
+			events = append(events, event)
+		}
+
+		if content.ModelTurn != nil {
+			events = append(events, s.convertModelTurn(content.ModelTurn)...)
+		}
+
+		if content.OutputTranscription != nil && content.OutputTranscription.Text != "" {
+			events = append(events, s.startResponse()...)
+			s.transcript.WriteString(content.OutputTranscription.Text)
+			event := types.NewRealtimeServerEvent(types.EventTypeAudioTranscriptDelta).SetIndex(s.responseId, s.itemId, 0, 0)
+			event.Delta = content.OutputTranscription.Text
+			events = append(events, event)
+		}
+	}
+
+	if serverMessage.ToolCall != nil {
+		events = append(events, s.startResponse()...)
+		for _, call := range serverMessage.ToolCall.FunctionCalls {
+			args, _ := json.Marshal(call.Args)
+			event := types.NewRealtimeServerEvent(types.EventTypeFunctionArgumentsDone).SetIndex(s.responseId, s.itemId, 0, 0)
+			event.CallId = call.Id
+			event.Name = call.Name
+			event.Arguments = string(args)
+			events = append(events, event)
+		}
+	}
+
+	if serverMessage.ServerContent != nil && serverMessage.ServerContent.TurnComplete {
+		usage = s.usage
+		s.usage = nil
+		events = append(events, s.finishResponse(usage)...)
+		s.awaitUsage = usage == nil
+	} else if serverMessage.ToolCall != nil {
+		// 工具调用后回合还会继续，用量等到回合结束时计费
+		events = append(events, s.finishResponse(nil)...)
+	}
+
+	return true, usage, joinRealtimeEvents(events), nil
+}
+
+func (s *geminiLiveSession) convertModelTurn(turn *GeminiChatContent) []*types.RealtimeServerEvent {
+	events := make([]*types.RealtimeServerEvent, 0)
+	var audio []byte
+
+	for _, part := range turn.Parts {
+		if part.Thought {
+			continue
+		}
+
+		if part.InlineData != nil && strings.HasPrefix(part.InlineData.MimeType, "audio/") {
+			data, err := base64.StdEncoding.DecodeString(part.InlineData.Data)
+			if err == nil {
+				audio = append(audio, data...)
+			}
+			continue
+		}
+
+		if part.Text != "" {
+			events = append(events, s.startResponse()...)
+			s.text.WriteString(part.Text)
+			event := types.NewRealtimeServerEvent(types.EventTypeTextDelta).SetIndex(s.responseId, s.itemId, 0, 0)
+			event.Delta = part.Text
+			events = append(events, event)
+		}
+	}
+
+	if len(audio) > 0 {
+		events = append(events, s.startResponse()...)
+		event := types.NewRealtimeServerEvent(types.EventTypeAudioDelta).SetIndex(s.responseId, s.itemId, 0, 0)
+		event.Delta = base64.StdEncoding.EncodeToString(audio)
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func (s *geminiLiveSession) startResponse() []*types.RealtimeServerEvent {
+	if s.responseId != "" {
+		return nil
+	}
+
+	s.awaitUsage = false
+
+	s.responseId = "resp_" + utils.GetRandomString(16)
+	s.itemId = "item_" + utils.GetRandomString(16)
+
+	created := types.NewRealtimeServerEvent(types.EventTypeResponseCreated)
+	created.Response = &types.ResponseEvent{ID: s.responseId, Object: "realtime.response", Status: "in_progress"}
+
+	itemAdded := types.NewRealtimeServerEvent(types.EventTypeOutputItemAdded)
+	itemAdded.ResponseId = s.responseId
+	itemAdded.OutputIndex = utils.GetPointer(0)
+	itemAdded.Item = &types.RealtimeItem{ID: s.itemId, Object: "realtime.item", Type: "message", Status: "in_progress", Role: types.ChatMessageRoleAssistant}
+
+	return []*types.RealtimeServerEvent{created, itemAdded}
+}
+
+func (s *geminiLiveSession) finishResponse(usage *types.UsageEvent) []*types.RealtimeServerEvent {
+	events := s.startResponse()
+
+	content := types.RealtimeContent{Type: "audio", Transcript: s.transcript.String()}
+	if s.text.Len() > 0 {
+		content = types.RealtimeContent{Type: "text", Text: s.text.String()}
+	}
+	item := types.RealtimeItem{
+		ID:      s.itemId,
+		Object:  "realtime.item",
+		Type:    "message",
+		Status:  "completed",
+		Role:    types.ChatMessageRoleAssistant,
+		Content: []types.RealtimeContent{content},
+	}
+
+	itemDone := types.NewRealtimeServerEvent(types.EventTypeOutputItemDone)
+	itemDone.ResponseId = s.responseId
+	itemDone.OutputIndex = utils.GetPointer(0)
+	itemDone.Item = &item
+
+	done := types.NewRealtimeServerEvent(types.EventTypeResponseDone)
+	done.Response = &types.ResponseEvent{
+		ID:     s.responseId,
+		Object: "realtime.response",
+		Status: "completed",
+		Output: []types.RealtimeItem{item},
+		Usage:  usage,
+	}
+
+	s.responseId = ""
+	s.itemId = ""
+	s.text.Reset()
+	s.transcript.Reset()
+
+	return append(events, itemDone, done)
+}
+
+func joinRealtimeEvents(events []*types.RealtimeServerEvent) []byte {
+	if len(events) == 0 {
+		return []byte{}
+	}
+
+	var buffer bytes.Buffer
+	for _, event := range events {
+		buffer.Write(event.Bytes())
+		buffer.WriteByte('\n')
+	}
+
+	return buffer.Bytes()
+}
+
+func (u *GeminiLiveUsageMetadata) ToUsageEvent() *types.UsageEvent {
+	usage := &types.UsageEvent{
+		InputTokens:  u.PromptTokenCount,
+		OutputTokens: u.ResponseTokenCount + u.ThoughtsTokenCount,
+		TotalTokens:  u.TotalTokenCount,
+	}
+
+	for _, detail := range u.PromptTokensDetails {
+		switch detail.Modality {
+		case ModalityAUDIO:
+			usage.InputTokenDetails.AudioTokens += detail.TokenCount
+		case ModalityTEXT:
+			usage.InputTokenDetails.TextTokens += detail.TokenCount
+		}
+	}
+
+	for _, detail := range u.ResponseTokensDetails {
+		switch detail.Modality {
+		case ModalityAUDIO:
+			usage.OutputTokenDetails.AudioTokens += detail.TokenCount
+		case ModalityTEXT:
+			usage.OutputTokenDetails.TextTokens += detail.TokenCount
+		}
+	}
+
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	}
+
+	return usage
+}
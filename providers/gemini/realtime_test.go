@@ -0,0 +1,202 @@
+package gemini
+
+import (
+	"bytes"
+	"done-hub/common/requester"
+	"done-hub/types"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// handleServer 发送上游消息，返回转换后的事件和计费的用量
+func handleServer(t *testing.T, s *geminiLiveSession, message string) ([]types.RealtimeServerEvent, *types.UsageEvent) {
+	shouldContinue, usage, newMessage, err := s.HandleMessage(requester.SupplierMessage, websocket.TextMessage, []byte(message))
+	assert.Nil(t, err)
+	assert.True(t, shouldContinue)
+	assert.NotNil(t, newMessage)
+
+	events := make([]types.RealtimeServerEvent, 0)
+	for _, line := range bytes.Split(newMessage, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var event types.RealtimeServerEvent
+		assert.Nil(t, json.Unmarshal(line, &event))
+		events = append(events, event)
+	}
+	return events, usage
+}
+
+func eventTypes(events []types.RealtimeServerEvent) []string {
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		names = append(names, event.Type)
+	}
+	return names
+}
+
+func usageMetadata(prompt, response int) string {
+	return fmt.Sprintf(`"usageMetadata":{"promptTokenCount":%d,"responseTokenCount":%d,"totalTokenCount":%d}`, prompt, response, prompt+response)
+}
+
+func TestGeminiLiveSessionEvents(t *testing.T) {
+	s := &geminiLiveSession{modelName: "gemini-live", voice: "Puck"}
+
+	events, usage := handleServer(t, s, `{"setupComplete":{}}`)
+	assert.Nil(t, usage)
+	assert.Equal(t, []string{types.EventTypeSessionCreated}, eventTypes(events))
+	assert.Equal(t, "gemini-live", events[0].Session.Model)
+	assert.Equal(t, "Puck", events[0].Session.Voice)
+
+	events, _ = handleServer(t, s, `{"serverContent":{"inputTranscription":{"text":"hi"}}}`)
+	assert.Equal(t, []string{types.EventTypeTranscriptionDelta}, eventTypes(events))
+	assert.Equal(t, "hi", events[0].Delta)
+
+	// 第一次输出时创建响应，音频合并为一个 delta
+	audio := base64.StdEncoding.EncodeToString([]byte{1, 2})
+	events, _ = handleServer(t, s, `{"serverContent":{"modelTurn":{"parts":[{"inlineData":{"mimeType":"audio/pcm","data":"`+audio+`"}},{"inlineData":{"mimeType":"audio/pcm","data":"`+audio+`"}}]}}}`)
+	assert.Equal(t, []string{types.EventTypeResponseCreated, types.EventTypeOutputItemAdded, types.EventTypeAudioDelta}, eventTypes(events))
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte{1, 2, 1, 2}), events[2].Delta)
+	responseId := events[0].Response.ID
+	assert.Equal(t, responseId, events[2].ResponseId)
+
+	events, _ = handleServer(t, s, `{"serverContent":{"outputTranscription":{"text":"Hello"}}}`)
+	assert.Equal(t, []string{types.EventTypeAudioTranscriptDelta}, eventTypes(events))
+	assert.Equal(t, responseId, events[0].ResponseId)
+
+	events, _ = handleServer(t, s, `{"serverContent":{"turnComplete":true}}`)
+	assert.Equal(t, []string{types.EventTypeOutputItemDone, types.EventTypeResponseDone}, eventTypes(events))
+	assert.Equal(t, responseId, events[1].Response.ID)
+	assert.Equal(t, "Hello", events[1].Response.Output[0].Content[0].Transcript)
+
+	events, _ = handleServer(t, s, `{"toolCall":{"functionCalls":[{"id":"call_1","name":"get_weather","args":{"city":"Paris"}}]}}`)
+	assert.Equal(t, []string{types.EventTypeResponseCreated, types.EventTypeOutputItemAdded, types.EventTypeFunctionArgumentsDone, types.EventTypeOutputItemDone, types.EventTypeResponseDone}, eventTypes(events))
+	assert.Equal(t, "call_1", events[2].CallId)
+	assert.Equal(t, `{"city":"Paris"}`, events[2].Arguments)
+	assert.NotEqual(t, responseId, events[0].Response.ID)
+
+	events, _ = handleServer(t, s, `{"serverContent":{"interrupted":true}}`)
+	assert.Equal(t, []string{types.EventTypeSpeechStarted}, eventTypes(events))
+
+	shouldContinue, _, _, err := s.HandleMessage(requester.SupplierMessage, websocket.TextMessage, []byte(`{"error":{"code":400,"message":"bad","status":"INVALID_ARGUMENT"}}`))
+	assert.False(t, shouldContinue)
+	assert.NotNil(t, err)
+}
+
+func TestGeminiLiveSessionUserMessage(t *testing.T) {
+	s := &geminiLiveSession{}
+
+	handle := func(event string) *GeminiLiveClientMessage {
+		shouldContinue, usage, newMessage, err := s.HandleMessage(requester.UserMessage, websocket.TextMessage, []byte(event))
+		assert.Nil(t, err)
+		assert.True(t, shouldContinue)
+		assert.Nil(t, usage)
+		if len(newMessage) == 0 {
+			return nil
+		}
+		var message GeminiLiveClientMessage
+		assert.Nil(t, json.Unmarshal(newMessage, &message))
+		return &message
+	}
+
+	message := handle(`{"type":"input_audio_buffer.append","audio":"AAAA"}`)
+	assert.Equal(t, geminiLiveInputAudioMimeType, message.RealtimeInput.Audio.MimeType)
+	assert.Equal(t, "AAAA", message.RealtimeInput.Audio.Data)
+
+	message = handle(`{"type":"input_audio_buffer.commit"}`)
+	assert.True(t, message.RealtimeInput.AudioStreamEnd)
+
+	message = handle(`{"type":"conversation.item.create","item":{"type":"message","role":"user","content":[{"type":"input_text","text":"hi"}]}}`)
+	assert.Equal(t, "user", message.ClientContent.Turns[0].Role)
+	assert.Equal(t, "hi", message.ClientContent.Turns[0].Parts[0].Text)
+
+	message = handle(`{"type":"conversation.item.create","item":{"type":"function_call_output","call_id":"call_1","name":"get_weather","output":"sunny"}}`)
+	assert.Equal(t, "call_1", message.ToolResponse.FunctionResponses[0].Id)
+	assert.Equal(t, map[string]any{"output": "sunny"}, message.ToolResponse.FunctionResponses[0].Response)
+
+	message = handle(`{"type":"response.create"}`)
+	assert.True(t, message.ClientContent.TurnComplete)
+
+	// 没有 instructions 的 session.update 直接丢弃
+	assert.Nil(t, handle(`{"type":"session.update","session":{"voice":"alloy"}}`))
+}
+
+func TestGeminiLiveSessionUsage(t *testing.T) {
+	t.Run("billed once on turn complete", func(t *testing.T) {
+		s := &geminiLiveSession{}
+
+		// 回合开始前和进行中送达的用量只保留最新的一份
+		_, usage := handleServer(t, s, `{"serverContent":{"inputTranscription":{"text":"hi"}},`+usageMetadata(5, 0)+`}`)
+		assert.Nil(t, usage)
+		_, usage = handleServer(t, s, `{"serverContent":{"modelTurn":{"parts":[{"text":"Hel"}]}},`+usageMetadata(10, 2)+`}`)
+		assert.Nil(t, usage)
+		_, usage = handleServer(t, s, `{"serverContent":{"modelTurn":{"parts":[{"text":"lo"}]}},`+usageMetadata(10, 4)+`}`)
+		assert.Nil(t, usage)
+
+		events, usage := handleServer(t, s, `{"serverContent":{"turnComplete":true}}`)
+		assert.Equal(t, 10, usage.InputTokens)
+		assert.Equal(t, 4, usage.OutputTokens)
+		assert.Equal(t, usage, events[len(events)-1].Response.Usage)
+
+		// 已计费的回合在连接关闭时不再计费
+		_, usage, _, _ = s.HandleMessage(requester.SessionClosed, 0, nil)
+		assert.Nil(t, usage)
+	})
+
+	t.Run("usage after turn complete", func(t *testing.T) {
+		s := &geminiLiveSession{}
+
+		_, usage := handleServer(t, s, `{"serverContent":{"modelTurn":{"parts":[{"text":"Hello"}]}}}`)
+		assert.Nil(t, usage)
+		_, usage = handleServer(t, s, `{"serverContent":{"turnComplete":true}}`)
+		assert.Nil(t, usage)
+
+		// 回合结束后才送达的用量计入上一个回合
+		_, usage = handleServer(t, s, `{`+usageMetadata(10, 4)+`}`)
+		assert.Equal(t, 14, usage.TotalTokens)
+
+		// 下一个回合开始前的用量等到回合结束时计费
+		_, usage = handleServer(t, s, `{`+usageMetadata(3, 0)+`}`)
+		assert.Nil(t, usage)
+		_, usage = handleServer(t, s, `{"serverContent":{"modelTurn":{"parts":[{"text":"Hi"}]}},`+usageMetadata(8, 2)+`}`)
+		assert.Nil(t, usage)
+		_, usage = handleServer(t, s, `{"serverContent":{"turnComplete":true}}`)
+		assert.Equal(t, 10, usage.TotalTokens)
+	})
+
+	t.Run("tool call", func(t *testing.T) {
+		s := &geminiLiveSession{}
+
+		// 工具调用后回合继续，用量在回合结束时计费
+		events, usage := handleServer(t, s, `{"toolCall":{"functionCalls":[{"id":"call_1","name":"get_time"}]},`+usageMetadata(10, 2)+`}`)
+		assert.Nil(t, usage)
+		assert.Nil(t, events[len(events)-1].Response.Usage)
+
+		_, usage = handleServer(t, s, `{"serverContent":{"modelTurn":{"parts":[{"text":"noon"}]}},`+usageMetadata(20, 5)+`}`)
+		assert.Nil(t, usage)
+		_, usage = handleServer(t, s, `{"serverContent":{"turnComplete":true}}`)
+		assert.Equal(t, 25, usage.TotalTokens)
+	})
+
+	t.Run("session closed", func(t *testing.T) {
+		s := &geminiLiveSession{}
+
+		_, usage := handleServer(t, s, `{"serverContent":{"modelTurn":{"parts":[{"text":"Hel"}]}},`+usageMetadata(10, 2)+`}`)
+		assert.Nil(t, usage)
+
+		// 连接关闭时未结束的回合按最新的用量计费
+		shouldContinue, usage, newMessage, err := s.HandleMessage(requester.SessionClosed, 0, nil)
+		assert.False(t, shouldContinue)
+		assert.Nil(t, newMessage)
+		assert.Nil(t, err)
+		assert.Equal(t, 12, usage.TotalTokens)
+
+		_, usage, _, _ = s.HandleMessage(requester.SessionClosed, 0, nil)
+		assert.Nil(t, usage)
+	})
+}
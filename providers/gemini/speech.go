@@ -0,0 +1,139 @@
+package gemini
+
+import (
+	"bytes"
+	"done-hub/common"
+	"done-hub/common/utils"
+	"done-hub/types"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// OpenAI 音色到 Gemini 预置音色的默认映射
+var speechVoiceMap = map[string]string{
+	"alloy":   "Kore",
+	"echo":    "Puck",
+	"fable":   "Fenrir",
+	"onyx":    "Charon",
+	"nova":    "Aoede",
+	"shimmer": "Leda",
+}
+
+const defaultSpeechSampleRate = 24000
+
+func (p *GeminiProvider) CreateSpeech(request *types.SpeechAudioRequest) (*http.Response, *types.OpenAIErrorWithStatusCode) {
+	voice := request.Voice
+	if mapped, ok := speechVoiceMap[voice]; ok {
+		voice = mapped
+	}
+
+	geminiRequest := &GeminiChatRequest{
+		Model: request.Model,
+		Contents: []GeminiChatContent{
+			{
+				Role:  "user",
+				Parts: []GeminiPart{{Text: request.Input}},
+			},
+		},
+		GenerationConfig: GeminiChatGenerationConfig{
+			ResponseModalities: []string{ModalityAUDIO},
+			SpeechConfig: &GeminiSpeechConfig{
+				VoiceConfig: &GeminiVoiceConfig{
+					PrebuiltVoiceConfig: &GeminiPrebuiltVoiceConfig{VoiceName: voice},
+				},
+			},
+		},
+	}
+
+	geminiResponse, errWithCode := p.sendGenerateContent(geminiRequest)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	inlineData := getResponseInlineData(geminiResponse, "audio/")
+	if inlineData == nil {
+		return nil, common.StringErrorWrapper("no audio generated", "no_audio_generated", http.StatusInternalServerError)
+	}
+
+	pcm, err := base64.StdEncoding.DecodeString(inlineData.Data)
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "decode_audio_data_failed", http.StatusInternalServerError)
+	}
+
+	// Gemini 只返回 PCM 数据，除 pcm 外统一封装为 wav
+	audio := pcm
+	contentType := "audio/pcm"
+	if request.ResponseFormat != "pcm" {
+		audio = utils.PCM16ToWav(pcm, getPCMSampleRate(inlineData.MimeType))
+		contentType = "audio/wav"
+	}
+
+	response := &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(audio)),
+		Header:     make(http.Header),
+	}
+	response.Header.Set("Content-Type", contentType)
+	response.Header.Set("Content-Length", strconv.Itoa(len(audio)))
+
+	if geminiResponse.UsageMetadata != nil {
+		p.Usage.PromptTokens = geminiResponse.UsageMetadata.PromptTokenCount
+		p.Usage.CompletionTokens = geminiResponse.UsageMetadata.CandidatesTokenCount
+	}
+	p.Usage.TotalTokens = p.Usage.PromptTokens + p.Usage.CompletionTokens
+
+	return response, nil
+}
+
+func (p *GeminiProvider) sendGenerateContent(geminiRequest *GeminiChatRequest) (*GeminiChatResponse, *types.OpenAIErrorWithStatusCode) {
+	fullRequestURL := p.GetFullRequestURL("generateContent", geminiRequest.Model)
+	headers := p.GetRequestHeaders()
+
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(geminiRequest), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	geminiResponse := &GeminiChatResponse{}
+	_, errWithCode := p.Requester.SendRequest(req, geminiResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	if len(geminiResponse.Candidates) == 0 {
+		return nil, common.StringErrorWrapper("no candidates", "no_candidates", http.StatusInternalServerError)
+	}
+
+	return geminiResponse, nil
+}
+
+func getResponseInlineData(response *GeminiChatResponse, mimePrefix string) *GeminiInlineData {
+	for _, candidate := range response.Candidates {
+		for _, part := range candidate.Content.Parts {
+			if part.InlineData != nil && strings.HasPrefix(part.InlineData.MimeType, mimePrefix) {
+				return part.InlineData
+			}
+		}
+	}
+
+	return nil
+}
+
+// 从 audio/L16;codec=pcm;rate=24000 中解析采样率
+func getPCMSampleRate(mimeType string) int {
+	for _, param := range strings.Split(mimeType, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if found && key == "rate" {
+			if rate, err := strconv.Atoi(value); err == nil && rate > 0 {
+				return rate
+			}
+		}
+	}
+
+	return defaultSpeechSampleRate
+}
@@ -0,0 +1,125 @@
+package gemini
+
+import (
+	"done-hub/common"
+	"done-hub/common/utils"
+	"done-hub/types"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+const transcriptionPrompt = "Generate a verbatim transcript of the speech in this audio. Output only the transcript text, without any explanation or formatting."
+
+func (p *GeminiProvider) CreateTranscriptions(request *types.AudioRequest) (*types.AudioResponseWrapper, *types.OpenAIErrorWithStatusCode) {
+	switch request.ResponseFormat {
+	case "", "json", "text", "verbose_json":
+	default:
+		return nil, common.StringErrorWrapperLocal("response_format "+request.ResponseFormat+" is not supported", "invalid_request_error", http.StatusBadRequest)
+	}
+
+	audio, mimeType, err := readAudioFile(request)
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "read_audio_file_failed", http.StatusBadRequest)
+	}
+
+	prompt := transcriptionPrompt
+	if request.Language != "" {
+		prompt += " The audio language is " + request.Language + "."
+	}
+	if request.Prompt != "" {
+		prompt += " Context: " + request.Prompt
+	}
+
+	geminiRequest := &GeminiChatRequest{
+		Model: request.Model,
+		Contents: []GeminiChatContent{
+			{
+				Role: "user",
+				Parts: []GeminiPart{
+					{Text: prompt},
+					{InlineData: &GeminiInlineData{
+						MimeType: mimeType,
+						Data:     base64.StdEncoding.EncodeToString(audio),
+					}},
+				},
+			},
+		},
+	}
+	if request.Temperature != 0 {
+		temperature := float64(request.Temperature)
+		geminiRequest.GenerationConfig.Temperature = &temperature
+	}
+
+	geminiResponse, errWithCode := p.sendGenerateContent(geminiRequest)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	var textBuilder strings.Builder
+	for _, part := range geminiResponse.Candidates[0].Content.Parts {
+		if part.Thought {
+			continue
+		}
+		textBuilder.WriteString(part.Text)
+	}
+	text := strings.TrimSpace(textBuilder.String())
+	duration := utils.GetAudioDuration(audio)
+
+	audioResponseWrapper := &types.AudioResponseWrapper{
+		Text:     text,
+		Duration: duration,
+	}
+
+	switch request.ResponseFormat {
+	case "text":
+		audioResponseWrapper.Headers = map[string]string{"Content-Type": "text/plain; charset=utf-8"}
+		audioResponseWrapper.Body = []byte(text)
+	default:
+		audioResponse := &types.AudioResponse{Text: text}
+		if request.ResponseFormat == "verbose_json" {
+			audioResponse.Task = "transcribe"
+			audioResponse.Language = request.Language
+			audioResponse.Duration = duration
+		}
+		audioResponseWrapper.Headers = map[string]string{"Content-Type": "application/json"}
+		audioResponseWrapper.Body, _ = json.Marshal(audioResponse)
+	}
+
+	if geminiResponse.UsageMetadata != nil {
+		p.Usage.PromptTokens = geminiResponse.UsageMetadata.PromptTokenCount
+		p.Usage.CompletionTokens = geminiResponse.UsageMetadata.CandidatesTokenCount + geminiResponse.UsageMetadata.ThoughtsTokenCount
+	} else {
+		p.Usage.CompletionTokens = common.CountTokenText(text, request.Model)
+	}
+	p.Usage.TotalTokens = p.Usage.PromptTokens + p.Usage.CompletionTokens
+
+	return audioResponseWrapper, nil
+}
+
+func readAudioFile(request *types.AudioRequest) ([]byte, string, error) {
+	file, err := request.File.Open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, "", err
+	}
+
+	mimeType := request.File.Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = mime.TypeByExtension(filepath.Ext(request.File.Filename))
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	return data, mimeType, nil
+}
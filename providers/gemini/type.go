@@ -3,10 +3,8 @@ package gemini
 import (
 	"done-hub/common"
 	"done-hub/common/image"
-	"done-hub/common/storage"
 	"done-hub/common/utils"
 	"done-hub/types"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -228,23 +226,12 @@ func (candidate *GeminiChatCandidate) ToOpenAIStreamChoice(request *types.ChatCo
 			isTools = true
 			choice.Delta.ToolCalls = append(choice.Delta.ToolCalls, part.FunctionCall.ToOpenAITool())
 		} else if part.InlineData != nil {
-			imgText := ""
 			if strings.HasPrefix(part.InlineData.MimeType, "image/") {
 
 				images = append(images, types.MultimediaData{
 					Data: part.InlineData.Data,
 				})
-				url := ""
-				imageData, err := base64.StdEncoding.DecodeString(part.InlineData.Data)
-				if err == nil {
-					url = storage.Upload(imageData, utils.GetUUID()+".png")
-				}
-				if url == "" {
-					imgText = "![image](data:" + part.InlineData.MimeType + ";base64," + part.InlineData.Data + ")"
-				} else {
-					imgText = fmt.Sprintf("%s(%s)", GeminiImageSymbol, url)
-				}
-				content = append(content, imgText)
+				content = append(content, fmt.Sprintf("%s(%s)", GeminiImageSymbol, GetInlineImageURL(part.InlineData)))
 			}
 			//  else if strings.HasPrefix(part.InlineData.MimeType, "audio/") {
 			// 	choice.Message.Audio = types.MultimediaData{
@@ -313,23 +300,12 @@ func (candidate *GeminiChatCandidate) ToOpenAIChoice(request *types.ChatCompleti
 			useTools = true
 			choice.Message.ToolCalls = append(choice.Message.ToolCalls, part.FunctionCall.ToOpenAITool())
 		} else if part.InlineData != nil {
-			imgText := ""
 			if strings.HasPrefix(part.InlineData.MimeType, "image/") {
 
 				images = append(images, types.MultimediaData{
 					Data: part.InlineData.Data,
 				})
-				url := ""
-				imageData, err := base64.StdEncoding.DecodeString(part.InlineData.Data)
-				if err == nil {
-					url = storage.Upload(imageData, utils.GetUUID()+".png")
-				}
-				if url == "" {
-					imgText = "![image](data:" + part.InlineData.MimeType + ";base64," + part.InlineData.Data + ")"
-				} else {
-					imgText = fmt.Sprintf("%s(%s)", GeminiImageSymbol, url)
-				}
-				content = append(content, imgText)
+				content = append(content, fmt.Sprintf("%s(%s)", GeminiImageSymbol, GetInlineImageURL(part.InlineData)))
 			}
 			//  else if strings.HasPrefix(part.InlineData.MimeType, "audio/") {
 			// 	choice.Message.Audio = types.MultimediaData{
@@ -414,16 +390,34 @@ type GeminiCodeExecution struct {
 }
 
 type GeminiChatGenerationConfig struct {
-	Temperature        *float64        `json:"temperature,omitempty"`
-	TopP               *float64        `json:"topP,omitempty"`
-	TopK               *float64        `json:"topK,omitempty"`
-	MaxOutputTokens    int             `json:"maxOutputTokens,omitempty"`
-	CandidateCount     int             `json:"candidateCount,omitempty"`
-	StopSequences      []string        `json:"stopSequences,omitempty"`
-	ResponseMimeType   string          `json:"responseMimeType,omitempty"`
-	ResponseSchema     any             `json:"responseSchema,omitempty"`
-	ResponseModalities []string        `json:"responseModalities,omitempty"`
-	ThinkingConfig     *ThinkingConfig `json:"thinkingConfig,omitempty"`
+	Temperature        *float64            `json:"temperature,omitempty"`
+	TopP               *float64            `json:"topP,omitempty"`
+	TopK               *float64            `json:"topK,omitempty"`
+	MaxOutputTokens    int                 `json:"maxOutputTokens,omitempty"`
+	CandidateCount     int                 `json:"candidateCount,omitempty"`
+	StopSequences      []string            `json:"stopSequences,omitempty"`
+	ResponseMimeType   string              `json:"responseMimeType,omitempty"`
+	ResponseSchema     any                 `json:"responseSchema,omitempty"`
+	ResponseModalities []string            `json:"responseModalities,omitempty"`
+	ThinkingConfig     *ThinkingConfig     `json:"thinkingConfig,omitempty"`
+	SpeechConfig       *GeminiSpeechConfig `json:"speechConfig,omitempty"`
+	ImageConfig        *GeminiImageConfig  `json:"imageConfig,omitempty"`
+}
+
+type GeminiImageConfig struct {
+	AspectRatio string `json:"aspectRatio,omitempty"`
+}
+
+type GeminiSpeechConfig struct {
+	VoiceConfig *GeminiVoiceConfig `json:"voiceConfig,omitempty"`
+}
+
+type GeminiVoiceConfig struct {
+	PrebuiltVoiceConfig *GeminiPrebuiltVoiceConfig `json:"prebuiltVoiceConfig,omitempty"`
+}
+
+type GeminiPrebuiltVoiceConfig struct {
+	VoiceName string `json:"voiceName"`
 }
 
 type ThinkingConfig struct {
@@ -764,3 +758,100 @@ func isEmptyOrOnlyNewlines(s string) bool {
 	trimmed := strings.TrimSpace(s)
 	return trimmed == ""
 }
+
+// Gemini Live API (BidiGenerateContent)
+type GeminiLiveSetup struct {
+	Model                    string                      `json:"model"`
+	GenerationConfig         *GeminiChatGenerationConfig `json:"generationConfig,omitempty"`
+	SystemInstruction        *GeminiChatContent          `json:"systemInstruction,omitempty"`
+	Tools                    []GeminiChatTools           `json:"tools,omitempty"`
+	InputAudioTranscription  *struct{}                   `json:"inputAudioTranscription,omitempty"`
+	OutputAudioTranscription *struct{}                   `json:"outputAudioTranscription,omitempty"`
+}
+
+type GeminiLiveClientMessage struct {
+	Setup         *GeminiLiveSetup         `json:"setup,omitempty"`
+	ClientContent *GeminiLiveClientContent `json:"clientContent,omitempty"`
+	RealtimeInput *GeminiLiveRealtimeInput `json:"realtimeInput,omitempty"`
+	ToolResponse  *GeminiLiveToolResponse  `json:"toolResponse,omitempty"`
+}
+
+type GeminiLiveClientContent struct {
+	Turns        []GeminiChatContent `json:"turns,omitempty"`
+	TurnComplete bool                `json:"turnComplete,omitempty"`
+}
+
+type GeminiLiveRealtimeInput struct {
+	Audio          *GeminiInlineData `json:"audio,omitempty"`
+	Text           string            `json:"text,omitempty"`
+	AudioStreamEnd bool              `json:"audioStreamEnd,omitempty"`
+}
+
+type GeminiLiveToolResponse struct {
+	FunctionResponses []GeminiLiveFunctionResponse `json:"functionResponses"`
+}
+
+type GeminiLiveFunctionResponse struct {
+	Id       string         `json:"id,omitempty"`
+	Name     string         `json:"name,omitempty"`
+	Response map[string]any `json:"response"`
+}
+
+type GeminiLiveServerMessage struct {
+	SetupComplete *struct{}                `json:"setupComplete,omitempty"`
+	ServerContent *GeminiLiveServerContent `json:"serverContent,omitempty"`
+	ToolCall      *GeminiLiveToolCall      `json:"toolCall,omitempty"`
+	UsageMetadata *GeminiLiveUsageMetadata `json:"usageMetadata,omitempty"`
+	GeminiErrorResponse
+}
+
+type GeminiLiveServerContent struct {
+	ModelTurn           *GeminiChatContent       `json:"modelTurn,omitempty"`
+	TurnComplete        bool                     `json:"turnComplete,omitempty"`
+	Interrupted         bool                     `json:"interrupted,omitempty"`
+	InputTranscription  *GeminiLiveTranscription `json:"inputTranscription,omitempty"`
+	OutputTranscription *GeminiLiveTranscription `json:"outputTranscription,omitempty"`
+}
+
+type GeminiLiveTranscription struct {
+	Text string `json:"text"`
+}
+
+type GeminiLiveToolCall struct {
+	FunctionCalls []GeminiLiveFunctionCall `json:"functionCalls"`
+}
+
+type GeminiLiveFunctionCall struct {
+	Id   string         `json:"id"`
+	Name string         `json:"name"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+type GeminiLiveUsageMetadata struct {
+	PromptTokenCount      int                          `json:"promptTokenCount"`
+	ResponseTokenCount    int                          `json:"responseTokenCount"`
+	ThoughtsTokenCount    int                          `json:"thoughtsTokenCount,omitempty"`
+	TotalTokenCount       int                          `json:"totalTokenCount"`
+	PromptTokensDetails   []GeminiUsageMetadataDetails `json:"promptTokensDetails,omitempty"`
+	ResponseTokensDetails []GeminiUsageMetadataDetails `json:"responseTokensDetails,omitempty"`
+}
+
+type GeminiBatchEmbeddingRequest struct {
+	Requests []GeminiEmbeddingRequest `json:"requests"`
+}
+
+type GeminiEmbeddingRequest struct {
+	Model                string            `json:"model"`
+	Content              GeminiChatContent `json:"content"`
+	TaskType             string            `json:"taskType,omitempty"`
+	OutputDimensionality int               `json:"outputDimensionality,omitempty"`
+}
+
+type GeminiBatchEmbeddingResponse struct {
+	Embeddings []GeminiEmbedding `json:"embeddings"`
+	GeminiErrorResponse
+}
+
+type GeminiEmbedding struct {
+	Values []float64 `json:"values"`
+}
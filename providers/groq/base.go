@@ -25,9 +25,12 @@ func (f GroqProviderFactory) Create(channel *model.Channel) base.ProviderInterfa
 
 func getConfig() base.ProviderConfig {
 	return base.ProviderConfig{
-		BaseURL:         "https://api.groq.com/openai",
-		ChatCompletions: "/v1/chat/completions",
-		ModelList:       "/v1/models",
+		BaseURL:             "https://api.groq.com/openai",
+		ChatCompletions:     "/v1/chat/completions",
+		AudioSpeech:         "/v1/audio/speech",
+		AudioTranscriptions: "/v1/audio/transcriptions",
+		AudioTranslations:   "/v1/audio/translations",
+		ModelList:           "/v1/models",
 	}
 }
 
@@ -29,6 +29,9 @@ type OpenAIProvider struct {
 
 	UsageHandler        UsageHandler
 	RequestHandleBefore RequestHandleBefore
+
+	// 需要从原始请求体中透传的扩展参数（如 vLLM 的 guided_json、llama.cpp 的 grammar）
+	PassthroughParams []string
 }
 
 // 创建 OpenAIProvider
@@ -268,6 +271,32 @@ func (p *OpenAIProvider) mergeCustomParams(requestMap map[string]interface{}, cu
 	return requestMap
 }
 
+// getPassthroughParams 从原始请求体中提取需要透传的扩展参数
+func (p *OpenAIProvider) getPassthroughParams() map[string]json.RawMessage {
+	if len(p.PassthroughParams) == 0 || p.Context == nil {
+		return nil
+	}
+
+	rawBody, ok := p.GetRawBody()
+	if !ok {
+		return nil
+	}
+
+	var rawMap map[string]json.RawMessage
+	if err := json.Unmarshal(rawBody, &rawMap); err != nil {
+		return nil
+	}
+
+	params := make(map[string]json.RawMessage)
+	for _, key := range p.PassthroughParams {
+		if value, exists := rawMap[key]; exists {
+			params[key] = value
+		}
+	}
+
+	return params
+}
+
 // 修改GetRequestTextBody函数中的对应部分
 func (p *OpenAIProvider) GetRequestTextBody(relayMode int, ModelName string, request any) (*http.Request, *types.OpenAIErrorWithStatusCode) {
 	url, errWithCode := p.GetSupportedAPIUri(relayMode)
@@ -285,8 +314,9 @@ func (p *OpenAIProvider) GetRequestTextBody(relayMode int, ModelName string, req
 	if err != nil {
 		return nil, common.ErrorWrapper(err, "custom_parameter_error", http.StatusInternalServerError)
 	}
+	passthroughParams := p.getPassthroughParams()
 	// 如果有额外参数，将其添加到请求体中
-	if customParams != nil {
+	if customParams != nil || len(passthroughParams) > 0 {
 		// 将请求体转换为map，以便添加额外参数
 		var requestMap map[string]interface{}
 		requestBytes, err := json.Marshal(request)
@@ -299,8 +329,14 @@ func (p *OpenAIProvider) GetRequestTextBody(relayMode int, ModelName string, req
 			return nil, common.ErrorWrapper(err, "unmarshal_request_failed", http.StatusInternalServerError)
 		}
 
+		for key, value := range passthroughParams {
+			requestMap[key] = value
+		}
+
 		// 处理自定义额外参数
-		requestMap = p.mergeCustomParams(requestMap, customParams)
+		if customParams != nil {
+			requestMap = p.mergeCustomParams(requestMap, customParams)
+		}
 
 		// 使用修改后的请求体创建请求
 		req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(requestMap), p.Requester.WithHeader(headers))
@@ -23,6 +23,7 @@ func (p *OpenAIProvider) CreateTranscriptions(request *types.AudioRequest) (*typ
 	defer req.Body.Close()
 
 	var textResponse string
+	var duration float64
 	var resp *http.Response
 	var err error
 	audioResponseWrapper := &types.AudioResponseWrapper{}
@@ -33,6 +34,7 @@ func (p *OpenAIProvider) CreateTranscriptions(request *types.AudioRequest) (*typ
 			return nil, errWithCode
 		}
 		textResponse = openAIProviderTranscriptionsResponse.Text
+		duration = openAIProviderTranscriptionsResponse.Duration
 	} else {
 		openAIProviderTranscriptionsTextResponse := new(OpenAIProviderTranscriptionsTextResponse)
 		resp, errWithCode = p.Requester.SendRequest(req, openAIProviderTranscriptionsTextResponse, true)
@@ -53,6 +55,9 @@ func (p *OpenAIProvider) CreateTranscriptions(request *types.AudioRequest) (*typ
 		return nil, common.ErrorWrapper(err, "read_response_body_failed", http.StatusInternalServerError)
 	}
 
+	audioResponseWrapper.Text = textResponse
+	audioResponseWrapper.Duration = duration
+
 	completionTokens := common.CountTokenText(textResponse, request.Model)
 
 	p.Usage.CompletionTokens = completionTokens
@@ -78,7 +83,9 @@ func (p *OpenAIProvider) getRequestAudioBody(relayMode int, ModelName string, re
 	// 创建请求
 	var req *http.Request
 	var err error
-	if p.OriginalModel != request.Model {
+	body, hasRawBody := p.GetRawBody()
+	// 模型被映射、流式请求或请求由网关内部构造时，需要重新构建表单
+	if p.OriginalModel != request.Model || request.Stream || !hasRawBody {
 		var formBody bytes.Buffer
 		builder := p.Requester.CreateFormBuilder(&formBody)
 		if err := audioMultipartForm(request, builder); err != nil {
@@ -92,11 +99,6 @@ func (p *OpenAIProvider) getRequestAudioBody(relayMode int, ModelName string, re
 			p.Requester.WithContentType(builder.FormDataContentType()))
 		req.ContentLength = int64(formBody.Len())
 	} else {
-		body, exists := p.GetRawBody()
-		if !exists {
-			return nil, common.StringErrorWrapperLocal("request body not found", "request_body_not_found", http.StatusInternalServerError)
-		}
-
 		req, err = p.Requester.NewRequest(
 			http.MethodPost,
 			fullRequestURL,
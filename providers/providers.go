@@ -17,6 +17,7 @@ import (
 	"done-hub/providers/cohere"
 	"done-hub/providers/coze"
 	"done-hub/providers/deepseek"
+	"done-hub/providers/elevenlabs"
 	"done-hub/providers/gemini"
 	"done-hub/providers/github"
 	"done-hub/providers/groq"
@@ -33,6 +34,8 @@ import (
 	"done-hub/providers/palm"
 	"done-hub/providers/recraftAI"
 	"done-hub/providers/replicate"
+	"done-hub/providers/rerank"
+	"done-hub/providers/selfhosted"
 	"done-hub/providers/siliconflow"
 	"done-hub/providers/stabilityAI"
 	"done-hub/providers/suno"
@@ -86,6 +89,7 @@ func init() {
 		config.ChannelTypeVertexAI:        vertexai.VertexAIProviderFactory{},
 		config.ChannelTypeSiliconflow:     siliconflow.SiliconflowProviderFactory{},
 		config.ChannelTypeJina:            jina.JinaProviderFactory{},
+		config.ChannelTypeRerank:          rerank.RerankProviderFactory{},
 		config.ChannelTypeGithub:          github.GithubProviderFactory{},
 		config.ChannelTypeRecraft:         recraftAI.RecraftProviderFactory{},
 		config.ChannelTypeReplicate:       replicate.ReplicateProviderFactory{},
@@ -93,6 +97,12 @@ func init() {
 		config.ChannelTypeAzureDatabricks: azuredatabricks.AzureDatabricksProviderFactory{},
 		config.ChannelTypeAzureV1:         azure_v1.AzureV1ProviderFactory{},
 		config.ChannelTypeXAI:             xAI.XAIProviderFactory{},
+		config.ChannelTypeElevenLabs:      elevenlabs.ElevenLabsProviderFactory{},
+		config.ChannelTypeVLLM:            selfhosted.SelfHostedProviderFactory{},
+		config.ChannelTypeSGLang:          selfhosted.SelfHostedProviderFactory{},
+		config.ChannelTypeLlamaCpp:        selfhosted.SelfHostedProviderFactory{},
+		config.ChannelTypeLMStudio:        selfhosted.SelfHostedProviderFactory{},
+		config.ChannelTypeTGI:             selfhosted.SelfHostedProviderFactory{},
 	}
 }
 
@@ -0,0 +1,88 @@
+package rerank
+
+import (
+	"bytes"
+	"done-hub/common/requester"
+	"done-hub/model"
+	"done-hub/providers/base"
+	"done-hub/providers/openai"
+	"done-hub/types"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// 兼容 TEI 接口格式时，渠道的 other 字段填写 tei
+const formatTEI = "tei"
+
+// 定义供应商工厂
+type RerankProviderFactory struct{}
+
+// 创建 RerankProvider，用于对接 TEI、Infinity、vLLM 等自部署的 OpenAI 兼容服务
+func (f RerankProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	return &RerankProvider{
+		OpenAIProvider: openai.OpenAIProvider{
+			BaseProvider: base.BaseProvider{
+				Config:    getConfig(channel.Other),
+				Channel:   channel,
+				Requester: requester.NewHTTPRequester(*channel.Proxy, RequestErrorHandle),
+			},
+		},
+	}
+}
+
+type RerankProvider struct {
+	openai.OpenAIProvider
+}
+
+func getConfig(format string) base.ProviderConfig {
+	config := base.ProviderConfig{
+		Rerank:     "/v1/rerank",
+		Embeddings: "/v1/embeddings",
+		ModelList:  "/v1/models",
+	}
+
+	if format == formatTEI {
+		config.Rerank = "/rerank"
+	}
+
+	return config
+}
+
+// 请求错误处理，兼容 OpenAI、Jina({"detail": ...}) 以及 TEI({"error": ...}) 的错误格式
+func RequestErrorHandle(resp *http.Response) *types.OpenAIError {
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil
+	}
+	resp.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+	openaiError := &types.OpenAIErrorResponse{}
+	if err := json.Unmarshal(bodyBytes, openaiError); err == nil && openaiError.Error.Message != "" {
+		return &openaiError.Error
+	}
+
+	rerankError := &RerankError{}
+	if err := json.Unmarshal(bodyBytes, rerankError); err != nil {
+		return nil
+	}
+
+	return errorHandle(rerankError)
+}
+
+// 错误处理
+func errorHandle(rerankError *RerankError) *types.OpenAIError {
+	message := rerankError.Detail
+	if message == "" {
+		message = rerankError.Error
+	}
+	if message == "" {
+		return nil
+	}
+
+	return &types.OpenAIError{
+		Message: message,
+		Type:    "rerank_error",
+		Code:    rerankError.ErrorType,
+	}
+}
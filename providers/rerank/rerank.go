@@ -0,0 +1,143 @@
+package rerank
+
+import (
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/types"
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+func (p *RerankProvider) CreateRerank(request *types.RerankRequest) (*types.RerankResponse, *types.OpenAIErrorWithStatusCode) {
+	if p.Channel.Other == formatTEI {
+		return p.createTEIRerank(request)
+	}
+
+	rerankRequest := &RerankRequest{
+		Model:           request.Model,
+		Query:           request.Query,
+		TopN:            request.TopN,
+		Documents:       request.Documents,
+		ReturnDocuments: true,
+	}
+
+	rerankResponse := &RerankResponse{}
+	if errWithCode := p.sendRerank(rerankRequest, rerankResponse); errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	response := &types.RerankResponse{
+		Model:   request.Model,
+		Usage:   p.Usage,
+		Results: make([]types.RerankResult, 0, len(rerankResponse.Results)),
+	}
+
+	for _, result := range rerankResponse.Results {
+		rerankResult := types.RerankResult{
+			Index:    result.Index,
+			Document: types.RerankResultDocument{Text: parseDocumentText(result.Document)},
+		}
+		if result.RelevanceScore != nil {
+			rerankResult.RelevanceScore = *result.RelevanceScore
+		} else if result.Score != nil {
+			rerankResult.RelevanceScore = *result.Score
+		}
+		response.Results = append(response.Results, rerankResult)
+	}
+
+	if rerankResponse.Usage != nil && rerankResponse.Usage.TotalTokens > 0 {
+		p.Usage.PromptTokens = rerankResponse.Usage.TotalTokens
+		p.Usage.TotalTokens = rerankResponse.Usage.TotalTokens
+	} else {
+		p.Usage.TotalTokens = p.Usage.PromptTokens
+	}
+
+	return response, nil
+}
+
+// TEI 使用 texts 字段，且按原始顺序返回分数，需要自行排序并截取 top_n
+func (p *RerankProvider) createTEIRerank(request *types.RerankRequest) (*types.RerankResponse, *types.OpenAIErrorWithStatusCode) {
+	documents, err := request.GetDocumentsList()
+	if err != nil {
+		return nil, common.ErrorWrapperLocal(err, "invalid_documents", http.StatusBadRequest)
+	}
+
+	teiRequest := &TEIRerankRequest{
+		Query:      request.Query,
+		Texts:      documents,
+		ReturnText: true,
+		Truncate:   true,
+	}
+
+	var teiResponse []TEIRerankResult
+	if errWithCode := p.sendRerank(teiRequest, &teiResponse); errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	sort.SliceStable(teiResponse, func(i, j int) bool {
+		return teiResponse[i].Score > teiResponse[j].Score
+	})
+	if request.TopN > 0 && request.TopN < len(teiResponse) {
+		teiResponse = teiResponse[:request.TopN]
+	}
+
+	response := &types.RerankResponse{
+		Model:   request.Model,
+		Usage:   p.Usage,
+		Results: make([]types.RerankResult, 0, len(teiResponse)),
+	}
+	for _, result := range teiResponse {
+		text := result.Text
+		if text == "" && result.Index < len(documents) {
+			text = documents[result.Index]
+		}
+		response.Results = append(response.Results, types.RerankResult{
+			Index:          result.Index,
+			Document:       types.RerankResultDocument{Text: text},
+			RelevanceScore: result.Score,
+		})
+	}
+
+	p.Usage.TotalTokens = p.Usage.PromptTokens
+
+	return response, nil
+}
+
+func (p *RerankProvider) sendRerank(body any, response any) *types.OpenAIErrorWithStatusCode {
+	url, errWithCode := p.GetSupportedAPIUri(config.RelayModeRerank)
+	if errWithCode != nil {
+		return errWithCode
+	}
+
+	fullRequestURL := p.GetFullRequestURL(url, "")
+	headers := p.GetRequestHeaders()
+
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(body), p.Requester.WithHeader(headers))
+	if err != nil {
+		return common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	_, errWithCode = p.Requester.SendRequest(req, response, false)
+
+	return errWithCode
+}
+
+func parseDocumentText(document json.RawMessage) string {
+	if len(document) == 0 {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(document, &text); err == nil {
+		return text
+	}
+
+	doc := &types.RerankResultDocument{}
+	if err := json.Unmarshal(document, doc); err == nil {
+		return doc.Text
+	}
+
+	return ""
+}
@@ -0,0 +1,49 @@
+package rerank
+
+import "encoding/json"
+
+type RerankError struct {
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+	ErrorType string `json:"error_type,omitempty"`
+}
+
+type RerankRequest struct {
+	Model           string `json:"model"`
+	Query           string `json:"query"`
+	TopN            int    `json:"top_n,omitempty"`
+	Documents       []any  `json:"documents"`
+	ReturnDocuments bool   `json:"return_documents"`
+}
+
+type RerankResponse struct {
+	Model   string         `json:"model,omitempty"`
+	Usage   *RerankUsage   `json:"usage,omitempty"`
+	Results []RerankResult `json:"results"`
+}
+
+type RerankUsage struct {
+	PromptTokens int `json:"prompt_tokens,omitempty"`
+	TotalTokens  int `json:"total_tokens,omitempty"`
+}
+
+// 不同服务返回的 document 可能是字符串或 {"text": "..."}
+type RerankResult struct {
+	Index          int             `json:"index"`
+	Document       json.RawMessage `json:"document,omitempty"`
+	RelevanceScore *float64        `json:"relevance_score,omitempty"`
+	Score          *float64        `json:"score,omitempty"`
+}
+
+type TEIRerankRequest struct {
+	Query      string   `json:"query"`
+	Texts      []string `json:"texts"`
+	ReturnText bool     `json:"return_text"`
+	Truncate   bool     `json:"truncate"`
+}
+
+type TEIRerankResult struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+	Text  string  `json:"text,omitempty"`
+}
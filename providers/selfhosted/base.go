@@ -0,0 +1,45 @@
+package selfhosted
+
+import (
+	"done-hub/common/config"
+	"done-hub/common/requester"
+	"done-hub/model"
+	"done-hub/providers/base"
+	"done-hub/providers/openai"
+	"done-hub/providers/rerank"
+)
+
+// 定义供应商工厂，对接 vLLM、SGLang、llama.cpp server、LM Studio、TGI 等自部署推理服务
+type SelfHostedProviderFactory struct{}
+
+func (f SelfHostedProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	server := getServer(channel.Type)
+
+	return &SelfHostedProvider{
+		RerankProvider: rerank.RerankProvider{
+			OpenAIProvider: openai.OpenAIProvider{
+				BaseProvider: base.BaseProvider{
+					Config:    server.Config,
+					Channel:   channel,
+					Requester: requester.NewHTTPRequester(*channel.Proxy, rerank.RequestErrorHandle),
+				},
+				SupportStreamOptions: server.StreamOptions,
+				PassthroughParams:    server.Params,
+			},
+		},
+		Server: server,
+	}
+}
+
+type SelfHostedProvider struct {
+	rerank.RerankProvider
+	Server *Server
+}
+
+func getServer(channelType int) *Server {
+	if server, ok := servers[channelType]; ok {
+		return server
+	}
+
+	return servers[config.ChannelTypeVLLM]
+}
@@ -0,0 +1,108 @@
+package selfhosted
+
+import (
+	"bufio"
+	"context"
+	"done-hub/model"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const healthCheckTimeout = 5 * time.Second
+
+// CheckHealth 检查服务是否可用，并从 Prometheus 指标中读取排队情况
+func (p *SelfHostedProvider) CheckHealth() *model.ChannelHealth {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	p.Requester.Context = ctx
+
+	health := &model.ChannelHealth{}
+	if _, err := p.sendHealthRequest(p.Server.HealthPath); err != nil {
+		health.Message = err.Error()
+		return health
+	}
+	health.Healthy = true
+
+	if p.Server.MetricsPath == "" {
+		return health
+	}
+
+	// 指标接口可能未开启（如 llama.cpp 需要 --metrics），此时只做存活检查
+	body, err := p.sendHealthRequest(p.Server.MetricsPath)
+	if err != nil {
+		return health
+	}
+
+	metrics := parseMetrics(body, p.Server.QueueMetric, p.Server.RunningMetric)
+	health.QueueDepth = int(metrics[p.Server.QueueMetric])
+	health.Running = int(metrics[p.Server.RunningMetric])
+
+	return health
+}
+
+func (p *SelfHostedProvider) sendHealthRequest(path string) (string, error) {
+	fullRequestURL := p.GetFullRequestURL(path, "")
+	headers := p.GetRequestHeaders()
+
+	req, err := p.Requester.NewRequest(http.MethodGet, fullRequestURL, p.Requester.WithHeader(headers))
+	if err != nil {
+		return "", err
+	}
+
+	resp, errWithCode := p.Requester.SendRequestRaw(req)
+	if errWithCode != nil {
+		return "", fmt.Errorf("%s", errWithCode.Message)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// parseMetrics 解析 Prometheus 文本格式，同名指标的多个标签组合累加
+func parseMetrics(body string, names ...string) map[string]float64 {
+	metrics := make(map[string]float64, len(names))
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name := line
+		if index := strings.IndexAny(line, "{ "); index > 0 {
+			name = line[:index]
+		}
+
+		for _, target := range names {
+			if target == "" || name != target {
+				continue
+			}
+
+			value := line[len(name):]
+			if strings.HasPrefix(value, "{") {
+				value = value[strings.LastIndex(value, "}")+1:]
+			}
+
+			fields := strings.Fields(value)
+			if len(fields) == 0 {
+				break
+			}
+			if number, err := strconv.ParseFloat(fields[0], 64); err == nil {
+				metrics[target] += number
+			}
+			break
+		}
+	}
+
+	return metrics
+}
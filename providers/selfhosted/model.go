@@ -0,0 +1,69 @@
+package selfhosted
+
+import (
+	"done-hub/common/config"
+	"errors"
+	"net/http"
+)
+
+// 获取服务当前已加载的模型
+func (p *SelfHostedProvider) GetModelList() ([]string, error) {
+	switch p.Channel.Type {
+	case config.ChannelTypeLMStudio:
+		if models, err := p.getLMStudioModels(); err == nil && len(models) > 0 {
+			return models, nil
+		}
+	case config.ChannelTypeTGI:
+		return p.getTGIModels()
+	}
+
+	return p.OpenAIProvider.GetModelList()
+}
+
+// LM Studio 的 /v1/models 会返回所有已下载的模型，这里只取已加载的
+func (p *SelfHostedProvider) getLMStudioModels() ([]string, error) {
+	response := &LMStudioModelListResponse{}
+	if err := p.sendGet("/api/v0/models", response); err != nil {
+		return nil, err
+	}
+
+	var modelList []string
+	for _, model := range response.Data {
+		if model.State == "loaded" {
+			modelList = append(modelList, model.Id)
+		}
+	}
+
+	return modelList, nil
+}
+
+// TGI 一个实例只加载一个模型
+func (p *SelfHostedProvider) getTGIModels() ([]string, error) {
+	response := &TGIInfoResponse{}
+	if err := p.sendGet(p.Config.ModelList, response); err != nil {
+		return nil, err
+	}
+
+	if response.ModelId == "" {
+		return nil, errors.New("model not found")
+	}
+
+	return []string{response.ModelId}, nil
+}
+
+func (p *SelfHostedProvider) sendGet(path string, response any) error {
+	fullRequestURL := p.GetFullRequestURL(path, "")
+	headers := p.GetRequestHeaders()
+
+	req, err := p.Requester.NewRequest(http.MethodGet, fullRequestURL, p.Requester.WithHeader(headers))
+	if err != nil {
+		return errors.New("new_request_failed")
+	}
+
+	_, errWithCode := p.Requester.SendRequest(req, response, false)
+	if errWithCode != nil {
+		return errors.New(errWithCode.Message)
+	}
+
+	return nil
+}
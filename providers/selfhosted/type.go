@@ -0,0 +1,122 @@
+package selfhosted
+
+import (
+	"done-hub/common/config"
+	"done-hub/providers/base"
+)
+
+type Server struct {
+	Name          string
+	Config        base.ProviderConfig
+	HealthPath    string
+	MetricsPath   string
+	QueueMetric   string // 排队中的请求数
+	RunningMetric string // 处理中的请求数
+	Params        []string
+	StreamOptions bool
+}
+
+// 各服务通用的采样参数
+var samplingParams = []string{"top_k", "min_p", "repetition_penalty", "min_tokens", "stop_token_ids", "ignore_eos", "skip_special_tokens", "chat_template_kwargs"}
+
+var servers = map[int]*Server{
+	config.ChannelTypeVLLM: {
+		Name: "vLLM",
+		Config: base.ProviderConfig{
+			Completions:     "/v1/completions",
+			ChatCompletions: "/v1/chat/completions",
+			Embeddings:      "/v1/embeddings",
+			Rerank:          "/v1/rerank",
+			ModelList:       "/v1/models",
+		},
+		HealthPath:    "/health",
+		MetricsPath:   "/metrics",
+		QueueMetric:   "vllm:num_requests_waiting",
+		RunningMetric: "vllm:num_requests_running",
+		Params: append([]string{
+			"guided_json", "guided_regex", "guided_choice", "guided_grammar", "guided_decoding_backend", "guided_whitespace_pattern",
+			"structured_outputs", "length_penalty", "use_beam_search", "best_of", "include_stop_str_in_output",
+			"spaces_between_special_tokens", "add_generation_prompt", "continue_final_message", "chat_template",
+			"truncate_prompt_tokens", "prompt_logprobs", "priority", "echo",
+		}, samplingParams...),
+		StreamOptions: true,
+	},
+	config.ChannelTypeSGLang: {
+		Name: "SGLang",
+		Config: base.ProviderConfig{
+			Completions:     "/v1/completions",
+			ChatCompletions: "/v1/chat/completions",
+			Embeddings:      "/v1/embeddings",
+			Rerank:          "/v1/rerank",
+			ModelList:       "/v1/models",
+		},
+		HealthPath:    "/health",
+		MetricsPath:   "/metrics",
+		QueueMetric:   "sglang:num_queue_reqs",
+		RunningMetric: "sglang:num_running_reqs",
+		Params: append([]string{
+			"regex", "ebnf", "json_schema", "no_stop_trim", "separate_reasoning", "lora_path", "session_params",
+		}, samplingParams...),
+		StreamOptions: true,
+	},
+	config.ChannelTypeLlamaCpp: {
+		Name: "llama.cpp",
+		Config: base.ProviderConfig{
+			Completions:     "/v1/completions",
+			ChatCompletions: "/v1/chat/completions",
+			Embeddings:      "/v1/embeddings",
+			Rerank:          "/v1/rerank",
+			ModelList:       "/v1/models",
+		},
+		HealthPath:    "/health",
+		MetricsPath:   "/metrics",
+		QueueMetric:   "llamacpp:requests_deferred",
+		RunningMetric: "llamacpp:requests_processing",
+		Params: []string{
+			"grammar", "json_schema", "top_k", "min_p", "typical_p", "repeat_penalty", "repeat_last_n",
+			"mirostat", "mirostat_tau", "mirostat_eta", "dynatemp_range", "dynatemp_exponent",
+			"xtc_probability", "xtc_threshold", "dry_multiplier", "dry_base", "dry_allowed_length",
+			"samplers", "n_probs", "cache_prompt", "id_slot", "reasoning_format", "chat_template_kwargs",
+		},
+	},
+	config.ChannelTypeLMStudio: {
+		Name: "LM Studio",
+		Config: base.ProviderConfig{
+			Completions:     "/v1/completions",
+			ChatCompletions: "/v1/chat/completions",
+			Embeddings:      "/v1/embeddings",
+			ModelList:       "/v1/models",
+		},
+		HealthPath: "/v1/models",
+		Params:     []string{"top_k", "min_p", "repeat_penalty", "ttl", "draft_model"},
+	},
+	config.ChannelTypeTGI: {
+		Name: "TGI",
+		Config: base.ProviderConfig{
+			Completions:     "/v1/completions",
+			ChatCompletions: "/v1/chat/completions",
+			ModelList:       "/info",
+		},
+		HealthPath:    "/health",
+		MetricsPath:   "/metrics",
+		QueueMetric:   "tgi_queue_size",
+		RunningMetric: "tgi_batch_current_size",
+		Params:        []string{"top_k", "repetition_penalty", "top_n_tokens", "grammar", "typical_p", "watermark", "decoder_input_details"},
+	},
+}
+
+// LM Studio /api/v0/models 返回的模型状态
+type LMStudioModelListResponse struct {
+	Data []LMStudioModel `json:"data"`
+}
+
+type LMStudioModel struct {
+	Id    string `json:"id"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+}
+
+// TGI /info 返回的服务信息
+type TGIInfoResponse struct {
+	ModelId string `json:"model_id"`
+}
@@ -0,0 +1,32 @@
+package siliconflow
+
+import (
+	"done-hub/types"
+	"net/http"
+	"strings"
+)
+
+var voiceMap = map[string]string{
+	"alloy":   "alex",
+	"echo":    "benjamin",
+	"fable":   "charles",
+	"onyx":    "david",
+	"nova":    "anna",
+	"shimmer": "bella",
+}
+
+func (p *SiliconflowProvider) CreateSpeech(request *types.SpeechAudioRequest) (*http.Response, *types.OpenAIErrorWithStatusCode) {
+	speechRequest := *request
+	// 系统音色需要使用 模型:音色 的格式，自定义音色(speech:xxx)保持不变
+	if !strings.Contains(speechRequest.Voice, ":") {
+		voice := speechRequest.Voice
+		if mapped, ok := voiceMap[voice]; ok {
+			voice = mapped
+		}
+		speechRequest.Voice = speechRequest.Model + ":" + voice
+	}
+	// 上游不支持 SSE 输出，由网关分片输出
+	speechRequest.StreamFormat = ""
+
+	return p.OpenAIProvider.CreateSpeech(&speechRequest)
+}
@@ -0,0 +1,104 @@
+package vertexai
+
+import (
+	"done-hub/common"
+	"done-hub/providers/gemini"
+	"done-hub/types"
+	"net/http"
+)
+
+type VertexAIEmbeddingRequest struct {
+	Instances  []VertexAIEmbeddingInstance  `json:"instances"`
+	Parameters *VertexAIEmbeddingParameters `json:"parameters,omitempty"`
+}
+
+type VertexAIEmbeddingInstance struct {
+	Content  string `json:"content"`
+	TaskType string `json:"task_type,omitempty"`
+}
+
+type VertexAIEmbeddingParameters struct {
+	AutoTruncate         bool `json:"autoTruncate"`
+	OutputDimensionality int  `json:"outputDimensionality,omitempty"`
+}
+
+type VertexAIEmbeddingResponse struct {
+	Predictions []VertexAIEmbeddingPrediction `json:"predictions"`
+}
+
+type VertexAIEmbeddingPrediction struct {
+	Embeddings struct {
+		Values     []float64 `json:"values"`
+		Statistics struct {
+			TokenCount int  `json:"token_count"`
+			Truncated  bool `json:"truncated"`
+		} `json:"statistics"`
+	} `json:"embeddings"`
+}
+
+func (p *VertexAIProvider) CreateEmbeddings(request *types.EmbeddingRequest) (*types.EmbeddingResponse, *types.OpenAIErrorWithStatusCode) {
+	inputs := request.ParseInput()
+	if len(inputs) == 0 {
+		return nil, common.StringErrorWrapperLocal("input is required", "invalid_request_error", http.StatusBadRequest)
+	}
+
+	vertexRequest := &VertexAIEmbeddingRequest{
+		Instances: make([]VertexAIEmbeddingInstance, 0, len(inputs)),
+		Parameters: &VertexAIEmbeddingParameters{
+			AutoTruncate:         true,
+			OutputDimensionality: request.Dimensions,
+		},
+	}
+	for _, input := range inputs {
+		vertexRequest.Instances = append(vertexRequest.Instances, VertexAIEmbeddingInstance{Content: input})
+	}
+
+	// 获取请求地址
+	fullRequestURL := p.GetFullRequestURL(request.Model, "predict")
+	if fullRequestURL == "" {
+		return nil, common.ErrorWrapper(nil, "invalid_vertex_ai_config", http.StatusInternalServerError)
+	}
+
+	headers := p.GetRequestHeaders()
+	if headers == nil {
+		return nil, common.ErrorWrapperLocal(nil, "invalid_vertexai_config", http.StatusInternalServerError)
+	}
+
+	p.Requester.ErrorHandler = RequestErrorHandle(gemini.RequestErrorHandle(""))
+
+	req, errWithCode := p.NewRequestWithCustomParams(http.MethodPost, fullRequestURL, vertexRequest, headers, request.Model)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	vertexResponse := &VertexAIEmbeddingResponse{}
+	_, errWithCode = p.Requester.SendRequest(req, vertexResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	response := &types.EmbeddingResponse{
+		Object: "list",
+		Model:  request.Model,
+		Data:   make([]types.Embedding, 0, len(vertexResponse.Predictions)),
+	}
+
+	promptTokens := 0
+	for index, prediction := range vertexResponse.Predictions {
+		promptTokens += prediction.Embeddings.Statistics.TokenCount
+		response.Data = append(response.Data, types.Embedding{
+			Object:    "embedding",
+			Embedding: prediction.Embeddings.Values,
+			Index:     index,
+		})
+	}
+
+	if promptTokens > 0 {
+		p.Usage.PromptTokens = promptTokens
+	}
+	p.Usage.TotalTokens = p.Usage.PromptTokens
+	response.Usage = p.Usage
+
+	return response, nil
+}
@@ -0,0 +1,46 @@
+package vertexai
+
+import (
+	"done-hub/common"
+	"done-hub/providers/gemini"
+	"done-hub/types"
+	"net/http"
+)
+
+func (p *VertexAIProvider) CreateImageEdits(request *types.ImageEditRequest) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	if !gemini.IsGeminiImageModel(request.Model) {
+		return nil, common.StringErrorWrapperLocal("model "+request.Model+" does not support image edits", "invalid_request_error", http.StatusBadRequest)
+	}
+
+	geminiRequest, errWithCode := gemini.BuildImageEditChatRequest(request)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	return gemini.CreateImagesByContent(geminiRequest, request.N, request.ResponseFormat, p.GetUsage(), p.sendGeminiContent)
+}
+
+// 调用 Gemini 模型的 generateContent 接口
+func (p *VertexAIProvider) sendGeminiContent(geminiRequest *gemini.GeminiChatRequest) (*gemini.GeminiChatResponse, *types.OpenAIErrorWithStatusCode) {
+	fullRequestURL := p.GetFullRequestURL(geminiRequest.Model, "generateContent")
+	headers := p.GetRequestHeaders()
+	if headers == nil {
+		return nil, common.ErrorWrapperLocal(nil, "invalid_vertexai_config", http.StatusInternalServerError)
+	}
+
+	p.Requester.ErrorHandler = RequestErrorHandle(gemini.RequestErrorHandle(""))
+
+	req, errWithCode := p.NewRequestWithCustomParams(http.MethodPost, fullRequestURL, geminiRequest, headers, geminiRequest.Model)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	geminiResponse := &gemini.GeminiChatResponse{}
+	_, errWithCode = p.Requester.SendRequest(req, geminiResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	return geminiResponse, nil
+}
@@ -2,6 +2,7 @@ package vertexai
 
 import (
 	"done-hub/common"
+	"done-hub/providers/gemini"
 	"done-hub/types"
 	"net/http"
 	"time"
@@ -43,6 +44,18 @@ type VertexAIImagePrediction struct {
 }
 
 func (p *VertexAIProvider) CreateImageGenerations(request *types.ImageRequest) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	if gemini.IsGeminiImageModel(request.Model) {
+		aspectRatio := ""
+		if request.AspectRatio != nil {
+			aspectRatio = *request.AspectRatio
+		} else if request.Size != "" {
+			aspectRatio = sizeToAspectRatio(request.Size)
+		}
+		geminiRequest := gemini.BuildImageChatRequest(request.Model, request.Prompt, nil, aspectRatio)
+
+		return gemini.CreateImagesByContent(geminiRequest, request.N, request.ResponseFormat, p.GetUsage(), p.sendGeminiContent)
+	}
+
 	// 构建请求体
 	vertexRequest := &VertexAIImageRequest{
 		Instances: []VertexAIImageInstance{
@@ -33,6 +33,7 @@ type RelayBaseInterface interface {
 	setProvider(modelName string) error
 	getProvider() providersBase.ProviderInterface
 	getOriginalModel() string
+	setRoutedModel(modelName string)
 	getModelName() string
 	getContext() *gin.Context
 	IsStream() bool
@@ -79,6 +80,11 @@ func (r *relayBase) setOriginalModel(modelName string) {
 	r.originalModel = parts[0]
 }
 
+// setRoutedModel 路由模型解析或降级后使用实际模型，重试时也使用该模型
+func (r *relayBase) setRoutedModel(modelName string) {
+	r.originalModel = modelName
+}
+
 func (r *relayBase) getContext() *gin.Context {
 	return r.c
 }
@@ -5,7 +5,11 @@ import (
 	"done-hub/common/config"
 	"done-hub/common/requester"
 	"done-hub/common/utils"
+	"done-hub/model"
 	providersBase "done-hub/providers/base"
+	"done-hub/relay/plugin"
+	"done-hub/relay/toolcall"
+	"done-hub/relay/websearch"
 	"done-hub/safty"
 	"done-hub/types"
 	"encoding/json"
@@ -21,6 +25,7 @@ import (
 type relayChat struct {
 	relayBase
 	chatRequest types.ChatCompletionRequest
+	pipeline    *plugin.Pipeline
 }
 
 func NewRelayChat(c *gin.Context) *relayChat {
@@ -55,7 +60,7 @@ func (r *relayChat) setRequest() error {
 	otherArg := r.getOtherArg()
 
 	if otherArg == "search" {
-		handleSearch(r.c, &r.chatRequest)
+		enableChatSearch(&r.chatRequest)
 		return nil
 	}
 
@@ -75,6 +80,29 @@ func (r *relayChat) getPromptTokens() (int, error) {
 	return common.CountTokenMessages(r.chatRequest.Messages, r.modelName, channel.PreCost), nil
 }
 
+func (r *relayChat) getRouteRequirement() *model.RouteRequirement {
+	req := &model.RouteRequirement{
+		Tools:            len(r.chatRequest.Tools) > 0 || len(r.chatRequest.Functions) > 0,
+		PromptTokens:     common.CountTokenMessages(r.chatRequest.Messages, r.originalModel, config.PreCostNotImage),
+		CompletionTokens: max(r.chatRequest.MaxTokens, r.chatRequest.MaxCompletionTokens),
+	}
+
+	if r.chatRequest.ResponseFormat != nil {
+		req.JSONMode = r.chatRequest.ResponseFormat.Type == "json_object" || r.chatRequest.ResponseFormat.Type == "json_schema"
+	}
+
+	for _, message := range r.chatRequest.Messages {
+		for _, part := range message.ParseContent() {
+			if part.Type == types.ContentTypeImageURL {
+				req.Vision = true
+				break
+			}
+		}
+	}
+
+	return req
+}
+
 var need2Response = map[string]bool{
 	"o3-pro-2025-06-10":                true,
 	"o3-pro":                           true,
@@ -88,6 +116,21 @@ var need2Response = map[string]bool{
 }
 
 func (r *relayChat) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
+	// 插件只修改本次发送的请求，重试时使用原始请求
+	r.pipeline = getPluginPipeline(r.c, r.provider.GetChannel(), &r.chatRequest, r.modelName)
+	if !r.pipeline.Empty() {
+		origin := r.chatRequest
+		defer func() {
+			r.chatRequest = origin
+		}()
+
+		if pluginErr := r.pipeline.PreRequest(r.c, &r.chatRequest); pluginErr != nil {
+			err = common.StringErrorWrapperLocal(pluginErr.Error(), "plugin_error", http.StatusBadRequest)
+			done = true
+			return
+		}
+	}
+
 	if need2Response[r.modelName] {
 		resProvider, ok := r.provider.(providersBase.ResponsesInterface)
 		if ok {
@@ -101,6 +144,7 @@ func (r *relayChat) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
 		done = true
 		return
 	}
+	chatProvider = websearch.Wrap(toolcall.Wrap(chatProvider))
 
 	r.chatRequest.Model = r.modelName
 	// 内容审查
@@ -123,6 +167,7 @@ func (r *relayChat) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
 		if err != nil {
 			return
 		}
+		response = r.pipeline.WrapStream(r.c, &r.chatRequest, response)
 
 		if r.heartbeat != nil {
 			r.heartbeat.Stop()
@@ -137,7 +182,7 @@ func (r *relayChat) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
 		r.SetFirstResponseTime(firstResponseTime)
 	} else {
 		var response *types.ChatCompletionResponse
-		response, err = chatProvider.CreateChatCompletion(&r.chatRequest)
+		response, err = r.createChatCompletion(chatProvider)
 		if err != nil {
 			return
 		}
@@ -147,6 +192,7 @@ func (r *relayChat) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
 		}
 
 		err = responseJsonClient(r.c, response)
+		r.pipeline.PostResponse(r.c, &r.chatRequest, response.GetContent())
 
 	}
 
@@ -157,6 +203,37 @@ func (r *relayChat) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
 	return
 }
 
+// createChatCompletion 插件要求重试时使用插件返回的请求重新发送，每次请求的用量累加计费
+func (r *relayChat) createChatCompletion(chatProvider providersBase.ChatInterface) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	request := &r.chatRequest
+	spent := types.Usage{}
+
+	for i := 0; ; i++ {
+		response, err := chatProvider.CreateChatCompletion(request)
+		if err != nil {
+			return nil, err
+		}
+
+		retry, pluginErr := r.pipeline.PostRequest(r.c, request, response)
+		if pluginErr != nil {
+			return nil, common.StringErrorWrapperLocal(pluginErr.Error(), "plugin_error", http.StatusInternalServerError)
+		}
+
+		usage := r.provider.GetUsage()
+		if retry == nil || i >= maxPluginRetries {
+			usage.PromptTokens += spent.PromptTokens
+			usage.CompletionTokens += spent.CompletionTokens
+			usage.TotalTokens += spent.TotalTokens
+			return response, nil
+		}
+
+		spent.PromptTokens += usage.PromptTokens
+		spent.CompletionTokens += usage.CompletionTokens
+		spent.TotalTokens += usage.TotalTokens
+		request = retry
+	}
+}
+
 func (r *relayChat) getUsageResponse() string {
 	if r.chatRequest.StreamOptions != nil && r.chatRequest.StreamOptions.IncludeUsage {
 		usageResponse := types.ChatCompletionStreamResponse{
@@ -189,6 +266,7 @@ func (r *relayChat) compatibleSend(resProvider providersBase.ResponsesInterface)
 		if err != nil {
 			return
 		}
+		response = r.pipeline.WrapStream(r.c, &r.chatRequest, response)
 
 		if r.heartbeat != nil {
 			r.heartbeat.Stop()
@@ -208,10 +286,18 @@ func (r *relayChat) compatibleSend(resProvider providersBase.ResponsesInterface)
 			return
 		}
 
+		// 转换为 responses 接口的请求不支持插件重试
+		chatResponse := response.ToChat()
+		if _, pluginErr := r.pipeline.PostRequest(r.c, &r.chatRequest, chatResponse); pluginErr != nil {
+			err = common.StringErrorWrapperLocal(pluginErr.Error(), "plugin_error", http.StatusInternalServerError)
+			return
+		}
+
 		if r.heartbeat != nil {
 			r.heartbeat.Stop()
 		}
-		err = responseJsonClient(r.c, response.ToChat())
+		err = responseJsonClient(r.c, chatResponse)
+		r.pipeline.PostResponse(r.c, &r.chatRequest, chatResponse.GetContent())
 	}
 
 	if err != nil {
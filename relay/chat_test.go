@@ -0,0 +1,136 @@
+package relay
+
+import (
+	"done-hub/common/logger"
+	"done-hub/common/requester"
+	"done-hub/model"
+	"done-hub/providers/base"
+	"done-hub/relay/plugin"
+	"done-hub/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logger.SetupLogger()
+}
+
+// fakeChatProvider 依次返回预设的内容，每次请求的用量相同
+type fakeChatProvider struct {
+	base.BaseProvider
+	contents []string
+	requests []*types.ChatCompletionRequest
+}
+
+func (p *fakeChatProvider) GetRequestHeaders() map[string]string {
+	return map[string]string{}
+}
+
+func (p *fakeChatProvider) CreateChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	p.requests = append(p.requests, request)
+	content := p.contents[min(len(p.requests), len(p.contents))-1]
+
+	p.Usage.PromptTokens = 10
+	p.Usage.CompletionTokens = 5
+	p.Usage.TotalTokens = 15
+
+	return &types.ChatCompletionResponse{
+		Choices: []types.ChatCompletionChoice{{
+			Message:      types.ChatCompletionMessage{Role: types.ChatMessageRoleAssistant, Content: content},
+			FinishReason: types.FinishReasonStop,
+		}},
+	}, nil
+}
+
+func (p *fakeChatProvider) CreateChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	return nil, nil
+}
+
+func newTestRelayChat(provider *fakeChatProvider, maxRetries int) *relayChat {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+	provider.Usage = &types.Usage{}
+	r := NewRelayChat(c)
+	r.provider = provider
+	r.chatRequest = types.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []types.ChatCompletionMessage{
+			{Role: types.ChatMessageRoleUser, Content: "hello"},
+		},
+		ResponseFormat: &types.ChatCompletionResponseFormat{
+			Type: "json_schema",
+			JsonSchema: &types.FormatJsonSchema{
+				Name: "answer",
+				Schema: map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"answer": map[string]any{"type": "string"}},
+					"required":   []any{"answer"},
+				},
+			},
+		},
+	}
+	r.pipeline = plugin.Build(model.PluginPipeline{{
+		Name:   "json_schema",
+		Params: map[string]any{"max_retries": maxRetries},
+	}})
+	return r
+}
+
+func TestCreateChatCompletionRetry(t *testing.T) {
+	provider := &fakeChatProvider{contents: []string{`{"result":"42"}`, `not json`, `{"answer":"42"}`}}
+	r := newTestRelayChat(provider, 2)
+
+	response, err := r.createChatCompletion(provider)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"answer":"42"}`, response.GetContent())
+	assert.Len(t, provider.requests, 3)
+
+	// 每次重试都在上一次的请求后追加回答和错误信息
+	assert.Len(t, provider.requests[0].Messages, 1)
+	assert.Len(t, provider.requests[1].Messages, 3)
+	assert.Len(t, provider.requests[2].Messages, 5)
+	assert.Len(t, r.chatRequest.Messages, 1)
+
+	// 用量为所有请求之和
+	usage := provider.GetUsage()
+	assert.Equal(t, 30, usage.PromptTokens)
+	assert.Equal(t, 15, usage.CompletionTokens)
+	assert.Equal(t, 45, usage.TotalTokens)
+
+	result := r.c.MustGet("structured_output").(*plugin.StructuredOutputResult)
+	assert.Equal(t, plugin.StructuredOutputRetried, result.Status)
+	assert.Equal(t, 2, result.Retries)
+}
+
+func TestCreateChatCompletionRetryExhausted(t *testing.T) {
+	provider := &fakeChatProvider{contents: []string{`{"result":"42"}`}}
+	r := newTestRelayChat(provider, 1)
+
+	response, err := r.createChatCompletion(provider)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"result":"42"}`, response.GetContent())
+	assert.Len(t, provider.requests, 2)
+	assert.Equal(t, 20, provider.GetUsage().PromptTokens)
+
+	result := r.c.MustGet("structured_output").(*plugin.StructuredOutputResult)
+	assert.Equal(t, plugin.StructuredOutputInvalid, result.Status)
+}
+
+func TestCreateChatCompletionWithoutRetry(t *testing.T) {
+	provider := &fakeChatProvider{contents: []string{`{"answer":"42"}`}}
+	r := newTestRelayChat(provider, 2)
+
+	_, err := r.createChatCompletion(provider)
+	assert.Nil(t, err)
+	assert.Len(t, provider.requests, 1)
+	assert.Equal(t, 15, provider.GetUsage().TotalTokens)
+
+	result := r.c.MustGet("structured_output").(*plugin.StructuredOutputResult)
+	assert.Equal(t, plugin.StructuredOutputValid, result.Status)
+}
@@ -9,6 +9,7 @@ import (
 	"done-hub/providers/base"
 	"done-hub/providers/claude"
 	commonadapter "done-hub/providers/common"
+	"done-hub/relay/toolcall"
 	"done-hub/safty"
 	"done-hub/types"
 	"encoding/json"
@@ -91,7 +92,7 @@ func (r *relayClaudeOnly) send() (err *types.OpenAIErrorWithStatusCode, done boo
 	// 如果没有直接实现 Claude 接口，检查是否实现了 ChatInterface，使用通用适配器
 	if baseChatProvider, ok := r.provider.(base.ChatInterface); ok {
 		// 使用通用适配器，适用于所有实现了ChatInterface但未实现Claude接口的Provider
-		adapter := commonadapter.NewClaudeAdapter(baseChatProvider)
+		adapter := commonadapter.NewClaudeAdapter(toolcall.Wrap(baseChatProvider))
 		chatProvider = adapter
 		logger.SysLog(fmt.Sprintf("[Claude Relay] 使用通用适配器为 Provider 类型 %T 提供 Claude 支持", r.provider))
 		return r.sendWithClaudeInterface(chatProvider)
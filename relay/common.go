@@ -121,6 +121,25 @@ func fetchChannelById(channelId int) (*model.Channel, error) {
 
 func fetchChannelByModel(c *gin.Context, modelName string) (*model.Channel, error) {
 	group := c.GetString("token_group")
+	filters := getChannelFilters(c, modelName)
+	if filter := getScriptChannelFilter(c, group, modelName, filters); filter != nil {
+		filters = append(filters, filter)
+	}
+
+	channel, err := model.ChannelGroup.Next(group, modelName, filters...)
+	if err != nil {
+		message := fmt.Sprintf("当前分组 %s 下对于模型 %s 无可用渠道", group, modelName)
+		if channel != nil {
+			logger.SysError(fmt.Sprintf("渠道不存在：%d", channel.Id))
+			message = "数据库一致性已被破坏，请联系管理员"
+		}
+		return nil, errors.New(message)
+	}
+
+	return channel, nil
+}
+
+func getChannelFilters(c *gin.Context, modelName string) []model.ChannelsFilterFunc {
 	skipOnlyChat := c.GetBool("skip_only_chat")
 	isStream := c.GetBool("is_stream")
 
@@ -144,17 +163,7 @@ func fetchChannelByModel(c *gin.Context, modelName string) (*model.Channel, erro
 		filters = append(filters, model.FilterDisabledStream(modelName))
 	}
 
-	channel, err := model.ChannelGroup.Next(group, modelName, filters...)
-	if err != nil {
-		message := fmt.Sprintf("当前分组 %s 下对于模型 %s 无可用渠道", group, modelName)
-		if channel != nil {
-			logger.SysError(fmt.Sprintf("渠道不存在：%d", channel.Id))
-			message = "数据库一致性已被破坏，请联系管理员"
-		}
-		return nil, errors.New(message)
-	}
-
-	return channel, nil
+	return filters
 }
 
 func responseJsonClient(c *gin.Context, data interface{}) *types.OpenAIErrorWithStatusCode {
@@ -435,11 +444,21 @@ func shouldRetryBadRequest(channelType int, apiErr *types.OpenAIErrorWithStatusC
 	}
 }
 
-func processChannelRelayError(ctx context.Context, channelId int, channelName string, err *types.OpenAIErrorWithStatusCode, channelType int) {
+func processChannelRelayError(ctx context.Context, channelId int, channelName string, modelName string, err *types.OpenAIErrorWithStatusCode, channelType int) {
 	logger.LogError(ctx, fmt.Sprintf("relay error (channel #%d(%s)): %s", channelId, channelName, err.Message))
+	// 熔断只是临时摘除，密钥失效等致命错误仍然需要禁用渠道
 	if controller.ShouldDisableChannel(channelType, err) {
 		controller.DisableChannel(channelId, channelName, err.Message, true)
+		return
 	}
+
+	if config.CircuitBreakerEnabled {
+		controller.RecordChannelFailure(channelId, channelType, modelName, err)
+	}
+}
+
+func processChannelRelaySuccess(channelId int, modelName string) {
+	model.ChannelGroup.RecordCircuitSuccess(channelId, modelName)
 }
 
 var (
@@ -6,6 +6,9 @@ import (
 	providersBase "done-hub/providers/base"
 	"done-hub/safty"
 	"done-hub/types"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
 	"net/http"
 	"strings"
 
@@ -67,6 +70,7 @@ func (r *relayEmbeddings) send() (err *types.OpenAIErrorWithStatusCode, done boo
 	if err != nil {
 		return
 	}
+	formatEmbeddingResponse(&r.request, response)
 	err = responseJsonClient(r.c, response)
 
 	if err != nil {
@@ -75,3 +79,85 @@ func (r *relayEmbeddings) send() (err *types.OpenAIErrorWithStatusCode, done boo
 
 	return
 }
+
+// 统一处理 dimensions 与 encoding_format，部分供应商不支持时由网关截断或编码，保证切换供应商时返回格式一致
+func formatEmbeddingResponse(request *types.EmbeddingRequest, response *types.EmbeddingResponse) {
+	isBase64 := request.EncodingFormat == "base64"
+	if request.Dimensions <= 0 && !isBase64 {
+		return
+	}
+
+	for i := range response.Data {
+		values, ok := parseEmbeddingValues(response.Data[i].Embedding)
+		if !ok {
+			continue
+		}
+
+		if request.Dimensions > 0 && len(values) > request.Dimensions {
+			values = truncateEmbedding(values, request.Dimensions)
+		}
+
+		if isBase64 {
+			response.Data[i].Embedding = encodeEmbeddingBase64(values)
+		} else {
+			response.Data[i].Embedding = values
+		}
+	}
+}
+
+func parseEmbeddingValues(embedding any) ([]float64, bool) {
+	switch value := embedding.(type) {
+	case []float64:
+		return value, true
+	case []float32:
+		values := make([]float64, len(value))
+		for i, v := range value {
+			values[i] = float64(v)
+		}
+		return values, true
+	case []any:
+		values := make([]float64, 0, len(value))
+		for _, item := range value {
+			v, ok := item.(float64)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, v)
+		}
+		return values, true
+	}
+
+	// 已经是 base64 编码的结果保持原样
+	return nil, false
+}
+
+// 截断后重新归一化，与 OpenAI text-embedding-3 的 dimensions 行为一致
+func truncateEmbedding(values []float64, dimensions int) []float64 {
+	values = values[:dimensions]
+
+	var norm float64
+	for _, v := range values {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		return values
+	}
+
+	normalized := make([]float64, dimensions)
+	for i, v := range values {
+		normalized[i] = v / norm
+	}
+
+	return normalized
+}
+
+// 按 OpenAI 的格式编码为 little-endian float32 的 base64 字符串
+func encodeEmbeddingBase64(values []float64) string {
+	buffer := make([]byte, len(values)*4)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(buffer[i*4:], math.Float32bits(float32(v)))
+	}
+
+	return base64.StdEncoding.EncodeToString(buffer)
+}
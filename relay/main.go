@@ -37,7 +37,13 @@ func Relay(c *gin.Context) {
 	}
 
 	c.Set("is_stream", relay.IsStream())
-	if err := relay.setProvider(relay.getOriginalModel()); err != nil {
+	if err := applyRequestScripts(c, relay); err != nil {
+		openaiErr := common.StringErrorWrapperLocal(err.Error(), "one_hub_error", http.StatusForbidden)
+		relay.HandleJsonError(openaiErr)
+		return
+	}
+
+	if err := applyModelRouter(c, relay); err != nil {
 		openaiErr := common.StringErrorWrapperLocal(err.Error(), "one_hub_error", http.StatusServiceUnavailable)
 		relay.HandleJsonError(openaiErr)
 		return
@@ -48,31 +54,61 @@ func Relay(c *gin.Context) {
 		defer heartbeat.Close()
 	}
 
+	// 请求时指定跳过的渠道，降级时需要保留
+	skipChannelIds, _ := utils.GetGinValue[[]int](c, "skip_channel_ids")
+
+	apiErr, fallback := relayModel(c, relay)
+	if apiErr != nil && fallback {
+		apiErr = relayFallback(c, relay, apiErr, skipChannelIds)
+	}
+
+	if apiErr != nil {
+		if heartbeat != nil && heartbeat.IsSafeWriteStream() {
+			relay.HandleStreamError(apiErr)
+			return
+		}
+
+		relay.HandleJsonError(apiErr)
+	}
+}
+
+// relayModel 使用当前模型请求，失败时按 RetryTimes 更换渠道重试
+// fallback 表示所有渠道都失败且可以降级到其他模型
+func relayModel(c *gin.Context, relay RelayBaseInterface) (apiErr *types.OpenAIErrorWithStatusCode, fallback bool) {
+	if err := relay.setProvider(relay.getOriginalModel()); err != nil {
+		return common.StringErrorWrapperLocal(err.Error(), "one_hub_error", http.StatusServiceUnavailable), true
+	}
+
 	apiErr, done := RelayHandler(relay)
 	if apiErr == nil {
 		metrics.RecordProvider(c, 200)
+		processChannelRelaySuccess(c.GetInt("channel_id"), c.GetString("original_model"))
 		return
 	}
 
 	channel := relay.getProvider().GetChannel()
-	go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
+	go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, c.GetString("original_model"), apiErr, channel.Type)
 
 	retryTimes := config.RetryTimes
 	if done || !shouldRetry(c, apiErr, channel.Type) {
 		logger.LogError(c.Request.Context(), fmt.Sprintf("relay error happen, status code is %d, won't retry in this case", apiErr.StatusCode))
-		retryTimes = 0
+		return
 	}
 
 	startTime := c.GetTime("requestStartTime")
 	timeout := time.Duration(config.RetryTimeOut) * time.Second
+	fallback = true
+	// 当前失败的渠道是否已经冻结
+	cooled := false
 
 	for i := retryTimes; i > 0; i-- {
 		// 冻结通道
 		shouldCooldowns(c, channel, apiErr)
+		cooled = true
 
 		if time.Since(startTime) > timeout {
 			apiErr = common.StringErrorWrapperLocal("重试超时，上游负载已饱和，请稍后再试", "system_error", http.StatusTooManyRequests)
-			break
+			return apiErr, false
 		}
 
 		if err := relay.setProvider(relay.getOriginalModel()); err != nil {
@@ -84,22 +120,50 @@ func Relay(c *gin.Context) {
 		apiErr, done = RelayHandler(relay)
 		if apiErr == nil {
 			metrics.RecordProvider(c, 200)
-			return
+			processChannelRelaySuccess(c.GetInt("channel_id"), c.GetString("original_model"))
+			return nil, false
 		}
-		go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
+		cooled = false
+		go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, c.GetString("original_model"), apiErr, channel.Type)
 		if done || !shouldRetry(c, apiErr, channel.Type) {
-			break
+			return apiErr, false
 		}
 	}
 
-	if apiErr != nil {
-		if heartbeat != nil && heartbeat.IsSafeWriteStream() {
-			relay.HandleStreamError(apiErr)
-			return
-		}
+	// 重试用尽时最后一次失败的渠道还没有冻结，没有重试时保持原来的行为不冻结
+	if retryTimes > 0 && !cooled {
+		shouldCooldowns(c, channel, apiErr)
+	}
 
-		relay.HandleJsonError(apiErr)
+	return
+}
+
+// relayFallback 主模型重试用尽后按降级链依次改用其他模型，计费使用实际模型的价格
+func relayFallback(c *gin.Context, relay RelayBaseInterface, apiErr *types.OpenAIErrorWithStatusCode, skipChannelIds []int) *types.OpenAIErrorWithStatusCode {
+	requestModel := relay.getOriginalModel()
+	tokenSetting, _ := utils.GetGinValue[*model.TokenSetting](c, "token_setting")
+	chain := model.GetModelFallbackChain(tokenSetting, requestModel)
+	if len(chain) == 0 {
+		return apiErr
+	}
+
+	for _, fallbackModel := range chain {
+		logger.LogError(c.Request.Context(), fmt.Sprintf("model %s failed, fallback to %s", relay.getOriginalModel(), fallbackModel))
+
+		// 不同模型的渠道互不影响，重新选择渠道
+		c.Set("skip_channel_ids", skipChannelIds)
+		c.Set("fallback_from", requestModel)
+		c.Header("X-Fallback-Model", fallbackModel)
+		relay.setRoutedModel(fallbackModel)
+
+		var fallback bool
+		apiErr, fallback = relayModel(c, relay)
+		if apiErr == nil || !fallback {
+			return apiErr
+		}
 	}
+
+	return apiErr
 }
 
 func RelayHandler(relay RelayBaseInterface) (err *types.OpenAIErrorWithStatusCode, done bool) {
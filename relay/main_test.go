@@ -0,0 +1,234 @@
+package relay
+
+import (
+	"done-hub/common/cache"
+	"done-hub/common/config"
+	"done-hub/common/utils"
+	"done-hub/model"
+	"done-hub/providers/base"
+	"done-hub/types"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeFallbackRelay 按模型依次选择渠道，渠道的返回状态码为 0 时请求成功
+type fakeFallbackRelay struct {
+	relayBase
+	channels map[string][]int
+	status   map[int]int
+	attempts []string
+	// 每个模型第一次选择渠道时请求跳过的渠道
+	skipChannelIds map[string][]int
+}
+
+func (r *fakeFallbackRelay) setRequest() error {
+	return nil
+}
+
+func (r *fakeFallbackRelay) getPromptTokens() (int, error) {
+	return 10, nil
+}
+
+func (r *fakeFallbackRelay) setProvider(modelName string) error {
+	skipChannelIds, _ := utils.GetGinValue[[]int](r.c, "skip_channel_ids")
+	if _, ok := r.skipChannelIds[modelName]; !ok {
+		r.skipChannelIds[modelName] = slices.Clone(skipChannelIds)
+	}
+
+	for _, channelId := range r.channels[modelName] {
+		if slices.Contains(skipChannelIds, channelId) {
+			continue
+		}
+
+		r.c.Set("channel_id", channelId)
+		r.c.Set("original_model", modelName)
+		r.c.Set("new_model", modelName)
+		r.provider = &fakeChatProvider{BaseProvider: base.BaseProvider{Channel: &model.Channel{Id: channelId}}}
+		r.modelName = modelName
+		return nil
+	}
+
+	return errors.New("no available channel")
+}
+
+func (r *fakeFallbackRelay) send() (*types.OpenAIErrorWithStatusCode, bool) {
+	channelId := r.provider.GetChannel().Id
+	r.attempts = append(r.attempts, fmt.Sprintf("%s#%d", r.modelName, channelId))
+
+	if status := r.status[channelId]; status != 0 {
+		return &types.OpenAIErrorWithStatusCode{
+			OpenAIError: types.OpenAIError{Message: "upstream error", Type: "upstream_error"},
+			StatusCode:  status,
+		}, false
+	}
+
+	usage := r.provider.GetUsage()
+	usage.CompletionTokens = 5
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return nil, false
+}
+
+// setupRelayFallbackTest 准备用户、令牌和价格，返回请求 requestModel 的 relay
+func setupRelayFallbackTest(t *testing.T, requestModel string, retryTimes int, fallback map[string][]string) *fakeFallbackRelay {
+	cache.InitCacheManager()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, db.AutoMigrate(&model.User{}, &model.Token{}, &model.Channel{}, &model.Log{}, &model.PriceOverride{}))
+	// 内存数据库每个连接是独立的
+	sqlDB, err := db.DB()
+	assert.Nil(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	assert.Nil(t, db.Create(&model.User{Id: 1, Username: "user", Quota: 1000000, AffCode: "aff", AccessToken: "access"}).Error)
+	assert.Nil(t, db.Session(&gorm.Session{SkipHooks: true}).Create(&model.Token{Id: 1, UserId: 1, Key: "key", Name: "token", RemainQuota: 1000000}).Error)
+
+	oldDB, oldPricing := model.DB, model.PricingInstance
+	oldLogConsume, oldBatch := config.LogConsumeEnabled, config.BatchUpdateEnabled
+	oldRetryTimes, oldRetryTimeOut, oldCooldownSeconds := config.RetryTimes, config.RetryTimeOut, config.RetryCooldownSeconds
+	model.DB = db
+	model.PricingInstance = &model.Pricing{Prices: map[string]*model.Price{
+		"gpt-4o":         {Type: model.TokensPriceType, Input: 2.5, Output: 10},
+		"gpt-4o-mini":    {Type: model.TokensPriceType, Input: 0.15, Output: 0.6},
+		"claude-3-haiku": {Type: model.TokensPriceType, Input: 0.25, Output: 1.25},
+	}}
+	config.LogConsumeEnabled = true
+	config.BatchUpdateEnabled = false
+	config.RetryTimes = retryTimes
+	config.RetryTimeOut = 60
+	config.RetryCooldownSeconds = 60
+	t.Cleanup(func() {
+		model.DB, model.PricingInstance = oldDB, oldPricing
+		config.LogConsumeEnabled, config.BatchUpdateEnabled = oldLogConsume, oldBatch
+		config.RetryTimes, config.RetryTimeOut, config.RetryCooldownSeconds = oldRetryTimes, oldRetryTimeOut, oldCooldownSeconds
+		model.ChannelGroup.Cooldowns = sync.Map{}
+	})
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Set("id", 1)
+	c.Set("token_id", 1)
+	c.Set("token_name", "token")
+	c.Set("group_ratio", 1.0)
+	c.Set("requestStartTime", time.Now())
+	c.Set("token_setting", &model.TokenSetting{Fallback: fallback})
+
+	r := &fakeFallbackRelay{skipChannelIds: map[string][]int{}}
+	r.c = c
+	r.setOriginalModel(requestModel)
+	return r
+}
+
+// relayWithFallback 与 Relay 中的处理一致
+func relayWithFallback(r *fakeFallbackRelay) *types.OpenAIErrorWithStatusCode {
+	skipChannelIds, _ := utils.GetGinValue[[]int](r.c, "skip_channel_ids")
+	apiErr, fallback := relayModel(r.c, r)
+	if apiErr != nil && fallback {
+		apiErr = relayFallback(r.c, r, apiErr, skipChannelIds)
+	}
+	return apiErr
+}
+
+func TestRelayFallback(t *testing.T) {
+	r := setupRelayFallbackTest(t, "gpt-4o", 1, map[string][]string{"gpt-4o": {"gpt-4o-mini", "claude-3-haiku"}})
+	r.channels = map[string][]int{"gpt-4o": {1, 2, 9}, "gpt-4o-mini": {3}, "claude-3-haiku": {4, 5}}
+	r.status = map[int]int{1: http.StatusTooManyRequests, 2: http.StatusTooManyRequests, 3: http.StatusInternalServerError, 4: http.StatusTooManyRequests}
+	// 请求时指定跳过的渠道
+	r.c.Set("skip_channel_ids", []int{9})
+
+	apiErr := relayWithFallback(r)
+	assert.Nil(t, apiErr)
+
+	// 主模型重试用尽后按降级链的顺序请求
+	assert.Equal(t, []string{"gpt-4o#1", "gpt-4o#2", "gpt-4o-mini#3", "claude-3-haiku#4", "claude-3-haiku#5"}, r.attempts)
+	// 每个模型重新选择渠道时保留请求指定的跳过渠道
+	assert.Equal(t, map[string][]int{"gpt-4o": {9}, "gpt-4o-mini": {9}, "claude-3-haiku": {9}}, r.skipChannelIds)
+	assert.Equal(t, "claude-3-haiku", r.c.Writer.Header().Get("X-Fallback-Model"))
+
+	// 使用降级后模型的价格计费
+	var log model.Log
+	assert.Eventually(t, func() bool {
+		return model.DB.Where("type = ?", model.LogTypeConsume).First(&log).Error == nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "claude-3-haiku", log.ModelName)
+	assert.Equal(t, 5, log.ChannelId)
+	assert.Equal(t, 9, log.Quota)
+	metadata := log.Metadata.Data()
+	assert.Equal(t, "gpt-4o", metadata["fallback_from"])
+	assert.Equal(t, 0.25, metadata["input_ratio"])
+
+	// 失败的渠道按实际请求的模型冻结
+	assert.True(t, model.ChannelGroup.IsInCooldown(1, "gpt-4o"))
+	assert.True(t, model.ChannelGroup.IsInCooldown(2, "gpt-4o"))
+	assert.True(t, model.ChannelGroup.IsInCooldown(4, "claude-3-haiku"))
+	assert.False(t, model.ChannelGroup.IsInCooldown(5, "claude-3-haiku"))
+}
+
+func TestRelayFallbackExhausted(t *testing.T) {
+	r := setupRelayFallbackTest(t, "gpt-4o", 1, map[string][]string{"gpt-4o": {"gpt-4o-mini"}})
+	r.channels = map[string][]int{"gpt-4o": {1}, "gpt-4o-mini": {2}}
+	r.status = map[int]int{1: http.StatusTooManyRequests, 2: http.StatusTooManyRequests}
+
+	apiErr := relayWithFallback(r)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, []string{"gpt-4o#1", "gpt-4o-mini#2"}, r.attempts)
+	assert.True(t, model.ChannelGroup.IsInCooldown(1, "gpt-4o"))
+	assert.True(t, model.ChannelGroup.IsInCooldown(2, "gpt-4o-mini"))
+}
+
+func TestRelayFallbackNotRetryable(t *testing.T) {
+	r := setupRelayFallbackTest(t, "gpt-4o", 1, map[string][]string{"gpt-4o": {"gpt-4o-mini"}})
+	r.channels = map[string][]int{"gpt-4o": {1, 2}, "gpt-4o-mini": {3}}
+	r.status = map[int]int{1: http.StatusBadRequest}
+
+	// 不可重试的错误直接返回，不降级
+	apiErr := relayWithFallback(r)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, []string{"gpt-4o#1"}, r.attempts)
+	assert.Empty(t, r.c.Writer.Header().Get("X-Fallback-Model"))
+}
+
+func TestRelayModelWithoutRetry(t *testing.T) {
+	r := setupRelayFallbackTest(t, "gpt-4o", 0, map[string][]string{"gpt-4o": {"gpt-4o-mini"}})
+	r.channels = map[string][]int{"gpt-4o": {1, 2}, "gpt-4o-mini": {3}}
+	r.status = map[int]int{1: http.StatusTooManyRequests}
+
+	// 没有重试次数时不冻结渠道，直接降级
+	apiErr := relayWithFallback(r)
+	assert.Nil(t, apiErr)
+	assert.Equal(t, []string{"gpt-4o#1", "gpt-4o-mini#3"}, r.attempts)
+	assert.False(t, model.ChannelGroup.IsInCooldown(1, "gpt-4o"))
+
+	assert.Eventually(t, func() bool {
+		var count int64
+		model.DB.Model(&model.Log{}).Where("type = ? AND model_name = ?", model.LogTypeConsume, "gpt-4o-mini").Count(&count)
+		return count == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRelayModelCooldownOnce(t *testing.T) {
+	r := setupRelayFallbackTest(t, "gpt-4o", 2, nil)
+	r.channels = map[string][]int{"gpt-4o": {1, 2}}
+	r.status = map[int]int{1: http.StatusTooManyRequests, 2: http.StatusTooManyRequests}
+
+	apiErr, fallback := relayModel(r.c, r)
+	assert.NotNil(t, apiErr)
+	assert.True(t, fallback)
+	assert.Equal(t, []string{"gpt-4o#1", "gpt-4o#2"}, r.attempts)
+
+	// 没有可用渠道提前结束重试时，最后失败的渠道只冻结一次
+	skipChannelIds, _ := utils.GetGinValue[[]int](r.c, "skip_channel_ids")
+	assert.Equal(t, []int{1, 2}, skipChannelIds)
+	assert.True(t, model.ChannelGroup.IsInCooldown(2, "gpt-4o"))
+}
@@ -46,6 +46,8 @@ func ListModelsByToken(c *gin.Context) {
 		})
 		return
 	}
+	// 路由模型对所有分组可见，请求时解析为分组下的实际模型
+	models = append(models, model.ModelRouterInstance.GetNames()...)
 	sort.Strings(models)
 
 	var groupOpenAIModels []*OpenAIModels
@@ -229,18 +231,30 @@ type ModelPrice struct {
 }
 
 type AvailableModelResponse struct {
-	Groups  []string     `json:"groups"`
-	OwnedBy string       `json:"owned_by"`
-	Price   *model.Price `json:"price"`
+	Groups        []string     `json:"groups"`
+	OwnedBy       string       `json:"owned_by"`
+	Price         *model.Price `json:"price"`
+	PriceOverride bool         `json:"price_override,omitempty"` // 价格为用户专属价格
 }
 
 func AvailableModel(c *gin.Context) {
 	groupName := c.GetString("group")
+	availableModels := getAvailableModels(groupName)
+
+	// 登录用户展示应用专属价格后的价格，令牌级的专属价格不在这里展示
+	if userId := c.GetInt("id"); userId > 0 {
+		for modelName, availableModel := range availableModels {
+			if override := model.GetPriceOverride(userId, 0, modelName); override != nil {
+				availableModel.Price = override.ApplyToPrice(availableModel.Price)
+				availableModel.PriceOverride = true
+			}
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
-		"data":    getAvailableModels(groupName),
+		"data":    availableModels,
 	})
 }
 
@@ -0,0 +1,39 @@
+package relay
+
+import (
+	"done-hub/model"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 支持路由模型的请求需要实现该接口，用于分析请求需要的模型能力
+type routeRequirementGetter interface {
+	getRouteRequirement() *model.RouteRequirement
+}
+
+// applyModelRouter 请求的是路由模型时，解析为实际模型并替换原始模型
+func applyModelRouter(c *gin.Context, relay RelayBaseInterface) error {
+	router := model.ModelRouterInstance.Get(relay.getOriginalModel())
+	if router == nil {
+		return nil
+	}
+
+	getter, ok := relay.(routeRequirementGetter)
+	if !ok {
+		return fmt.Errorf("当前接口不支持路由模型 %s", router.Name)
+	}
+
+	modelName, err := model.ModelRouterInstance.Resolve(router, c.GetString("token_group"), getter.getRouteRequirement(), func(modelName string) []model.ChannelsFilterFunc {
+		return getChannelFilters(c, modelName)
+	})
+	if err != nil {
+		return err
+	}
+
+	relay.setRoutedModel(modelName)
+	c.Set("model_router", router.Name)
+	c.Header("X-Routed-Model", modelName)
+
+	return nil
+}
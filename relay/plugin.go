@@ -0,0 +1,36 @@
+package relay
+
+import (
+	"done-hub/common/utils"
+	"done-hub/model"
+	"done-hub/relay/plugin"
+	"done-hub/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 插件要求重新请求的最大次数
+const maxPluginRetries = 5
+
+// getPluginPipeline 依次合并结构化输出、令牌、分组和渠道的插件，同名插件使用后面的配置
+// 令牌的插件由用户自行配置，放在管理员配置的分组和渠道插件之前，不能替换同名的管理员插件
+func getPluginPipeline(c *gin.Context, channel *model.Channel, request *types.ChatCompletionRequest, modelName string) *plugin.Pipeline {
+	pipelines := make([]model.PluginPipeline, 0, 4)
+	if structured := getStructuredOutputPipeline(c, channel, request, modelName); structured != nil {
+		pipelines = append(pipelines, structured)
+	}
+	if setting, ok := utils.GetGinValue[*model.TokenSetting](c, "token_setting"); ok && setting != nil {
+		pipelines = append(pipelines, setting.Plugins)
+	}
+	if group := model.GlobalUserGroupRatio.GetBySymbol(c.GetString("token_group")); group != nil {
+		pipelines = append(pipelines, group.GetPluginPipeline())
+	}
+	if channel != nil {
+		pipelines = append(pipelines, channel.GetPluginPipeline())
+	}
+
+	pipeline := plugin.Build(pipelines...)
+	c.Set("plugins", pipeline.Names())
+
+	return pipeline
+}
@@ -0,0 +1,256 @@
+package plugin
+
+import (
+	"done-hub/common/jsonschema"
+	"done-hub/common/logger"
+	"done-hub/common/utils"
+	"done-hub/types"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const jsonSchemaMaxRetries = 5
+
+// 结构化输出的校验结果
+const (
+	StructuredOutputValid    = "valid"    // 首次输出即符合
+	StructuredOutputRepaired = "repaired" // 去掉多余的文本后符合
+	StructuredOutputRetried  = "retried"  // 重新请求后符合
+	StructuredOutputInvalid  = "invalid"  // 重试后仍不符合
+)
+
+// StructuredOutputResult 记录到请求上下文和日志中
+type StructuredOutputResult struct {
+	Mode    string `json:"mode,omitempty"`
+	Status  string `json:"status"`
+	Retries int    `json:"retries,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// jsonSchemaPlugin 校验输出是否符合 JSON schema，不符合时带上错误信息重新请求
+// 未配置 schema 时使用请求中 response_format 的 schema，流式响应无法重试，只记录校验结果
+// Inject 为 true 时把 schema 写入系统提示词，用于不支持 response_format 的渠道
+type jsonSchemaPlugin struct {
+	Schema     any  `json:"schema,omitempty"`
+	MaxRetries int  `json:"max_retries,omitempty"`
+	Inject     bool `json:"inject,omitempty"`
+	schema     any
+	retries    int
+	recorded   bool
+}
+
+func init() {
+	Register("json_schema", func(params map[string]any) (any, error) {
+		p := &jsonSchemaPlugin{MaxRetries: 2}
+		if err := decodeParams(params, p); err != nil {
+			return nil, err
+		}
+
+		if p.MaxRetries < 0 || p.MaxRetries > jsonSchemaMaxRetries {
+			return nil, fmt.Errorf("max_retries 必须在 0 到 %d 之间", jsonSchemaMaxRetries)
+		}
+
+		schema, err := jsonschema.ParseSchema(p.Schema)
+		if err != nil {
+			return nil, errors.New("schema 无效")
+		}
+		p.schema = schema
+
+		return p, nil
+	})
+}
+
+func (p *jsonSchemaPlugin) getSchema(request *types.ChatCompletionRequest) any {
+	if p.schema != nil {
+		return p.schema
+	}
+
+	format := request.ResponseFormat
+	if format == nil || format.Type != "json_schema" || format.JsonSchema == nil {
+		return nil
+	}
+
+	schema, err := jsonschema.ParseSchema(format.JsonSchema.Schema)
+	if err != nil {
+		return nil
+	}
+	return schema
+}
+
+func (p *jsonSchemaPlugin) PreRequest(c *gin.Context, request *types.ChatCompletionRequest) error {
+	if !p.Inject {
+		return nil
+	}
+
+	schema := p.getSchema(request)
+	if schema == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	instruction := "Respond only with a JSON value that conforms to the following JSON schema, without any explanation or code fences:\n" + string(data)
+
+	messages := make([]types.ChatCompletionMessage, 0, len(request.Messages)+1)
+	if len(request.Messages) > 0 && request.Messages[0].IsSystemRole() {
+		system := request.Messages[0]
+		system.Content = strings.TrimSpace(system.StringContent() + "\n\n" + instruction)
+		messages = append(messages, system)
+		messages = append(messages, request.Messages[1:]...)
+	} else {
+		messages = append(messages, types.ChatCompletionMessage{Role: types.ChatMessageRoleSystem, Content: instruction})
+		messages = append(messages, request.Messages...)
+	}
+	request.Messages = messages
+
+	return nil
+}
+
+func (p *jsonSchemaPlugin) PostRequest(c *gin.Context, request *types.ChatCompletionRequest, response *types.ChatCompletionResponse) (*types.ChatCompletionRequest, error) {
+	schema := p.getSchema(request)
+	if schema == nil || len(response.Choices) == 0 {
+		return nil, nil
+	}
+
+	message := &response.Choices[0].Message
+	// 调用工具时没有输出内容
+	if len(message.ToolCalls) > 0 || message.FunctionCall != nil {
+		return nil, nil
+	}
+
+	original := message.StringContent()
+	content, validateErr := repairJSON(schema, original)
+	if validateErr == nil {
+		message.Content = content
+		status := StructuredOutputValid
+		if p.retries > 0 {
+			status = StructuredOutputRetried
+		} else if content != strings.TrimSpace(original) {
+			status = StructuredOutputRepaired
+		}
+		p.record(c, status, nil)
+		return nil, nil
+	}
+
+	if p.retries >= p.MaxRetries {
+		logger.LogWarn(c.Request.Context(), fmt.Sprintf("json_schema plugin: response still invalid after %d retries: %s", p.retries, validateErr.Error()))
+		p.record(c, StructuredOutputInvalid, validateErr)
+		return nil, nil
+	}
+	p.retries++
+
+	retry := *request
+	retry.Messages = make([]types.ChatCompletionMessage, 0, len(request.Messages)+2)
+	retry.Messages = append(retry.Messages, request.Messages...)
+	retry.Messages = append(retry.Messages,
+		types.ChatCompletionMessage{
+			Role:    types.ChatMessageRoleAssistant,
+			Content: original,
+		},
+		types.ChatCompletionMessage{
+			Role:    types.ChatMessageRoleUser,
+			Content: fmt.Sprintf("Your previous response does not match the required JSON schema: %s. Respond again with only the corrected JSON, without any explanation or code fences.", validateErr.Error()),
+		},
+	)
+
+	return &retry, nil
+}
+
+// PostResponse 流式响应结束后校验完整的内容
+func (p *jsonSchemaPlugin) PostResponse(c *gin.Context, request *types.ChatCompletionRequest, content string) {
+	if p.recorded {
+		return
+	}
+
+	schema := p.getSchema(request)
+	if schema == nil || content == "" {
+		return
+	}
+
+	if _, err := repairJSON(schema, content); err != nil {
+		p.record(c, StructuredOutputInvalid, err)
+		return
+	}
+	p.record(c, StructuredOutputValid, nil)
+}
+
+func (p *jsonSchemaPlugin) record(c *gin.Context, status string, err error) {
+	p.recorded = true
+
+	result := &StructuredOutputResult{
+		Mode:    c.GetString("structured_output_mode"),
+		Status:  status,
+		Retries: p.retries,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	c.Set("structured_output", result)
+
+	if !c.Writer.Written() {
+		c.Header("X-Structured-Output", status)
+	}
+}
+
+// repairJSON 依次尝试原始内容、去掉代码块、截取第一个 JSON 值，返回第一个符合 schema 的内容
+func repairJSON(schema any, content string) (string, error) {
+	content = strings.TrimSpace(content)
+	candidates := []string{content}
+	if extracted := extractJSON(content); extracted != content {
+		candidates = append(candidates, extracted)
+	}
+	if sliced := sliceJSON(content); sliced != "" && !utils.Contains(sliced, candidates) {
+		candidates = append(candidates, sliced)
+	}
+
+	var firstErr error
+	for _, candidate := range candidates {
+		err := jsonschema.ValidateJSON(schema, candidate)
+		if err == nil {
+			return candidate, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return content, firstErr
+}
+
+// extractJSON 去掉模型常加的 markdown 代码块
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+
+	content = strings.TrimPrefix(content, "```")
+	if index := strings.Index(content, "\n"); index >= 0 {
+		content = content[index+1:]
+	}
+	content = strings.TrimSuffix(strings.TrimSpace(content), "```")
+	return strings.TrimSpace(content)
+}
+
+// sliceJSON 截取文本中第一个 { 或 [ 到最后一个 } 或 ] 之间的内容
+func sliceJSON(content string) string {
+	start := strings.IndexAny(content, "{[")
+	if start < 0 {
+		return ""
+	}
+
+	closing := "}"
+	if content[start] == '[' {
+		closing = "]"
+	}
+	end := strings.LastIndex(content, closing)
+	if end <= start {
+		return ""
+	}
+	return content[start : end+1]
+}
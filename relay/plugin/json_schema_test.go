@@ -0,0 +1,205 @@
+package plugin
+
+import (
+	"done-hub/common/logger"
+	"done-hub/model"
+	"done-hub/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logger.SetupLogger()
+}
+
+func getTestContext() *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	return c
+}
+
+var answerSchema = map[string]any{
+	"type":       "object",
+	"properties": map[string]any{"answer": map[string]any{"type": "string"}},
+	"required":   []any{"answer"},
+}
+
+func getSchemaRequest() *types.ChatCompletionRequest {
+	return &types.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []types.ChatCompletionMessage{
+			{Role: types.ChatMessageRoleUser, Content: "hello"},
+		},
+		ResponseFormat: &types.ChatCompletionResponseFormat{
+			Type:       "json_schema",
+			JsonSchema: &types.FormatJsonSchema{Name: "answer", Schema: answerSchema},
+		},
+	}
+}
+
+func getContentResponse(content string) *types.ChatCompletionResponse {
+	return &types.ChatCompletionResponse{
+		Choices: []types.ChatCompletionChoice{{
+			Message: types.ChatCompletionMessage{Role: types.ChatMessageRoleAssistant, Content: content},
+		}},
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"plain", `{"a":1}`, `{"a":1}`},
+		{"fenced json", "```json\n{\"a\":1}\n```", `{"a":1}`},
+		{"fenced", "```\n{\"a\":1}\n```", `{"a":1}`},
+		{"fenced with spaces", "  ```json\n{\"a\":1}\n```  ", `{"a":1}`},
+		{"text", "hello", "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, extractJSON(tt.content))
+		})
+	}
+}
+
+func TestSliceJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"object", `Here it is: {"a":1} done`, `{"a":1}`},
+		{"array", `Result: [1,2] ok`, `[1,2]`},
+		{"nested", `x {"a":{"b":[1]}} y`, `{"a":{"b":[1]}}`},
+		{"object first", `{"a":[1]}`, `{"a":[1]}`},
+		{"no json", "hello", ""},
+		{"unclosed", `{"a":1`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sliceJSON(tt.content))
+		})
+	}
+}
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		valid   bool
+	}{
+		{"valid", `{"answer":"42"}`, `{"answer":"42"}`, true},
+		{"trim", "  {\"answer\":\"42\"}\n", `{"answer":"42"}`, true},
+		{"fenced", "```json\n{\"answer\":\"42\"}\n```", `{"answer":"42"}`, true},
+		{"surrounded", `Sure! {"answer":"42"} Hope it helps.`, `{"answer":"42"}`, true},
+		{"missing field", `{"result":"42"}`, `{"result":"42"}`, false},
+		{"wrong type", `{"answer":42}`, `{"answer":42}`, false},
+		{"not json", "I don't know", "I don't know", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repairJSON(answerSchema, tt.content)
+			assert.Equal(t, tt.want, got)
+			if tt.valid {
+				assert.Nil(t, err)
+			} else {
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func newJSONSchemaPlugin(t *testing.T, params map[string]any) *jsonSchemaPlugin {
+	pipeline := Build(model.PluginPipeline{{Name: "json_schema", Params: params}})
+	assert.Len(t, pipeline.plugins, 1)
+	return pipeline.plugins[0].(*jsonSchemaPlugin)
+}
+
+func TestJSONSchemaPostRequest(t *testing.T) {
+	t.Run("repaired", func(t *testing.T) {
+		c := getTestContext()
+		p := newJSONSchemaPlugin(t, nil)
+		response := getContentResponse("```json\n{\"answer\":\"42\"}\n```")
+
+		retry, err := p.PostRequest(c, getSchemaRequest(), response)
+		assert.Nil(t, err)
+		assert.Nil(t, retry)
+		assert.Equal(t, `{"answer":"42"}`, response.Choices[0].Message.Content)
+
+		result := c.MustGet("structured_output").(*StructuredOutputResult)
+		assert.Equal(t, StructuredOutputRepaired, result.Status)
+	})
+
+	t.Run("retry then invalid", func(t *testing.T) {
+		c := getTestContext()
+		p := newJSONSchemaPlugin(t, map[string]any{"max_retries": 1})
+		request := getSchemaRequest()
+
+		retry, err := p.PostRequest(c, request, getContentResponse(`{"result":"42"}`))
+		assert.Nil(t, err)
+		assert.NotNil(t, retry)
+		// 重试请求带上上一次的回答和错误信息，原始请求不变
+		assert.Len(t, retry.Messages, 3)
+		assert.Equal(t, types.ChatMessageRoleAssistant, retry.Messages[1].Role)
+		assert.Equal(t, types.ChatMessageRoleUser, retry.Messages[2].Role)
+		assert.Len(t, request.Messages, 1)
+
+		retry, err = p.PostRequest(c, retry, getContentResponse(`{"result":"42"}`))
+		assert.Nil(t, err)
+		assert.Nil(t, retry)
+
+		result := c.MustGet("structured_output").(*StructuredOutputResult)
+		assert.Equal(t, StructuredOutputInvalid, result.Status)
+		assert.Equal(t, 1, result.Retries)
+		assert.NotEmpty(t, result.Error)
+	})
+
+	t.Run("tool calls", func(t *testing.T) {
+		c := getTestContext()
+		p := newJSONSchemaPlugin(t, nil)
+		response := getContentResponse("")
+		response.Choices[0].Message.ToolCalls = []*types.ChatCompletionToolCalls{{Id: "call_1"}}
+
+		retry, err := p.PostRequest(c, getSchemaRequest(), response)
+		assert.Nil(t, err)
+		assert.Nil(t, retry)
+		_, exists := c.Get("structured_output")
+		assert.False(t, exists)
+	})
+}
+
+func TestJSONSchemaInject(t *testing.T) {
+	p := newJSONSchemaPlugin(t, map[string]any{"inject": true})
+
+	request := getSchemaRequest()
+	assert.Nil(t, p.PreRequest(getTestContext(), request))
+	assert.Len(t, request.Messages, 2)
+	assert.True(t, request.Messages[0].IsSystemRole())
+	assert.Contains(t, request.Messages[0].StringContent(), `"answer"`)
+
+	request = getSchemaRequest()
+	request.Messages = append([]types.ChatCompletionMessage{{Role: types.ChatMessageRoleSystem, Content: "Be brief."}}, request.Messages...)
+	assert.Nil(t, p.PreRequest(getTestContext(), request))
+	assert.Len(t, request.Messages, 2)
+	assert.Contains(t, request.Messages[0].StringContent(), "Be brief.")
+	assert.Contains(t, request.Messages[0].StringContent(), `"answer"`)
+}
+
+func TestJSONSchemaParams(t *testing.T) {
+	_, err := create(model.PluginConfig{Name: "json_schema", Params: map[string]any{"max_retries": 6}})
+	assert.NotNil(t, err)
+
+	_, err = create(model.PluginConfig{Name: "json_schema", Params: map[string]any{"schema": "not json"}})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,102 @@
+package plugin
+
+import (
+	"bytes"
+	"done-hub/types"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 这些参数决定了请求的路由和返回方式，不允许插件修改
+var protectedParams = map[string]bool{
+	"model":    true,
+	"messages": true,
+	"stream":   true,
+}
+
+// paramsPlugin 删除或强制设置请求参数
+type paramsPlugin struct {
+	Strip []string       `json:"strip,omitempty"`
+	Force map[string]any `json:"force,omitempty"`
+}
+
+func init() {
+	Register("params", func(params map[string]any) (any, error) {
+		p := &paramsPlugin{}
+		if err := decodeParams(params, p); err != nil {
+			return nil, err
+		}
+
+		for _, name := range p.Strip {
+			if protectedParams[name] {
+				return nil, fmt.Errorf("不允许删除参数 %s", name)
+			}
+		}
+		for name := range p.Force {
+			if protectedParams[name] {
+				return nil, fmt.Errorf("不允许设置参数 %s", name)
+			}
+		}
+		if err := validateForceParams(p.Force); err != nil {
+			return nil, err
+		}
+		return p, nil
+	})
+}
+
+// validateForceParams 强制设置的参数需要写入请求结构体，未知的参数会被丢弃，类型错误会导致每个请求都失败，所以在创建时检查
+func validateForceParams(force map[string]any) error {
+	if len(force) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(force)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&types.ChatCompletionRequest{}); err != nil {
+		return fmt.Errorf("强制设置的参数无效: %s", err.Error())
+	}
+	return nil
+}
+
+func (p *paramsPlugin) PreRequest(c *gin.Context, request *types.ChatCompletionRequest) error {
+	if len(p.Strip) == 0 && len(p.Force) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	var requestMap map[string]any
+	if err := json.Unmarshal(data, &requestMap); err != nil {
+		return err
+	}
+
+	for _, name := range p.Strip {
+		delete(requestMap, name)
+	}
+	for name, value := range p.Force {
+		requestMap[name] = value
+	}
+
+	if data, err = json.Marshal(requestMap); err != nil {
+		return err
+	}
+
+	newRequest := types.ChatCompletionRequest{}
+	if err := json.Unmarshal(data, &newRequest); err != nil {
+		return err
+	}
+	newRequest.OneOtherArg = request.OneOtherArg
+	*request = newRequest
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package plugin
+
+import (
+	"done-hub/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]any
+		valid  bool
+	}{
+		{"force", map[string]any{"force": map[string]any{"max_tokens": 100, "temperature": 0.5, "stop": []any{"\n"}}}, true},
+		{"strip", map[string]any{"strip": []any{"temperature", "top_p"}}, true},
+		{"protected strip", map[string]any{"strip": []any{"model"}}, false},
+		{"protected force", map[string]any{"force": map[string]any{"stream": false}}, false},
+		// 未知参数写入请求时会被丢弃
+		{"unknown force", map[string]any{"force": map[string]any{"max_token": 100}}, false},
+		// 类型错误会导致每个请求都失败
+		{"wrong type", map[string]any{"force": map[string]any{"max_tokens": "100"}}, false},
+		{"wrong nested type", map[string]any{"force": map[string]any{"stream_options": map[string]any{"include_usage": "yes"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(model.PluginPipeline{{Name: "params", Params: tt.params}})
+			if tt.valid {
+				assert.Nil(t, err)
+			} else {
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func TestParamsPreRequest(t *testing.T) {
+	pipeline := Build(model.PluginPipeline{{Name: "params", Params: map[string]any{
+		"strip": []any{"top_p"},
+		"force": map[string]any{"max_tokens": 100, "temperature": 0.5},
+	}}})
+	assert.Equal(t, []string{"params"}, pipeline.Names())
+
+	request := getSchemaRequest()
+	topP := 0.9
+	request.TopP = &topP
+	request.OneOtherArg = "other"
+
+	assert.Nil(t, pipeline.PreRequest(getTestContext(), request))
+	assert.Equal(t, 100, request.MaxTokens)
+	assert.Equal(t, 0.5, *request.Temperature)
+	assert.Nil(t, request.TopP)
+	assert.Equal(t, "gpt-4o", request.Model)
+	assert.Len(t, request.Messages, 1)
+	assert.Equal(t, "other", request.OneOtherArg)
+
+	// 参数无效的插件不会加入
+	pipeline = Build(model.PluginPipeline{{Name: "params", Params: map[string]any{"force": map[string]any{"max_tokens": "100"}}}})
+	assert.True(t, pipeline.Empty())
+}
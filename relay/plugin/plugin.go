@@ -0,0 +1,265 @@
+package plugin
+
+import (
+	"done-hub/common/logger"
+	"done-hub/model"
+	"done-hub/types"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreRequestHook 发送到上游之前修改请求
+type PreRequestHook interface {
+	PreRequest(c *gin.Context, request *types.ChatCompletionRequest) error
+}
+
+// PostRequestHook 收到上游的非流式响应后、返回给客户端之前执行
+// 返回非空的请求时使用该请求重新发送
+type PostRequestHook interface {
+	PostRequest(c *gin.Context, request *types.ChatCompletionRequest, response *types.ChatCompletionResponse) (*types.ChatCompletionRequest, error)
+}
+
+// StreamChunkHook 处理每个流式响应块
+type StreamChunkHook interface {
+	StreamChunk(c *gin.Context, chunk *types.ChatCompletionStreamResponse) error
+}
+
+// StreamFlusher 流式结束时返回插件缓存的剩余内容
+type StreamFlusher interface {
+	FlushStream() string
+}
+
+// PostResponseHook 响应返回给客户端之后执行，content 为返回的完整文本
+type PostResponseHook interface {
+	PostResponse(c *gin.Context, request *types.ChatCompletionRequest, content string)
+}
+
+// Factory 根据参数创建插件，每次请求都会创建新的实例
+type Factory func(params map[string]any) (any, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{}
+)
+
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[name] = factory
+}
+
+// GetNames 返回所有已注册的插件
+func GetNames() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func create(config model.PluginConfig) (any, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[config.Name]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("插件不存在: %s", config.Name)
+	}
+
+	instance, err := factory(config.Params)
+	if err != nil {
+		return nil, fmt.Errorf("插件 %s 参数错误: %s", config.Name, err.Error())
+	}
+	return instance, nil
+}
+
+// Validate 检查插件是否存在以及参数是否正确
+func Validate(pipeline model.PluginPipeline) error {
+	if err := pipeline.Validate(); err != nil {
+		return err
+	}
+
+	for _, config := range pipeline {
+		if _, err := create(config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeParams 将参数解析到插件的配置结构
+func decodeParams(params map[string]any, target any) error {
+	if len(params) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+type Pipeline struct {
+	names   []string
+	plugins []any
+}
+
+// Build 合并多个来源的插件，按传入顺序执行，同名插件使用后面的配置
+func Build(pipelines ...model.PluginPipeline) *Pipeline {
+	merged := make([]model.PluginConfig, 0)
+	for _, pipeline := range pipelines {
+		for _, config := range pipeline {
+			for i, exists := range merged {
+				if exists.Name == config.Name {
+					merged = append(merged[:i], merged[i+1:]...)
+					break
+				}
+			}
+			merged = append(merged, config)
+		}
+	}
+
+	p := &Pipeline{}
+	for _, config := range merged {
+		instance, err := create(config)
+		if err != nil {
+			logger.SysError("plugin pipeline: " + err.Error())
+			continue
+		}
+		p.names = append(p.names, config.Name)
+		p.plugins = append(p.plugins, instance)
+	}
+
+	return p
+}
+
+func (p *Pipeline) Empty() bool {
+	return p == nil || len(p.plugins) == 0
+}
+
+func (p *Pipeline) Names() []string {
+	if p == nil {
+		return nil
+	}
+	return p.names
+}
+
+func (p *Pipeline) PreRequest(c *gin.Context, request *types.ChatCompletionRequest) error {
+	if p == nil {
+		return nil
+	}
+
+	for i, instance := range p.plugins {
+		if hook, ok := instance.(PreRequestHook); ok {
+			if err := hook.PreRequest(c, request); err != nil {
+				return fmt.Errorf("插件 %s: %s", p.names[i], err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// PostRequest 依次执行插件，有插件要求重试时立即返回新的请求
+func (p *Pipeline) PostRequest(c *gin.Context, request *types.ChatCompletionRequest, response *types.ChatCompletionResponse) (*types.ChatCompletionRequest, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	for i, instance := range p.plugins {
+		hook, ok := instance.(PostRequestHook)
+		if !ok {
+			continue
+		}
+
+		retry, err := hook.PostRequest(c, request, response)
+		if err != nil {
+			return nil, fmt.Errorf("插件 %s: %s", p.names[i], err.Error())
+		}
+		if retry != nil {
+			return retry, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *Pipeline) HasStreamHook() bool {
+	if p == nil {
+		return false
+	}
+
+	for _, instance := range p.plugins {
+		if _, ok := instance.(StreamChunkHook); ok {
+			return true
+		}
+		if _, ok := instance.(PostResponseHook); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Pipeline) StreamChunk(c *gin.Context, chunk *types.ChatCompletionStreamResponse) error {
+	for i, instance := range p.plugins {
+		if hook, ok := instance.(StreamChunkHook); ok {
+			if err := hook.StreamChunk(c, chunk); err != nil {
+				return fmt.Errorf("插件 %s: %s", p.names[i], err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// flushStream 收集插件缓存的内容，前面插件输出的内容需要经过后面的插件处理
+func (p *Pipeline) flushStream(c *gin.Context, template *types.ChatCompletionStreamResponse) string {
+	var content string
+	for _, instance := range p.plugins {
+		if content != "" {
+			if hook, ok := instance.(StreamChunkHook); ok {
+				chunk := newContentChunk(template, content)
+				if err := hook.StreamChunk(c, chunk); err == nil {
+					content = chunk.GetResponseText()
+				}
+			}
+		}
+
+		if flusher, ok := instance.(StreamFlusher); ok {
+			content += flusher.FlushStream()
+		}
+	}
+	return content
+}
+
+func (p *Pipeline) PostResponse(c *gin.Context, request *types.ChatCompletionRequest, content string) {
+	if p == nil {
+		return
+	}
+
+	for _, instance := range p.plugins {
+		if hook, ok := instance.(PostResponseHook); ok {
+			hook.PostResponse(c, request, content)
+		}
+	}
+}
+
+func newContentChunk(template *types.ChatCompletionStreamResponse, content string) *types.ChatCompletionStreamResponse {
+	chunk := &types.ChatCompletionStreamResponse{
+		Object: "chat.completion.chunk",
+		Choices: []types.ChatCompletionStreamChoice{
+			{Delta: types.ChatCompletionStreamChoiceDelta{Content: content}},
+		},
+	}
+	if template != nil {
+		chunk.ID = template.ID
+		chunk.Created = template.Created
+		chunk.Model = template.Model
+	}
+	return chunk
+}
@@ -0,0 +1,154 @@
+package plugin
+
+import (
+	"done-hub/model"
+	"done-hub/types"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStream 依次返回预设的数据块，最后返回 io.EOF
+type fakeStream struct {
+	chunks []string
+}
+
+func (s *fakeStream) Recv() (<-chan string, <-chan error) {
+	dataChan := make(chan string)
+	errChan := make(chan error)
+	go func() {
+		for _, chunk := range s.chunks {
+			dataChan <- chunk
+		}
+		errChan <- io.EOF
+	}()
+	return dataChan, errChan
+}
+
+func (s *fakeStream) Close() {}
+
+func getContentChunk(t *testing.T, content string) string {
+	data, err := json.Marshal(&types.ChatCompletionStreamResponse{
+		ID:     "chatcmpl-1",
+		Object: "chat.completion.chunk",
+		Model:  "gpt-4o",
+		Choices: []types.ChatCompletionStreamChoice{
+			{Delta: types.ChatCompletionStreamChoiceDelta{Content: content}},
+		},
+	})
+	assert.Nil(t, err)
+	return string(data)
+}
+
+// readStream 读取所有数据块，返回拼接后的内容
+func readStream(t *testing.T, dataChan <-chan string, errChan <-chan error) string {
+	var content strings.Builder
+	for {
+		select {
+		case data := <-dataChan:
+			var chunk types.ChatCompletionStreamResponse
+			assert.Nil(t, json.Unmarshal([]byte(data), &chunk))
+			assert.Equal(t, "chatcmpl-1", chunk.ID)
+			content.WriteString(chunk.GetResponseText())
+		case err := <-errChan:
+			assert.True(t, errors.Is(err, io.EOF))
+			return content.String()
+		}
+	}
+}
+
+func TestBuildMergeOrder(t *testing.T) {
+	pipeline := Build(
+		model.PluginPipeline{
+			{Name: "strip_think"},
+			{Name: "system_prompt", Params: map[string]any{"content": "group"}},
+		},
+		model.PluginPipeline{
+			{Name: "regex_replace", Params: map[string]any{"rules": []any{map[string]any{"pattern": "a", "replace": "b"}}}},
+		},
+		model.PluginPipeline{
+			{Name: "system_prompt", Params: map[string]any{"content": "channel"}},
+			{Name: "not_exists"},
+		},
+	)
+
+	// 同名插件使用后面的配置并移到后面，无效的插件被跳过
+	assert.Equal(t, []string{"strip_think", "regex_replace", "system_prompt"}, pipeline.Names())
+	assert.Equal(t, "channel", pipeline.plugins[2].(*systemPromptPlugin).Content)
+	assert.Equal(t, "b", pipeline.plugins[1].(*regexReplacePlugin).Rules[0].Replace)
+
+	// 后面的配置无效时同名插件不会回退到前面的配置
+	pipeline = Build(
+		model.PluginPipeline{{Name: "regex_replace", Params: map[string]any{"rules": []any{map[string]any{"pattern": "a"}}}}},
+		model.PluginPipeline{{Name: "regex_replace", Params: map[string]any{"rules": []any{}}}},
+	)
+	assert.True(t, pipeline.Empty())
+
+	assert.True(t, Build().Empty())
+	var nilPipeline *Pipeline
+	assert.True(t, nilPipeline.Empty())
+	assert.Nil(t, nilPipeline.Names())
+}
+
+func TestPreRequestRestoreOnRetry(t *testing.T) {
+	pipeline := Build(model.PluginPipeline{
+		{Name: "system_prompt", Params: map[string]any{"content": "Be brief."}},
+		{Name: "json_schema", Params: map[string]any{"inject": true}},
+	})
+
+	request := getSchemaRequest()
+	request.Messages = append([]types.ChatCompletionMessage{{Role: types.ChatMessageRoleSystem, Content: "origin"}}, request.Messages...)
+
+	// 与 relayChat.send 一致，插件只修改本次发送的请求
+	for i := 0; i < 2; i++ {
+		origin := *request
+		assert.Nil(t, pipeline.PreRequest(getTestContext(), request))
+		assert.Len(t, request.Messages, 3)
+		// 按顺序执行，json_schema 的说明追加到 system_prompt 插入的系统提示词之后
+		assert.True(t, strings.HasPrefix(request.Messages[0].StringContent(), "Be brief.\n\n"))
+		assert.Equal(t, 1, strings.Count(request.Messages[0].StringContent(), `"required":["answer"]`))
+		assert.Equal(t, "origin", request.Messages[1].StringContent())
+		*request = origin
+
+		// 原始请求的消息没有被修改
+		assert.Len(t, request.Messages, 2)
+		assert.Equal(t, "origin", request.Messages[0].StringContent())
+	}
+}
+
+func TestFlushStreamChaining(t *testing.T) {
+	pipeline := Build(model.PluginPipeline{
+		{Name: "strip_think"},
+		{Name: "regex_replace", Params: map[string]any{"rules": []any{map[string]any{"pattern": "<th", "replace": "[th]"}}}},
+	})
+
+	c := getTestContext()
+	chunk := newContentChunk(nil, "hello <th")
+	assert.Nil(t, pipeline.StreamChunk(c, chunk))
+	assert.Equal(t, "hello ", chunk.GetResponseText())
+
+	// strip_think 缓存的内容需要经过后面的 regex_replace
+	assert.Equal(t, "[th]", pipeline.flushStream(c, chunk))
+}
+
+func TestWrapStream(t *testing.T) {
+	stream := &fakeStream{}
+	assert.Equal(t, stream, Build().WrapStream(getTestContext(), getSchemaRequest(), stream))
+
+	pipeline := Build(model.PluginPipeline{
+		{Name: "strip_think"},
+		{Name: "regex_replace", Params: map[string]any{"rules": []any{map[string]any{"pattern": "<th", "replace": "[th]"}}}},
+	})
+	stream = &fakeStream{chunks: []string{
+		getContentChunk(t, "<think>plan"),
+		getContentChunk(t, "</think>\n\nhello"),
+		getContentChunk(t, " world <th"),
+	}}
+
+	dataChan, errChan := pipeline.WrapStream(getTestContext(), getSchemaRequest(), stream).Recv()
+	assert.Equal(t, "hello world [th]", readStream(t, dataChan, errChan))
+}
@@ -0,0 +1,68 @@
+package plugin
+
+import (
+	"done-hub/types"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+type regexRule struct {
+	Pattern string `json:"pattern"`
+	Replace string `json:"replace"`
+}
+
+// regexReplacePlugin 使用正则替换输出内容，流式响应按块匹配，跨块的内容不会被替换
+type regexReplacePlugin struct {
+	Rules    []regexRule `json:"rules"`
+	compiled []*regexp.Regexp
+}
+
+func init() {
+	Register("regex_replace", func(params map[string]any) (any, error) {
+		p := &regexReplacePlugin{}
+		if err := decodeParams(params, p); err != nil {
+			return nil, err
+		}
+
+		if len(p.Rules) == 0 {
+			return nil, errors.New("rules 不能为空")
+		}
+
+		for _, rule := range p.Rules {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("正则表达式错误: %s", rule.Pattern)
+			}
+			p.compiled = append(p.compiled, re)
+		}
+		return p, nil
+	})
+}
+
+func (p *regexReplacePlugin) replace(content string) string {
+	for i, re := range p.compiled {
+		content = re.ReplaceAllString(content, p.Rules[i].Replace)
+	}
+	return content
+}
+
+func (p *regexReplacePlugin) PostRequest(c *gin.Context, request *types.ChatCompletionRequest, response *types.ChatCompletionResponse) (*types.ChatCompletionRequest, error) {
+	for i := range response.Choices {
+		if content, ok := response.Choices[i].Message.Content.(string); ok {
+			response.Choices[i].Message.Content = p.replace(content)
+		}
+	}
+	return nil, nil
+}
+
+func (p *regexReplacePlugin) StreamChunk(c *gin.Context, chunk *types.ChatCompletionStreamResponse) error {
+	for i := range chunk.Choices {
+		if chunk.Choices[i].Delta.Content != "" {
+			chunk.Choices[i].Delta.Content = p.replace(chunk.Choices[i].Delta.Content)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package plugin
+
+import (
+	"done-hub/common/logger"
+	"done-hub/common/requester"
+	"done-hub/types"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamProcessor 在流式数据返回给客户端之前执行插件
+type streamProcessor struct {
+	c        *gin.Context
+	pipeline *Pipeline
+	request  *types.ChatCompletionRequest
+	content  strings.Builder
+	last     *types.ChatCompletionStreamResponse
+}
+
+// WrapStream 没有处理流式响应的插件时直接返回原始的 stream
+func (p *Pipeline) WrapStream(c *gin.Context, request *types.ChatCompletionRequest, stream requester.StreamReaderInterface[string]) requester.StreamReaderInterface[string] {
+	if !p.HasStreamHook() {
+		return stream
+	}
+
+	return requester.NewChunkStreamReader(stream, &streamProcessor{
+		c:        c,
+		pipeline: p,
+		request:  request,
+	})
+}
+
+func (s *streamProcessor) Process(data string) (string, bool) {
+	var chunk types.ChatCompletionStreamResponse
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return data, true
+	}
+
+	text := chunk.GetResponseText()
+	if err := s.pipeline.StreamChunk(s.c, &chunk); err != nil {
+		logger.LogError(s.c.Request.Context(), err.Error())
+		return data, true
+	}
+	s.last = &chunk
+
+	newText := chunk.GetResponseText()
+	s.content.WriteString(newText)
+
+	// 插件把内容全部去掉时，只有内容的块不再返回
+	if text != "" && newText == "" && chunk.IsEmpty() {
+		return "", false
+	}
+
+	result, err := json.Marshal(chunk)
+	if err != nil {
+		return data, true
+	}
+	return string(result), true
+}
+
+// Finish 输出插件缓存的内容，并在结束后执行 PostResponse
+func (s *streamProcessor) Finish() []string {
+	var result []string
+	if content := s.pipeline.flushStream(s.c, s.last); content != "" {
+		s.content.WriteString(content)
+		if data, err := json.Marshal(newContentChunk(s.last, content)); err == nil {
+			result = append(result, string(data))
+		}
+	}
+	s.pipeline.PostResponse(s.c, s.request, s.content.String())
+	return result
+}
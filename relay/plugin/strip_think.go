@@ -0,0 +1,154 @@
+package plugin
+
+import (
+	"done-hub/types"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+var thinkBlockRegex = regexp.MustCompile(`(?s)<think>(.*?)</think>\s*`)
+
+// stripThinkPlugin 去掉输出中的 <think> 块，Reasoning 为 true 时移动到 reasoning_content
+type stripThinkPlugin struct {
+	Reasoning bool `json:"reasoning,omitempty"`
+	states    map[int]*thinkState
+}
+
+// thinkState 流式响应中每个 choice 的解析状态
+type thinkState struct {
+	inThink     bool
+	trimLeading bool
+	pending     string
+}
+
+func init() {
+	Register("strip_think", func(params map[string]any) (any, error) {
+		p := &stripThinkPlugin{states: map[int]*thinkState{}}
+		if err := decodeParams(params, p); err != nil {
+			return nil, err
+		}
+		return p, nil
+	})
+}
+
+func (p *stripThinkPlugin) PostRequest(c *gin.Context, request *types.ChatCompletionRequest, response *types.ChatCompletionResponse) (*types.ChatCompletionRequest, error) {
+	for i := range response.Choices {
+		message := &response.Choices[i].Message
+		content, ok := message.Content.(string)
+		if !ok || !strings.Contains(content, thinkOpenTag) {
+			continue
+		}
+
+		var reasoning strings.Builder
+		message.Content = thinkBlockRegex.ReplaceAllStringFunc(content, func(block string) string {
+			reasoning.WriteString(strings.TrimSpace(thinkBlockRegex.FindStringSubmatch(block)[1]))
+			return ""
+		})
+
+		if p.Reasoning && message.ReasoningContent == "" {
+			message.ReasoningContent = reasoning.String()
+		}
+	}
+	return nil, nil
+}
+
+func (p *stripThinkPlugin) StreamChunk(c *gin.Context, chunk *types.ChatCompletionStreamResponse) error {
+	for i := range chunk.Choices {
+		delta := &chunk.Choices[i].Delta
+		if delta.Content == "" {
+			continue
+		}
+
+		state, ok := p.states[chunk.Choices[i].Index]
+		if !ok {
+			state = &thinkState{}
+			p.states[chunk.Choices[i].Index] = state
+		}
+
+		content, reasoning := state.process(delta.Content)
+		delta.Content = content
+		if p.Reasoning && reasoning != "" {
+			delta.ReasoningContent += reasoning
+		}
+	}
+	return nil
+}
+
+// FlushStream 返回缓存的不完整标签，未闭合的 <think> 块直接丢弃
+func (p *stripThinkPlugin) FlushStream() string {
+	var content strings.Builder
+	for _, state := range p.states {
+		if !state.inThink {
+			content.WriteString(state.pending)
+		}
+		state.pending = ""
+	}
+	return content.String()
+}
+
+// process 返回可以输出的内容和 think 块中的内容，可能是标签一部分的结尾会缓存到下一块
+func (s *thinkState) process(text string) (content, reasoning string) {
+	buffer := s.pending + text
+	s.pending = ""
+
+	var out, think strings.Builder
+	for buffer != "" {
+		tag := thinkOpenTag
+		if s.inThink {
+			tag = thinkCloseTag
+		}
+
+		if index := strings.Index(buffer, tag); index >= 0 {
+			if s.inThink {
+				think.WriteString(buffer[:index])
+				s.trimLeading = true
+			} else {
+				out.WriteString(s.trim(buffer[:index]))
+			}
+			buffer = buffer[index+len(tag):]
+			s.inThink = !s.inThink
+			continue
+		}
+
+		keep := partialTagSuffix(buffer, tag)
+		if s.inThink {
+			think.WriteString(buffer[:len(buffer)-keep])
+		} else {
+			out.WriteString(s.trim(buffer[:len(buffer)-keep]))
+		}
+		s.pending = buffer[len(buffer)-keep:]
+		break
+	}
+
+	return out.String(), think.String()
+}
+
+// trim 去掉 think 块之后的空白
+func (s *thinkState) trim(content string) string {
+	if !s.trimLeading {
+		return content
+	}
+
+	content = strings.TrimLeft(content, " \t\r\n")
+	if content != "" {
+		s.trimLeading = false
+	}
+	return content
+}
+
+// partialTagSuffix 返回 text 结尾与 tag 开头重合的长度
+func partialTagSuffix(text, tag string) int {
+	for size := min(len(text), len(tag)-1); size > 0; size-- {
+		if strings.HasSuffix(text, tag[:size]) {
+			return size
+		}
+	}
+	return 0
+}
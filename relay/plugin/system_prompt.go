@@ -0,0 +1,76 @@
+package plugin
+
+import (
+	"done-hub/types"
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	systemPromptPrepend = "prepend" // 插入到最前面
+	systemPromptAppend  = "append"  // 插入到已有的系统提示词之后
+	systemPromptReplace = "replace" // 替换已有的系统提示词
+)
+
+// systemPromptPlugin 注入系统提示词
+type systemPromptPlugin struct {
+	Content string `json:"content"`
+	Mode    string `json:"mode,omitempty"`
+}
+
+func init() {
+	Register("system_prompt", func(params map[string]any) (any, error) {
+		p := &systemPromptPlugin{}
+		if err := decodeParams(params, p); err != nil {
+			return nil, err
+		}
+
+		if p.Content == "" {
+			return nil, errors.New("content 不能为空")
+		}
+
+		switch p.Mode {
+		case "":
+			p.Mode = systemPromptPrepend
+		case systemPromptPrepend, systemPromptAppend, systemPromptReplace:
+		default:
+			return nil, fmt.Errorf("mode 无效: %s", p.Mode)
+		}
+		return p, nil
+	})
+}
+
+// PreRequest 生成新的消息列表，不修改原请求的消息
+func (p *systemPromptPlugin) PreRequest(c *gin.Context, request *types.ChatCompletionRequest) error {
+	system := types.ChatCompletionMessage{
+		Role:    types.ChatMessageRoleSystem,
+		Content: p.Content,
+	}
+
+	messages := make([]types.ChatCompletionMessage, 0, len(request.Messages)+1)
+	switch p.Mode {
+	case systemPromptPrepend:
+		messages = append(messages, system)
+		messages = append(messages, request.Messages...)
+	case systemPromptAppend:
+		index := 0
+		for index < len(request.Messages) && request.Messages[index].IsSystemRole() {
+			index++
+		}
+		messages = append(messages, request.Messages[:index]...)
+		messages = append(messages, system)
+		messages = append(messages, request.Messages[index:]...)
+	case systemPromptReplace:
+		messages = append(messages, system)
+		for _, message := range request.Messages {
+			if !message.IsSystemRole() {
+				messages = append(messages, message)
+			}
+		}
+	}
+
+	request.Messages = messages
+	return nil
+}
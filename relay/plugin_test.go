@@ -0,0 +1,55 @@
+package relay
+
+import (
+	"done-hub/model"
+	"done-hub/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestGetPluginPipelineTokenCannotReplaceAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+	groupPipeline := datatypes.NewJSONType(model.PluginPipeline{
+		{Name: "params", Params: map[string]any{"force": map[string]any{"max_tokens": 100}}},
+	})
+	model.GlobalUserGroupRatio.Lock()
+	oldGroups := model.GlobalUserGroupRatio.UserGroup
+	model.GlobalUserGroupRatio.UserGroup = map[string]*model.UserGroup{
+		"vip": {Symbol: "vip", PluginPipeline: &groupPipeline},
+	}
+	model.GlobalUserGroupRatio.Unlock()
+	t.Cleanup(func() {
+		model.GlobalUserGroupRatio.Lock()
+		model.GlobalUserGroupRatio.UserGroup = oldGroups
+		model.GlobalUserGroupRatio.Unlock()
+	})
+
+	c.Set("token_group", "vip")
+	c.Set("token_setting", &model.TokenSetting{Plugins: model.PluginPipeline{
+		{Name: "params", Params: map[string]any{"force": map[string]any{"temperature": 0.5}}},
+		{Name: "strip_think"},
+	}})
+
+	channelPipeline := datatypes.NewJSONType(model.PluginPipeline{{Name: "strip_think", Params: map[string]any{"reasoning": true}}})
+	channel := &model.Channel{PluginPipeline: &channelPipeline}
+
+	request := &types.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []types.ChatCompletionMessage{{Role: types.ChatMessageRoleUser, Content: "hello"}},
+	}
+	pipeline := getPluginPipeline(c, channel, request, "gpt-4o")
+	assert.Equal(t, []string{"params", "strip_think"}, pipeline.Names())
+
+	// 分组强制的参数不会被令牌的同名插件替换
+	assert.Nil(t, pipeline.PreRequest(c, request))
+	assert.Equal(t, 100, request.MaxTokens)
+	assert.Nil(t, request.Temperature)
+}
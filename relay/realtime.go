@@ -1,6 +1,7 @@
 package relay
 
 import (
+	"bytes"
 	"done-hub/common"
 	"done-hub/common/config"
 	"done-hub/common/logger"
@@ -23,6 +24,7 @@ type RelayModeChatRealtime struct {
 	userConn       *websocket.Conn
 	messageHandler requester.MessageHandler
 	providerConn   *websocket.Conn
+	bridge         *realtimeBridge
 	quota          *relay_util.Quota
 	usage          *types.UsageEvent
 }
@@ -64,6 +66,12 @@ func ChatRealtime(c *gin.Context) {
 
 	relay.usage = &types.UsageEvent{}
 
+	if relay.bridge != nil {
+		relay.bridge.Run()
+		relay.quota.Consume(relay.c, relay.usage.ToChatUsage(), false)
+		return
+	}
+
 	wsProxy := requester.NewWSProxy(relay.userConn, relay.providerConn, time.Minute*2, relay.messageHandler, relay.usageHandler)
 
 	wsProxy.Start()
@@ -79,6 +87,7 @@ func ChatRealtime(c *gin.Context) {
 
 		logger.LogInfo(relay.c.Request.Context(), fmt.Sprintf("连接由%s关闭", closedBy))
 		wsProxy.Close()
+		relay.flushUsage()
 		relay.quota.Consume(relay.c, relay.usage.ToChatUsage(), false)
 
 	}()
@@ -106,14 +115,28 @@ func (r *RelayModeChatRealtime) getProvider() bool {
 			return false
 		}
 
-		realtimeProvider, ok := r.provider.(providersBase.RealtimeInterface)
-		if !ok {
-			r.abortWithMessage("channel not implemented")
-			return false
-		}
 		channel := r.provider.GetChannel()
 
-		providerConn, messageHandler, apiErr := realtimeProvider.CreateChatRealtime(r.modelName)
+		var providerConn *websocket.Conn
+		var messageHandler requester.MessageHandler
+		apiErr := common.StringErrorWrapperLocal("channel not implemented", "unsupported_api", http.StatusNotImplemented)
+		if realtimeProvider, ok := r.provider.(providersBase.RealtimeInterface); ok {
+			providerConn, messageHandler, apiErr = realtimeProvider.CreateChatRealtime(r.modelName)
+		}
+
+		// 渠道没有原生 Realtime 接口时，使用转录/对话/语音合成流水线桥接
+		if apiErr != nil && apiErr.Code == "unsupported_api" {
+			chatProvider, ok := r.provider.(providersBase.ChatInterface)
+			if !ok {
+				r.abortWithMessage("channel not implemented")
+				return false
+			}
+
+			r.bridge = newRealtimeBridge(r, chatProvider)
+			metrics.RecordProvider(r.c, 200)
+			return true
+		}
+
 		if apiErr != nil {
 			r.skipChannelIds(channel.Id)
 			logger.LogError(r.c.Request.Context(), fmt.Sprintf("using channel #%d(%s) Error: %s to retry (remain times %d)", channel.Id, channel.Name, apiErr.Error(), i))
@@ -154,18 +177,23 @@ func (r *RelayModeChatRealtime) getRealtimeFirstMessage() bool {
 		return false
 	}
 
-	if messageType != websocket.TextMessage {
-		return false
-	}
-
 	shouldContinue, _, newMessage, err := r.messageHandler(requester.SupplierMessage, messageType, firstMessage)
 
 	if !shouldContinue || err != nil {
 		return false
 	}
 
+	// 二进制消息只有经过转换后才能发送给客户端
+	if newMessage == nil && messageType != websocket.TextMessage {
+		return false
+	}
+
 	if newMessage != nil {
-		r.userConn.WriteMessage(websocket.TextMessage, newMessage)
+		for _, message := range bytes.Split(newMessage, []byte("\n")) {
+			if len(message) > 0 {
+				r.userConn.WriteMessage(websocket.TextMessage, message)
+			}
+		}
 	} else {
 		r.userConn.WriteMessage(websocket.TextMessage, firstMessage)
 	}
@@ -173,6 +201,18 @@ func (r *RelayModeChatRealtime) getRealtimeFirstMessage() bool {
 	return true
 }
 
+// flushUsage 连接关闭后计入处理器中尚未计费的用量
+func (r *RelayModeChatRealtime) flushUsage() {
+	_, usage, _, _ := r.messageHandler(requester.SessionClosed, 0, nil)
+	if usage == nil {
+		return
+	}
+
+	if err := r.usageHandler(usage); err != nil {
+		logger.LogError(r.c.Request.Context(), "flush realtime usage failed: "+err.Error())
+	}
+}
+
 func (r *RelayModeChatRealtime) usageHandler(usage *types.UsageEvent) error {
 	err := r.quota.UpdateUserRealtimeQuota(r.usage, usage)
 	if err != nil {
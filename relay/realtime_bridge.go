@@ -0,0 +1,708 @@
+package relay
+
+import (
+	"context"
+	"done-hub/common"
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/common/requester"
+	"done-hub/common/utils"
+	"done-hub/model"
+	providersBase "done-hub/providers/base"
+	"done-hub/relay/relay_util"
+	"done-hub/types"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// realtime 协议默认音频格式为 24kHz 单声道 pcm16
+	realtimeBridgeSampleRate = 24000
+	// 输入音频缓冲区最多保存 5 分钟的音频
+	realtimeBridgeMaxAudioBuffer = realtimeBridgeSampleRate * 2 * 300
+)
+
+// realtimeBridge 为没有原生 Realtime 接口的渠道提供 OpenAI Realtime 协议
+// 通过 转录 -> 对话(流式) -> 语音合成 的流水线实现，轮次由客户端通过 commit/response.create 控制
+type realtimeBridge struct {
+	relay    *RelayModeChatRealtime
+	provider providersBase.ChatInterface
+
+	session     types.RealtimeSession
+	messages    []types.ChatCompletionMessage
+	audioBuffer []byte
+
+	stateLock sync.Mutex
+	writeLock sync.Mutex
+	cancel    context.CancelFunc
+	running   sync.WaitGroup
+}
+
+func newRealtimeBridge(relay *RelayModeChatRealtime, provider providersBase.ChatInterface) *realtimeBridge {
+	return &realtimeBridge{
+		relay:    relay,
+		provider: provider,
+		session: types.RealtimeSession{
+			Model:             relay.getOriginalModel(),
+			Modalities:        []string{"text", "audio"},
+			Voice:             "alloy",
+			InputAudioFormat:  "pcm16",
+			OutputAudioFormat: "pcm16",
+		},
+	}
+}
+
+func (b *realtimeBridge) Run() {
+	defer b.relay.userConn.Close()
+
+	created := types.NewRealtimeServerEvent(types.EventTypeSessionCreated)
+	created.Session = &b.session
+	if err := b.send(created); err != nil {
+		return
+	}
+
+	for {
+		b.relay.userConn.SetReadDeadline(time.Now().Add(time.Minute * 2))
+		messageType, message, err := b.relay.userConn.ReadMessage()
+		if err != nil {
+			logger.LogInfo(b.relay.c.Request.Context(), "realtime bridge closed: "+err.Error())
+			break
+		}
+
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		var event types.RealtimeClientEvent
+		if err := json.Unmarshal(message, &event); err != nil {
+			b.sendError(event.EventId, "invalid_event", err.Error())
+			continue
+		}
+
+		if err := b.handleEvent(&event); err != nil {
+			b.sendError(event.EventId, "invalid_request_error", err.Error())
+		}
+	}
+
+	b.cancelResponse()
+	b.running.Wait()
+}
+
+func (b *realtimeBridge) handleEvent(event *types.RealtimeClientEvent) error {
+	switch event.Type {
+	case types.EventTypeSessionUpdate:
+		if event.Session != nil {
+			b.updateSession(event.Session)
+		}
+		updated := types.NewRealtimeServerEvent(types.EventTypeSessionUpdated)
+		updated.Session = &b.session
+		return b.send(updated)
+
+	case types.EventTypeInputAudioBufferAppend:
+		if len(b.audioBuffer)+base64.StdEncoding.DecodedLen(len(event.Audio)) > realtimeBridgeMaxAudioBuffer {
+			return errors.New("input audio buffer exceeds the maximum size, commit or clear it first")
+		}
+		audio, err := base64.StdEncoding.DecodeString(event.Audio)
+		if err != nil {
+			return err
+		}
+		b.audioBuffer = append(b.audioBuffer, audio...)
+		return nil
+
+	case types.EventTypeInputAudioBufferClear:
+		b.audioBuffer = nil
+		return b.send(types.NewRealtimeServerEvent(types.EventTypeInputAudioBufferCleared))
+
+	case types.EventTypeInputAudioBufferCommit:
+		return b.commitAudio()
+
+	case types.EventTypeItemCreate:
+		return b.createItem(event.Item)
+
+	case types.EventTypeResponseCreate:
+		b.cancelResponse()
+		b.running.Wait()
+
+		ctx, cancel := context.WithCancel(b.relay.c.Request.Context())
+		b.cancel = cancel
+		session := b.session
+		b.running.Add(1)
+		go func() {
+			defer b.running.Done()
+			b.createResponse(ctx, session, event.Response)
+		}()
+		return nil
+
+	case types.EventTypeResponseCancel:
+		b.cancelResponse()
+		return nil
+	}
+
+	return nil
+}
+
+func (b *realtimeBridge) updateSession(session *types.RealtimeSession) {
+	if session.Modalities != nil {
+		b.session.Modalities = session.Modalities
+	}
+	if session.Instructions != "" {
+		b.session.Instructions = session.Instructions
+	}
+	if session.Voice != "" {
+		b.session.Voice = session.Voice
+	}
+	if session.InputAudioTranscription != nil {
+		b.session.InputAudioTranscription = session.InputAudioTranscription
+	}
+	if session.Tools != nil {
+		b.session.Tools = session.Tools
+	}
+	if session.ToolChoice != nil {
+		b.session.ToolChoice = session.ToolChoice
+	}
+	if session.Temperature != nil {
+		b.session.Temperature = session.Temperature
+	}
+	if session.MaxResponseOutputTokens != nil {
+		b.session.MaxResponseOutputTokens = session.MaxResponseOutputTokens
+	}
+	b.session.TurnDetection = session.TurnDetection
+}
+
+func (b *realtimeBridge) commitAudio() error {
+	if len(b.audioBuffer) == 0 {
+		return errors.New("input audio buffer is empty")
+	}
+
+	audio := b.audioBuffer
+	b.audioBuffer = nil
+
+	itemId := "item_" + utils.GetRandomString(16)
+	committed := types.NewRealtimeServerEvent(types.EventTypeInputAudioBufferCommited)
+	committed.ItemId = itemId
+	if err := b.send(committed); err != nil {
+		return err
+	}
+
+	transcript, err := b.transcribe(audio)
+	if err != nil {
+		return err
+	}
+
+	item := &types.RealtimeItem{
+		ID:      itemId,
+		Object:  "realtime.item",
+		Type:    "message",
+		Status:  "completed",
+		Role:    types.ChatMessageRoleUser,
+		Content: []types.RealtimeContent{{Type: "input_audio", Transcript: transcript}},
+	}
+	b.appendMessage(types.ChatCompletionMessage{
+		Role:    types.ChatMessageRoleUser,
+		Content: transcript,
+	})
+
+	itemCreated := types.NewRealtimeServerEvent(types.EventTypeItemCreated)
+	itemCreated.Item = item
+	if err := b.send(itemCreated); err != nil {
+		return err
+	}
+
+	completed := types.NewRealtimeServerEvent(types.EventTypeTranscriptionCompleted)
+	completed.ItemId = itemId
+	completed.ContentIndex = utils.GetPointer(0)
+	completed.Transcript = transcript
+	return b.send(completed)
+}
+
+func (b *realtimeBridge) createItem(item *types.RealtimeItem) error {
+	if item == nil {
+		return errors.New("item is required")
+	}
+
+	if item.ID == "" {
+		item.ID = "item_" + utils.GetRandomString(16)
+	}
+
+	switch item.Type {
+	case "function_call_output":
+		b.appendMessage(types.ChatCompletionMessage{
+			Role:       types.ChatMessageRoleTool,
+			ToolCallID: item.CallId,
+			Content:    item.Output,
+		})
+	case "function_call":
+		b.appendMessage(types.ChatCompletionMessage{
+			Role: types.ChatMessageRoleAssistant,
+			ToolCalls: []*types.ChatCompletionToolCalls{{
+				Id:       item.CallId,
+				Type:     types.ToolChoiceTypeFunction,
+				Function: &types.ChatCompletionToolCallsFunction{Name: item.Name, Arguments: item.Arguments},
+			}},
+		})
+	case "message":
+		var text strings.Builder
+		for _, content := range item.Content {
+			switch content.Type {
+			case "input_text", "text":
+				text.WriteString(content.Text)
+			case "input_audio", "audio":
+				text.WriteString(content.Transcript)
+			}
+		}
+		role := item.Role
+		if role == "" {
+			role = types.ChatMessageRoleUser
+		}
+		b.appendMessage(types.ChatCompletionMessage{Role: role, Content: text.String()})
+	default:
+		return fmt.Errorf("unsupported item type: %s", item.Type)
+	}
+
+	itemCreated := types.NewRealtimeServerEvent(types.EventTypeItemCreated)
+	itemCreated.Item = item
+	return b.send(itemCreated)
+}
+
+func (b *realtimeBridge) createResponse(ctx context.Context, session types.RealtimeSession, override *types.RealtimeSession) {
+	if override != nil {
+		if override.Modalities != nil {
+			session.Modalities = override.Modalities
+		}
+		if override.Instructions != "" {
+			session.Instructions = override.Instructions
+		}
+		if override.Voice != "" {
+			session.Voice = override.Voice
+		}
+	}
+	withAudio := utils.Contains("audio", session.Modalities)
+
+	responseId := "resp_" + utils.GetRandomString(16)
+	itemId := "item_" + utils.GetRandomString(16)
+
+	created := types.NewRealtimeServerEvent(types.EventTypeResponseCreated)
+	created.Response = &types.ResponseEvent{ID: responseId, Object: "realtime.response", Status: "in_progress"}
+	b.send(created)
+
+	item := types.RealtimeItem{ID: itemId, Object: "realtime.item", Type: "message", Status: "in_progress", Role: types.ChatMessageRoleAssistant}
+	itemAdded := types.NewRealtimeServerEvent(types.EventTypeOutputItemAdded)
+	itemAdded.ResponseId = responseId
+	itemAdded.OutputIndex = utils.GetPointer(0)
+	itemAdded.Item = &item
+	b.send(itemAdded)
+
+	partType := "text"
+	if withAudio {
+		partType = "audio"
+	}
+	partAdded := types.NewRealtimeServerEvent(types.EventTypeContentPartAdded).SetIndex(responseId, itemId, 0, 0)
+	partAdded.Part = &types.RealtimeContent{Type: partType}
+	b.send(partAdded)
+
+	text, toolCalls, usage, apiErr := b.streamChat(ctx, session, responseId, itemId, withAudio)
+	status := "completed"
+	if apiErr != nil {
+		b.sendError("", "upstream_error", apiErr.Error())
+		status = "failed"
+	} else if ctx.Err() != nil {
+		status = "cancelled"
+	}
+
+	if withAudio && text != "" && status == "completed" {
+		if err := b.speak(ctx, session.Voice, text, responseId, itemId); err != nil {
+			b.sendError("", "upstream_error", err.Error())
+		}
+	}
+
+	if withAudio {
+		transcriptDone := types.NewRealtimeServerEvent(types.EventTypeAudioTranscriptDone).SetIndex(responseId, itemId, 0, 0)
+		transcriptDone.Transcript = text
+		b.send(transcriptDone)
+		b.send(types.NewRealtimeServerEvent(types.EventTypeAudioDone).SetIndex(responseId, itemId, 0, 0))
+	} else {
+		textDone := types.NewRealtimeServerEvent(types.EventTypeTextDone).SetIndex(responseId, itemId, 0, 0)
+		textDone.Text = text
+		b.send(textDone)
+	}
+
+	content := types.RealtimeContent{Type: partType, Text: text}
+	if withAudio {
+		content = types.RealtimeContent{Type: partType, Transcript: text}
+	}
+	partDone := types.NewRealtimeServerEvent(types.EventTypeContentPartDone).SetIndex(responseId, itemId, 0, 0)
+	partDone.Part = &content
+	b.send(partDone)
+
+	item.Status = status
+	item.Content = []types.RealtimeContent{content}
+	output := []types.RealtimeItem{item}
+	itemDone := types.NewRealtimeServerEvent(types.EventTypeOutputItemDone)
+	itemDone.ResponseId = responseId
+	itemDone.OutputIndex = utils.GetPointer(0)
+	itemDone.Item = &item
+	b.send(itemDone)
+
+	for i, toolCall := range toolCalls {
+		callItem := types.RealtimeItem{
+			ID:        "item_" + utils.GetRandomString(16),
+			Object:    "realtime.item",
+			Type:      "function_call",
+			Status:    "completed",
+			CallId:    toolCall.Id,
+			Name:      toolCall.Function.Name,
+			Arguments: toolCall.Function.Arguments,
+		}
+		argumentsDone := types.NewRealtimeServerEvent(types.EventTypeFunctionArgumentsDone).SetIndex(responseId, callItem.ID, i+1, 0)
+		argumentsDone.CallId = callItem.CallId
+		argumentsDone.Name = callItem.Name
+		argumentsDone.Arguments = callItem.Arguments
+		b.send(argumentsDone)
+		output = append(output, callItem)
+	}
+
+	if text != "" || len(toolCalls) > 0 {
+		b.appendMessage(types.ChatCompletionMessage{
+			Role:      types.ChatMessageRoleAssistant,
+			Content:   text,
+			ToolCalls: toolCalls,
+		})
+	}
+
+	if err := b.consume(usage); err != nil {
+		b.sendError("", "insufficient_quota", err.Error())
+	}
+
+	done := types.NewRealtimeServerEvent(types.EventTypeResponseDone)
+	done.Response = &types.ResponseEvent{
+		ID:     responseId,
+		Object: "realtime.response",
+		Status: status,
+		Output: output,
+		Usage:  usage,
+	}
+	b.send(done)
+}
+
+func (b *realtimeBridge) streamChat(ctx context.Context, session types.RealtimeSession, responseId, itemId string, withAudio bool) (string, []*types.ChatCompletionToolCalls, *types.UsageEvent, error) {
+	modelName := b.relay.modelName
+	b.stateLock.Lock()
+	messages := append([]types.ChatCompletionMessage{}, b.messages...)
+	b.stateLock.Unlock()
+	if session.Instructions != "" {
+		messages = append([]types.ChatCompletionMessage{{Role: types.ChatMessageRoleSystem, Content: session.Instructions}}, messages...)
+	}
+
+	request := &types.ChatCompletionRequest{
+		Model:         modelName,
+		Messages:      messages,
+		Stream:        true,
+		StreamOptions: &types.StreamOptions{IncludeUsage: true},
+		Temperature:   session.Temperature,
+		ToolChoice:    session.ToolChoice,
+	}
+	if maxTokens, ok := session.MaxResponseOutputTokens.(float64); ok {
+		request.MaxTokens = int(maxTokens)
+	}
+	for _, tool := range session.Tools {
+		request.Tools = append(request.Tools, &types.ChatCompletionTool{
+			Type: types.ToolChoiceTypeFunction,
+			Function: types.ChatCompletionFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	chatUsage := &types.Usage{
+		PromptTokens: common.CountTokenMessages(messages, modelName, b.provider.GetChannel().PreCost),
+	}
+	b.provider.SetUsage(chatUsage)
+
+	stream, apiErr := b.provider.CreateChatCompletionStream(request)
+	if apiErr != nil {
+		return "", nil, &types.UsageEvent{}, apiErr
+	}
+	defer stream.Close()
+
+	deltaType := types.EventTypeTextDelta
+	if withAudio {
+		deltaType = types.EventTypeAudioTranscriptDelta
+	}
+
+	var text strings.Builder
+	toolCalls := make(map[int]*types.ChatCompletionToolCalls)
+	dataChan, errChan := stream.Recv()
+
+	var streamErr error
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case data, ok := <-dataChan:
+			if !ok {
+				break loop
+			}
+			var chunk types.ChatCompletionStreamResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				continue
+			}
+			for _, choice := range chunk.Choices {
+				if choice.Delta.Content != "" {
+					text.WriteString(choice.Delta.Content)
+					delta := types.NewRealtimeServerEvent(deltaType).SetIndex(responseId, itemId, 0, 0)
+					delta.Delta = choice.Delta.Content
+					b.send(delta)
+				}
+				mergeRealtimeToolCalls(toolCalls, choice.Delta.ToolCalls)
+			}
+		case err := <-errChan:
+			if !errors.Is(err, io.EOF) {
+				streamErr = err
+			}
+			break loop
+		}
+	}
+
+	if chatUsage.CompletionTokens == 0 && text.Len() > 0 {
+		chatUsage.CompletionTokens = common.CountTokenText(text.String(), modelName)
+	}
+
+	usage := &types.UsageEvent{
+		InputTokens:  chatUsage.PromptTokens,
+		OutputTokens: chatUsage.CompletionTokens,
+		TotalTokens:  chatUsage.PromptTokens + chatUsage.CompletionTokens,
+	}
+	usage.InputTokenDetails.TextTokens = chatUsage.PromptTokens
+	usage.InputTokenDetails.CachedTokens = chatUsage.PromptTokensDetails.CachedTokens
+	usage.OutputTokenDetails.TextTokens = chatUsage.CompletionTokens
+
+	calls := make([]*types.ChatCompletionToolCalls, 0, len(toolCalls))
+	for i := 0; i < len(toolCalls); i++ {
+		if call, ok := toolCalls[i]; ok {
+			calls = append(calls, call)
+		}
+	}
+
+	return text.String(), calls, usage, streamErr
+}
+
+func mergeRealtimeToolCalls(toolCalls map[int]*types.ChatCompletionToolCalls, deltas []*types.ChatCompletionToolCalls) {
+	for _, delta := range deltas {
+		if delta == nil || delta.Function == nil {
+			continue
+		}
+		call, ok := toolCalls[delta.Index]
+		if !ok {
+			call = &types.ChatCompletionToolCalls{
+				Id:       delta.Id,
+				Type:     types.ToolChoiceTypeFunction,
+				Function: &types.ChatCompletionToolCallsFunction{},
+			}
+			toolCalls[delta.Index] = call
+		}
+		if delta.Id != "" {
+			call.Id = delta.Id
+		}
+		if delta.Function.Name != "" {
+			call.Function.Name = delta.Function.Name
+		}
+		call.Function.Arguments += delta.Function.Arguments
+	}
+}
+
+// transcribe 使用转录模型识别已提交的音频，按转录模型的价格单独计费
+func (b *realtimeBridge) transcribe(pcm []byte) (string, error) {
+	modelName := config.RealtimeBridgeTranscriptionModel
+	language := ""
+	if b.session.InputAudioTranscription != nil {
+		if b.session.InputAudioTranscription.Model != "" {
+			modelName = b.session.InputAudioTranscription.Model
+		}
+		language = b.session.InputAudioTranscription.Language
+	}
+
+	aux, err := b.getAuxProvider(modelName)
+	if err != nil {
+		return "", err
+	}
+	transcriptionProvider, ok := aux.provider.(providersBase.TranscriptionsInterface)
+	if !ok {
+		return "", fmt.Errorf("model %s does not support transcriptions", modelName)
+	}
+
+	file, err := requester.NewFileHeader("file", "audio.wav", utils.PCM16ToWav(pcm, realtimeBridgeSampleRate))
+	if err != nil {
+		return "", err
+	}
+
+	response, apiErr := transcriptionProvider.CreateTranscriptions(&types.AudioRequest{
+		File:           file,
+		Model:          aux.modelName,
+		Language:       language,
+		ResponseFormat: "json",
+	})
+	if apiErr != nil {
+		return "", apiErr
+	}
+
+	var audioResponse types.AudioResponse
+	if err := json.Unmarshal(response.Body, &audioResponse); err != nil {
+		return "", err
+	}
+
+	usage := aux.provider.GetUsage()
+	if model.PricingInstance.GetPrice(aux.billingModel).Type == model.SecondsPriceType {
+		seconds := float64(len(pcm)) / float64(realtimeBridgeSampleRate*2)
+		usage.PromptTokens = max(int(math.Ceil(seconds)), 1)
+		usage.CompletionTokens = 0
+	} else if usage.TotalTokens == 0 {
+		usage.PromptTokens = common.CountTokenText(audioResponse.Text, aux.billingModel)
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	aux.consume(usage)
+
+	return audioResponse.Text, nil
+}
+
+// speak 使用语音合成模型输出 pcm16 音频，按语音合成模型的价格单独计费
+func (b *realtimeBridge) speak(ctx context.Context, voice, text, responseId, itemId string) error {
+	aux, err := b.getAuxProvider(config.RealtimeBridgeSpeechModel)
+	if err != nil {
+		return err
+	}
+	speechProvider, ok := aux.provider.(providersBase.SpeechInterface)
+	if !ok {
+		return fmt.Errorf("model %s does not support speech", config.RealtimeBridgeSpeechModel)
+	}
+
+	response, apiErr := speechProvider.CreateSpeech(&types.SpeechAudioRequest{
+		Model:          aux.modelName,
+		Input:          text,
+		Voice:          voice,
+		ResponseFormat: "pcm",
+	})
+	if apiErr != nil {
+		return apiErr
+	}
+	defer response.Body.Close()
+
+	// 与语音合成接口一致，按输入文本的长度计费
+	usage := aux.provider.GetUsage()
+	usage.PromptTokens = len(text)
+	if model.PricingInstance.GetPrice(aux.billingModel).Type == model.CharsPriceType {
+		usage.PromptTokens = utf8.RuneCountInString(text)
+	}
+	usage.CompletionTokens = 0
+	usage.TotalTokens = usage.PromptTokens
+	aux.consume(usage)
+
+	// 每次发送约 200ms 的音频
+	buffer := make([]byte, realtimeBridgeSampleRate*2/5)
+	for ctx.Err() == nil {
+		n, err := io.ReadFull(response.Body, buffer)
+		if n > 0 {
+			delta := types.NewRealtimeServerEvent(types.EventTypeAudioDelta).SetIndex(responseId, itemId, 0, 0)
+			delta.Delta = base64.StdEncoding.EncodeToString(buffer[:n])
+			b.send(delta)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	return nil
+}
+
+// auxProvider 转录/语音合成使用的渠道，在上下文的副本中选择，不影响当前会话记录的渠道信息
+type auxProvider struct {
+	c            *gin.Context
+	provider     providersBase.ProviderInterface
+	modelName    string // 请求上游使用的模型
+	billingModel string // 计费使用的模型
+}
+
+// getAuxProvider 转录与语音合成可能并发执行，各自使用独立的上下文副本
+func (b *realtimeBridge) getAuxProvider(modelName string) (*auxProvider, error) {
+	c := b.relay.c.Copy()
+	provider, newModelName, err := GetProvider(c, modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	quota, err := model.CacheGetUserQuota(c.GetInt("id"))
+	if err != nil {
+		return nil, err
+	}
+	if quota <= 0 {
+		return nil, errors.New("user quota is not enough")
+	}
+
+	aux := &auxProvider{
+		c:            c,
+		provider:     provider,
+		modelName:    newModelName,
+		billingModel: newModelName,
+	}
+	if c.GetBool("billing_original_model") {
+		aux.billingModel = modelName
+	}
+	provider.SetUsage(&types.Usage{})
+
+	return aux, nil
+}
+
+// consume 按辅助模型的价格单独记录消费
+func (a *auxProvider) consume(usage *types.Usage) {
+	relay_util.NewQuota(a.c, a.billingModel, usage.PromptTokens).Consume(a.c, usage, false)
+}
+
+func (b *realtimeBridge) appendMessage(message types.ChatCompletionMessage) {
+	b.stateLock.Lock()
+	defer b.stateLock.Unlock()
+
+	b.messages = append(b.messages, message)
+}
+
+// consume 累计用量并更新实时配额，转录与对话可能并发执行
+func (b *realtimeBridge) consume(usage *types.UsageEvent) error {
+	b.stateLock.Lock()
+	defer b.stateLock.Unlock()
+
+	return b.relay.usageHandler(usage)
+}
+
+func (b *realtimeBridge) cancelResponse() {
+	if b.cancel != nil {
+		b.cancel()
+		b.cancel = nil
+	}
+}
+
+func (b *realtimeBridge) send(event *types.RealtimeServerEvent) error {
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
+	return b.relay.userConn.WriteMessage(websocket.TextMessage, event.Bytes())
+}
+
+func (b *realtimeBridge) sendError(eventId, code, message string) {
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
+	eventErr := types.NewErrorEvent(eventId, "invalid_request_error", code, message)
+	b.relay.userConn.WriteMessage(websocket.TextMessage, []byte(eventErr.Error()))
+}
@@ -0,0 +1,251 @@
+package relay
+
+import (
+	"done-hub/common/config"
+	"done-hub/common/requester"
+	"done-hub/model"
+	"done-hub/relay/relay_util"
+	"done-hub/types"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeChunkStream 依次返回预设的数据块，最后返回 io.EOF
+type fakeChunkStream struct {
+	chunks []string
+}
+
+func (s *fakeChunkStream) Recv() (<-chan string, <-chan error) {
+	dataChan := make(chan string)
+	errChan := make(chan error)
+	go func() {
+		for _, chunk := range s.chunks {
+			dataChan <- chunk
+		}
+		errChan <- io.EOF
+	}()
+	return dataChan, errChan
+}
+
+func (s *fakeChunkStream) Close() {}
+
+// fakeStreamProvider 每次请求返回相同的数据块，和真实渠道一样在请求时更新用量
+type fakeStreamProvider struct {
+	fakeChatProvider
+	chunks []string
+}
+
+func (p *fakeStreamProvider) CreateChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	p.requests = append(p.requests, request)
+	p.Usage.PromptTokens = 20
+	p.Usage.CompletionTokens = 6
+	return &fakeChunkStream{chunks: p.chunks}, nil
+}
+
+func getStreamChunk(t *testing.T, delta types.ChatCompletionStreamChoiceDelta) string {
+	data, err := json.Marshal(&types.ChatCompletionStreamResponse{
+		ID:      "chatcmpl-1",
+		Object:  "chat.completion.chunk",
+		Choices: []types.ChatCompletionStreamChoice{{Delta: delta}},
+	})
+	assert.Nil(t, err)
+	return string(data)
+}
+
+// startTestBridge 启动桥接会话，返回客户端连接和会话
+func startTestBridge(t *testing.T, provider *fakeStreamProvider) (*websocket.Conn, *RelayModeChatRealtime) {
+	gin.SetMode(gin.TestMode)
+	// 不加载分词器，提示词按估算计数，最终以渠道返回的用量为准
+	disabled := config.DisableTokenEncoders
+	config.DisableTokenEncoders = true
+	t.Cleanup(func() {
+		config.DisableTokenEncoders = disabled
+	})
+
+	provider.Usage = &types.Usage{}
+	provider.Channel = &model.Channel{}
+
+	relay := &RelayModeChatRealtime{quota: &relay_util.Quota{}, usage: &types.UsageEvent{}}
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = r
+		relay.c = c
+		relay.userConn = conn
+		relay.setOriginalModel("gpt-4o")
+		relay.modelName = "gpt-4o"
+		relay.bridge = newRealtimeBridge(relay, provider)
+		relay.bridge.Run()
+	}))
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		<-done
+		server.Close()
+	})
+
+	return conn, relay
+}
+
+func sendClientEvent(t *testing.T, conn *websocket.Conn, event string) {
+	assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte(event)))
+}
+
+// readServerEvents 读取事件直到收到指定类型的事件
+func readServerEvents(t *testing.T, conn *websocket.Conn, until string) []types.RealtimeServerEvent {
+	events := make([]types.RealtimeServerEvent, 0)
+	for {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, message, err := conn.ReadMessage()
+		if !assert.Nil(t, err) {
+			return events
+		}
+
+		var event types.RealtimeServerEvent
+		assert.Nil(t, json.Unmarshal(message, &event))
+		events = append(events, event)
+		if event.Type == until {
+			return events
+		}
+	}
+}
+
+func realtimeEventTypes(events []types.RealtimeServerEvent) []string {
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		names = append(names, event.Type)
+	}
+	return names
+}
+
+func TestRealtimeBridge(t *testing.T) {
+	provider := &fakeStreamProvider{chunks: []string{
+		getStreamChunk(t, types.ChatCompletionStreamChoiceDelta{Content: "Hel"}),
+		getStreamChunk(t, types.ChatCompletionStreamChoiceDelta{Content: "lo"}),
+		getStreamChunk(t, types.ChatCompletionStreamChoiceDelta{ToolCalls: []*types.ChatCompletionToolCalls{{
+			Id: "call_1", Index: 0, Function: &types.ChatCompletionToolCallsFunction{Name: "get_time", Arguments: `{"tz":`},
+		}}}),
+		getStreamChunk(t, types.ChatCompletionStreamChoiceDelta{ToolCalls: []*types.ChatCompletionToolCalls{{
+			Index: 0, Function: &types.ChatCompletionToolCallsFunction{Arguments: `"UTC"}`},
+		}}}),
+	}}
+	conn, relay := startTestBridge(t, provider)
+
+	events := readServerEvents(t, conn, types.EventTypeSessionCreated)
+	assert.Equal(t, "gpt-4o", events[0].Session.Model)
+
+	sendClientEvent(t, conn, `{"type":"session.update","session":{"modalities":["text"],"instructions":"Be brief.","tools":[{"type":"function","name":"get_time"}]}}`)
+	events = readServerEvents(t, conn, types.EventTypeSessionUpdated)
+	assert.Equal(t, []string{"text"}, events[0].Session.Modalities)
+
+	sendClientEvent(t, conn, `{"type":"conversation.item.create","item":{"type":"message","role":"user","content":[{"type":"input_text","text":"What time is it?"}]}}`)
+	readServerEvents(t, conn, types.EventTypeItemCreated)
+
+	sendClientEvent(t, conn, `{"type":"response.create"}`)
+	events = readServerEvents(t, conn, types.EventTypeResponseDone)
+	assert.Equal(t, []string{
+		types.EventTypeResponseCreated,
+		types.EventTypeOutputItemAdded,
+		types.EventTypeContentPartAdded,
+		types.EventTypeTextDelta,
+		types.EventTypeTextDelta,
+		types.EventTypeTextDone,
+		types.EventTypeContentPartDone,
+		types.EventTypeOutputItemDone,
+		types.EventTypeFunctionArgumentsDone,
+		types.EventTypeResponseDone,
+	}, realtimeEventTypes(events))
+
+	// 请求携带系统提示词、对话记录和工具
+	request := provider.requests[0]
+	assert.True(t, request.Stream)
+	assert.Len(t, request.Messages, 2)
+	assert.Equal(t, "Be brief.", request.Messages[0].StringContent())
+	assert.Equal(t, "What time is it?", request.Messages[1].StringContent())
+	assert.Equal(t, "get_time", request.Tools[0].Function.Name)
+
+	// 文本和工具调用的分片被合并
+	assert.Equal(t, "Hello", events[5].Text)
+	assert.Equal(t, "call_1", events[8].CallId)
+	assert.Equal(t, `{"tz":"UTC"}`, events[8].Arguments)
+
+	done := events[len(events)-1].Response
+	assert.Equal(t, "completed", done.Status)
+	assert.Len(t, done.Output, 2)
+	assert.Equal(t, 20, done.Usage.InputTokens)
+	assert.Equal(t, 6, done.Usage.OutputTokens)
+
+	// 下一次响应带上之前的回答，用量按响应累计
+	sendClientEvent(t, conn, `{"type":"response.create"}`)
+	readServerEvents(t, conn, types.EventTypeResponseDone)
+	assert.Len(t, provider.requests, 2)
+	assert.Len(t, provider.requests[1].Messages, 3)
+	assert.Equal(t, "Hello", provider.requests[1].Messages[2].StringContent())
+	assert.Equal(t, "call_1", provider.requests[1].Messages[2].ToolCalls[0].Id)
+
+	// 每次响应在发送 response.done 之前计费
+	assert.Equal(t, 52, relay.usage.TotalTokens)
+	assert.Equal(t, 40, relay.usage.InputTokens)
+	assert.Equal(t, 12, relay.usage.OutputTokens)
+}
+
+func TestRealtimeBridgeInvalidEvents(t *testing.T) {
+	conn, _ := startTestBridge(t, &fakeStreamProvider{})
+	readServerEvents(t, conn, types.EventTypeSessionCreated)
+
+	sendClientEvent(t, conn, `{"type":"input_audio_buffer.commit"}`)
+	events := readServerEvents(t, conn, "error")
+	assert.Len(t, events, 1)
+
+	sendClientEvent(t, conn, `{"type":"conversation.item.create","item":{"type":"unknown"}}`)
+	events = readServerEvents(t, conn, "error")
+	assert.Len(t, events, 1)
+
+	sendClientEvent(t, conn, `{"type":"input_audio_buffer.clear"}`)
+	readServerEvents(t, conn, types.EventTypeInputAudioBufferCleared)
+}
+
+func TestRealtimeFlushUsage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/realtime", nil)
+
+	var sources []requester.MessageSource
+	pending := &types.UsageEvent{InputTokens: 10, OutputTokens: 2, TotalTokens: 12}
+	relay := &RelayModeChatRealtime{
+		relayBase: relayBase{c: c},
+		quota:     &relay_util.Quota{},
+		usage:     &types.UsageEvent{TotalTokens: 5},
+		messageHandler: func(source requester.MessageSource, messageType int, message []byte) (bool, *types.UsageEvent, []byte, error) {
+			sources = append(sources, source)
+			usage := pending
+			pending = nil
+			return false, usage, nil, nil
+		},
+	}
+
+	// 连接关闭时计入处理器中尚未计费的用量
+	relay.flushUsage()
+	assert.Equal(t, []requester.MessageSource{requester.SessionClosed}, sources)
+	assert.Equal(t, 17, relay.usage.TotalTokens)
+
+	relay.flushUsage()
+	assert.Equal(t, 17, relay.usage.TotalTokens)
+}
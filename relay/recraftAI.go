@@ -43,13 +43,14 @@ func RelayRecraftAI(c *gin.Context) {
 		quota.Consume(c, usage, false)
 
 		metrics.RecordProvider(c, 200)
+		processChannelRelaySuccess(recraftProvider.GetChannel().Id, model)
 		errWithCode := responseMultipart(c, response)
 		logger.LogError(c.Request.Context(), fmt.Sprintf("relay error happen %v, won't retry in this case", errWithCode))
 		return
 	}
 
 	channel := recraftProvider.GetChannel()
-	go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
+	go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, model, apiErr, channel.Type)
 
 	retryTimes := config.RetryTimes
 	if !shouldRetry(c, apiErr, channel.Type) {
@@ -71,12 +72,13 @@ func RelayRecraftAI(c *gin.Context) {
 			quota.Consume(c, usage, false)
 
 			metrics.RecordProvider(c, 200)
+			processChannelRelaySuccess(channel.Id, model)
 			errWithCode := responseMultipart(c, response)
 			logger.LogError(c.Request.Context(), fmt.Sprintf("relay error happen %v, won't retry in this case", errWithCode))
 			return
 		}
 
-		go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
+		go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, model, apiErr, channel.Type)
 		if !shouldRetry(c, apiErr, channel.Type) {
 			break
 		}
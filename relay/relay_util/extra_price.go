@@ -21,6 +21,7 @@ var defaultExtraServicePrices = ExtraServicePriceConfig{
 	WebSearch: map[string]float64{
 		"high_tier": 0.025,
 		"standard":  0.01,
+		"server":    0.01, // 服务端搜索工具，按搜索次数计费
 	},
 	FileSearch:      0.0025,
 	CodeInterpreter: 0.03,
@@ -57,6 +58,8 @@ func getDefaultExtraServicePrice(serviceType, modelName, extraType string) float
 	case types.APITollTypeWebSearchPreview:
 		tier := getModelTier(modelName)
 		return defaultExtraServicePrices.WebSearch[tier]
+	case types.APITollTypeWebSearch:
+		return defaultExtraServicePrices.WebSearch["server"]
 	case types.APITollTypeFileSearch:
 		return defaultExtraServicePrices.FileSearch
 	case types.APITollTypeCodeInterpreter:
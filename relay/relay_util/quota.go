@@ -8,6 +8,7 @@ import (
 	"done-hub/model"
 	"done-hub/types"
 	"errors"
+	"fmt"
 	"math"
 	"net/http"
 	"time"
@@ -21,14 +22,30 @@ type Quota struct {
 	price            model.Price
 	groupName        string
 	groupRatio       float64
+	inputPrice       float64
+	outputPrice      float64
+	listInputPrice   float64 // 未应用专属价格的售价，用于计算上游成本
+	listOutputPrice  float64
 	inputRatio       float64
 	outputRatio      float64
+	priceTier        *model.PriceTier
+	priceWindow      *model.PriceTimeWindow
+	priceOverride    *model.PriceOverride
+	modelRouter      string // 请求的路由模型名称
+	fallbackFrom     string // 降级前请求的模型
+	plugins          []string
+	structuredOutput any  // 结构化输出的校验结果
+	scriptQuota      *int // 脚本调整前的额度
+	requestTime      time.Time
 	preConsumedQuota int
 	cacheQuota       int
 	userId           int
 	channelId        int
+	channel          *model.Channel
 	tokenId          int
 	HandelStatus     bool
+	// 不限量订阅套餐，命中时不计费
+	subscriptionPlan *model.SubscriptionPlan
 
 	startTime         time.Time
 	firstResponseTime time.Time
@@ -46,15 +63,49 @@ func NewQuota(c *gin.Context, modelName string, promptTokens int) *Quota {
 	}
 
 	quota.price = *model.PricingInstance.GetPrice(quota.modelName)
+	quota.channel = model.ChannelGroup.GetChannel(quota.channelId)
 	quota.groupRatio = c.GetFloat64("group_ratio")
 	quota.groupName = c.GetString("token_group")
-	quota.inputRatio = quota.price.GetInput() * quota.groupRatio
-	quota.outputRatio = quota.price.GetOutput() * quota.groupRatio
+	quota.modelRouter = c.GetString("model_router")
+	quota.fallbackFrom = c.GetString("fallback_from")
+	quota.requestTime = c.GetTime("requestStartTime")
+	if quota.requestTime.IsZero() {
+		quota.requestTime = time.Now()
+	}
+	quota.priceOverride = model.GetPriceOverride(quota.userId, quota.tokenId, modelName)
+	quota.resolvePrice(promptTokens)
+	quota.subscriptionPlan = model.GetUnlimitedSubscriptionPlan(quota.userId, modelName)
 
 	return quota
 }
 
+// 按提示词长度和请求时间确定阶梯、时段价格，再应用用户专属价格
+func (q *Quota) resolvePrice(promptTokens int) {
+	q.priceTier = q.price.GetTier(promptTokens)
+	q.priceWindow = q.price.GetTimeWindow(q.requestTime)
+	q.listInputPrice, q.listOutputPrice = q.price.Resolve(promptTokens, q.requestTime)
+	q.inputPrice, q.outputPrice = q.listInputPrice, q.listOutputPrice
+
+	if q.priceOverride != nil {
+		if q.priceOverride.IsFixed() {
+			q.inputPrice, q.outputPrice = q.priceOverride.Apply(q.price.GetInput(), q.price.GetOutput())
+		} else {
+			q.inputPrice, q.outputPrice = q.priceOverride.Apply(q.inputPrice, q.outputPrice)
+		}
+	}
+
+	q.inputRatio = q.inputPrice * q.groupRatio
+	q.outputRatio = q.outputPrice * q.groupRatio
+}
+
 func (q *Quota) PreQuotaConsumption() *types.OpenAIErrorWithStatusCode {
+	if q.subscriptionPlan != nil {
+		if !model.AllowSubscriptionRequest(q.userId, q.subscriptionPlan) {
+			return common.ErrorWrapper(errors.New("subscription rate limit exceeded"), "subscription_rate_limit_exceeded", http.StatusTooManyRequests)
+		}
+		return nil
+	}
+
 	if q.price.Type == model.TimesPriceType {
 		q.preConsumedQuota = int(1000 * q.inputRatio)
 	} else if q.price.Input != 0 || q.price.Output != 0 {
@@ -129,8 +180,11 @@ func (q *Quota) completedQuotaConsumption(usage *types.Usage, tokenName string,
 	}()
 
 	quota := q.GetTotalQuotaByUsage(usage)
+	quota = q.applyBillingScripts(usage, tokenName, isStream, quota)
+	cost := q.GetTotalCostByUsage(usage)
 
-	if quota > 0 {
+	// 脚本把费用改为 0 时也要退还预扣的额度
+	if quota > 0 || q.preConsumedQuota > 0 {
 		quotaDelta := quota - q.preConsumedQuota
 		err := model.PostConsumeTokenQuota(q.tokenId, quotaDelta)
 		if err != nil {
@@ -140,6 +194,8 @@ func (q *Quota) completedQuotaConsumption(usage *types.Usage, tokenName string,
 		if err != nil {
 			return errors.New("error consuming token remain quota: " + err.Error())
 		}
+	}
+	if quota > 0 {
 		model.UpdateChannelUsedQuota(q.channelId, quota)
 	}
 
@@ -152,6 +208,7 @@ func (q *Quota) completedQuotaConsumption(usage *types.Usage, tokenName string,
 		q.modelName,
 		tokenName,
 		quota,
+		cost,
 		"",
 		q.getRequestTime(),
 		isStream,
@@ -159,6 +216,9 @@ func (q *Quota) completedQuotaConsumption(usage *types.Usage, tokenName string,
 		sourceIp,
 	)
 	model.UpdateUserUsedQuotaAndRequestCount(q.userId, quota)
+	if quota > 0 {
+		model.CheckUserConsumeAlerts(q.userId, q.tokenId)
+	}
 
 	return nil
 }
@@ -180,6 +240,9 @@ func (q *Quota) Undo(c *gin.Context) {
 func (q *Quota) Consume(c *gin.Context, usage *types.Usage, isStream bool) {
 	tokenName := c.GetString("token_name")
 	q.startTime = c.GetTime("requestStartTime")
+	// 插件在发送请求时才确定
+	q.plugins = c.GetStringSlice("plugins")
+	q.structuredOutput, _ = c.Get("structured_output")
 	// 如果没有报错，则消费配额
 	go func(ctx context.Context) {
 		err := q.completedQuotaConsumption(usage, tokenName, isStream, c.ClientIP(), ctx)
@@ -189,6 +252,55 @@ func (q *Quota) Consume(c *gin.Context, usage *types.Usage, isStream bool) {
 	}(c.Request.Context())
 }
 
+// applyBillingScripts 执行计费脚本，脚本返回非负整数时替换本次扣费额度
+func (q *Quota) applyBillingScripts(usage *types.Usage, tokenName string, isStream bool, quota int) int {
+	if !model.ScriptInstance.Has(model.ScriptHookBilling) {
+		return quota
+	}
+
+	original := quota
+	ctx := map[string]any{
+		"model":             q.modelName,
+		"quota":             quota,
+		"prompt_tokens":     usage.PromptTokens,
+		"completion_tokens": usage.CompletionTokens,
+		"user_id":           q.userId,
+		"token_id":          q.tokenId,
+		"token_name":        tokenName,
+		"group":             q.groupName,
+		"channel_id":        q.channelId,
+		"is_stream":         isStream,
+	}
+
+	model.ScriptInstance.Run(model.ScriptHookBilling, ctx, func(result any) error {
+		var value float64
+		switch v := result.(type) {
+		case int64:
+			value = float64(v)
+		case float64:
+			value = v
+		default:
+			logger.SysError(fmt.Sprintf("billing script returned invalid quota: %v", result))
+			return nil
+		}
+
+		if value < 0 || value > math.MaxInt32 {
+			logger.SysError(fmt.Sprintf("billing script returned invalid quota: %v", result))
+			return nil
+		}
+
+		quota = int(math.Round(value))
+		// 后面的脚本拿到调整后的额度
+		ctx["quota"] = quota
+		return nil
+	})
+
+	if quota != original {
+		q.scriptQuota = &original
+	}
+	return quota
+}
+
 func (q *Quota) GetInputRatio() float64 {
 	return q.inputRatio
 }
@@ -198,8 +310,40 @@ func (q *Quota) GetLogMeta(usage *types.Usage) map[string]any {
 		"group_name":   q.groupName,
 		"price_type":   q.price.Type,
 		"group_ratio":  q.groupRatio,
-		"input_ratio":  q.price.GetInput(),
-		"output_ratio": q.price.GetOutput(),
+		"input_ratio":  q.inputPrice,
+		"output_ratio": q.outputPrice,
+	}
+
+	if q.priceTier != nil {
+		meta["price_tier"] = q.priceTier.Threshold
+	}
+
+	if q.priceWindow != nil {
+		meta["time_window_ratio"] = q.priceWindow.Ratio
+	}
+
+	if q.priceOverride != nil {
+		meta["price_override"] = q.priceOverride.Id
+	}
+
+	if q.modelRouter != "" {
+		meta["model_router"] = q.modelRouter
+	}
+
+	if q.fallbackFrom != "" {
+		meta["fallback_from"] = q.fallbackFrom
+	}
+
+	if len(q.plugins) > 0 {
+		meta["plugins"] = q.plugins
+	}
+
+	if q.structuredOutput != nil {
+		meta["structured_output"] = q.structuredOutput
+	}
+
+	if q.scriptQuota != nil {
+		meta["script_original_quota"] = *q.scriptQuota
 	}
 
 	firstResponseTime := q.GetFirstResponseTime()
@@ -221,6 +365,10 @@ func (q *Quota) GetLogMeta(usage *types.Usage) map[string]any {
 		meta["extra_billing"] = q.extraBillingData
 	}
 
+	if q.subscriptionPlan != nil {
+		meta["subscription_plan"] = q.subscriptionPlan.Name
+	}
+
 	return meta
 }
 
@@ -230,6 +378,10 @@ func (q *Quota) getRequestTime() int {
 
 // 通过 token 数获取消费配额
 func (q *Quota) GetTotalQuota(promptTokens, completionTokens int, extraBilling map[string]types.ExtraBilling) (quota int) {
+	if q.subscriptionPlan != nil {
+		return 0
+	}
+
 	if q.price.Type == model.TimesPriceType {
 		quota = int(1000 * q.inputRatio)
 	} else {
@@ -262,8 +414,8 @@ func (q *Quota) GetTotalQuota(promptTokens, completionTokens int, extraBilling m
 		quota = 0
 	}
 
-	// 如果禁用了空回复计费且没有输出token，则不计费
-	if !config.EmptyResponseBillingEnabled && completionTokens == 0 {
+	// 如果禁用了空回复计费且没有输出token，则不计费（按秒/按字符计费的模型只有输入）
+	if !config.EmptyResponseBillingEnabled && completionTokens == 0 && !q.price.IsUnitPrice() {
 		quota = 0
 	}
 
@@ -308,10 +460,48 @@ func (q *Quota) getComputeTokensByUsageEvent(usage *types.UsageEvent) (promptTok
 
 // 通过 usage 获取消费配额
 func (q *Quota) GetTotalQuotaByUsage(usage *types.Usage) (quota int) {
+	// 阶梯价格以实际的提示词 token 数为准
+	q.resolvePrice(usage.PromptTokens)
 	promptTokens, completionTokens := q.getComputeTokensByUsage(usage)
 	return q.GetTotalQuota(promptTokens, completionTokens, usage.ExtraBilling)
 }
 
+// 计算上游成本，不受分组倍率和订阅套餐影响
+func (q *Quota) GetTotalCost(promptTokens, completionTokens int) int {
+	if q.channel == nil {
+		return 0
+	}
+
+	// 按 token 计费时没有 token 说明请求出错，按次计费的请求仍然产生上游成本
+	if q.price.Type != model.TimesPriceType && promptTokens+completionTokens == 0 {
+		return 0
+	}
+
+	input, output := q.channel.ResolveCost(q.modelName, q.listInputPrice, q.listOutputPrice)
+
+	var cost float64
+	if q.price.Type == model.TimesPriceType {
+		cost = 1000 * input
+	} else {
+		cost = float64(promptTokens)*input + float64(completionTokens)*output
+	}
+
+	if q.extraBillingData != nil {
+		costRatio := q.channel.GetModelCostRatio(q.modelName)
+		for _, value := range q.extraBillingData {
+			cost += value.Price * float64(config.QuotaPerUnit) * float64(value.CallCount) * costRatio
+		}
+	}
+
+	return int(math.Ceil(cost))
+}
+
+// 通过 usage 获取上游成本，需在 GetTotalQuotaByUsage 之后调用
+func (q *Quota) GetTotalCostByUsage(usage *types.Usage) int {
+	promptTokens, completionTokens := q.getComputeTokensByUsage(usage)
+	return q.GetTotalCost(promptTokens, completionTokens)
+}
+
 func (q *Quota) GetFirstResponseTime() int64 {
 	// 先判断 firstResponseTime 是否为0
 	if q.firstResponseTime.IsZero() {
@@ -0,0 +1,225 @@
+package relay_util
+
+import (
+	"context"
+	"done-hub/common/cache"
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/common/script"
+	"done-hub/model"
+	"done-hub/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	logger.SetupLogger()
+	cache.InitCacheManager()
+}
+
+func setupQuotaTestDB(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, db.AutoMigrate(&model.User{}, &model.Token{}, &model.Channel{}))
+	// 内存数据库每个连接是独立的
+	sqlDB, err := db.DB()
+	assert.Nil(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	oldDB := model.DB
+	oldLogConsume := config.LogConsumeEnabled
+	oldBatch := config.BatchUpdateEnabled
+	model.DB = db
+	config.LogConsumeEnabled = false
+	config.BatchUpdateEnabled = false
+	t.Cleanup(func() {
+		model.DB = oldDB
+		config.LogConsumeEnabled = oldLogConsume
+		config.BatchUpdateEnabled = oldBatch
+	})
+}
+
+func setBillingScript(t *testing.T, code string) {
+	program, err := script.Compile("billing", code, model.GetScriptBudget())
+	assert.Nil(t, err)
+
+	model.ScriptInstance.SetPrograms([]*script.Program{program})
+	t.Cleanup(func() {
+		model.ScriptInstance.SetPrograms(nil)
+	})
+}
+
+func newTestQuota() *Quota {
+	return &Quota{
+		modelName:   "gpt-4o",
+		price:       model.Price{Type: model.TokensPriceType},
+		groupRatio:  1,
+		inputRatio:  1,
+		outputRatio: 2,
+		userId:      1,
+		channelId:   1,
+		tokenId:     1,
+	}
+}
+
+func TestApplyBillingScripts(t *testing.T) {
+	usage := &types.Usage{PromptTokens: 100, CompletionTokens: 50}
+
+	tests := []struct {
+		name   string
+		code   string
+		want   int
+		script bool
+	}{
+		{"double", "def on_billing(ctx):\n    return ctx[\"quota\"] * 2", 400, true},
+		{"free", "def on_billing(ctx):\n    return 0", 0, true},
+		{"round float", "def on_billing(ctx):\n    return ctx[\"quota\"] / 3.0", 67, true},
+		{"keep", "def on_billing(ctx):\n    return None", 200, false},
+		{"negative", "def on_billing(ctx):\n    return -1", 200, false},
+		{"invalid", "def on_billing(ctx):\n    return \"free\"", 200, false},
+		{"error", "def on_billing(ctx):\n    return ctx[\"missing\"]", 200, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setBillingScript(t, tt.code)
+
+			q := newTestQuota()
+			quota := q.applyBillingScripts(usage, "test", false, 200)
+			assert.Equal(t, tt.want, quota)
+			if tt.script {
+				assert.NotNil(t, q.scriptQuota)
+				assert.Equal(t, 200, *q.scriptQuota)
+			} else {
+				assert.Nil(t, q.scriptQuota)
+			}
+		})
+	}
+}
+
+func TestCompletedQuotaConsumptionScript(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      string
+		wantQuota int
+	}{
+		// 预扣 500，脚本免费时全部退还
+		{"free refunds pre-consumed", "def on_billing(ctx):\n    return 0", 1000},
+		{"override charges script quota", "def on_billing(ctx):\n    return 300", 700},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupQuotaTestDB(t)
+			setBillingScript(t, tt.code)
+
+			// 模拟预扣 500 之后的余额
+			db := model.DB.Session(&gorm.Session{SkipHooks: true})
+			assert.Nil(t, db.Create(&model.User{Id: 1, Username: "test", Quota: 500}).Error)
+			assert.Nil(t, db.Create(&model.Token{Id: 1, UserId: 1, Key: "test", RemainQuota: 500}).Error)
+			assert.Nil(t, db.Create(&model.Channel{Id: 1, Name: "test"}).Error)
+
+			q := newTestQuota()
+			q.preConsumedQuota = 500
+			usage := &types.Usage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150}
+			err := q.completedQuotaConsumption(usage, "test", false, "", context.Background())
+			assert.Nil(t, err)
+
+			user := &model.User{}
+			assert.Nil(t, model.DB.First(user, 1).Error)
+			assert.Equal(t, tt.wantQuota, user.Quota)
+
+			token := &model.Token{}
+			assert.Nil(t, model.DB.First(token, 1).Error)
+			assert.Equal(t, tt.wantQuota, token.RemainQuota)
+		})
+	}
+}
+
+func TestGetTotalCost(t *testing.T) {
+	costRatio := 0.5
+	channel := &model.Channel{Id: 1, CostRatio: &costRatio}
+
+	tests := []struct {
+		name         string
+		priceType    string
+		subscription bool
+		usage        types.Usage
+		wantQuota    int
+		wantCost     int
+	}{
+		{"tokens", model.TokensPriceType, false, types.Usage{PromptTokens: 100, CompletionTokens: 50}, 400, 100},
+		{"tokens without usage", model.TokensPriceType, false, types.Usage{}, 0, 0},
+		{"times", model.TimesPriceType, false, types.Usage{PromptTokens: 100, CompletionTokens: 50}, 2000, 500},
+		// 按次计费的请求没有 token 时仍然计算上游成本
+		{"times without usage", model.TimesPriceType, false, types.Usage{}, 0, 500},
+		// 订阅套餐不扣费，上游成本照常计算
+		{"subscription tokens", model.TokensPriceType, true, types.Usage{PromptTokens: 100, CompletionTokens: 50}, 0, 100},
+		{"subscription times", model.TimesPriceType, true, types.Usage{PromptTokens: 1}, 0, 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQuota()
+			q.channel = channel
+			q.price = model.Price{Type: tt.priceType, ChannelType: config.ChannelTypeOpenAI, Input: 1, Output: 2}
+			q.groupRatio = 2
+			if tt.subscription {
+				q.subscriptionPlan = &model.SubscriptionPlan{}
+			}
+
+			assert.Equal(t, tt.wantQuota, q.GetTotalQuotaByUsage(&tt.usage))
+			assert.Equal(t, tt.wantCost, q.GetTotalCostByUsage(&tt.usage))
+		})
+	}
+}
+
+func TestResolvePriceWithOverride(t *testing.T) {
+	fixedInput := 0.5
+	tiers := datatypes.NewJSONType([]model.PriceTier{{Threshold: 1000, Input: 4, Output: 8}})
+	windows := datatypes.NewJSONType([]model.PriceTimeWindow{{Start: "22:00", End: "06:00", Timezone: "UTC", Ratio: 0.5}})
+	day := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	night := time.Date(2026, 1, 1, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		override     *model.PriceOverride
+		promptTokens int
+		at           time.Time
+		input        float64
+		output       float64
+		listInput    float64
+	}{
+		{"base", nil, 100, day, 2, 4, 2},
+		{"tier", nil, 1001, day, 4, 8, 4},
+		{"tier at night", nil, 1001, night, 2, 4, 2},
+		// 倍率模式在阶梯和时段价格上折算
+		{"ratio", &model.PriceOverride{Ratio: 0.5}, 100, day, 1, 2, 2},
+		{"ratio with tier", &model.PriceOverride{Ratio: 0.5}, 1001, night, 1, 2, 2},
+		// 固定价格不受阶梯和时段影响，未设置的一侧使用基础价格
+		{"fixed", &model.PriceOverride{Ratio: 1, Input: &fixedInput}, 100, day, 0.5, 4, 2},
+		{"fixed with tier", &model.PriceOverride{Ratio: 1, Input: &fixedInput}, 1001, night, 0.5, 4, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQuota()
+			q.price = model.Price{Type: model.TokensPriceType, Input: 2, Output: 4, Tiers: &tiers, TimeWindows: &windows}
+			q.priceOverride = tt.override
+			q.requestTime = tt.at
+			q.resolvePrice(tt.promptTokens)
+
+			assert.Equal(t, tt.input, q.inputPrice)
+			assert.Equal(t, tt.output, q.outputPrice)
+			// 上游成本使用未应用专属价格的售价
+			assert.Equal(t, tt.listInput, q.listInputPrice)
+			assert.Equal(t, tt.promptTokens > 1000, q.priceTier != nil)
+			assert.Equal(t, tt.at == night, q.priceWindow != nil)
+		})
+	}
+}
@@ -251,6 +251,7 @@ func (converter *OpenAIResponsesStreamConverter) processMessage(choice types.Cha
 
 	// 处理文本增量
 	converter.part.Text += choice.Delta.Content
+	converter.part.Annotations = append(converter.part.Annotations, types.ChatAnnotationsToResponses(choice.Delta.Annotations)...)
 }
 
 // 结束message part
@@ -27,11 +27,12 @@ func RelayRerank(c *gin.Context) {
 
 	apiErr, done := RelayHandler(relay)
 	if apiErr == nil {
+		processChannelRelaySuccess(c.GetInt("channel_id"), c.GetString("original_model"))
 		return
 	}
 
 	channel := relay.getProvider().GetChannel()
-	go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
+	go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, c.GetString("original_model"), apiErr, channel.Type)
 
 	retryTimes := config.RetryTimes
 	if done || !shouldRetry(c, apiErr, channel.Type) {
@@ -50,9 +51,10 @@ func RelayRerank(c *gin.Context) {
 		logger.LogError(c.Request.Context(), fmt.Sprintf("using channel #%d(%s) to retry (remain times %d)", channel.Id, channel.Name, i))
 		apiErr, done = RelayHandler(relay)
 		if apiErr == nil {
+			processChannelRelaySuccess(c.GetInt("channel_id"), c.GetString("original_model"))
 			return
 		}
-		go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
+		go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, c.GetString("original_model"), apiErr, channel.Type)
 		if done || !shouldRetry(c, apiErr, channel.Type) {
 			break
 		}
@@ -6,6 +6,8 @@ import (
 	"done-hub/common/requester"
 	providersBase "done-hub/providers/base"
 	"done-hub/relay/relay_util"
+	"done-hub/relay/toolcall"
+	"done-hub/relay/websearch"
 	"done-hub/types"
 	"errors"
 	"io"
@@ -63,7 +65,7 @@ func (r *relayResponses) send() (err *types.OpenAIErrorWithStatusCode, done bool
 			return
 		}
 
-		return r.compatibleSend(chatProvider)
+		return r.compatibleSend(websearch.Wrap(toolcall.Wrap(chatProvider)))
 	}
 
 	if r.responsesRequest.Stream {
@@ -104,6 +106,7 @@ func (r *relayResponses) compatibleSend(chatProvider providersBase.ChatInterface
 	if err != nil {
 		return common.ErrorWrapperLocal(err, "invalid_claude_config", http.StatusInternalServerError), true
 	}
+	setResponsesSearch(&r.responsesRequest, chatReq)
 
 	if r.responsesRequest.Stream {
 		var response requester.StreamReaderInterface[string]
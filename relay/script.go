@@ -0,0 +1,191 @@
+package relay
+
+import (
+	"done-hub/common/config"
+	"done-hub/common/logger"
+	"done-hub/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 不传递给脚本的请求头
+var scriptHiddenHeaders = map[string]bool{
+	"authorization":  true,
+	"cookie":         true,
+	"x-api-key":      true,
+	"api-key":        true,
+	"x-goog-api-key": true,
+}
+
+// newScriptContext 脚本中可以读取的令牌、用户和请求头信息
+func newScriptContext(c *gin.Context, modelName string) map[string]any {
+	headers := make(map[string]any, len(c.Request.Header))
+	for key, values := range c.Request.Header {
+		key = strings.ToLower(key)
+		if scriptHiddenHeaders[key] || len(values) == 0 {
+			continue
+		}
+		headers[key] = values[0]
+	}
+
+	return map[string]any{
+		"model":       modelName,
+		"path":        c.Request.URL.Path,
+		"user_id":     c.GetInt("id"),
+		"group":       c.GetString("group"),
+		"token_id":    c.GetInt("token_id"),
+		"token_name":  c.GetString("token_name"),
+		"token_group": c.GetString("token_group"),
+		"is_stream":   c.GetBool("is_stream"),
+		"headers":     headers,
+	}
+}
+
+// applyRequestScripts 执行请求脚本，脚本可以修改请求或拒绝请求
+func applyRequestScripts(c *gin.Context, relay RelayBaseInterface) error {
+	if !model.ScriptInstance.Has(model.ScriptHookRequest) {
+		return nil
+	}
+
+	request := relay.getRequest()
+	if request == nil || reflect.ValueOf(request).Kind() != reflect.Ptr {
+		return nil
+	}
+
+	ctx := newScriptContext(c, relay.getOriginalModel())
+	ctx["request"] = request
+
+	var changed bool
+	err := model.ScriptInstance.Run(model.ScriptHookRequest, ctx, func(result any) error {
+		values, ok := result.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		if reason, ok := values["reject"].(string); ok && reason != "" {
+			return errors.New(reason)
+		}
+
+		if modified, ok := values["request"].(map[string]any); ok {
+			ctx["request"] = modified
+			changed = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := replaceRequest(request, ctx["request"]); err != nil {
+		logger.LogError(c.Request.Context(), "script modify request failed: "+err.Error())
+		return nil
+	}
+
+	c.Set("is_stream", relay.IsStream())
+
+	// 脚本修改了模型时按新的模型分发
+	if values, ok := ctx["request"].(map[string]any); ok {
+		if modelName, ok := values["model"].(string); ok && modelName != "" && modelName != relay.getOriginalModel() {
+			relay.setRoutedModel(modelName)
+		}
+	}
+
+	return nil
+}
+
+// replaceRequest 使用脚本返回的数据替换原请求，解析失败时保留原请求
+func replaceRequest(request any, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	target := reflect.ValueOf(request).Elem()
+	replaced := reflect.New(target.Type())
+	if err := json.Unmarshal(body, replaced.Interface()); err != nil {
+		return err
+	}
+
+	target.Set(replaced.Elem())
+	return nil
+}
+
+// getScriptChannelFilter 执行渠道脚本，过滤掉脚本没有保留的渠道
+func getScriptChannelFilter(c *gin.Context, group, modelName string, filters []model.ChannelsFilterFunc) model.ChannelsFilterFunc {
+	if !model.ScriptInstance.Has(model.ScriptHookChannels) {
+		return nil
+	}
+
+	channels, levels := model.ChannelGroup.AvailableChannels(group, modelName, filters...)
+	if len(channels) == 0 {
+		return nil
+	}
+
+	candidates := make([]any, 0, len(channels))
+	for i, channel := range channels {
+		weight := config.DefaultChannelWeight
+		if channel.Weight != nil {
+			weight = *channel.Weight
+		}
+		candidates = append(candidates, map[string]any{
+			"id":       channel.Id,
+			"name":     channel.Name,
+			"type":     channel.Type,
+			"tag":      channel.Tag,
+			"priority": levels[i],
+			"weight":   weight,
+		})
+	}
+
+	ctx := newScriptContext(c, modelName)
+	ctx["channels"] = candidates
+
+	var keep map[int]bool
+	model.ScriptInstance.Run(model.ScriptHookChannels, ctx, func(result any) error {
+		ids, ok := result.([]any)
+		if !ok {
+			logger.LogError(c.Request.Context(), fmt.Sprintf("channel script returned invalid result: %v", result))
+			return nil
+		}
+
+		selected := make(map[int]bool, len(ids))
+		remaining := make([]any, 0, len(ids))
+		for _, id := range ids {
+			channelId, ok := id.(int64)
+			if !ok {
+				continue
+			}
+			// 多个脚本时只能在前面脚本保留的渠道中选择
+			if keep != nil && !keep[int(channelId)] {
+				continue
+			}
+			selected[int(channelId)] = true
+		}
+		for _, candidate := range candidates {
+			if selected[candidate.(map[string]any)["id"].(int)] {
+				remaining = append(remaining, candidate)
+			}
+		}
+
+		keep = selected
+		ctx["channels"] = remaining
+		return nil
+	})
+
+	if keep == nil {
+		return nil
+	}
+
+	return func(channelId int, _ *model.ChannelChoice) bool {
+		return !keep[channelId]
+	}
+}
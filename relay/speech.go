@@ -2,13 +2,24 @@ package relay
 
 import (
 	"done-hub/common"
+	"done-hub/common/requester"
+	"done-hub/model"
 	providersBase "done-hub/providers/base"
 	"done-hub/types"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 流式输出时每个音频分片的大小
+const speechStreamChunkSize = 32 * 1024
+
 type relaySpeech struct {
 	relayBase
 	request types.SpeechAudioRequest
@@ -30,6 +41,10 @@ func (r *relaySpeech) setRequest() error {
 	return nil
 }
 
+func (r *relaySpeech) IsStream() bool {
+	return r.request.IsStream()
+}
+
 func (r *relaySpeech) getPromptTokens() (int, error) {
 	return len(r.request.Input), nil
 }
@@ -48,7 +63,14 @@ func (r *relaySpeech) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
 	if err != nil {
 		return
 	}
-	err = responseMultipart(r.c, response)
+
+	r.setCharsUsage()
+
+	if r.IsStream() {
+		err = r.responseStream(response)
+	} else {
+		err = responseMultipart(r.c, response)
+	}
 
 	if err != nil {
 		done = true
@@ -56,3 +78,58 @@ func (r *relaySpeech) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
 
 	return
 }
+
+// 按字符计费的模型，使用输入文本的字符数作为计费数量
+func (r *relaySpeech) setCharsUsage() {
+	price := model.PricingInstance.GetPrice(r.getModelName())
+	if price.Type != model.CharsPriceType {
+		return
+	}
+
+	usage := r.provider.GetUsage()
+	usage.PromptTokens = utf8.RuneCountInString(r.request.Input)
+	usage.CompletionTokens = 0
+	usage.TotalTokens = usage.PromptTokens
+}
+
+// 上游原生支持 SSE 时直接透传，否则将完整音频切片后按 OpenAI 的格式输出
+func (r *relaySpeech) responseStream(resp *http.Response) *types.OpenAIErrorWithStatusCode {
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return responseMultipart(r.c, resp)
+	}
+	defer resp.Body.Close()
+
+	audio, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return common.ErrorWrapper(err, "read_response_body_failed", http.StatusInternalServerError)
+	}
+
+	requester.SetEventStreamHeaders(r.c)
+	r.c.Writer.WriteHeader(http.StatusOK)
+
+	for start := 0; start < len(audio); start += speechStreamChunkSize {
+		end := min(start+speechStreamChunkSize, len(audio))
+		r.writeStreamData(&types.SpeechStreamResponse{
+			Type:  types.SpeechAudioDelta,
+			Audio: base64.StdEncoding.EncodeToString(audio[start:end]),
+		})
+	}
+
+	usage := r.provider.GetUsage()
+	r.writeStreamData(&types.SpeechStreamResponse{
+		Type: types.SpeechAudioDone,
+		Usage: &types.AudioStreamUsage{
+			InputTokens:  usage.PromptTokens,
+			OutputTokens: usage.CompletionTokens,
+			TotalTokens:  usage.PromptTokens + usage.CompletionTokens,
+		},
+	})
+
+	return nil
+}
+
+func (r *relaySpeech) writeStreamData(data any) {
+	str, _ := json.Marshal(data)
+	fmt.Fprintf(r.c.Writer, "data: %s\n\n", str)
+	r.c.Writer.Flush()
+}
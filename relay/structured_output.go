@@ -0,0 +1,60 @@
+package relay
+
+import (
+	"done-hub/common/config"
+	"done-hub/model"
+	"done-hub/providers/claude"
+	"done-hub/types"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 结构化输出的实现方式
+const (
+	structuredOutputNative = "native" // 渠道原生支持 json_schema
+	structuredOutputPrompt = "prompt" // 把 schema 写入提示词
+)
+
+// isNativeStructuredOutput 渠道是否可以直接处理 json_schema
+func isNativeStructuredOutput(channel *model.Channel, request *types.ChatCompletionRequest, modelName string) bool {
+	switch channel.Type {
+	case config.ChannelTypeOpenAI, config.ChannelTypeAzure, config.ChannelTypeAzureV1, config.ChannelTypeGemini:
+		return true
+	case config.ChannelTypeAnthropic, config.ChannelTypeBedrock:
+		return claude.UseStructuredOutputTool(request)
+	case config.ChannelTypeVertexAI:
+		if strings.HasPrefix(modelName, "claude") {
+			return claude.UseStructuredOutputTool(request)
+		}
+		return true
+	}
+	return false
+}
+
+// getStructuredOutputPipeline 请求要求 json_schema 输出时校验输出，渠道不支持时写入提示词
+func getStructuredOutputPipeline(c *gin.Context, channel *model.Channel, request *types.ChatCompletionRequest, modelName string) model.PluginPipeline {
+	if !config.StructuredOutputEnabled || channel == nil || request == nil {
+		return nil
+	}
+
+	format := request.ResponseFormat
+	if format == nil || format.Type != "json_schema" || format.JsonSchema == nil || format.JsonSchema.Schema == nil {
+		return nil
+	}
+
+	mode := structuredOutputPrompt
+	if isNativeStructuredOutput(channel, request, modelName) {
+		mode = structuredOutputNative
+	}
+	c.Set("structured_output_mode", mode)
+
+	retries := min(max(config.StructuredOutputMaxRetries, 0), 5)
+	return model.PluginPipeline{{
+		Name: "json_schema",
+		Params: map[string]any{
+			"max_retries": retries,
+			"inject":      mode == structuredOutputPrompt,
+		},
+	}}
+}
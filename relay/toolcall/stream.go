@@ -0,0 +1,225 @@
+package toolcall
+
+import (
+	"done-hub/types"
+	"encoding/json"
+	"strings"
+)
+
+// choiceState 流式响应中每个 choice 的解析状态
+type choiceState struct {
+	inBlock bool
+	done    bool   // 已经输出了工具调用，之后的内容丢弃
+	pending string // 可能是开始标签一部分的内容
+	block   strings.Builder
+}
+
+// streamProcessor 从流式内容中识别工具调用块，转换为 tool_calls
+type streamProcessor struct {
+	request *types.ChatCompletionRequest
+	tools   map[string]*types.ChatCompletionFunction
+	states  map[int]*choiceState
+	last    *types.ChatCompletionStreamResponse
+}
+
+func newStreamProcessor(request *types.ChatCompletionRequest, tools map[string]*types.ChatCompletionFunction) *streamProcessor {
+	return &streamProcessor{
+		request: request,
+		tools:   tools,
+		states:  map[int]*choiceState{},
+	}
+}
+
+func (s *streamProcessor) Process(data string) (string, bool) {
+	var chunk types.ChatCompletionStreamResponse
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return data, true
+	}
+	if len(chunk.Choices) == 0 {
+		return data, true
+	}
+	s.last = &chunk
+
+	changed := false
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		state := s.getState(choice.Index)
+		if choice.Delta.Content == "" && choice.FinishReason == nil {
+			continue
+		}
+
+		changed = true
+		s.processChoice(state, choice)
+	}
+
+	if !changed {
+		return data, true
+	}
+
+	if chunk.IsEmpty() {
+		return "", false
+	}
+
+	result, err := json.Marshal(chunk)
+	if err != nil {
+		return data, true
+	}
+	return string(result), true
+}
+
+func (s *streamProcessor) getState(index int) *choiceState {
+	state, ok := s.states[index]
+	if !ok {
+		state = &choiceState{}
+		s.states[index] = state
+	}
+	return state
+}
+
+func (s *streamProcessor) processChoice(state *choiceState, choice *types.ChatCompletionStreamChoice) {
+	content := choice.Delta.Content
+	choice.Delta.Content = ""
+
+	var calls []*types.ChatCompletionToolCalls
+	if !state.done {
+		choice.Delta.Content, calls = state.feed(content, s.tools)
+	}
+
+	// 结束时输出缓存的内容，未闭合的块也尝试解析
+	if choice.FinishReason != nil && !state.done {
+		var text string
+		text, calls = state.flush(s.tools)
+		choice.Delta.Content += text
+	}
+
+	if calls != nil {
+		choice.Delta.ToolCalls = calls
+		choice.CheckChoice(s.request)
+	}
+
+	if choice.FinishReason != nil && state.done {
+		choice.FinishReason = types.FinishReasonToolCalls
+	}
+}
+
+// Finish 上游没有返回结束原因时，在结束前输出剩余的内容
+func (s *streamProcessor) Finish() []string {
+	chunk := s.flushAll()
+	if chunk == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return nil
+	}
+	return []string{string(data)}
+}
+
+// flushAll 上游没有返回结束原因就结束时，输出所有 choice 缓存的内容
+func (s *streamProcessor) flushAll() *types.ChatCompletionStreamResponse {
+	var choices []types.ChatCompletionStreamChoice
+	for index, state := range s.states {
+		if state.done {
+			continue
+		}
+
+		text, calls := state.flush(s.tools)
+		if text == "" && calls == nil {
+			continue
+		}
+
+		choice := types.ChatCompletionStreamChoice{
+			Index: index,
+			Delta: types.ChatCompletionStreamChoiceDelta{Content: text, ToolCalls: calls},
+		}
+		if calls != nil {
+			choice.FinishReason = types.FinishReasonToolCalls
+			choice.CheckChoice(s.request)
+		}
+		choices = append(choices, choice)
+	}
+
+	if len(choices) == 0 {
+		return nil
+	}
+
+	chunk := &types.ChatCompletionStreamResponse{
+		Object:  "chat.completion.chunk",
+		Choices: choices,
+	}
+	if s.last != nil {
+		chunk.ID = s.last.ID
+		chunk.Created = s.last.Created
+		chunk.Model = s.last.Model
+	}
+	return chunk
+}
+
+// feed 返回可以直接输出的文本，工具调用块结束时返回解析出的调用
+func (state *choiceState) feed(content string, tools map[string]*types.ChatCompletionFunction) (string, []*types.ChatCompletionToolCalls) {
+	if state.inBlock {
+		state.block.WriteString(content)
+		return state.closeBlock(tools)
+	}
+
+	text := state.pending + content
+	state.pending = ""
+
+	if start := strings.Index(text, openTag); start >= 0 {
+		state.inBlock = true
+		state.block.WriteString(text[start+len(openTag):])
+		output, calls := state.closeBlock(tools)
+		return strings.TrimRight(text[:start], " \t\r\n") + output, calls
+	}
+
+	// 末尾可能是开始标签的一部分，等待后续内容
+	for i := min(len(openTag)-1, len(text)); i > 0; i-- {
+		if strings.HasSuffix(text, openTag[:i]) {
+			state.pending = text[len(text)-i:]
+			return text[:len(text)-i], nil
+		}
+	}
+
+	return text, nil
+}
+
+// closeBlock 找到结束标签时解析调用，解析失败时把整个块作为普通文本输出
+func (state *choiceState) closeBlock(tools map[string]*types.ChatCompletionFunction) (string, []*types.ChatCompletionToolCalls) {
+	block := state.block.String()
+	end := strings.Index(block, closeTag)
+	if end < 0 {
+		return "", nil
+	}
+
+	state.inBlock = false
+	state.block.Reset()
+
+	calls, ok := parseBlock(block[:end], tools)
+	if !ok {
+		return openTag + block, nil
+	}
+
+	state.done = true
+	return "", calls
+}
+
+func (state *choiceState) flush(tools map[string]*types.ChatCompletionFunction) (string, []*types.ChatCompletionToolCalls) {
+	if !state.inBlock {
+		text := state.pending
+		state.pending = ""
+		return text, nil
+	}
+
+	block := state.block.String()
+	state.inBlock = false
+	state.block.Reset()
+
+	calls, ok := parseBlock(block, tools)
+	if !ok {
+		return openTag + block, nil
+	}
+
+	state.done = true
+	return "", calls
+}
@@ -0,0 +1,238 @@
+package toolcall
+
+import (
+	"done-hub/common/requester"
+	"done-hub/types"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStream 依次返回预设的数据块，最后返回 io.EOF
+type fakeStream struct {
+	chunks []string
+}
+
+func (s *fakeStream) Recv() (<-chan string, <-chan error) {
+	dataChan := make(chan string)
+	errChan := make(chan error)
+	go func() {
+		for _, chunk := range s.chunks {
+			dataChan <- chunk
+		}
+		errChan <- io.EOF
+	}()
+	return dataChan, errChan
+}
+
+func (s *fakeStream) Close() {}
+
+func getChunk(t *testing.T, content string, finishReason any) string {
+	data, err := json.Marshal(&types.ChatCompletionStreamResponse{
+		ID:     "chatcmpl-1",
+		Object: "chat.completion.chunk",
+		Model:  "gpt-4o",
+		Choices: []types.ChatCompletionStreamChoice{{
+			Delta:        types.ChatCompletionStreamChoiceDelta{Content: content},
+			FinishReason: finishReason,
+		}},
+	})
+	assert.Nil(t, err)
+	return string(data)
+}
+
+// streamResult 流式输出合并后的结果
+type streamResult struct {
+	content      string
+	calls        []*types.ChatCompletionToolCalls
+	finishReason any
+}
+
+func readStream(t *testing.T, chunks []string) streamResult {
+	stream := requester.NewChunkStreamReader(&fakeStream{chunks: chunks}, newStreamProcessor(&types.ChatCompletionRequest{}, testTools))
+	dataChan, errChan := stream.Recv()
+
+	var result streamResult
+	var content strings.Builder
+	for {
+		select {
+		case data := <-dataChan:
+			var chunk types.ChatCompletionStreamResponse
+			assert.Nil(t, json.Unmarshal([]byte(data), &chunk))
+			assert.Equal(t, "chatcmpl-1", chunk.ID)
+			for _, choice := range chunk.Choices {
+				content.WriteString(choice.Delta.Content)
+				result.calls = append(result.calls, choice.Delta.ToolCalls...)
+				if choice.FinishReason != nil {
+					result.finishReason = choice.FinishReason
+				}
+			}
+		case err := <-errChan:
+			assert.True(t, errors.Is(err, io.EOF))
+			result.content = content.String()
+			return result
+		}
+	}
+}
+
+func TestChoiceStateFeed(t *testing.T) {
+	t.Run("tag split across chunks", func(t *testing.T) {
+		state := &choiceState{}
+
+		text, calls := state.feed("Let me check. <tool", testTools)
+		assert.Equal(t, "Let me check. ", text)
+		assert.Nil(t, calls)
+		assert.Equal(t, "<tool", state.pending)
+
+		text, calls = state.feed(`_calls>[{"name":"get_weather",`, testTools)
+		assert.Equal(t, "", text)
+		assert.Nil(t, calls)
+		assert.True(t, state.inBlock)
+
+		text, calls = state.feed(`"arguments":{"city":"Paris"}}]</tool_`, testTools)
+		assert.Equal(t, "", text)
+		assert.Nil(t, calls)
+
+		text, calls = state.feed("calls>", testTools)
+		assert.Equal(t, "", text)
+		assert.Len(t, calls, 1)
+		assert.Equal(t, `{"city":"Paris"}`, calls[0].Function.Arguments)
+		assert.True(t, state.done)
+		assert.False(t, state.inBlock)
+	})
+
+	t.Run("partial tag that is not a tag", func(t *testing.T) {
+		state := &choiceState{}
+
+		text, _ := state.feed("a <to", testTools)
+		assert.Equal(t, "a ", text)
+
+		text, _ = state.feed("day", testTools)
+		assert.Equal(t, "<today", text)
+		assert.Empty(t, state.pending)
+	})
+
+	t.Run("trailing whitespace before block", func(t *testing.T) {
+		state := &choiceState{}
+
+		text, calls := state.feed("ok\n\n<tool_calls>[{\"name\":\"get_time\"}]</tool_calls>", testTools)
+		assert.Equal(t, "ok", text)
+		assert.Len(t, calls, 1)
+	})
+
+	t.Run("unknown tool", func(t *testing.T) {
+		state := &choiceState{}
+
+		block := `<tool_calls>[{"name":"rm"}]</tool_calls>`
+		text, calls := state.feed(block, testTools)
+		assert.Equal(t, block, text)
+		assert.Nil(t, calls)
+		assert.False(t, state.done)
+		assert.False(t, state.inBlock)
+	})
+
+	t.Run("fenced json", func(t *testing.T) {
+		state := &choiceState{}
+
+		state.feed("<tool_calls>\n```json\n", testTools)
+		_, calls := state.feed("[{\"name\":\"get_time\"}]\n```\n</tool_calls>", testTools)
+		assert.Len(t, calls, 1)
+		assert.Equal(t, "get_time", calls[0].Function.Name)
+	})
+}
+
+func TestChoiceStateFlush(t *testing.T) {
+	t.Run("pending text", func(t *testing.T) {
+		state := &choiceState{}
+		state.feed("a <tool_", testTools)
+
+		text, calls := state.flush(testTools)
+		assert.Equal(t, "<tool_", text)
+		assert.Nil(t, calls)
+	})
+
+	t.Run("unclosed block", func(t *testing.T) {
+		state := &choiceState{}
+		state.feed(`<tool_calls>[{"name":"get_time"}]`, testTools)
+
+		text, calls := state.flush(testTools)
+		assert.Equal(t, "", text)
+		assert.Len(t, calls, 1)
+		assert.True(t, state.done)
+	})
+
+	t.Run("unclosed invalid block", func(t *testing.T) {
+		state := &choiceState{}
+		state.feed(`<tool_calls>[{"name":"get_`, testTools)
+
+		text, calls := state.flush(testTools)
+		assert.Equal(t, `<tool_calls>[{"name":"get_`, text)
+		assert.Nil(t, calls)
+		assert.False(t, state.done)
+	})
+}
+
+func TestStreamProcessor(t *testing.T) {
+	t.Run("tool calls", func(t *testing.T) {
+		result := readStream(t, []string{
+			getChunk(t, "Let me check. <tool", nil),
+			getChunk(t, `_calls>[{"name":"get_weather","arguments":{"city":"Paris"}}]`, nil),
+			getChunk(t, "</tool_calls>", nil),
+			getChunk(t, "ignored", nil),
+			getChunk(t, "", types.FinishReasonStop),
+		})
+
+		assert.Equal(t, "Let me check. ", result.content)
+		assert.Len(t, result.calls, 1)
+		assert.Equal(t, "get_weather", result.calls[0].Function.Name)
+		assert.Equal(t, types.FinishReasonToolCalls, result.finishReason)
+	})
+
+	t.Run("plain text", func(t *testing.T) {
+		result := readStream(t, []string{
+			getChunk(t, "a <to", nil),
+			getChunk(t, "day", nil),
+			getChunk(t, "", types.FinishReasonStop),
+		})
+
+		assert.Equal(t, "a <today", result.content)
+		assert.Empty(t, result.calls)
+		assert.Equal(t, types.FinishReasonStop, result.finishReason)
+	})
+
+	t.Run("pending text flushed on finish reason", func(t *testing.T) {
+		result := readStream(t, []string{
+			getChunk(t, "a <tool", nil),
+			getChunk(t, "", types.FinishReasonStop),
+		})
+
+		assert.Equal(t, "a <tool", result.content)
+		assert.Equal(t, types.FinishReasonStop, result.finishReason)
+	})
+
+	t.Run("unclosed block at eof", func(t *testing.T) {
+		result := readStream(t, []string{
+			getChunk(t, "<tool_calls>", nil),
+			getChunk(t, `[{"name":"get_time"}]`, nil),
+		})
+
+		assert.Equal(t, "", result.content)
+		assert.Len(t, result.calls, 1)
+		assert.Equal(t, types.FinishReasonToolCalls, result.finishReason)
+	})
+
+	t.Run("unknown tool at eof", func(t *testing.T) {
+		result := readStream(t, []string{
+			getChunk(t, "<tool_calls>", nil),
+			getChunk(t, `[{"name":"rm"}]`, nil),
+		})
+
+		assert.Equal(t, `<tool_calls>[{"name":"rm"}]`, result.content)
+		assert.Empty(t, result.calls)
+		assert.Nil(t, result.finishReason)
+	})
+}
@@ -0,0 +1,373 @@
+package toolcall
+
+import (
+	"bytes"
+	"done-hub/common/requester"
+	"done-hub/common/utils"
+	providersBase "done-hub/providers/base"
+	"done-hub/types"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 模型不支持原生函数调用时，把工具定义写入提示词，再从输出中解析工具调用
+
+const (
+	openTag  = "<tool_calls>"
+	closeTag = "</tool_calls>"
+)
+
+const promptTemplate = `You have access to the following tools:
+
+%s
+
+To call tools, reply with exactly one block in the following format and write nothing after it:
+<tool_calls>
+[{"name": "tool_name", "arguments": {"argument_name": "value"}}]
+</tool_calls>
+The block contains a JSON array, you can call several tools at once. The arguments must be a JSON object that matches the parameters of the tool. Tool results will be returned to you in <tool_result> blocks.%s`
+
+// emulatedProvider 包装不支持函数调用的渠道
+type emulatedProvider struct {
+	providersBase.ChatInterface
+}
+
+// Wrap 渠道开启了工具调用模拟时返回包装后的 provider，否则原样返回
+func Wrap(provider providersBase.ChatInterface) providersBase.ChatInterface {
+	channel := provider.GetChannel()
+	if channel == nil || !channel.ToolEmulation {
+		return provider
+	}
+
+	if _, ok := provider.(*emulatedProvider); ok {
+		return provider
+	}
+	return &emulatedProvider{ChatInterface: provider}
+}
+
+func (p *emulatedProvider) CreateChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	if !needEmulation(request) {
+		return p.ChatInterface.CreateChatCompletion(request)
+	}
+
+	tools := getFunctions(request)
+	response, errWithCode := p.ChatInterface.CreateChatCompletion(convertRequest(request, tools))
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	for i := range response.Choices {
+		choice := &response.Choices[i]
+		content, ok := choice.Message.Content.(string)
+		if !ok || !strings.Contains(content, openTag) {
+			continue
+		}
+
+		text, calls, ok := parseToolCalls(content, tools)
+		if !ok {
+			continue
+		}
+
+		choice.Message.Content = text
+		choice.Message.ToolCalls = calls
+		choice.FinishReason = types.FinishReasonToolCalls
+		choice.CheckChoice(request)
+	}
+
+	return response, nil
+}
+
+func (p *emulatedProvider) CreateChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	if !needEmulation(request) {
+		return p.ChatInterface.CreateChatCompletionStream(request)
+	}
+
+	tools := getFunctions(request)
+	stream, errWithCode := p.ChatInterface.CreateChatCompletionStream(convertRequest(request, tools))
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	return requester.NewChunkStreamReader(stream, newStreamProcessor(request, tools)), nil
+}
+
+// needEmulation 请求中有工具定义或者历史消息中有工具调用
+func needEmulation(request *types.ChatCompletionRequest) bool {
+	if len(request.Tools) > 0 || len(request.Functions) > 0 {
+		return true
+	}
+
+	for _, message := range request.Messages {
+		if len(message.ToolCalls) > 0 || message.FunctionCall != nil ||
+			message.Role == types.ChatMessageRoleTool || message.Role == types.ChatMessageRoleFunction {
+			return true
+		}
+	}
+	return false
+}
+
+// getFunctions 请求中声明的函数，旧版的 functions 也转换为工具
+func getFunctions(request *types.ChatCompletionRequest) map[string]*types.ChatCompletionFunction {
+	functions := make(map[string]*types.ChatCompletionFunction)
+	for _, tool := range request.Tools {
+		if tool == nil || (tool.Type != "" && tool.Type != "function") || tool.Function.Name == "" {
+			continue
+		}
+		functions[tool.Function.Name] = &tool.Function
+	}
+	for _, function := range request.Functions {
+		if function != nil && function.Name != "" {
+			functions[function.Name] = function
+		}
+	}
+	return functions
+}
+
+// convertRequest 复制请求，去掉工具参数并把工具定义和调用历史转换为文本
+func convertRequest(request *types.ChatCompletionRequest, tools map[string]*types.ChatCompletionFunction) *types.ChatCompletionRequest {
+	converted := *request
+	converted.Tools = nil
+	converted.ToolChoice = nil
+	converted.ParallelToolCalls = false
+	converted.Functions = nil
+	converted.FunctionCall = nil
+
+	messages := convertMessages(request.Messages)
+
+	choice := getToolChoice(request)
+	if len(tools) > 0 && choice != "none" {
+		prompt := buildPrompt(request, tools, choice)
+		if len(messages) > 0 && messages[0].IsSystemRole() {
+			system := messages[0]
+			system.Content = strings.TrimSpace(system.StringContent() + "\n\n" + prompt)
+			messages[0] = system
+		} else {
+			messages = append([]types.ChatCompletionMessage{{
+				Role:    types.ChatMessageRoleSystem,
+				Content: prompt,
+			}}, messages...)
+		}
+	}
+
+	converted.Messages = messages
+	return &converted
+}
+
+// getToolChoice 返回 auto、none、required 或者指定的函数名
+func getToolChoice(request *types.ChatCompletionRequest) string {
+	choice := request.ToolChoice
+	if choice == nil {
+		choice = request.FunctionCall
+	}
+
+	switch value := choice.(type) {
+	case string:
+		if value == "any" {
+			return "required"
+		}
+		return value
+	case map[string]any:
+		if function, ok := value["function"].(map[string]any); ok {
+			if name, ok := function["name"].(string); ok {
+				return name
+			}
+		}
+		if name, ok := value["name"].(string); ok {
+			return name
+		}
+	}
+	return "auto"
+}
+
+func buildPrompt(request *types.ChatCompletionRequest, tools map[string]*types.ChatCompletionFunction, choice string) string {
+	// 按请求中的顺序输出工具
+	names := make([]string, 0, len(tools))
+	for _, tool := range request.Tools {
+		if tool != nil && tools[tool.Function.Name] != nil && !utils.Contains(tool.Function.Name, names) {
+			names = append(names, tool.Function.Name)
+		}
+	}
+	for _, function := range request.Functions {
+		if function != nil && tools[function.Name] != nil && !utils.Contains(function.Name, names) {
+			names = append(names, function.Name)
+		}
+	}
+
+	definitions := make([]string, 0, len(names))
+	for _, name := range names {
+		tool := tools[name]
+		definition, _ := json.Marshal(map[string]any{
+			"name":        tool.Name,
+			"description": tool.Description,
+			"parameters":  tool.Parameters,
+		})
+		definitions = append(definitions, string(definition))
+	}
+
+	var requirement string
+	switch choice {
+	case "auto", "":
+		requirement = "\nIf no tool is needed, answer the user directly without the block."
+	case "required":
+		requirement = "\nYou must call at least one tool."
+	default:
+		requirement = fmt.Sprintf("\nYou must call the tool %q.", choice)
+	}
+
+	return fmt.Sprintf(promptTemplate, strings.Join(definitions, "\n"), requirement)
+}
+
+// convertMessages 把助手的工具调用和工具的返回结果转换为普通消息
+func convertMessages(messages []types.ChatCompletionMessage) []types.ChatCompletionMessage {
+	callNames := make(map[string]string)
+	converted := make([]types.ChatCompletionMessage, 0, len(messages))
+
+	for _, message := range messages {
+		switch {
+		case len(message.ToolCalls) > 0 || message.FunctionCall != nil:
+			calls := make([]map[string]any, 0, len(message.ToolCalls)+1)
+			for _, call := range message.ToolCalls {
+				if call == nil || call.Function == nil {
+					continue
+				}
+				callNames[call.Id] = call.Function.Name
+				calls = append(calls, map[string]any{"name": call.Function.Name, "arguments": rawArguments(call.Function.Arguments)})
+			}
+			if message.FunctionCall != nil {
+				calls = append(calls, map[string]any{"name": message.FunctionCall.Name, "arguments": rawArguments(message.FunctionCall.Arguments)})
+			}
+
+			block, _ := json.Marshal(calls)
+			content := strings.TrimSpace(message.StringContent())
+			if content != "" {
+				content += "\n"
+			}
+
+			converted = append(converted, types.ChatCompletionMessage{
+				Role:    types.ChatMessageRoleAssistant,
+				Content: content + openTag + "\n" + string(block) + "\n" + closeTag,
+			})
+
+		case message.Role == types.ChatMessageRoleTool || message.Role == types.ChatMessageRoleFunction:
+			name := callNames[message.ToolCallID]
+			if name == "" && message.Name != nil {
+				name = *message.Name
+			}
+			result := fmt.Sprintf("<tool_result name=%q>\n%s\n</tool_result>", name, message.StringContent())
+
+			// 连续的工具结果合并为一条用户消息
+			if last := len(converted) - 1; last >= 0 && converted[last].Role == types.ChatMessageRoleUser {
+				if content, ok := converted[last].Content.(string); ok && strings.HasPrefix(content, "<tool_result") {
+					converted[last].Content = content + "\n" + result
+					continue
+				}
+			}
+
+			converted = append(converted, types.ChatCompletionMessage{
+				Role:    types.ChatMessageRoleUser,
+				Content: result,
+			})
+
+		default:
+			converted = append(converted, message)
+		}
+	}
+
+	return converted
+}
+
+// rawArguments 参数是合法的 JSON 时按 JSON 输出，避免在提示词中出现转义后的字符串
+func rawArguments(arguments string) any {
+	if json.Valid([]byte(arguments)) {
+		return json.RawMessage(arguments)
+	}
+	return arguments
+}
+
+type emulatedCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// parseToolCalls 解析输出中的工具调用块，返回块之前的文本；调用了未声明的工具时视为普通文本
+func parseToolCalls(content string, tools map[string]*types.ChatCompletionFunction) (string, []*types.ChatCompletionToolCalls, bool) {
+	start := strings.Index(content, openTag)
+	if start < 0 {
+		return content, nil, false
+	}
+
+	block := content[start+len(openTag):]
+	if end := strings.Index(block, closeTag); end >= 0 {
+		block = block[:end]
+	}
+
+	calls, ok := parseBlock(block, tools)
+	if !ok {
+		return content, nil, false
+	}
+
+	return strings.TrimSpace(content[:start]), calls, true
+}
+
+func parseBlock(block string, tools map[string]*types.ChatCompletionFunction) ([]*types.ChatCompletionToolCalls, bool) {
+	block = strings.TrimSpace(block)
+	// 有些模型会加上 markdown 代码块
+	block = strings.TrimPrefix(block, "```json")
+	block = strings.TrimPrefix(block, "```")
+	block = strings.TrimSuffix(block, "```")
+	block = strings.TrimSpace(block)
+
+	var items []emulatedCall
+	if strings.HasPrefix(block, "{") {
+		var item emulatedCall
+		if err := json.Unmarshal([]byte(block), &item); err != nil {
+			return nil, false
+		}
+		items = append(items, item)
+	} else if err := json.Unmarshal([]byte(block), &items); err != nil {
+		return nil, false
+	}
+
+	if len(items) == 0 {
+		return nil, false
+	}
+
+	calls := make([]*types.ChatCompletionToolCalls, 0, len(items))
+	for i, item := range items {
+		if _, ok := tools[item.Name]; !ok {
+			return nil, false
+		}
+
+		calls = append(calls, &types.ChatCompletionToolCalls{
+			Id:    "call_" + utils.GetRandomString(24),
+			Type:  "function",
+			Index: i,
+			Function: &types.ChatCompletionToolCallsFunction{
+				Name:      item.Name,
+				Arguments: normalizeArguments(item.Arguments),
+			},
+		})
+	}
+
+	return calls, true
+}
+
+// normalizeArguments 参数可能是对象，也可能是 JSON 字符串
+func normalizeArguments(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return "{}"
+	}
+
+	var text string
+	if raw[0] == '"' && json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+
+	var compact bytes.Buffer
+	if json.Compact(&compact, raw) == nil {
+		return compact.String()
+	}
+	return string(raw)
+}
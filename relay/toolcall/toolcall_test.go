@@ -0,0 +1,100 @@
+package toolcall
+
+import (
+	"done-hub/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testTools = map[string]*types.ChatCompletionFunction{
+	"get_weather": {Name: "get_weather"},
+	"get_time":    {Name: "get_time"},
+}
+
+func TestParseBlock(t *testing.T) {
+	tests := []struct {
+		name      string
+		block     string
+		names     []string
+		arguments []string
+	}{
+		{"array", `[{"name":"get_weather","arguments":{"city":"Paris"}}]`, []string{"get_weather"}, []string{`{"city":"Paris"}`}},
+		{"single object", `{"name":"get_weather","arguments":{"city":"Paris"}}`, []string{"get_weather"}, []string{`{"city":"Paris"}`}},
+		{"multiple", `[{"name":"get_weather","arguments":{}},{"name":"get_time"}]`, []string{"get_weather", "get_time"}, []string{`{}`, `{}`}},
+		{"fenced json", "\n```json\n[{\"name\":\"get_weather\",\"arguments\":{\"city\": \"Paris\"}}]\n```\n", []string{"get_weather"}, []string{`{"city":"Paris"}`}},
+		{"fenced", "```\n{\"name\":\"get_time\"}\n```", []string{"get_time"}, []string{`{}`}},
+		{"string arguments", `[{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}]`, []string{"get_weather"}, []string{`{"city":"Paris"}`}},
+		{"unknown tool", `[{"name":"get_weather"},{"name":"delete_files"}]`, nil, nil},
+		{"empty array", `[]`, nil, nil},
+		{"invalid json", `[{"name":"get_weather"`, nil, nil},
+		{"text", `I will call get_weather`, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, ok := parseBlock(tt.block, testTools)
+			if tt.names == nil {
+				assert.False(t, ok)
+				assert.Nil(t, calls)
+				return
+			}
+
+			assert.True(t, ok)
+			assert.Len(t, calls, len(tt.names))
+			for i, call := range calls {
+				assert.Equal(t, i, call.Index)
+				assert.Equal(t, "function", call.Type)
+				assert.NotEmpty(t, call.Id)
+				assert.Equal(t, tt.names[i], call.Function.Name)
+				assert.Equal(t, tt.arguments[i], call.Function.Arguments)
+			}
+		})
+	}
+}
+
+func TestParseToolCalls(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		text    string
+		calls   int
+		ok      bool
+	}{
+		{"with text", "Let me check.\n<tool_calls>\n[{\"name\":\"get_weather\",\"arguments\":{}}]\n</tool_calls>", "Let me check.", 1, true},
+		{"only block", `<tool_calls>[{"name":"get_time"}]</tool_calls>`, "", 1, true},
+		{"unclosed", `<tool_calls>[{"name":"get_time"}]`, "", 1, true},
+		{"text after block", `<tool_calls>[{"name":"get_time"}]</tool_calls> done`, "", 1, true},
+		{"no block", "hello", "hello", 0, false},
+		{"unknown tool", `ok <tool_calls>[{"name":"rm"}]</tool_calls>`, `ok <tool_calls>[{"name":"rm"}]</tool_calls>`, 0, false},
+		{"invalid block", `<tool_calls>not json</tool_calls>`, `<tool_calls>not json</tool_calls>`, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, calls, ok := parseToolCalls(tt.content, testTools)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.text, text)
+			assert.Len(t, calls, tt.calls)
+		})
+	}
+}
+
+func TestNormalizeArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"empty", ``, `{}`},
+		{"null", `null`, `{}`},
+		{"object", `{ "a": 1 }`, `{"a":1}`},
+		{"string", `"{\"a\":1}"`, `{"a":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeArguments([]byte(tt.raw)))
+		})
+	}
+}
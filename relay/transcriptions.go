@@ -2,9 +2,18 @@ package relay
 
 import (
 	"done-hub/common"
+	"done-hub/common/requester"
+	"done-hub/common/utils"
+	"done-hub/model"
 	providersBase "done-hub/providers/base"
 	"done-hub/types"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -30,6 +39,10 @@ func (r *relayTranscriptions) setRequest() error {
 	return nil
 }
 
+func (r *relayTranscriptions) IsStream() bool {
+	return r.request.Stream
+}
+
 func (r *relayTranscriptions) getPromptTokens() (int, error) {
 	return 0, nil
 }
@@ -48,7 +61,14 @@ func (r *relayTranscriptions) send() (err *types.OpenAIErrorWithStatusCode, done
 	if err != nil {
 		return
 	}
-	err = responseCustom(r.c, response)
+
+	r.setSecondsUsage(response)
+
+	if r.IsStream() {
+		err = r.responseStream(response)
+	} else {
+		err = responseCustom(r.c, response)
+	}
 
 	if err != nil {
 		done = true
@@ -56,3 +76,81 @@ func (r *relayTranscriptions) send() (err *types.OpenAIErrorWithStatusCode, done
 
 	return
 }
+
+// 按秒计费的模型，使用音频时长作为计费数量
+func (r *relayTranscriptions) setSecondsUsage(response *types.AudioResponseWrapper) {
+	price := model.PricingInstance.GetPrice(r.getModelName())
+	if price.Type != model.SecondsPriceType {
+		return
+	}
+
+	duration := response.Duration
+	if duration <= 0 {
+		duration = getAudioFileDuration(r.request.File)
+	}
+
+	usage := r.provider.GetUsage()
+	usage.PromptTokens = max(int(math.Ceil(duration)), 1)
+	usage.CompletionTokens = 0
+	usage.TotalTokens = usage.PromptTokens
+}
+
+func (r *relayTranscriptions) responseStream(response *types.AudioResponseWrapper) *types.OpenAIErrorWithStatusCode {
+	requester.SetEventStreamHeaders(r.c)
+	r.c.Writer.WriteHeader(http.StatusOK)
+
+	text := response.Text
+	if text == "" {
+		text = strings.TrimSpace(string(response.Body))
+	}
+
+	// 上游不支持流式时，按词切分模拟增量输出
+	words := strings.SplitAfter(text, " ")
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		r.writeStreamData(&types.TranscriptStreamResponse{
+			Type:  types.TranscriptTextDelta,
+			Delta: word,
+		})
+	}
+
+	usage := r.provider.GetUsage()
+	r.writeStreamData(&types.TranscriptStreamResponse{
+		Type: types.TranscriptTextDone,
+		Text: text,
+		Usage: &types.AudioStreamUsage{
+			InputTokens:  usage.PromptTokens,
+			OutputTokens: usage.CompletionTokens,
+			TotalTokens:  usage.PromptTokens + usage.CompletionTokens,
+		},
+	})
+
+	return nil
+}
+
+func (r *relayTranscriptions) writeStreamData(data any) {
+	str, _ := json.Marshal(data)
+	fmt.Fprintf(r.c.Writer, "data: %s\n\n", str)
+	r.c.Writer.Flush()
+}
+
+func getAudioFileDuration(fileHeader *multipart.FileHeader) float64 {
+	if fileHeader == nil {
+		return 0
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return 0
+	}
+
+	return utils.GetAudioDuration(data)
+}
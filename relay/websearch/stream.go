@@ -0,0 +1,78 @@
+package websearch
+
+import (
+	"done-hub/types"
+	"encoding/json"
+	"io"
+)
+
+// responseStream 把完整的响应转换为流式响应
+type responseStream struct {
+	chunks []string
+}
+
+func newResponseStream(response *types.ChatCompletionResponse) *responseStream {
+	stream := &responseStream{}
+	for _, choice := range response.Choices {
+		message := choice.Message
+		deltas := make([]types.ChatCompletionStreamChoiceDelta, 0, 3)
+
+		if message.ReasoningContent != "" {
+			deltas = append(deltas, types.ChatCompletionStreamChoiceDelta{ReasoningContent: message.ReasoningContent})
+		}
+		if content := message.StringContent(); content != "" {
+			deltas = append(deltas, types.ChatCompletionStreamChoiceDelta{Content: content, Annotations: message.Annotations})
+		}
+		if len(message.ToolCalls) > 0 {
+			deltas = append(deltas, types.ChatCompletionStreamChoiceDelta{ToolCalls: message.ToolCalls})
+		}
+		if len(deltas) == 0 {
+			deltas = append(deltas, types.ChatCompletionStreamChoiceDelta{})
+		}
+		deltas[0].Role = types.ChatMessageRoleAssistant
+
+		for _, delta := range deltas {
+			stream.add(response, types.ChatCompletionStreamChoice{Index: choice.Index, Delta: delta})
+		}
+
+		finishReason := choice.FinishReason
+		if finishReason == "" {
+			finishReason = types.FinishReasonStop
+		}
+		stream.add(response, types.ChatCompletionStreamChoice{Index: choice.Index, FinishReason: finishReason})
+	}
+
+	return stream
+}
+
+func (s *responseStream) add(response *types.ChatCompletionResponse, choice types.ChatCompletionStreamChoice) {
+	chunk := types.ChatCompletionStreamResponse{
+		ID:      response.ID,
+		Object:  "chat.completion.chunk",
+		Created: response.Created,
+		Model:   response.Model,
+		Choices: []types.ChatCompletionStreamChoice{choice},
+	}
+
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return
+	}
+	s.chunks = append(s.chunks, string(data))
+}
+
+func (s *responseStream) Recv() (<-chan string, <-chan error) {
+	dataChan := make(chan string)
+	errChan := make(chan error)
+
+	go func() {
+		for _, chunk := range s.chunks {
+			dataChan <- chunk
+		}
+		errChan <- io.EOF
+	}()
+
+	return dataChan, errChan
+}
+
+func (s *responseStream) Close() {}
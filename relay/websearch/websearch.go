@@ -0,0 +1,325 @@
+package websearch
+
+import (
+	"done-hub/common/logger"
+	"done-hub/common/requester"
+	"done-hub/common/search"
+	"done-hub/common/search/search_type"
+	providersBase "done-hub/providers/base"
+	"done-hub/types"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 联网搜索作为服务端工具提供给模型，由模型决定是否搜索，搜索结果作为工具结果返回给模型
+
+const (
+	toolName = "web_search"
+	// 最多搜索的轮数，达到后不再提供搜索工具
+	maxSteps = 3
+)
+
+const toolDescription = "Search the web for up-to-date information. The results are numbered, cite them in your answer with their number in square brackets, e.g. [1]."
+
+var citationRegexp = regexp.MustCompile(`\[(\d+)\]`)
+
+// searchProvider 在渠道外层执行搜索工具的调用
+type searchProvider struct {
+	providersBase.ChatInterface
+}
+
+// Wrap 开启了搜索服务时返回包装后的 provider，是否搜索由每个请求决定
+func Wrap(provider providersBase.ChatInterface) providersBase.ChatInterface {
+	if !search.IsEnable() {
+		return provider
+	}
+
+	if _, ok := provider.(*searchProvider); ok {
+		return provider
+	}
+	return &searchProvider{ChatInterface: provider}
+}
+
+// IsSearchTool 聊天和 responses 接口中的联网搜索工具
+func IsSearchTool(toolType string) bool {
+	return toolType == "web_search" || toolType == "web_search_preview"
+}
+
+func (p *searchProvider) CreateChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	if !needSearch(request) {
+		return p.ChatInterface.CreateChatCompletion(request)
+	}
+
+	return p.run(request)
+}
+
+func (p *searchProvider) CreateChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	if !needSearch(request) {
+		return p.ChatInterface.CreateChatCompletionStream(request)
+	}
+
+	// 搜索过程中无法流式输出，完成后把最终的响应按流式格式返回
+	response, errWithCode := p.run(request)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	return newResponseStream(response), nil
+}
+
+// needSearch 请求了联网搜索，并且模型本身不支持搜索
+func needSearch(request *types.ChatCompletionRequest) bool {
+	// 原生支持搜索的模型和旧版的 functions 请求不处理
+	if strings.Contains(request.Model, "search-preview") || len(request.Functions) > 0 {
+		return false
+	}
+
+	if request.WebSearchOptions != nil {
+		return true
+	}
+
+	for _, tool := range request.Tools {
+		if tool != nil && IsSearchTool(tool.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// convertRequest 复制请求，把搜索参数替换为搜索函数
+func convertRequest(request *types.ChatCompletionRequest) *types.ChatCompletionRequest {
+	converted := *request
+	converted.Stream = false
+	converted.StreamOptions = nil
+	converted.WebSearchOptions = nil
+
+	converted.Tools = clientTools(request)
+	converted.Tools = append(converted.Tools, &types.ChatCompletionTool{
+		Type: "function",
+		Function: types.ChatCompletionFunction{
+			Name:        toolName,
+			Description: toolDescription,
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"query": map[string]any{
+						"type":        "string",
+						"description": "The search keywords, in the same language as the user",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+	})
+
+	converted.Messages = append([]types.ChatCompletionMessage{}, request.Messages...)
+	return &converted
+}
+
+// clientTools 客户端自己声明的工具
+func clientTools(request *types.ChatCompletionRequest) []*types.ChatCompletionTool {
+	tools := make([]*types.ChatCompletionTool, 0, len(request.Tools))
+	for _, tool := range request.Tools {
+		if tool == nil || IsSearchTool(tool.Type) {
+			continue
+		}
+		tools = append(tools, tool)
+	}
+	return tools
+}
+
+// run 循环执行搜索，直到模型不再调用搜索工具或者达到最大轮数
+func (p *searchProvider) run(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	converted := convertRequest(request)
+	spent := types.Usage{}
+	var sources []search_type.SearchResult
+	searches := 0
+
+	for step := 0; ; step++ {
+		// 最后一轮不再提供搜索工具，要求模型直接回答
+		if step >= maxSteps {
+			converted.Tools = clientTools(request)
+			if len(converted.Tools) == 0 {
+				converted.Tools = nil
+				converted.ToolChoice = nil
+			}
+		}
+
+		response, errWithCode := p.ChatInterface.CreateChatCompletion(converted)
+		if errWithCode != nil {
+			return nil, errWithCode
+		}
+
+		calls, onlySearch := getSearchCalls(response)
+		if len(calls) == 0 || !onlySearch || step >= maxSteps {
+			removeSearchCalls(response)
+			addCitations(response, sources)
+			p.addUsage(&spent, searches)
+			return response, nil
+		}
+
+		if usage := p.GetUsage(); usage != nil {
+			spent.PromptTokens += usage.PromptTokens
+			spent.CompletionTokens += usage.CompletionTokens
+			spent.TotalTokens += usage.TotalTokens
+		}
+
+		message := response.Choices[0].Message
+		converted.Messages = append(converted.Messages, types.ChatCompletionMessage{
+			Role:      types.ChatMessageRoleAssistant,
+			Content:   message.Content,
+			ToolCalls: calls,
+		})
+
+		for _, call := range calls {
+			content, results := query(call.Function.Arguments, len(sources))
+			if results != nil {
+				sources = append(sources, results...)
+				searches++
+			}
+
+			converted.Messages = append(converted.Messages, types.ChatCompletionMessage{
+				Role:       types.ChatMessageRoleTool,
+				ToolCallID: call.Id,
+				Content:    content,
+			})
+		}
+	}
+}
+
+// addUsage 累加之前每轮的用量，并按搜索次数计费
+func (p *searchProvider) addUsage(spent *types.Usage, searches int) {
+	usage := p.GetUsage()
+	if usage == nil {
+		return
+	}
+
+	usage.PromptTokens += spent.PromptTokens
+	usage.CompletionTokens += spent.CompletionTokens
+	usage.TotalTokens += spent.TotalTokens
+
+	for i := 0; i < searches; i++ {
+		usage.IncExtraBilling(types.APITollTypeWebSearch, "")
+	}
+}
+
+// getSearchCalls 返回第一个 choice 中的搜索调用，以及是否只调用了搜索工具
+func getSearchCalls(response *types.ChatCompletionResponse) ([]*types.ChatCompletionToolCalls, bool) {
+	if len(response.Choices) == 0 {
+		return nil, false
+	}
+
+	var calls []*types.ChatCompletionToolCalls
+	onlySearch := true
+	for _, call := range response.Choices[0].Message.ToolCalls {
+		if call == nil || call.Function == nil {
+			continue
+		}
+		if call.Function.Name != toolName {
+			onlySearch = false
+			continue
+		}
+		calls = append(calls, call)
+	}
+	return calls, onlySearch
+}
+
+// removeSearchCalls 同时调用了客户端的工具时，只把客户端的工具调用返回
+func removeSearchCalls(response *types.ChatCompletionResponse) {
+	for i := range response.Choices {
+		message := &response.Choices[i].Message
+		if len(message.ToolCalls) == 0 {
+			continue
+		}
+
+		calls := make([]*types.ChatCompletionToolCalls, 0, len(message.ToolCalls))
+		for _, call := range message.ToolCalls {
+			if call == nil || (call.Function != nil && call.Function.Name == toolName) {
+				continue
+			}
+			call.Index = len(calls)
+			calls = append(calls, call)
+		}
+
+		if len(calls) == 0 {
+			message.ToolCalls = nil
+			if response.Choices[i].FinishReason == types.FinishReasonToolCalls {
+				response.Choices[i].FinishReason = types.FinishReasonStop
+			}
+			continue
+		}
+		message.ToolCalls = calls
+	}
+}
+
+// query 执行一次搜索，结果从 offset+1 开始编号，搜索失败时返回错误信息给模型
+func query(arguments string, offset int) (string, []search_type.SearchResult) {
+	var args struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil || strings.TrimSpace(args.Query) == "" {
+		return "Invalid arguments, the query is required.", nil
+	}
+
+	responses, err := search.Query(args.Query)
+	if err != nil {
+		logger.SysError(fmt.Sprintf("web search query failed: %s", err.Error()))
+		return "Search failed: " + err.Error(), nil
+	}
+
+	results := responses.Results
+	if len(results) > search_type.MaxResults {
+		results = results[:search_type.MaxResults]
+	}
+	if len(results) == 0 {
+		return "No results found.", []search_type.SearchResult{}
+	}
+
+	var builder strings.Builder
+	for i, result := range results {
+		fmt.Fprintf(&builder, "[%d] %s\nUrl: %s\n%s\n\n", offset+i+1, result.Title, result.Url, result.Content)
+	}
+	return strings.TrimSpace(builder.String()), results
+}
+
+// addCitations 根据回答中的 [N] 标记生成 url_citation 标注，位置按字符计算
+func addCitations(response *types.ChatCompletionResponse, sources []search_type.SearchResult) {
+	if len(sources) == 0 {
+		return
+	}
+
+	for i := range response.Choices {
+		message := &response.Choices[i].Message
+		content, ok := message.Content.(string)
+		if !ok || content == "" {
+			continue
+		}
+
+		var annotations []types.ChatAnnotation
+		for _, match := range citationRegexp.FindAllStringSubmatchIndex(content, -1) {
+			number, err := strconv.Atoi(content[match[2]:match[3]])
+			if err != nil || number < 1 || number > len(sources) {
+				continue
+			}
+
+			source := sources[number-1]
+			annotations = append(annotations, types.ChatAnnotation{
+				Type: "url_citation",
+				UrlCitation: &types.ChatUrlCitation{
+					StartIndex: utf8.RuneCountInString(content[:match[0]]),
+					EndIndex:   utf8.RuneCountInString(content[:match[1]]),
+					Url:        source.Url,
+					Title:      source.Title,
+				},
+			})
+		}
+
+		if len(annotations) > 0 {
+			message.Annotations = annotations
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package websearch
+
+import (
+	"done-hub/common/requester"
+	"done-hub/common/search"
+	"done-hub/common/search/channel"
+	"done-hub/providers/base"
+	"done-hub/types"
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	search.AddSearchers(channel.NewLocal())
+}
+
+// fakeChatProvider 依次返回预设的响应，和真实渠道一样每次请求都会覆盖用量
+type fakeChatProvider struct {
+	base.BaseProvider
+	responses []*types.ChatCompletionResponse
+	requests  []*types.ChatCompletionRequest
+}
+
+func (p *fakeChatProvider) GetRequestHeaders() map[string]string {
+	return map[string]string{}
+}
+
+func (p *fakeChatProvider) CreateChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	// 记录发送时的消息，之后的追加不影响记录
+	sent := *request
+	sent.Messages = append([]types.ChatCompletionMessage{}, request.Messages...)
+	p.requests = append(p.requests, &sent)
+
+	*p.Usage = types.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+	return p.responses[min(len(p.requests), len(p.responses))-1], nil
+}
+
+func (p *fakeChatProvider) CreateChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	return nil, nil
+}
+
+func newSearchProvider(responses ...*types.ChatCompletionResponse) (*searchProvider, *fakeChatProvider) {
+	provider := &fakeChatProvider{responses: responses}
+	provider.Usage = &types.Usage{}
+	return &searchProvider{ChatInterface: provider}, provider
+}
+
+func getSearchRequest() *types.ChatCompletionRequest {
+	return &types.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []types.ChatCompletionMessage{
+			{Role: types.ChatMessageRoleUser, Content: "What is new in Go?"},
+		},
+		Tools: []*types.ChatCompletionTool{{Type: "web_search"}},
+	}
+}
+
+func getCallResponse(names ...string) *types.ChatCompletionResponse {
+	calls := make([]*types.ChatCompletionToolCalls, 0, len(names))
+	for i, name := range names {
+		arguments, _ := json.Marshal(map[string]string{"query": name})
+		callName := toolName
+		if strings.HasPrefix(name, "client:") {
+			callName = strings.TrimPrefix(name, "client:")
+		}
+		calls = append(calls, &types.ChatCompletionToolCalls{
+			Id:       "call_" + name,
+			Type:     "function",
+			Index:    i,
+			Function: &types.ChatCompletionToolCallsFunction{Name: callName, Arguments: string(arguments)},
+		})
+	}
+
+	return &types.ChatCompletionResponse{
+		ID: "chatcmpl-1",
+		Choices: []types.ChatCompletionChoice{{
+			Message:      types.ChatCompletionMessage{Role: types.ChatMessageRoleAssistant, ToolCalls: calls},
+			FinishReason: types.FinishReasonToolCalls,
+		}},
+	}
+}
+
+func getAnswerResponse(content string) *types.ChatCompletionResponse {
+	return &types.ChatCompletionResponse{
+		ID: "chatcmpl-1",
+		Choices: []types.ChatCompletionChoice{{
+			Message:      types.ChatCompletionMessage{Role: types.ChatMessageRoleAssistant, Content: content},
+			FinishReason: types.FinishReasonStop,
+		}},
+	}
+}
+
+func getToolResults(request *types.ChatCompletionRequest) []string {
+	var results []string
+	for _, message := range request.Messages {
+		if message.Role == types.ChatMessageRoleTool {
+			results = append(results, message.StringContent())
+		}
+	}
+	return results
+}
+
+func TestRun(t *testing.T) {
+	answer := "Go 1.22 发布了 [1]，详见 [4] 和 [9]。[10] [0]"
+	p, provider := newSearchProvider(
+		getCallResponse("go"),
+		getCallResponse("go 1.22", "go release"),
+		getAnswerResponse(answer),
+	)
+
+	request := getSearchRequest()
+	response, errWithCode := p.run(request)
+	assert.Nil(t, errWithCode)
+	assert.Len(t, provider.requests, 3)
+	assert.Len(t, request.Messages, 1)
+
+	// 搜索工具转换为函数，每轮追加调用和结果
+	assert.Len(t, provider.requests[0].Tools, 1)
+	assert.Equal(t, toolName, provider.requests[0].Tools[0].Function.Name)
+	assert.Len(t, provider.requests[1].Messages, 3)
+	assert.Len(t, provider.requests[2].Messages, 6)
+
+	// 每次搜索的结果接着之前的编号
+	results := getToolResults(provider.requests[2])
+	assert.Len(t, results, 3)
+	assert.True(t, strings.HasPrefix(results[0], "[1] go - result 1"))
+	assert.True(t, strings.HasPrefix(results[1], "[4] go 1.22 - result 1"))
+	assert.True(t, strings.HasPrefix(results[2], "[7] go release - result 1"))
+
+	// 用量为所有请求之和，每次搜索单独计费
+	usage := provider.GetUsage()
+	assert.Equal(t, 30, usage.PromptTokens)
+	assert.Equal(t, 15, usage.CompletionTokens)
+	assert.Equal(t, 45, usage.TotalTokens)
+	assert.Equal(t, 3, usage.ExtraBilling[types.APITollTypeWebSearch].CallCount)
+
+	// 标注位置按字符计算，超出范围的编号不生成标注
+	message := response.Choices[0].Message
+	assert.Equal(t, answer, message.StringContent())
+	annotations, ok := message.Annotations.([]types.ChatAnnotation)
+	assert.True(t, ok)
+	assert.Len(t, annotations, 3)
+
+	runes := []rune(answer)
+	expected := []struct {
+		marker string
+		url    string
+	}{
+		{"[1]", "https://example.com/search/1?q=go"},
+		{"[4]", "https://example.com/search/1?q=go+1.22"},
+		{"[9]", "https://example.com/search/3?q=go+release"},
+	}
+	for i, annotation := range annotations {
+		citation := annotation.UrlCitation
+		assert.Equal(t, "url_citation", annotation.Type)
+		assert.Equal(t, expected[i].marker, string(runes[citation.StartIndex:citation.EndIndex]))
+		assert.Equal(t, utf8.RuneCountInString(answer[:strings.Index(answer, expected[i].marker)]), citation.StartIndex)
+		assert.Equal(t, expected[i].url, citation.Url)
+	}
+}
+
+func TestRunMaxSteps(t *testing.T) {
+	p, provider := newSearchProvider(getCallResponse("go"))
+
+	response, errWithCode := p.run(getSearchRequest())
+	assert.Nil(t, errWithCode)
+	assert.Len(t, provider.requests, maxSteps+1)
+
+	// 最后一轮不再提供搜索工具，模型仍然调用时去掉搜索调用
+	assert.Nil(t, provider.requests[maxSteps].Tools)
+	assert.Empty(t, response.Choices[0].Message.ToolCalls)
+	assert.Equal(t, types.FinishReasonStop, response.Choices[0].FinishReason)
+
+	usage := provider.GetUsage()
+	assert.Equal(t, 10*(maxSteps+1), usage.PromptTokens)
+	assert.Equal(t, maxSteps, usage.ExtraBilling[types.APITollTypeWebSearch].CallCount)
+}
+
+func TestRunWithClientTool(t *testing.T) {
+	p, provider := newSearchProvider(getCallResponse("go", "client:get_weather"))
+
+	request := getSearchRequest()
+	request.Tools = append(request.Tools, &types.ChatCompletionTool{
+		Type:     "function",
+		Function: types.ChatCompletionFunction{Name: "get_weather"},
+	})
+
+	response, errWithCode := p.run(request)
+	assert.Nil(t, errWithCode)
+	assert.Len(t, provider.requests, 1)
+	assert.Len(t, provider.requests[0].Tools, 2)
+
+	// 同时调用了客户端的工具时直接返回，只保留客户端的调用
+	calls := response.Choices[0].Message.ToolCalls
+	assert.Len(t, calls, 1)
+	assert.Equal(t, "get_weather", calls[0].Function.Name)
+	assert.Equal(t, 0, calls[0].Index)
+
+	usage := provider.GetUsage()
+	assert.Equal(t, 10, usage.PromptTokens)
+	assert.Empty(t, usage.ExtraBilling)
+}
+
+func TestRunInvalidArguments(t *testing.T) {
+	invalid := getCallResponse("go")
+	invalid.Choices[0].Message.ToolCalls[0].Function.Arguments = `{"query":""}`
+	p, provider := newSearchProvider(invalid, getAnswerResponse("I don't know [1]."))
+
+	response, errWithCode := p.run(getSearchRequest())
+	assert.Nil(t, errWithCode)
+	assert.Equal(t, []string{"Invalid arguments, the query is required."}, getToolResults(provider.requests[1]))
+	assert.Empty(t, response.Choices[0].Message.Annotations)
+	assert.Empty(t, provider.GetUsage().ExtraBilling)
+}
@@ -28,6 +28,7 @@ func SetApiRouter(router *gin.Engine) {
 
 	apiRouter.POST("/telegram/:token", middleware.Telegram(), controller.TelegramBotWebHook)
 	apiRouter.Use(middleware.GlobalAPIRateLimit())
+	apiRouter.Use(middleware.AuditLog())
 	{
 		apiRouter.GET("/image/:id", controller.CheckImg)
 		apiRouter.GET("/status", controller.GetStatus)
@@ -58,6 +59,9 @@ func SetApiRouter(router *gin.Engine) {
 			userRoute.POST("/register", middleware.CriticalRateLimit(), middleware.TurnstileCheck(), controller.Register)
 			userRoute.POST("/login", middleware.CriticalRateLimit(), middleware.SessionSecurity(), controller.Login)
 			userRoute.GET("/logout", middleware.SessionSecurity(), controller.Logout)
+			userRoute.POST("/login/2fa", middleware.CriticalRateLimit(), middleware.SessionSecurity(), controller.LoginTwoFactor)
+			userRoute.POST("/login/webauthn/begin", middleware.CriticalRateLimit(), middleware.SessionSecurity(), controller.LoginWebAuthnBegin)
+			userRoute.POST("/login/webauthn/finish", middleware.CriticalRateLimit(), middleware.SessionSecurity(), controller.LoginWebAuthnFinish)
 
 			selfRoute := userRoute.Group("/")
 			selfRoute.Use(middleware.UserAuth())
@@ -78,6 +82,23 @@ func SetApiRouter(router *gin.Engine) {
 				selfRoute.GET("/payment", controller.GetUserPaymentList)
 				selfRoute.POST("/order", controller.CreateOrder)
 				selfRoute.GET("/order/status", controller.CheckOrderStatus)
+				selfRoute.GET("/2fa", controller.GetSelfTwoFactor)
+				selfRoute.POST("/2fa/totp/setup", controller.SetupTotp)
+				selfRoute.POST("/2fa/totp/enable", middleware.CriticalRateLimit(), controller.EnableTotp)
+				selfRoute.POST("/2fa/totp/disable", middleware.CriticalRateLimit(), controller.DisableTotp)
+				selfRoute.POST("/2fa/recovery_codes", middleware.CriticalRateLimit(), controller.RegenerateRecoveryCodes)
+				selfRoute.POST("/2fa/passkey/begin", middleware.CriticalRateLimit(), controller.PasskeyRegisterBegin)
+				selfRoute.POST("/2fa/passkey/finish", controller.PasskeyRegisterFinish)
+				selfRoute.POST("/2fa/passkey/verify/begin", middleware.CriticalRateLimit(), controller.PasskeyVerifyBegin)
+				selfRoute.POST("/2fa/passkey/verify/finish", middleware.CriticalRateLimit(), controller.PasskeyVerifyFinish)
+				selfRoute.DELETE("/2fa/passkey/:id", middleware.CriticalRateLimit(), controller.DeletePasskey)
+				selfRoute.GET("/alert", controller.GetSelfAlertSetting)
+				selfRoute.PUT("/alert", controller.UpdateSelfAlertSetting)
+				selfRoute.GET("/subscription/plans", controller.GetEnabledSubscriptionPlans)
+				selfRoute.GET("/subscription", controller.GetSelfSubscription)
+				selfRoute.POST("/subscription", controller.CreateSubscriptionOrder)
+				selfRoute.POST("/subscription/cancel", controller.CancelSelfSubscription)
+				selfRoute.GET("/price_override", controller.GetSelfPriceOverrides)
 			}
 
 			adminRoute := userRoute.Group("/")
@@ -90,6 +111,7 @@ func SetApiRouter(router *gin.Engine) {
 				adminRoute.POST("/quota/:id", controller.ChangeUserQuota)
 				adminRoute.PUT("/", controller.UpdateUser)
 				adminRoute.DELETE("/:id", controller.DeleteUser)
+				adminRoute.DELETE("/:id/2fa", middleware.RootAuth(), controller.ResetUserTwoFactor)
 			}
 		}
 		optionRoute := apiRouter.Group("/option")
@@ -104,11 +126,40 @@ func SetApiRouter(router *gin.Engine) {
 			optionRoute.GET("/telegram/:id", controller.GetTelegramMenu)
 			optionRoute.DELETE("/telegram/:id", controller.DeleteTelegramMenu)
 			optionRoute.GET("/safe_tools", controller.GetSafeTools)
+			optionRoute.GET("/config/export", controller.ExportConfig)
+			optionRoute.POST("/config/apply", controller.ApplyConfig)
 			optionRoute.POST("/invoice/gen/:time", controller.GenInvoice)
 			optionRoute.POST("/invoice/update/:time", controller.UpdateInvoice)
 			optionRoute.POST("/system_info/log", controller.SystemLog)
 		}
 
+		webhookRoute := apiRouter.Group("/webhook")
+		webhookRoute.Use(middleware.AdminAuth())
+		{
+			webhookRoute.GET("/events", controller.GetWebhookEvents)
+			webhookRoute.GET("/", controller.GetWebhookSubscriptions)
+			webhookRoute.POST("/", controller.AddWebhookSubscription)
+			webhookRoute.PUT("/", controller.UpdateWebhookSubscription)
+			webhookRoute.DELETE("/:id", controller.DeleteWebhookSubscription)
+			webhookRoute.POST("/:id/test", controller.TestWebhookSubscription)
+			webhookRoute.GET("/delivery", controller.GetWebhookDeliveries)
+			webhookRoute.GET("/delivery/:id", controller.GetWebhookDelivery)
+			webhookRoute.POST("/delivery/:id/replay", controller.ReplayWebhookDelivery)
+		}
+
+		// 脚本会影响所有请求的路由和计费，只允许 root 管理
+		scriptRoute := apiRouter.Group("/script")
+		scriptRoute.Use(middleware.RootAuth())
+		{
+			scriptRoute.GET("/hooks", controller.GetScriptHooks)
+			scriptRoute.GET("/", controller.GetScripts)
+			scriptRoute.GET("/:id", controller.GetScript)
+			scriptRoute.POST("/", controller.AddScript)
+			scriptRoute.PUT("/", controller.UpdateScript)
+			scriptRoute.DELETE("/:id", controller.DeleteScript)
+			scriptRoute.POST("/test", controller.TestScript)
+		}
+
 		modelOwnedByRoute := apiRouter.Group("/model_ownedby")
 		modelOwnedByRoute.GET("/", controller.GetAllModelOwnedBy)
 		modelOwnedByRoute.Use(middleware.AdminAuth())
@@ -138,6 +189,7 @@ func SetApiRouter(router *gin.Engine) {
 			channelRoute.POST("/provider_models_list", controller.GetModelList)
 			channelRoute.GET("/:id", controller.GetChannel)
 			channelRoute.GET("/test", controller.TestAllChannels)
+			channelRoute.GET("/check_logs", controller.GetChannelCheckLogs)
 			channelRoute.GET("/test/:id", controller.TestChannel)
 			channelRoute.GET("/update_balance", controller.UpdateAllChannelsBalance)
 			channelRoute.GET("/update_balance/:id", controller.UpdateChannelBalance)
@@ -148,9 +200,22 @@ func SetApiRouter(router *gin.Engine) {
 			channelRoute.PUT("/batch/add_user_group", controller.BatchAddUserGroupToChannels)
 			channelRoute.DELETE("/disabled", controller.DeleteDisabledChannel)
 			channelRoute.DELETE("/:id/tag", controller.DeleteChannelTag)
+			channelRoute.DELETE("/:id/circuit_breaker", controller.ResetChannelCircuitBreakers)
 			channelRoute.DELETE("/:id", controller.DeleteChannel)
 			channelRoute.DELETE("/batch", controller.BatchDeleteChannel)
 		}
+		channelBenchmarkRoute := apiRouter.Group("/channel_benchmark")
+		channelBenchmarkRoute.Use(middleware.AdminAuth())
+		{
+			channelBenchmarkRoute.GET("/", controller.GetChannelBenchmarks)
+			channelBenchmarkRoute.GET("/trend", controller.GetChannelBenchmarkTrend)
+			channelBenchmarkRoute.GET("/changes", controller.GetChannelBenchmarkChanges)
+			channelBenchmarkRoute.POST("/run", controller.RunChannelBenchmark)
+			channelBenchmarkRoute.GET("/schedule", controller.GetChannelBenchmarkSchedules)
+			channelBenchmarkRoute.POST("/schedule", controller.AddChannelBenchmarkSchedule)
+			channelBenchmarkRoute.PUT("/schedule", controller.UpdateChannelBenchmarkSchedule)
+			channelBenchmarkRoute.DELETE("/schedule/:id", controller.DeleteChannelBenchmarkSchedule)
+		}
 		channelTagRoute := apiRouter.Group("/channel_tag")
 		channelTagRoute.Use(middleware.AdminAuth())
 		{
@@ -187,6 +252,7 @@ func SetApiRouter(router *gin.Engine) {
 		logRoute := apiRouter.Group("/log")
 		logRoute.GET("/", middleware.AdminAuth(), controller.GetLogsList)
 		logRoute.DELETE("/", middleware.AdminAuth(), controller.DeleteHistoryLogs)
+		logRoute.GET("/audit", middleware.AdminAuth(), controller.GetAuditLogsList)
 		logRoute.GET("/stat", middleware.AdminAuth(), controller.GetLogsStat)
 		logRoute.GET("/self/stat", middleware.UserAuth(), controller.GetLogsSelfStat)
 		// logRoute.GET("/search", middleware.AdminAuth(), controller.SearchAllLogs)
@@ -203,6 +269,7 @@ func SetApiRouter(router *gin.Engine) {
 		{
 			analyticsRoute.GET("/statistics", controller.GetStatisticsDetail)
 			analyticsRoute.GET("/period", controller.GetStatisticsByPeriod)
+			analyticsRoute.GET("/margin", controller.GetMarginStatisticsByPeriod)
 			analyticsRoute.GET("/recharge", controller.GetRechargeStatisticsByTimeRange)
 		}
 
@@ -216,6 +283,10 @@ func SetApiRouter(router *gin.Engine) {
 			pricesRoute.POST("/multiple", controller.BatchSetPrices)
 			pricesRoute.PUT("/multiple/delete", controller.BatchDeletePrices)
 			pricesRoute.POST("/sync", controller.SyncPricing)
+			pricesRoute.GET("/override", controller.GetPriceOverrides)
+			pricesRoute.POST("/override", controller.AddPriceOverride)
+			pricesRoute.PUT("/override", controller.UpdatePriceOverride)
+			pricesRoute.DELETE("/override/:id", controller.DeletePriceOverride)
 			pricesRoute.GET("/updateService", controller.GetUpdatePriceService)
 
 		}
@@ -231,6 +302,17 @@ func SetApiRouter(router *gin.Engine) {
 			paymentRoute.DELETE("/:id", controller.DeletePayment)
 		}
 
+		subscriptionRoute := apiRouter.Group("/subscription")
+		subscriptionRoute.Use(middleware.AdminAuth())
+		{
+			subscriptionRoute.GET("/", controller.GetUserSubscriptions)
+			subscriptionRoute.GET("/plan", controller.GetSubscriptionPlans)
+			subscriptionRoute.GET("/plan/:id", controller.GetSubscriptionPlan)
+			subscriptionRoute.POST("/plan", controller.AddSubscriptionPlan)
+			subscriptionRoute.PUT("/plan", controller.UpdateSubscriptionPlan)
+			subscriptionRoute.DELETE("/plan/:id", controller.DeleteSubscriptionPlan)
+		}
+
 		mjRoute := apiRouter.Group("/mj")
 		mjRoute.GET("/self", middleware.UserAuth(), controller.GetUserMidjourney)
 		mjRoute.GET("/", middleware.AdminAuth(), controller.GetAllMidjourney)
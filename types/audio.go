@@ -8,6 +8,11 @@ type SpeechAudioRequest struct {
 	Voice          string  `json:"voice" binding:"required"`
 	ResponseFormat string  `json:"response_format,omitempty"`
 	Speed          float64 `json:"speed,omitempty"`
+	StreamFormat   string  `json:"stream_format,omitempty"`
+}
+
+func (r *SpeechAudioRequest) IsStream() bool {
+	return r.StreamFormat == "sse"
 }
 
 type AudioRequest struct {
@@ -17,6 +22,7 @@ type AudioRequest struct {
 	Prompt         string                `form:"prompt"`
 	ResponseFormat string                `form:"response_format"`
 	Temperature    float32               `form:"temperature"`
+	Stream         bool                  `form:"stream"`
 }
 
 type AudioResponse struct {
@@ -37,4 +43,33 @@ type AudioWordsList struct {
 type AudioResponseWrapper struct {
 	Headers map[string]string
 	Body    []byte
+	// 识别出的文本及音频时长(秒)，用于流式输出和按秒计费
+	Text     string
+	Duration float64
+}
+
+const (
+	SpeechAudioDelta    = "speech.audio.delta"
+	SpeechAudioDone     = "speech.audio.done"
+	TranscriptTextDelta = "transcript.text.delta"
+	TranscriptTextDone  = "transcript.text.done"
+)
+
+type AudioStreamUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+type SpeechStreamResponse struct {
+	Type  string            `json:"type"`
+	Audio string            `json:"audio,omitempty"`
+	Usage *AudioStreamUsage `json:"usage,omitempty"`
+}
+
+type TranscriptStreamResponse struct {
+	Type  string            `json:"type"`
+	Delta string            `json:"delta,omitempty"`
+	Text  string            `json:"text,omitempty"`
+	Usage *AudioStreamUsage `json:"usage,omitempty"`
 }
@@ -59,6 +59,51 @@ type ChatCompletionMessage struct {
 	Image            []MultimediaData                 `json:"image,omitempty"`
 }
 
+// ChatAnnotation 聊天接口的引用标注
+type ChatAnnotation struct {
+	Type        string           `json:"type"`
+	UrlCitation *ChatUrlCitation `json:"url_citation,omitempty"`
+}
+
+type ChatUrlCitation struct {
+	StartIndex int    `json:"start_index"`
+	EndIndex   int    `json:"end_index"`
+	Url        string `json:"url"`
+	Title      string `json:"title,omitempty"`
+}
+
+// ChatAnnotationsToResponses 把聊天接口的引用标注转换为 responses 接口的格式
+func ChatAnnotationsToResponses(annotations any) []Annotations {
+	if annotations == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(annotations)
+	if err != nil {
+		return nil
+	}
+
+	var chatAnnotations []ChatAnnotation
+	if err := json.Unmarshal(data, &chatAnnotations); err != nil {
+		return nil
+	}
+
+	result := make([]Annotations, 0, len(chatAnnotations))
+	for _, annotation := range chatAnnotations {
+		if annotation.Type != "url_citation" || annotation.UrlCitation == nil {
+			continue
+		}
+		result = append(result, Annotations{
+			Type:       annotation.Type,
+			Url:        annotation.UrlCitation.Url,
+			Title:      annotation.UrlCitation.Title,
+			StartIndex: annotation.UrlCitation.StartIndex,
+			EndIndex:   annotation.UrlCitation.EndIndex,
+		})
+	}
+	return result
+}
+
 func (m ChatCompletionMessage) StringContent() string {
 	content, ok := m.Content.(string)
 	if ok {
@@ -396,6 +441,7 @@ type ChatCompletionStreamChoiceDelta struct {
 	ReasoningContent string                           `json:"reasoning_content,omitempty"`
 	Reasoning        string                           `json:"reasoning,omitempty"`
 	Image            []MultimediaData                 `json:"image,omitempty"`
+	Annotations      any                              `json:"annotations,omitempty"`
 }
 
 func (m *ChatCompletionStreamChoiceDelta) ToolToFuncCalls() {
@@ -445,6 +491,22 @@ func (c *ChatCompletionStreamResponse) GetResponseText() (responseText string) {
 	return
 }
 
+// IsEmpty 没有任何需要返回给客户端的内容
+func (c *ChatCompletionStreamResponse) IsEmpty() bool {
+	if c.Usage != nil {
+		return false
+	}
+
+	for _, choice := range c.Choices {
+		delta := choice.Delta
+		if choice.FinishReason != nil || delta.Content != "" || delta.Role != "" || delta.ReasoningContent != "" ||
+			delta.Reasoning != "" || len(delta.ToolCalls) > 0 || delta.FunctionCall != nil || len(delta.Image) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
 type ChatAudio struct {
 	Voice  string `json:"voice"`
 	Format string `json:"format"`
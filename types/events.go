@@ -11,6 +11,33 @@ const (
 	EventTypeResponseDone   = "response.done"
 	EventTypeSessionCreated = "session.created"
 	EventTypeError          = "error"
+
+	EventTypeSessionUpdate            = "session.update"
+	EventTypeSessionUpdated           = "session.updated"
+	EventTypeInputAudioBufferAppend   = "input_audio_buffer.append"
+	EventTypeInputAudioBufferCommit   = "input_audio_buffer.commit"
+	EventTypeInputAudioBufferClear    = "input_audio_buffer.clear"
+	EventTypeInputAudioBufferCommited = "input_audio_buffer.committed"
+	EventTypeInputAudioBufferCleared  = "input_audio_buffer.cleared"
+	EventTypeSpeechStarted            = "input_audio_buffer.speech_started"
+	EventTypeItemCreate               = "conversation.item.create"
+	EventTypeItemCreated              = "conversation.item.created"
+	EventTypeTranscriptionDelta       = "conversation.item.input_audio_transcription.delta"
+	EventTypeTranscriptionCompleted   = "conversation.item.input_audio_transcription.completed"
+	EventTypeResponseCreate           = "response.create"
+	EventTypeResponseCancel           = "response.cancel"
+	EventTypeResponseCreated          = "response.created"
+	EventTypeOutputItemAdded          = "response.output_item.added"
+	EventTypeOutputItemDone           = "response.output_item.done"
+	EventTypeContentPartAdded         = "response.content_part.added"
+	EventTypeContentPartDone          = "response.content_part.done"
+	EventTypeTextDelta                = "response.text.delta"
+	EventTypeTextDone                 = "response.text.done"
+	EventTypeAudioDelta               = "response.audio.delta"
+	EventTypeAudioDone                = "response.audio.done"
+	EventTypeAudioTranscriptDelta     = "response.audio_transcript.delta"
+	EventTypeAudioTranscriptDone      = "response.audio_transcript.done"
+	EventTypeFunctionArgumentsDone    = "response.function_call_arguments.done"
 )
 
 type Event struct {
@@ -62,10 +89,111 @@ func (e *Event) Error() string {
 }
 
 type ResponseEvent struct {
-	ID     string      `json:"id"`
-	Object string      `json:"object"`
-	Status string      `json:"status"`
-	Usage  *UsageEvent `json:"usage,omitempty"`
+	ID     string         `json:"id"`
+	Object string         `json:"object"`
+	Status string         `json:"status"`
+	Output []RealtimeItem `json:"output,omitempty"`
+	Usage  *UsageEvent    `json:"usage,omitempty"`
+}
+
+// RealtimeSession realtime 会话配置，session.update 和 response.create 共用
+type RealtimeSession struct {
+	Model                   string                       `json:"model,omitempty"`
+	Modalities              []string                     `json:"modalities,omitempty"`
+	Instructions            string                       `json:"instructions,omitempty"`
+	Voice                   string                       `json:"voice,omitempty"`
+	InputAudioFormat        string                       `json:"input_audio_format,omitempty"`
+	OutputAudioFormat       string                       `json:"output_audio_format,omitempty"`
+	InputAudioTranscription *RealtimeTranscriptionConfig `json:"input_audio_transcription,omitempty"`
+	TurnDetection           any                          `json:"turn_detection,omitempty"`
+	Tools                   []RealtimeTool               `json:"tools,omitempty"`
+	ToolChoice              any                          `json:"tool_choice,omitempty"`
+	Temperature             *float64                     `json:"temperature,omitempty"`
+	MaxResponseOutputTokens any                          `json:"max_response_output_tokens,omitempty"`
+}
+
+type RealtimeTranscriptionConfig struct {
+	Model    string `json:"model,omitempty"`
+	Language string `json:"language,omitempty"`
+	Prompt   string `json:"prompt,omitempty"`
+}
+
+type RealtimeTool struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type RealtimeItem struct {
+	ID        string            `json:"id,omitempty"`
+	Object    string            `json:"object,omitempty"`
+	Type      string            `json:"type"`
+	Status    string            `json:"status,omitempty"`
+	Role      string            `json:"role,omitempty"`
+	Content   []RealtimeContent `json:"content,omitempty"`
+	CallId    string            `json:"call_id,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Arguments string            `json:"arguments,omitempty"`
+	Output    string            `json:"output,omitempty"`
+}
+
+type RealtimeContent struct {
+	Type       string `json:"type"`
+	Text       string `json:"text,omitempty"`
+	Audio      string `json:"audio,omitempty"`
+	Transcript string `json:"transcript,omitempty"`
+}
+
+// RealtimeClientEvent 客户端发送的 realtime 事件
+type RealtimeClientEvent struct {
+	EventId  string           `json:"event_id,omitempty"`
+	Type     string           `json:"type"`
+	Session  *RealtimeSession `json:"session,omitempty"`
+	Audio    string           `json:"audio,omitempty"`
+	Item     *RealtimeItem    `json:"item,omitempty"`
+	Response *RealtimeSession `json:"response,omitempty"`
+}
+
+// RealtimeServerEvent 网关转换后发送给客户端的 realtime 事件
+type RealtimeServerEvent struct {
+	EventId      string           `json:"event_id"`
+	Type         string           `json:"type"`
+	Session      *RealtimeSession `json:"session,omitempty"`
+	Response     *ResponseEvent   `json:"response,omitempty"`
+	Item         *RealtimeItem    `json:"item,omitempty"`
+	Part         *RealtimeContent `json:"part,omitempty"`
+	ResponseId   string           `json:"response_id,omitempty"`
+	ItemId       string           `json:"item_id,omitempty"`
+	OutputIndex  *int             `json:"output_index,omitempty"`
+	ContentIndex *int             `json:"content_index,omitempty"`
+	Delta        string           `json:"delta,omitempty"`
+	Text         string           `json:"text,omitempty"`
+	Transcript   string           `json:"transcript,omitempty"`
+	CallId       string           `json:"call_id,omitempty"`
+	Name         string           `json:"name,omitempty"`
+	Arguments    string           `json:"arguments,omitempty"`
+}
+
+func NewRealtimeServerEvent(eventType string) *RealtimeServerEvent {
+	return &RealtimeServerEvent{
+		EventId: fmt.Sprintf("event_%s", utils.GetRandomString(16)),
+		Type:    eventType,
+	}
+}
+
+// SetIndex 设置输出位置，realtime 协议中 delta 类事件需要携带
+func (e *RealtimeServerEvent) SetIndex(responseId, itemId string, outputIndex, contentIndex int) *RealtimeServerEvent {
+	e.ResponseId = responseId
+	e.ItemId = itemId
+	e.OutputIndex = &outputIndex
+	e.ContentIndex = &contentIndex
+	return e
+}
+
+func (e *RealtimeServerEvent) Bytes() []byte {
+	jsonBytes, _ := json.Marshal(e)
+	return jsonBytes
 }
 
 type UsageEvent struct {
@@ -12,6 +12,7 @@ const (
 	APITollTypeFileSearch       = "file_search"
 	APITollTypeCodeInterpreter  = "code_interpreter"
 	APITollTypeImageGeneration  = "image_generation"
+	APITollTypeWebSearch        = "web_search" // 服务端的联网搜索工具
 )
 
 // message / file_search_call / computer_call / web_search_call / computer_call_output / function_call / function_call_output / reasoning / image_generation_call / code_interpreter_call / local_shell_call / local_shell_call_output / mcp_list_tools / mcp_approval_request / mcp_approval_response / mcp_call
@@ -764,8 +765,9 @@ func (cc *ChatCompletionResponse) ToResponses(request *OpenAIResponsesRequest) *
 			chatContent, ok := choice.Message.Content.(string)
 			if ok && chatContent != "" {
 				content = append(content, ContentResponses{
-					Type: ContentTypeOutputText,
-					Text: chatContent,
+					Type:        ContentTypeOutputText,
+					Text:        chatContent,
+					Annotations: ChatAnnotationsToResponses(choice.Message.Annotations),
 				})
 			}
 
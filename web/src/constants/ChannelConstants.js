@@ -222,6 +222,13 @@ export const CHANNEL_OPTIONS = {
     color: 'orange',
     url: 'https://jina.ai/'
   },
+  48: {
+    key: 48,
+    text: 'Rerank(OpenAI兼容)',
+    value: 48,
+    color: 'default',
+    url: ''
+  },
   24: {
     key: 24,
     text: 'Azure Speech',
@@ -271,6 +278,48 @@ export const CHANNEL_OPTIONS = {
     color: 'orange',
     url: 'https://x.ai'
   },
+  57: {
+    key: 57,
+    text: 'ElevenLabs',
+    value: 57,
+    color: 'default',
+    url: 'https://elevenlabs.io'
+  },
+  58: {
+    key: 58,
+    text: 'vLLM',
+    value: 58,
+    color: 'default',
+    url: 'https://docs.vllm.ai'
+  },
+  59: {
+    key: 59,
+    text: 'SGLang',
+    value: 59,
+    color: 'default',
+    url: 'https://docs.sglang.ai'
+  },
+  60: {
+    key: 60,
+    text: 'llama.cpp',
+    value: 60,
+    color: 'default',
+    url: 'https://github.com/ggml-org/llama.cpp'
+  },
+  61: {
+    key: 61,
+    text: 'LM Studio',
+    value: 61,
+    color: 'default',
+    url: 'https://lmstudio.ai'
+  },
+  62: {
+    key: 62,
+    text: 'TGI',
+    value: 62,
+    color: 'default',
+    url: 'https://huggingface.co/docs/text-generation-inference'
+  },
   8: {
     key: 8,
     text: '自定义渠道',
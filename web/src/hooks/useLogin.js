@@ -4,20 +4,31 @@ import { useDispatch } from 'react-redux';
 import { LOGIN, SET_USER_GROUP } from 'store/actions';
 import { useNavigate } from 'react-router';
 import { showSuccess } from 'utils/common';
+import { getPasskeyAssertion } from 'utils/webauthn';
 import { useTranslation } from 'react-i18next';
 
 const useLogin = () => {
   const { t } = useTranslation();
   const dispatch = useDispatch();
   const navigate = useNavigate();
+
+  // 开启了两步验证的用户需要跳转到验证页面
+  const requireTwoFactor = (data) => {
+    if (data?.require_2fa) {
+      navigate('/login/2fa', { state: data });
+      return true;
+    }
+    return false;
+  };
+
   const login = async (username, password) => {
     try {
       const res = await API.post(`/api/user/login`, {
         username,
         password
       });
-      const { success, message } = res.data;
-      if (success) {
+      const { success, message, data } = res.data;
+      if (success && !requireTwoFactor(data)) {
         loadUser();
         loadUserGroup();
         navigate('/panel');
@@ -33,8 +44,8 @@ const useLogin = () => {
     try {
       const affCode = localStorage.getItem('aff');
       const res = await API.get(`/api/oauth/github?code=${code}&state=${state}&aff=${affCode}`);
-      const { success, message } = res.data;
-      if (success) {
+      const { success, message, data } = res.data;
+      if (success && !requireTwoFactor(data)) {
         if (message === 'bind') {
           showSuccess(t('common.bindOk'));
           navigate('/panel');
@@ -56,8 +67,8 @@ const useLogin = () => {
     try {
       const affCode = localStorage.getItem('aff');
       const res = await API.get(`/api/oauth/oidc?code=${code}&state=${state}&aff=${affCode}`);
-      const { success, message } = res.data;
-      if (success) {
+      const { success, message, data } = res.data;
+      if (success && !requireTwoFactor(data)) {
         if (message === 'bind') {
           showSuccess(t('common.bindOk'));
           navigate('/panel');
@@ -79,8 +90,8 @@ const useLogin = () => {
     try {
       const affCode = localStorage.getItem('aff');
       const res = await API.get(`/api/oauth/lark?code=${code}&state=${state}&aff=${affCode}`);
-      const { success, message } = res.data;
-      if (success) {
+      const { success, message, data } = res.data;
+      if (success && !requireTwoFactor(data)) {
         if (message === 'bind') {
           showSuccess(t('common.bindOk'));
           navigate('/panel');
@@ -101,6 +112,23 @@ const useLogin = () => {
     try {
       const affCode = localStorage.getItem('aff');
       const res = await API.get(`/api/oauth/wechat?code=${code}&aff=${affCode}`);
+      const { success, message, data } = res.data;
+      if (success && !requireTwoFactor(data)) {
+        loadUser();
+        loadUserGroup();
+        showSuccess(t('common.loginOk'));
+        navigate('/panel');
+      }
+      return { success, message };
+    } catch (err) {
+      // 请求失败，设置错误信息
+      return { success: false, message: '' };
+    }
+  };
+
+  const twoFactorLogin = async (code) => {
+    try {
+      const res = await API.post(`/api/user/login/2fa`, { code });
       const { success, message } = res.data;
       if (success) {
         loadUser();
@@ -110,11 +138,32 @@ const useLogin = () => {
       }
       return { success, message };
     } catch (err) {
-      // 请求失败，设置错误信息
       return { success: false, message: '' };
     }
   };
 
+  const passkeyLogin = async () => {
+    try {
+      const res = await API.post(`/api/user/login/webauthn/begin`);
+      const { success, message, data } = res.data;
+      if (!success) {
+        return { success, message };
+      }
+
+      const assertion = await getPasskeyAssertion(data);
+      const finishRes = await API.post(`/api/user/login/webauthn/finish`, assertion);
+      if (finishRes.data.success) {
+        loadUser();
+        loadUserGroup();
+        showSuccess(t('common.loginOk'));
+        navigate('/panel');
+      }
+      return { success: finishRes.data.success, message: finishRes.data.message };
+    } catch (err) {
+      return { success: false, message: err?.message || '' };
+    }
+  };
+
   const logout = async () => {
     await API.get('/api/user/logout');
     localStorage.removeItem('user');
@@ -151,7 +200,7 @@ const useLogin = () => {
     return [];
   }, []);
 
-  return { login, logout, githubLogin, wechatLogin, larkLogin, oidcLogin, loadUser, loadUserGroup };
+  return { login, logout, githubLogin, wechatLogin, larkLogin, oidcLogin, twoFactorLogin, passkeyLogin, loadUser, loadUserGroup };
 };
 
 export default useLogin;
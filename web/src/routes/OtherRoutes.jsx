@@ -8,6 +8,7 @@ import MinimalLayout from 'layout/MinimalLayout';
 // login option 3 routing
 const AuthLogin = Loadable(lazy(() => import('views/Authentication/Auth/Login')));
 const AuthRegister = Loadable(lazy(() => import('views/Authentication/Auth/Register')));
+const TwoFactor = Loadable(lazy(() => import('views/Authentication/Auth/TwoFactor')));
 const GitHubOAuth = Loadable(lazy(() => import('views/Authentication/Auth/GitHubOAuth')));
 const LarkOAuth = Loadable(lazy(() => import('views/Authentication/Auth/LarkOAuth')));
 const OIDCOAuth = Loadable(lazy(() => import('views/Authentication/Auth/OIDCOAuth')));
@@ -50,6 +51,10 @@ const OtherRoutes = {
       path: '/login',
       element: <AuthLogin />
     },
+    {
+      path: '/login/2fa',
+      element: <TwoFactor />
+    },
     {
       path: '/register',
       element: <AuthRegister />
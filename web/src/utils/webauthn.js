@@ -0,0 +1,74 @@
+// WebAuthn 接口使用 ArrayBuffer，服务端使用 base64url 编码
+const base64urlToBuffer = (value) => {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+  const padded = base64 + '='.repeat((4 - (base64.length % 4)) % 4);
+  const binary = atob(padded);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes.buffer;
+};
+
+const bufferToBase64url = (buffer) => {
+  const bytes = new Uint8Array(buffer);
+  let binary = '';
+  for (let i = 0; i < bytes.length; i++) {
+    binary += String.fromCharCode(bytes[i]);
+  }
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+};
+
+const convertCredentials = (credentials) =>
+  (credentials || []).map((credential) => ({
+    ...credential,
+    id: base64urlToBuffer(credential.id)
+  }));
+
+export const isWebAuthnSupported = () => typeof window !== 'undefined' && !!window.PublicKeyCredential;
+
+export const createPasskey = async (options) => {
+  const publicKey = options.publicKey;
+  const credential = await navigator.credentials.create({
+    publicKey: {
+      ...publicKey,
+      challenge: base64urlToBuffer(publicKey.challenge),
+      user: { ...publicKey.user, id: base64urlToBuffer(publicKey.user.id) },
+      excludeCredentials: convertCredentials(publicKey.excludeCredentials)
+    }
+  });
+
+  return {
+    id: credential.id,
+    rawId: bufferToBase64url(credential.rawId),
+    type: credential.type,
+    response: {
+      attestationObject: bufferToBase64url(credential.response.attestationObject),
+      clientDataJSON: bufferToBase64url(credential.response.clientDataJSON),
+      transports: credential.response.getTransports ? credential.response.getTransports() : []
+    }
+  };
+};
+
+export const getPasskeyAssertion = async (options) => {
+  const publicKey = options.publicKey;
+  const credential = await navigator.credentials.get({
+    publicKey: {
+      ...publicKey,
+      challenge: base64urlToBuffer(publicKey.challenge),
+      allowCredentials: convertCredentials(publicKey.allowCredentials)
+    }
+  });
+
+  return {
+    id: credential.id,
+    rawId: bufferToBase64url(credential.rawId),
+    type: credential.type,
+    response: {
+      authenticatorData: bufferToBase64url(credential.response.authenticatorData),
+      clientDataJSON: bufferToBase64url(credential.response.clientDataJSON),
+      signature: bufferToBase64url(credential.response.signature),
+      userHandle: credential.response.userHandle ? bufferToBase64url(credential.response.userHandle) : null
+    }
+  };
+};
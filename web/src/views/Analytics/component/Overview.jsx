@@ -20,6 +20,8 @@ export default function Overview() {
   const [orderData, setOrderData] = useState([]);
   const [orderLoading, setOrderLoading] = useState(true);
   const [usersData, setUsersData] = useState([]);
+  const [marginLoading, setMarginLoading] = useState(true);
+  const [marginData, setMarginData] = useState({});
   const [dateRange, setDateRange] = useState({ start: dayjs().subtract(6, 'day').startOf('day'), end: dayjs().endOf('day') });
 
   const [groupType, setGroupType] = useState('model_type');
@@ -33,7 +35,32 @@ export default function Overview() {
     setDateRange(value);
   };
 
+  const fetchMarginData = async (date, gType) => {
+    setMarginLoading(true);
+    try {
+      // 毛利统计不支持按模型类型分组，此时按日期汇总
+      const res = await API.get('/api/analytics/margin', {
+        params: {
+          start_timestamp: date.start.unix(),
+          end_timestamp: date.end.unix(),
+          group_type: gType === 'model_type' ? 'day' : gType
+        }
+      });
+      const { success, message, data } = res.data;
+      if (success) {
+        setMarginData(getMarginData(data || [], date));
+      } else {
+        showError(message);
+      }
+      setMarginLoading(false);
+    } catch (error) {
+      console.log(error);
+      return;
+    }
+  };
+
   const fetchData = async (date, gType, uId) => {
+    fetchMarginData(date, gType);
     setUsersLoading(true);
     setChannelLoading(true);
     setRedemptionLoading(true);
@@ -127,6 +154,24 @@ export default function Overview() {
           decimal={3}
         />
       </Grid>
+      <Grid item xs={12}>
+        <ApexCharts
+          id="upstream_cost"
+          isLoading={marginLoading}
+          chartDatas={marginData?.costs || {}}
+          title={t('analytics_index.upstreamCostStatistics')}
+          decimal={3}
+        />
+      </Grid>
+      <Grid item xs={12}>
+        <ApexCharts
+          id="margin"
+          isLoading={marginLoading}
+          chartDatas={marginData?.margin || {}}
+          title={t('analytics_index.marginStatistics')}
+          decimal={3}
+        />
+      </Grid>
       <Grid item xs={12}>
         <ApexCharts
           id="token"
@@ -279,6 +324,51 @@ function getBarChartOptions(data, dateRange) {
   return channelData;
 }
 
+function getMarginData(data, dateRange) {
+  const dates = getDates(dateRange.start, dateRange.end);
+  const groups = new Map();
+  const summary = [
+    { name: '收入', data: new Array(dates.length).fill(0) },
+    { name: '成本', data: new Array(dates.length).fill(0) },
+    { name: '毛利', data: new Array(dates.length).fill(0) }
+  ];
+  let totalRevenue = 0;
+  let totalCost = 0;
+
+  for (const item of data) {
+    const index = dates.indexOf(item.date);
+    if (index === -1) continue;
+
+    const name = item.name || item.date;
+    if (!groups.has(name)) {
+      groups.set(name, { name, data: new Array(dates.length).fill(0) });
+    }
+    groups.get(name).data[index] = calculateQuota(item.cost, 3);
+
+    summary[0].data[index] += item.revenue;
+    summary[1].data[index] += item.cost;
+    summary[2].data[index] += item.margin;
+    totalRevenue += item.revenue;
+    totalCost += item.cost;
+  }
+
+  for (const series of summary) {
+    series.data = series.data.map((quota) => calculateQuota(quota, 3));
+  }
+
+  const marginRate = totalRevenue > 0 ? (((totalRevenue - totalCost) / totalRevenue) * 100).toFixed(2) : '0.00';
+
+  const costs = generateBarChartOptions(dates, Array.from(groups.values()), '美元', 3);
+  costs.options.title.text = '总成本：$' + renderChartNumber(calculateQuota(totalCost, 3), 3);
+
+  const margin = generateBarChartOptions(dates, summary, '美元', 3);
+  margin.options.chart.stacked = false;
+  margin.options.title.text =
+    '总毛利：$' + renderChartNumber(calculateQuota(totalRevenue - totalCost, 3), 3) + '（毛利率 ' + marginRate + '%）';
+
+  return { costs, margin };
+}
+
 function getRedemptionData(data, dateRange) {
   if (!data) return null;
 
@@ -0,0 +1,120 @@
+import { Link, useLocation, useNavigate } from 'react-router-dom';
+import React, { useEffect, useState } from 'react';
+import { showError } from 'utils/common';
+import { isWebAuthnSupported } from 'utils/webauthn';
+import useLogin from 'hooks/useLogin';
+
+// material-ui
+import { useTheme } from '@mui/material/styles';
+import { Button, Divider, Grid, Stack, TextField, Typography, useMediaQuery } from '@mui/material';
+
+// project imports
+import AuthWrapper from '../AuthWrapper';
+import AuthCardWrapper from '../AuthCardWrapper';
+import Logo from 'ui-component/Logo';
+import { useTranslation } from 'react-i18next';
+
+// ================================|| AUTH3 - TWO FACTOR ||================================ //
+
+const TwoFactor = () => {
+  const { t } = useTranslation();
+  const theme = useTheme();
+  const matchDownSM = useMediaQuery(theme.breakpoints.down('md'));
+  const location = useLocation();
+  const navigate = useNavigate();
+  const { twoFactorLogin, passkeyLogin } = useLogin();
+  const [code, setCode] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  const methods = location.state || {};
+
+  useEffect(() => {
+    if (!methods.require_2fa) {
+      navigate('/login');
+    }
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
+  const handleSubmit = async (event) => {
+    event.preventDefault();
+    if (!code) {
+      return;
+    }
+    setLoading(true);
+    const { success, message } = await twoFactorLogin(code);
+    setLoading(false);
+    if (!success) {
+      showError(message || t('login.twoFactorError'));
+    }
+  };
+
+  const handlePasskey = async () => {
+    setLoading(true);
+    const { success, message } = await passkeyLogin();
+    setLoading(false);
+    if (!success) {
+      showError(message || t('login.twoFactorError'));
+    }
+  };
+
+  return (
+    <AuthWrapper>
+      <Grid container direction="column" justifyContent="flex-end">
+        <Grid item xs={12}>
+          <Grid container justifyContent="center" alignItems="center" sx={{ minHeight: 'calc(100vh - 136px)' }}>
+            <Grid item sx={{ m: { xs: 1, sm: 3 }, mb: 0 }}>
+              <AuthCardWrapper>
+                <Grid container spacing={2} alignItems="center" justifyContent="center">
+                  <Grid item sx={{ mb: 3 }}>
+                    <Link to="#">
+                      <Logo />
+                    </Link>
+                  </Grid>
+                  <Grid item xs={12}>
+                    <Stack alignItems="center" justifyContent="center" spacing={1}>
+                      <Typography color={theme.palette.primary.main} gutterBottom variant={matchDownSM ? 'h3' : 'h2'}>
+                        {t('login.twoFactor')}
+                      </Typography>
+                      <Typography variant="caption" textAlign="center">
+                        {methods.totp ? t('login.twoFactorTip') : t('login.recoveryCodeTip')}
+                      </Typography>
+                    </Stack>
+                  </Grid>
+                  <Grid item xs={12}>
+                    <form noValidate onSubmit={handleSubmit}>
+                      <TextField
+                        fullWidth
+                        autoFocus
+                        label={methods.totp ? t('login.twoFactorCode') : t('login.recoveryCode')}
+                        value={code}
+                        onChange={(e) => setCode(e.target.value.trim())}
+                        inputProps={{ autoComplete: 'one-time-code' }}
+                      />
+                      <Button fullWidth size="large" type="submit" variant="contained" color="primary" disabled={loading} sx={{ mt: 2 }}>
+                        {t('common.submit')}
+                      </Button>
+                    </form>
+                  </Grid>
+                  {methods.webauthn && isWebAuthnSupported() && (
+                    <>
+                      <Grid item xs={12}>
+                        <Divider />
+                      </Grid>
+                      <Grid item xs={12}>
+                        <Button fullWidth size="large" variant="outlined" onClick={handlePasskey} disabled={loading}>
+                          {t('login.usePasskey')}
+                        </Button>
+                      </Grid>
+                    </>
+                  )}
+                </Grid>
+              </AuthCardWrapper>
+            </Grid>
+          </Grid>
+        </Grid>
+      </Grid>
+    </AuthWrapper>
+  );
+};
+
+export default TwoFactor;
@@ -145,7 +145,7 @@ export function ChannelCheck({ item, open, onClose }) {
   };
 
   const getModelStatus = (result) => {
-    const hasFailure = result.process.some((process) => process.results.some((item2) => item2.status !== 1));
+    const hasFailure = result.process.some((process) => process.results.some((item2) => item2.status === 0));
     return {
       success: !hasFailure,
       icon: hasFailure ? 'solar:danger-circle-bold' : 'solar:check-circle-bold',
@@ -292,16 +292,22 @@ export function ChannelCheck({ item, open, onClose }) {
                               variant="outlined"
                               sx={{
                                 p: 1,
-                                borderColor: item2.status === 1 ? 'success.light' : 'error.light',
+                                borderColor: item2.status === 1 ? 'success.light' : item2.status === 2 ? 'warning.light' : 'error.light',
                                 bgcolor: (theme) => (theme.palette.mode === 'dark' ? 'rgba(255, 255, 255, 0.02)' : 'background.paper')
                               }}
                             >
                               <Stack direction="row" alignItems="flex-start" spacing={1}>
                                 <Icon
-                                  icon={item2.status === 1 ? 'solar:check-circle-bold' : 'solar:close-circle-bold'}
+                                  icon={
+                                    item2.status === 1
+                                      ? 'solar:check-circle-bold'
+                                      : item2.status === 2
+                                        ? 'solar:question-circle-bold'
+                                        : 'solar:close-circle-bold'
+                                  }
                                   width={20}
                                   sx={{
-                                    color: item2.status === 1 ? 'success.main' : 'error.main',
+                                    color: item2.status === 1 ? 'success.main' : item2.status === 2 ? 'warning.main' : 'error.main',
                                     mt: 0.25
                                   }}
                                 />
@@ -69,7 +69,10 @@ const getValidationSchema = (t) =>
     }),
     model_mapping: Yup.array(),
     model_headers: Yup.array(),
-    custom_parameter: Yup.string().nullable()
+    custom_parameter: Yup.string().nullable(),
+    cost_ratio: Yup.number().min(0, t('channel_edit.costRatioErr')),
+    model_costs: Yup.string().nullable(),
+    plugin_pipeline: Yup.string().nullable()
   });
 
 const EditModal = ({ open, channelId, onCancel, onOk, groupOptions, isTag, modelOptions, prices }) => {
@@ -253,6 +256,26 @@ const EditModal = ({ open, channelId, onCancel, onOk, groupOptions, isTag, model
       values.disabled_stream = removeDuplicates(values.disabled_stream)
     }
 
+    let modelCosts
+    if (values.model_costs) {
+      try {
+        modelCosts = JSON.parse(values.model_costs)
+      } catch (error) {
+        showError('Error parsing model_costs: ' + error.message)
+        return
+      }
+    }
+
+    let pluginPipeline
+    if (values.plugin_pipeline) {
+      try {
+        pluginPipeline = JSON.parse(values.plugin_pipeline)
+      } catch (error) {
+        showError('Error parsing plugin_pipeline: ' + error.message)
+        return
+      }
+    }
+
     // 获取现有的模型 ID
     const existingModelIds = values.models.map((model) => model.id)
 
@@ -274,9 +297,9 @@ const EditModal = ({ open, channelId, onCancel, onOk, groupOptions, isTag, model
 
     try {
       if (channelId) {
-        res = await API.put(baseApiUrl, { ...values, id: parseInt(channelId), models: modelsStr })
+        res = await API.put(baseApiUrl, { ...values, id: parseInt(channelId), models: modelsStr, model_costs: modelCosts, plugin_pipeline: pluginPipeline })
       } else {
-        res = await API.post(baseApiUrl, { ...values, models: modelsStr })
+        res = await API.post(baseApiUrl, { ...values, models: modelsStr, model_costs: modelCosts, plugin_pipeline: pluginPipeline })
       }
       const { success, message } = res.data
       if (success) {
@@ -376,6 +399,10 @@ const EditModal = ({ open, channelId, onCancel, onOk, groupOptions, isTag, model
           data.custom_parameter = ''
         }
 
+        data.model_costs = data.model_costs ? JSON.stringify(data.model_costs, null, 2) : ''
+        data.plugin_pipeline = data.plugin_pipeline ? JSON.stringify(data.plugin_pipeline, null, 2) : ''
+        data.cost_ratio = data.cost_ratio ?? 1
+
         data.base_url = data.base_url ?? ''
         data.is_edit = true
         if (data.plugin === null) {
@@ -1014,6 +1041,70 @@ const EditModal = ({ open, channelId, onCancel, onOk, groupOptions, isTag, model
                     )}
                   </FormControl>
                 )}
+                {inputPrompt.cost_ratio && (
+                  <FormControl fullWidth error={Boolean(touched.cost_ratio && errors.cost_ratio)}
+                               sx={{ ...theme.typography.otherInput }}>
+                    <InputLabel htmlFor="channel-cost_ratio-label">{customizeT(inputLabel.cost_ratio)}</InputLabel>
+                    <OutlinedInput
+                      id="channel-cost_ratio-label"
+                      label={customizeT(inputLabel.cost_ratio)}
+                      type="number"
+                      disabled={hasTag}
+                      value={values.cost_ratio}
+                      name="cost_ratio"
+                      onBlur={handleBlur}
+                      onChange={(e) => setFieldValue('cost_ratio', e.target.value === '' ? '' : Number(e.target.value))}
+                      inputProps={{ min: 0, step: 0.01 }}
+                      aria-describedby="helper-text-channel-cost_ratio-label"
+                    />
+                    {touched.cost_ratio && errors.cost_ratio ? (
+                      <FormHelperText error id="helper-tex-channel-cost_ratio-label">
+                        {errors.cost_ratio}
+                      </FormHelperText>
+                    ) : (
+                      <FormHelperText
+                        id="helper-tex-channel-cost_ratio-label"> {customizeT(inputPrompt.cost_ratio)} </FormHelperText>
+                    )}
+                  </FormControl>
+                )}
+                {inputPrompt.model_costs && (
+                  <FormControl fullWidth error={Boolean(touched.model_costs && errors.model_costs)}
+                               sx={{ ...theme.typography.otherInput }}>
+                    <TextField
+                      id="channel-model_costs-label"
+                      label={customizeT(inputLabel.model_costs)}
+                      multiline
+                      minRows={values.model_costs ? 4 : 1}
+                      disabled={hasTag}
+                      value={values.model_costs}
+                      name="model_costs"
+                      onBlur={handleBlur}
+                      onChange={handleChange}
+                      aria-describedby="helper-text-channel-model_costs-label"
+                    />
+                    <FormHelperText
+                      id="helper-tex-channel-model_costs-label"> {customizeT(inputPrompt.model_costs)} </FormHelperText>
+                  </FormControl>
+                )}
+                {inputPrompt.plugin_pipeline && (
+                  <FormControl fullWidth error={Boolean(touched.plugin_pipeline && errors.plugin_pipeline)}
+                               sx={{ ...theme.typography.otherInput }}>
+                    <TextField
+                      id="channel-plugin_pipeline-label"
+                      label={customizeT(inputLabel.plugin_pipeline)}
+                      multiline
+                      minRows={values.plugin_pipeline ? 4 : 1}
+                      disabled={hasTag}
+                      value={values.plugin_pipeline}
+                      name="plugin_pipeline"
+                      onBlur={handleBlur}
+                      onChange={handleChange}
+                      aria-describedby="helper-text-channel-plugin_pipeline-label"
+                    />
+                    <FormHelperText
+                      id="helper-tex-channel-plugin_pipeline-label"> {customizeT(inputPrompt.plugin_pipeline)} </FormHelperText>
+                  </FormControl>
+                )}
                 {inputPrompt.only_chat && (
                   <FormControl fullWidth>
                     <FormControlLabel
@@ -1088,6 +1179,23 @@ const EditModal = ({ open, channelId, onCancel, onOk, groupOptions, isTag, model
                     <FormHelperText id="helper-tex-compatible_response-label">{customizeT(inputPrompt.compatible_response)}</FormHelperText>
                   </FormControl>
                 )}
+                {inputPrompt.tool_emulation && (
+                  <FormControl fullWidth>
+                    <FormControlLabel
+                      control={
+                        <Switch
+                          disabled={hasTag}
+                          checked={Boolean(values.tool_emulation)}
+                          onChange={(event) => {
+                            setFieldValue('tool_emulation', event.target.checked)
+                          }}
+                        />
+                      }
+                      label={customizeT(inputLabel.tool_emulation)}
+                    />
+                    <FormHelperText id="helper-tex-tool_emulation-label">{customizeT(inputPrompt.tool_emulation)}</FormHelperText>
+                  </FormControl>
+                )}
                 {pluginList[values.type] &&
                   Object.keys(pluginList[values.type]).map((pluginId) => {
                     const plugin = pluginList[values.type][pluginId]
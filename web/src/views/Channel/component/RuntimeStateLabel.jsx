@@ -0,0 +1,72 @@
+import PropTypes from 'prop-types';
+import { useState } from 'react';
+import Stack from '@mui/material/Stack';
+import Tooltip from '@mui/material/Tooltip';
+import Label from 'ui-component/Label';
+import { API } from 'utils/api';
+import { showError, showSuccess, timestamp2string } from 'utils/common';
+import { useTranslation } from 'react-i18next';
+
+const RuntimeStateLabel = ({ channelId, health, circuitBreakers }) => {
+  const { t } = useTranslation();
+  const [breakers, setBreakers] = useState(circuitBreakers || []);
+
+  const handleReset = async () => {
+    try {
+      const res = await API.delete(`/api/channel/${channelId}/circuit_breaker`);
+      const { success, message } = res.data;
+      if (success) {
+        setBreakers([]);
+        showSuccess(t('channel_row.circuitResetSuccess'));
+      } else {
+        showError(message);
+      }
+    } catch (error) {
+      return;
+    }
+  };
+
+  if (!health && breakers.length === 0) {
+    return null;
+  }
+
+  const openCount = breakers.filter((breaker) => breaker.state === 'open').length;
+  const breakerTitle = (
+    <>
+      {t('channel_row.circuitTip')}
+      {breakers.map((breaker) => (
+        <div key={breaker.model}>
+          {breaker.model}: {breaker.state === 'open' ? t('channel_row.circuitOpen') : t('channel_row.circuitHalfOpen')}
+          {breaker.state === 'open' && ` (${t('channel_row.circuitUntil')}${timestamp2string(breaker.open_until)})`}
+        </div>
+      ))}
+    </>
+  );
+
+  return (
+    <Stack direction="column" alignItems="center" spacing={0.5}>
+      {health && (
+        <Tooltip title={health.message || t('channel_row.healthQueue', { queue: health.queue_depth, running: health.running })} placement="top">
+          <Label color={health.healthy ? 'success' : 'error'} variant="soft">
+            {health.healthy ? t('channel_row.healthy') : t('channel_row.unhealthy')}
+          </Label>
+        </Tooltip>
+      )}
+      {breakers.length > 0 && (
+        <Tooltip title={breakerTitle} placement="top" onClick={handleReset}>
+          <Label color={openCount > 0 ? 'error' : 'warning'} variant="soft" sx={{ cursor: 'pointer' }}>
+            {openCount > 0 ? t('channel_row.circuitOpen') : t('channel_row.circuitHalfOpen')} {breakers.length}
+          </Label>
+        </Tooltip>
+      )}
+    </Stack>
+  );
+};
+
+RuntimeStateLabel.propTypes = {
+  channelId: PropTypes.number,
+  health: PropTypes.object,
+  circuitBreakers: PropTypes.array
+};
+
+export default RuntimeStateLabel;
@@ -45,6 +45,7 @@ import {
 import Label from 'ui-component/Label';
 // import TableSwitch from 'ui-component/Switch';
 import ResponseTimeLabel from './ResponseTimeLabel';
+import RuntimeStateLabel from './RuntimeStateLabel';
 import GroupLabel from './GroupLabel';
 
 import { alpha, styled } from '@mui/material/styles';
@@ -415,6 +416,12 @@ export default function ChannelTableRow({ item, manageChannel, onRefresh, groupO
               >
                 {statusInfo(t, statusSwitch)}
               </Typography>
+              <RuntimeStateLabel
+                key={`${item.id}-${item.circuit_breakers?.length || 0}`}
+                channelId={item.id}
+                health={item.health}
+                circuitBreakers={item.circuit_breakers}
+              />
             </Stack>
           )}
           {item.tag && (
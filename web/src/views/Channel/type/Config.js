@@ -17,7 +17,11 @@ const defaultConfig = {
     only_chat: false,
     pre_cost: 1,
     disabled_stream: [],
-    compatible_response: false
+    compatible_response: false,
+    tool_emulation: false,
+    cost_ratio: 1,
+    model_costs: '',
+    plugin_pipeline: ''
   },
   inputLabel: {
     name: '渠道名称',
@@ -37,7 +41,11 @@ const defaultConfig = {
     provider_models_list: '',
     pre_cost: '预计费选项',
     disabled_stream: '禁用流式的模型',
-    compatible_response: '兼容Response API'
+    compatible_response: '兼容Response API',
+    tool_emulation: '工具调用模拟',
+    cost_ratio: '成本倍率',
+    model_costs: '模型成本',
+    plugin_pipeline: '请求处理插件'
   },
   prompt: {
     type: '请选择渠道类型',
@@ -61,7 +69,13 @@ const defaultConfig = {
     pre_cost:
       '这里选择预计费选项，用于预估费用，如果你觉得计算图片占用太多资源，可以选择关闭图片计费。但是请注意：有些渠道在stream下是不会返回tokens的，这会导致输入tokens计算错误。',
     disabled_stream: '这里填写禁用流式的模型，注意：如果填写了禁用流式的模型，那么这些模型在流式请求时会跳过该渠道',
-    compatible_response: '兼容Response API'
+    compatible_response: '兼容Response API',
+    tool_emulation: '开启后，对于不支持原生函数调用的模型，会把工具定义写入提示词，并将模型输出的调用解析为 tool_calls，支持流式以及 OpenAI、Claude、Responses 格式',
+    cost_ratio: '上游实际成本相对于模型售价的倍率，用于统计毛利，例如：官方Key为1，折扣渠道为0.3，免费渠道为0',
+    model_costs:
+      '按模型单独设置上游成本，优先于成本倍率，价格单位与模型价格相同，例如：{"gpt-4o": {"ratio": 0.5}, "gpt-4o-mini": {"input": 0.075, "output": 0.3}}',
+    plugin_pipeline:
+      '按顺序执行的请求处理插件，在分组和令牌的插件之后执行，同名插件使用渠道的配置。可用插件：params、system_prompt、regex_replace、strip_think、json_schema，例如：[{"name": "strip_think"}, {"name": "params", "params": {"strip": ["presence_penalty"], "force": {"temperature": 0.7}}}]'
   },
   modelGroup: 'OpenAI'
 }
@@ -482,6 +496,18 @@ const typeConfig = {
     },
     modelGroup: 'Jina'
   },
+  48: {
+    inputLabel: {
+      base_url: '服务地址',
+      other: '接口格式'
+    },
+    prompt: {
+      base_url: '请输入 TEI、Infinity、vLLM 等服务的地址，例如：http://127.0.0.1:8080',
+      key: '服务未开启鉴权时可随意填写',
+      other: '留空使用 Jina/Cohere 兼容的 /v1/rerank 接口，填写 tei 使用 TEI 的 /rerank 接口'
+    },
+    modelGroup: 'Rerank'
+  },
   49: {
     input: {
       models: ['gpt-4o', 'gpt-4o-mini', 'text-embedding-3-large', 'text-embedding-3-small', 'Cohere-command-r-plus', 'Cohere-command-r'],
@@ -547,6 +573,76 @@ const typeConfig = {
     inputLabel: {
       provider_models_list: '从OR获取模型列表'
     }
+  },
+  57: {
+    input: {
+      models: ['eleven_multilingual_v2', 'eleven_turbo_v2_5', 'eleven_flash_v2_5', 'scribe_v1']
+    },
+    prompt: {
+      test_model: '',
+      key: '请输入 ElevenLabs 的 API Key'
+    },
+    modelGroup: 'ElevenLabs'
+  },
+  58: {
+    inputLabel: {
+      base_url: '服务地址',
+      provider_models_list: '获取已加载的模型'
+    },
+    prompt: {
+      base_url: '请输入 vLLM 服务的地址，例如：http://127.0.0.1:8000，guided_json、guided_regex 等扩展参数会原样透传',
+      key: '服务未开启鉴权时可随意填写',
+      provider_models_list: '从服务获取当前已加载的模型'
+    },
+    modelGroup: 'vLLM'
+  },
+  59: {
+    inputLabel: {
+      base_url: '服务地址',
+      provider_models_list: '获取已加载的模型'
+    },
+    prompt: {
+      base_url: '请输入 SGLang 服务的地址，例如：http://127.0.0.1:30000，regex、ebnf、json_schema 等扩展参数会原样透传',
+      key: '服务未开启鉴权时可随意填写',
+      provider_models_list: '从服务获取当前已加载的模型'
+    },
+    modelGroup: 'SGLang'
+  },
+  60: {
+    inputLabel: {
+      base_url: '服务地址',
+      provider_models_list: '获取已加载的模型'
+    },
+    prompt: {
+      base_url: '请输入 llama.cpp 服务的地址，例如：http://127.0.0.1:8080，grammar、json_schema 等扩展参数会原样透传，排队检测需要启动时开启 --metrics',
+      key: '服务未开启鉴权时可随意填写',
+      provider_models_list: '从服务获取当前已加载的模型'
+    },
+    modelGroup: 'llama.cpp'
+  },
+  61: {
+    inputLabel: {
+      base_url: '服务地址',
+      provider_models_list: '获取已加载的模型'
+    },
+    prompt: {
+      base_url: '请输入 LM Studio 服务的地址，例如：http://127.0.0.1:1234，仅获取已加载的模型',
+      key: '服务未开启鉴权时可随意填写',
+      provider_models_list: '从服务获取当前已加载的模型'
+    },
+    modelGroup: 'LM Studio'
+  },
+  62: {
+    inputLabel: {
+      base_url: '服务地址',
+      provider_models_list: '获取已加载的模型'
+    },
+    prompt: {
+      base_url: '请输入 TGI 服务的地址，例如：http://127.0.0.1:8080，grammar、top_n_tokens 等扩展参数会原样透传',
+      key: '服务未开启鉴权时可随意填写',
+      provider_models_list: '从服务获取当前已加载的模型'
+    },
+    modelGroup: 'TGI'
   }
 }
 
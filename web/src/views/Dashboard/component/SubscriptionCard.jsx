@@ -0,0 +1,180 @@
+import { useCallback, useEffect, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import {
+  Button,
+  Checkbox,
+  Chip,
+  Divider,
+  FormControl,
+  FormControlLabel,
+  InputLabel,
+  MenuItem,
+  Select,
+  Stack,
+  Typography
+} from '@mui/material';
+import SubCard from 'ui-component/cards/SubCard';
+import PayDialog from 'views/Topup/component/PayDialog';
+import { API } from 'utils/api';
+import { renderQuota, showError, showSuccess, timestamp2string } from 'utils/common';
+
+const statusColor = {
+  active: 'success',
+  canceled: 'warning',
+  expired: 'default'
+};
+
+const SubscriptionCard = () => {
+  const { t } = useTranslation();
+  const [subscription, setSubscription] = useState(null);
+  const [plans, setPlans] = useState([]);
+  const [payments, setPayments] = useState([]);
+  const [selectedPlan, setSelectedPlan] = useState('');
+  const [selectedPayment, setSelectedPayment] = useState('');
+  const [autoRenew, setAutoRenew] = useState(false);
+  const [open, setOpen] = useState(false);
+
+  const loadSubscription = useCallback(async () => {
+    try {
+      const res = await API.get('/api/user/subscription');
+      const { success, message, data } = res.data;
+      if (success) {
+        setSubscription(data);
+      } else {
+        showError(message);
+      }
+    } catch (error) {
+      return;
+    }
+  }, []);
+
+  const loadPlans = useCallback(async () => {
+    try {
+      const [planRes, paymentRes] = await Promise.all([API.get('/api/user/subscription/plans'), API.get('/api/user/payment')]);
+      if (planRes.data.success && planRes.data.data) {
+        setPlans(planRes.data.data);
+        if (planRes.data.data.length > 0) {
+          setSelectedPlan(planRes.data.data[0].id);
+        }
+      }
+      if (paymentRes.data.success && paymentRes.data.data) {
+        const data = paymentRes.data.data.sort((a, b) => b.sort - a.sort);
+        setPayments(data);
+        if (data.length > 0) {
+          setSelectedPayment(data[0].uuid);
+        }
+      }
+    } catch (error) {
+      return;
+    }
+  }, []);
+
+  useEffect(() => {
+    loadSubscription().then();
+    loadPlans().then();
+  }, [loadSubscription, loadPlans]);
+
+  const cancelAutoRenew = async () => {
+    try {
+      const res = await API.post('/api/user/subscription/cancel');
+      const { success, message } = res.data;
+      if (success) {
+        showSuccess(t('subscriptionCard.cancelSuccess'));
+        loadSubscription().then();
+      } else {
+        showError(message);
+      }
+    } catch (error) {
+      return;
+    }
+  };
+
+  const onClosePayDialog = () => {
+    setOpen(false);
+    loadSubscription().then();
+  };
+
+  // 没有订阅也没有可购买的套餐时不显示
+  if (!subscription && plans.length === 0) {
+    return null;
+  }
+
+  const plan = subscription?.plan;
+  const currentPlan = plans.find((item) => item.id === selectedPlan);
+
+  return (
+    <SubCard title={t('subscriptionCard.title')}>
+      {subscription && plan && (
+        <Stack spacing={1}>
+          <Stack direction="row" alignItems="center" justifyContent="space-between">
+            <Typography variant="h4">{plan.name}</Typography>
+            <Chip size="small" color={statusColor[subscription.status]} label={t(`subscriptionCard.status.${subscription.status}`)} />
+          </Stack>
+          <Typography variant="body2">
+            {t('subscriptionCard.periodEnd')}: {timestamp2string(subscription.period_end)}
+          </Typography>
+          {plan.quota > 0 && (
+            <Typography variant="body2">
+              {t('subscriptionCard.quotaPerPeriod')}: {renderQuota(plan.quota)}
+            </Typography>
+          )}
+          {plan.unlimited && (
+            <Typography variant="body2">
+              {t('subscriptionCard.unlimitedModels')}: {plan.models || t('subscriptionCard.allModels')}
+              {plan.rate_limit > 0 && ` (${plan.rate_limit} RPM)`}
+            </Typography>
+          )}
+          <Typography variant="body2">
+            {t('subscriptionCard.autoRenew')}: {subscription.auto_renew ? t('subscriptionCard.on') : t('subscriptionCard.off')}
+          </Typography>
+          {subscription.auto_renew && (
+            <Button variant="outlined" color="warning" size="small" onClick={cancelAutoRenew}>
+              {t('subscriptionCard.cancelAutoRenew')}
+            </Button>
+          )}
+        </Stack>
+      )}
+
+      {!subscription?.auto_renew && plans.length > 0 && payments.length > 0 && (
+        <Stack spacing={2} mt={subscription ? 2 : 0}>
+          {subscription && <Divider />}
+          <FormControl fullWidth size="small">
+            <InputLabel>{t('subscriptionCard.plan')}</InputLabel>
+            <Select label={t('subscriptionCard.plan')} value={selectedPlan} onChange={(e) => setSelectedPlan(e.target.value)}>
+              {plans.map((item) => (
+                <MenuItem key={item.id} value={item.id}>
+                  {item.name} - ${item.price} / {item.period_days} {t('subscriptionCard.days')}
+                </MenuItem>
+              ))}
+            </Select>
+          </FormControl>
+          {currentPlan?.description && (
+            <Typography variant="body2" color="text.secondary">
+              {currentPlan.description}
+            </Typography>
+          )}
+          <FormControl fullWidth size="small">
+            <InputLabel>{t('subscriptionCard.payment')}</InputLabel>
+            <Select label={t('subscriptionCard.payment')} value={selectedPayment} onChange={(e) => setSelectedPayment(e.target.value)}>
+              {payments.map((item) => (
+                <MenuItem key={item.uuid} value={item.uuid}>
+                  {item.name}
+                </MenuItem>
+              ))}
+            </Select>
+          </FormControl>
+          <FormControlLabel
+            control={<Checkbox checked={autoRenew} onChange={(e) => setAutoRenew(e.target.checked)} />}
+            label={t('subscriptionCard.enableAutoRenew')}
+          />
+          <Button variant="contained" disabled={!selectedPlan || !selectedPayment} onClick={() => setOpen(true)}>
+            {subscription ? t('subscriptionCard.renew') : t('subscriptionCard.subscribe')}
+          </Button>
+          <PayDialog open={open} onClose={onClosePayDialog} uuid={selectedPayment} planId={selectedPlan} autoRenew={autoRenew} />
+        </Stack>
+      )}
+    </SubCard>
+  );
+};
+
+export default SubscriptionCard;
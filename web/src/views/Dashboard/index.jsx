@@ -14,6 +14,7 @@ import QuotaLogWeek from './component/QuotaLogWeek';
 import QuickStartCard from './component/QuickStartCard';
 import RPM from './component/RPM';
 import StatusPanel from './component/StatusPanel';
+import SubscriptionCard from './component/SubscriptionCard';
 import { useSelector } from 'react-redux';
 
 // TabPanel component for tab content
@@ -141,6 +142,10 @@ const Dashboard = () => {
           <Grid item lg={4} xs={12}>
             {/* 用户信息 */}
             <ModelUsagePieChart isLoading={isLoading} data={modelUsageData} />
+            {/* 订阅套餐 */}
+            <Box mt={2}>
+              <SubscriptionCard />
+            </Box>
             <Box mt={2}>
               <QuickStartCard />
             </Box>
@@ -110,6 +110,12 @@ export default function ModelPrice() {
             if (type === 'tokens') {
               nowUnit = `/ 1${unit}`;
             }
+            if (type === 'seconds') {
+              nowUnit = `/ 1${unit} s`;
+            }
+            if (type === 'characters') {
+              nowUnit = `/ 1${unit} chars`;
+            }
             return ValueFormatter(value, true, isM) + nowUnit;
           }
           return value;
@@ -123,7 +129,17 @@ export default function ModelPrice() {
           type: model.price.type,
           input: formatPrice(price.input, model.price.type),
           output: formatPrice(price.output, model.price.type),
-          extraRatios: model.price?.extra_ratios
+          extraRatios: model.price?.extra_ratios,
+          // 阶梯价格按当前分组倍率换算
+          tiers: model.groups.includes(selectedGroup)
+            ? (model.price?.tiers || []).map((tier) => ({
+                threshold: tier.threshold,
+                input: formatPrice(group.ratio * tier.input, model.price.type),
+                output: formatPrice(group.ratio * tier.output, model.price.type)
+              }))
+            : [],
+          timeWindows: model.price?.time_windows || [],
+          priceOverride: Boolean(model.price_override)
         };
       });
 
@@ -714,7 +730,7 @@ export default function ModelPrice() {
                         {row.output}
                       </Label>
                     </TableCell>
-                    <TableCell sx={{ py: 1.5 }}>{getOther(t, row.extraRatios)}</TableCell>
+                    <TableCell sx={{ py: 1.5 }}>{getOther(t, row.extraRatios, row.tiers, row.timeWindows, row.priceOverride)}</TableCell>
                   </TableRow>
                 ))
               ) : (
@@ -737,12 +753,56 @@ export default function ModelPrice() {
   );
 }
 
-function getOther(t, extraRatios) {
-  if (!extraRatios) return '';
+function getOther(t, extraRatios, tiers = [], timeWindows = [], priceOverride = false) {
+  if (!extraRatios && tiers.length === 0 && timeWindows.length === 0 && !priceOverride) return '';
 
   return (
     <Stack direction="column" spacing={0.5}>
-      {Object.entries(extraRatios).map(([key, value]) => (
+      {priceOverride && (
+        <Label
+          color="secondary"
+          variant="filled"
+          sx={{
+            borderRadius: '4px',
+            fontSize: '0.75rem',
+            py: 0.25,
+            px: 0.75
+          }}
+        >
+          {t('modelpricePage.priceOverride')}
+        </Label>
+      )}
+      {tiers.map((tier) => (
+        <Label
+          key={`tier-${tier.threshold}`}
+          color="warning"
+          variant="outlined"
+          sx={{
+            borderRadius: '4px',
+            fontSize: '0.75rem',
+            py: 0.25,
+            px: 0.75
+          }}
+        >
+          {t('modelpricePage.tierLabel', { threshold: tier.threshold })}: {tier.input} / {tier.output}
+        </Label>
+      ))}
+      {timeWindows.map((window) => (
+        <Label
+          key={`window-${window.start}-${window.end}`}
+          color="success"
+          variant="outlined"
+          sx={{
+            borderRadius: '4px',
+            fontSize: '0.75rem',
+            py: 0.25,
+            px: 0.75
+          }}
+        >
+          {window.start}-{window.end} {window.timezone || ''} x{window.ratio}
+        </Label>
+      ))}
+      {Object.entries(extraRatios || {}).map(([key, value]) => (
         <Label
           key={key}
           color="primary"
@@ -36,6 +36,7 @@ import { useTranslation } from 'react-i18next';
 import ToggleButtonGroup from 'ui-component/ToggleButton';
 import Decimal from 'decimal.js';
 import { ExtraRatiosSelector } from './ExtraRatiosSelector';
+import { PriceRulesEditor } from './PriceRulesEditor';
 
 const icon = <CheckBoxOutlineBlankIcon fontSize="small" />;
 const checkedIcon = <CheckBoxIcon fontSize="small" />;
@@ -48,7 +49,7 @@ const validateSingleMode = (t, values, rows) => {
     return t('pricing_edit.requiredModelName');
   }
 
-  if (values.type !== 'tokens' && values.type !== 'times') {
+  if (!priceType.some((item) => item.value === values.type)) {
     return t('pricing_edit.typeCheck');
   }
 
@@ -74,7 +75,7 @@ const validateSingleMode = (t, values, rows) => {
 const getValidationSchema = (t) =>
   Yup.object().shape({
     is_edit: Yup.boolean(),
-    type: Yup.string().oneOf(['tokens', 'times'], t('pricing_edit.typeErr')).required(t('pricing_edit.requiredType')),
+    type: Yup.string().oneOf(priceType.map((item) => item.value), t('pricing_edit.typeErr')).required(t('pricing_edit.requiredType')),
     channel_type: Yup.number().min(1, t('pricing_edit.channelTypeErr')).required(t('pricing_edit.requiredChannelType')),
     input: Yup.number().required(t('pricing_edit.requiredInput')),
     output: Yup.number().required(t('pricing_edit.requiredOutput')),
@@ -250,7 +251,9 @@ const EditModal = ({
           input: calculateRate(values.input),
           output: calculateRate(values.output),
           locked: values.locked,
-          extra_ratios: values.extra_ratios
+          extra_ratios: values.extra_ratios,
+          tiers: values.tiers,
+          time_windows: values.time_windows
         }
       });
       const { success, message } = res.data;
@@ -577,6 +580,28 @@ const EditModal = ({
     );
   };
 
+  // 渲染阶梯价格和分时段价格
+  const renderPriceRules = (formProps) => {
+    if (singleMode) {
+      return (
+        <PriceRulesEditor
+          tiers={inputs.tiers}
+          timeWindows={inputs.time_windows}
+          onChange={(name, value) => setInputs((prev) => ({ ...prev, [name]: value }))}
+        />
+      );
+    }
+
+    const { setFieldValue, values = {} } = formProps || {};
+    return (
+      <PriceRulesEditor
+        tiers={values.tiers}
+        timeWindows={values.time_windows}
+        onChange={(name, value) => setFieldValue(name, value)}
+      />
+    );
+  };
+
   // 渲染模型选择器 (多模式特有)
   const renderModelSelector = (formProps) => {
     if (!formProps) return null;
@@ -694,6 +719,7 @@ const EditModal = ({
             <Alert severity="warning">{t('pricing_edit.lockedTip')}</Alert>
 
             {renderExtraRatioSelector()}
+            {renderPriceRules()}
 
             {errors.general && (
               <Typography color="error" variant="body2">
@@ -717,6 +743,7 @@ const EditModal = ({
                 {renderLockedToggle(formProps)}
                 <Alert severity="warning">{t('pricing_edit.lockedTip')}</Alert>
                 {renderExtraRatioSelector(formProps)}
+                {renderPriceRules(formProps)}
                 {renderActions(formProps)}
               </form>
             )}
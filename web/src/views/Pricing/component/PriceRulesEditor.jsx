@@ -0,0 +1,88 @@
+import PropTypes from 'prop-types';
+import { useEffect, useState } from 'react';
+import { Stack, TextField, Typography } from '@mui/material';
+import { useTranslation } from 'react-i18next';
+
+const tiersExample = '[{"threshold": 200000, "input": 1.25, "output": 7.5}]';
+const timeWindowsExample = '[{"start": "16:30", "end": "00:30", "timezone": "UTC", "ratio": 0.5}]';
+
+const toText = (value) => (value && value.length > 0 ? JSON.stringify(value, null, 2) : '');
+
+const sameRules = (text, value) => {
+  try {
+    const parsed = text.trim() === '' ? [] : JSON.parse(text);
+    return JSON.stringify(parsed) === JSON.stringify(value || []);
+  } catch (e) {
+    return false;
+  }
+};
+
+// 阶梯价格和分时段价格，使用 JSON 编辑，空内容表示不启用
+export const PriceRulesEditor = ({ tiers, timeWindows, onChange }) => {
+  const { t } = useTranslation();
+  const [text, setText] = useState({ tiers: toText(tiers), time_windows: toText(timeWindows) });
+  const [errors, setErrors] = useState({});
+
+  // 外部数据变化时（切换编辑对象）同步文本，自己输入产生的变化不覆盖
+  useEffect(() => {
+    setText((prev) => ({
+      tiers: sameRules(prev.tiers, tiers) ? prev.tiers : toText(tiers),
+      time_windows: sameRules(prev.time_windows, timeWindows) ? prev.time_windows : toText(timeWindows)
+    }));
+  }, [tiers, timeWindows]);
+
+  const handleChange = (name, value) => {
+    setText((prev) => ({ ...prev, [name]: value }));
+
+    if (value.trim() === '') {
+      setErrors((prev) => ({ ...prev, [name]: null }));
+      onChange(name, undefined);
+      return;
+    }
+
+    try {
+      const parsed = JSON.parse(value);
+      if (!Array.isArray(parsed)) {
+        throw new Error();
+      }
+      setErrors((prev) => ({ ...prev, [name]: null }));
+      onChange(name, parsed.length > 0 ? parsed : undefined);
+    } catch (e) {
+      setErrors((prev) => ({ ...prev, [name]: t('pricing_edit.priceRulesJsonErr') }));
+    }
+  };
+
+  return (
+    <Stack spacing={2} sx={{ mt: 2 }}>
+      <Typography variant="subtitle1">{t('pricing_edit.priceRules')}</Typography>
+      <TextField
+        label={t('pricing_edit.tiers')}
+        multiline
+        minRows={2}
+        value={text.tiers}
+        placeholder={tiersExample}
+        onChange={(e) => handleChange('tiers', e.target.value)}
+        error={!!errors.tiers}
+        helperText={errors.tiers || t('pricing_edit.tiersTip')}
+        fullWidth
+      />
+      <TextField
+        label={t('pricing_edit.timeWindows')}
+        multiline
+        minRows={2}
+        value={text.time_windows}
+        placeholder={timeWindowsExample}
+        onChange={(e) => handleChange('time_windows', e.target.value)}
+        error={!!errors.time_windows}
+        helperText={errors.time_windows || t('pricing_edit.timeWindowsTip')}
+        fullWidth
+      />
+    </Stack>
+  );
+};
+
+PriceRulesEditor.propTypes = {
+  tiers: PropTypes.array,
+  timeWindows: PropTypes.array,
+  onChange: PropTypes.func
+};
@@ -1,6 +1,8 @@
 export const priceType = [
   { value: 'tokens', label: '按Token收费' },
-  { value: 'times', label: '按次收费' }
+  { value: 'times', label: '按次收费' },
+  { value: 'seconds', label: '按音频秒数收费' },
+  { value: 'characters', label: '按字符收费' }
 ];
 
 export function ValueFormatter(value) {
@@ -98,9 +98,10 @@ export default function Multiple({ prices, reloadData, ownedby, noPriceModels })
 
   useEffect(() => {
     const grouped = prices.reduce((acc, item, index) => {
-      // 需要保证 extra_ratios 和 locked 字段也相同才能合并
+      // 需要保证 extra_ratios、阶梯/分时段价格和 locked 字段也相同才能合并
       const extraRatiosStr = item.extra_ratios ? JSON.stringify(item.extra_ratios) : '';
-      const key = `${item.type}-${item.channel_type}-${item.input}-${item.output}-${extraRatiosStr}-${item.locked}`;
+      const rulesStr = JSON.stringify([item.tiers || [], item.time_windows || []]);
+      const key = `${item.type}-${item.channel_type}-${item.input}-${item.output}-${extraRatiosStr}-${rulesStr}-${item.locked}`;
 
       if (!acc[key]) {
         acc[key] = {
@@ -0,0 +1,136 @@
+import { useCallback, useEffect, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import { Alert, Button, Checkbox, FormControlLabel, Stack, TextField } from '@mui/material';
+import Grid from '@mui/material/Unstable_Grid2';
+import SubCard from 'ui-component/cards/SubCard';
+import { API } from 'utils/api';
+import { renderQuotaWithPrompt, showError, showSuccess } from 'utils/common';
+
+const defaultSetting = {
+  balance_threshold: 0,
+  token_quota_threshold: 0,
+  token_expire_days: 0,
+  daily_spend_threshold: 0,
+  notify_email: false,
+  notify_telegram: false,
+  webhook_url: ''
+};
+
+const AlertCard = () => {
+  const { t } = useTranslation();
+  const [setting, setSetting] = useState(defaultSetting);
+  const [loading, setLoading] = useState(false);
+
+  const loadSetting = useCallback(async () => {
+    try {
+      const res = await API.get('/api/user/alert');
+      const { success, message, data } = res.data;
+      if (success) {
+        setSetting({ ...defaultSetting, ...data });
+      } else {
+        showError(message);
+      }
+    } catch (error) {
+      return;
+    }
+  }, []);
+
+  useEffect(() => {
+    loadSetting().then();
+  }, [loadSetting]);
+
+  const handleNumberChange = (event) => {
+    const { name, value } = event.target;
+    setSetting((prev) => ({ ...prev, [name]: parseInt(value) || 0 }));
+  };
+
+  const handleChange = (event) => {
+    const { name, value, checked, type } = event.target;
+    setSetting((prev) => ({ ...prev, [name]: type === 'checkbox' ? checked : value }));
+  };
+
+  const handleSubmit = async () => {
+    setLoading(true);
+    try {
+      const res = await API.put('/api/user/alert', setting);
+      const { success, message } = res.data;
+      if (success) {
+        showSuccess(t('profilePage.alertSaved'));
+      } else {
+        showError(message);
+      }
+    } catch (error) {
+      return;
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const quotaFields = [
+    { name: 'balance_threshold', label: t('profilePage.alertBalanceThreshold') },
+    { name: 'token_quota_threshold', label: t('profilePage.alertTokenQuotaThreshold') },
+    { name: 'daily_spend_threshold', label: t('profilePage.alertDailySpendThreshold') }
+  ];
+
+  return (
+    <SubCard title={t('profilePage.alert')}>
+      <Grid container spacing={2}>
+        <Grid xs={12}>
+          <Alert severity="info">{t('profilePage.alertNotice')}</Alert>
+        </Grid>
+        {quotaFields.map((field) => (
+          <Grid xs={12} md={6} key={field.name}>
+            <TextField
+              fullWidth
+              type="number"
+              name={field.name}
+              label={field.label}
+              value={setting[field.name]}
+              onChange={handleNumberChange}
+              helperText={renderQuotaWithPrompt(setting[field.name])}
+            />
+          </Grid>
+        ))}
+        <Grid xs={12} md={6}>
+          <TextField
+            fullWidth
+            type="number"
+            name="token_expire_days"
+            label={t('profilePage.alertTokenExpireDays')}
+            value={setting.token_expire_days}
+            onChange={handleNumberChange}
+          />
+        </Grid>
+        <Grid xs={12}>
+          <Stack direction="row" spacing={2}>
+            <FormControlLabel
+              control={<Checkbox name="notify_email" checked={setting.notify_email} onChange={handleChange} />}
+              label={t('profilePage.alertNotifyEmail')}
+            />
+            <FormControlLabel
+              control={<Checkbox name="notify_telegram" checked={setting.notify_telegram} onChange={handleChange} />}
+              label={t('profilePage.alertNotifyTelegram')}
+            />
+          </Stack>
+        </Grid>
+        <Grid xs={12}>
+          <TextField
+            fullWidth
+            name="webhook_url"
+            label={t('profilePage.alertWebhookUrl')}
+            placeholder="https://"
+            value={setting.webhook_url}
+            onChange={handleChange}
+          />
+        </Grid>
+        <Grid xs={12}>
+          <Button variant="contained" onClick={handleSubmit} disabled={loading}>
+            {t('profilePage.submit')}
+          </Button>
+        </Grid>
+      </Grid>
+    </SubCard>
+  );
+};
+
+export default AlertCard;
@@ -0,0 +1,257 @@
+import { useCallback, useEffect, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import { Alert, Button, Chip, Stack, TextField, Typography } from '@mui/material';
+import Grid from '@mui/material/Unstable_Grid2';
+import { QRCode } from 'react-qrcode-logo';
+import SubCard from 'ui-component/cards/SubCard';
+import { API } from 'utils/api';
+import { copy, showError, showSuccess, timestamp2string } from 'utils/common';
+import { createPasskey, getPasskeyAssertion, isWebAuthnSupported } from 'utils/webauthn';
+
+const TwoFactorCard = () => {
+  const { t } = useTranslation();
+  const [status, setStatus] = useState({ totp_enabled: false, recovery_codes: 0, passkeys: 0 });
+  const [passkeys, setPasskeys] = useState([]);
+  const [setup, setSetup] = useState(null);
+  const [code, setCode] = useState('');
+  const [recoveryCodes, setRecoveryCodes] = useState([]);
+
+  const loadStatus = useCallback(async () => {
+    try {
+      const res = await API.get('/api/user/2fa');
+      const { success, message, data } = res.data;
+      if (success) {
+        setStatus(data.status);
+        setPasskeys(data.passkeys || []);
+      } else {
+        showError(message);
+      }
+    } catch (error) {
+      return;
+    }
+  }, []);
+
+  useEffect(() => {
+    loadStatus().then();
+  }, [loadStatus]);
+
+  const post = async (url, body) => {
+    try {
+      const res = await API.post(url, body);
+      const { success, message, data } = res.data;
+      if (!success) {
+        showError(message);
+        return null;
+      }
+      return data ?? true;
+    } catch (error) {
+      return null;
+    }
+  };
+
+  // 只绑定了通行密钥时，修改两步验证前需要先使用通行密钥验证，开启了 TOTP 时使用输入的验证码
+  const verifyPasskey = async () => {
+    if (status.totp_enabled || status.passkeys === 0) {
+      return true;
+    }
+
+    const options = await post('/api/user/2fa/passkey/verify/begin');
+    if (!options) {
+      return false;
+    }
+    const assertion = await getPasskeyAssertion(options);
+    return !!(await post('/api/user/2fa/passkey/verify/finish', assertion));
+  };
+
+  const handleSetup = async () => {
+    try {
+      if (!(await verifyPasskey())) {
+        return;
+      }
+    } catch (error) {
+      showError(error.message);
+      return;
+    }
+
+    const data = await post('/api/user/2fa/totp/setup');
+    if (data) {
+      setSetup(data);
+      setCode('');
+    }
+  };
+
+  const handleEnable = async () => {
+    const data = await post('/api/user/2fa/totp/enable', { code });
+    if (data) {
+      setSetup(null);
+      setCode('');
+      setRecoveryCodes(data);
+      showSuccess(t('profilePage.twoFactorEnabled'));
+      loadStatus();
+    }
+  };
+
+  const handleDisable = async () => {
+    const data = await post('/api/user/2fa/totp/disable', { code });
+    if (data) {
+      setCode('');
+      setRecoveryCodes([]);
+      showSuccess(t('profilePage.twoFactorDisabled'));
+      loadStatus();
+    }
+  };
+
+  const handleRegenerate = async () => {
+    const data = await post('/api/user/2fa/recovery_codes', { code });
+    if (data) {
+      setCode('');
+      setRecoveryCodes(data);
+      loadStatus();
+    }
+  };
+
+  const handleAddPasskey = async () => {
+    try {
+      if (!(await verifyPasskey())) {
+        return;
+      }
+
+      const options = await post('/api/user/2fa/passkey/begin', { code });
+      if (!options) {
+        return;
+      }
+
+      const credential = await createPasskey(options);
+      const data = await post('/api/user/2fa/passkey/finish', credential);
+      if (data) {
+        setCode('');
+        showSuccess(t('profilePage.passkeyAdded'));
+        loadStatus();
+      }
+    } catch (error) {
+      showError(error.message);
+    }
+  };
+
+  const handleDeletePasskey = async (id) => {
+    try {
+      if (!(await verifyPasskey())) {
+        return;
+      }
+
+      const res = await API.delete(`/api/user/2fa/passkey/${id}`, { data: { code } });
+      const { success, message } = res.data;
+      if (success) {
+        setCode('');
+        loadStatus();
+      } else {
+        showError(message);
+      }
+    } catch (error) {
+      return;
+    }
+  };
+
+  return (
+    <SubCard title={t('profilePage.twoFactor')}>
+      <Grid container spacing={2}>
+        <Grid xs={12}>
+          <Alert severity="info">{t('profilePage.twoFactorNotice')}</Alert>
+        </Grid>
+        {recoveryCodes.length > 0 && (
+          <Grid xs={12}>
+            <Alert severity="warning" action={<Button onClick={() => copy(recoveryCodes.join('\n'))}>{t('token_index.copy')}</Button>}>
+              {t('profilePage.recoveryCodesNotice')}
+              <Typography component="pre" sx={{ fontFamily: 'monospace', mt: 1 }}>
+                {recoveryCodes.join('\n')}
+              </Typography>
+            </Alert>
+          </Grid>
+        )}
+        <Grid xs={12}>
+          <Stack direction="row" spacing={1} alignItems="center">
+            <Typography variant="h4">TOTP</Typography>
+            <Chip
+              size="small"
+              color={status.totp_enabled ? 'success' : 'default'}
+              label={status.totp_enabled ? t('profilePage.enabled') : t('profilePage.notEnabled')}
+            />
+            {status.totp_enabled && (
+              <Typography variant="caption">{t('profilePage.recoveryCodesLeft', { count: status.recovery_codes })}</Typography>
+            )}
+          </Stack>
+        </Grid>
+        {setup && (
+          <Grid xs={12}>
+            <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2} alignItems="center">
+              <QRCode value={setup.url} size={160} />
+              <Typography variant="body2" sx={{ wordBreak: 'break-all' }}>
+                {t('profilePage.totpSetupTip')}
+                <br />
+                <b>{setup.secret}</b>
+              </Typography>
+            </Stack>
+          </Grid>
+        )}
+        {(setup || status.totp_enabled) && (
+          <Grid xs={12} md={4}>
+            <TextField
+              fullWidth
+              size="small"
+              label={status.totp_enabled ? t('profilePage.twoFactorCodeOrRecovery') : t('login.twoFactorCode')}
+              value={code}
+              onChange={(e) => setCode(e.target.value.trim())}
+            />
+          </Grid>
+        )}
+        <Grid xs={12}>
+          <Stack direction="row" spacing={1}>
+            {!status.totp_enabled && !setup && (
+              <Button variant="contained" onClick={handleSetup}>
+                {t('profilePage.enableTotp')}
+              </Button>
+            )}
+            {setup && (
+              <Button variant="contained" onClick={handleEnable} disabled={!code}>
+                {t('profilePage.verifyAndEnable')}
+              </Button>
+            )}
+            {status.totp_enabled && (
+              <>
+                <Button variant="outlined" onClick={handleRegenerate} disabled={!code}>
+                  {t('profilePage.regenerateRecoveryCodes')}
+                </Button>
+                <Button variant="outlined" color="error" onClick={handleDisable} disabled={!code}>
+                  {t('profilePage.disableTotp')}
+                </Button>
+              </>
+            )}
+          </Stack>
+        </Grid>
+        <Grid xs={12}>
+          <Typography variant="h4">{t('profilePage.passkeys')}</Typography>
+        </Grid>
+        {passkeys.map((passkey) => (
+          <Grid xs={12} key={passkey.id}>
+            <Stack direction="row" spacing={1} alignItems="center">
+              <Typography>{passkey.name}</Typography>
+              <Typography variant="caption">
+                {passkey.last_used_at ? timestamp2string(passkey.last_used_at) : timestamp2string(passkey.created_at)}
+              </Typography>
+              <Button size="small" color="error" onClick={() => handleDeletePasskey(passkey.id)} disabled={status.totp_enabled && !code}>
+                {t('common.delete')}
+              </Button>
+            </Stack>
+          </Grid>
+        ))}
+        <Grid xs={12}>
+          <Button variant="outlined" onClick={handleAddPasskey} disabled={!isWebAuthnSupported() || (status.totp_enabled && !code)}>
+            {t('profilePage.addPasskey')}
+          </Button>
+        </Grid>
+      </Grid>
+    </SubCard>
+  );
+};
+
+export default TwoFactorCard;
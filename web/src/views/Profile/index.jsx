@@ -25,6 +25,8 @@ import * as Yup from 'yup';
 import WechatModal from 'views/Authentication/AuthForms/WechatModal';
 import { useSelector } from 'react-redux';
 import EmailModal from './component/EmailModal';
+import TwoFactorCard from './component/TwoFactorCard';
+import AlertCard from './component/AlertCard';
 import Turnstile from 'react-turnstile';
 import LarkIcon from 'assets/images/icons/lark.svg';
 import { useTheme } from '@mui/material/styles';
@@ -302,6 +304,8 @@ export default function Profile() {
                 )}
               </Grid>
             </SubCard>
+            <TwoFactorCard />
+            <AlertCard />
             <SubCard title={t('profilePage.other')}>
               <Grid container spacing={2}>
                 <Grid xs={12}>
@@ -56,6 +56,10 @@ const OperationSetting = () => {
     RetryTimes: 0,
     RetryTimeOut: 0,
     RetryCooldownSeconds: 0,
+    ScriptMaxSteps: 0,
+    ScriptTimeout: 0,
+    StructuredOutputEnabled: 'true',
+    StructuredOutputMaxRetries: 0,
     MjNotifyEnabled: 'false',
     ChatImageRequestProxy: '',
     PaymentUSDRate: 0,
@@ -66,6 +70,8 @@ const OperationSetting = () => {
     ClaudeAPIEnabled: 'true',
     GeminiAPIEnabled: 'true',
     DisableChannelKeywords: '',
+    ModelRouterSetting: '',
+    ModelFallbackChains: '',
     EnableSafe: 'false',
     SafeToolName: '',
     SafeKeyWords: '',
@@ -92,6 +98,9 @@ const OperationSetting = () => {
           if (item.key === 'RechargeDiscount') {
             item.value = JSON.stringify(JSON.parse(item.value), null, 2)
           }
+          if ((item.key === 'ModelRouterSetting' || item.key === 'ModelFallbackChains') && item.value) {
+            item.value = JSON.stringify(JSON.parse(item.value), null, 2)
+          }
           if (item.key === 'SafeKeyWords' && typeof item.value === 'string' && item.value.startsWith('[')) {
             try {
               item.value = JSON.parse(item.value)
@@ -259,8 +268,20 @@ const OperationSetting = () => {
           }
           break
         case 'general':
-          if (inputs.QuotaPerUnit < 0 || inputs.RetryTimes < 0 || inputs.RetryCooldownSeconds < 0 || inputs.RetryTimeOut < 0) {
-            showError('单位额度、重试次数、冷却时间、重试超时时间不能为负数')
+          if (
+            inputs.QuotaPerUnit < 0 ||
+            inputs.RetryTimes < 0 ||
+            inputs.RetryCooldownSeconds < 0 ||
+            inputs.RetryTimeOut < 0 ||
+            inputs.ScriptMaxSteps < 0 ||
+            inputs.ScriptTimeout < 0 ||
+            inputs.StructuredOutputMaxRetries < 0
+          ) {
+            showError('单位额度、重试次数、冷却时间、重试超时时间、脚本限制不能为负数')
+            return
+          }
+          if (inputs.StructuredOutputMaxRetries > 5) {
+            showError('结构化输出重试次数不能超过 5')
             return
           }
 
@@ -282,6 +303,15 @@ const OperationSetting = () => {
           if (originInputs['RetryTimeOut'] !== inputs.RetryTimeOut) {
             await updateOption('RetryTimeOut', inputs.RetryTimeOut)
           }
+          if (originInputs['ScriptMaxSteps'] !== inputs.ScriptMaxSteps) {
+            await updateOption('ScriptMaxSteps', inputs.ScriptMaxSteps)
+          }
+          if (originInputs['ScriptTimeout'] !== inputs.ScriptTimeout) {
+            await updateOption('ScriptTimeout', inputs.ScriptTimeout)
+          }
+          if (originInputs['StructuredOutputMaxRetries'] !== inputs.StructuredOutputMaxRetries) {
+            await updateOption('StructuredOutputMaxRetries', inputs.StructuredOutputMaxRetries)
+          }
           if (originInputs['EmptyResponseBillingEnabled'] !== inputs.EmptyResponseBillingEnabled) {
             await updateOption('EmptyResponseBillingEnabled', inputs.EmptyResponseBillingEnabled)
           }
@@ -329,6 +359,24 @@ const OperationSetting = () => {
             await updateOption('DisableChannelKeywords', inputs.DisableChannelKeywords)
           }
           break
+        case 'ModelRouterSetting':
+          if (originInputs.ModelRouterSetting !== inputs.ModelRouterSetting) {
+            if (inputs.ModelRouterSetting.trim() !== '' && !verifyJSON(inputs.ModelRouterSetting)) {
+              showError('路由模型设置不是合法的 JSON 字符串')
+              return
+            }
+            await updateOption('ModelRouterSetting', inputs.ModelRouterSetting)
+          }
+          break
+        case 'ModelFallbackChains':
+          if (originInputs.ModelFallbackChains !== inputs.ModelFallbackChains) {
+            if (inputs.ModelFallbackChains.trim() !== '' && !verifyJSON(inputs.ModelFallbackChains)) {
+              showError('模型降级设置不是合法的 JSON 字符串')
+              return
+            }
+            await updateOption('ModelFallbackChains', inputs.ModelFallbackChains)
+          }
+          break
         case 'safety':
           try {
             if (originInputs.EnableSafe !== inputs.EnableSafe) {
@@ -508,6 +556,48 @@ const OperationSetting = () => {
                 disabled={loading}
               />
             </FormControl>
+            <FormControl fullWidth>
+              <InputLabel htmlFor="ScriptMaxSteps">
+                {t('setting_index.operationSettings.generalSettings.scriptMaxSteps.label')}
+              </InputLabel>
+              <OutlinedInput
+                id="ScriptMaxSteps"
+                name="ScriptMaxSteps"
+                value={inputs.ScriptMaxSteps}
+                onChange={handleInputChange}
+                label={t('setting_index.operationSettings.generalSettings.scriptMaxSteps.label')}
+                placeholder={t('setting_index.operationSettings.generalSettings.scriptMaxSteps.placeholder')}
+                disabled={loading}
+              />
+            </FormControl>
+            <FormControl fullWidth>
+              <InputLabel htmlFor="ScriptTimeout">
+                {t('setting_index.operationSettings.generalSettings.scriptTimeout.label')}
+              </InputLabel>
+              <OutlinedInput
+                id="ScriptTimeout"
+                name="ScriptTimeout"
+                value={inputs.ScriptTimeout}
+                onChange={handleInputChange}
+                label={t('setting_index.operationSettings.generalSettings.scriptTimeout.label')}
+                placeholder={t('setting_index.operationSettings.generalSettings.scriptTimeout.placeholder')}
+                disabled={loading}
+              />
+            </FormControl>
+            <FormControl fullWidth>
+              <InputLabel htmlFor="StructuredOutputMaxRetries">
+                {t('setting_index.operationSettings.generalSettings.structuredOutputMaxRetries.label')}
+              </InputLabel>
+              <OutlinedInput
+                id="StructuredOutputMaxRetries"
+                name="StructuredOutputMaxRetries"
+                value={inputs.StructuredOutputMaxRetries}
+                onChange={handleInputChange}
+                label={t('setting_index.operationSettings.generalSettings.structuredOutputMaxRetries.label')}
+                placeholder={t('setting_index.operationSettings.generalSettings.structuredOutputMaxRetries.placeholder')}
+                disabled={loading}
+              />
+            </FormControl>
           </Stack>
           <Stack
             direction={{ sm: 'column', md: 'row' }}
@@ -550,6 +640,17 @@ const OperationSetting = () => {
                 />
               }
             />
+            <FormControlLabel
+              label={t('setting_index.operationSettings.generalSettings.structuredOutput')}
+              control={
+                <Checkbox
+                  checked={dataLoaded ? inputs.StructuredOutputEnabled === 'true' : false}
+                  onChange={handleInputChange}
+                  name="StructuredOutputEnabled"
+                  disabled={!dataLoaded || loading}
+                />
+              }
+            />
             <Tooltip
               title={t('setting_index.operationSettings.generalSettings.unifiedRequestResponseModelTooltip')}
               placement="top"
@@ -1093,6 +1194,66 @@ const OperationSetting = () => {
         </Stack>
       </SubCard>
 
+      <SubCard title={t('setting_index.operationSettings.modelRouterSettings.title')}>
+        <Stack spacing={2}>
+          <Alert severity="info">{t('setting_index.operationSettings.modelRouterSettings.info')}</Alert>
+          <Stack justifyContent="flex-start" alignItems="flex-start" spacing={2}>
+            <FormControl fullWidth>
+              <TextField
+                multiline
+                maxRows={15}
+                id="ModelRouterSetting"
+                label={t('setting_index.operationSettings.modelRouterSettings.title')}
+                value={inputs.ModelRouterSetting}
+                name="ModelRouterSetting"
+                onChange={handleTextFieldChange}
+                minRows={5}
+                placeholder='{"routers": [{"name": "auto", "strategy": "cheapest", "require": {"tools": true}}], "capabilities": {"gpt-4o*": {"vision": true, "tools": true, "json_mode": true, "context_length": 128000}}}'
+                disabled={loading}
+              />
+            </FormControl>
+            <Button
+              variant="contained"
+              onClick={() => {
+                submitConfig('ModelRouterSetting').then()
+              }}
+            >
+              {t('setting_index.operationSettings.modelRouterSettings.save')}
+            </Button>
+          </Stack>
+        </Stack>
+      </SubCard>
+
+      <SubCard title={t('setting_index.operationSettings.modelFallbackSettings.title')}>
+        <Stack spacing={2}>
+          <Alert severity="info">{t('setting_index.operationSettings.modelFallbackSettings.info')}</Alert>
+          <Stack justifyContent="flex-start" alignItems="flex-start" spacing={2}>
+            <FormControl fullWidth>
+              <TextField
+                multiline
+                maxRows={15}
+                id="ModelFallbackChains"
+                label={t('setting_index.operationSettings.modelFallbackSettings.title')}
+                value={inputs.ModelFallbackChains}
+                name="ModelFallbackChains"
+                onChange={handleTextFieldChange}
+                minRows={5}
+                placeholder='{"claude-sonnet-4": ["gpt-4.1", "deepseek-chat"]}'
+                disabled={loading}
+              />
+            </FormControl>
+            <Button
+              variant="contained"
+              onClick={() => {
+                submitConfig('ModelFallbackChains').then()
+              }}
+            >
+              {t('setting_index.operationSettings.modelFallbackSettings.save')}
+            </Button>
+          </Stack>
+        </Stack>
+      </SubCard>
+
       <SubCard title={t('setting_index.operationSettings.claudeSettings.title')}>
         <Stack spacing={2}>
           <Stack justifyContent="flex-start" alignItems="flex-start" spacing={2}>
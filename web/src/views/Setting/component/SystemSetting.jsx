@@ -29,6 +29,7 @@ const SystemSetting = () => {
   const { t } = useTranslation();
   let [inputs, setInputs] = useState({
     PasswordLoginEnabled: '',
+    AdminRequireTwoFactor: '',
     PasswordRegisterEnabled: '',
     EmailVerificationEnabled: '',
     GitHubOAuthEnabled: '',
@@ -111,6 +112,7 @@ const SystemSetting = () => {
       case 'TurnstileCheckEnabled':
       case 'EmailDomainRestrictionEnabled':
       case 'RegisterEnabled':
+      case 'AdminRequireTwoFactor':
         value = inputs[key] === 'true' ? 'false' : 'true';
         break;
       default:
@@ -304,6 +306,14 @@ const SystemSetting = () => {
                 }
               />
             </Grid>
+            <Grid xs={12} md={3}>
+              <FormControlLabel
+                label={t('setting_index.systemSettings.configureLoginRegister.adminRequireTwoFactor')}
+                control={
+                  <Checkbox checked={inputs.AdminRequireTwoFactor === 'true'} onChange={handleInputChange} name="AdminRequireTwoFactor" />
+                }
+              />
+            </Grid>
             <Grid xs={12} md={3}>
               <FormControlLabel
                 label={t('setting_index.systemSettings.configureLoginRegister.passwordRegister')}
@@ -21,6 +21,7 @@ import {
   FormHelperText,
   Select,
   MenuItem,
+  TextField,
   Typography
 } from '@mui/material';
 
@@ -70,12 +71,28 @@ const EditModal = ({ open, tokenId, onCancel, onOk, userGroupOptions }) => {
   const { t } = useTranslation();
   const theme = useTheme();
   const [inputs, setInputs] = useState(originInputs);
+  const [fallbackText, setFallbackText] = useState('');
+  const [pluginsText, setPluginsText] = useState('');
 
   const submit = async (values, { setErrors, setStatus, setSubmitting }) => {
     setSubmitting(true);
 
     values.remain_quota = parseInt(values.remain_quota);
     values.setting.heartbeat.timeout_seconds = parseInt(values.setting.heartbeat.timeout_seconds);
+    try {
+      values.setting.fallback = fallbackText.trim() === '' ? undefined : JSON.parse(fallbackText);
+    } catch (error) {
+      showError(t('token_index.fallbackJsonErr'));
+      setSubmitting(false);
+      return;
+    }
+    try {
+      values.setting.plugins = pluginsText.trim() === '' ? undefined : JSON.parse(pluginsText);
+    } catch (error) {
+      showError(t('token_index.pluginsJsonErr'));
+      setSubmitting(false);
+      return;
+    }
     let res;
 
     try {
@@ -110,6 +127,8 @@ const EditModal = ({ open, tokenId, onCancel, onOk, userGroupOptions }) => {
       if (success) {
         data.is_edit = true;
         setInputs(data);
+        setFallbackText(data.setting?.fallback ? JSON.stringify(data.setting.fallback, null, 2) : '');
+        setPluginsText(data.setting?.plugins ? JSON.stringify(data.setting.plugins, null, 2) : '');
       } else {
         showError(message);
       }
@@ -123,6 +142,8 @@ const EditModal = ({ open, tokenId, onCancel, onOk, userGroupOptions }) => {
       loadToken().then();
     } else {
       setInputs(originInputs);
+      setFallbackText('');
+      setPluginsText('');
     }
     // eslint-disable-next-line react-hooks/exhaustive-deps
   }, [tokenId]);
@@ -282,6 +303,36 @@ const EditModal = ({ open, tokenId, onCancel, onOk, userGroupOptions }) => {
                 </FormControl>
               )}
 
+              <Divider sx={{ margin: '16px 0px' }} />
+              <Typography variant="h4">{t('token_index.fallback')}</Typography>
+              <Typography variant="caption">{t('token_index.fallbackTip')}</Typography>
+
+              <FormControl fullWidth>
+                <TextField
+                  multiline
+                  minRows={2}
+                  maxRows={10}
+                  value={fallbackText}
+                  placeholder='{"claude-sonnet-4": ["gpt-4.1", "deepseek-chat"]}'
+                  onChange={(e) => setFallbackText(e.target.value)}
+                />
+              </FormControl>
+
+              <Divider sx={{ margin: '16px 0px' }} />
+              <Typography variant="h4">{t('token_index.plugins')}</Typography>
+              <Typography variant="caption">{t('token_index.pluginsTip')}</Typography>
+
+              <FormControl fullWidth>
+                <TextField
+                  multiline
+                  minRows={2}
+                  maxRows={10}
+                  value={pluginsText}
+                  placeholder='[{"name": "strip_think"}]'
+                  onChange={(e) => setPluginsText(e.target.value)}
+                />
+              </FormControl>
+
               <Divider sx={{ margin: '16px 0px' }} />
 
               <FormControl fullWidth>
@@ -9,7 +9,7 @@ import { API } from 'utils/api';
 import { showError } from 'utils/common';
 import { useSelector } from 'react-redux';
 
-const PayDialog = ({ open, onClose, amount, uuid }) => {
+const PayDialog = ({ open, onClose, amount, uuid, planId, autoRenew }) => {
   const theme = useTheme();
   const siteInfo = useSelector((state) => state.siteInfo);
   const defaultLogo = theme.palette.mode === 'light' ? '/logo-loading.svg' : '/logo-loading-white.svg';
@@ -70,10 +70,12 @@ const PayDialog = ({ open, onClose, amount, uuid }) => {
     setMessage('正在拉起支付中...');
     setLoading(true);
 
-    API.post('/api/user/order', {
-      uuid: uuid,
-      amount: Number(amount)
-    }).then((response) => {
+    // 购买订阅套餐时使用套餐下单接口
+    const request = planId
+      ? API.post('/api/user/subscription', { uuid: uuid, plan_id: planId, auto_renew: !!autoRenew })
+      : API.post('/api/user/order', { uuid: uuid, amount: Number(amount) });
+
+    request.then((response) => {
       if (!response.data.success) {
         showError(response.data.message);
         setLoading(false);
@@ -100,7 +102,7 @@ const PayDialog = ({ open, onClose, amount, uuid }) => {
       }
       pollOrderStatus(response.data.data.trade_no);
     });
-  }, [open, onClose, amount, uuid, pollOrderStatus]);
+  }, [open, onClose, amount, uuid, planId, autoRenew, pollOrderStatus]);
 
   //打开支付宝
   const handleOpenAlipay = (alipayUrl) => {
@@ -165,5 +167,7 @@ PayDialog.propTypes = {
   open: PropTypes.bool,
   onClose: PropTypes.func,
   amount: PropTypes.number,
-  uuid: PropTypes.string
+  uuid: PropTypes.string,
+  planId: PropTypes.number,
+  autoRenew: PropTypes.bool
 };
@@ -15,7 +15,8 @@ import {
   OutlinedInput,
   Switch,
   FormControlLabel,
-  FormHelperText
+  FormHelperText,
+  TextField
 } from '@mui/material';
 
 import { showSuccess, showError, trims } from 'utils/common';
@@ -41,7 +42,8 @@ const originInputs = {
   api_rate: 300,
   promotion: false,
   min: 0,
-  max: 0
+  max: 0,
+  plugin_pipeline: ''
 };
 
 const EditModal = ({ open, userGroupId, onCancel, onOk }) => {
@@ -54,11 +56,23 @@ const EditModal = ({ open, userGroupId, onCancel, onOk }) => {
 
     let res;
     values = trims(values);
+
+    let pluginPipeline;
+    if (values.plugin_pipeline) {
+      try {
+        pluginPipeline = JSON.parse(values.plugin_pipeline);
+      } catch (error) {
+        showError(t('userGroup.pluginPipelineJsonErr'));
+        setSubmitting(false);
+        return;
+      }
+    }
+
     try {
       if (values.is_edit) {
-        res = await API.put(`/api/user_group/`, { ...values, id: parseInt(userGroupId) });
+        res = await API.put(`/api/user_group/`, { ...values, id: parseInt(userGroupId), plugin_pipeline: pluginPipeline });
       } else {
-        res = await API.post(`/api/user_group/`, values);
+        res = await API.post(`/api/user_group/`, { ...values, plugin_pipeline: pluginPipeline });
       }
       const { success, message } = res.data;
       if (success) {
@@ -85,6 +99,7 @@ const EditModal = ({ open, userGroupId, onCancel, onOk }) => {
       const { success, message, data } = res.data;
       if (success) {
         data.is_edit = true;
+        data.plugin_pipeline = data.plugin_pipeline ? JSON.stringify(data.plugin_pipeline, null, 2) : '';
         setInputs(data);
       } else {
         showError(message);
@@ -271,6 +286,19 @@ const EditModal = ({ open, userGroupId, onCancel, onOk }) => {
                 />
               </FormControl>
 
+              <FormControl fullWidth sx={{ ...theme.typography.otherInput }}>
+                <TextField
+                  label={t('userGroup.pluginPipeline')}
+                  multiline
+                  minRows={values.plugin_pipeline ? 4 : 1}
+                  value={values.plugin_pipeline}
+                  name="plugin_pipeline"
+                  onBlur={handleBlur}
+                  onChange={handleChange}
+                />
+                <FormHelperText>{t('userGroup.pluginPipelineTip')}</FormHelperText>
+              </FormControl>
+
               <DialogActions>
                 <Button onClick={onCancel}>{t('userPage.cancel')}</Button>
                 <Button disableElevation disabled={isSubmitting} type="submit" variant="contained" color="primary">